@@ -15,7 +15,10 @@ import (
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/database"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/logger"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/router"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/secrets"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
 	"go.uber.org/zap"
 )
 
@@ -49,20 +52,62 @@ func main() {
 		return
 	}
 
+	// Encrypt credential secrets at rest when a key is configured
+	var secretsBox *secrets.Box
+	if cfg.Secrets.EncryptionKey != "" {
+		secretsBox, err = secrets.NewBox(cfg.Secrets.EncryptionKey)
+		if err != nil {
+			log.Error("failed to create secrets box", zap.Error(err))
+			return
+		}
+		model.SetSecretsBox(secretsBox)
+	} else {
+		log.Warn("secrets.encryption_key is not set; credential secrets are stored in plaintext")
+	}
+
 	// Auto migrate database schemas
 	if migrateErr := database.AutoMigrate(db); migrateErr != nil {
 		log.Error("failed to migrate database", zap.Error(migrateErr))
 		return
 	}
 
+	// Encrypt secrets saved before encryption was enabled
+	if secretsBox != nil {
+		if encryptErr := database.EncryptSecrets(db, secretsBox); encryptErr != nil {
+			log.Error("failed to encrypt stored secrets", zap.Error(encryptErr))
+			return
+		}
+	}
+
 	// Seed default data (roles and admin user)
 	if seedErr := database.Seed(db, cfg); seedErr != nil {
 		log.Error("failed to seed database", zap.Error(seedErr))
 		return
 	}
 
+	// Load the terragrunt config template
+	terragruntTemplate, err := service.LoadTerragruntTemplate(cfg.Terragrunt.TemplateFile)
+	if err != nil {
+		log.Error("failed to load terragrunt template", zap.Error(err))
+		return
+	}
+
+	// Load the node name and config path templates
+	nodePathTemplates, err := service.ParseNodePathTemplates(cfg.Terragrunt.NodeNameTemplate, cfg.Terragrunt.ConfigPathTemplate)
+	if err != nil {
+		log.Error("failed to load node path templates", zap.Error(err))
+		return
+	}
+
+	// Load the node config commit message template
+	commitTemplate, err := service.ParseCommitMessageTemplate(cfg.Git.CommitMessageTemplate)
+	if err != nil {
+		log.Error("failed to load commit message template", zap.Error(err))
+		return
+	}
+
 	// Setup router
-	r := router.New(db, log, cfg)
+	r := router.New(db, log, cfg, terragruntTemplate, nodePathTemplates, commitTemplate)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -0,0 +1,106 @@
+// Package blobstore stores uploaded files outside the database.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when a blob does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// File permission constants for stored blobs.
+const (
+	dirPerm  = 0o750 // Directory permissions (rwxr-x---)
+	filePerm = 0o640 // File permissions (rw-r-----)
+)
+
+// Store saves and retrieves opaque blobs by reference.
+type Store interface {
+	// Put stores the content of r and returns its reference and size in bytes.
+	Put(ctx context.Context, r io.Reader) (ref string, size int64, err error)
+	Open(ctx context.Context, ref string) (io.ReadCloser, error)
+	Delete(ctx context.Context, ref string) error
+}
+
+// FileStore keeps blobs as files in a directory, named by a random UUID reference.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a file store rooted at dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, errors.New("blob directory is required")
+	}
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Put writes r to a new file. The file only becomes visible under its reference once the
+// content is fully written.
+func (s *FileStore) Put(_ context.Context, r io.Reader) (string, int64, error) {
+	ref := uuid.New().String()
+
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op once renamed
+
+	size, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), filePerm); err != nil {
+		return "", 0, fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, ref)); err != nil {
+		return "", 0, fmt.Errorf("failed to store blob: %w", err)
+	}
+	return ref, size, nil
+}
+
+// Open opens a stored blob for reading.
+func (s *FileStore) Open(_ context.Context, ref string) (io.ReadCloser, error) {
+	path, err := s.path(ref)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path) // #nosec G304 -- ref is validated as a UUID
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete removes a stored blob. Deleting a missing blob is not an error.
+func (s *FileStore) Delete(_ context.Context, ref string) error {
+	path, err := s.path(ref)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path maps a reference to its file, rejecting anything that is not a UUID.
+func (s *FileStore) path(ref string) (string, error) {
+	if _, err := uuid.Parse(ref); err != nil {
+		return "", ErrNotFound
+	}
+	return filepath.Join(s.dir, ref), nil
+}
@@ -0,0 +1,37 @@
+// Package blobstore provides file store tests.
+package blobstore
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	ref, size, err := store.Put(ctx, strings.NewReader("diagram"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), size)
+
+	r, err := store.Open(ctx, ref)
+	require.NoError(t, err)
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "diagram", string(content))
+
+	require.NoError(t, store.Delete(ctx, ref))
+	_, err = store.Open(ctx, ref)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.NoError(t, store.Delete(ctx, ref))
+
+	_, err = store.Open(ctx, "../etc/passwd")
+	require.ErrorIs(t, err, ErrNotFound)
+}
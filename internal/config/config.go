@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
 	"gopkg.in/yaml.v3"
@@ -13,11 +14,161 @@ import (
 
 // Config represents the application configuration.
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	JWT      JWTConfig      `yaml:"jwt"`
-	SSO      SSOConfig      `yaml:"sso"`
-	Admin    AdminConfig    `yaml:"admin"`
+	Server       ServerConfig       `yaml:"server"`
+	Database     DatabaseConfig     `yaml:"database"`
+	JWT          JWTConfig          `yaml:"jwt"`
+	SSO          SSOConfig          `yaml:"sso"`
+	LDAP         LDAPConfig         `yaml:"ldap"`
+	Admin        AdminConfig        `yaml:"admin"`
+	Request      RequestConfig      `yaml:"request"`
+	Resource     ResourceConfig     `yaml:"resource"`
+	Approval     ApprovalConfig     `yaml:"approval"`
+	Terragrunt   TerragruntConfig   `yaml:"terragrunt"`
+	Terraform    TerraformConfig    `yaml:"terraform"`
+	IPAM         IPAMConfig         `yaml:"ipam"`
+	Attachment   AttachmentConfig   `yaml:"attachment"`
+	Notification NotificationConfig `yaml:"notification"`
+	Git          GitConfig          `yaml:"git"`
+	Secrets      SecretsConfig      `yaml:"secrets"`
+}
+
+// SecretsConfig represents encryption of stored credential secrets.
+type SecretsConfig struct {
+	// EncryptionKey encrypts the secrets of credentials, git repositories and terraform
+	// registries before they are stored. They are stored in plaintext while it is empty,
+	// which is only allowed outside release mode.
+	EncryptionKey string `yaml:"encryption_key"`
+}
+
+// GitConfig represents git repository integration settings.
+type GitConfig struct {
+	// WebhookSecretKey encrypts the secrets that repositories' push webhooks are signed with.
+	// Webhook secrets cannot be set while it is empty.
+	WebhookSecretKey string `yaml:"webhook_secret_key"`
+	// CommitMessageTemplate is a Go text/template for the message of each node config commit.
+	// The built-in messages are used when empty.
+	CommitMessageTemplate string `yaml:"commit_message_template"`
+}
+
+// NotificationConfig represents outbound notification settings.
+type NotificationConfig struct {
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+}
+
+// WebhookConfig is an external endpoint, such as a CMDB or monitoring system, that is told
+// about platform events.
+type WebhookConfig struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Secret signs each delivery: X-VC-Signature carries "sha256=" and the hex HMAC-SHA256
+	// of the body. Deliveries are unsigned when empty.
+	Secret string `yaml:"secret"`
+	// Events lists the event types to deliver, such as "resource.provisioned". Every
+	// supported event is delivered when empty.
+	Events []string `yaml:"events"`
+}
+
+// AttachmentConfig represents resource request attachment settings.
+type AttachmentConfig struct {
+	// Dir is where uploaded files are stored.
+	Dir string `yaml:"dir"`
+	// MaxFileSize is the largest accepted upload in bytes.
+	MaxFileSize int64 `yaml:"max_file_size"`
+	// AllowedTypes lists accepted MIME types, matched against the sniffed file content.
+	AllowedTypes []string `yaml:"allowed_types"`
+}
+
+// TerragruntConfig represents generated terragrunt configuration settings.
+type TerragruntConfig struct {
+	// TemplateFile is a Go text/template used to render each node's terragrunt.hcl.
+	// The built-in template is used when empty.
+	TemplateFile string `yaml:"template_file"`
+	// NodeNameTemplate and ConfigPathTemplate are Go text/templates naming each node and its
+	// path in the storage repository. The built-in layouts are used when empty.
+	NodeNameTemplate   string `yaml:"node_name_template"`
+	ConfigPathTemplate string `yaml:"config_path_template"`
+}
+
+// TerraformConfig represents raw terraform generation settings.
+type TerraformConfig struct {
+	// SpecDefaults maps a provider to spec values used when a request omits them. They are
+	// merged over the built-in defaults field by field.
+	SpecDefaults map[string]map[string]interface{} `yaml:"spec_defaults"`
+	// SecretsInFiles writes provider passwords and tokens to the generated terraform.tfvars and
+	// terragrunt.hcl. By default they are passed as TF_VAR_ environment variables instead.
+	SecretsInFiles bool `yaml:"secrets_in_files"`
+	// WorkDir holds one work directory per resource request.
+	WorkDir string `yaml:"work_dir"`
+	// Cleanup is what is left of a work directory when a run finishes: keep-state,
+	// remove-providers or remove-all. ProviderCleanup overrides it per provider.
+	Cleanup         string            `yaml:"cleanup"`
+	ProviderCleanup map[string]string `yaml:"provider_cleanup"`
+}
+
+// RequestConfig represents resource request limits.
+type RequestConfig struct {
+	// MaxQuantity is the most nodes a single request may ask for.
+	MaxQuantity int `yaml:"max_quantity"`
+}
+
+// ResourceConfig represents resource settings.
+type ResourceConfig struct {
+	// ImmutableFields lists the JSON names of resource fields that updates may not change.
+	// Defaults to provider, environment and type when unset; an empty list allows all.
+	ImmutableFields []string `yaml:"immutable_fields"`
+}
+
+// IPAMConfig represents IP address management settings.
+type IPAMConfig struct {
+	Hostname HostnameConfig `yaml:"hostname"`
+	// UtilizationAlertPercent is the percentage of a pool's addresses in use (allocated or
+	// reserved) at which an ip_pool.utilization_high event is published. Defaults to 80 when
+	// unset; a negative value disables the alert.
+	UtilizationAlertPercent int `yaml:"utilization_alert_percent"`
+	// ProbePort is the TCP port dialed to check whether an address is in use before it is
+	// allocated from a pool that verifies addresses, when ICMP echo cannot be sent. Defaults
+	// to 22.
+	ProbePort int `yaml:"probe_port"`
+	// ProbeTimeout bounds each check, in milliseconds. Defaults to 500.
+	ProbeTimeout int `yaml:"probe_timeout_ms"`
+}
+
+// HostnameConfig controls the hostnames given to IP allocations that are made without one.
+type HostnameConfig struct {
+	// Generate enables generated hostnames of the form <prefix>-<zone code>-<index>.
+	Generate bool `yaml:"generate"`
+	// Prefix starts every generated hostname.
+	Prefix string `yaml:"prefix"`
+	// IndexDigits zero-pads the index to this many digits.
+	IndexDigits int `yaml:"index_digits"`
+}
+
+// ApprovalConfig represents resource request approval configuration.
+type ApprovalConfig struct {
+	AutoApproveRules []AutoApproveRule `yaml:"auto_approve_rules"`
+	// Environments sets whether requests in an environment need approval, keyed by
+	// environment. Environments not listed follow AutoApproveRules.
+	Environments map[string]EnvironmentApproval `yaml:"environments"`
+}
+
+// EnvironmentApproval is the approval requirement of one environment.
+type EnvironmentApproval struct {
+	// RequiresApproval false approves every request in the environment on creation; true
+	// holds every request for manual review, even when an auto-approve rule matches.
+	RequiresApproval bool `yaml:"requires_approval"`
+}
+
+// AutoApproveRule describes requests that are approved without manual review.
+// Every condition that is set must match; empty lists and zero limits are ignored.
+type AutoApproveRule struct {
+	Name           string   `yaml:"name"`
+	Environments   []string `yaml:"environments"`
+	Providers      []string `yaml:"providers"`
+	RequesterRoles []string `yaml:"requester_roles"`
+	MaxCPU         int      `yaml:"max_cpu"`
+	MaxMemory      int      `yaml:"max_memory"` // in MB
+	MaxDisk        int      `yaml:"max_disk"`   // in GB
+	MaxQuantity    int      `yaml:"max_quantity"`
 }
 
 // AdminConfig represents the default admin account configuration.
@@ -33,6 +184,10 @@ type ServerConfig struct {
 	Mode         string `yaml:"mode"` // debug, release, test
 	ReadTimeout  int    `yaml:"read_timeout"`
 	WriteTimeout int    `yaml:"write_timeout"`
+	// Maintenance starts the API in read-only maintenance mode, which admins can also toggle
+	// at runtime. Requests other than reads are refused with MaintenanceMessage.
+	Maintenance        bool   `yaml:"maintenance"`
+	MaintenanceMessage string `yaml:"maintenance_message"`
 }
 
 // DatabaseConfig represents database configuration.
@@ -45,6 +200,8 @@ type DatabaseConfig struct {
 	MaxIdleConns    int    `yaml:"max_idle_conns"`
 	MaxOpenConns    int    `yaml:"max_open_conns"`
 	ConnMaxLifetime int    `yaml:"conn_max_lifetime"` // in minutes
+	ReplicaDSN      string `yaml:"replica_dsn"`       // Optional read replica; reads opt in per query
+	QueryTimeout    int    `yaml:"query_timeout"`     // in seconds; bounds queries whose context has no deadline
 }
 
 // JWTConfig represents JWT configuration.
@@ -55,13 +212,40 @@ type JWTConfig struct {
 	Issuer          string `yaml:"issuer"`
 }
 
-// SSOConfig represents SSO configuration.
+// SSOConfig represents OpenID Connect single sign-on configuration.
 type SSOConfig struct {
 	Enabled      bool   `yaml:"enabled"`
-	ProviderURL  string `yaml:"provider_url"`
+	ProviderURL  string `yaml:"provider_url"` // OIDC issuer; discovery is read from <issuer>/.well-known/openid-configuration
 	ClientID     string `yaml:"client_id"`
 	ClientSecret string `yaml:"client_secret"`
 	RedirectURL  string `yaml:"redirect_url"`
+	// DefaultRole is the role code given to users provisioned on their first SSO login.
+	DefaultRole string `yaml:"default_role"`
+	// LinkLocalAccounts lets an SSO login with a verified email take over the local account
+	// with that email. Administrators and accounts linked to another identity never are.
+	LinkLocalAccounts bool `yaml:"link_local_accounts"`
+}
+
+// LDAPConfig represents LDAP/AD authentication configuration. When enabled, users without a
+// local password are authenticated by binding to the directory with their own credentials.
+type LDAPConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	URL     string `yaml:"url"` // ldap://host:389 or ldaps://host:636
+	// BindDN and BindPassword are the service account used to look users up. The search is
+	// anonymous when BindDN is empty.
+	BindDN       string `yaml:"bind_dn"`
+	BindPassword string `yaml:"bind_password"`
+	BaseDN       string `yaml:"base_dn"`
+	// UserFilter finds a user's entry; {username} is replaced with the escaped login name.
+	UserFilter           string `yaml:"user_filter"`
+	EmailAttribute       string `yaml:"email_attribute"`
+	DisplayNameAttribute string `yaml:"display_name_attribute"`
+	GroupAttribute       string `yaml:"group_attribute"`
+	// GroupRoles maps group DNs to role codes. When set, an LDAP user's roles are replaced
+	// on every login by the roles of their groups, or the default role if none match.
+	GroupRoles map[string]string `yaml:"group_roles"`
+	// DefaultRole is the role code given to users whose groups map to no role.
+	DefaultRole string `yaml:"default_role"`
 }
 
 // Load loads configuration from the specified file path.
@@ -101,6 +285,9 @@ func (c *Config) applyEnvOverrides() {
 	if dbPass := os.Getenv("VC_DB_PASSWORD"); dbPass != "" {
 		c.Database.Password = dbPass
 	}
+	if replicaDSN := os.Getenv("VC_DB_REPLICA_DSN"); replicaDSN != "" {
+		c.Database.ReplicaDSN = replicaDSN
+	}
 	if jwtSecret := os.Getenv("VC_JWT_SECRET"); jwtSecret != "" {
 		c.JWT.Secret = jwtSecret
 	}
@@ -113,6 +300,98 @@ func (c *Config) applyEnvOverrides() {
 	if adminEmail := os.Getenv("VC_ADMIN_EMAIL"); adminEmail != "" {
 		c.Admin.Email = adminEmail
 	}
+	if ssoSecret := os.Getenv("VC_SSO_CLIENT_SECRET"); ssoSecret != "" {
+		c.SSO.ClientSecret = ssoSecret
+	}
+	if ldapPass := os.Getenv("VC_LDAP_BIND_PASSWORD"); ldapPass != "" {
+		c.LDAP.BindPassword = ldapPass
+	}
+	if webhookKey := os.Getenv("VC_GIT_WEBHOOK_SECRET_KEY"); webhookKey != "" {
+		c.Git.WebhookSecretKey = webhookKey
+	}
+	if secretsKey := os.Getenv("VC_SECRETS_ENCRYPTION_KEY"); secretsKey != "" {
+		c.Secrets.EncryptionKey = secretsKey
+	}
+	if workDir := os.Getenv("VC_TERRAFORM_WORK_DIR"); workDir != "" {
+		c.Terraform.WorkDir = workDir
+	}
+	if templateFile := os.Getenv("VC_TERRAGRUNT_TEMPLATE_FILE"); templateFile != "" {
+		c.Terragrunt.TemplateFile = templateFile
+	}
+
+	if attachmentDir := os.Getenv("VC_ATTACHMENT_DIR"); attachmentDir != "" {
+		c.Attachment.Dir = attachmentDir
+	}
+
+	// Apply defaults for the server
+	if c.Server.MaintenanceMessage == "" {
+		c.Server.MaintenanceMessage = constants.DefaultMaintenanceMessage
+	}
+
+	// Apply defaults for SSO
+	if c.SSO.DefaultRole == "" {
+		c.SSO.DefaultRole = constants.DefaultSSORole
+	}
+
+	// Apply defaults for LDAP
+	if c.LDAP.UserFilter == "" {
+		c.LDAP.UserFilter = constants.DefaultLDAPUserFilter
+	}
+	if c.LDAP.EmailAttribute == "" {
+		c.LDAP.EmailAttribute = constants.DefaultLDAPEmailAttribute
+	}
+	if c.LDAP.DisplayNameAttribute == "" {
+		c.LDAP.DisplayNameAttribute = constants.DefaultLDAPDisplayNameAttribute
+	}
+	if c.LDAP.GroupAttribute == "" {
+		c.LDAP.GroupAttribute = constants.DefaultLDAPGroupAttribute
+	}
+	if c.LDAP.DefaultRole == "" {
+		c.LDAP.DefaultRole = constants.DefaultSSORole
+	}
+
+	// Apply defaults for database queries
+	if c.Database.QueryTimeout <= 0 {
+		c.Database.QueryTimeout = int(constants.DefaultDBQueryTimeout / time.Second)
+	}
+
+	// Apply defaults for terraform work directories
+	if c.Terraform.WorkDir == "" {
+		c.Terraform.WorkDir = constants.DefaultTerraformWorkDir
+	}
+	if c.Terraform.Cleanup == "" {
+		c.Terraform.Cleanup = constants.TerraformCleanupKeepState
+	}
+
+	// Apply defaults for resource requests
+	if c.Request.MaxQuantity <= 0 {
+		c.Request.MaxQuantity = constants.DefaultMaxRequestQuantity
+	}
+	if c.Resource.ImmutableFields == nil {
+		c.Resource.ImmutableFields = constants.DefaultImmutableResourceFields()
+	}
+
+	// Apply defaults for IP address management
+	if c.IPAM.UtilizationAlertPercent == 0 {
+		c.IPAM.UtilizationAlertPercent = constants.DefaultIPPoolUtilizationAlertPercent
+	}
+	if c.IPAM.ProbePort == 0 {
+		c.IPAM.ProbePort = constants.DefaultIPProbePort
+	}
+	if c.IPAM.ProbeTimeout == 0 {
+		c.IPAM.ProbeTimeout = int(constants.DefaultIPProbeTimeout / time.Millisecond)
+	}
+
+	// Apply defaults for attachments
+	if c.Attachment.Dir == "" {
+		c.Attachment.Dir = constants.DefaultAttachmentDir
+	}
+	if c.Attachment.MaxFileSize <= 0 {
+		c.Attachment.MaxFileSize = constants.DefaultAttachmentMaxSize
+	}
+	if len(c.Attachment.AllowedTypes) == 0 {
+		c.Attachment.AllowedTypes = constants.DefaultAttachmentTypes()
+	}
 
 	// Apply defaults for admin
 	if c.Admin.Username == "" {
@@ -145,6 +424,67 @@ func (c *Config) validate() error {
 	if len(c.JWT.Secret) < constants.MinJWTSecretLength {
 		errs = append(errs, "jwt.secret must be at least 32 characters")
 	}
+	if c.Server.Mode == "release" && c.Secrets.EncryptionKey == "" {
+		errs = append(errs, "secrets.encryption_key is required in release mode")
+	}
+
+	if c.SSO.Enabled {
+		if c.SSO.ProviderURL == "" {
+			errs = append(errs, "sso.provider_url is required when SSO is enabled")
+		}
+		if c.SSO.ClientID == "" {
+			errs = append(errs, "sso.client_id is required when SSO is enabled")
+		}
+		if c.SSO.RedirectURL == "" {
+			errs = append(errs, "sso.redirect_url is required when SSO is enabled")
+		}
+	}
+
+	if c.LDAP.Enabled {
+		if c.LDAP.URL == "" {
+			errs = append(errs, "ldap.url is required when LDAP is enabled")
+		}
+		if c.LDAP.BaseDN == "" {
+			errs = append(errs, "ldap.base_dn is required when LDAP is enabled")
+		}
+		if !strings.Contains(c.LDAP.UserFilter, "{username}") {
+			errs = append(errs, "ldap.user_filter must contain {username}")
+		}
+	}
+
+	if !validTerraformCleanup(c.Terraform.Cleanup) {
+		errs = append(errs, fmt.Sprintf("terraform.cleanup %q must be keep-state, remove-providers or remove-all", c.Terraform.Cleanup))
+	}
+	for provider, policy := range c.Terraform.ProviderCleanup {
+		if !validTerraformCleanup(policy) {
+			errs = append(errs, fmt.Sprintf("terraform.provider_cleanup.%s %q must be keep-state, remove-providers or remove-all", provider, policy))
+		}
+	}
+
+	if c.IPAM.UtilizationAlertPercent > 100 {
+		errs = append(errs, fmt.Sprintf("ipam.utilization_alert_percent %d must not exceed 100", c.IPAM.UtilizationAlertPercent))
+	}
+	if c.IPAM.ProbePort < 1 || c.IPAM.ProbePort > 65535 {
+		errs = append(errs, fmt.Sprintf("ipam.probe_port %d must be between 1 and 65535", c.IPAM.ProbePort))
+	}
+	if c.IPAM.ProbeTimeout < 0 {
+		errs = append(errs, fmt.Sprintf("ipam.probe_timeout_ms %d must not be negative", c.IPAM.ProbeTimeout))
+	}
+
+	for i, rule := range c.Approval.AutoApproveRules {
+		if rule.Name == "" {
+			errs = append(errs, fmt.Sprintf("approval.auto_approve_rules[%d].name is required", i))
+		}
+	}
+
+	for i, hook := range c.Notification.Webhooks {
+		if hook.Name == "" {
+			errs = append(errs, fmt.Sprintf("notification.webhooks[%d].name is required", i))
+		}
+		if !strings.HasPrefix(hook.URL, "https://") && !strings.HasPrefix(hook.URL, "http://") {
+			errs = append(errs, fmt.Sprintf("notification.webhooks[%d].url must be an http(s) URL", i))
+		}
+	}
 
 	if len(errs) > 0 {
 		return errors.New(strings.Join(errs, "; "))
@@ -152,8 +492,17 @@ func (c *Config) validate() error {
 	return nil
 }
 
-// DSN returns the database connection string.
+// validTerraformCleanup reports whether policy names a work directory cleanup policy.
+func validTerraformCleanup(policy string) bool {
+	switch policy {
+	case constants.TerraformCleanupKeepState, constants.TerraformCleanupRemoveProviders, constants.TerraformCleanupRemoveAll:
+		return true
+	}
+	return false
+}
+
+// DSN returns the database connection string. Times are read and written in UTC.
 func (c *DatabaseConfig) DSN() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
 		c.User, c.Password, c.Host, c.Port, c.DBName)
 }
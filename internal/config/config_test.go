@@ -42,6 +42,32 @@ jwt:
 				assert.Equal(t, ":8080", cfg.Server.Addr)
 				assert.Equal(t, "localhost", cfg.Database.Host)
 				assert.Equal(t, 3306, cfg.Database.Port)
+				assert.Equal(t, 30, cfg.Database.QueryTimeout)
+				assert.Equal(t, "/tmp/terraform", cfg.Terraform.WorkDir)
+				assert.Equal(t, "keep-state", cfg.Terraform.Cleanup)
+				assert.Equal(t, []string{"provider", "environment", "type"}, cfg.Resource.ImmutableFields)
+				assert.Equal(t, 80, cfg.IPAM.UtilizationAlertPercent)
+				assert.Equal(t, 22, cfg.IPAM.ProbePort)
+				assert.Equal(t, 500, cfg.IPAM.ProbeTimeout)
+				assert.False(t, cfg.Server.Maintenance)
+				assert.NotEmpty(t, cfg.Server.MaintenanceMessage)
+			},
+		},
+		{
+			name: "immutable resource fields can be emptied",
+			configYAML: `
+server:
+  addr: ":8080"
+database:
+  host: "localhost"
+  dbname: "test_db"
+jwt:
+  secret: "this-is-a-very-long-secret-key-for-testing"
+resource:
+  immutable_fields: []
+`,
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Empty(t, cfg.Resource.ImmutableFields)
 			},
 		},
 		{
@@ -59,13 +85,17 @@ jwt:
   secret: "this-is-a-very-long-secret-key-for-testing"
 `,
 			envVars: map[string]string{
-				"VC_SERVER_ADDR": ":9090",
-				"VC_DB_HOST":     "db.example.com",
+				"VC_SERVER_ADDR":            ":9090",
+				"VC_DB_HOST":                "db.example.com",
+				"VC_GIT_WEBHOOK_SECRET_KEY": "webhook-key",
+				"VC_SECRETS_ENCRYPTION_KEY": "secrets-key",
 			},
 			expectError: false,
 			validate: func(t *testing.T, cfg *Config) {
 				assert.Equal(t, ":9090", cfg.Server.Addr)
 				assert.Equal(t, "db.example.com", cfg.Database.Host)
+				assert.Equal(t, "webhook-key", cfg.Git.WebhookSecretKey)
+				assert.Equal(t, "secrets-key", cfg.Secrets.EncryptionKey)
 			},
 		},
 		{
@@ -81,6 +111,69 @@ jwt:
 `,
 			expectError: true,
 		},
+		{
+			name: "unknown terraform cleanup policy",
+			configYAML: `
+server:
+  addr: ":8080"
+database:
+  host: "localhost"
+  dbname: "test_db"
+jwt:
+  secret: "this-is-a-very-long-secret-key-for-testing"
+terraform:
+  provider_cleanup:
+    pve: "remove-everything"
+`,
+			expectError: true,
+		},
+		{
+			name: "utilization alert above 100 percent",
+			configYAML: `
+server:
+  addr: ":8080"
+database:
+  host: "localhost"
+  dbname: "test_db"
+jwt:
+  secret: "this-is-a-very-long-secret-key-for-testing"
+ipam:
+  utilization_alert_percent: 120
+`,
+			expectError: true,
+		},
+		{
+			name: "release mode without a secrets key",
+			configYAML: `
+server:
+  addr: ":8080"
+  mode: "release"
+database:
+  host: "localhost"
+  dbname: "test_db"
+jwt:
+  secret: "this-is-a-very-long-secret-key-for-testing"
+`,
+			expectError: true,
+		},
+		{
+			name: "release mode with a secrets key",
+			configYAML: `
+server:
+  addr: ":8080"
+  mode: "release"
+database:
+  host: "localhost"
+  dbname: "test_db"
+jwt:
+  secret: "this-is-a-very-long-secret-key-for-testing"
+secrets:
+  encryption_key: "secrets-key"
+`,
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "release", cfg.Server.Mode)
+			},
+		},
 		{
 			name: "jwt secret too short",
 			configYAML: `
@@ -145,6 +238,6 @@ func TestDatabaseDSN(t *testing.T) {
 		DBName:   "testdb",
 	}
 
-	expected := "root:password@tcp(localhost:3306)/testdb?charset=utf8mb4&parseTime=True&loc=Local"
+	expected := "root:password@tcp(localhost:3306)/testdb?charset=utf8mb4&parseTime=True&loc=UTC"
 	assert.Equal(t, expected, cfg.DSN())
 }
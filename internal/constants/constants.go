@@ -17,14 +17,151 @@ const (
 	ShutdownTimeout   = 30 * time.Second
 )
 
+// DefaultMaintenanceMessage is returned for requests refused in maintenance mode.
+const DefaultMaintenanceMessage = "The platform is in maintenance mode; changes are disabled until it ends"
+
+// SSO constants.
+const (
+	// OIDCHTTPTimeout bounds each request to the OIDC provider.
+	OIDCHTTPTimeout = 10 * time.Second
+	// DefaultSSORole is the role code given to users provisioned on their first SSO login.
+	DefaultSSORole = "user"
+)
+
+// LDAP constants.
+const (
+	// LDAPTimeout bounds the connection to the LDAP server and each operation on it.
+	LDAPTimeout = 10 * time.Second
+	// DefaultLDAPUserFilter finds the entry of the user logging in; {username} is replaced
+	// with the escaped login name.
+	DefaultLDAPUserFilter = "(uid={username})"
+	// DefaultLDAPEmailAttribute holds a user's email address.
+	DefaultLDAPEmailAttribute = "mail"
+	// DefaultLDAPDisplayNameAttribute holds a user's display name.
+	DefaultLDAPDisplayNameAttribute = "displayName"
+	// DefaultLDAPGroupAttribute lists the DNs of the groups a user belongs to.
+	DefaultLDAPGroupAttribute = "memberOf"
+)
+
+// Webhook delivery constants.
+const (
+	// WebhookTimeout bounds each webhook request.
+	WebhookTimeout = 10 * time.Second
+	// WebhookMaxAttempts is how many times a delivery is tried before it is dropped.
+	WebhookMaxAttempts = 3
+	// WebhookRetryDelay is the wait before the first retry; it doubles for each later one.
+	WebhookRetryDelay = 2 * time.Second
+)
+
+// Terraform work directory constants.
+const (
+	// DefaultTerraformWorkDir holds one terraform work directory per resource request.
+	DefaultTerraformWorkDir = "/tmp/terraform"
+	// TerraformCleanupKeepState, TerraformCleanupRemoveProviders and TerraformCleanupRemoveAll
+	// name the policies for what is left of a work directory once a run finishes.
+	TerraformCleanupKeepState       = "keep-state"
+	TerraformCleanupRemoveProviders = "remove-providers"
+	TerraformCleanupRemoveAll       = "remove-all"
+	// ArtifactURLTTL is how long a signed download URL for a node config's terraform files
+	// and plan stays valid.
+	ArtifactURLTTL = 10 * time.Minute
+)
+
+// Git constants.
+const (
+	// DefaultGitCloneTimeout bounds each git clone unless GIT_CLONE_TIMEOUT sets another limit.
+	DefaultGitCloneTimeout = 10 * time.Minute
+	// GitWaitDelay is how long a killed git's helper processes, such as git-remote-https,
+	// may keep its output open before they are abandoned.
+	GitWaitDelay = 5 * time.Second
+	// GitWebhookReplayWindow is how long an accepted push webhook signature is remembered, so
+	// a captured delivery sent again within it is rejected.
+	GitWebhookReplayWindow = 24 * time.Hour
+	// GitWebhookMaxBodySize is the largest webhook payload read, in bytes; GitHub caps its
+	// payloads at 25 MB.
+	GitWebhookMaxBodySize = 25 << 20
+	// DefaultModulesCacheIdleTimeout is how long the cached clone of a modules repository is
+	// kept unused unless GIT_MODULES_CACHE_IDLE_TIMEOUT sets another limit.
+	DefaultModulesCacheIdleTimeout = 7 * 24 * time.Hour
+	// ModulesCacheSweepInterval is how often cached modules clones are checked for ones that
+	// are idle or whose repository is gone.
+	ModulesCacheSweepInterval = time.Hour
+)
+
 // Database connection timeouts.
 const (
 	DBConnectionTimeout = 5 * time.Second
+	// DefaultDBQueryTimeout bounds each query whose context has no deadline of its own,
+	// unless database.query_timeout sets another limit.
+	DefaultDBQueryTimeout = 30 * time.Second
+)
+
+// Resource lock constants.
+const (
+	// ResourceLockTTL bounds how long a provisioning lock is held if its holder
+	// dies without releasing it. A live holder renews the lease every third of it.
+	ResourceLockTTL = 10 * time.Minute
+)
+
+// Node config retry constants.
+const (
+	// MaxNodeConfigRetryBatch caps how many failed node configs one bulk retry re-queues.
+	MaxNodeConfigRetryBatch = 100
+)
+
+// Bulk delete constants.
+const (
+	// MaxBulkDeleteIDs caps how many resources or requests one bulk delete may name.
+	MaxBulkDeleteIDs = 100
+)
+
+// Resource import constants.
+const (
+	// MaxImportInstances caps how many instances one inventory import may name.
+	MaxImportInstances = 200
+)
+
+// Resource request constants.
+const (
+	// DefaultMaxRequestQuantity caps how many nodes one resource request may ask for when
+	// no limit is configured.
+	DefaultMaxRequestQuantity = 10
+)
+
+// IPAM constants.
+const (
+	// DefaultIPPoolUtilizationAlertPercent is the share of a pool's addresses in use at which
+	// operators are alerted when no threshold is configured.
+	DefaultIPPoolUtilizationAlertPercent = 80
+	// DefaultIPProbePort is the TCP port dialed to check an address before it is allocated,
+	// when ICMP echo needs privileges the process lacks.
+	DefaultIPProbePort = 22
+	// DefaultIPProbeTimeout bounds each check of an address before it is allocated.
+	DefaultIPProbeTimeout = 500 * time.Millisecond
 )
 
+// DefaultImmutableResourceFields returns the resource fields that cannot change after
+// creation when none are configured. They are baked into the node's committed config.
+func DefaultImmutableResourceFields() []string {
+	return []string{"provider", "environment", "type"}
+}
+
+// Request attachment defaults.
+const (
+	DefaultAttachmentDir     = "data/attachments"
+	DefaultAttachmentMaxSize = 10 << 20 // 10MB
+	MaxAttachmentLinkLength  = 1024
+)
+
+// DefaultAttachmentTypes returns the MIME types accepted for uploads when none are configured.
+func DefaultAttachmentTypes() []string {
+	return []string{"application/pdf", "image/png", "image/jpeg", "image/gif", "text/plain"}
+}
+
 // Query parameter constants.
 const (
-	QueryTrue = "true" // Common query parameter value
+	QueryTrue  = "true"  // Common query parameter value
+	QueryFalse = "false" // Turns off a query option that is on by default
 )
 
 // Provider type constants.
@@ -38,6 +175,72 @@ const (
 	ProviderTypeAzure     = "azure"
 )
 
+// ProviderInfo describes a supported provider and what the platform can do with it.
+type ProviderInfo struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// SupportsTerragrunt reports whether requests can be provisioned from a terragrunt module.
+	SupportsTerragrunt bool `json:"supports_terragrunt"`
+	// SupportsRawTerraform reports whether the platform can generate plain terraform files
+	// for requests that select no module.
+	SupportsRawTerraform bool `json:"supports_raw_terraform"`
+	// RequiredSpecFields lists the spec fields raw terraform generation needs, beyond cpu,
+	// memory and disk.
+	RequiredSpecFields []string `json:"required_spec_fields"`
+	// RequiresCredential reports whether a connection test needs a stored credential.
+	RequiresCredential bool `json:"requires_credential"`
+	// ConnectionTest reports whether connection tests actually reach the provider API;
+	// when false the test only validates the input.
+	ConnectionTest bool `json:"connection_test"`
+}
+
+// SupportedProviders returns the providers the platform accepts, in display order.
+func SupportedProviders() []ProviderInfo {
+	return []ProviderInfo{
+		{
+			Type: ProviderTypePVE, Name: "Proxmox VE",
+			SupportsTerragrunt: true, SupportsRawTerraform: true, ConnectionTest: true,
+			RequiredSpecFields: []string{"target_node", "template_name"},
+		},
+		{
+			Type: ProviderTypeVMware, Name: "VMware vSphere",
+			SupportsTerragrunt: true, SupportsRawTerraform: true, ConnectionTest: true,
+			RequiredSpecFields: []string{"datacenter", "cluster", "datastore", "network", "template_name"},
+		},
+		{
+			Type: ProviderTypeOpenStack, Name: "OpenStack",
+			SupportsTerragrunt: true, SupportsRawTerraform: true, ConnectionTest: true,
+			RequiredSpecFields: []string{"image_name", "flavor_name", "network_name"},
+		},
+		{Type: ProviderTypeAWS, Name: "AWS", SupportsTerragrunt: true, RequiredSpecFields: []string{}, RequiresCredential: true},
+		{Type: ProviderTypeAliyun, Name: "Alibaba Cloud", SupportsTerragrunt: true, RequiredSpecFields: []string{}, RequiresCredential: true},
+		{Type: ProviderTypeGCP, Name: "Google Cloud", SupportsTerragrunt: true, RequiredSpecFields: []string{}, RequiresCredential: true},
+		{Type: ProviderTypeAzure, Name: "Microsoft Azure", SupportsTerragrunt: true, RequiredSpecFields: []string{}, RequiresCredential: true},
+	}
+}
+
+// LookupProvider returns the registry entry for providerType.
+func LookupProvider(providerType string) (ProviderInfo, bool) {
+	for _, provider := range SupportedProviders() {
+		if provider.Type == providerType {
+			return provider, true
+		}
+	}
+	return ProviderInfo{}, false
+}
+
+// ConnectionTestCacheTTL is how long a provider connection test result is reused for the
+// same endpoint and credential.
+const ConnectionTestCacheTTL = 30 * time.Second
+
+// Provider connection test limits.
+const (
+	// ConnectionTestTimeout bounds each provider connection test.
+	ConnectionTestTimeout = 10 * time.Second
+	// ConnectionTestMaxResponseSize is the most of a response body a connection test reads.
+	ConnectionTestMaxResponseSize = 1 << 20
+)
+
 // Security constants.
 const (
 	MinJWTSecretLength = 32
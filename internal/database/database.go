@@ -8,20 +8,51 @@ import (
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-// New creates a new database connection.
+// New creates a new database connection. When cfg.ReplicaDSN is set, a second connection is
+// opened to the replica and reads scoped with ReadReplica are served from it. Queries whose
+// context has no deadline are bounded by cfg.QueryTimeout seconds.
 func New(cfg config.DatabaseConfig) (*gorm.DB, error) {
-	db, err := gorm.Open(mysql.Open(cfg.DSN()), &gorm.Config{
+	db, err := open(cfg.DSN(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if cfg.QueryTimeout > 0 {
+		if err := RegisterQueryTimeout(db, time.Duration(cfg.QueryTimeout)*time.Second); err != nil {
+			return nil, fmt.Errorf("failed to register query timeout: %w", err)
+		}
+	}
+
+	if cfg.ReplicaDSN == "" {
+		return db, nil
+	}
+
+	replica, err := open(cfg.ReplicaDSN, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to read replica: %w", err)
+	}
+	if err := RegisterReadReplica(db, replica); err != nil {
+		return nil, fmt.Errorf("failed to register read replica: %w", err)
+	}
+
+	return db, nil
+}
+
+// open connects to the MySQL server at dsn with the pool settings from cfg.
+func open(dsn string, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
 		Logger:                 logger.Default.LogMode(logger.Info),
 		SkipDefaultTransaction: true,
 		PrepareStmt:            true,
+		NowFunc:                model.Now,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
 
 	sqlDB, err := db.DB()
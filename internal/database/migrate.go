@@ -2,18 +2,22 @@
 package database
 
 import (
+	"fmt"
+
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/secrets"
 	"gorm.io/gorm"
 )
 
 // AutoMigrate runs database migrations for all models.
 func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&model.User{},
 		&model.Role{},
 		&model.Permission{},
 		&model.Resource{},
 		&model.ResourceRequest{},
+		&model.RequestStatusEvent{},
 		&model.AuditLog{},
 		&model.ProviderConfig{},
 		&model.Credential{},
@@ -28,5 +32,97 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.IPPool{},
 		&model.IPAllocation{},
 		&model.VMTemplate{},
-	)
+		&model.ResourceLock{},
+		&model.NameSequence{},
+		&model.RequestAttachment{},
+		&model.Delegation{},
+	); err != nil {
+		return err
+	}
+	if err := moveIPPoolBridges(db); err != nil {
+		return err
+	}
+	return backfillResourceSearchText(db)
+}
+
+// moveIPPoolBridges moves the bridge names that network_type held before it named the kind
+// of network a pool serves into the bridge column, and gives those pools the default private
+// network type. Deleted pools are moved too, so restoring one keeps its bridge.
+func moveIPPoolBridges(db *gorm.DB) error {
+	networkTypes := []string{model.NetworkTypeManagement, model.NetworkTypePublic, model.NetworkTypePrivate, model.NetworkTypeStorage}
+	// Columns are assigned in name order, so bridge reads the old network_type even on
+	// MySQL, which applies assignments left to right
+	return db.Unscoped().Model(&model.IPPool{}).
+		Where("network_type NOT IN ?", networkTypes).
+		UpdateColumns(map[string]interface{}{
+			"bridge":       gorm.Expr("network_type"),
+			"network_type": model.NetworkTypePrivate,
+		}).Error
+}
+
+// backfillResourceSearchText fills search_text for resources saved before the column
+// existed. Later saves keep it current through the Resource BeforeSave hook.
+func backfillResourceSearchText(db *gorm.DB) error {
+	var resources []*model.Resource
+	return db.Where("search_text IS NULL OR search_text = ''").
+		FindInBatches(&resources, 100, func(tx *gorm.DB, _ int) error {
+			for _, resource := range resources {
+				if err := tx.Model(resource).UpdateColumn("search_text", resource.BuildSearchText()).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// sealedColumns lists, by table, the columns stored with the sealed serializer.
+var sealedColumns = []struct {
+	table   string
+	columns []string
+}{
+	{table: model.Credential{}.TableName(), columns: []string{"secret_key", "token"}},
+	{table: model.GitRepository{}.TableName(), columns: []string{"token", "ssh_key"}},
+	{table: model.TerraformRegistry{}.TableName(), columns: []string{"token"}},
+}
+
+// EncryptSecrets seals the credential secrets saved before encryption was enabled with box.
+// Values that are empty or already sealed are left alone, so it is safe to run on every start.
+func EncryptSecrets(db *gorm.DB, box *secrets.Box) error {
+	for _, sealed := range sealedColumns {
+		for _, column := range sealed.columns {
+			if err := encryptColumn(db, box, sealed.table, column); err != nil {
+				return fmt.Errorf("failed to encrypt %s.%s: %w", sealed.table, column, err)
+			}
+		}
+	}
+	return nil
+}
+
+// encryptColumn seals the plaintext values of column in table. The column is read and written
+// by table name rather than through the model, so the sealed serializer does not see it.
+func encryptColumn(db *gorm.DB, box *secrets.Box, table, column string) error {
+	var rows []struct {
+		ID    string
+		Value string
+	}
+	err := db.Table(table).
+		Select("id", column+" AS value").
+		Where(column + " <> ''").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if secrets.IsSealed(row.Value) {
+			continue
+		}
+		value, err := box.Seal(row.Value)
+		if err != nil {
+			return err
+		}
+		if err := db.Table(table).Where("id = ?", row.ID).UpdateColumn(column, value).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
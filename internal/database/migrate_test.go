@@ -0,0 +1,96 @@
+// Package database provides migration tests.
+package database
+
+import (
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillResourceSearchText(t *testing.T) {
+	db := openTestDB(t, t.Name())
+	require.NoError(t, db.AutoMigrate(&model.Resource{}))
+
+	resource := &model.Resource{Name: "Web-01", Type: "vm", Provider: "pve", OwnerID: "owner-1", Environment: "dev", Tags: `["frontend"]`}
+	require.NoError(t, db.Create(resource).Error)
+	require.NoError(t, db.Model(resource).UpdateColumn("search_text", "").Error)
+
+	require.NoError(t, backfillResourceSearchText(db))
+
+	var stored model.Resource
+	require.NoError(t, db.First(&stored, "id = ?", resource.ID).Error)
+	assert.Equal(t, "web-01\nfrontend", stored.SearchText)
+}
+
+func TestMoveIPPoolBridges(t *testing.T) {
+	db := openTestDB(t, t.Name())
+	require.NoError(t, db.AutoMigrate(&model.IPPool{}))
+
+	// Pools saved before network_type named the kind of network hold a bridge name there
+	legacy := &model.IPPool{Name: "legacy", CIDR: "10.0.0.0/24", Gateway: "10.0.0.1", StartIP: "10.0.0.10", EndIP: "10.0.0.200", ZoneID: "zone-1", NetworkType: "vmbr1"}
+	deleted := &model.IPPool{Name: "deleted", CIDR: "10.0.1.0/24", Gateway: "10.0.1.1", StartIP: "10.0.1.10", EndIP: "10.0.1.200", ZoneID: "zone-1", NetworkType: "vmbr0"}
+	current := &model.IPPool{Name: "current", CIDR: "10.0.2.0/24", Gateway: "10.0.2.1", StartIP: "10.0.2.10", EndIP: "10.0.2.200", ZoneID: "zone-1", NetworkType: model.NetworkTypeStorage, Bridge: "vmbr2"}
+	for _, pool := range []*model.IPPool{legacy, deleted, current} {
+		require.NoError(t, db.Create(pool).Error)
+	}
+	require.NoError(t, db.Delete(deleted).Error)
+
+	require.NoError(t, moveIPPoolBridges(db))
+	require.NoError(t, moveIPPoolBridges(db), "running again changes nothing")
+
+	stored := func(id string) model.IPPool {
+		var pool model.IPPool
+		require.NoError(t, db.Unscoped().First(&pool, "id = ?", id).Error)
+		return pool
+	}
+	for id, want := range map[string]struct{ networkType, bridge string }{
+		legacy.ID:  {model.NetworkTypePrivate, "vmbr1"},
+		deleted.ID: {model.NetworkTypePrivate, "vmbr0"},
+		current.ID: {model.NetworkTypeStorage, "vmbr2"},
+	} {
+		pool := stored(id)
+		assert.Equal(t, want.networkType, pool.NetworkType, pool.Name)
+		assert.Equal(t, want.bridge, pool.Bridge, pool.Name)
+	}
+}
+
+func TestEncryptSecrets(t *testing.T) {
+	db := openTestDB(t, t.Name())
+	require.NoError(t, db.AutoMigrate(&model.Credential{}, &model.GitRepository{}, &model.TerraformRegistry{}))
+
+	// Rows saved before a key was configured hold plaintext
+	credential := &model.Credential{Name: "pve", Type: "pve", SecretKey: "s3cret", CreatedByID: "user-1"}
+	repo := &model.GitRepository{Name: "storage", Type: model.GitRepoTypeStorage, URL: "https://git.example.com/infra/nodes.git", Token: "glpat-1"}
+	registry := &model.TerraformRegistry{Name: "internal", Endpoint: "registry.example.com", Token: "tf-token"}
+	require.NoError(t, db.Create(credential).Error)
+	require.NoError(t, db.Create(repo).Error)
+	require.NoError(t, db.Create(registry).Error)
+
+	box, err := secrets.NewBox("test-secrets-key")
+	require.NoError(t, err)
+	require.NoError(t, EncryptSecrets(db, box))
+
+	stored := func(table, column, id string) string {
+		var value string
+		require.NoError(t, db.Table(table).Select(column).Where("id = ?", id).Scan(&value).Error)
+		return value
+	}
+	sealedSecret := stored("credentials", "secret_key", credential.ID)
+	for _, value := range []string{sealedSecret, stored("git_repositories", "token", repo.ID), stored("terraform_registries", "token", registry.ID)} {
+		assert.True(t, secrets.IsSealed(value), "got %q", value)
+	}
+	assert.Empty(t, stored("credentials", "token", credential.ID), "empty values are left alone")
+
+	// Running again does not seal values twice
+	require.NoError(t, EncryptSecrets(db, box))
+	assert.Equal(t, sealedSecret, stored("credentials", "secret_key", credential.ID))
+
+	model.SetSecretsBox(box)
+	t.Cleanup(func() { model.SetSecretsBox(nil) })
+	var loaded model.Credential
+	require.NoError(t, db.First(&loaded, "id = ?", credential.ID).Error)
+	assert.Equal(t, "s3cret", loaded.SecretKey)
+}
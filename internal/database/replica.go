@@ -0,0 +1,63 @@
+// Package database provides database connection and management utilities.
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// replicaSettingKey marks a statement as safe to serve from the read replica.
+const replicaSettingKey = "vc:read_replica"
+
+// replicaCallbackName is the name of the callback that routes reads to the replica.
+const replicaCallbackName = "vc:read_replica"
+
+// ReadReplica is a query scope that lets a read run against the read replica when one is
+// registered. Queries without it, writes and anything inside a transaction always use the
+// primary, so only reads that tolerate replication lag should opt in.
+func ReadReplica(db *gorm.DB) *gorm.DB {
+	return db.Set(replicaSettingKey, true)
+}
+
+// replicaResolver is a GORM plugin that swaps the connection pool of opted-in reads for the
+// replica's.
+type replicaResolver struct {
+	replica gorm.ConnPool
+}
+
+// Name implements gorm.Plugin.
+func (r *replicaResolver) Name() string {
+	return replicaCallbackName
+}
+
+// Initialize implements gorm.Plugin.
+func (r *replicaResolver) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register(replicaCallbackName, r.route); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register(replicaCallbackName, r.route)
+}
+
+// route points the statement at the replica when it opted in and is not part of a transaction.
+func (r *replicaResolver) route(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	if useReplica, ok := db.Get(replicaSettingKey); !ok || useReplica != true {
+		return
+	}
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return
+	}
+	db.Statement.ConnPool = r.replica
+}
+
+// RegisterReadReplica routes reads scoped with ReadReplica on primary to replica. Writes keep
+// using primary.
+func RegisterReadReplica(primary, replica *gorm.DB) error {
+	if replica == nil {
+		return errors.New("read replica connection is required")
+	}
+	return primary.Use(&replicaResolver{replica: replica.ConnPool})
+}
@@ -0,0 +1,87 @@
+// Package database provides read replica routing tests.
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type replicaTestRow struct {
+	ID   uint
+	Name string
+}
+
+func openTestDB(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&replicaTestRow{}))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() }) //nolint:errcheck // best-effort cleanup
+	return db
+}
+
+func names(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var rows []replicaTestRow
+	require.NoError(t, db.Order("id").Find(&rows).Error)
+	out := make([]string, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, row.Name)
+	}
+	return out
+}
+
+func TestRegisterReadReplica(t *testing.T) {
+	primary := openTestDB(t, t.Name()+"_primary")
+	replica := openTestDB(t, t.Name()+"_replica")
+	require.NoError(t, RegisterReadReplica(primary, replica))
+
+	require.NoError(t, primary.Create(&replicaTestRow{Name: "on-primary"}).Error)
+	require.NoError(t, replica.Create(&replicaTestRow{Name: "on-replica"}).Error)
+
+	t.Run("reads default to the primary", func(t *testing.T) {
+		assert.Equal(t, []string{"on-primary"}, names(t, primary))
+	})
+
+	t.Run("opted-in reads use the replica", func(t *testing.T) {
+		assert.Equal(t, []string{"on-replica"}, names(t, primary.Scopes(ReadReplica)))
+
+		var count int64
+		query := primary.Scopes(ReadReplica).Model(&replicaTestRow{}).Where("name LIKE ?", "on-%")
+		require.NoError(t, query.Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+		var rows []replicaTestRow
+		require.NoError(t, query.Find(&rows).Error)
+		require.Len(t, rows, 1)
+		assert.Equal(t, "on-replica", rows[0].Name, "scope survives Count on a reused query")
+	})
+
+	t.Run("writes always use the primary", func(t *testing.T) {
+		require.NoError(t, primary.Scopes(ReadReplica).Create(&replicaTestRow{Name: "written"}).Error)
+		assert.Equal(t, []string{"on-primary", "written"}, names(t, primary))
+		assert.Equal(t, []string{"on-replica"}, names(t, replica))
+	})
+
+	t.Run("reads inside a transaction use the primary", func(t *testing.T) {
+		err := primary.Transaction(func(tx *gorm.DB) error {
+			assert.Equal(t, []string{"on-primary", "written"}, names(t, tx.Scopes(ReadReplica)))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestRegisterReadReplica_RequiresReplica(t *testing.T) {
+	primary := openTestDB(t, t.Name())
+	require.Error(t, RegisterReadReplica(primary, nil))
+}
@@ -11,58 +11,207 @@ import (
 	"gorm.io/gorm"
 )
 
-// Seed initializes default data including roles and admin user.
-func Seed(db *gorm.DB, cfg *config.Config) error {
-	if err := seedRoles(db); err != nil {
-		return err
+// Permission actions granted by the default roles.
+const (
+	actionCreate  = "create"
+	actionRead    = "read"
+	actionUpdate  = "update"
+	actionDelete  = "delete"
+	actionApprove = "approve"
+)
+
+// permissionResources lists the resources the default permissions cover, with display names.
+var permissionResources = []struct {
+	Resource string
+	Name     string
+}{
+	{"user", "Users"},
+	{"role", "Roles"},
+	{"resource", "Resources"},
+	{"request", "Resource requests"},
+	{"infra", "Regions, zones and Terraform registry"},
+	{"settings", "Providers and credentials"},
+	{"git", "Git repositories and node configs"},
+	{"ipam", "IP pools and allocations"},
+	{"template", "VM templates"},
+	{"audit", "Audit logs"},
+}
+
+// crudActions are the actions every resource supports.
+var crudActions = []string{actionCreate, actionRead, actionUpdate, actionDelete}
+
+// defaultRole describes a role created on first run. Permissions lists permission codes,
+// or nil with AllPermissions set for roles that get every permission.
+type defaultRole struct {
+	Role           model.Role
+	AllPermissions bool
+	Permissions    []string
+}
+
+// defaultRoles returns the roles created on first run.
+func defaultRoles() []defaultRole {
+	var readAll []string
+	for _, r := range permissionResources {
+		readAll = append(readAll, permissionCode(r.Resource, actionRead))
 	}
-	if err := seedAdminUser(db, cfg); err != nil {
-		return err
+
+	operator := append([]string{}, readAll...)
+	for _, resource := range []string{"resource", "request", "infra", "git", "ipam", "template"} {
+		for _, action := range []string{actionCreate, actionUpdate, actionDelete} {
+			operator = append(operator, permissionCode(resource, action))
+		}
 	}
-	return nil
-}
+	operator = append(operator, permissionCode("request", actionApprove))
 
-func seedRoles(db *gorm.DB) error {
-	roles := []model.Role{
+	return []defaultRole{
+		{
+			Role: model.Role{
+				Name:        "Administrator",
+				Code:        "admin",
+				Description: "System administrator with full access",
+			},
+			AllPermissions: true,
+		},
 		{
-			Name:        "Administrator",
-			Code:        "admin",
-			Description: "System administrator with full access",
-			IsSystem:    true,
-			Status:      1,
+			Role: model.Role{
+				Name:        "Operator",
+				Code:        "operator",
+				Description: "Manages infrastructure and approves requests; cannot manage users, roles or credentials",
+			},
+			Permissions: operator,
 		},
 		{
-			Name:        "User",
-			Code:        "user",
-			Description: "Regular user with limited access",
-			IsSystem:    true,
-			Status:      1,
+			Role: model.Role{
+				Name:        "User",
+				Code:        "user",
+				Description: "Regular user with limited access",
+			},
+			Permissions: []string{
+				permissionCode("resource", actionRead),
+				permissionCode("request", actionRead),
+				permissionCode("request", actionCreate),
+				permissionCode("template", actionRead),
+			},
 		},
 		{
-			Name:        "Viewer",
-			Code:        "viewer",
-			Description: "Read-only access",
-			IsSystem:    true,
-			Status:      1,
+			Role: model.Role{
+				Name:        "Viewer",
+				Code:        "viewer",
+				Description: "Read-only access",
+			},
+			Permissions: readAll,
 		},
 	}
+}
+
+// permissionCode returns the code of the permission for action on resource.
+func permissionCode(resource, action string) string {
+	return resource + ":" + action
+}
+
+// Seed initializes default data: permissions, roles and the admin user. It only creates
+// what is missing, so it is safe to run on every start.
+func Seed(db *gorm.DB, cfg *config.Config) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		permissions, err := seedPermissions(tx)
+		if err != nil {
+			return err
+		}
+		if err := seedRoles(tx, permissions); err != nil {
+			return err
+		}
+		return seedAdminUser(tx, cfg)
+	})
+}
+
+// seedPermissions creates the missing default permissions and returns all of them by code.
+func seedPermissions(db *gorm.DB) (map[string]model.Permission, error) {
+	var existing []model.Permission
+	if err := db.Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]model.Permission, len(existing))
+	for _, permission := range existing {
+		byCode[permission.Code] = permission
+	}
+
+	for _, r := range permissionResources {
+		actions := crudActions
+		if r.Resource == "request" {
+			actions = append(append([]string{}, crudActions...), actionApprove)
+		}
+		for _, action := range actions {
+			code := permissionCode(r.Resource, action)
+			if _, ok := byCode[code]; ok {
+				continue
+			}
+			permission := model.Permission{
+				Name:        action + " " + r.Resource,
+				Code:        code,
+				Resource:    r.Resource,
+				Action:      action,
+				Description: r.Name + ": " + action,
+			}
+			if err := db.Create(&permission).Error; err != nil {
+				return nil, err
+			}
+			byCode[code] = permission
+		}
+	}
+	return byCode, nil
+}
+
+// seedRoles creates the missing default roles. Roles that exist without any permissions,
+// as older deployments seeded them, are granted the defaults; roles with permissions are
+// left as the administrators configured them.
+func seedRoles(db *gorm.DB, permissions map[string]model.Permission) error {
+	for _, def := range defaultRoles() {
+		var granted []model.Permission
+		if def.AllPermissions {
+			for _, permission := range permissions {
+				granted = append(granted, permission)
+			}
+		} else {
+			for _, code := range def.Permissions {
+				granted = append(granted, permissions[code])
+			}
+		}
 
-	for _, role := range roles {
 		var existing model.Role
-		result := db.Where("code = ?", role.Code).First(&existing)
+		result := db.Preload("Permissions").Where("code = ?", def.Role.Code).First(&existing)
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			role := def.Role
+			role.IsSystem = true
+			role.Status = 1
+			role.Permissions = granted
 			if err := db.Create(&role).Error; err != nil {
 				return err
 			}
 			log.Printf("Created role: %s", role.Code)
-		} else if result.Error == nil && !existing.IsSystem {
+			continue
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if !existing.IsSystem {
 			// Update existing role to mark as system role
-			db.Model(&existing).Update("is_system", true)
+			if err := db.Model(&existing).Update("is_system", true).Error; err != nil {
+				return err
+			}
+		}
+		if len(existing.Permissions) == 0 {
+			if err := db.Model(&existing).Association("Permissions").Append(granted); err != nil {
+				return err
+			}
+			log.Printf("Granted default permissions to role: %s", existing.Code)
 		}
 	}
 	return nil
 }
 
+// seedAdminUser creates the configured admin user unless it exists or another user already
+// holds the admin role, so renaming the first admin does not bring the default one back.
 func seedAdminUser(db *gorm.DB, cfg *config.Config) error {
 	// Check if admin user already exists
 	var existing model.User
@@ -70,10 +219,10 @@ func seedAdminUser(db *gorm.DB, cfg *config.Config) error {
 	if result.Error == nil {
 		// Admin user already exists, ensure it's marked as system user
 		if !existing.IsSystem || existing.Source == "" {
-			db.Model(&existing).Updates(map[string]interface{}{
+			return db.Model(&existing).Updates(map[string]interface{}{
 				"is_system": true,
 				"source":    model.UserSourceLocal,
-			})
+			}).Error
 		}
 		return nil
 	}
@@ -81,18 +230,23 @@ func seedAdminUser(db *gorm.DB, cfg *config.Config) error {
 		return result.Error
 	}
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(cfg.Admin.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
-
 	// Get admin role
 	var adminRole model.Role
 	if err := db.Where("code = ?", "admin").First(&adminRole).Error; err != nil {
 		return err
 	}
 
+	admins := db.Model(&adminRole).Association("Users").Count()
+	if admins > 0 {
+		return nil
+	}
+
+	// Hash password
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(cfg.Admin.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
 	// Create admin user
 	adminUser := model.User{
 		Username:     cfg.Admin.Username,
@@ -0,0 +1,127 @@
+// Package database provides seed tests.
+package database
+
+import (
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func openSeedTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := openTestDB(t, t.Name())
+	require.NoError(t, db.AutoMigrate(&model.Permission{}, &model.Role{}, &model.User{}))
+	return db
+}
+
+func seedTestConfig() *config.Config {
+	return &config.Config{Admin: config.AdminConfig{
+		Username: "root",
+		Password: "s3cret-pass",
+		Email:    "root@example.com",
+	}}
+}
+
+func count(t *testing.T, db *gorm.DB, value interface{}) int64 {
+	t.Helper()
+	var n int64
+	require.NoError(t, db.Model(value).Count(&n).Error)
+	return n
+}
+
+func rolePermissionCodes(t *testing.T, db *gorm.DB, code string) []string {
+	t.Helper()
+	var role model.Role
+	require.NoError(t, db.Preload("Permissions").Where("code = ?", code).First(&role).Error)
+	codes := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		codes = append(codes, permission.Code)
+	}
+	return codes
+}
+
+func TestSeed_FirstRun(t *testing.T) {
+	db := openSeedTestDB(t)
+	require.NoError(t, Seed(db, seedTestConfig()))
+
+	var admin model.User
+	require.NoError(t, db.Preload("Roles").Where("username = ?", "root").First(&admin).Error)
+	assert.Equal(t, "root@example.com", admin.Email)
+	assert.True(t, admin.IsSystem)
+	assert.Equal(t, model.UserSourceLocal, admin.Source)
+	require.Len(t, admin.Roles, 1)
+	assert.Equal(t, "admin", admin.Roles[0].Code)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte("s3cret-pass")))
+
+	for _, code := range []string{"admin", "operator", "user", "viewer"} {
+		assert.NotEmpty(t, rolePermissionCodes(t, db, code), code)
+	}
+
+	total := count(t, db, &model.Permission{})
+	assert.Len(t, rolePermissionCodes(t, db, "admin"), int(total))
+
+	operator := rolePermissionCodes(t, db, "operator")
+	assert.Contains(t, operator, "request:approve")
+	assert.Contains(t, operator, "user:read")
+	assert.NotContains(t, operator, "user:create")
+	assert.NotContains(t, operator, "settings:update")
+
+	for _, code := range rolePermissionCodes(t, db, "viewer") {
+		assert.Regexp(t, `:read$`, code)
+	}
+}
+
+func TestSeed_IsIdempotent(t *testing.T) {
+	db := openSeedTestDB(t)
+	cfg := seedTestConfig()
+	require.NoError(t, Seed(db, cfg))
+
+	users := count(t, db, &model.User{})
+	roles := count(t, db, &model.Role{})
+	permissions := count(t, db, &model.Permission{})
+	var grants int64
+	require.NoError(t, db.Table("role_permissions").Count(&grants).Error)
+
+	require.NoError(t, Seed(db, cfg))
+
+	assert.Equal(t, users, count(t, db, &model.User{}))
+	assert.Equal(t, roles, count(t, db, &model.Role{}))
+	assert.Equal(t, permissions, count(t, db, &model.Permission{}))
+	var grantsAfter int64
+	require.NoError(t, db.Table("role_permissions").Count(&grantsAfter).Error)
+	assert.Equal(t, grants, grantsAfter)
+}
+
+func TestSeed_KeepsExistingAdmins(t *testing.T) {
+	db := openSeedTestDB(t)
+	require.NoError(t, Seed(db, seedTestConfig()))
+
+	// The first admin was renamed; a changed config must not create a second one.
+	require.NoError(t, db.Model(&model.User{}).Where("username = ?", "root").Update("username", "ops-lead").Error)
+	cfg := seedTestConfig()
+	cfg.Admin.Username = "admin"
+	cfg.Admin.Email = "admin@localhost"
+	require.NoError(t, Seed(db, cfg))
+
+	assert.Equal(t, int64(1), count(t, db, &model.User{}))
+}
+
+func TestSeed_KeepsCustomizedRolePermissions(t *testing.T) {
+	db := openSeedTestDB(t)
+	require.NoError(t, Seed(db, seedTestConfig()))
+
+	var viewer model.Role
+	require.NoError(t, db.Where("code = ?", "viewer").First(&viewer).Error)
+	var auditRead model.Permission
+	require.NoError(t, db.Where("code = ?", "audit:read").First(&auditRead).Error)
+	require.NoError(t, db.Model(&viewer).Association("Permissions").Replace([]model.Permission{auditRead}))
+
+	require.NoError(t, Seed(db, seedTestConfig()))
+
+	assert.Equal(t, []string{"audit:read"}, rolePermissionCodes(t, db, "viewer"))
+}
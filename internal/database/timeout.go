@@ -0,0 +1,99 @@
+// Package database provides database connection and management utilities.
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// queryTimeoutCallbackName is the name of the callbacks that bound statement contexts.
+const queryTimeoutCallbackName = "vc:query_timeout"
+
+// queryTimeoutBoundKey holds the boundContext of a statement.
+const queryTimeoutBoundKey = "vc:query_timeout_bound"
+
+// boundContext records the context a statement had before it was bounded, so it can be put
+// back once the operation finishes.
+type boundContext struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+// queryTimeout is a GORM plugin that gives statements whose context has no deadline one
+// that expires after timeout, so a slow query cannot hold a request forever.
+type queryTimeout struct {
+	timeout time.Duration
+}
+
+// Name implements gorm.Plugin.
+func (q *queryTimeout) Name() string {
+	return queryTimeoutCallbackName
+}
+
+// Initialize implements gorm.Plugin. The context is bounded before the first callback of
+// each kind and released after the last, so it also covers implicit transactions and
+// preloads. Row and Rows are left alone, since their rows are read after the callbacks
+// return.
+func (q *queryTimeout) Initialize(db *gorm.DB) error {
+	type registrar interface {
+		Register(name string, fn func(*gorm.DB)) error
+	}
+	callbacks := db.Callback()
+	for _, around := range [][2]registrar{
+		{callbacks.Create().Before("*"), callbacks.Create().After("*")},
+		{callbacks.Query().Before("*"), callbacks.Query().After("*")},
+		{callbacks.Update().Before("*"), callbacks.Update().After("*")},
+		{callbacks.Delete().Before("*"), callbacks.Delete().After("*")},
+		{callbacks.Raw().Before("*"), callbacks.Raw().After("*")},
+	} {
+		if err := around[0].Register(queryTimeoutCallbackName+":bound", q.bound); err != nil {
+			return err
+		}
+		if err := around[1].Register(queryTimeoutCallbackName+":release", releaseQueryTimeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bound replaces a statement context without a deadline by one that expires after the timeout.
+func (q *queryTimeout) bound(db *gorm.DB) {
+	parent := db.Statement.Context
+	ctx := parent
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, q.timeout)
+	db.Statement.Context = ctx
+	db.InstanceSet(queryTimeoutBoundKey, &boundContext{parent: parent, cancel: cancel})
+}
+
+// releaseQueryTimeout cancels the context bound for the statement, if there is one, and
+// restores the caller's. A chain that runs another operation on the same statement, such
+// as a Count followed by a Find, then gets a fresh timeout instead of the cancelled context.
+func releaseQueryTimeout(db *gorm.DB) {
+	value, ok := db.InstanceGet(queryTimeoutBoundKey)
+	if !ok {
+		return
+	}
+	if bound, ok := value.(*boundContext); ok && bound != nil {
+		bound.cancel()
+		db.Statement.Context = bound.parent
+		db.InstanceSet(queryTimeoutBoundKey, (*boundContext)(nil))
+	}
+}
+
+// RegisterQueryTimeout bounds every create, query, update, delete and exec on db whose
+// context has no deadline to timeout. Contexts that carry a deadline keep it.
+func RegisterQueryTimeout(db *gorm.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		return errors.New("query timeout must be positive")
+	}
+	return db.Use(&queryTimeout{timeout: timeout})
+}
@@ -0,0 +1,82 @@
+// Package database provides query timeout tests.
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// slowQueries makes every query on db wait until its context is done or delay has passed,
+// as a query stuck on a busy database would, and records each statement's deadline.
+func slowQueries(t *testing.T, db *gorm.DB, delay time.Duration) *[]bool {
+	t.Helper()
+	var deadlines []bool
+	require.NoError(t, db.Callback().Query().Before("gorm:query").Register("test:slow", func(tx *gorm.DB) {
+		_, ok := tx.Statement.Context.Deadline()
+		deadlines = append(deadlines, ok)
+		select {
+		case <-tx.Statement.Context.Done():
+		case <-time.After(delay):
+		}
+	}))
+	return &deadlines
+}
+
+func TestRegisterQueryTimeout(t *testing.T) {
+	db := openTestDB(t, t.Name())
+	require.NoError(t, db.Create(&replicaTestRow{Name: "row"}).Error)
+	require.NoError(t, RegisterQueryTimeout(db, 50*time.Millisecond))
+	slowQueries(t, db, 5*time.Second)
+
+	t.Run("a query without a deadline is cancelled after the timeout", func(t *testing.T) {
+		start := time.Now()
+		err := db.WithContext(context.Background()).Find(&[]replicaTestRow{}).Error
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("a caller's deadline is kept", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err := db.WithContext(ctx).Find(&[]replicaTestRow{}).Error
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond, "the longer caller deadline applies")
+	})
+
+	t.Run("queries that finish in time are unaffected", func(t *testing.T) {
+		fast := openTestDB(t, t.Name())
+		require.NoError(t, RegisterQueryTimeout(fast, time.Second))
+		deadlines := slowQueries(t, fast, 0)
+		require.NoError(t, fast.Create(&replicaTestRow{Name: "fast"}).Error)
+
+		var rows []replicaTestRow
+		require.NoError(t, fast.Find(&rows).Error)
+		assert.Len(t, rows, 1)
+		assert.Equal(t, []bool{true}, *deadlines, "the query ran with a deadline")
+	})
+
+	t.Run("a chain can run another operation after the first", func(t *testing.T) {
+		chained := openTestDB(t, t.Name())
+		require.NoError(t, RegisterQueryTimeout(chained, time.Second))
+		require.NoError(t, chained.Create(&replicaTestRow{Name: "chained"}).Error)
+
+		// Repository List methods count and then fetch a page on the same statement
+		query := chained.WithContext(context.Background()).Model(&replicaTestRow{}).Where("name = ?", "chained")
+		var total int64
+		require.NoError(t, query.Count(&total).Error)
+		var rows []replicaTestRow
+		require.NoError(t, query.Offset(0).Limit(10).Find(&rows).Error)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, rows, 1)
+		_, ok := query.Statement.Context.Deadline()
+		assert.False(t, ok, "the caller's context is restored")
+	})
+
+	require.Error(t, RegisterQueryTimeout(db, 0))
+}
@@ -0,0 +1,139 @@
+// Package events provides an in-process event bus for reacting to domain events.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Type identifies a domain event.
+type Type string
+
+const (
+	// RequestCreated is published when a resource request is submitted.
+	RequestCreated Type = "request.created"
+	// RequestApproved is published when a resource request is approved, manually or by rule.
+	RequestApproved Type = "request.approved"
+	// RequestRejected is published when a resource request is rejected.
+	RequestRejected Type = "request.rejected"
+	// ResourceProvisioned is published when provisioning completes successfully.
+	ResourceProvisioned Type = "resource.provisioned"
+	// ResourceProvisioningFailed is published when provisioning fails.
+	ResourceProvisioningFailed Type = "resource.provisioning_failed"
+	// NodeConfigCreated is published when a node configuration is created.
+	NodeConfigCreated Type = "node_config.created"
+	// NodeConfigStatusChanged is published when a node configuration changes status.
+	NodeConfigStatusChanged Type = "node_config.status_changed"
+	// NodeConfigRetryRequested is published when a failed node configuration is queued for
+	// another provisioning attempt.
+	NodeConfigRetryRequested Type = "node_config.retry_requested"
+	// IPPoolUtilizationHigh is published when the share of an IP pool's addresses in use
+	// reaches the configured alert threshold. It is published again only after usage has
+	// dropped back below the threshold.
+	IPPoolUtilizationHigh Type = "ip_pool.utilization_high"
+)
+
+// Event is a domain event delivered to subscribers.
+type Event struct {
+	Type         Type
+	OccurredAt   time.Time
+	ActorID      string
+	RequestID    string
+	ResourceID   string
+	NodeConfigID string
+	Data         map[string]interface{}
+}
+
+// Handler reacts to a published event.
+type Handler func(ctx context.Context, event Event)
+
+type subscriber struct {
+	handler Handler
+	async   bool
+}
+
+// Bus dispatches published events to the subscribers registered for their type.
+// A nil *Bus is valid and drops every event.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[Type][]subscriber
+	wg          sync.WaitGroup
+	logger      *zap.Logger
+}
+
+// NewBus creates a new event bus.
+func NewBus(logger *zap.Logger) *Bus {
+	return &Bus{
+		subscribers: make(map[Type][]subscriber),
+		logger:      logger,
+	}
+}
+
+// Subscribe registers a handler that runs on the publisher's goroutine.
+func (b *Bus) Subscribe(eventType Type, handler Handler) {
+	b.subscribe(eventType, subscriber{handler: handler})
+}
+
+// SubscribeAsync registers a handler that runs on its own goroutine, so a slow
+// handler does not delay the publisher.
+func (b *Bus) SubscribeAsync(eventType Type, handler Handler) {
+	b.subscribe(eventType, subscriber{handler: handler, async: true})
+}
+
+func (b *Bus) subscribe(eventType Type, sub subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[eventType] = append(b.subscribers[eventType], sub)
+}
+
+// Publish delivers the event to its subscribers. Synchronous handlers finish
+// before Publish returns; asynchronous handlers outlive the caller's context.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	b.mu.RLock()
+	subs := b.subscribers[event.Type]
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if !sub.async {
+			b.dispatch(ctx, sub.handler, event)
+			continue
+		}
+		b.wg.Add(1)
+		go func(handler Handler) { //nolint:contextcheck // async handlers must not be cancelled with the request
+			defer b.wg.Done()
+			b.dispatch(context.WithoutCancel(ctx), handler, event)
+		}(sub.handler)
+	}
+}
+
+// Wait blocks until all running asynchronous handlers have returned.
+func (b *Bus) Wait() {
+	if b == nil {
+		return
+	}
+	b.wg.Wait()
+}
+
+// dispatch runs a handler, recovering from panics so one subscriber cannot
+// break the publisher or other subscribers.
+func (b *Bus) dispatch(ctx context.Context, handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("event handler panicked",
+				zap.String("event", string(event.Type)),
+				zap.Any("panic", r),
+			)
+		}
+	}()
+	handler(ctx, event)
+}
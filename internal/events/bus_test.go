@@ -0,0 +1,103 @@
+// Package events provides event bus tests.
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestBus_PublishDeliversToSubscribers(t *testing.T) {
+	bus := NewBus(zap.NewNop())
+
+	var mu sync.Mutex
+	var received []Event
+	record := func(_ context.Context, event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, event)
+	}
+	bus.Subscribe(RequestApproved, record)
+	bus.SubscribeAsync(RequestApproved, record)
+	bus.Subscribe(RequestRejected, func(context.Context, Event) {
+		t.Error("rejected handler must not receive approved events")
+	})
+
+	bus.Publish(context.Background(), Event{Type: RequestApproved, RequestID: "req-1"})
+	bus.Wait()
+
+	require.Len(t, received, 2)
+	for _, event := range received {
+		assert.Equal(t, "req-1", event.RequestID)
+		assert.False(t, event.OccurredAt.IsZero())
+	}
+}
+
+func TestBus_SlowAsyncSubscriberDoesNotBlockPublisher(t *testing.T) {
+	bus := NewBus(zap.NewNop())
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	bus.SubscribeAsync(ResourceProvisioned, func(context.Context, Event) {
+		<-release
+		close(done)
+	})
+
+	published := make(chan struct{})
+	go func() {
+		bus.Publish(context.Background(), Event{Type: ResourceProvisioned})
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked on a slow async subscriber")
+	}
+
+	close(release)
+	bus.Wait()
+	<-done
+}
+
+func TestBus_AsyncHandlerOutlivesCancelledContext(t *testing.T) {
+	bus := NewBus(zap.NewNop())
+
+	ctxErr := make(chan error, 1)
+	bus.SubscribeAsync(RequestCreated, func(ctx context.Context, _ Event) {
+		ctxErr <- ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bus.Publish(ctx, Event{Type: RequestCreated})
+	cancel()
+	bus.Wait()
+
+	assert.NoError(t, <-ctxErr)
+}
+
+func TestBus_PanickingSubscriberIsRecovered(t *testing.T) {
+	bus := NewBus(zap.NewNop())
+
+	called := false
+	bus.Subscribe(RequestCreated, func(context.Context, Event) { panic("boom") })
+	bus.Subscribe(RequestCreated, func(context.Context, Event) { called = true })
+
+	assert.NotPanics(t, func() {
+		bus.Publish(context.Background(), Event{Type: RequestCreated})
+	})
+	assert.True(t, called)
+}
+
+func TestBus_NilBusDropsEvents(t *testing.T) {
+	var bus *Bus
+	assert.NotPanics(t, func() {
+		bus.Publish(context.Background(), Event{Type: RequestCreated})
+		bus.Wait()
+	})
+}
@@ -0,0 +1,88 @@
+// Package handler provides HTTP request handlers.
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// artifactDownloadPath is the signed download route for a node config's artifacts.
+const artifactDownloadPath = "/api/v1/artifacts/node-configs/%s"
+
+// ArtifactHandler handles provisioning artifact download requests.
+type ArtifactHandler struct {
+	artifactService service.ArtifactService
+	logger          *zap.Logger
+}
+
+// NewArtifactHandler creates a new artifact handler.
+func NewArtifactHandler(artifactService service.ArtifactService, logger *zap.Logger) *ArtifactHandler {
+	return &ArtifactHandler{
+		artifactService: artifactService,
+		logger:          logger,
+	}
+}
+
+// CreateDownloadURL handles signing a short-lived URL that downloads a node config's
+// generated terraform files and plan without further authentication.
+func (h *ArtifactHandler) CreateDownloadURL(c *gin.Context) {
+	download, err := h.artifactService.SignDownload(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to sign artifact download")
+		return
+	}
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(download.Expires, 10))
+	query.Set("signature", download.Signature)
+	c.JSON(http.StatusOK, gin.H{
+		"url":        fmt.Sprintf(artifactDownloadPath, url.PathEscape(download.NodeConfigID)) + "?" + query.Encode(),
+		"expires_at": time.Unix(download.Expires, 0).UTC(),
+	})
+}
+
+// Download handles a signed artifact download, serving the archive as a zip file.
+func (h *ArtifactHandler) Download(c *gin.Context) {
+	id := c.Param("id")
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": service.ErrInvalidArtifactSignature.Error()})
+		return
+	}
+
+	archive, err := h.artifactService.DownloadArchive(c.Request.Context(), id, expires, c.Query("signature"))
+	if err != nil {
+		h.respondError(c, err, "Failed to package artifacts")
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "node-config-" + id + "-artifacts.zip"}))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// respondError maps artifact service errors to HTTP responses.
+func (h *ArtifactHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidArtifactSignature), errors.Is(err, service.ErrArtifactURLExpired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Node config not found"})
+	case errors.Is(err, terraform.ErrWorkDirNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Terraform work directory no longer exists"})
+	default:
+		h.logger.Error(message, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
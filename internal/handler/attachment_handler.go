@@ -0,0 +1,144 @@
+// Package handler provides HTTP request handlers.
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AttachmentHandler handles resource request attachment requests.
+type AttachmentHandler struct {
+	attachmentService service.AttachmentService
+	logger            *zap.Logger
+}
+
+// NewAttachmentHandler creates a new attachment handler.
+func NewAttachmentHandler(attachmentService service.AttachmentService, logger *zap.Logger) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+		logger:            logger,
+	}
+}
+
+// List lists the attachments of a resource request.
+func (h *AttachmentHandler) List(c *gin.Context) {
+	attachments, err := h.attachmentService.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to list attachments")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// AddLinkRequest represents a request to attach a link.
+type AddLinkRequest struct {
+	Name string `json:"name"`
+	URL  string `json:"url" binding:"required"`
+}
+
+// AddLink attaches a link to a resource request.
+func (h *AttachmentHandler) AddLink(c *gin.Context) {
+	var req AddLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attachment, err := h.attachmentService.AddLink(c.Request.Context(), &service.AddLinkAttachmentInput{
+		RequestID: c.Param("id"),
+		Name:      req.Name,
+		URL:       req.URL,
+		AddedByID: getUserID(c),
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to add attachment")
+		return
+	}
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// AddFile attaches an uploaded file (multipart field "file") to a resource request.
+func (h *AttachmentHandler) AddFile(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read uploaded file"})
+		return
+	}
+	defer file.Close() //nolint:errcheck // read-only upload
+
+	name := c.PostForm("name")
+	if name == "" {
+		name = header.Filename
+	}
+
+	attachment, err := h.attachmentService.AddFile(c.Request.Context(), &service.AddFileAttachmentInput{
+		RequestID: c.Param("id"),
+		Name:      name,
+		Content:   file,
+		AddedByID: getUserID(c),
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to add attachment")
+		return
+	}
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// Download streams the content of a file attachment.
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	attachment, content, err := h.attachmentService.Open(c.Request.Context(), c.Param("id"), c.Param("attachment_id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to open attachment")
+		return
+	}
+	defer content.Close() //nolint:errcheck // read-only blob
+
+	// Always download rather than render inline so uploaded content cannot run in the app's origin
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, content); err != nil {
+		h.logger.Warn("failed to stream attachment", zap.String("attachment_id", attachment.ID), zap.Error(err))
+	}
+}
+
+// Remove deletes an attachment from a resource request.
+func (h *AttachmentHandler) Remove(c *gin.Context) {
+	if err := h.attachmentService.Remove(c.Request.Context(), c.Param("id"), c.Param("attachment_id")); err != nil {
+		h.respondError(c, err, "Failed to remove attachment")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment removed successfully"})
+}
+
+// respondError maps attachment service errors to HTTP responses.
+func (h *AttachmentHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment or request not found"})
+	case errors.Is(err, service.ErrAttachmentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAttachmentTypeDenied):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidAttachment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(message, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -2,7 +2,12 @@
 package handler
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
@@ -43,6 +48,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	tokens, err := h.authService.Login(c.Request.Context(), req.Username, req.Password, clientIP)
 	if err != nil {
 		h.logger.Warn("login failed", zap.String("username", sanitize.Username(req.Username)), zap.Error(err))
+		if errors.Is(err, service.ErrLDAPUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Directory service is unavailable"})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
@@ -106,3 +115,82 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully logged out"})
 }
+
+// ssoStateCookie holds the state and nonce of an SSO login between its start and the
+// provider's callback.
+const ssoStateCookie = "vc_sso_state"
+
+// ssoStateMaxAge is how long, in seconds, a user has to complete an SSO login.
+const ssoStateMaxAge = 600
+
+// SSOLogin starts an SSO login by redirecting to the OIDC provider.
+func (h *AuthHandler) SSOLogin(c *gin.Context) {
+	state, errState := randomToken()
+	nonce, errNonce := randomToken()
+	if errState != nil || errNonce != nil {
+		h.logger.Error("failed to generate SSO state", zap.Error(errors.Join(errState, errNonce)))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start SSO login"})
+		return
+	}
+
+	authURL, err := h.authService.SSOAuthURL(c.Request.Context(), state, nonce)
+	if err != nil {
+		if errors.Is(err, service.ErrSSODisabled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "SSO is not enabled"})
+			return
+		}
+		h.logger.Error("failed to start SSO login", zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "SSO provider is unavailable"})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoStateCookie, state+"."+nonce, ssoStateMaxAge, "/", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// SSOCallback completes an SSO login from the OIDC provider's redirect and returns the
+// platform's tokens.
+func (h *AuthHandler) SSOCallback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		h.logger.Warn("SSO provider returned an error", zap.String("error", sanitize.ForLog(providerErr)))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "SSO login was not completed"})
+		return
+	}
+
+	cookie, err := c.Cookie(ssoStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoStateCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+	state, nonce, ok := strings.Cut(cookie, ".")
+	if err != nil || !ok || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired SSO state"})
+		return
+	}
+
+	tokens, err := h.authService.SSOLogin(c.Request.Context(), c.Query("code"), nonce, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrSSODisabled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "SSO is not enabled"})
+			return
+		}
+		h.logger.Warn("SSO login failed", zap.Error(err))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "SSO login failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_at":    tokens.ExpiresAt,
+		"token_type":    tokens.TokenType,
+	})
+}
+
+// randomToken returns 32 random bytes, base64url encoded.
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
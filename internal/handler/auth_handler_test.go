@@ -3,14 +3,17 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
 func init() {
@@ -298,3 +301,58 @@ func TestResourceHandler_List(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
 }
+
+// stubSSOAuthService answers SSO logins and records the nonce it was given.
+type stubSSOAuthService struct {
+	service.AuthService
+	gotNonce string
+}
+
+func (s *stubSSOAuthService) SSOLogin(_ context.Context, code, nonce, _ string) (*service.TokenPair, error) {
+	s.gotNonce = nonce
+	if code != "good-code" {
+		return nil, service.ErrSSOLoginFailed
+	}
+	return &service.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"}, nil
+}
+
+func TestAuthHandler_SSOCallback(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		cookie         string
+		expectedStatus int
+	}{
+		{name: "valid state", query: "?state=state-1&code=good-code", cookie: "state-1.nonce-1", expectedStatus: http.StatusOK},
+		{name: "missing state cookie", query: "?state=state-1&code=good-code", expectedStatus: http.StatusBadRequest},
+		{name: "state mismatch", query: "?state=other&code=good-code", cookie: "state-1.nonce-1", expectedStatus: http.StatusBadRequest},
+		{name: "code rejected", query: "?state=state-1&code=bad-code", cookie: "state-1.nonce-1", expectedStatus: http.StatusUnauthorized},
+		{name: "provider error", query: "?error=access_denied&state=state-1", cookie: "state-1.nonce-1", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &stubSSOAuthService{}
+			router := gin.New()
+			router.GET("/auth/oidc/callback", NewAuthHandler(svc, zap.NewNop()).SSOCallback)
+
+			req := httptest.NewRequest("GET", "/auth/oidc/callback"+tt.query, http.NoBody)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: ssoStateCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "nonce-1", svc.gotNonce)
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, "access", body["access_token"])
+			assert.Equal(t, "refresh", body["refresh_token"])
+		})
+	}
+}
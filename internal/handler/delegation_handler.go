@@ -0,0 +1,94 @@
+// Package handler provides HTTP request handlers.
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DelegationHandler handles approval delegation requests.
+type DelegationHandler struct {
+	delegationService service.DelegationService
+	logger            *zap.Logger
+}
+
+// NewDelegationHandler creates a new delegation handler.
+func NewDelegationHandler(delegationService service.DelegationService, logger *zap.Logger) *DelegationHandler {
+	return &DelegationHandler{
+		delegationService: delegationService,
+		logger:            logger,
+	}
+}
+
+// List lists the delegations the current user granted or received.
+func (h *DelegationHandler) List(c *gin.Context) {
+	delegations, err := h.delegationService.ListForUser(c.Request.Context(), getUserID(c))
+	if err != nil {
+		h.respondError(c, err, "Failed to list delegations")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"delegations": delegations})
+}
+
+// CreateDelegationRequest represents a request to delegate the current user's approval
+// authority.
+type CreateDelegationRequest struct {
+	ToUserID string    `json:"to_user_id" binding:"required"`
+	Scope    string    `json:"scope"` // Environment name; empty or "*" for every environment
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason"`
+}
+
+// Create delegates the current user's approval authority to another user.
+func (h *DelegationHandler) Create(c *gin.Context) {
+	var req CreateDelegationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	delegation, err := h.delegationService.Create(c.Request.Context(), &service.CreateDelegationInput{
+		FromUserID: getUserID(c),
+		ToUserID:   req.ToUserID,
+		Scope:      req.Scope,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		Reason:     req.Reason,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to create delegation")
+		return
+	}
+	c.JSON(http.StatusCreated, delegation)
+}
+
+// Delete revokes a delegation the current user granted.
+func (h *DelegationHandler) Delete(c *gin.Context) {
+	if err := h.delegationService.Delete(c.Request.Context(), c.Param("id"), getUserID(c)); err != nil {
+		h.respondError(c, err, "Failed to delete delegation")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Delegation revoked successfully"})
+}
+
+// respondError maps delegation service errors to HTTP responses.
+func (h *DelegationHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delegation not found"})
+	case errors.Is(err, service.ErrNotAuthorizedToApprove):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only users who can approve requests can delegate"})
+	case errors.Is(err, service.ErrInvalidDelegation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(message, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
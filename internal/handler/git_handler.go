@@ -3,12 +3,15 @@ package handler
 
 import (
 	"errors"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -66,6 +69,9 @@ type CreateGitRepoRequest struct {
 	BasePath    string `json:"base_path"`
 	Description string `json:"description"`
 	IsDefault   bool   `json:"is_default"`
+	Environment string `json:"environment" binding:"omitempty,oneof=dev test staging prod"`
+	// WebhookSecret signs push webhooks sent to /git/webhook/:repo_id.
+	WebhookSecret string `json:"webhook_secret"`
 }
 
 // CreateRepository handles creating a git repository.
@@ -77,19 +83,25 @@ func (h *GitHandler) CreateRepository(c *gin.Context) {
 	}
 
 	repo, err := h.gitService.CreateRepository(c.Request.Context(), &service.CreateGitRepoInput{
-		Name:        req.Name,
-		Type:        model.GitRepoType(req.Type),
-		URL:         req.URL,
-		Branch:      req.Branch,
-		AuthType:    model.GitAuthType(req.AuthType),
-		Username:    req.Username,
-		Token:       req.Token,
-		SSHKey:      req.SSHKey,
-		BasePath:    req.BasePath,
-		Description: req.Description,
-		IsDefault:   req.IsDefault,
+		Name:          req.Name,
+		Type:          model.GitRepoType(req.Type),
+		URL:           req.URL,
+		Branch:        req.Branch,
+		AuthType:      model.GitAuthType(req.AuthType),
+		Username:      req.Username,
+		Token:         req.Token,
+		SSHKey:        req.SSHKey,
+		BasePath:      req.BasePath,
+		Description:   req.Description,
+		IsDefault:     req.IsDefault,
+		Environment:   req.Environment,
+		WebhookSecret: req.WebhookSecret,
 	})
 	if err != nil {
+		if errors.Is(err, service.ErrWebhookKeyMissing) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to create git repository", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -133,6 +145,9 @@ type UpdateGitRepoRequest struct {
 	Description *string `json:"description"`
 	Status      *int8   `json:"status"`
 	IsDefault   *bool   `json:"is_default"`
+	Environment *string `json:"environment" binding:"omitempty,oneof=dev test staging prod"`
+	// WebhookSecret replaces the push webhook secret; an empty string disables the webhook.
+	WebhookSecret *string `json:"webhook_secret"`
 }
 
 // UpdateRepository handles updating a git repository.
@@ -157,23 +172,29 @@ func (h *GitHandler) UpdateRepository(c *gin.Context) {
 	}
 
 	repo, err := h.gitService.UpdateRepository(c.Request.Context(), id, &service.UpdateGitRepoInput{
-		Name:        req.Name,
-		URL:         req.URL,
-		Branch:      req.Branch,
-		AuthType:    authType,
-		Username:    req.Username,
-		Token:       req.Token,
-		SSHKey:      req.SSHKey,
-		BasePath:    req.BasePath,
-		Description: req.Description,
-		Status:      req.Status,
-		IsDefault:   req.IsDefault,
+		Name:          req.Name,
+		URL:           req.URL,
+		Branch:        req.Branch,
+		AuthType:      authType,
+		Username:      req.Username,
+		Token:         req.Token,
+		SSHKey:        req.SSHKey,
+		BasePath:      req.BasePath,
+		Description:   req.Description,
+		Status:        req.Status,
+		IsDefault:     req.IsDefault,
+		Environment:   req.Environment,
+		WebhookSecret: req.WebhookSecret,
 	})
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Repository not found"})
 			return
 		}
+		if errors.Is(err, service.ErrWebhookKeyMissing) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to update git repository", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update repository"})
 		return
@@ -213,13 +234,44 @@ func (h *GitHandler) TestConnection(c *gin.Context) {
 
 	if err := h.gitService.TestConnection(c.Request.Context(), id); err != nil {
 		h.logger.Error("git connection test failed", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(cloneErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Connection successful"})
 }
 
+// ValidateStorageRepo handles checking a storage repository's terragrunt layout.
+func (h *GitHandler) ValidateStorageRepo(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Repository ID required"})
+		return
+	}
+
+	result, err := h.gitService.ValidateStorageRepo(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Repository not found"})
+			return
+		}
+		h.logger.Error("storage repository validation failed", zap.Error(err))
+		c.JSON(cloneErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// cloneErrorStatus returns the status for an error from an operation that clones a
+// repository: 504 when the clone timed out, 400 for anything else git rejected.
+func cloneErrorStatus(err error) int {
+	if errors.Is(err, service.ErrGitCloneTimeout) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadRequest
+}
+
 // TestConnectionDirectRequest represents a direct connection test request.
 type TestConnectionDirectRequest struct {
 	URL      string `json:"url" binding:"required"`
@@ -247,7 +299,7 @@ func (h *GitHandler) TestConnectionDirect(c *gin.Context) {
 		SSHKey:   req.SSHKey,
 	}); err != nil {
 		h.logger.Error("git connection test failed", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(cloneErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -350,6 +402,10 @@ func (h *GitHandler) CommitNodeConfig(c *gin.Context) {
 
 	commitSHA, err := h.gitService.CommitNodeConfig(c.Request.Context(), id, req.Message)
 	if err != nil {
+		if errors.Is(err, service.ErrSecretInSpec) || errors.Is(err, service.ErrInvalidNodeFile) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to commit node config", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -361,6 +417,176 @@ func (h *GitHandler) CommitNodeConfig(c *gin.Context) {
 	})
 }
 
+// PreviewNodeConfigRequest describes a resource request to render a node config for. The
+// module is either a registered one (tf_module_id) or given inline by its source.
+type PreviewNodeConfigRequest struct {
+	Title         string                 `json:"title" binding:"required,min=1,max=200"`
+	Type          string                 `json:"type" binding:"required,oneof=vm container bare_metal"`
+	Environment   string                 `json:"environment" binding:"required,oneof=dev test staging prod"`
+	Provider      string                 `json:"provider" binding:"required,oneof=pve vmware openstack aws aliyun gcp azure"`
+	TfModuleID    *string                `json:"tf_module_id"`
+	ModuleSource  string                 `json:"module_source"`
+	ModuleVersion string                 `json:"module_version"`
+	Spec          string                 `json:"spec"`
+	VarOverrides  map[string]string      `json:"var_overrides"`
+	Dependencies  []model.NodeDependency `json:"dependencies"`
+}
+
+// PreviewNodeConfig handles rendering the terragrunt.hcl and path a request would be given,
+// without saving or committing anything.
+func (h *GitHandler) PreviewNodeConfig(c *gin.Context) {
+	var req PreviewNodeConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request := &model.ResourceRequest{
+		Title:        req.Title,
+		Type:         req.Type,
+		Environment:  req.Environment,
+		Provider:     req.Provider,
+		TfModuleID:   req.TfModuleID,
+		Spec:         req.Spec,
+		VarOverrides: req.VarOverrides,
+		Dependencies: req.Dependencies,
+	}
+	if req.ModuleSource != "" {
+		request.TfModuleID = nil
+		request.TfModule = &model.TerraformModule{Source: req.ModuleSource, Version: req.ModuleVersion}
+	}
+
+	preview, err := h.gitService.PreviewNodeConfig(c.Request.Context(), request)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidVarOverride), errors.Is(err, service.ErrSecretInSpec),
+			errors.Is(err, service.ErrInvalidModuleInputs), errors.Is(err, sanitize.ErrInvalidGitRef),
+			errors.Is(err, service.ErrInvalidDependency):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Terraform module not found"})
+		case errors.Is(err, service.ErrConfigPathCollision):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to preview node config", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview node config"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// DiffNodeConfig handles getting the change to a node's terragrunt.hcl from its pending
+// commit to its deployed commit.
+func (h *GitHandler) DiffNodeConfig(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Node config ID required"})
+		return
+	}
+
+	diff, err := h.gitService.DiffNodeConfig(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Node config not found"})
+		case errors.Is(err, service.ErrNodeConfigNotCommitted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrCommitNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrGitCloneTimeout):
+			c.JSON(http.StatusGatewayTimeout, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to diff node config", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to diff node config"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// ListNodeConfigHistory handles listing the commits that changed a node's config, newest
+// first.
+func (h *GitHandler) ListNodeConfigHistory(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Node config ID required"})
+		return
+	}
+
+	page := parseInt(c.DefaultQuery("page", "1"), 1)
+	pageSize := parseInt(c.DefaultQuery("page_size", "20"), constants.DefaultPageSize)
+	if pageSize > constants.MaxPageSize {
+		pageSize = constants.MaxPageSize
+	}
+
+	commits, total, err := h.gitService.ListNodeConfigHistory(c.Request.Context(), id, page, pageSize)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Node config not found"})
+		case errors.Is(err, service.ErrGitCloneTimeout):
+			c.JSON(http.StatusGatewayTimeout, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to list node config history", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list node config history"})
+		}
+		return
+	}
+
+	totalPages := (int(total) + pageSize - 1) / pageSize
+	c.JSON(http.StatusOK, gin.H{
+		"commits":     commits,
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": totalPages,
+	})
+}
+
+// RetryFailedNodeConfigsRequest selects the failed node configs to retry. All fields are optional.
+type RetryFailedNodeConfigsRequest struct {
+	StorageRepoID string `json:"storage_repo_id"`
+	Provider      string `json:"provider"`
+	FailedFor     string `json:"failed_for"` // Go duration, e.g. "30m"; only configs failed at least this long
+	Limit         int    `json:"limit"`
+}
+
+// RetryFailedNodeConfigs handles re-queueing failed node configurations for provisioning.
+func (h *GitHandler) RetryFailedNodeConfigs(c *gin.Context) {
+	var req RetryFailedNodeConfigsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var failedFor time.Duration
+	if req.FailedFor != "" {
+		d, err := time.ParseDuration(req.FailedFor)
+		if err != nil || d < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed_for must be a non-negative duration such as 30m"})
+			return
+		}
+		failedFor = d
+	}
+
+	summary, err := h.gitService.RetryFailedNodeConfigs(c.Request.Context(), service.NodeConfigRetryFilter{
+		StorageRepoID: req.StorageRepoID,
+		Provider:      req.Provider,
+		FailedFor:     failedFor,
+		Limit:         req.Limit,
+	})
+	if err != nil {
+		h.logger.Error("failed to retry node configs", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry node configs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
 // ListModulesFromGit handles listing Terraform modules from the default modules git repository.
 func (h *GitHandler) ListModulesFromGit(c *gin.Context) {
 	modules, err := h.gitService.ListModulesFromGit(c.Request.Context())
@@ -410,3 +636,48 @@ func (h *GitHandler) SyncModulesFromGit(c *gin.Context) {
 		"message": "Modules synced successfully",
 	})
 }
+
+// ClearModulesCache handles removing the cached clones of modules repositories, so the next
+// module scan clones afresh.
+func (h *GitHandler) ClearModulesCache(c *gin.Context) {
+	removed, err := h.gitService.ClearModulesCache(c.Request.Context())
+	if err != nil {
+		h.logger.Error("failed to clear modules cache", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear modules cache"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"removed": removed, "message": "Modules cache cleared"})
+}
+
+// ReceiveWebhook handles a push webhook from a git host for the repository in the path. The
+// delivery must carry an X-Hub-Signature-256 made with the repository's webhook secret; push
+// events to the default modules repository's branch resync modules.
+func (h *GitHandler) ReceiveWebhook(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, constants.GitWebhookMaxBodySize)
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read payload"})
+		return
+	}
+
+	result, err := h.gitService.HandleWebhook(c.Request.Context(), c.Param("repo_id"), &service.GitWebhookDelivery{
+		Event:     c.GetHeader("X-GitHub-Event"),
+		Signature: c.GetHeader("X-Hub-Signature-256"),
+		Body:      body,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidWebhookSignature), errors.Is(err, service.ErrWebhookReplayed):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidWebhookPayload):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to handle git webhook", zap.String("repo_id", sanitize.ForLog(c.Param("repo_id"))), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to handle webhook"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -28,11 +29,19 @@ func NewInfraHandler(infraService service.InfraService, logger *zap.Logger) *Inf
 	}
 }
 
+// infraListOptions reads the listing options of a region or zone list request: preload=false
+// leaves out the related regions or zones.
+func infraListOptions(c *gin.Context) repository.InfraListOptions {
+	return repository.InfraListOptions{SkipPreload: c.Query("preload") == constants.QueryFalse}
+}
+
 // ListRegions handles listing regions.
 func (h *InfraHandler) ListRegions(c *gin.Context) {
+	opts := infraListOptions(c)
+
 	// Check if requesting all regions (for dropdowns)
 	if c.Query("all") == constants.QueryTrue {
-		regions, err := h.infraService.ListAllRegions(c.Request.Context())
+		regions, err := h.infraService.ListAllRegions(c.Request.Context(), opts)
 		if err != nil {
 			h.logger.Error("failed to list all regions", zap.Error(err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list regions"})
@@ -48,7 +57,7 @@ func (h *InfraHandler) ListRegions(c *gin.Context) {
 		pageSize = constants.MaxPageSize
 	}
 
-	regions, total, err := h.infraService.ListRegions(c.Request.Context(), page, pageSize)
+	regions, total, err := h.infraService.ListRegions(c.Request.Context(), page, pageSize, opts)
 	if err != nil {
 		h.logger.Error("failed to list regions", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list regions"})
@@ -182,10 +191,12 @@ func (h *InfraHandler) DeleteRegion(c *gin.Context) {
 
 // ListZones handles listing zones.
 func (h *InfraHandler) ListZones(c *gin.Context) {
+	opts := infraListOptions(c)
+
 	// Check if filtering by region
 	regionID := c.Query("region_id")
 	if regionID != "" {
-		zones, err := h.infraService.ListZonesByRegion(c.Request.Context(), regionID)
+		zones, err := h.infraService.ListZonesByRegion(c.Request.Context(), regionID, opts)
 		if err != nil {
 			h.logger.Error("failed to list zones by region", zap.Error(err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list zones"})
@@ -201,7 +212,7 @@ func (h *InfraHandler) ListZones(c *gin.Context) {
 		pageSize = constants.MaxPageSize
 	}
 
-	zones, total, err := h.infraService.ListZones(c.Request.Context(), page, pageSize)
+	zones, total, err := h.infraService.ListZones(c.Request.Context(), page, pageSize, opts)
 	if err != nil {
 		h.logger.Error("failed to list zones", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list zones"})
@@ -731,6 +742,14 @@ func (h *InfraHandler) CreateModule(c *gin.Context) {
 		Variables:   req.Variables,
 	})
 	if err != nil {
+		if errors.Is(err, service.ErrModuleSourceExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, sanitize.ErrInvalidGitRef) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to create module", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -802,6 +821,10 @@ func (h *InfraHandler) UpdateModule(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Module not found"})
 			return
 		}
+		if errors.Is(err, sanitize.ErrInvalidGitRef) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to update module", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update module"})
 		return
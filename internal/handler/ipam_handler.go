@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
 	"github.com/gin-gonic/gin"
@@ -30,7 +31,13 @@ func NewIPAMHandler(ipamService service.IPAMService, logger *zap.Logger) *IPAMHa
 func (h *IPAMHandler) ListIPPools(c *gin.Context) {
 	// Check if requesting all for dropdowns
 	if c.Query("all") == constants.QueryTrue {
-		pools, _, err := h.ipamService.ListPools(c.Request.Context(), "", 1, constants.MaxPageSize)
+		pools, _, err := h.ipamService.ListPools(c.Request.Context(), service.IPPoolFilters{
+			NetworkType: c.Query("network_type"),
+		}, 1, constants.MaxPageSize)
+		if errors.Is(err, service.ErrInvalidNetworkType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
 			h.logger.Error("failed to list IP pools", zap.Error(err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list IP pools"})
@@ -40,15 +47,22 @@ func (h *IPAMHandler) ListIPPools(c *gin.Context) {
 		return
 	}
 
-	zoneID := c.Query("zone_id")
+	filters := service.IPPoolFilters{
+		ZoneID:      c.Query("zone_id"),
+		NetworkType: c.Query("network_type"),
+	}
 	page := parseInt(c.DefaultQuery("page", "1"), 1)
 	pageSize := parseInt(c.DefaultQuery("page_size", "20"), constants.DefaultPageSize)
 	if pageSize > constants.MaxPageSize {
 		pageSize = constants.MaxPageSize
 	}
 
-	pools, total, err := h.ipamService.ListPools(c.Request.Context(), zoneID, page, pageSize)
+	pools, total, err := h.ipamService.ListPools(c.Request.Context(), filters, page, pageSize)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidNetworkType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to list IP pools", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list IP pools"})
 		return
@@ -90,6 +104,22 @@ func (h *IPAMHandler) GetIPPool(c *gin.Context) {
 	})
 }
 
+// GetIPPoolUsage lists the allocations and resources that depend on an IP pool.
+func (h *IPAMHandler) GetIPPoolUsage(c *gin.Context) {
+	usage, err := h.ipamService.GetPoolUsage(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "IP pool not found"})
+			return
+		}
+		h.logger.Error("failed to get IP pool usage", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get IP pool usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
 // CreateIPPoolRequest represents an IP pool creation request.
 type CreateIPPoolRequest struct {
 	Name        string `json:"name" binding:"required,min=1,max=64"`
@@ -101,7 +131,11 @@ type CreateIPPoolRequest struct {
 	EndIP       string `json:"end_ip" binding:"required"`
 	ZoneID      string `json:"zone_id" binding:"required"`
 	NetworkType string `json:"network_type"`
+	Bridge      string `json:"bridge"`
 	Description string `json:"description"`
+	IsDefault   bool   `json:"is_default"`
+
+	VerifyBeforeAllocate bool `json:"verify_before_allocate"`
 }
 
 // CreateIPPool handles creating an IP pool.
@@ -122,10 +156,18 @@ func (h *IPAMHandler) CreateIPPool(c *gin.Context) {
 		EndIP:       req.EndIP,
 		ZoneID:      req.ZoneID,
 		NetworkType: req.NetworkType,
+		Bridge:      req.Bridge,
 		Description: req.Description,
+		IsDefault:   req.IsDefault,
+
+		VerifyBeforeAllocate: req.VerifyBeforeAllocate,
 	})
 	if err != nil {
 		h.logger.Error("failed to create IP pool", zap.Error(err))
+		if errors.Is(err, service.ErrPoolOverlap) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -139,8 +181,12 @@ type UpdateIPPoolRequest struct {
 	Gateway     *string `json:"gateway"`
 	DNS         *string `json:"dns"`
 	VLANTag     *int    `json:"vlan_tag"`
+	Bridge      *string `json:"bridge"`
 	Description *string `json:"description"`
 	Status      *int8   `json:"status"`
+	IsDefault   *bool   `json:"is_default"`
+
+	VerifyBeforeAllocate *bool `json:"verify_before_allocate"`
 }
 
 // UpdateIPPool handles updating an IP pool.
@@ -157,8 +203,12 @@ func (h *IPAMHandler) UpdateIPPool(c *gin.Context) {
 		Gateway:     req.Gateway,
 		DNS:         req.DNS,
 		VLANTag:     req.VLANTag,
+		Bridge:      req.Bridge,
 		Description: req.Description,
 		Status:      req.Status,
+		IsDefault:   req.IsDefault,
+
+		VerifyBeforeAllocate: req.VerifyBeforeAllocate,
 	})
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
@@ -197,7 +247,99 @@ func (h *IPAMHandler) ListIPAllocations(c *gin.Context) {
 		pageSize = constants.MaxPageSize
 	}
 
-	allocations, total, err := h.ipamService.ListAllocations(c.Request.Context(), poolID, page, pageSize)
+	allocations, total, err := h.ipamService.ListAllocations(c.Request.Context(), service.IPAllocationFilters{PoolID: poolID}, page, pageSize)
+	if err != nil {
+		h.logger.Error("failed to list IP allocations", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list IP allocations"})
+		return
+	}
+
+	totalPages := (int(total) + pageSize - 1) / pageSize
+	c.JSON(http.StatusOK, gin.H{
+		"allocations": allocations,
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": totalPages,
+	})
+}
+
+// ReserveIPRangeRequest represents a request to reserve a range of a pool's addresses.
+type ReserveIPRangeRequest struct {
+	StartIP string `json:"start_ip" binding:"required"`
+	EndIP   string `json:"end_ip" binding:"required"`
+	Note    string `json:"note"`
+}
+
+// ReserveIPRange handles reserving a range of a pool's addresses for static infrastructure.
+func (h *IPAMHandler) ReserveIPRange(c *gin.Context) {
+	var req ReserveIPRangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.ipamService.ReserveRange(c.Request.Context(), c.Param("id"), req.StartIP, req.EndIP, req.Note); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "IP pool not found"})
+		case errors.Is(err, repository.ErrIPInUse), errors.Is(err, repository.ErrTransactionConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrInvalidRange), errors.Is(err, repository.ErrIPOutOfRange):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to reserve IP range", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reserve IP range"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "IP range reserved successfully"})
+}
+
+// ListIPReservations handles listing the reserved addresses of an IP pool.
+func (h *IPAMHandler) ListIPReservations(c *gin.Context) {
+	page := parseInt(c.DefaultQuery("page", "1"), 1)
+	pageSize := parseInt(c.DefaultQuery("page_size", "50"), constants.DefaultPageSize)
+	if pageSize > constants.MaxPageSize {
+		pageSize = constants.MaxPageSize
+	}
+
+	filters := service.IPAllocationFilters{PoolID: c.Param("id"), Status: string(model.IPStatusReserved)}
+	reservations, total, err := h.ipamService.ListAllocations(c.Request.Context(), filters, page, pageSize)
+	if err != nil {
+		h.logger.Error("failed to list IP reservations", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list IP reservations"})
+		return
+	}
+
+	totalPages := (int(total) + pageSize - 1) / pageSize
+	c.JSON(http.StatusOK, gin.H{
+		"reservations": reservations,
+		"total":        total,
+		"page":         page,
+		"page_size":    pageSize,
+		"total_pages":  totalPages,
+	})
+}
+
+// ListAllIPAllocations handles listing IP allocations across all pools.
+func (h *IPAMHandler) ListAllIPAllocations(c *gin.Context) {
+	page := parseInt(c.DefaultQuery("page", "1"), 1)
+	pageSize := parseInt(c.DefaultQuery("page_size", "50"), constants.DefaultPageSize)
+	if pageSize > constants.MaxPageSize {
+		pageSize = constants.MaxPageSize
+	}
+
+	filters := service.IPAllocationFilters{
+		PoolID:     c.Query("pool_id"),
+		Status:     c.Query("status"),
+		Hostname:   c.Query("hostname"),
+		ResourceID: c.Query("resource_id"),
+		ZoneID:     c.Query("zone_id"),
+	}
+
+	allocations, total, err := h.ipamService.ListAllocations(c.Request.Context(), filters, page, pageSize)
 	if err != nil {
 		h.logger.Error("failed to list IP allocations", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list IP allocations"})
@@ -216,10 +358,12 @@ func (h *IPAMHandler) ListIPAllocations(c *gin.Context) {
 
 // AllocateIPRequest represents an IP allocation request.
 type AllocateIPRequest struct {
-	PoolID     string `json:"pool_id" binding:"required"`
-	Hostname   string `json:"hostname"`
-	ResourceID string `json:"resource_id"`
-	IPAddress  string `json:"ip_address"` // Optional: specific IP to allocate
+	PoolID      string `json:"pool_id" binding:"required_without=ZoneID"`
+	ZoneID      string `json:"zone_id"`      // Allocate from any matching pool in the zone when pool_id is empty
+	NetworkType string `json:"network_type"` // Required with zone_id
+	Hostname    string `json:"hostname"`
+	ResourceID  string `json:"resource_id"`
+	IPAddress   string `json:"ip_address"` // Optional: specific IP to allocate
 }
 
 // AllocateIP handles allocating an IP address from a pool.
@@ -230,13 +374,32 @@ func (h *IPAMHandler) AllocateIP(c *gin.Context) {
 		return
 	}
 
-	allocation, err := h.ipamService.AllocateIP(c.Request.Context(), &service.AllocateIPInput{
-		PoolID:     req.PoolID,
-		Hostname:   req.Hostname,
-		ResourceID: req.ResourceID,
-		IPAddress:  req.IPAddress,
-	})
+	var allocation *model.IPAllocation
+	var err error
+	if req.PoolID == "" {
+		allocation, err = h.ipamService.AllocateIPInZone(c.Request.Context(), &service.AllocateIPInZoneInput{
+			ZoneID:      req.ZoneID,
+			NetworkType: req.NetworkType,
+			Hostname:    req.Hostname,
+			ResourceID:  req.ResourceID,
+		})
+	} else {
+		allocation, err = h.ipamService.AllocateIP(c.Request.Context(), &service.AllocateIPInput{
+			PoolID:     req.PoolID,
+			Hostname:   req.Hostname,
+			ResourceID: req.ResourceID,
+			IPAddress:  req.IPAddress,
+		})
+	}
 	if err != nil {
+		if respondPoolExhausted(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrHostnameTaken) || errors.Is(err, repository.ErrIPInUse) ||
+			errors.Is(err, repository.ErrTransactionConflict) || errors.Is(err, repository.ErrAddressesAnswering) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to allocate IP", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -245,6 +408,23 @@ func (h *IPAMHandler) AllocateIP(c *gin.Context) {
 	c.JSON(http.StatusCreated, allocation)
 }
 
+// respondPoolExhausted writes a 409 with the pool usage behind an exhausted-pool error, so
+// callers can tell whether to expand the pool or release addresses. It reports whether err
+// was handled.
+func respondPoolExhausted(c *gin.Context, err error) bool {
+	var zoneErr *service.ZoneExhaustedError
+	if errors.As(err, &zoneErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": zoneErr.Error(), "details": zoneErr})
+		return true
+	}
+	var poolErr *repository.PoolExhaustedError
+	if errors.As(err, &poolErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": poolErr.Error(), "details": poolErr})
+		return true
+	}
+	return false
+}
+
 // ReleaseIP handles releasing an allocated IP address.
 func (h *IPAMHandler) ReleaseIP(c *gin.Context) {
 	id := c.Param("id")
@@ -260,6 +440,47 @@ func (h *IPAMHandler) ReleaseIP(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "IP released successfully"})
 }
 
+// ReallocateIPRequest represents a request to move an allocation to a new IP.
+type ReallocateIPRequest struct {
+	IPAddress string `json:"ip_address"` // Optional: empty picks the next available IP
+}
+
+// ReallocateIP handles moving an allocation to a new IP address.
+func (h *IPAMHandler) ReallocateIP(c *gin.Context) {
+	id := c.Param("id")
+	var req ReallocateIPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	allocation, err := h.ipamService.ReallocateIP(c.Request.Context(), id, req.IPAddress)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "IP allocation not found"})
+			return
+		}
+		if errors.Is(err, repository.ErrIPInUse) || errors.Is(err, repository.ErrTransactionConflict) ||
+			errors.Is(err, repository.ErrAddressesAnswering) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if respondPoolExhausted(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidIPAddress) || errors.Is(err, repository.ErrIPOutOfRange) ||
+			errors.Is(err, repository.ErrNotAllocated) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("failed to reallocate IP", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reallocate IP"})
+		return
+	}
+
+	c.JSON(http.StatusOK, allocation)
+}
+
 // GetAllocationsByResource handles getting IP allocations for a resource.
 func (h *IPAMHandler) GetAllocationsByResource(c *gin.Context) {
 	resourceID := c.Param("resource_id")
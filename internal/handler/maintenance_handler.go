@@ -0,0 +1,45 @@
+// Package handler provides HTTP request handlers.
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MaintenanceHandler handles maintenance mode requests.
+type MaintenanceHandler struct {
+	maintenanceService service.MaintenanceService
+	logger             *zap.Logger
+}
+
+// NewMaintenanceHandler creates a new maintenance handler.
+func NewMaintenanceHandler(maintenanceService service.MaintenanceService, logger *zap.Logger) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceService: maintenanceService,
+		logger:             logger,
+	}
+}
+
+// SetMaintenanceRequest represents the request body for toggling maintenance mode.
+type SetMaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// GetMaintenance handles reporting whether maintenance mode is enabled.
+func (h *MaintenanceHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, h.maintenanceService.Status())
+}
+
+// SetMaintenance handles turning maintenance mode on or off.
+func (h *MaintenanceHandler) SetMaintenance(c *gin.Context) {
+	var req SetMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.maintenanceService.SetEnabled(c.Request.Context(), *req.Enabled))
+}
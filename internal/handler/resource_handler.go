@@ -6,8 +6,10 @@ import (
 	"net/http"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -25,6 +27,19 @@ func getUserID(c *gin.Context) string {
 	return id
 }
 
+// getUserRoles safely extracts the user's role codes from context.
+func getUserRoles(c *gin.Context) []string {
+	roles, ok := c.Get("roles")
+	if !ok {
+		return nil
+	}
+	codes, ok := roles.([]string)
+	if !ok {
+		return nil
+	}
+	return codes
+}
+
 // ResourceHandler handles resource management requests.
 type ResourceHandler struct {
 	resourceService service.ResourceService
@@ -74,12 +89,52 @@ func (h *ResourceHandler) List(c *gin.Context) {
 	})
 }
 
+// Search handles resource search. q is matched against names, hostnames, IP addresses,
+// tags and spec values; the list filters narrow the results.
+func (h *ResourceHandler) Search(c *gin.Context) {
+	page := parseInt(c.DefaultQuery("page", "1"), 1)
+	pageSize := parseInt(c.DefaultQuery("page_size", "20"), constants.DefaultPageSize)
+
+	if pageSize > constants.MaxPageSize {
+		pageSize = constants.MaxPageSize
+	}
+
+	filters := service.ResourceFilters{
+		Type:        c.Query("type"),
+		Provider:    c.Query("provider"),
+		Status:      c.Query("status"),
+		Environment: c.Query("environment"),
+		OwnerID:     c.Query("owner_id"),
+	}
+
+	resources, total, err := h.resourceService.SearchResources(c.Request.Context(), c.Query("q"), filters, page, pageSize)
+	if err != nil {
+		if errors.Is(err, service.ErrEmptySearchQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("failed to search resources", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search resources"})
+		return
+	}
+
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
+
+	c.JSON(http.StatusOK, gin.H{
+		"resources":   resources,
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": totalPages,
+	})
+}
+
 // CreateResourceRequest represents a resource creation request.
 type CreateResourceRequest struct {
 	Name        string `json:"name" binding:"required,min=1,max=100"`
 	Type        string `json:"type" binding:"required,oneof=vm container bare_metal"`
-	Provider    string `json:"provider" binding:"required,oneof=pve vmware openstack aws aliyun"`
-	Environment string `json:"environment" binding:"required,oneof=dev test staging prod"`
+	Provider    string `json:"provider" binding:"omitempty,oneof=pve vmware openstack aws aliyun"` // Falls back to the user's default
+	Environment string `json:"environment" binding:"omitempty,oneof=dev test staging prod"`        // Falls back to the user's default
 	Spec        string `json:"spec"`
 	Description string `json:"description"`
 }
@@ -108,6 +163,14 @@ func (h *ResourceHandler) Create(c *gin.Context) {
 		OwnerID:     userIDStr,
 	})
 	if err != nil {
+		if errors.Is(err, service.ErrMissingDefault) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrResourceNameInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to create resource", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create resource"})
 		return
@@ -158,6 +221,14 @@ func (h *ResourceHandler) Update(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
 			return
 		}
+		if errors.Is(err, service.ErrImmutableField) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrResourceNameInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to update resource", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update resource"})
 		return
@@ -179,6 +250,10 @@ func (h *ResourceHandler) Delete(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
 			return
 		}
+		if errors.Is(err, repository.ErrResourceInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Resource is provisioning or running"})
+			return
+		}
 		h.logger.Error("failed to delete resource", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete resource"})
 		return
@@ -187,6 +262,33 @@ func (h *ResourceHandler) Delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// BulkDeleteBody represents the request body for deleting several records at once.
+type BulkDeleteBody struct {
+	IDs []string `json:"ids" binding:"required,min=1"`
+}
+
+// BulkDelete handles deleting several resources, reporting the outcome per resource.
+func (h *ResourceHandler) BulkDelete(c *gin.Context) {
+	var body BulkDeleteBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	result, err := h.resourceService.BulkDelete(c.Request.Context(), body.IDs)
+	if err != nil {
+		if errors.Is(err, service.ErrBulkDeleteLimit) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("failed to bulk delete resources", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete resources"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // ListRequests handles listing resource requests.
 func (h *ResourceHandler) ListRequests(c *gin.Context) {
 	page := parseInt(c.DefaultQuery("page", "1"), 1)
@@ -222,18 +324,20 @@ func (h *ResourceHandler) ListRequests(c *gin.Context) {
 
 // CreateRequestRequest represents a resource request creation.
 type CreateRequestRequest struct {
-	Title        string  `json:"title" binding:"required,min=1,max=200"`
-	Description  string  `json:"description"`
-	Type         string  `json:"type" binding:"required,oneof=vm container bare_metal"`
-	Environment  string  `json:"environment" binding:"required,oneof=dev test staging prod"`
-	Provider     string  `json:"provider" binding:"required,oneof=pve vmware openstack aws aliyun gcp azure"`
-	RegionID     *string `json:"region_id"`
-	ZoneID       *string `json:"zone_id"`
-	TfProviderID *string `json:"tf_provider_id"` // Selected Terraform provider
-	TfModuleID   *string `json:"tf_module_id"`   // Selected Terraform module
-	CredentialID *string `json:"credential_id"`  // Selected credential for access
-	Spec         string  `json:"spec"`
-	Quantity     int     `json:"quantity"`
+	Title        string                 `json:"title" binding:"required,min=1,max=200"`
+	Description  string                 `json:"description"`
+	Type         string                 `json:"type" binding:"required,oneof=vm container bare_metal"`
+	Environment  string                 `json:"environment" binding:"omitempty,oneof=dev test staging prod"`                  // Falls back to the user's default
+	Provider     string                 `json:"provider" binding:"omitempty,oneof=pve vmware openstack aws aliyun gcp azure"` // Falls back to the user's default
+	RegionID     *string                `json:"region_id"`
+	ZoneID       *string                `json:"zone_id"`        // Falls back to the user's default when no region is chosen
+	TfProviderID *string                `json:"tf_provider_id"` // Selected Terraform provider
+	TfModuleID   *string                `json:"tf_module_id"`   // Selected Terraform module
+	CredentialID *string                `json:"credential_id"`  // Selected credential for access
+	Spec         string                 `json:"spec"`
+	VarOverrides map[string]string      `json:"var_overrides"` // Module variables set beyond the spec
+	Dependencies []model.NodeDependency `json:"dependencies"`  // Sibling nodes whose outputs feed module inputs
+	Quantity     int                    `json:"quantity"`
 }
 
 // CreateRequest handles resource request creation.
@@ -256,21 +360,30 @@ func (h *ResourceHandler) CreateRequest(c *gin.Context) {
 	}
 
 	request, err := h.resourceService.CreateRequest(c.Request.Context(), &service.CreateRequestInput{
-		Title:        req.Title,
-		Description:  req.Description,
-		Type:         req.Type,
-		Environment:  req.Environment,
-		Provider:     req.Provider,
-		RegionID:     req.RegionID,
-		ZoneID:       req.ZoneID,
-		TfProviderID: req.TfProviderID,
-		TfModuleID:   req.TfModuleID,
-		CredentialID: req.CredentialID,
-		Spec:         req.Spec,
-		Quantity:     quantity,
-		RequesterID:  userIDStr,
+		Title:          req.Title,
+		Description:    req.Description,
+		Type:           req.Type,
+		Environment:    req.Environment,
+		Provider:       req.Provider,
+		RegionID:       req.RegionID,
+		ZoneID:         req.ZoneID,
+		TfProviderID:   req.TfProviderID,
+		TfModuleID:     req.TfModuleID,
+		CredentialID:   req.CredentialID,
+		Spec:           req.Spec,
+		VarOverrides:   req.VarOverrides,
+		Dependencies:   req.Dependencies,
+		Quantity:       quantity,
+		RequesterID:    userIDStr,
+		RequesterRoles: getUserRoles(c),
 	})
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidVarOverride) || errors.Is(err, service.ErrQuantityExceeded) ||
+			errors.Is(err, service.ErrSecretInSpec) || errors.Is(err, service.ErrMissingDefault) ||
+			errors.Is(err, service.ErrInvalidDependency) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to create request", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
 		return
@@ -301,6 +414,56 @@ func (h *ResourceHandler) GetRequest(c *gin.Context) {
 	c.JSON(http.StatusOK, request)
 }
 
+// GetRequestHistory handles listing a request's status transitions.
+func (h *ResourceHandler) GetRequestHistory(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request ID required"})
+		return
+	}
+
+	history, err := h.resourceService.GetRequestHistory(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
+			return
+		}
+		h.logger.Error("failed to get request history", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get request history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+// GetRequestOutputsRaw handles fetching a completed request's terraform outputs as the raw
+// `output -json` document. Sensitive values are redacted.
+func (h *ResourceHandler) GetRequestOutputsRaw(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request ID required"})
+		return
+	}
+
+	outputs, err := h.resourceService.GetRequestOutputsRaw(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
+		case errors.Is(err, terraform.ErrWorkDirNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Terraform work directory no longer exists"})
+		case errors.Is(err, service.ErrInvalidRequestStatus):
+			c.JSON(http.StatusConflict, gin.H{"error": "Outputs are only available for completed requests"})
+		default:
+			h.logger.Error("failed to get request outputs", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get request outputs"})
+		}
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", outputs)
+}
+
 // ApproveRequestBody represents an approval request body.
 type ApproveRequestBody struct {
 	Reason string `json:"reason"`
@@ -336,6 +499,18 @@ func (h *ResourceHandler) ApproveRequest(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Request cannot be approved"})
 			return
 		}
+		if errors.Is(err, service.ErrNotAuthorizedToApprove) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to approve requests"})
+			return
+		}
+		if errors.Is(err, service.ErrResourceBusy) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Resource is busy with another operation"})
+			return
+		}
+		if errors.Is(err, repository.ErrTransactionConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Request is being updated concurrently, try again"})
+			return
+		}
 		h.logger.Error("failed to approve request", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve request"})
 		return
@@ -411,6 +586,10 @@ func (h *ResourceHandler) RetryRequest(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Only failed requests can be retried"})
 			return
 		}
+		if errors.Is(err, service.ErrResourceBusy) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Resource is busy with another operation"})
+			return
+		}
 		h.logger.Error("failed to retry request", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry request"})
 		return
@@ -439,6 +618,10 @@ func (h *ResourceHandler) DeleteRequest(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
 			return
 		}
+		if errors.Is(err, service.ErrInvalidRequestStatus) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to delete request", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -446,3 +629,32 @@ func (h *ResourceHandler) DeleteRequest(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Request deleted successfully"})
 }
+
+// BulkDeleteRequests handles deleting several resource requests, reporting the outcome per
+// request.
+func (h *ResourceHandler) BulkDeleteRequests(c *gin.Context) {
+	userIDStr := getUserID(c)
+	if userIDStr == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	var body BulkDeleteBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	result, err := h.resourceService.BulkDeleteRequests(c.Request.Context(), body.IDs, userIDStr)
+	if err != nil {
+		if errors.Is(err, service.ErrBulkDeleteLimit) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("failed to bulk delete requests", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,98 @@
+// Package handler provides HTTP request handlers.
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ResourceImportHandler handles importing existing provider instances as resources.
+type ResourceImportHandler struct {
+	importService service.ResourceImportService
+	logger        *zap.Logger
+}
+
+// NewResourceImportHandler creates a new resource import handler.
+func NewResourceImportHandler(importService service.ResourceImportService, logger *zap.Logger) *ResourceImportHandler {
+	return &ResourceImportHandler{
+		importService: importService,
+		logger:        logger,
+	}
+}
+
+// ImportInstanceRequest describes one instance in an imported provider inventory.
+type ImportInstanceRequest struct {
+	ID        string                 `json:"id" binding:"required"`
+	Name      string                 `json:"name" binding:"required"`
+	IPAddress string                 `json:"ip_address"`
+	Hostname  string                 `json:"hostname"`
+	Spec      map[string]interface{} `json:"spec"`
+}
+
+// ImportResourcesRequest represents a request to import the inventory of a provider zone.
+type ImportResourcesRequest struct {
+	Provider        string                  `json:"provider" binding:"required"`
+	ZoneID          string                  `json:"zone_id" binding:"required"`
+	Environment     string                  `json:"environment" binding:"required"`
+	Type            string                  `json:"type"`
+	Instances       []ImportInstanceRequest `json:"instances" binding:"required,min=1,dive"`
+	TerraformImport bool                    `json:"terraform_import"`
+	CredentialID    string                  `json:"credential_id"`
+}
+
+// Import records the instances of a provider inventory as resources owned by the current
+// user, reporting the outcome per instance.
+func (h *ResourceImportHandler) Import(c *gin.Context) {
+	userIDStr := getUserID(c)
+	if userIDStr == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+
+	var req ImportResourcesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	instances := make([]service.ImportInstance, 0, len(req.Instances))
+	for _, instance := range req.Instances {
+		instances = append(instances, service.ImportInstance{
+			ExternalID: instance.ID,
+			Name:       instance.Name,
+			IPAddress:  instance.IPAddress,
+			Hostname:   instance.Hostname,
+			Spec:       instance.Spec,
+		})
+	}
+
+	result, err := h.importService.Import(c.Request.Context(), &service.ImportResourcesInput{
+		Provider:        req.Provider,
+		ZoneID:          req.ZoneID,
+		Environment:     req.Environment,
+		Type:            req.Type,
+		OwnerID:         userIDStr,
+		Instances:       instances,
+		TerraformImport: req.TerraformImport,
+		CredentialID:    req.CredentialID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImportLimit), errors.Is(err, service.ErrInvalidImportInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		default:
+			h.logger.Error("failed to import resources", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import resources"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
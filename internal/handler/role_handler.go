@@ -2,10 +2,12 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
 	"github.com/gin-gonic/gin"
@@ -170,3 +172,62 @@ func (h *RoleHandler) Delete(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// RolePermissionsRequest names the permissions to add, remove or set on a role.
+type RolePermissionsRequest struct {
+	PermissionIDs []string `json:"permission_ids"`
+}
+
+// ListPermissions handles listing a role's permissions.
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.roleService.ListPermissions(c.Request.Context(), c.Param("id"))
+	h.respondPermissions(c, permissions, err)
+}
+
+// AddPermissions handles granting permissions to a role.
+func (h *RoleHandler) AddPermissions(c *gin.Context) {
+	h.changePermissions(c, h.roleService.AddPermissions)
+}
+
+// RemovePermissions handles revoking permissions from a role.
+func (h *RoleHandler) RemovePermissions(c *gin.Context) {
+	h.changePermissions(c, h.roleService.RemovePermissions)
+}
+
+// SetPermissions handles replacing a role's permissions.
+func (h *RoleHandler) SetPermissions(c *gin.Context) {
+	h.changePermissions(c, h.roleService.SetPermissions)
+}
+
+// changePermissions binds the permission IDs, applies change and responds with the role's
+// resulting permissions.
+func (h *RoleHandler) changePermissions(c *gin.Context, change func(ctx context.Context, id string, permissionIDs []string) ([]*model.Permission, error)) {
+	var req RolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	permissions, err := change(c.Request.Context(), c.Param("id"), req.PermissionIDs)
+	h.respondPermissions(c, permissions, err)
+}
+
+func (h *RoleHandler) respondPermissions(c *gin.Context, permissions []*model.Permission, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		case errors.Is(err, repository.ErrUnknownPermission):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to update role permissions", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role permissions"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"permissions": permissions,
+		"total":       len(permissions),
+	})
+}
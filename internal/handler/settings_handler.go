@@ -4,30 +4,41 @@
 package handler
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 // SettingsHandler handles settings-related HTTP requests.
 type SettingsHandler struct {
 	settingsService service.SettingsService
+	auditRepo       repository.AuditRepository // Records each reveal of a credential's secrets
 	logger          *zap.Logger
 }
 
 // NewSettingsHandler creates a new settings handler.
-func NewSettingsHandler(settingsService service.SettingsService, logger *zap.Logger) *SettingsHandler {
+func NewSettingsHandler(settingsService service.SettingsService, auditRepo repository.AuditRepository, logger *zap.Logger) *SettingsHandler {
 	return &SettingsHandler{
 		settingsService: settingsService,
+		auditRepo:       auditRepo,
 		logger:          logger,
 	}
 }
 
+// ListSupportedProviders handles listing the provider types the platform supports and
+// their capabilities.
+func (h *SettingsHandler) ListSupportedProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": constants.SupportedProviders()})
+}
+
 // CreateProviderRequest represents the request body for creating a provider.
 type CreateProviderRequest struct {
 	Name         string `json:"name" binding:"required,min=1,max=128"`
@@ -47,6 +58,8 @@ type UpdateProviderRequest struct {
 	Config      *string `json:"config"`
 	Status      *int8   `json:"status"`
 	IsDefault   *bool   `json:"is_default"`
+	// CredentialID links a credential of the provider's type; an empty string unlinks it.
+	CredentialID *string `json:"credential_id"`
 }
 
 // ListProviders lists all providers.
@@ -56,9 +69,12 @@ func (h *SettingsHandler) ListProviders(c *gin.Context) {
 	if pageSize > constants.MaxPageSize {
 		pageSize = constants.MaxPageSize
 	}
-	providerType := c.Query("type")
+	filters := service.SettingsFilters{
+		Type:   c.Query("type"),
+		ZoneID: c.Query("zone_id"),
+	}
 
-	providers, total, err := h.settingsService.ListProviders(c.Request.Context(), providerType, page, pageSize)
+	providers, total, err := h.settingsService.ListProviders(c.Request.Context(), filters, page, pageSize)
 	if err != nil {
 		h.logger.Error("failed to list providers", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list providers"})
@@ -93,6 +109,10 @@ func (h *SettingsHandler) CreateProvider(c *gin.Context) {
 		CredentialID: req.CredentialID,
 	})
 	if err != nil {
+		if errors.Is(err, service.ErrCredentialTypeMismatch) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to create provider", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create provider"})
 		return
@@ -123,6 +143,22 @@ func (h *SettingsHandler) GetProvider(c *gin.Context) {
 	c.JSON(http.StatusOK, provider)
 }
 
+// GetProviderUsage lists what depends on a provider.
+func (h *SettingsHandler) GetProviderUsage(c *gin.Context) {
+	usage, err := h.settingsService.GetProviderUsage(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Provider not found"})
+			return
+		}
+		h.logger.Error("failed to get provider usage", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get provider usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
 // UpdateProvider updates a provider.
 func (h *SettingsHandler) UpdateProvider(c *gin.Context) {
 	id := c.Param("id")
@@ -138,18 +174,23 @@ func (h *SettingsHandler) UpdateProvider(c *gin.Context) {
 	}
 
 	provider, err := h.settingsService.UpdateProvider(c.Request.Context(), id, &service.UpdateProviderInput{
-		Name:        req.Name,
-		Endpoint:    req.Endpoint,
-		Description: req.Description,
-		Config:      req.Config,
-		Status:      req.Status,
-		IsDefault:   req.IsDefault,
+		Name:         req.Name,
+		Endpoint:     req.Endpoint,
+		Description:  req.Description,
+		Config:       req.Config,
+		Status:       req.Status,
+		IsDefault:    req.IsDefault,
+		CredentialID: req.CredentialID,
 	})
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Provider not found"})
 			return
 		}
+		if errors.Is(err, service.ErrCredentialTypeMismatch) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to update provider", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update provider"})
 		return
@@ -185,6 +226,7 @@ type TestProviderConnectionRequest struct {
 	Endpoint     string `json:"endpoint" binding:"required,url"`
 	CredentialID string `json:"credential_id"`
 	Config       string `json:"config"`
+	Force        bool   `json:"force"` // Bypass the cached result of a recent test
 }
 
 // TestProviderConnection tests a provider connection.
@@ -200,9 +242,10 @@ func (h *SettingsHandler) TestProviderConnection(c *gin.Context) {
 		Endpoint:     req.Endpoint,
 		CredentialID: req.CredentialID,
 		Config:       req.Config,
+		Force:        req.Force,
 	}); err != nil {
 		h.logger.Error("failed to test provider connection", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		connectionTestFailed(c, err)
 		return
 	}
 
@@ -216,6 +259,7 @@ type TestCredentialConnectionRequest struct {
 	AccessKey string `json:"access_key"`
 	SecretKey string `json:"secret_key"`
 	Token     string `json:"token"`
+	Force     bool   `json:"force"` // Bypass the cached result of a recent test
 }
 
 // TestCredentialConnection tests a credential connection.
@@ -232,15 +276,27 @@ func (h *SettingsHandler) TestCredentialConnection(c *gin.Context) {
 		AccessKey: req.AccessKey,
 		SecretKey: req.SecretKey,
 		Token:     req.Token,
+		Force:     req.Force,
 	}); err != nil {
 		h.logger.Error("failed to test credential connection", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		connectionTestFailed(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Authentication successful"})
 }
 
+// connectionTestFailed responds to a failed connection test. When the endpoint was
+// contacted, code tells a DNS, TLS, authentication or server failure apart.
+func connectionTestFailed(c *gin.Context, err error) {
+	var connErr *service.ConnectionError
+	if errors.As(err, &connErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": connErr.Kind})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
 // CreateCredentialRequest represents the request body for creating a credential.
 type CreateCredentialRequest struct {
 	Name        string  `json:"name" binding:"required,min=1,max=128"`
@@ -266,6 +322,43 @@ type UpdateCredentialRequest struct {
 	Status      *int8   `json:"status"`
 }
 
+// CredentialResponse is a credential as the API returns it: its secrets are shown masked,
+// and only GET /settings/credentials/:id/secret returns them in full.
+type CredentialResponse struct {
+	*model.Credential
+	SecretKeyMasked string `json:"secret_key_masked"`
+	TokenMasked     string `json:"token_masked"`
+}
+
+// secretVisibleChars is the number of trailing characters a masked secret shows.
+const secretVisibleChars = 4
+
+// secretMinMaskedLength is the length below which a masked secret shows none of its
+// characters, as the last few would give away too much of it.
+const secretMinMaskedLength = 12
+
+// maskSecret returns secret with all but its last characters replaced, or an empty string
+// when there is no secret.
+func maskSecret(secret string) string {
+	runes := []rune(secret)
+	if len(runes) == 0 {
+		return ""
+	}
+	if len(runes) < secretMinMaskedLength {
+		return "********"
+	}
+	return "********" + string(runes[len(runes)-secretVisibleChars:])
+}
+
+// newCredentialResponse returns credential with its secrets masked.
+func newCredentialResponse(credential *model.Credential) CredentialResponse {
+	return CredentialResponse{
+		Credential:      credential,
+		SecretKeyMasked: maskSecret(credential.SecretKey),
+		TokenMasked:     maskSecret(credential.Token),
+	}
+}
+
 // ListCredentials lists all credentials.
 func (h *SettingsHandler) ListCredentials(c *gin.Context) {
 	page := parseInt(c.DefaultQuery("page", "1"), 1)
@@ -273,18 +366,26 @@ func (h *SettingsHandler) ListCredentials(c *gin.Context) {
 	if pageSize > constants.MaxPageSize {
 		pageSize = constants.MaxPageSize
 	}
-	credentialType := c.Query("type")
+	filters := service.SettingsFilters{
+		Type:   c.Query("type"),
+		ZoneID: c.Query("zone_id"),
+	}
 
-	credentials, total, err := h.settingsService.ListCredentials(c.Request.Context(), credentialType, page, pageSize)
+	credentials, total, err := h.settingsService.ListCredentials(c.Request.Context(), filters, page, pageSize)
 	if err != nil {
 		h.logger.Error("failed to list credentials", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list credentials"})
 		return
 	}
 
+	responses := make([]CredentialResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		responses = append(responses, newCredentialResponse(credential))
+	}
+
 	totalPages := (int(total) + pageSize - 1) / pageSize
 	c.JSON(http.StatusOK, gin.H{
-		"credentials": credentials,
+		"credentials": responses,
 		"total":       total,
 		"page":        page,
 		"page_size":   pageSize,
@@ -319,12 +420,16 @@ func (h *SettingsHandler) CreateCredential(c *gin.Context) {
 		CreatedByID: userID,
 	})
 	if err != nil {
+		if errors.Is(err, service.ErrCredentialTypeMismatch) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("failed to create credential", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create credential"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, credential)
+	c.JSON(http.StatusCreated, newCredentialResponse(credential))
 }
 
 // GetCredential gets a credential by ID.
@@ -346,7 +451,67 @@ func (h *SettingsHandler) GetCredential(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, credential)
+	c.JSON(http.StatusOK, newCredentialResponse(credential))
+}
+
+// RevealCredential returns the secrets of a credential, which its other responses mask. Each
+// reveal is recorded in the audit log before the secrets are returned.
+func (h *SettingsHandler) RevealCredential(c *gin.Context) {
+	id := c.Param("id")
+	secret, err := h.settingsService.RevealCredential(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+			return
+		}
+		h.logger.Error("failed to reveal credential", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reveal credential"})
+		return
+	}
+
+	details, err := json.Marshal(map[string]string{"credential_id": id})
+	if err != nil {
+		h.logger.Error("failed to encode audit details", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reveal credential"})
+		return
+	}
+	auditLog := &model.AuditLog{
+		ID:         uuid.New().String(),
+		UserID:     getUserID(c),
+		Username:   c.GetString("username"),
+		Action:     "reveal_secret",
+		Resource:   "credential",
+		ResourceID: id,
+		Details:    string(details),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		Status:     "success",
+		CreatedAt:  model.Now(),
+	}
+	// The secrets are only returned once the reveal is on record
+	if err := h.auditRepo.Create(c.Request.Context(), auditLog); err != nil {
+		h.logger.Error("failed to audit credential reveal", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reveal credential"})
+		return
+	}
+
+	c.JSON(http.StatusOK, secret)
+}
+
+// GetCredentialUsage lists what depends on a credential.
+func (h *SettingsHandler) GetCredentialUsage(c *gin.Context) {
+	usage, err := h.settingsService.GetCredentialUsage(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+			return
+		}
+		h.logger.Error("failed to get credential usage", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get credential usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
 }
 
 // UpdateCredential updates a credential.
@@ -383,7 +548,7 @@ func (h *SettingsHandler) UpdateCredential(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, credential)
+	c.JSON(http.StatusOK, newCredentialResponse(credential))
 }
 
 // DeleteCredential deletes a credential.
@@ -0,0 +1,162 @@
+// Package handler provides settings handler tests.
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSettingsHandler_ListSupportedProviders(t *testing.T) {
+	h := NewSettingsHandler(nil, nil, zap.NewNop())
+	router := gin.New()
+	router.GET("/providers/supported", h.ListSupportedProviders)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/providers/supported", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Providers []constants.ProviderInfo `json:"providers"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Providers, len(constants.SupportedProviders()))
+
+	var pve *constants.ProviderInfo
+	for i := range body.Providers {
+		if body.Providers[i].Type == constants.ProviderTypePVE {
+			pve = &body.Providers[i]
+		}
+	}
+	require.NotNil(t, pve, "pve is listed")
+	assert.Equal(t, "Proxmox VE", pve.Name)
+	assert.True(t, pve.SupportsTerragrunt)
+	assert.True(t, pve.SupportsRawTerraform)
+	assert.Equal(t, []string{"target_node", "template_name"}, pve.RequiredSpecFields)
+	assert.False(t, pve.RequiresCredential)
+	assert.True(t, pve.ConnectionTest)
+}
+
+// stubCredentialService serves a single credential.
+type stubCredentialService struct {
+	service.SettingsService
+	credential *model.Credential
+}
+
+func (s *stubCredentialService) GetCredential(_ context.Context, id string) (*model.Credential, error) {
+	if id != s.credential.ID {
+		return nil, repository.ErrNotFound
+	}
+	return s.credential, nil
+}
+
+func (s *stubCredentialService) ListCredentials(context.Context, service.SettingsFilters, int, int) ([]*model.Credential, int64, error) {
+	return []*model.Credential{s.credential}, 1, nil
+}
+
+func (s *stubCredentialService) RevealCredential(ctx context.Context, id string) (*service.CredentialSecret, error) {
+	credential, err := s.GetCredential(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &service.CredentialSecret{AccessKey: credential.AccessKey, SecretKey: credential.SecretKey, Token: credential.Token}, nil
+}
+
+// recordingAuditRepository keeps the audit logs created, failing with err when it is set.
+type recordingAuditRepository struct {
+	repository.AuditRepository
+	logs []*model.AuditLog
+	err  error
+}
+
+func (r *recordingAuditRepository) Create(_ context.Context, log *model.AuditLog) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+func TestSettingsHandler_CredentialSecretsAreMasked(t *testing.T) {
+	credential := &model.Credential{
+		BaseModel: model.BaseModel{ID: "cred-1"},
+		Name:      "pve", Type: "pve", AccessKey: "root@pam",
+		SecretKey: "correct-horse-battery-staple", Token: "short-token",
+	}
+	h := NewSettingsHandler(&stubCredentialService{credential: credential}, nil, zap.NewNop())
+	router := gin.New()
+	router.GET("/credentials", h.ListCredentials)
+	router.GET("/credentials/:id", h.GetCredential)
+
+	for _, path := range []string{"/credentials", "/credentials/cred-1"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		body := w.Body.String()
+		for _, raw := range []string{"correct-horse-battery-staple", "short-token", "root@pam"} {
+			assert.NotContains(t, body, raw, "%s leaks a secret", path)
+		}
+		assert.Contains(t, body, `"secret_key_masked":"********aple"`)
+		assert.Contains(t, body, `"token_masked":"********"`, "short secrets show no characters")
+		assert.Contains(t, body, `"name":"pve"`)
+	}
+}
+
+func TestSettingsHandler_RevealCredential(t *testing.T) {
+	credential := &model.Credential{BaseModel: model.BaseModel{ID: "cred-1"}, Name: "pve", Type: "pve", SecretKey: "s3cret"}
+	newRouter := func(audit *recordingAuditRepository) *gin.Engine {
+		h := NewSettingsHandler(&stubCredentialService{credential: credential}, audit, zap.NewNop())
+		router := gin.New()
+		router.GET("/credentials/:id/secret", func(c *gin.Context) {
+			c.Set("user_id", "user-1")
+			c.Set("username", "admin")
+		}, h.RevealCredential)
+		return router
+	}
+
+	t.Run("reveal is audited", func(t *testing.T) {
+		audit := &recordingAuditRepository{}
+		w := httptest.NewRecorder()
+		newRouter(audit).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/credentials/cred-1/secret", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var secret service.CredentialSecret
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &secret))
+		assert.Equal(t, "s3cret", secret.SecretKey)
+		require.Len(t, audit.logs, 1)
+		assert.Equal(t, "reveal_secret", audit.logs[0].Action)
+		assert.Equal(t, "credential", audit.logs[0].Resource)
+		assert.Equal(t, "cred-1", audit.logs[0].ResourceID)
+		assert.Equal(t, "user-1", audit.logs[0].UserID)
+		assert.NotContains(t, audit.logs[0].Details, "s3cret")
+	})
+
+	t.Run("secrets are withheld when the audit log fails", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newRouter(&recordingAuditRepository{err: errors.New("database is down")}).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/credentials/cred-1/secret", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotContains(t, w.Body.String(), "s3cret")
+	})
+
+	t.Run("missing credential", func(t *testing.T) {
+		audit := &recordingAuditRepository{}
+		w := httptest.NewRecorder()
+		newRouter(audit).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/credentials/cred-2/secret", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, audit.logs)
+	})
+}
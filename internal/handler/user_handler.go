@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
 	"github.com/gin-gonic/gin"
@@ -280,6 +281,67 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
 }
 
+// UpdatePreferencesRequest represents a request to replace the current user's defaults.
+type UpdatePreferencesRequest struct {
+	DefaultEnvironment string  `json:"default_environment" binding:"omitempty,oneof=dev test staging prod"`
+	DefaultProvider    string  `json:"default_provider" binding:"omitempty,oneof=pve vmware openstack aws aliyun gcp azure"`
+	DefaultZoneID      *string `json:"default_zone_id"`
+}
+
+// GetPreferences handles getting the current user's default environment, provider and zone.
+func (h *UserHandler) GetPreferences(c *gin.Context) {
+	userIDStr := getUserID(c)
+	if userIDStr == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	prefs, err := h.userService.GetPreferences(c.Request.Context(), userIDStr)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		h.logger.Error("failed to get user preferences", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences handles replacing the current user's defaults. Omitted fields are cleared.
+func (h *UserHandler) UpdatePreferences(c *gin.Context) {
+	userIDStr := getUserID(c)
+	if userIDStr == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, err := h.userService.UpdatePreferences(c.Request.Context(), userIDStr, model.UserPreferences{
+		DefaultEnvironment: req.DefaultEnvironment,
+		DefaultProvider:    req.DefaultProvider,
+		DefaultZoneID:      req.DefaultZoneID,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		h.logger.Error("failed to update user preferences", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
 // parseInt parses a string to int with a default value.
 func parseInt(s string, defaultVal int) int {
 	if v, err := strconv.Atoi(s); err == nil {
@@ -0,0 +1,169 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// BER tags used by the LDAP messages this client sends and reads.
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x30
+	tagSet         = 0x31
+
+	tagBindRequest       = 0x60
+	tagBindResponse      = 0x61
+	tagUnbindRequest     = 0x42
+	tagSearchRequest     = 0x63
+	tagSearchEntry       = 0x64
+	tagSearchDone        = 0x65
+	tagSearchReference   = 0x73
+	tagExtendedResponse  = 0x78
+	tagSimpleAuth        = 0x80
+	tagFilterAnd         = 0xa0
+	tagFilterOr          = 0xa1
+	tagFilterNot         = 0xa2
+	tagFilterEquality    = 0xa3
+	tagFilterSubstrings  = 0xa4
+	tagFilterGreaterOrEq = 0xa5
+	tagFilterLessOrEq    = 0xa6
+	tagFilterPresent     = 0x87
+	tagFilterApprox      = 0xa8
+	tagSubstringInitial  = 0x80
+	tagSubstringAny      = 0x81
+	tagSubstringFinal    = 0x82
+)
+
+// maxMessageSize caps the size of a single message read from the server.
+const maxMessageSize = 16 << 20
+
+// element is a decoded BER tag-length-value.
+type element struct {
+	tag     byte
+	content []byte
+}
+
+// encode returns the BER encoding of tag with the concatenated contents.
+func encode(tag byte, contents ...[]byte) []byte {
+	size := 0
+	for _, c := range contents {
+		size += len(c)
+	}
+	out := append([]byte{tag}, encodeLength(size)...)
+	for _, c := range contents {
+		out = append(out, c...)
+	}
+	return out
+}
+
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+func encodeString(tag byte, s string) []byte {
+	return encode(tag, []byte(s))
+}
+
+// encodeInt encodes n as a minimal two's complement integer.
+func encodeInt(tag byte, n int64) []byte {
+	b := []byte{byte(n)}
+	for n > 127 || n < -128 {
+		n >>= 8
+		b = append([]byte{byte(n)}, b...)
+	}
+	return encode(tag, b)
+}
+
+func encodeBool(v bool) []byte {
+	if v {
+		return encode(tagBoolean, []byte{0xff})
+	}
+	return encode(tagBoolean, []byte{0x00})
+}
+
+// readElement reads one complete element from r.
+func readElement(r *bufio.Reader) (element, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return element{}, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return element{}, err
+	}
+	length := int(first)
+	if first&0x80 != 0 {
+		n := int(first & 0x7f)
+		if n == 0 || n > 4 {
+			return element{}, fmt.Errorf("unsupported BER length of %d bytes", n)
+		}
+		length = 0
+		for range n {
+			b, err := r.ReadByte()
+			if err != nil {
+				return element{}, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxMessageSize {
+		return element{}, fmt.Errorf("message of %d bytes is too large", length)
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return element{}, err
+	}
+	return element{tag: tag, content: content}, nil
+}
+
+// children decodes the elements inside a constructed element's content.
+func children(content []byte) ([]element, error) {
+	var out []element
+	for len(content) > 0 {
+		if len(content) < 2 {
+			return nil, errors.New("truncated BER element")
+		}
+		tag, length, header := content[0], int(content[1]), 2
+		if content[1]&0x80 != 0 {
+			n := int(content[1] & 0x7f)
+			if n == 0 || n > 4 || len(content) < 2+n {
+				return nil, errors.New("invalid BER length")
+			}
+			length = 0
+			for _, b := range content[2 : 2+n] {
+				length = length<<8 | int(b)
+			}
+			header += n
+		}
+		if length < 0 || len(content)-header < length {
+			return nil, errors.New("truncated BER element")
+		}
+		out = append(out, element{tag: tag, content: content[header : header+length]})
+		content = content[header+length:]
+	}
+	return out, nil
+}
+
+// decodeInt decodes a two's complement integer.
+func decodeInt(b []byte) (int64, error) {
+	if len(b) == 0 || len(b) > 8 {
+		return 0, errors.New("invalid BER integer")
+	}
+	n := int64(int8(b[0]))
+	for _, c := range b[1:] {
+		n = n<<8 | int64(c)
+	}
+	return n, nil
+}
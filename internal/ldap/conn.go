@@ -0,0 +1,298 @@
+// Package ldap provides a minimal LDAPv3 client for authenticating users against a
+// directory. It supports simple binds and subtree searches, which is all a login needs.
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidCredentials is returned when the server rejects a bind's DN or password.
+var ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+
+// LDAP result codes this client distinguishes.
+const (
+	resultSuccess            = 0
+	resultSizeLimitExceeded  = 4
+	resultInvalidCredentials = 49
+)
+
+// ResultError is a non-success result returned by the server.
+type ResultError struct {
+	Code    int
+	Message string
+}
+
+func (e *ResultError) Error() string {
+	return fmt.Sprintf("ldap: result code %d: %s", e.Code, e.Message)
+}
+
+// Entry is a directory entry returned by a search.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Values returns the values of the attribute name, matched case-insensitively.
+func (e *Entry) Values(name string) []string {
+	for attr, values := range e.Attributes {
+		if strings.EqualFold(attr, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+// Value returns the first value of the attribute name, or "" when it has none.
+func (e *Entry) Value(name string) string {
+	if values := e.Values(name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// SearchRequest describes a subtree search.
+type SearchRequest struct {
+	BaseDN     string
+	Filter     string // RFC 4515 string filter
+	Attributes []string
+	SizeLimit  int // 0 means no limit
+}
+
+// Conn is a connection to an LDAP server. Operations run one at a time.
+type Conn struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+	msgID   int64
+}
+
+// Dial connects to the server at rawURL, which is ldap://host[:port] or
+// ldaps://host[:port]. timeout bounds the dial and each later operation.
+func Dial(ctx context.Context, rawURL string, timeout time.Duration) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP URL: %w", err)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("invalid LDAP URL %q: no host", rawURL)
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "ldap":
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, portOr(u, "389")))
+	case "ldaps":
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", net.JoinHostPort(host, portOr(u, "636")))
+	default:
+		return nil, fmt.Errorf("unsupported LDAP URL scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP server: %w", err)
+	}
+	return newConn(conn, timeout), nil
+}
+
+func portOr(u *url.URL, port string) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	return port
+}
+
+func newConn(conn net.Conn, timeout time.Duration) *Conn {
+	return &Conn{conn: conn, reader: bufio.NewReader(conn), timeout: timeout}
+}
+
+// Bind authenticates the connection as dn with a simple bind. An empty password is refused
+// without contacting the server, since servers treat it as an unauthenticated bind that
+// succeeds for any DN.
+func (c *Conn) Bind(dn, password string) error {
+	if password == "" {
+		return ErrInvalidCredentials
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, err := c.send(encode(tagBindRequest,
+		encodeInt(tagInteger, 3),
+		encodeString(tagOctetString, dn),
+		encodeString(tagSimpleAuth, password),
+	))
+	if err != nil {
+		return err
+	}
+	op, err := c.receive(id)
+	if err != nil {
+		return err
+	}
+	if op.tag != tagBindResponse {
+		return fmt.Errorf("ldap: unexpected response 0x%02x to bind", op.tag)
+	}
+	if err := parseResult(op.content); err != nil {
+		var resultErr *ResultError
+		if errors.As(err, &resultErr) && resultErr.Code == resultInvalidCredentials {
+			return ErrInvalidCredentials
+		}
+		return err
+	}
+	return nil
+}
+
+// Search runs a subtree search and returns the matching entries. Referrals are ignored.
+func (c *Conn) Search(req SearchRequest) ([]*Entry, error) {
+	filter, err := compileFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make([][]byte, 0, len(req.Attributes))
+	for _, attr := range req.Attributes {
+		attrs = append(attrs, encodeString(tagOctetString, attr))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, err := c.send(encode(tagSearchRequest,
+		encodeString(tagOctetString, req.BaseDN),
+		encodeInt(tagEnumerated, 2), // wholeSubtree
+		encodeInt(tagEnumerated, 0), // neverDerefAliases
+		encodeInt(tagInteger, int64(req.SizeLimit)),
+		encodeInt(tagInteger, int64(c.timeout/time.Second)),
+		encodeBool(false),
+		filter,
+		encode(tagSequence, attrs...),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for {
+		op, err := c.receive(id)
+		if err != nil {
+			return nil, err
+		}
+		switch op.tag {
+		case tagSearchEntry:
+			entry, err := parseEntry(op.content)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		case tagSearchReference:
+		case tagSearchDone:
+			if err := parseResult(op.content); err != nil {
+				var resultErr *ResultError
+				if errors.As(err, &resultErr) && resultErr.Code == resultSizeLimitExceeded {
+					return entries, nil
+				}
+				return nil, err
+			}
+			return entries, nil
+		default:
+			return nil, fmt.Errorf("ldap: unexpected response 0x%02x to search", op.tag)
+		}
+	}
+}
+
+// Close unbinds and closes the connection.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _ = c.send(encode(tagUnbindRequest)) //nolint:errcheck // the connection is closed either way
+	return c.conn.Close()
+}
+
+// send writes a message carrying op and returns its message ID.
+func (c *Conn) send(op []byte) (int64, error) {
+	c.msgID++
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	if _, err := c.conn.Write(encode(tagSequence, encodeInt(tagInteger, c.msgID), op)); err != nil {
+		return 0, fmt.Errorf("ldap: write failed: %w", err)
+	}
+	return c.msgID, nil
+}
+
+// receive reads messages until one answers id and returns its protocol operation.
+func (c *Conn) receive(id int64) (element, error) {
+	for {
+		msg, err := readElement(c.reader)
+		if err != nil {
+			return element{}, fmt.Errorf("ldap: read failed: %w", err)
+		}
+		parts, err := children(msg.content)
+		if err != nil || msg.tag != tagSequence || len(parts) < 2 || parts[0].tag != tagInteger {
+			return element{}, errors.New("ldap: malformed message")
+		}
+		msgID, err := decodeInt(parts[0].content)
+		if err != nil {
+			return element{}, err
+		}
+		if msgID == 0 && parts[1].tag == tagExtendedResponse {
+			return element{}, errors.New("ldap: server closed the connection")
+		}
+		if msgID == id {
+			return parts[1], nil
+		}
+	}
+}
+
+// parseResult returns the error carried by an LDAPResult, or nil on success.
+func parseResult(content []byte) error {
+	parts, err := children(content)
+	if err != nil || len(parts) < 3 || parts[0].tag != tagEnumerated {
+		return errors.New("ldap: malformed result")
+	}
+	code, err := decodeInt(parts[0].content)
+	if err != nil {
+		return err
+	}
+	if code != resultSuccess {
+		return &ResultError{Code: int(code), Message: string(parts[2].content)}
+	}
+	return nil
+}
+
+// parseEntry decodes a SearchResultEntry.
+func parseEntry(content []byte) (*Entry, error) {
+	parts, err := children(content)
+	if err != nil || len(parts) != 2 {
+		return nil, errors.New("ldap: malformed search entry")
+	}
+	attrs, err := children(parts[1].content)
+	if err != nil {
+		return nil, errors.New("ldap: malformed search entry")
+	}
+
+	entry := &Entry{DN: string(parts[0].content), Attributes: make(map[string][]string, len(attrs))}
+	for _, attr := range attrs {
+		fields, err := children(attr.content)
+		if err != nil || len(fields) != 2 {
+			return nil, errors.New("ldap: malformed attribute")
+		}
+		values, err := children(fields[1].content)
+		if err != nil {
+			return nil, errors.New("ldap: malformed attribute")
+		}
+		name := string(fields[0].content)
+		for _, v := range values {
+			entry.Attributes[name] = append(entry.Attributes[name], string(v.content))
+		}
+	}
+	return entry, nil
+}
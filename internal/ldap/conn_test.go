@@ -0,0 +1,157 @@
+// Package ldap provides LDAP client tests.
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUserDN = "uid=jdoe,ou=people,dc=example,dc=com"
+
+// fakeServer answers binds for testUserDN with the password "secret" and returns one entry
+// for every search, recording the encoded filters it was sent.
+type fakeServer struct {
+	filters [][]byte
+}
+
+func (s *fakeServer) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close() //nolint:errcheck // test server
+	r := bufio.NewReader(conn)
+	for {
+		msg, err := readElement(r)
+		if err != nil {
+			return
+		}
+		parts, err := children(msg.content)
+		require.NoError(t, err)
+		id, err := decodeInt(parts[0].content)
+		require.NoError(t, err)
+		op := parts[1]
+
+		switch op.tag {
+		case tagBindRequest:
+			fields, err := children(op.content)
+			require.NoError(t, err)
+			code := int64(resultInvalidCredentials)
+			if string(fields[1].content) == testUserDN && string(fields[2].content) == "secret" {
+				code = resultSuccess
+			}
+			s.reply(t, conn, id, encode(tagBindResponse, result(code)...))
+		case tagSearchRequest:
+			fields, err := children(op.content)
+			require.NoError(t, err)
+			s.filters = append(s.filters, encode(fields[6].tag, fields[6].content))
+			s.reply(t, conn, id, encode(tagSearchEntry,
+				encodeString(tagOctetString, testUserDN),
+				encode(tagSequence,
+					attribute("mail", "jdoe@example.com"),
+					attribute("memberOf", "cn=admins,ou=groups,dc=example,dc=com", "cn=devs,ou=groups,dc=example,dc=com"),
+				),
+			))
+			s.reply(t, conn, id, encode(tagSearchDone, result(resultSuccess)...))
+		case tagUnbindRequest:
+			return
+		}
+	}
+}
+
+func (s *fakeServer) reply(t *testing.T, conn net.Conn, id int64, op []byte) {
+	_, err := conn.Write(encode(tagSequence, encodeInt(tagInteger, id), op))
+	require.NoError(t, err)
+}
+
+func result(code int64) [][]byte {
+	return [][]byte{encodeInt(tagEnumerated, code), encodeString(tagOctetString, ""), encodeString(tagOctetString, "")}
+}
+
+func attribute(name string, values ...string) []byte {
+	encoded := make([][]byte, 0, len(values))
+	for _, v := range values {
+		encoded = append(encoded, encodeString(tagOctetString, v))
+	}
+	return encode(tagSequence, encodeString(tagOctetString, name), encode(tagSet, encoded...))
+}
+
+func newTestConn(t *testing.T) (*Conn, *fakeServer) {
+	t.Helper()
+	client, server := net.Pipe()
+	fake := &fakeServer{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fake.serve(t, server)
+	}()
+	conn := newConn(client, 5*time.Second)
+	t.Cleanup(func() {
+		_ = conn.Close() //nolint:errcheck // test cleanup
+		<-done
+	})
+	return conn, fake
+}
+
+func TestConn_Bind(t *testing.T) {
+	conn, _ := newTestConn(t)
+
+	require.NoError(t, conn.Bind(testUserDN, "secret"))
+	require.ErrorIs(t, conn.Bind(testUserDN, "wrong"), ErrInvalidCredentials)
+	require.ErrorIs(t, conn.Bind(testUserDN, ""), ErrInvalidCredentials, "empty passwords are never sent")
+}
+
+func TestConn_Search(t *testing.T) {
+	conn, fake := newTestConn(t)
+
+	entries, err := conn.Search(SearchRequest{
+		BaseDN:     "dc=example,dc=com",
+		Filter:     "(uid=" + EscapeFilter("jdoe") + ")",
+		Attributes: []string{"mail", "memberOf"},
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, testUserDN, entries[0].DN)
+	assert.Equal(t, "jdoe@example.com", entries[0].Value("MAIL"))
+	assert.Len(t, entries[0].Values("memberof"), 2)
+	assert.Empty(t, entries[0].Value("cn"))
+
+	require.Len(t, fake.filters, 1)
+	want := encode(tagFilterEquality, encodeString(tagOctetString, "uid"), encodeString(tagOctetString, "jdoe"))
+	assert.True(t, bytes.Equal(want, fake.filters[0]))
+}
+
+func TestCompileFilter(t *testing.T) {
+	eq := func(attr, value string) []byte {
+		return encode(tagFilterEquality, encodeString(tagOctetString, attr), encodeString(tagOctetString, value))
+	}
+
+	got, err := compileFilter("(&(objectClass=person)(!(uid=a\\2ab))(|(cn=x)(mail=*)))")
+	require.NoError(t, err)
+	want := encode(tagFilterAnd,
+		eq("objectClass", "person"),
+		encode(tagFilterNot, eq("uid", "a*b")),
+		encode(tagFilterOr, eq("cn", "x"), encodeString(tagFilterPresent, "mail")),
+	)
+	assert.Equal(t, want, got)
+
+	got, err = compileFilter("(cn=jo*n*)")
+	require.NoError(t, err)
+	want = encode(tagFilterSubstrings, encodeString(tagOctetString, "cn"), encode(tagSequence,
+		encodeString(tagSubstringInitial, "jo"),
+		encodeString(tagSubstringAny, "n"),
+	))
+	assert.Equal(t, want, got)
+
+	for _, invalid := range []string{"uid=jdoe", "(uid=jdoe", "(&)", "(=jdoe)", "(uid:dn:=jdoe)", "(uid=a\\2)", "(uid=a)(cn=b)"} {
+		_, err := compileFilter(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestEscapeFilter(t *testing.T) {
+	assert.Equal(t, "jdoe", EscapeFilter("jdoe"))
+	assert.Equal(t, "\\2a\\28\\29\\5c\\00", EscapeFilter("*()\\\x00"))
+}
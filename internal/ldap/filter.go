@@ -0,0 +1,170 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// EscapeFilter escapes s for use as a value in a search filter (RFC 4515).
+func EscapeFilter(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '*', '(', ')', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// compileFilter encodes a string search filter such as "(&(objectClass=person)(uid=jdoe))".
+// Extensible matches are not supported.
+func compileFilter(filter string) ([]byte, error) {
+	encoded, rest, err := parseFilter(strings.TrimSpace(filter))
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", filter, err)
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("invalid filter %q: unexpected %q", filter, rest)
+	}
+	return encoded, nil
+}
+
+// parseFilter encodes the parenthesized filter at the start of s and returns the rest of s.
+func parseFilter(s string) ([]byte, string, error) {
+	if !strings.HasPrefix(s, "(") || len(s) < 2 {
+		return nil, "", fmt.Errorf("expected '(' at %q", s)
+	}
+	s = s[1:]
+
+	switch s[0] {
+	case '&', '|':
+		tag := byte(tagFilterAnd)
+		if s[0] == '|' {
+			tag = tagFilterOr
+		}
+		s = s[1:]
+		var parts [][]byte
+		for strings.HasPrefix(s, "(") {
+			part, rest, err := parseFilter(s)
+			if err != nil {
+				return nil, "", err
+			}
+			parts = append(parts, part)
+			s = rest
+		}
+		if len(parts) == 0 || !strings.HasPrefix(s, ")") {
+			return nil, "", fmt.Errorf("unterminated filter list at %q", s)
+		}
+		return encode(tag, parts...), s[1:], nil
+	case '!':
+		part, rest, err := parseFilter(s[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.HasPrefix(rest, ")") {
+			return nil, "", fmt.Errorf("unterminated filter at %q", rest)
+		}
+		return encode(tagFilterNot, part), rest[1:], nil
+	}
+
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return nil, "", fmt.Errorf("unterminated filter at %q", s)
+	}
+	item, err := parseItem(s[:end])
+	if err != nil {
+		return nil, "", err
+	}
+	return item, s[end+1:], nil
+}
+
+// parseItem encodes a single comparison such as "uid=jdoe" or "cn=j*".
+func parseItem(item string) ([]byte, error) {
+	eq := strings.IndexByte(item, '=')
+	if eq < 1 {
+		return nil, fmt.Errorf("invalid filter item %q", item)
+	}
+	attr, value := item[:eq], item[eq+1:]
+
+	tag := byte(tagFilterEquality)
+	switch attr[len(attr)-1] {
+	case '>':
+		tag = tagFilterGreaterOrEq
+	case '<':
+		tag = tagFilterLessOrEq
+	case '~':
+		tag = tagFilterApprox
+	case ':':
+		return nil, fmt.Errorf("extensible match %q is not supported", item)
+	}
+	if tag != tagFilterEquality {
+		attr = attr[:len(attr)-1]
+	}
+	if attr == "" || strings.ContainsAny(attr, " ()*\\") {
+		return nil, fmt.Errorf("invalid attribute in %q", item)
+	}
+
+	if tag == tagFilterEquality && value == "*" {
+		return encodeString(tagFilterPresent, attr), nil
+	}
+	if tag == tagFilterEquality && strings.Contains(value, "*") {
+		return encodeSubstrings(attr, strings.Split(value, "*"))
+	}
+
+	unescaped, err := unescapeFilterValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return encode(tag, encodeString(tagOctetString, attr), encodeString(tagOctetString, unescaped)), nil
+}
+
+// encodeSubstrings encodes a substrings filter from the value split at its wildcards.
+func encodeSubstrings(attr string, parts []string) ([]byte, error) {
+	var subs [][]byte
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		unescaped, err := unescapeFilterValue(part)
+		if err != nil {
+			return nil, err
+		}
+		tag := byte(tagSubstringAny)
+		switch i {
+		case 0:
+			tag = tagSubstringInitial
+		case len(parts) - 1:
+			tag = tagSubstringFinal
+		}
+		subs = append(subs, encodeString(tag, unescaped))
+	}
+	return encode(tagFilterSubstrings, encodeString(tagOctetString, attr), encode(tagSequence, subs...)), nil
+}
+
+// unescapeFilterValue decodes the \XX escapes in a filter value.
+func unescapeFilterValue(value string) (string, error) {
+	if !strings.Contains(value, "\\") {
+		return value, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			b.WriteByte(value[i])
+			continue
+		}
+		if i+3 > len(value) {
+			return "", fmt.Errorf("truncated escape in %q", value)
+		}
+		decoded, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %q", value)
+		}
+		b.Write(decoded)
+		i += 2
+	}
+	return b.String(), nil
+}
@@ -4,6 +4,7 @@ package middleware
 import (
 	"bytes"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
@@ -41,9 +42,10 @@ func getStringFromContext(c *gin.Context, key string) string {
 	return ""
 }
 
-// readRequestBody reads and restores the request body for logging.
+// readRequestBody reads and restores the request body for logging. Multipart bodies are file
+// uploads and are left unread so they are neither buffered in memory nor stored in the log.
 func (m *AuditMiddleware) readRequestBody(c *gin.Context) []byte {
-	if c.Request.Body == nil {
+	if c.Request.Body == nil || strings.HasPrefix(c.ContentType(), "multipart/") {
 		return nil
 	}
 	requestBody, err := io.ReadAll(c.Request.Body)
@@ -96,7 +98,7 @@ func (m *AuditMiddleware) Audit() gin.HandlerFunc {
 			UserAgent: c.Request.UserAgent(),
 			Status:    determineStatus(c.Writer.Status()),
 			Details:   string(requestBody),
-			CreatedAt: time.Now(),
+			CreatedAt: model.Now(),
 		}
 
 		// Log asynchronously to avoid blocking requests
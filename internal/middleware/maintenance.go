@@ -0,0 +1,31 @@
+// Package middleware provides HTTP middleware functions.
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Maintenance returns a middleware that refuses requests other than GET, HEAD and OPTIONS
+// with 503 while maintenance mode is enabled. Routes in exempt, given as registered paths
+// such as "/api/v1/admin/maintenance", are let through so the mode can be turned off again.
+func Maintenance(maintenance service.MaintenanceService, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		status := maintenance.Status()
+		if !status.Enabled || slices.Contains(exempt, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": status.Message})
+	}
+}
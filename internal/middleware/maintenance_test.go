@@ -0,0 +1,65 @@
+// Package middleware provides maintenance mode tests.
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestMaintenance(t *testing.T) {
+	maintenance := service.NewMaintenanceService(false, "down for maintenance", zap.NewNop())
+	router := gin.New()
+	router.Use(Maintenance(maintenance, "/admin/maintenance"))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/resources", ok)
+	router.HEAD("/resources", ok)
+	router.POST("/resources", ok)
+	router.PUT("/resources/:id", ok)
+	router.DELETE("/resources/:id", ok)
+	router.PUT("/admin/maintenance", ok)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, http.NoBody))
+		return w
+	}
+
+	t.Run("disabled allows reads and writes", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			assert.Equal(t, http.StatusOK, serve(method, "/resources").Code, method)
+		}
+		assert.Equal(t, http.StatusOK, serve(http.MethodDelete, "/resources/1").Code)
+	})
+
+	maintenance.SetEnabled(context.Background(), true)
+
+	t.Run("enabled allows reads", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/resources").Code)
+		assert.Equal(t, http.StatusOK, serve(http.MethodHead, "/resources").Code)
+	})
+
+	t.Run("enabled blocks writes", func(t *testing.T) {
+		for _, method := range []string{http.MethodPut, http.MethodDelete} {
+			w := serve(method, "/resources/1")
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code, method)
+			assert.JSONEq(t, `{"error":"down for maintenance"}`, w.Body.String())
+		}
+		assert.Equal(t, http.StatusServiceUnavailable, serve(http.MethodPost, "/resources").Code)
+	})
+
+	t.Run("the toggle stays available", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(http.MethodPut, "/admin/maintenance").Code)
+	})
+
+	t.Run("disabling again allows writes", func(t *testing.T) {
+		maintenance.SetEnabled(context.Background(), false)
+		assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/resources").Code)
+	})
+}
@@ -62,6 +62,7 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 		c.Set("username", claims.Username)
 		c.Set("roles", claims.Roles)
 		c.Set("token", token)
+		c.Request = c.Request.WithContext(service.WithUserID(c.Request.Context(), claims.UserID))
 
 		c.Next()
 	}
@@ -24,6 +24,12 @@ func (b *BaseModel) BeforeCreate(_ *gorm.DB) error {
 	return nil
 }
 
+// AuditStamp records which users created and last modified a record.
+type AuditStamp struct {
+	CreatedByID *string `gorm:"type:char(36);index" json:"created_by_id"`
+	UpdatedByID *string `gorm:"type:char(36)" json:"updated_by_id"`
+}
+
 // UserSource represents how the user was created.
 type UserSource string
 
@@ -44,19 +50,28 @@ const (
 // User represents a platform user.
 type User struct {
 	BaseModel
-	Username     string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"username"`
-	Email        string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
-	PasswordHash string     `gorm:"type:varchar(255);not null" json:"-"`
-	DisplayName  string     `gorm:"type:varchar(128)" json:"display_name"`
-	Phone        string     `gorm:"type:varchar(20)" json:"phone"`
-	Avatar       string     `gorm:"type:varchar(512)" json:"avatar"`
-	Source       UserSource `gorm:"type:varchar(20);default:'local';not null" json:"source"` // User source: local, ldap, oidc, saml, oauth2
-	ExternalID   string     `gorm:"type:varchar(255)" json:"external_id,omitempty"`          // External ID from SSO provider
-	IsSystem     bool       `gorm:"default:false;not null" json:"is_system"`                 // System user (cannot be deleted)
-	Status       int8       `gorm:"type:tinyint;default:1;not null" json:"status"`           // 0: disabled, 1: active
-	LastLoginAt  *time.Time `json:"last_login_at"`
-	LastLoginIP  string     `gorm:"type:varchar(45)" json:"last_login_ip"`
-	Roles        []Role     `gorm:"many2many:user_roles;" json:"roles,omitempty"`
+	Username     string          `gorm:"type:varchar(64);uniqueIndex;not null" json:"username"`
+	Email        string          `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
+	PasswordHash string          `gorm:"type:varchar(255);not null" json:"-"`
+	DisplayName  string          `gorm:"type:varchar(128)" json:"display_name"`
+	Phone        string          `gorm:"type:varchar(20)" json:"phone"`
+	Avatar       string          `gorm:"type:varchar(512)" json:"avatar"`
+	Source       UserSource      `gorm:"type:varchar(20);default:'local';not null" json:"source"` // User source: local, ldap, oidc, saml, oauth2
+	ExternalID   string          `gorm:"type:varchar(255)" json:"external_id,omitempty"`          // External ID from SSO provider
+	IsSystem     bool            `gorm:"default:false;not null" json:"is_system"`                 // System user (cannot be deleted)
+	Status       int8            `gorm:"type:tinyint;default:1;not null" json:"status"`           // 0: disabled, 1: active
+	LastLoginAt  *time.Time      `json:"last_login_at"`
+	LastLoginIP  string          `gorm:"type:varchar(45)" json:"last_login_ip"`
+	Preferences  UserPreferences `gorm:"embedded" json:"preferences"`
+	Roles        []Role          `gorm:"many2many:user_roles;" json:"roles,omitempty"`
+}
+
+// UserPreferences holds the defaults used when a user creates a request or resource
+// without naming an environment, provider or zone.
+type UserPreferences struct {
+	DefaultEnvironment string  `gorm:"type:varchar(32)" json:"default_environment"`
+	DefaultProvider    string  `gorm:"type:varchar(32)" json:"default_provider"`
+	DefaultZoneID      *string `gorm:"type:char(36)" json:"default_zone_id"`
 }
 
 // TableName returns the table name for User.
@@ -100,13 +115,14 @@ func (Permission) TableName() string {
 // Resource represents a computing resource (VM, container, etc.).
 type Resource struct {
 	BaseModel
-	Name        string     `gorm:"type:varchar(128);not null" json:"name"`
+	AuditStamp
+	Name        string     `gorm:"type:varchar(128);index;not null" json:"name"`
 	Type        string     `gorm:"type:varchar(32);not null" json:"type"`                     // vm, container, bare_metal
 	Provider    string     `gorm:"type:varchar(32);not null" json:"provider"`                 // pve, vmware, openstack
-	Status      string     `gorm:"type:varchar(32);not null;default:'pending'" json:"status"` // pending, provisioning, running, stopped, error
+	Status      string     `gorm:"type:varchar(32);not null;default:'pending'" json:"status"` // pending, provisioning, running, degraded, stopped, error, imported
 	Spec        string     `gorm:"type:json" json:"spec"`                                     // CPU, memory, disk specs as JSON
-	IPAddress   string     `gorm:"type:varchar(45)" json:"ip_address"`
-	HostName    string     `gorm:"type:varchar(255)" json:"hostname"`
+	IPAddress   string     `gorm:"type:varchar(45);index" json:"ip_address"`
+	HostName    string     `gorm:"type:varchar(255);index" json:"hostname"`
 	OwnerID     string     `gorm:"type:char(36);index;not null" json:"owner_id"`
 	Owner       *User      `gorm:"foreignKey:OwnerID" json:"owner,omitempty"`
 	Environment string     `gorm:"type:varchar(32);index;not null" json:"environment"` // dev, test, staging, prod
@@ -114,6 +130,10 @@ type Resource struct {
 	ExpiresAt   *time.Time `json:"expires_at"`
 	Tags        string     `gorm:"type:json" json:"tags"` // JSON array of tags
 	Description string     `gorm:"type:text" json:"description"`
+	// ResourceAddresses lists the terraform resource addresses created for this resource.
+	ResourceAddresses []string `gorm:"type:json;serializer:json" json:"resource_addresses"`
+	// SearchText is the normalized text resource search matches against, rebuilt on every save.
+	SearchText string `gorm:"type:text" json:"-"`
 }
 
 // TableName returns the table name for Resource.
@@ -132,12 +152,24 @@ type ResourceSpec struct {
 	Network  string `json:"network"`   // Network configuration
 }
 
+// NodeDependency declares that a request's node reads outputs of the node created for
+// another request, such as a VM placed on a network provisioned first. It becomes a
+// terragrunt dependency block named Name.
+type NodeDependency struct {
+	Name      string            `json:"name"`
+	RequestID string            `json:"request_id"`
+	Inputs    map[string]string `json:"inputs"` // Module input name to the dependency's output name
+}
+
 // ResourceRequest represents a resource request/application.
 type ResourceRequest struct {
 	BaseModel
+	AuditStamp
 	Title                string             `gorm:"type:varchar(255);not null" json:"title"`
 	Description          string             `gorm:"type:text" json:"description"`
-	Spec                 string             `gorm:"type:json;not null" json:"spec"` // Requested spec
+	Spec                 string             `gorm:"type:json;not null" json:"spec"`                           // Requested spec
+	VarOverrides         map[string]string  `gorm:"type:json;serializer:json" json:"var_overrides,omitempty"` // Module variables set beyond the spec
+	Dependencies         []NodeDependency   `gorm:"type:json;serializer:json" json:"dependencies,omitempty"`  // Sibling nodes whose outputs feed module inputs
 	Environment          string             `gorm:"type:varchar(32);not null" json:"environment"`
 	Provider             string             `gorm:"type:varchar(32);not null" json:"provider"`
 	Type                 string             `gorm:"type:varchar(32);not null" json:"type"` // vm, container, bare_metal
@@ -149,7 +181,7 @@ type ResourceRequest struct {
 	TfProvider           *TerraformProvider `gorm:"foreignKey:TfProviderID" json:"tf_provider,omitempty"`
 	TfModuleID           *string            `gorm:"type:char(36)" json:"tf_module_id"` // Selected Terraform module
 	TfModule             *TerraformModule   `gorm:"foreignKey:TfModuleID" json:"tf_module,omitempty"`
-	CredentialID         *string            `gorm:"type:char(36)" json:"credential_id"` // Selected credential for access
+	CredentialID         *string            `gorm:"type:char(36);index" json:"credential_id"` // Selected credential for access
 	Credential           *Credential        `gorm:"foreignKey:CredentialID" json:"credential,omitempty"`
 	NodeConfigID         *string            `gorm:"type:char(36)" json:"node_config_id"` // Link to node configuration in storage repo
 	Quantity             int                `gorm:"type:int;default:1;not null" json:"quantity"`
@@ -159,6 +191,9 @@ type ResourceRequest struct {
 	ApproverID           *string            `gorm:"type:char(36)" json:"approver_id"`
 	Approver             *User              `gorm:"foreignKey:ApproverID" json:"approver,omitempty"`
 	ApprovedAt           *time.Time         `json:"approved_at"`
+	ApprovedOnBehalfOfID *string            `gorm:"type:char(36)" json:"approved_on_behalf_of_id,omitempty"` // Approver whose delegated authority was used
+	AutoApprovedBy       string             `gorm:"type:varchar(128)" json:"auto_approved_by,omitempty"`     // Synthetic approver when approved by a rule or environment
+	ApprovalPolicy       string             `gorm:"type:varchar(32)" json:"approval_policy,omitempty"`       // Policy that decided whether the request needed approval
 	RejectedAt           *time.Time         `json:"rejected_at"`
 	ProvisionStartedAt   *time.Time         `json:"provision_started_at"`
 	ProvisionCompletedAt *time.Time         `json:"provision_completed_at"`
@@ -176,6 +211,35 @@ func (ResourceRequest) TableName() string {
 	return "resource_requests"
 }
 
+// ApprovalPolicy values record what decided whether a resource request needed approval.
+const (
+	// ApprovalPolicyManual means no environment setting or rule applied, so the request
+	// waited for an approver.
+	ApprovalPolicyManual = "manual"
+	// ApprovalPolicyEnvironmentGated means the request's environment requires approval.
+	ApprovalPolicyEnvironmentGated = "environment-gated"
+	// ApprovalPolicyEnvironmentAuto means the request's environment skips approval.
+	ApprovalPolicyEnvironmentAuto = "environment-auto"
+	// ApprovalPolicyRule means an auto-approve rule approved the request.
+	ApprovalPolicyRule = "rule"
+)
+
+// RequestStatusEvent records one status transition of a resource request.
+type RequestStatusEvent struct {
+	BaseModel
+	RequestID  string `gorm:"type:char(36);not null;uniqueIndex:idx_request_status_events_seq" json:"request_id"`
+	Sequence   int    `gorm:"not null;uniqueIndex:idx_request_status_events_seq" json:"sequence"` // Position in the request's history, from 1
+	FromStatus string `gorm:"type:varchar(32)" json:"from_status"`                                // Empty for the initial status
+	ToStatus   string `gorm:"type:varchar(32);not null" json:"to_status"`
+	ActorID    string `gorm:"type:varchar(128)" json:"actor_id"` // User ID, rule:<name> for auto-approval, empty for the system
+	Reason     string `gorm:"type:text" json:"reason"`
+}
+
+// TableName returns the table name for RequestStatusEvent.
+func (RequestStatusEvent) TableName() string {
+	return "request_status_events"
+}
+
 // AuditLog represents an audit log entry.
 type AuditLog struct {
 	ID         string    `gorm:"type:char(36);primaryKey" json:"id"`
@@ -207,6 +271,7 @@ func (a *AuditLog) BeforeCreate(_ *gorm.DB) error {
 // ProviderConfig represents a cloud/infrastructure provider configuration.
 type ProviderConfig struct {
 	BaseModel
+	AuditStamp
 	Name         string      `gorm:"type:varchar(128);not null" json:"name"`
 	Type         string      `gorm:"type:varchar(32);not null" json:"type"`      // pve, vmware, openstack, aws, aliyun, gcp, azure
 	Endpoint     string      `gorm:"type:varchar(512);not null" json:"endpoint"` // API endpoint URL
@@ -214,7 +279,7 @@ type ProviderConfig struct {
 	Config       string      `gorm:"type:json" json:"config"`                       // Provider-specific config as JSON
 	Status       int8        `gorm:"type:tinyint;default:1;not null" json:"status"` // 0: disabled, 1: active
 	IsDefault    bool        `gorm:"default:false" json:"is_default"`
-	CredentialID *string     `gorm:"type:char(36)" json:"credential_id"` // Link to credential for authentication
+	CredentialID *string     `gorm:"type:char(36);index" json:"credential_id"` // Link to credential for authentication
 	Credential   *Credential `gorm:"foreignKey:CredentialID" json:"credential,omitempty"`
 }
 
@@ -227,15 +292,15 @@ func (ProviderConfig) TableName() string {
 type Credential struct {
 	BaseModel
 	Name        string          `gorm:"type:varchar(128);not null" json:"name"`
-	Type        string          `gorm:"type:varchar(32);not null" json:"type"` // pve, vmware, openstack, aws, aliyun, gcp, azure
-	ProviderID  *string         `gorm:"type:char(36)" json:"provider_id"`      // Optional link to provider config
+	Type        string          `gorm:"type:varchar(32);not null" json:"type"`  // pve, vmware, openstack, aws, aliyun, gcp, azure
+	ProviderID  *string         `gorm:"type:char(36);index" json:"provider_id"` // Optional link to provider config
 	Provider    *ProviderConfig `gorm:"foreignKey:ProviderID" json:"provider,omitempty"`
 	ZoneID      *string         `gorm:"type:char(36)" json:"zone_id"` // Link to zone this credential is for
 	Zone        *Zone           `gorm:"foreignKey:ZoneID" json:"zone,omitempty"`
-	Endpoint    string          `gorm:"type:varchar(512)" json:"endpoint"` // API endpoint URL for this credential
-	AccessKey   string          `gorm:"type:varchar(512)" json:"-"`        // Encrypted access key / username
-	SecretKey   string          `gorm:"type:varchar(512)" json:"-"`        // Encrypted secret key / password
-	Token       string          `gorm:"type:text" json:"-"`                // Encrypted token (optional)
+	Endpoint    string          `gorm:"type:varchar(512)" json:"endpoint"`    // API endpoint URL for this credential
+	AccessKey   string          `gorm:"type:varchar(512)" json:"-"`           // Encrypted access key / username
+	SecretKey   string          `gorm:"type:text;serializer:sealed" json:"-"` // Secret key / password, encrypted at rest
+	Token       string          `gorm:"type:text;serializer:sealed" json:"-"` // Token (optional), encrypted at rest
 	Description string          `gorm:"type:text" json:"description"`
 	Status      int8            `gorm:"type:tinyint;default:1;not null" json:"status"` // 0: disabled, 1: active
 	LastUsedAt  *time.Time      `json:"last_used_at"`
@@ -277,19 +342,25 @@ const (
 // GitRepository represents a git repository for storing terraform modules or node configs.
 type GitRepository struct {
 	BaseModel
+	AuditStamp
 	Name        string      `gorm:"type:varchar(128);not null" json:"name"`
 	Type        GitRepoType `gorm:"type:varchar(32);not null" json:"type"` // modules, storage
 	URL         string      `gorm:"type:varchar(512);not null" json:"url"` // Git URL (https or ssh)
 	Branch      string      `gorm:"type:varchar(128);default:'main'" json:"branch"`
 	AuthType    GitAuthType `gorm:"type:varchar(32);default:'none'" json:"auth_type"` // Authentication type
 	Username    string      `gorm:"type:varchar(256)" json:"username,omitempty"`      // Git auth username
-	Token       string      `gorm:"type:text" json:"-"`                               // Git auth token/password (encrypted)
-	SSHKey      string      `gorm:"type:text" json:"-"`                               // SSH private key (encrypted)
+	Token       string      `gorm:"type:text;serializer:sealed" json:"-"`             // Git auth token/password, encrypted at rest
+	SSHKey      string      `gorm:"type:text;serializer:sealed" json:"-"`             // SSH private key, encrypted at rest
 	BasePath    string      `gorm:"type:varchar(512);default:'/'" json:"base_path"`   // Base path within repo for configs
 	Description string      `gorm:"type:text" json:"description"`
 	Status      int8        `gorm:"type:tinyint;default:1;not null" json:"status"` // 0: disabled, 1: active
 	IsDefault   bool        `gorm:"default:false" json:"is_default"`
 	LastSyncAt  *time.Time  `json:"last_sync_at"`
+	// Environment scopes a default repository to requests for one environment; empty makes it
+	// the default for every environment without one of its own.
+	Environment string `gorm:"type:varchar(32);index" json:"environment"`
+	// WebhookSecret is the sealed HMAC secret push webhooks are signed with; empty disables them.
+	WebhookSecret string `gorm:"type:text" json:"-"`
 }
 
 // TableName returns the table name for GitRepository.
@@ -321,24 +392,26 @@ const (
 // NodeConfig represents a node configuration stored in the storage repository.
 type NodeConfig struct {
 	BaseModel
-	Name              string           `gorm:"type:varchar(128);not null" json:"name"`                  // Node name (e.g., minio-01)
-	Path              string           `gorm:"type:varchar(512);not null" json:"path"`                  // Path in storage repo (e.g., proxmox-ve/instance/minio/minio-01)
-	ResourceRequestID string           `gorm:"type:char(36);not null;index" json:"resource_request_id"` // Link to resource request
-	ResourceRequest   *ResourceRequest `gorm:"foreignKey:ResourceRequestID" json:"resource_request,omitempty"`
-	StorageRepoID     string           `gorm:"type:char(36);not null;index" json:"storage_repo_id"` // Link to storage repository
-	StorageRepo       *GitRepository   `gorm:"foreignKey:StorageRepoID" json:"storage_repo,omitempty"`
-	ModuleRepoID      *string          `gorm:"type:char(36)" json:"module_repo_id"` // Link to modules repository
-	ModuleRepo        *GitRepository   `gorm:"foreignKey:ModuleRepoID" json:"module_repo,omitempty"`
-	TerragruntConfig  string           `gorm:"type:text" json:"terragrunt_config"` // Generated terragrunt.hcl content
-	TerraformVars     string           `gorm:"type:json" json:"terraform_vars"`    // Variables as JSON
-	Status            NodeConfigStatus `gorm:"type:varchar(32);default:'pending'" json:"status"`
-	CommitSHA         string           `gorm:"type:varchar(64)" json:"commit_sha"`         // Current commit SHA in storage repo
-	PendingCommitSHA  string           `gorm:"type:varchar(64)" json:"pending_commit_sha"` // Pending commit SHA (before approval)
-	TerraformState    string           `gorm:"type:text" json:"terraform_state"`           // Terraform state (if stored locally)
-	ProvisionLog      string           `gorm:"type:text" json:"provision_log"`             // Provisioning log
-	ErrorMessage      string           `gorm:"type:text" json:"error_message"`             // Error message if failed
-	ProvisionedAt     *time.Time       `json:"provisioned_at"`
-	DestroyedAt       *time.Time       `json:"destroyed_at"`
+	Name              string            `gorm:"type:varchar(128);not null" json:"name"`                  // Node name (e.g., minio-01)
+	Path              string            `gorm:"type:varchar(512);not null" json:"path"`                  // Path in storage repo (e.g., proxmox-ve/instance/minio/minio-01)
+	ResourceRequestID string            `gorm:"type:char(36);not null;index" json:"resource_request_id"` // Link to resource request
+	ResourceRequest   *ResourceRequest  `gorm:"foreignKey:ResourceRequestID" json:"resource_request,omitempty"`
+	StorageRepoID     string            `gorm:"type:char(36);not null;index" json:"storage_repo_id"` // Link to storage repository
+	StorageRepo       *GitRepository    `gorm:"foreignKey:StorageRepoID" json:"storage_repo,omitempty"`
+	ModuleRepoID      *string           `gorm:"type:char(36)" json:"module_repo_id"` // Link to modules repository
+	ModuleRepo        *GitRepository    `gorm:"foreignKey:ModuleRepoID" json:"module_repo,omitempty"`
+	TerragruntConfig  string            `gorm:"type:text" json:"terragrunt_config"`                     // Generated terragrunt.hcl content
+	ExtraFiles        map[string]string `gorm:"type:json;serializer:json" json:"extra_files,omitempty"` // Files committed with terragrunt.hcl, by path relative to the node directory
+	TerraformVars     string            `gorm:"type:json" json:"terraform_vars"`                        // Variables as JSON
+	Status            NodeConfigStatus  `gorm:"type:varchar(32);default:'pending'" json:"status"`
+	CommitSHA         string            `gorm:"type:varchar(64)" json:"commit_sha"`         // Current commit SHA in storage repo
+	PendingCommitSHA  string            `gorm:"type:varchar(64)" json:"pending_commit_sha"` // Pending commit SHA (before approval)
+	TerraformState    string            `gorm:"type:text" json:"terraform_state"`           // Terraform state (if stored locally)
+	ProvisionLog      string            `gorm:"type:text" json:"provision_log"`             // Provisioning log
+	ErrorMessage      string            `gorm:"type:text" json:"error_message"`             // Error message if failed
+	ProvisionedAt     *time.Time        `json:"provisioned_at"`
+	DestroyedAt       *time.Time        `json:"destroyed_at"`
+	ResourceAddresses []string          `gorm:"type:json;serializer:json" json:"resource_addresses"` // Terraform addresses created by the apply
 }
 
 // TableName returns the table name for NodeConfig.
@@ -352,7 +425,7 @@ type TerraformRegistry struct {
 	Name        string `gorm:"type:varchar(128);not null" json:"name"`
 	Endpoint    string `gorm:"type:varchar(512);not null" json:"endpoint"` // e.g., registry.infra.plz.ac
 	Username    string `gorm:"type:varchar(256)" json:"-"`                 // Auth username (encrypted)
-	Token       string `gorm:"type:text" json:"-"`                         // Auth token (encrypted)
+	Token       string `gorm:"type:text;serializer:sealed" json:"-"`       // Auth token, encrypted at rest
 	Description string `gorm:"type:text" json:"description"`
 	Status      int8   `gorm:"type:tinyint;default:1;not null" json:"status"` // 0: disabled, 1: active
 	IsDefault   bool   `gorm:"default:false" json:"is_default"`
@@ -392,8 +465,22 @@ type TerraformModule struct {
 	ProviderID  *string            `gorm:"type:char(36)" json:"provider_id"` // Link to required provider
 	Provider    *TerraformProvider `gorm:"foreignKey:ProviderID" json:"provider,omitempty"`
 	Description string             `gorm:"type:text" json:"description"`
+	DisplayName string             `gorm:"type:varchar(128)" json:"display_name"` // From README front-matter
+	Category    string             `gorm:"type:varchar(64);index" json:"category"`
+	Icon        string             `gorm:"type:varchar(256)" json:"icon"`
+	Tags        string             `gorm:"type:varchar(512)" json:"tags"`                 // Comma-separated tags
 	Variables   string             `gorm:"type:json" json:"variables"`                    // Available variables as JSON
 	Status      int8               `gorm:"type:tinyint;default:1;not null" json:"status"` // 0: disabled, 1: active
+
+	// ParseWarnings lists the files the last sync from git could not read or parse.
+	ParseWarnings []ModuleParseWarning `gorm:"type:json;serializer:json" json:"parse_warnings,omitempty"`
+}
+
+// ModuleParseWarning records a module file left out of a scan because it could not be read
+// or parsed, so the variables and outputs it declares are missing from the module.
+type ModuleParseWarning struct {
+	File    string `json:"file"` // Relative to the module directory
+	Message string `json:"message"`
 }
 
 // TableName returns the table name for TerraformModule.
@@ -456,18 +543,27 @@ func (SSHKey) TableName() string {
 // IPPool represents an IP address pool for IPAM.
 type IPPool struct {
 	BaseModel
+	AuditStamp
 	Name        string `gorm:"type:varchar(128);not null" json:"name"`
 	CIDR        string `gorm:"type:varchar(64);not null" json:"cidr"`       // e.g., "10.31.0.0/24"
 	Gateway     string `gorm:"type:varchar(45);not null" json:"gateway"`    // e.g., "10.31.0.254"
 	DNS         string `gorm:"type:varchar(256)" json:"dns"`                // Comma-separated DNS servers
-	VLANTag     int    `gorm:"default:-1" json:"vlan_tag"`                  // -1 means no VLAN
+	VLANTag     int    `gorm:"default:0" json:"vlan_tag"`                   // 0 means untagged
 	StartIP     string `gorm:"type:varchar(45);not null" json:"start_ip"`   // Start of usable range
 	EndIP       string `gorm:"type:varchar(45);not null" json:"end_ip"`     // End of usable range
 	ZoneID      string `gorm:"type:char(36);not null;index" json:"zone_id"` // Associated zone
 	Zone        *Zone  `gorm:"foreignKey:ZoneID" json:"zone,omitempty"`
-	NetworkType string `gorm:"type:varchar(32);default:'vmbr0'" json:"network_type"` // Bridge name
+	NetworkType string `gorm:"type:varchar(32);default:'private'" json:"network_type"` // management, public, private, storage
+	Bridge      string `gorm:"type:varchar(32)" json:"bridge"`                         // Bridge name, e.g. vmbr0
 	Description string `gorm:"type:text" json:"description"`
 	Status      int8   `gorm:"type:tinyint;default:1;not null" json:"status"` // 0: disabled, 1: active
+	IsDefault   bool   `gorm:"default:false" json:"is_default"`               // Tried first for allocations in its zone
+	// VerifyBeforeAllocate probes each address before it is handed out, so one already in use
+	// outside the platform is skipped.
+	VerifyBeforeAllocate bool `gorm:"default:false" json:"verify_before_allocate"`
+	// LastAllocatedIP is where the search for the next free address resumes, so allocation
+	// does not rescan the taken start of a large range.
+	LastAllocatedIP string `gorm:"type:varchar(45)" json:"-"`
 }
 
 // TableName returns the table name for IPPool.
@@ -475,6 +571,32 @@ func (IPPool) TableName() string {
 	return "ip_pools"
 }
 
+// 802.1Q VLAN tag bounds for IP pools. IDs 0 and 4095 are reserved by the standard, so 0 is
+// used to mean the pool is untagged.
+const (
+	VLANUntagged = 0
+	MinVLANTag   = 1
+	MaxVLANTag   = 4094
+)
+
+// IPPool network type constants describe which workloads a pool serves.
+const (
+	NetworkTypeManagement = "management"
+	NetworkTypePublic     = "public"
+	NetworkTypePrivate    = "private"
+	NetworkTypeStorage    = "storage"
+)
+
+// IsValidNetworkType reports whether networkType is one of the known IP pool network types.
+func IsValidNetworkType(networkType string) bool {
+	switch networkType {
+	case NetworkTypeManagement, NetworkTypePublic, NetworkTypePrivate, NetworkTypeStorage:
+		return true
+	default:
+		return false
+	}
+}
+
 // IPAllocationStatus represents the status of an IP allocation.
 type IPAllocationStatus string
 
@@ -527,3 +649,91 @@ type VMTemplate struct {
 func (VMTemplate) TableName() string {
 	return "vm_templates"
 }
+
+// ResourceLock is a lease that serializes operations on one resource across replicas.
+type ResourceLock struct {
+	LockKey   string    `gorm:"type:varchar(191);primaryKey" json:"lock_key"`
+	Owner     string    `gorm:"type:char(36);not null" json:"owner"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for ResourceLock.
+func (ResourceLock) TableName() string {
+	return "resource_locks"
+}
+
+// NameSequence is a counter handing out increasing numbers within a scope, such as the
+// node names of one provider and resource type.
+type NameSequence struct {
+	Scope     string    `gorm:"type:varchar(191);primaryKey" json:"scope"`
+	Value     int64     `gorm:"not null;default:0" json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for NameSequence.
+func (NameSequence) TableName() string {
+	return "name_sequences"
+}
+
+// AttachmentKind distinguishes linked from uploaded request attachments.
+type AttachmentKind string
+
+// AttachmentKind constants.
+const (
+	// AttachmentKindLink is a URL stored inline, such as a ticket link.
+	AttachmentKindLink AttachmentKind = "link"
+	// AttachmentKindFile is an uploaded file kept in the blob store.
+	AttachmentKindFile AttachmentKind = "file"
+)
+
+// RequestAttachment is supporting material attached to a resource request for approvers.
+type RequestAttachment struct {
+	BaseModel
+	RequestID   string         `gorm:"type:char(36);not null;index" json:"request_id"`
+	Kind        AttachmentKind `gorm:"type:varchar(16);not null" json:"kind"`
+	Name        string         `gorm:"type:varchar(255);not null" json:"name"`
+	URL         string         `gorm:"type:varchar(1024)" json:"url,omitempty"` // Link target
+	BlobRef     string         `gorm:"type:varchar(64)" json:"-"`               // File location in the blob store
+	ContentType string         `gorm:"type:varchar(128)" json:"content_type,omitempty"`
+	Size        int64          `json:"size,omitempty"` // File size in bytes
+	AddedByID   string         `gorm:"type:char(36);not null" json:"added_by_id"`
+	AddedBy     *User          `gorm:"foreignKey:AddedByID" json:"added_by,omitempty"`
+}
+
+// TableName returns the table name for RequestAttachment.
+func (RequestAttachment) TableName() string {
+	return "request_attachments"
+}
+
+// DelegationScopeAll is the delegation scope covering requests in every environment.
+const DelegationScopeAll = "*"
+
+// Delegation lets ToUser approve resource requests on FromUser's behalf between StartsAt
+// and EndsAt, for example while FromUser is on leave. Scope is an environment name or
+// DelegationScopeAll.
+type Delegation struct {
+	BaseModel
+	FromUserID string    `gorm:"type:char(36);index;not null" json:"from_user_id"`
+	FromUser   *User     `gorm:"foreignKey:FromUserID" json:"from_user,omitempty"`
+	ToUserID   string    `gorm:"type:char(36);index;not null" json:"to_user_id"`
+	ToUser     *User     `gorm:"foreignKey:ToUserID" json:"to_user,omitempty"`
+	Scope      string    `gorm:"type:varchar(32);not null;default:'*'" json:"scope"`
+	StartsAt   time.Time `gorm:"not null" json:"starts_at"`
+	EndsAt     time.Time `gorm:"not null" json:"ends_at"`
+	Reason     string    `gorm:"type:varchar(255)" json:"reason"`
+}
+
+// TableName returns the table name for Delegation.
+func (Delegation) TableName() string {
+	return "delegations"
+}
+
+// Covers reports whether the delegation applies to a request in environment at time at.
+// The window includes StartsAt and excludes EndsAt.
+func (d *Delegation) Covers(environment string, at time.Time) bool {
+	if d.Scope != DelegationScopeAll && d.Scope != environment {
+		return false
+	}
+	return !at.Before(d.StartsAt) && at.Before(d.EndsAt)
+}
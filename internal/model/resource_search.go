@@ -0,0 +1,82 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// BeforeSave refreshes SearchText so it always reflects the saved name, tags and spec.
+func (r *Resource) BeforeSave(_ *gorm.DB) error {
+	r.SearchText = r.BuildSearchText()
+	return nil
+}
+
+// BuildSearchText returns the lowercased terms a resource can be found by, one per line:
+// its name, hostname and IP address, each tag value, and each spec value both on its own
+// and as key=value. Nested spec keys are joined with dots.
+func (r *Resource) BuildSearchText() string {
+	terms := []string{r.Name, r.HostName, r.IPAddress}
+	terms = appendJSONTerms(terms, r.Tags)
+	terms = appendJSONTerms(terms, r.Spec)
+
+	seen := make(map[string]bool, len(terms))
+	lines := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		lines = append(lines, term)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// appendJSONTerms appends the searchable terms in raw, a JSON document. Text that is not
+// JSON is kept as a single term.
+func appendJSONTerms(terms []string, raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return terms
+	}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return append(terms, raw)
+	}
+	return appendValueTerms(terms, "", doc)
+}
+
+func appendValueTerms(terms []string, key string, value interface{}) []string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			path := k
+			if key != "" {
+				path = key + "." + k
+			}
+			terms = appendValueTerms(terms, path, v[k])
+		}
+	case []interface{}:
+		for _, item := range v {
+			terms = appendValueTerms(terms, key, item)
+		}
+	case nil:
+	default:
+		text := fmt.Sprint(v)
+		terms = append(terms, text)
+		if key != "" {
+			terms = append(terms, key+"="+text)
+		}
+	}
+	return terms
+}
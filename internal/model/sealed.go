@@ -0,0 +1,75 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/secrets"
+	"gorm.io/gorm/schema"
+)
+
+// ErrSecretsKeyMissing is returned when an encrypted column is read while no secrets box is
+// configured to open it.
+var ErrSecretsKeyMissing = errors.New("secrets encryption key is not configured")
+
+// secretsBox encrypts the columns tagged serializer:sealed; nil stores them in plaintext.
+var secretsBox atomic.Pointer[secrets.Box]
+
+// SetSecretsBox sets the box that encrypts credential secrets at rest. Until it is set, or
+// when box is nil, they are written in plaintext.
+func SetSecretsBox(box *secrets.Box) {
+	secretsBox.Store(box)
+}
+
+func init() {
+	schema.RegisterSerializer("sealed", sealedSerializer{})
+}
+
+// sealedSerializer encrypts a string column when it is written and decrypts it when it is
+// read. Empty values are stored as they are, and values written before encryption was
+// enabled are read back unchanged until they are next saved.
+type sealedSerializer struct{}
+
+// Scan implements schema.SerializerInterface.
+func (sealedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch value := dbValue.(type) {
+	case nil:
+	case string:
+		stored = value
+	case []byte:
+		stored = string(value)
+	default:
+		return fmt.Errorf("unsupported data type for sealed column %s: %T", field.Name, dbValue)
+	}
+
+	plaintext := stored
+	if secrets.IsSealed(stored) {
+		box := secretsBox.Load()
+		if box == nil {
+			return fmt.Errorf("%w: cannot read %s", ErrSecretsKeyMissing, field.Name)
+		}
+		opened, err := box.Open(stored)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", field.Name, err)
+		}
+		plaintext = opened
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+// Value implements schema.SerializerInterface.
+func (sealedSerializer) Value(_ context.Context, field *schema.Field, _ reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("unsupported data type for sealed column %s: %T", field.Name, fieldValue)
+	}
+	box := secretsBox.Load()
+	if plaintext == "" || box == nil {
+		return plaintext, nil
+	}
+	return box.Seal(plaintext)
+}
@@ -0,0 +1,11 @@
+// Package model defines the database models for the application.
+package model
+
+import "time"
+
+// Now returns the current time in UTC. Timestamps are stored and returned in UTC so API
+// responses always serialize them as RFC 3339 with a Z suffix, whatever the server's zone.
+// Use it instead of time.Now for any time that is persisted or sent to clients.
+func Now() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,40 @@
+// Package model provides timestamp serialization tests.
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNowIsUTC(t *testing.T) {
+	now := Now()
+	assert.Equal(t, time.UTC, now.Location())
+
+	data, err := json.Marshal(now)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(data), `Z"`), "got %s", data)
+}
+
+func TestTimestampJSONRoundTrip(t *testing.T) {
+	// A time taken in another zone serializes as the same instant in UTC
+	shanghai := time.FixedZone("CST", 8*60*60)
+	approvedAt := time.Date(2026, 1, 2, 11, 4, 5, 0, shanghai).UTC()
+
+	request := ResourceRequest{BaseModel: BaseModel{ID: "req-1", CreatedAt: approvedAt}, ApprovedAt: &approvedAt}
+	data, err := json.Marshal(request)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"created_at":"2026-01-02T03:04:05Z"`)
+	assert.Contains(t, string(data), `"approved_at":"2026-01-02T03:04:05Z"`)
+
+	var decoded ResourceRequest
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.NotNil(t, decoded.ApprovedAt)
+	assert.True(t, decoded.ApprovedAt.Equal(approvedAt))
+	assert.Equal(t, time.UTC, decoded.ApprovedAt.Location(), "a Z suffix decodes as UTC")
+	assert.True(t, decoded.CreatedAt.Equal(approvedAt))
+}
@@ -117,7 +117,7 @@ func (s *service) NotifyResourceRequestApproved(ctx context.Context, userID, req
 			"status":     "approved",
 			"reason":     reason,
 		},
-		CreatedAt: time.Now(),
+		CreatedAt: time.Now().UTC(),
 	}
 	return s.Send(ctx, notification)
 }
@@ -134,7 +134,7 @@ func (s *service) NotifyResourceRequestRejected(ctx context.Context, userID, req
 			"status":     "rejected",
 			"reason":     reason,
 		},
-		CreatedAt: time.Now(),
+		CreatedAt: time.Now().UTC(),
 	}
 	return s.Send(ctx, notification)
 }
@@ -152,7 +152,7 @@ func (s *service) NotifyResourceProvisioned(ctx context.Context, userID, resourc
 			"outputs":       outputs,
 			"status":        "completed",
 		},
-		CreatedAt: time.Now(),
+		CreatedAt: time.Now().UTC(),
 	}
 	return s.Send(ctx, notification)
 }
@@ -169,7 +169,7 @@ func (s *service) NotifyResourceProvisioningFailed(ctx context.Context, userID,
 			"status":     "failed",
 			"error":      errorMsg,
 		},
-		CreatedAt: time.Now(),
+		CreatedAt: time.Now().UTC(),
 	}
 	return s.Send(ctx, notification)
 }
@@ -0,0 +1,265 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/events"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Webhook request headers.
+const (
+	HeaderEvent     = "X-VC-Event"
+	HeaderDelivery  = "X-VC-Delivery"
+	HeaderSignature = "X-VC-Signature"
+)
+
+// sensitiveOutputWords mark output names that are never sent outside the platform, even when
+// the module does not declare them sensitive.
+var sensitiveOutputWords = []string{"password", "secret", "token", "private_key", "credential", "kubeconfig"}
+
+// ResourceProvisionedPayload is the body delivered for a resource.provisioned event.
+type ResourceProvisionedPayload struct {
+	Event      string              `json:"event"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	RequestID  string              `json:"request_id"`
+	Resource   ProvisionedResource `json:"resource"`
+}
+
+// ProvisionedResource describes a resource that has gone live.
+type ProvisionedResource struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Provider    string            `json:"provider"`
+	Environment string            `json:"environment"`
+	IP          string            `json:"ip,omitempty"`
+	Outputs     map[string]string `json:"outputs,omitempty"`
+}
+
+// IPPoolUtilizationPayload is the body delivered for an ip_pool.utilization_high event.
+type IPPoolUtilizationPayload struct {
+	Event      string         `json:"event"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Pool       PoolUsageAlert `json:"pool"`
+}
+
+// PoolUsageAlert describes an IP pool whose utilization reached the alert threshold.
+type PoolUsageAlert struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	ZoneID           string  `json:"zone_id,omitempty"`
+	Total            int64   `json:"total"`
+	Allocated        int64   `json:"allocated"`
+	Reserved         int64   `json:"reserved"`
+	Percent          float64 `json:"percent"`
+	ThresholdPercent int     `json:"threshold_percent"`
+}
+
+// PublicOutputs returns the outputs that may leave the platform: those not declared
+// sensitive and not named like a credential.
+func PublicOutputs(outputs map[string]string, sensitive []string) map[string]string {
+	public := make(map[string]string, len(outputs))
+	for name, value := range outputs {
+		if slices.Contains(sensitive, name) || isSensitiveOutputName(name) {
+			continue
+		}
+		public[name] = value
+	}
+	return public
+}
+
+func isSensitiveOutputName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, word := range sensitiveOutputWords {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookNotifier delivers platform events to the configured webhooks as signed JSON posts.
+type WebhookNotifier struct {
+	hooks      []config.WebhookConfig
+	client     *http.Client
+	retryDelay time.Duration
+	logger     *zap.Logger
+}
+
+// NewWebhookNotifier creates a notifier for hooks. A nil client uses one with the default
+// webhook timeout.
+func NewWebhookNotifier(hooks []config.WebhookConfig, client *http.Client, logger *zap.Logger) *WebhookNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: constants.WebhookTimeout}
+	}
+	return &WebhookNotifier{
+		hooks:      hooks,
+		client:     client,
+		retryDelay: constants.WebhookRetryDelay,
+		logger:     logger,
+	}
+}
+
+// Subscribe registers the notifier on bus for the events it delivers. Deliveries run
+// asynchronously so slow endpoints do not hold up provisioning.
+func (n *WebhookNotifier) Subscribe(bus *events.Bus) {
+	if len(n.hooks) == 0 {
+		return
+	}
+	bus.SubscribeAsync(events.ResourceProvisioned, n.handleResourceProvisioned)
+	bus.SubscribeAsync(events.IPPoolUtilizationHigh, n.handlePoolUtilizationHigh)
+}
+
+// handleResourceProvisioned delivers a resource.provisioned event.
+func (n *WebhookNotifier) handleResourceProvisioned(ctx context.Context, event events.Event) {
+	payload := ResourceProvisionedPayload{
+		Event:      string(event.Type),
+		OccurredAt: event.OccurredAt,
+		RequestID:  event.RequestID,
+		Resource: ProvisionedResource{
+			ID:          event.ResourceID,
+			Name:        dataString(event.Data, "resource_name"),
+			Provider:    dataString(event.Data, "provider"),
+			Environment: dataString(event.Data, "environment"),
+			IP:          dataString(event.Data, "ip"),
+		},
+	}
+	if outputs, ok := event.Data["outputs"].(map[string]string); ok {
+		payload.Resource.Outputs = PublicOutputs(outputs, nil)
+	}
+	n.deliverAll(ctx, event.Type, payload)
+}
+
+// handlePoolUtilizationHigh delivers an ip_pool.utilization_high event.
+func (n *WebhookNotifier) handlePoolUtilizationHigh(ctx context.Context, event events.Event) {
+	pool := PoolUsageAlert{
+		ID:               dataString(event.Data, "pool_id"),
+		Name:             dataString(event.Data, "pool_name"),
+		ZoneID:           dataString(event.Data, "zone_id"),
+		Total:            dataValue[int64](event.Data, "total"),
+		Allocated:        dataValue[int64](event.Data, "allocated"),
+		Reserved:         dataValue[int64](event.Data, "reserved"),
+		Percent:          dataValue[float64](event.Data, "percent"),
+		ThresholdPercent: dataValue[int](event.Data, "threshold_percent"),
+	}
+	n.deliverAll(ctx, event.Type, IPPoolUtilizationPayload{
+		Event:      string(event.Type),
+		OccurredAt: event.OccurredAt,
+		Pool:       pool,
+	})
+}
+
+// deliverAll sends payload to every webhook subscribed to eventType.
+func (n *WebhookNotifier) deliverAll(ctx context.Context, eventType events.Type, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		n.logger.Error("failed to encode webhook payload", zap.String("event", string(eventType)), zap.Error(err))
+		return
+	}
+	for _, hook := range n.hooks {
+		if len(hook.Events) > 0 && !slices.Contains(hook.Events, string(eventType)) {
+			continue
+		}
+		if err := n.deliver(ctx, hook, eventType, body); err != nil {
+			n.logger.Error("webhook delivery failed",
+				zap.String("webhook", hook.Name),
+				zap.String("url", sanitize.URL(hook.URL)),
+				zap.String("event", string(eventType)),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// deliver posts body to hook, retrying network errors and server errors with backoff.
+func (n *WebhookNotifier) deliver(ctx context.Context, hook config.WebhookConfig, eventType events.Type, body []byte) error {
+	deliveryID := uuid.New().String()
+	delay := n.retryDelay
+
+	var lastErr error
+	for attempt := 1; attempt <= constants.WebhookMaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		retry, err := n.post(ctx, hook, eventType, deliveryID, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying.
+func (n *WebhookNotifier) post(ctx context.Context, hook config.WebhookConfig, eventType events.Type, deliveryID string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "vc-lab-platform-webhook")
+	req.Header.Set(HeaderEvent, string(eventType))
+	req.Header.Set(HeaderDelivery, deliveryID)
+	if hook.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(hook.Secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()                                      //nolint:errcheck // read-only body
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16)) //nolint:errcheck // drained for connection reuse
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("webhook returned %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+}
+
+// Sign returns the X-VC-Signature value for body: "sha256=" and the hex HMAC-SHA256 of body
+// keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func dataString(data map[string]interface{}, key string) string {
+	return dataValue[string](data, key)
+}
+
+// dataValue returns the value of key in data, or T's zero value when it is missing or of
+// another type.
+func dataValue[T any](data map[string]interface{}, key string) T {
+	value, _ := data[key].(T) //nolint:errcheck // missing or mistyped values are zero
+	return value
+}
@@ -0,0 +1,184 @@
+// Package notification provides webhook delivery tests.
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// delivery is a webhook request received by a test endpoint.
+type delivery struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookEndpoint records deliveries and answers with the queued status codes, then 200.
+type webhookEndpoint struct {
+	mu       sync.Mutex
+	received []delivery
+	statuses []int
+}
+
+func newWebhookEndpoint(t *testing.T, statuses ...int) (*webhookEndpoint, *httptest.Server) {
+	t.Helper()
+	endpoint := &webhookEndpoint{statuses: statuses}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		endpoint.mu.Lock()
+		defer endpoint.mu.Unlock()
+		endpoint.received = append(endpoint.received, delivery{header: r.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(endpoint.statuses) > 0 {
+			status, endpoint.statuses = endpoint.statuses[0], endpoint.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return endpoint, server
+}
+
+func (e *webhookEndpoint) deliveries() []delivery {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]delivery(nil), e.received...)
+}
+
+func provisionedEvent() events.Event {
+	return events.Event{
+		Type:       events.ResourceProvisioned,
+		RequestID:  "req-1",
+		ResourceID: "res-1",
+		Data: map[string]interface{}{
+			"provider":      "pve",
+			"environment":   "dev",
+			"resource_name": "web-req-1",
+			"ip":            "10.0.0.5",
+			"outputs": map[string]string{
+				"vm_id":          "101",
+				"vm_ip":          "10.0.0.5",
+				"admin_password": "hunter2",
+				"api_token":      "tok",
+			},
+		},
+	}
+}
+
+func TestWebhookNotifier_ResourceProvisioned(t *testing.T) {
+	endpoint, server := newWebhookEndpoint(t)
+	other, otherServer := newWebhookEndpoint(t)
+	notifier := NewWebhookNotifier([]config.WebhookConfig{
+		{Name: "cmdb", URL: server.URL, Secret: "s3cret", Events: []string{"resource.provisioned"}},
+		{Name: "audit", URL: otherServer.URL, Events: []string{"request.created"}},
+	}, server.Client(), zap.NewNop())
+	bus := events.NewBus(zap.NewNop())
+	notifier.Subscribe(bus)
+
+	bus.Publish(context.Background(), provisionedEvent())
+	bus.Wait()
+
+	received := endpoint.deliveries()
+	require.Len(t, received, 1)
+	got := received[0]
+	assert.Equal(t, "resource.provisioned", got.header.Get(HeaderEvent))
+	assert.NotEmpty(t, got.header.Get(HeaderDelivery))
+	assert.Equal(t, Sign("s3cret", got.body), got.header.Get(HeaderSignature))
+
+	var payload ResourceProvisionedPayload
+	require.NoError(t, json.Unmarshal(got.body, &payload))
+	assert.Equal(t, "req-1", payload.RequestID)
+	assert.Equal(t, ProvisionedResource{
+		ID:          "res-1",
+		Name:        "web-req-1",
+		Provider:    "pve",
+		Environment: "dev",
+		IP:          "10.0.0.5",
+		Outputs:     map[string]string{"vm_id": "101", "vm_ip": "10.0.0.5"},
+	}, payload.Resource)
+	assert.NotContains(t, string(got.body), "hunter2")
+	assert.NotContains(t, string(got.body), "tok\"")
+
+	assert.Empty(t, other.deliveries(), "hooks only receive the events they list")
+}
+
+func TestWebhookNotifier_PoolUtilizationHigh(t *testing.T) {
+	endpoint, server := newWebhookEndpoint(t)
+	notifier := NewWebhookNotifier([]config.WebhookConfig{{Name: "monitoring", URL: server.URL}}, server.Client(), zap.NewNop())
+	bus := events.NewBus(zap.NewNop())
+	notifier.Subscribe(bus)
+
+	bus.Publish(context.Background(), events.Event{
+		Type: events.IPPoolUtilizationHigh,
+		Data: map[string]interface{}{
+			"pool_id":           "pool-1",
+			"pool_name":         "lab",
+			"zone_id":           "zone-1",
+			"total":             int64(10),
+			"allocated":         int64(7),
+			"reserved":          int64(1),
+			"percent":           80.0,
+			"threshold_percent": 80,
+		},
+	})
+	bus.Wait()
+
+	received := endpoint.deliveries()
+	require.Len(t, received, 1)
+	assert.Equal(t, "ip_pool.utilization_high", received[0].header.Get(HeaderEvent))
+	var payload IPPoolUtilizationPayload
+	require.NoError(t, json.Unmarshal(received[0].body, &payload))
+	assert.Equal(t, PoolUsageAlert{
+		ID: "pool-1", Name: "lab", ZoneID: "zone-1",
+		Total: 10, Allocated: 7, Reserved: 1, Percent: 80, ThresholdPercent: 80,
+	}, payload.Pool)
+}
+
+func TestWebhookNotifier_Retries(t *testing.T) {
+	t.Run("server errors are retried", func(t *testing.T) {
+		endpoint, server := newWebhookEndpoint(t, http.StatusBadGateway, http.StatusServiceUnavailable)
+		notifier := NewWebhookNotifier([]config.WebhookConfig{{Name: "cmdb", URL: server.URL}}, server.Client(), zap.NewNop())
+		notifier.retryDelay = 0
+
+		notifier.handleResourceProvisioned(context.Background(), provisionedEvent())
+
+		received := endpoint.deliveries()
+		require.Len(t, received, 3)
+		assert.Equal(t, received[0].header.Get(HeaderDelivery), received[2].header.Get(HeaderDelivery), "retries reuse the delivery ID")
+		assert.Empty(t, received[0].header.Get(HeaderSignature), "no secret, no signature")
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		endpoint, server := newWebhookEndpoint(t, http.StatusBadRequest)
+		notifier := NewWebhookNotifier([]config.WebhookConfig{{Name: "cmdb", URL: server.URL}}, server.Client(), zap.NewNop())
+		notifier.retryDelay = 0
+
+		notifier.handleResourceProvisioned(context.Background(), provisionedEvent())
+
+		assert.Len(t, endpoint.deliveries(), 1)
+	})
+}
+
+func TestPublicOutputs(t *testing.T) {
+	outputs := map[string]string{
+		"vm_ip":           "10.0.0.5",
+		"root_pass":       "x",
+		"DB_Password":     "x",
+		"ssh_private_key": "x",
+		"client_secret":   "x",
+	}
+
+	assert.Equal(t, map[string]string{"vm_ip": "10.0.0.5"}, PublicOutputs(outputs, []string{"root_pass"}))
+	assert.Empty(t, PublicOutputs(nil, nil))
+}
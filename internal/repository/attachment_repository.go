@@ -0,0 +1,53 @@
+// Package repository provides data access layer implementations.
+package repository
+
+import (
+	"context"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"gorm.io/gorm"
+)
+
+// AttachmentRepository defines the interface for resource request attachment operations.
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *model.RequestAttachment) error
+	GetByID(ctx context.Context, id string) (*model.RequestAttachment, error)
+	ListByRequest(ctx context.Context, requestID string) ([]*model.RequestAttachment, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentRepository creates a new attachment repository.
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+// Create creates a new attachment.
+func (r *attachmentRepository) Create(ctx context.Context, attachment *model.RequestAttachment) error {
+	return r.db.WithContext(ctx).Create(attachment).Error
+}
+
+// GetByID retrieves an attachment by ID.
+func (r *attachmentRepository) GetByID(ctx context.Context, id string) (*model.RequestAttachment, error) {
+	return firstOrNotFound[model.RequestAttachment](r.db.WithContext(ctx), "id = ?", id)
+}
+
+// ListByRequest retrieves the attachments of a resource request, oldest first.
+func (r *attachmentRepository) ListByRequest(ctx context.Context, requestID string) ([]*model.RequestAttachment, error) {
+	var attachments []*model.RequestAttachment
+	if err := r.db.WithContext(ctx).Preload("AddedBy").
+		Where("request_id = ?", requestID).
+		Order(orderOldestFirst).
+		Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// Delete soft deletes an attachment.
+func (r *attachmentRepository) Delete(ctx context.Context, id string) error {
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.RequestAttachment{}, "id = ?", id))
+}
@@ -4,6 +4,7 @@ package repository
 import (
 	"context"
 
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/database"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/gorm"
 )
@@ -41,7 +42,7 @@ func (r *auditRepository) List(ctx context.Context, filters AuditFilters, offset
 	var logs []*model.AuditLog
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&model.AuditLog{})
+	query := r.db.WithContext(ctx).Scopes(database.ReadReplica).Model(&model.AuditLog{})
 
 	// Apply filters
 	if filters.UserID != "" {
@@ -60,7 +61,7 @@ func (r *auditRepository) List(ctx context.Context, filters AuditFilters, offset
 	}
 
 	// Get paginated results
-	result := query.Preload("User").Offset(offset).Limit(limit).Order("created_at DESC").Find(&logs)
+	result := query.Preload("User").Offset(offset).Limit(limit).Order(orderNewestFirst).Find(&logs)
 	if result.Error != nil {
 		return nil, 0, result.Error
 	}
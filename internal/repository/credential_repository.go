@@ -3,7 +3,6 @@ package repository
 
 import (
 	"context"
-	"errors"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/gorm"
@@ -13,9 +12,17 @@ import (
 type CredentialRepository interface {
 	Create(ctx context.Context, credential *model.Credential) error
 	GetByID(ctx context.Context, id string) (*model.Credential, error)
-	List(ctx context.Context, credentialType string, offset, limit int) ([]*model.Credential, int64, error)
+	List(ctx context.Context, filters CredentialFilters, offset, limit int) ([]*model.Credential, int64, error)
 	Update(ctx context.Context, credential *model.Credential) error
 	Delete(ctx context.Context, id string) error
+	GetUsage(ctx context.Context, id string) (*Usage, error)
+}
+
+// CredentialFilters defines filters for credential queries.
+type CredentialFilters struct {
+	Type string
+	// ZoneID matches credentials scoped to the zone plus global (zone-less) credentials.
+	ZoneID string
 }
 
 type credentialRepository struct {
@@ -34,31 +41,27 @@ func (r *credentialRepository) Create(ctx context.Context, credential *model.Cre
 
 // GetByID retrieves a credential by ID.
 func (r *credentialRepository) GetByID(ctx context.Context, id string) (*model.Credential, error) {
-	var credential model.Credential
-	if err := r.db.WithContext(ctx).Preload("Zone").Preload("Provider").Preload("CreatedBy").First(&credential, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &credential, nil
+	return firstOrNotFound[model.Credential](r.db.WithContext(ctx).Preload("Zone").Preload("Provider").Preload("CreatedBy"), "id = ?", id)
 }
 
-// List retrieves credentials with optional filtering.
-func (r *credentialRepository) List(ctx context.Context, credentialType string, offset, limit int) ([]*model.Credential, int64, error) {
+// List retrieves credentials with optional type and zone filtering.
+func (r *credentialRepository) List(ctx context.Context, filters CredentialFilters, offset, limit int) ([]*model.Credential, int64, error) {
 	var credentials []*model.Credential
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&model.Credential{})
-	if credentialType != "" {
-		query = query.Where("type = ?", credentialType)
+	if filters.Type != "" {
+		query = query.Where("type = ?", filters.Type)
+	}
+	if filters.ZoneID != "" {
+		query = query.Where("zone_id = ? OR zone_id IS NULL", filters.ZoneID)
 	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	if err := query.Preload("Zone").Preload("Provider").Preload("CreatedBy").Offset(offset).Limit(limit).Order("created_at DESC").Find(&credentials).Error; err != nil {
+	if err := query.Preload("Zone").Preload("Provider").Preload("CreatedBy").Offset(offset).Limit(limit).Order(orderNewestFirst).Find(&credentials).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -72,5 +75,24 @@ func (r *credentialRepository) Update(ctx context.Context, credential *model.Cre
 
 // Delete soft deletes a credential.
 func (r *credentialRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.Credential{}, "id = ?", id).Error
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.Credential{}, "id = ?", id))
+}
+
+// GetUsage reports the providers and resource requests that reference a credential, and the
+// resources those requests created.
+func (r *credentialRepository) GetUsage(ctx context.Context, id string) (*Usage, error) {
+	db := r.db.WithContext(ctx)
+	providers, err := loadUsageGroup(
+		db.Model(&model.ProviderConfig{}).Where("credential_id = ?", id),
+		"provider_configs", "id, name",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{Providers: providers}
+	if err := requestUsage(db, usage, "credential_id = ?", id); err != nil {
+		return nil, err
+	}
+	return usage, nil
 }
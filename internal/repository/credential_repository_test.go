@@ -0,0 +1,185 @@
+// Package repository provides credential and provider repository tests.
+package repository
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createTestCredential(t *testing.T, db *gorm.DB, name, credentialType string, zoneID *string) *model.Credential {
+	t.Helper()
+	credential := &model.Credential{Name: name, Type: credentialType, ZoneID: zoneID, CreatedByID: "user-1", Status: 1}
+	require.NoError(t, db.Create(credential).Error)
+	return credential
+}
+
+func credentialNames(credentials []*model.Credential) []string {
+	names := make([]string, 0, len(credentials))
+	for _, c := range credentials {
+		names = append(names, c.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCredentialRepository_ListByTypeAndZone(t *testing.T) {
+	db := newTestDB(t, &model.Credential{}, &model.Zone{}, &model.ProviderConfig{}, &model.User{})
+	repo := NewCredentialRepository(db)
+	ctx := context.Background()
+
+	zoneA, zoneB := "zone-a", "zone-b"
+	createTestCredential(t, db, "pve-zone-a", "pve", &zoneA)
+	createTestCredential(t, db, "pve-zone-b", "pve", &zoneB)
+	createTestCredential(t, db, "pve-global", "pve", nil)
+	createTestCredential(t, db, "vmware-global", "vmware", nil)
+	createTestCredential(t, db, "vmware-zone-a", "vmware", &zoneA)
+
+	credentials, total, err := repo.List(ctx, CredentialFilters{Type: "pve", ZoneID: zoneA}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, []string{"pve-global", "pve-zone-a"}, credentialNames(credentials))
+
+	credentials, total, err = repo.List(ctx, CredentialFilters{ZoneID: zoneB}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []string{"pve-global", "pve-zone-b", "vmware-global"}, credentialNames(credentials))
+
+	_, total, err = repo.List(ctx, CredentialFilters{Type: "pve"}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+}
+
+func TestProviderRepository_ListByTypeAndZone(t *testing.T) {
+	db := newTestDB(t, &model.Credential{}, &model.Zone{}, &model.ProviderConfig{}, &model.User{})
+	repo := NewProviderRepository(db)
+	ctx := context.Background()
+
+	zoneA, zoneB := "zone-a", "zone-b"
+	credA := createTestCredential(t, db, "pve-zone-a", "pve", &zoneA)
+	credB := createTestCredential(t, db, "pve-zone-b", "pve", &zoneB)
+	credGlobal := createTestCredential(t, db, "pve-global", "pve", nil)
+
+	for _, p := range []*model.ProviderConfig{
+		{Name: "pve-a", Type: "pve", Endpoint: "https://a", CredentialID: &credA.ID},
+		{Name: "pve-b", Type: "pve", Endpoint: "https://b", CredentialID: &credB.ID},
+		{Name: "pve-global", Type: "pve", Endpoint: "https://g", CredentialID: &credGlobal.ID},
+		{Name: "pve-nocred", Type: "pve", Endpoint: "https://n"},
+		{Name: "vmware-a", Type: "vmware", Endpoint: "https://v", CredentialID: &credA.ID},
+	} {
+		require.NoError(t, db.Create(p).Error)
+	}
+
+	providers, total, err := repo.List(ctx, ProviderFilters{Type: "pve", ZoneID: zoneA}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+
+	names := make([]string, 0, len(providers))
+	for _, p := range providers {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"pve-a", "pve-global", "pve-nocred"}, names)
+}
+
+func createTestRequest(t *testing.T, db *gorm.DB, title string, credentialID, resourceID *string) *model.ResourceRequest {
+	t.Helper()
+	request := &model.ResourceRequest{
+		Title:        title,
+		Spec:         "{}",
+		Environment:  "dev",
+		Provider:     "pve",
+		Type:         "vm",
+		RequesterID:  "user-1",
+		Status:       "completed",
+		CredentialID: credentialID,
+		ResourceID:   resourceID,
+	}
+	require.NoError(t, db.Create(request).Error)
+	return request
+}
+
+func createTestResource(t *testing.T, db *gorm.DB, name string) *model.Resource {
+	t.Helper()
+	resource := &model.Resource{Name: name, Type: "vm", Provider: "pve", OwnerID: "user-1", Environment: "dev", Status: "running"}
+	require.NoError(t, db.Create(resource).Error)
+	return resource
+}
+
+func usageNames(group *UsageGroup) []string {
+	names := make([]string, 0, len(group.Items))
+	for _, item := range group.Items {
+		names = append(names, item.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCredentialRepository_GetUsage(t *testing.T) {
+	db := newTestDB(t, &model.Credential{}, &model.ProviderConfig{}, &model.ResourceRequest{}, &model.Resource{})
+	repo := NewCredentialRepository(db)
+	ctx := context.Background()
+
+	used := createTestCredential(t, db, "pve-used", "pve", nil)
+	unused := createTestCredential(t, db, "pve-unused", "pve", nil)
+	other := createTestCredential(t, db, "pve-other", "pve", nil)
+
+	require.NoError(t, db.Create(&model.ProviderConfig{Name: "pve-a", Type: "pve", Endpoint: "https://a", CredentialID: &used.ID}).Error)
+	vm := createTestResource(t, db, "vm-1")
+	createTestResource(t, db, "vm-unrelated")
+	createTestRequest(t, db, "build vm-1", &used.ID, &vm.ID)
+	createTestRequest(t, db, "pending vm", &used.ID, nil)
+	createTestRequest(t, db, "other vm", &other.ID, nil)
+
+	usage, err := repo.GetUsage(ctx, used.ID)
+	require.NoError(t, err)
+	assert.True(t, usage.InUse())
+	assert.Equal(t, int64(1), usage.Providers.Count)
+	assert.Equal(t, []string{"pve-a"}, usageNames(usage.Providers))
+	assert.Equal(t, int64(2), usage.Requests.Count)
+	assert.Equal(t, []string{"build vm-1", "pending vm"}, usageNames(usage.Requests))
+	assert.Equal(t, "completed", usage.Requests.Items[0].Status)
+	assert.Equal(t, []string{"vm-1"}, usageNames(usage.Resources))
+	assert.Nil(t, usage.Allocations)
+
+	usage, err = repo.GetUsage(ctx, unused.ID)
+	require.NoError(t, err)
+	assert.False(t, usage.InUse())
+	assert.Empty(t, usage.Requests.Items)
+	assert.Equal(t, int64(0), usage.Resources.Count)
+}
+
+func TestProviderRepository_GetUsage(t *testing.T) {
+	db := newTestDB(t, &model.Credential{}, &model.ProviderConfig{}, &model.ResourceRequest{}, &model.Resource{})
+	repo := NewProviderRepository(db)
+	ctx := context.Background()
+
+	provider := &model.ProviderConfig{Name: "pve-a", Type: "pve", Endpoint: "https://a"}
+	idle := &model.ProviderConfig{Name: "pve-idle", Type: "pve", Endpoint: "https://i"}
+	require.NoError(t, db.Create(provider).Error)
+	require.NoError(t, db.Create(idle).Error)
+
+	linked := createTestCredential(t, db, "pve-linked", "pve", nil)
+	require.NoError(t, db.Model(linked).Update("provider_id", provider.ID).Error)
+	deleted := createTestCredential(t, db, "pve-deleted", "pve", nil)
+	require.NoError(t, db.Model(deleted).Update("provider_id", provider.ID).Error)
+	require.NoError(t, db.Delete(deleted).Error)
+
+	vm := createTestResource(t, db, "vm-1")
+	createTestRequest(t, db, "build vm-1", &linked.ID, &vm.ID)
+
+	usage, err := repo.GetUsage(ctx, provider.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pve-linked"}, usageNames(usage.Credentials))
+	assert.Equal(t, int64(1), usage.Requests.Count)
+	assert.Equal(t, []string{"vm-1"}, usageNames(usage.Resources))
+
+	usage, err = repo.GetUsage(ctx, idle.ID)
+	require.NoError(t, err)
+	assert.False(t, usage.InUse())
+}
@@ -0,0 +1,67 @@
+// Package repository provides data access layer implementations.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"gorm.io/gorm"
+)
+
+// DelegationRepository defines the interface for approval delegation operations.
+type DelegationRepository interface {
+	Create(ctx context.Context, delegation *model.Delegation) error
+	GetByID(ctx context.Context, id string) (*model.Delegation, error)
+	// ListByUser returns the delegations userID granted or received, newest first.
+	ListByUser(ctx context.Context, userID string) ([]*model.Delegation, error)
+	// ListActiveTo returns the delegations to toUserID whose window contains at.
+	ListActiveTo(ctx context.Context, toUserID string, at time.Time) ([]*model.Delegation, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type delegationRepository struct {
+	db *gorm.DB
+}
+
+// NewDelegationRepository creates a new delegation repository.
+func NewDelegationRepository(db *gorm.DB) DelegationRepository {
+	return &delegationRepository{db: db}
+}
+
+// Create creates a new delegation.
+func (r *delegationRepository) Create(ctx context.Context, delegation *model.Delegation) error {
+	return r.db.WithContext(ctx).Create(delegation).Error
+}
+
+// GetByID retrieves a delegation by ID.
+func (r *delegationRepository) GetByID(ctx context.Context, id string) (*model.Delegation, error) {
+	return firstOrNotFound[model.Delegation](r.db.WithContext(ctx).Preload("FromUser").Preload("ToUser"), "id = ?", id)
+}
+
+func (r *delegationRepository) ListByUser(ctx context.Context, userID string) ([]*model.Delegation, error) {
+	var delegations []*model.Delegation
+	if err := r.db.WithContext(ctx).Preload("FromUser").Preload("ToUser").
+		Where("from_user_id = ? OR to_user_id = ?", userID, userID).
+		Order(orderNewestFirst).
+		Find(&delegations).Error; err != nil {
+		return nil, err
+	}
+	return delegations, nil
+}
+
+func (r *delegationRepository) ListActiveTo(ctx context.Context, toUserID string, at time.Time) ([]*model.Delegation, error) {
+	var delegations []*model.Delegation
+	if err := r.db.WithContext(ctx).
+		Where("to_user_id = ? AND starts_at <= ? AND ends_at > ?", toUserID, at, at).
+		Order(orderOldestFirst).
+		Find(&delegations).Error; err != nil {
+		return nil, err
+	}
+	return delegations, nil
+}
+
+// Delete soft deletes a delegation.
+func (r *delegationRepository) Delete(ctx context.Context, id string) error {
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.Delegation{}, "id = ?", id))
+}
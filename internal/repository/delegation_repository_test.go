@@ -0,0 +1,41 @@
+// Package repository provides delegation repository tests.
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelegationRepository_ListActiveTo(t *testing.T) {
+	db := newTestDB(t, &model.User{}, &model.Delegation{})
+	repo := NewDelegationRepository(db)
+	ctx := context.Background()
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, d := range []*model.Delegation{
+		{FromUserID: "lead", ToUserID: "deputy", Scope: model.DelegationScopeAll, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Reason: "active"},
+		{FromUserID: "lead", ToUserID: "deputy", Scope: model.DelegationScopeAll, StartsAt: now.Add(-2 * time.Hour), EndsAt: now, Reason: "ends now"},
+		{FromUserID: "lead", ToUserID: "deputy", Scope: model.DelegationScopeAll, StartsAt: now, EndsAt: now.Add(time.Hour), Reason: "starts now"},
+		{FromUserID: "lead", ToUserID: "deputy", Scope: model.DelegationScopeAll, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour), Reason: "future"},
+		{FromUserID: "lead", ToUserID: "other", Scope: model.DelegationScopeAll, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Reason: "other delegate"},
+	} {
+		require.NoError(t, repo.Create(ctx, d))
+	}
+
+	active, err := repo.ListActiveTo(ctx, "deputy", now)
+	require.NoError(t, err)
+	reasons := make([]string, 0, len(active))
+	for _, d := range active {
+		reasons = append(reasons, d.Reason)
+	}
+	assert.ElementsMatch(t, []string{"active", "starts now"}, reasons)
+
+	mine, err := repo.ListByUser(ctx, "lead")
+	require.NoError(t, err)
+	assert.Len(t, mine, 5)
+}
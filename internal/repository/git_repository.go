@@ -3,10 +3,11 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"time"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GitRepoRepository defines the interface for git repository data access.
@@ -14,7 +15,7 @@ type GitRepoRepository interface {
 	Create(ctx context.Context, repo *model.GitRepository) error
 	GetByID(ctx context.Context, id string) (*model.GitRepository, error)
 	GetByType(ctx context.Context, repoType model.GitRepoType) ([]model.GitRepository, error)
-	GetDefaultByType(ctx context.Context, repoType model.GitRepoType) (*model.GitRepository, error)
+	GetDefaultByType(ctx context.Context, repoType model.GitRepoType, environment string) (*model.GitRepository, error)
 	List(ctx context.Context, page, pageSize int) ([]model.GitRepository, int64, error)
 	Update(ctx context.Context, repo *model.GitRepository) error
 	Delete(ctx context.Context, id string) error
@@ -34,38 +35,29 @@ func (r *gitRepoRepository) Create(ctx context.Context, repo *model.GitRepositor
 }
 
 func (r *gitRepoRepository) GetByID(ctx context.Context, id string) (*model.GitRepository, error) {
-	var repo model.GitRepository
-	if err := r.db.WithContext(ctx).First(&repo, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &repo, nil
+	return firstOrNotFound[model.GitRepository](r.db.WithContext(ctx), "id = ?", id)
 }
 
 func (r *gitRepoRepository) GetByType(ctx context.Context, repoType model.GitRepoType) ([]model.GitRepository, error) {
 	var repos []model.GitRepository
 	if err := r.db.WithContext(ctx).
 		Where("type = ? AND status = ?", repoType, 1).
-		Order("created_at DESC").
+		Order(orderNewestFirst).
 		Find(&repos).Error; err != nil {
 		return nil, err
 	}
 	return repos, nil
 }
 
-func (r *gitRepoRepository) GetDefaultByType(ctx context.Context, repoType model.GitRepoType) (*model.GitRepository, error) {
-	var repo model.GitRepository
-	if err := r.db.WithContext(ctx).
+// GetDefaultByType returns the default repository of a type for an environment: the default
+// scoped to that environment if there is one, otherwise the global default.
+func (r *gitRepoRepository) GetDefaultByType(ctx context.Context, repoType model.GitRepoType, environment string) (*model.GitRepository, error) {
+	query := r.db.WithContext(ctx).
 		Where("type = ? AND is_default = ? AND status = ?", repoType, true, 1).
-		First(&repo).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &repo, nil
+		Where("environment = ? OR environment = '' OR environment IS NULL", environment).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "CASE WHEN environment = ? THEN 0 ELSE 1 END", Vars: []interface{}{environment}}}).
+		Order(orderNewestFirst)
+	return firstOrNotFound[model.GitRepository](query)
 }
 
 func (r *gitRepoRepository) List(ctx context.Context, page, pageSize int) ([]model.GitRepository, int64, error) {
@@ -78,7 +70,7 @@ func (r *gitRepoRepository) List(ctx context.Context, page, pageSize int) ([]mod
 
 	offset := (page - 1) * pageSize
 	if err := r.db.WithContext(ctx).
-		Order("created_at DESC").
+		Order(orderNewestFirst).
 		Offset(offset).Limit(pageSize).
 		Find(&repos).Error; err != nil {
 		return nil, 0, err
@@ -92,7 +84,7 @@ func (r *gitRepoRepository) Update(ctx context.Context, repo *model.GitRepositor
 }
 
 func (r *gitRepoRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.GitRepository{}, "id = ?", id).Error
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.GitRepository{}, "id = ?", id))
 }
 
 // NodeConfigRepository defines the interface for node config data access.
@@ -102,10 +94,21 @@ type NodeConfigRepository interface {
 	GetByResourceRequestID(ctx context.Context, requestID string) (*model.NodeConfig, error)
 	ListByStorageRepo(ctx context.Context, repoID string, page, pageSize int) ([]model.NodeConfig, int64, error)
 	ListByStatus(ctx context.Context, status model.NodeConfigStatus, page, pageSize int) ([]model.NodeConfig, int64, error)
+	List(ctx context.Context, filters NodeConfigFilters, limit int) ([]model.NodeConfig, error)
+	ResetForRetry(ctx context.Context, id string) (bool, error)
+	ExistsByPath(ctx context.Context, storageRepoID, path string) (bool, error)
 	Update(ctx context.Context, config *model.NodeConfig) error
 	Delete(ctx context.Context, id string) error
 }
 
+// NodeConfigFilters defines filters for node config queries.
+type NodeConfigFilters struct {
+	Status        model.NodeConfigStatus
+	StorageRepoID string
+	Provider      string    // Matched through the config's resource request
+	UpdatedBefore time.Time // Zero means no age limit
+}
+
 type nodeConfigRepository struct {
 	db *gorm.DB
 }
@@ -120,34 +123,20 @@ func (r *nodeConfigRepository) Create(ctx context.Context, config *model.NodeCon
 }
 
 func (r *nodeConfigRepository) GetByID(ctx context.Context, id string) (*model.NodeConfig, error) {
-	var config model.NodeConfig
-	if err := r.db.WithContext(ctx).
+	query := r.db.WithContext(ctx).
 		Preload("ResourceRequest").
 		Preload("StorageRepo").
-		Preload("ModuleRepo").
-		First(&config, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &config, nil
+		Preload("ModuleRepo")
+	return firstOrNotFound[model.NodeConfig](query, "id = ?", id)
 }
 
 func (r *nodeConfigRepository) GetByResourceRequestID(ctx context.Context, requestID string) (*model.NodeConfig, error) {
-	var config model.NodeConfig
-	if err := r.db.WithContext(ctx).
+	query := r.db.WithContext(ctx).
 		Preload("ResourceRequest").
 		Preload("StorageRepo").
 		Preload("ModuleRepo").
-		Where("resource_request_id = ?", requestID).
-		First(&config).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &config, nil
+		Where("resource_request_id = ?", requestID)
+	return firstOrNotFound[model.NodeConfig](query)
 }
 
 func (r *nodeConfigRepository) ListByStorageRepo(ctx context.Context, repoID string, page, pageSize int) ([]model.NodeConfig, int64, error) {
@@ -164,7 +153,7 @@ func (r *nodeConfigRepository) ListByStorageRepo(ctx context.Context, repoID str
 	if err := query.
 		Preload("ResourceRequest").
 		Preload("StorageRepo").
-		Order("created_at DESC").
+		Order(orderNewestFirst).
 		Offset(offset).Limit(pageSize).
 		Find(&configs).Error; err != nil {
 		return nil, 0, err
@@ -187,7 +176,7 @@ func (r *nodeConfigRepository) ListByStatus(ctx context.Context, status model.No
 	if err := query.
 		Preload("ResourceRequest").
 		Preload("StorageRepo").
-		Order("created_at DESC").
+		Order(orderNewestFirst).
 		Offset(offset).Limit(pageSize).
 		Find(&configs).Error; err != nil {
 		return nil, 0, err
@@ -196,10 +185,60 @@ func (r *nodeConfigRepository) ListByStatus(ctx context.Context, status model.No
 	return configs, total, nil
 }
 
+// List retrieves up to limit node configs matching the filters, oldest first.
+func (r *nodeConfigRepository) List(ctx context.Context, filters NodeConfigFilters, limit int) ([]model.NodeConfig, error) {
+	query := r.db.WithContext(ctx).Model(&model.NodeConfig{})
+	if filters.Status != "" {
+		query = query.Where("node_configs.status = ?", filters.Status)
+	}
+	if filters.StorageRepoID != "" {
+		query = query.Where("node_configs.storage_repo_id = ?", filters.StorageRepoID)
+	}
+	if filters.Provider != "" {
+		query = query.Where("node_configs.resource_request_id IN (?)",
+			r.db.Model(&model.ResourceRequest{}).Select("id").Where("provider = ?", filters.Provider))
+	}
+	if !filters.UpdatedBefore.IsZero() {
+		query = query.Where("node_configs.updated_at < ?", filters.UpdatedBefore)
+	}
+
+	var configs []model.NodeConfig
+	if err := query.Order("node_configs.updated_at ASC, node_configs.id ASC").Limit(limit).Find(&configs).Error; err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// ResetForRetry moves a failed node config back to approved and clears its error. It reports
+// false when the config is no longer failed, so concurrent retries cannot both claim it.
+func (r *nodeConfigRepository) ResetForRetry(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.NodeConfig{}).
+		Where("id = ? AND status = ?", id, model.NodeConfigStatusFailed).
+		Updates(map[string]interface{}{
+			"status":        model.NodeConfigStatusApproved,
+			"error_message": "",
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ExistsByPath reports whether a node config already uses the path in the storage repository.
+func (r *nodeConfigRepository) ExistsByPath(ctx context.Context, storageRepoID, path string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.NodeConfig{}).
+		Where("storage_repo_id = ? AND path = ?", storageRepoID, path).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 func (r *nodeConfigRepository) Update(ctx context.Context, config *model.NodeConfig) error {
 	return r.db.WithContext(ctx).Save(config).Error
 }
 
 func (r *nodeConfigRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.NodeConfig{}, "id = ?", id).Error
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.NodeConfig{}, "id = ?", id))
 }
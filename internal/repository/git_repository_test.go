@@ -0,0 +1,165 @@
+// Package repository provides git repository tests.
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeConfigRepository_ExistsByPath(t *testing.T) {
+	db := newTestDB(t, &model.NodeConfig{})
+	repo := NewNodeConfigRepository(db)
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&model.NodeConfig{
+		Name:              "minio-1234abcd",
+		Path:              "proxmox-ve/instance/vm/minio-1234abcd",
+		ResourceRequestID: "req-1",
+		StorageRepoID:     "storage-1",
+	}).Error)
+
+	exists, err := repo.ExistsByPath(ctx, "storage-1", "proxmox-ve/instance/vm/minio-1234abcd")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = repo.ExistsByPath(ctx, "storage-2", "proxmox-ve/instance/vm/minio-1234abcd")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = repo.ExistsByPath(ctx, "storage-1", "proxmox-ve/instance/vm/minio-5678efgh")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestNodeConfigRepository_ListAndResetForRetry(t *testing.T) {
+	db := newTestDB(t, &model.NodeConfig{}, &model.ResourceRequest{})
+	repo := NewNodeConfigRepository(db)
+	ctx := context.Background()
+
+	pveRequest := createTestRequest(t, db, "pve request", nil, nil)
+	awsRequest := createTestRequest(t, db, "aws request", nil, nil)
+	require.NoError(t, db.Model(awsRequest).Update("provider", "aws").Error)
+
+	create := func(name, requestID, storageRepoID string, status model.NodeConfigStatus, updatedAt time.Time) *model.NodeConfig {
+		config := &model.NodeConfig{
+			Name:              name,
+			Path:              "proxmox-ve/instance/vm/" + name,
+			ResourceRequestID: requestID,
+			StorageRepoID:     storageRepoID,
+			Status:            status,
+			ErrorMessage:      "provider unavailable",
+		}
+		require.NoError(t, db.Create(config).Error)
+		require.NoError(t, db.Model(config).UpdateColumn("updated_at", updatedAt).Error)
+		return config
+	}
+	now := time.Now()
+	oldFailed := create("old-failed", pveRequest.ID, "storage-1", model.NodeConfigStatusFailed, now.Add(-2*time.Hour))
+	newFailed := create("new-failed", pveRequest.ID, "storage-1", model.NodeConfigStatusFailed, now)
+	otherRepo := create("other-repo", pveRequest.ID, "storage-2", model.NodeConfigStatusFailed, now.Add(-3*time.Hour))
+	awsFailed := create("aws-failed", awsRequest.ID, "storage-1", model.NodeConfigStatusFailed, now.Add(-time.Hour))
+	create("active", pveRequest.ID, "storage-1", model.NodeConfigStatusActive, now.Add(-4*time.Hour))
+
+	ids := func(configs []model.NodeConfig) []string {
+		out := make([]string, 0, len(configs))
+		for _, config := range configs {
+			out = append(out, config.ID)
+		}
+		return out
+	}
+
+	t.Run("failed configs oldest first", func(t *testing.T) {
+		configs, err := repo.List(ctx, NodeConfigFilters{Status: model.NodeConfigStatusFailed}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{otherRepo.ID, oldFailed.ID, awsFailed.ID, newFailed.ID}, ids(configs))
+	})
+
+	t.Run("filters combine", func(t *testing.T) {
+		configs, err := repo.List(ctx, NodeConfigFilters{
+			Status:        model.NodeConfigStatusFailed,
+			StorageRepoID: "storage-1",
+			Provider:      "pve",
+			UpdatedBefore: now.Add(-time.Minute),
+		}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{oldFailed.ID}, ids(configs))
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		configs, err := repo.List(ctx, NodeConfigFilters{Status: model.NodeConfigStatusFailed}, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{otherRepo.ID, oldFailed.ID}, ids(configs))
+	})
+
+	t.Run("reset only applies to failed configs", func(t *testing.T) {
+		reset, err := repo.ResetForRetry(ctx, oldFailed.ID)
+		require.NoError(t, err)
+		assert.True(t, reset)
+
+		config, err := repo.GetByID(ctx, oldFailed.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.NodeConfigStatusApproved, config.Status)
+		assert.Empty(t, config.ErrorMessage)
+
+		reset, err = repo.ResetForRetry(ctx, oldFailed.ID)
+		require.NoError(t, err)
+		assert.False(t, reset, "a config already reset is no longer failed")
+	})
+}
+
+func TestGitRepoRepository_GetDefaultByType(t *testing.T) {
+	db := newTestDB(t, &model.GitRepository{})
+	repo := NewGitRepoRepository(db)
+	ctx := context.Background()
+
+	create := func(name string, repoType model.GitRepoType, environment string, isDefault bool) *model.GitRepository {
+		gitRepo := &model.GitRepository{
+			Name:        name,
+			Type:        repoType,
+			URL:         "https://git.example.com/lab/" + name + ".git",
+			IsDefault:   isDefault,
+			Environment: environment,
+			Status:      1,
+		}
+		require.NoError(t, db.Create(gitRepo).Error)
+		return gitRepo
+	}
+	global := create("storage", model.GitRepoTypeStorage, "", true)
+	prod := create("storage-prod", model.GitRepoTypeStorage, "prod", true)
+	create("storage-dev-draft", model.GitRepoTypeStorage, "dev", false)
+	modules := create("modules", model.GitRepoTypeModules, "", true)
+
+	t.Run("environment default wins over the global one", func(t *testing.T) {
+		got, err := repo.GetDefaultByType(ctx, model.GitRepoTypeStorage, "prod")
+		require.NoError(t, err)
+		assert.Equal(t, prod.ID, got.ID)
+	})
+
+	t.Run("falls back to the global default", func(t *testing.T) {
+		got, err := repo.GetDefaultByType(ctx, model.GitRepoTypeStorage, "dev")
+		require.NoError(t, err)
+		assert.Equal(t, global.ID, got.ID, "a non-default dev repository is not used")
+
+		got, err = repo.GetDefaultByType(ctx, model.GitRepoTypeModules, "prod")
+		require.NoError(t, err)
+		assert.Equal(t, modules.ID, got.ID)
+	})
+
+	t.Run("without environment only the global default matches", func(t *testing.T) {
+		got, err := repo.GetDefaultByType(ctx, model.GitRepoTypeStorage, "")
+		require.NoError(t, err)
+		assert.Equal(t, global.ID, got.ID)
+	})
+
+	t.Run("another environment's default is never used", func(t *testing.T) {
+		require.NoError(t, db.Model(global).Update("is_default", false).Error)
+
+		_, err := repo.GetDefaultByType(ctx, model.GitRepoTypeStorage, "staging")
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+}
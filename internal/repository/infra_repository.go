@@ -3,19 +3,34 @@ package repository
 
 import (
 	"context"
-	"errors"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/gorm"
 )
 
+// InfraListOptions controls what region and zone listings load besides the rows.
+type InfraListOptions struct {
+	// SkipPreload lists the rows alone: regions without their zones, and zones without their
+	// region, which they still reference by region_id.
+	SkipPreload bool
+}
+
+// preload adds association to query unless the options skip it. GORM loads it for every
+// listed row with a single IN query.
+func (o InfraListOptions) preload(query *gorm.DB, association string) *gorm.DB {
+	if o.SkipPreload {
+		return query
+	}
+	return query.Preload(association)
+}
+
 // RegionRepository defines the interface for region data access.
 type RegionRepository interface {
 	Create(ctx context.Context, region *model.Region) error
 	GetByID(ctx context.Context, id string) (*model.Region, error)
 	GetByCode(ctx context.Context, code string) (*model.Region, error)
-	List(ctx context.Context, page, pageSize int) ([]model.Region, int64, error)
-	ListAll(ctx context.Context) ([]model.Region, error)
+	List(ctx context.Context, page, pageSize int, opts InfraListOptions) ([]model.Region, int64, error)
+	ListAll(ctx context.Context, opts InfraListOptions) ([]model.Region, error)
 	Update(ctx context.Context, region *model.Region) error
 	Delete(ctx context.Context, id string) error
 }
@@ -36,30 +51,16 @@ func (r *regionRepository) Create(ctx context.Context, region *model.Region) err
 
 // GetByID retrieves a region by ID.
 func (r *regionRepository) GetByID(ctx context.Context, id string) (*model.Region, error) {
-	var region model.Region
-	if err := r.db.WithContext(ctx).Preload("Zones").First(&region, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &region, nil
+	return firstOrNotFound[model.Region](r.db.WithContext(ctx).Preload("Zones"), "id = ?", id)
 }
 
 // GetByCode retrieves a region by code.
 func (r *regionRepository) GetByCode(ctx context.Context, code string) (*model.Region, error) {
-	var region model.Region
-	if err := r.db.WithContext(ctx).Preload("Zones").First(&region, "code = ?", code).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &region, nil
+	return firstOrNotFound[model.Region](r.db.WithContext(ctx).Preload("Zones"), "code = ?", code)
 }
 
 // List retrieves regions with pagination.
-func (r *regionRepository) List(ctx context.Context, page, pageSize int) ([]model.Region, int64, error) {
+func (r *regionRepository) List(ctx context.Context, page, pageSize int, opts InfraListOptions) ([]model.Region, int64, error) {
 	var regions []model.Region
 	var total int64
 
@@ -68,8 +69,8 @@ func (r *regionRepository) List(ctx context.Context, page, pageSize int) ([]mode
 	}
 
 	offset := (page - 1) * pageSize
-	if err := r.db.WithContext(ctx).Preload("Zones").
-		Order("created_at DESC").
+	if err := opts.preload(r.db.WithContext(ctx), "Zones").
+		Order(orderNewestFirst).
 		Offset(offset).Limit(pageSize).
 		Find(&regions).Error; err != nil {
 		return nil, 0, err
@@ -79,11 +80,11 @@ func (r *regionRepository) List(ctx context.Context, page, pageSize int) ([]mode
 }
 
 // ListAll retrieves all active regions.
-func (r *regionRepository) ListAll(ctx context.Context) ([]model.Region, error) {
+func (r *regionRepository) ListAll(ctx context.Context, opts InfraListOptions) ([]model.Region, error) {
 	var regions []model.Region
-	if err := r.db.WithContext(ctx).Preload("Zones").
+	if err := opts.preload(r.db.WithContext(ctx), "Zones").
 		Where("status = ?", 1).
-		Order("name ASC").
+		Order(orderByName).
 		Find(&regions).Error; err != nil {
 		return nil, err
 	}
@@ -97,7 +98,7 @@ func (r *regionRepository) Update(ctx context.Context, region *model.Region) err
 
 // Delete soft deletes a region.
 func (r *regionRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.Region{}, "id = ?", id).Error
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.Region{}, "id = ?", id))
 }
 
 // ZoneRepository defines the interface for zone data access.
@@ -105,8 +106,8 @@ type ZoneRepository interface {
 	Create(ctx context.Context, zone *model.Zone) error
 	GetByID(ctx context.Context, id string) (*model.Zone, error)
 	GetByCode(ctx context.Context, code string) (*model.Zone, error)
-	List(ctx context.Context, page, pageSize int) ([]model.Zone, int64, error)
-	ListByRegion(ctx context.Context, regionID string) ([]model.Zone, error)
+	List(ctx context.Context, page, pageSize int, opts InfraListOptions) ([]model.Zone, int64, error)
+	ListByRegion(ctx context.Context, regionID string, opts InfraListOptions) ([]model.Zone, error)
 	Update(ctx context.Context, zone *model.Zone) error
 	Delete(ctx context.Context, id string) error
 }
@@ -127,34 +128,20 @@ func (r *zoneRepository) Create(ctx context.Context, zone *model.Zone) error {
 
 // GetByID retrieves a zone by ID.
 func (r *zoneRepository) GetByID(ctx context.Context, id string) (*model.Zone, error) {
-	var zone model.Zone
-	if err := r.db.WithContext(ctx).
-		Preload("Region").
-		First(&zone, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &zone, nil
+	query := r.db.WithContext(ctx).
+		Preload("Region")
+	return firstOrNotFound[model.Zone](query, "id = ?", id)
 }
 
 // GetByCode retrieves a zone by code.
 func (r *zoneRepository) GetByCode(ctx context.Context, code string) (*model.Zone, error) {
-	var zone model.Zone
-	if err := r.db.WithContext(ctx).
-		Preload("Region").
-		First(&zone, "code = ?", code).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &zone, nil
+	query := r.db.WithContext(ctx).
+		Preload("Region")
+	return firstOrNotFound[model.Zone](query, "code = ?", code)
 }
 
 // List retrieves zones with pagination.
-func (r *zoneRepository) List(ctx context.Context, page, pageSize int) ([]model.Zone, int64, error) {
+func (r *zoneRepository) List(ctx context.Context, page, pageSize int, opts InfraListOptions) ([]model.Zone, int64, error) {
 	var zones []model.Zone
 	var total int64
 
@@ -163,9 +150,8 @@ func (r *zoneRepository) List(ctx context.Context, page, pageSize int) ([]model.
 	}
 
 	offset := (page - 1) * pageSize
-	if err := r.db.WithContext(ctx).
-		Preload("Region").
-		Order("created_at DESC").
+	if err := opts.preload(r.db.WithContext(ctx), "Region").
+		Order(orderNewestFirst).
 		Offset(offset).Limit(pageSize).
 		Find(&zones).Error; err != nil {
 		return nil, 0, err
@@ -175,12 +161,11 @@ func (r *zoneRepository) List(ctx context.Context, page, pageSize int) ([]model.
 }
 
 // ListByRegion retrieves all active zones in a region.
-func (r *zoneRepository) ListByRegion(ctx context.Context, regionID string) ([]model.Zone, error) {
+func (r *zoneRepository) ListByRegion(ctx context.Context, regionID string, opts InfraListOptions) ([]model.Zone, error) {
 	var zones []model.Zone
-	if err := r.db.WithContext(ctx).
-		Preload("Region").
+	if err := opts.preload(r.db.WithContext(ctx), "Region").
 		Where("region_id = ? AND status = ?", regionID, 1).
-		Order("name ASC").
+		Order(orderByName).
 		Find(&zones).Error; err != nil {
 		return nil, err
 	}
@@ -194,7 +179,7 @@ func (r *zoneRepository) Update(ctx context.Context, zone *model.Zone) error {
 
 // Delete soft deletes a zone.
 func (r *zoneRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.Zone{}, "id = ?", id).Error
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.Zone{}, "id = ?", id))
 }
 
 // TerraformRegistryRepository defines the interface for terraform registry data access.
@@ -221,14 +206,7 @@ func (r *terraformRegistryRepository) Create(ctx context.Context, registry *mode
 }
 
 func (r *terraformRegistryRepository) GetByID(ctx context.Context, id string) (*model.TerraformRegistry, error) {
-	var registry model.TerraformRegistry
-	if err := r.db.WithContext(ctx).First(&registry, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &registry, nil
+	return firstOrNotFound[model.TerraformRegistry](r.db.WithContext(ctx), "id = ?", id)
 }
 
 func (r *terraformRegistryRepository) List(ctx context.Context, page, pageSize int) ([]model.TerraformRegistry, int64, error) {
@@ -241,7 +219,7 @@ func (r *terraformRegistryRepository) List(ctx context.Context, page, pageSize i
 
 	offset := (page - 1) * pageSize
 	if err := r.db.WithContext(ctx).
-		Order("created_at DESC").
+		Order(orderNewestFirst).
 		Offset(offset).Limit(pageSize).
 		Find(&registries).Error; err != nil {
 		return nil, 0, err
@@ -254,7 +232,7 @@ func (r *terraformRegistryRepository) ListAll(ctx context.Context) ([]model.Terr
 	var registries []model.TerraformRegistry
 	if err := r.db.WithContext(ctx).
 		Where("status = ?", 1).
-		Order("name ASC").
+		Order(orderByName).
 		Find(&registries).Error; err != nil {
 		return nil, err
 	}
@@ -266,7 +244,7 @@ func (r *terraformRegistryRepository) Update(ctx context.Context, registry *mode
 }
 
 func (r *terraformRegistryRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.TerraformRegistry{}, "id = ?", id).Error
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.TerraformRegistry{}, "id = ?", id))
 }
 
 // TerraformProviderRepository defines the interface for terraform provider data access.
@@ -293,14 +271,7 @@ func (r *terraformProviderRepository) Create(ctx context.Context, provider *mode
 }
 
 func (r *terraformProviderRepository) GetByID(ctx context.Context, id string) (*model.TerraformProvider, error) {
-	var provider model.TerraformProvider
-	if err := r.db.WithContext(ctx).Preload("Registry").First(&provider, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &provider, nil
+	return firstOrNotFound[model.TerraformProvider](r.db.WithContext(ctx).Preload("Registry"), "id = ?", id)
 }
 
 func (r *terraformProviderRepository) List(ctx context.Context, page, pageSize int) ([]model.TerraformProvider, int64, error) {
@@ -313,7 +284,7 @@ func (r *terraformProviderRepository) List(ctx context.Context, page, pageSize i
 
 	offset := (page - 1) * pageSize
 	if err := r.db.WithContext(ctx).Preload("Registry").
-		Order("created_at DESC").
+		Order(orderNewestFirst).
 		Offset(offset).Limit(pageSize).
 		Find(&providers).Error; err != nil {
 		return nil, 0, err
@@ -326,7 +297,7 @@ func (r *terraformProviderRepository) ListByRegistry(ctx context.Context, regist
 	var providers []model.TerraformProvider
 	if err := r.db.WithContext(ctx).Preload("Registry").
 		Where("registry_id = ? AND status = ?", registryID, 1).
-		Order("name ASC").
+		Order(orderByName).
 		Find(&providers).Error; err != nil {
 		return nil, err
 	}
@@ -338,7 +309,7 @@ func (r *terraformProviderRepository) Update(ctx context.Context, provider *mode
 }
 
 func (r *terraformProviderRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.TerraformProvider{}, "id = ?", id).Error
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.TerraformProvider{}, "id = ?", id))
 }
 
 // TerraformModuleRepository defines the interface for terraform module data access.
@@ -366,25 +337,11 @@ func (r *terraformModuleRepository) Create(ctx context.Context, module *model.Te
 }
 
 func (r *terraformModuleRepository) GetByID(ctx context.Context, id string) (*model.TerraformModule, error) {
-	var module model.TerraformModule
-	if err := r.db.WithContext(ctx).First(&module, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &module, nil
+	return firstOrNotFound[model.TerraformModule](r.db.WithContext(ctx), "id = ?", id)
 }
 
 func (r *terraformModuleRepository) GetBySource(ctx context.Context, source string) (*model.TerraformModule, error) {
-	var module model.TerraformModule
-	if err := r.db.WithContext(ctx).First(&module, "source = ?", source).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &module, nil
+	return firstOrNotFound[model.TerraformModule](r.db.WithContext(ctx), "source = ?", source)
 }
 
 func (r *terraformModuleRepository) List(ctx context.Context, page, pageSize int) ([]model.TerraformModule, int64, error) {
@@ -397,7 +354,7 @@ func (r *terraformModuleRepository) List(ctx context.Context, page, pageSize int
 
 	offset := (page - 1) * pageSize
 	if err := r.db.WithContext(ctx).
-		Order("created_at DESC").
+		Order(orderNewestFirst).
 		Offset(offset).Limit(pageSize).
 		Find(&modules).Error; err != nil {
 		return nil, 0, err
@@ -410,7 +367,7 @@ func (r *terraformModuleRepository) ListAll(ctx context.Context) ([]model.Terraf
 	var modules []model.TerraformModule
 	if err := r.db.WithContext(ctx).
 		Where("status = ?", 1).
-		Order("name ASC").
+		Order(orderByName).
 		Find(&modules).Error; err != nil {
 		return nil, err
 	}
@@ -422,5 +379,5 @@ func (r *terraformModuleRepository) Update(ctx context.Context, module *model.Te
 }
 
 func (r *terraformModuleRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.TerraformModule{}, "id = ?", id).Error
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.TerraformModule{}, "id = ?", id))
 }
@@ -0,0 +1,120 @@
+// Package repository provides region and zone repository tests.
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// countQueries counts the select statements issued on db, preloads included.
+func countQueries(t *testing.T, db *gorm.DB) *int {
+	t.Helper()
+	queries := new(int)
+	require.NoError(t, db.Callback().Query().Before("gorm:query").Register("test:count_queries", func(*gorm.DB) {
+		*queries++
+	}))
+	return queries
+}
+
+// seedRegions creates regions regions with zonesPerRegion zones each.
+func seedRegions(t *testing.T, db *gorm.DB, regions, zonesPerRegion int) []string {
+	t.Helper()
+	ids := make([]string, 0, regions)
+	for i := range regions {
+		region := &model.Region{Name: fmt.Sprintf("region-%d", i), Code: fmt.Sprintf("r%d", i), DisplayName: "Region", Status: 1}
+		require.NoError(t, db.Create(region).Error)
+		for j := range zonesPerRegion {
+			zone := &model.Zone{
+				Name: fmt.Sprintf("zone-%d", j), Code: fmt.Sprintf("r%d-z%d", i, j),
+				DisplayName: "Zone", RegionID: region.ID, Status: 1,
+			}
+			require.NoError(t, db.Create(zone).Error)
+		}
+		ids = append(ids, region.ID)
+	}
+	return ids
+}
+
+func TestRegionRepository_ListPreload(t *testing.T) {
+	db := newTestDB(t, &model.Region{}, &model.Zone{})
+	seedRegions(t, db, 6, 3)
+	queries := countQueries(t, db)
+	repo := NewRegionRepository(db)
+	ctx := context.Background()
+
+	t.Run("skipping preloads lists the regions alone", func(t *testing.T) {
+		*queries = 0
+		regions, err := repo.ListAll(ctx, InfraListOptions{SkipPreload: true})
+		require.NoError(t, err)
+		assert.Len(t, regions, 6)
+		assert.Equal(t, 1, *queries)
+		for _, region := range regions {
+			assert.Empty(t, region.Zones)
+		}
+	})
+
+	t.Run("zones are loaded in one batch", func(t *testing.T) {
+		*queries = 0
+		regions, err := repo.ListAll(ctx, InfraListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, regions, 6)
+		assert.Equal(t, 2, *queries, "one query for the regions and one for all their zones")
+		for _, region := range regions {
+			assert.Len(t, region.Zones, 3)
+		}
+	})
+
+	t.Run("paginated", func(t *testing.T) {
+		*queries = 0
+		regions, total, err := repo.List(ctx, 1, 4, InfraListOptions{SkipPreload: true})
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), total)
+		assert.Len(t, regions, 4)
+		assert.Equal(t, 2, *queries, "the count and the page")
+
+		*queries = 0
+		regions, _, err = repo.List(ctx, 1, 4, InfraListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, regions, 4)
+		assert.Equal(t, 3, *queries, "the count, the page and the zones of the page")
+	})
+}
+
+func TestZoneRepository_ListPreload(t *testing.T) {
+	db := newTestDB(t, &model.Region{}, &model.Zone{})
+	regionIDs := seedRegions(t, db, 3, 5)
+	queries := countQueries(t, db)
+	repo := NewZoneRepository(db)
+	ctx := context.Background()
+
+	t.Run("skipping preloads keeps the region reference", func(t *testing.T) {
+		*queries = 0
+		zones, err := repo.ListByRegion(ctx, regionIDs[0], InfraListOptions{SkipPreload: true})
+		require.NoError(t, err)
+		assert.Len(t, zones, 5)
+		assert.Equal(t, 1, *queries)
+		for _, zone := range zones {
+			assert.Nil(t, zone.Region)
+			assert.Equal(t, regionIDs[0], zone.RegionID)
+		}
+	})
+
+	t.Run("regions are loaded in one batch", func(t *testing.T) {
+		*queries = 0
+		zones, total, err := repo.List(ctx, 1, 15, InfraListOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(15), total)
+		assert.Len(t, zones, 15)
+		assert.Equal(t, 3, *queries, "the count, the page and one query for all three regions")
+		for _, zone := range zones {
+			require.NotNil(t, zone.Region)
+			assert.Equal(t, zone.RegionID, zone.Region.ID)
+		}
+	})
+}
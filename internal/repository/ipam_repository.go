@@ -2,22 +2,97 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"math/big"
 	"net"
 	"time"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// IPAM errors.
+var (
+	ErrIPInUse       = errors.New("IP address is already allocated")
+	ErrIPOutOfRange  = errors.New("IP address is not within pool range")
+	ErrPoolExhausted = errors.New("no available IP addresses in pool")
+	ErrHostnameTaken = errors.New("hostname is already allocated in this pool")
+	ErrInvalidRange  = errors.New("invalid IP range")
+	ErrNotAllocated  = errors.New("IP allocation is not active")
+	// ErrAddressesAnswering is returned when too many candidates in a row answer a probe
+	ErrAddressesAnswering = errors.New("candidate IP addresses answer on the network")
+)
+
+// AddressProbe reports whether a host already answers at ip. Pools that verify addresses
+// have each candidate probed with it before the candidate is allocated.
+type AddressProbe func(ctx context.Context, ip net.IP) bool
+
+// PoolExhaustedError explains why no address could be allocated from a pool: how large its
+// range is and how much of it is taken by allocations and reservations. It unwraps to
+// ErrPoolExhausted.
+type PoolExhaustedError struct {
+	PoolID    string `json:"pool_id"`
+	PoolName  string `json:"pool_name"`
+	StartIP   string `json:"start_ip"`
+	EndIP     string `json:"end_ip"`
+	Total     int64  `json:"total"`
+	Allocated int64  `json:"allocated"`
+	Reserved  int64  `json:"reserved"`
+}
+
+// Error implements the error interface.
+func (e *PoolExhaustedError) Error() string {
+	return fmt.Sprintf("%s: pool %q (%s-%s) has %d addresses, %d allocated, %d reserved",
+		ErrPoolExhausted.Error(), e.PoolName, e.StartIP, e.EndIP, e.Total, e.Allocated, e.Reserved)
+}
+
+// Unwrap returns ErrPoolExhausted so callers can match with errors.Is.
+func (e *PoolExhaustedError) Unwrap() error {
+	return ErrPoolExhausted
+}
+
+// PoolUtilization counts the addresses in a pool's range and how many of them are taken.
+type PoolUtilization struct {
+	PoolID    string `json:"pool_id"`
+	PoolName  string `json:"pool_name"`
+	ZoneID    string `json:"zone_id"`
+	Total     int64  `json:"total"`
+	Allocated int64  `json:"allocated"`
+	Reserved  int64  `json:"reserved"`
+}
+
+// Used returns the number of addresses that are allocated or reserved.
+func (u *PoolUtilization) Used() int64 {
+	return u.Allocated + u.Reserved
+}
+
+// Percent returns the share of the pool's addresses in use, from 0 to 100.
+func (u *PoolUtilization) Percent() float64 {
+	if u.Total == 0 {
+		return 0
+	}
+	return float64(u.Used()) * 100 / float64(u.Total)
+}
+
 // IPPoolRepository defines the interface for IP pool operations.
 type IPPoolRepository interface {
 	Create(ctx context.Context, pool *model.IPPool) error
 	GetByID(ctx context.Context, id string) (*model.IPPool, error)
-	List(ctx context.Context, zoneID string, offset, limit int) ([]*model.IPPool, int64, error)
+	List(ctx context.Context, filters IPPoolFilters, offset, limit int) ([]*model.IPPool, int64, error)
 	Update(ctx context.Context, pool *model.IPPool) error
 	Delete(ctx context.Context, id string) error
+	GetUsage(ctx context.Context, id string) (*Usage, error)
+}
+
+// IPPoolFilters defines filters for IP pool queries.
+type IPPoolFilters struct {
+	ZoneID      string
+	NetworkType string
+	Status      *int8
 }
 
 // IPAllocationRepository defines the interface for IP allocation operations.
@@ -25,13 +100,30 @@ type IPAllocationRepository interface {
 	Create(ctx context.Context, allocation *model.IPAllocation) error
 	GetByID(ctx context.Context, id string) (*model.IPAllocation, error)
 	GetByIPAddress(ctx context.Context, poolID, ipAddress string) (*model.IPAllocation, error)
+	List(ctx context.Context, filters IPAllocationFilters, offset, limit int) ([]*model.IPAllocation, int64, error)
 	ListByPool(ctx context.Context, poolID string, offset, limit int) ([]*model.IPAllocation, int64, error)
 	ListByResource(ctx context.Context, resourceID string) ([]*model.IPAllocation, error)
 	Update(ctx context.Context, allocation *model.IPAllocation) error
 	Delete(ctx context.Context, id string) error
 	AllocateNextAvailable(ctx context.Context, poolID, hostname, resourceID string) (*model.IPAllocation, error)
+	AllocateSpecific(ctx context.Context, poolID, ip, hostname, resourceID string) (*model.IPAllocation, error)
+	AllocateProbed(ctx context.Context, poolID, ip, hostname, resourceID string, probe AddressProbe) (*model.IPAllocation, error)
+	ReserveRange(ctx context.Context, poolID, startIP, endIP, note string) error
+	ListHostnames(ctx context.Context, poolID, prefix string) ([]string, error)
 	Release(ctx context.Context, id string) error
+	Reallocate(ctx context.Context, id, newIP string) (*model.IPAllocation, error)
+	ReallocateProbed(ctx context.Context, id, newIP string, probe AddressProbe) (*model.IPAllocation, error)
 	GetAvailableCount(ctx context.Context, poolID string) (int64, error)
+	GetUtilization(ctx context.Context, poolID string) (*PoolUtilization, error)
+}
+
+// IPAllocationFilters defines filters for IP allocation queries across pools.
+type IPAllocationFilters struct {
+	PoolID     string
+	Status     string
+	Hostname   string // Substring match
+	ResourceID string
+	ZoneID     string // Matched through the allocation's pool
 }
 
 type ipPoolRepository struct {
@@ -52,47 +144,68 @@ func NewIPAllocationRepository(db *gorm.DB) IPAllocationRepository {
 	return &ipAllocationRepository{db: db}
 }
 
-// Create creates a new IP pool.
+// Create creates an IP pool. A default pool replaces the zone's previous default.
 func (r *ipPoolRepository) Create(ctx context.Context, pool *model.IPPool) error {
-	return r.db.WithContext(ctx).Create(pool).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if pool.IsDefault {
+			if err := clearZoneDefault(tx, pool.ZoneID, ""); err != nil {
+				return err
+			}
+		}
+		return tx.Create(pool).Error
+	})
 }
 
 // GetByID retrieves an IP pool by ID.
 func (r *ipPoolRepository) GetByID(ctx context.Context, id string) (*model.IPPool, error) {
-	var pool model.IPPool
-	if err := r.db.WithContext(ctx).Preload("Zone").First(&pool, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &pool, nil
+	return firstOrNotFound[model.IPPool](r.db.WithContext(ctx).Preload("Zone"), "id = ?", id)
 }
 
-// List retrieves IP pools with optional zone filtering.
-func (r *ipPoolRepository) List(ctx context.Context, zoneID string, offset, limit int) ([]*model.IPPool, int64, error) {
+// List retrieves IP pools with optional zone, network type and status filtering.
+func (r *ipPoolRepository) List(ctx context.Context, filters IPPoolFilters, offset, limit int) ([]*model.IPPool, int64, error) {
 	var pools []*model.IPPool
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&model.IPPool{})
-	if zoneID != "" {
-		query = query.Where("zone_id = ?", zoneID)
+	if filters.ZoneID != "" {
+		query = query.Where("zone_id = ?", filters.ZoneID)
+	}
+	if filters.NetworkType != "" {
+		query = query.Where("network_type = ?", filters.NetworkType)
+	}
+	if filters.Status != nil {
+		query = query.Where("status = ?", *filters.Status)
 	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	if err := query.Preload("Zone").Offset(offset).Limit(limit).Order("created_at DESC").Find(&pools).Error; err != nil {
+	if err := query.Preload("Zone").Offset(offset).Limit(limit).Order(orderNewestFirst).Find(&pools).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return pools, total, nil
 }
 
-// Update updates an existing IP pool.
+// Update updates an existing IP pool. A default pool replaces the zone's previous default.
 func (r *ipPoolRepository) Update(ctx context.Context, pool *model.IPPool) error {
-	return r.db.WithContext(ctx).Save(pool).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if pool.IsDefault {
+			if err := clearZoneDefault(tx, pool.ZoneID, pool.ID); err != nil {
+				return err
+			}
+		}
+		return tx.Save(pool).Error
+	})
+}
+
+// clearZoneDefault unsets the default flag of every pool in the zone other than exceptID, so
+// a zone has at most one default pool.
+func clearZoneDefault(tx *gorm.DB, zoneID, exceptID string) error {
+	return tx.Model(&model.IPPool{}).
+		Where("zone_id = ? AND is_default = ? AND id <> ?", zoneID, true, exceptID).
+		Update("is_default", false).Error
 }
 
 // Delete deletes an IP pool by ID.
@@ -106,43 +219,87 @@ func (r *ipPoolRepository) Delete(ctx context.Context, id string) error {
 		return errors.New("cannot delete IP pool with active allocations")
 	}
 
-	result := r.db.WithContext(ctx).Delete(&model.IPPool{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.IPPool{}, "id = ?", id))
+}
+
+// GetUsage reports the active allocations in a pool and the resources holding them.
+func (r *ipPoolRepository) GetUsage(ctx context.Context, id string) (*Usage, error) {
+	db := r.db.WithContext(ctx)
+	allocations, err := loadUsageGroup(
+		db.Model(&model.IPAllocation{}).Where("ip_pool_id = ? AND status != ?", id, model.IPStatusAvailable),
+		"ip_allocations", "id, ip_address AS name, status",
+	)
+	if err != nil {
+		return nil, err
 	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
+
+	holders := db.Model(&model.IPAllocation{}).Select("resource_id").
+		Where("ip_pool_id = ? AND status != ? AND resource_id IS NOT NULL", id, model.IPStatusAvailable)
+	resources, err := loadUsageGroup(
+		db.Model(&model.Resource{}).Where("id IN (?)", holders),
+		"resources", "id, name, status",
+	)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return &Usage{Allocations: allocations, Resources: resources}, nil
 }
 
 // Create creates a new IP allocation.
 func (r *ipAllocationRepository) Create(ctx context.Context, allocation *model.IPAllocation) error {
-	return r.db.WithContext(ctx).Create(allocation).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkHostnameFree(tx, allocation.IPPoolID, allocation.Hostname); err != nil {
+			return err
+		}
+		return tx.Create(allocation).Error
+	})
 }
 
 // GetByID retrieves an IP allocation by ID.
 func (r *ipAllocationRepository) GetByID(ctx context.Context, id string) (*model.IPAllocation, error) {
-	var allocation model.IPAllocation
-	if err := r.db.WithContext(ctx).Preload("IPPool").First(&allocation, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &allocation, nil
+	return firstOrNotFound[model.IPAllocation](r.db.WithContext(ctx).Preload("IPPool"), "id = ?", id)
 }
 
 // GetByIPAddress retrieves an IP allocation by pool ID and IP address.
 func (r *ipAllocationRepository) GetByIPAddress(ctx context.Context, poolID, ipAddress string) (*model.IPAllocation, error) {
-	var allocation model.IPAllocation
-	if err := r.db.WithContext(ctx).Preload("IPPool").First(&allocation, "ip_pool_id = ? AND ip_address = ?", poolID, ipAddress).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
+	return firstOrNotFound[model.IPAllocation](r.db.WithContext(ctx).Preload("IPPool"), "ip_pool_id = ? AND ip_address = ?", poolID, ipAddress)
+}
+
+// List retrieves IP allocations across pools with optional filtering.
+func (r *ipAllocationRepository) List(ctx context.Context, filters IPAllocationFilters, offset, limit int) ([]*model.IPAllocation, int64, error) {
+	var allocations []*model.IPAllocation
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.IPAllocation{})
+	if filters.PoolID != "" {
+		query = query.Where("ip_allocations.ip_pool_id = ?", filters.PoolID)
+	}
+	if filters.Status != "" {
+		query = query.Where("ip_allocations.status = ?", filters.Status)
+	}
+	if filters.Hostname != "" {
+		query = query.Where("ip_allocations.hostname LIKE ?", "%"+filters.Hostname+"%")
+	}
+	if filters.ResourceID != "" {
+		query = query.Where("ip_allocations.resource_id = ?", filters.ResourceID)
+	}
+	if filters.ZoneID != "" {
+		query = query.
+			Joins("JOIN ip_pools ON ip_pools.id = ip_allocations.ip_pool_id AND ip_pools.deleted_at IS NULL").
+			Where("ip_pools.zone_id = ?", filters.ZoneID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Preload("IPPool").Offset(offset).Limit(limit).
+		Order("ip_allocations.ip_pool_id ASC, ip_allocations.ip_address ASC, ip_allocations.id ASC").
+		Find(&allocations).Error; err != nil {
+		return nil, 0, err
 	}
-	return &allocation, nil
+
+	return allocations, total, nil
 }
 
 // ListByPool retrieves IP allocations for a specific pool.
@@ -156,7 +313,7 @@ func (r *ipAllocationRepository) ListByPool(ctx context.Context, poolID string,
 		return nil, 0, err
 	}
 
-	if err := query.Preload("IPPool").Offset(offset).Limit(limit).Order("ip_address ASC").Find(&allocations).Error; err != nil {
+	if err := query.Preload("IPPool").Offset(offset).Limit(limit).Order("ip_address ASC, id ASC").Find(&allocations).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -166,7 +323,7 @@ func (r *ipAllocationRepository) ListByPool(ctx context.Context, poolID string,
 // ListByResource retrieves IP allocations for a specific resource.
 func (r *ipAllocationRepository) ListByResource(ctx context.Context, resourceID string) ([]*model.IPAllocation, error) {
 	var allocations []*model.IPAllocation
-	if err := r.db.WithContext(ctx).Preload("IPPool").Where("resource_id = ?", resourceID).Find(&allocations).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("IPPool").Where("resource_id = ?", resourceID).Order("ip_address ASC, id ASC").Find(&allocations).Error; err != nil {
 		return nil, err
 	}
 	return allocations, nil
@@ -179,97 +336,431 @@ func (r *ipAllocationRepository) Update(ctx context.Context, allocation *model.I
 
 // Delete deletes an IP allocation by ID.
 func (r *ipAllocationRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Delete(&model.IPAllocation{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.IPAllocation{}, "id = ?", id))
 }
 
-// AllocateNextAvailable allocates the next available IP address from a pool.
-//
-//nolint:gocognit // complexity is inherent to transactional IP allocation logic
+// Bounds of how many candidate addresses AllocateNextAvailable looks up per query. The first
+// lookup is small, since the address after the last allocated one is usually free, and each
+// further one doubles up to the maximum.
+const (
+	allocationScanMinBatch = 8
+	allocationScanMaxBatch = 256
+)
+
+// maxReservationSize caps how many addresses one ReserveRange call marks, since each one is
+// a row.
+const maxReservationSize = 4096
+
+// maxAddressProbes is how many candidates in a row may answer a probe before AllocateProbed
+// gives up on a pool.
+const maxAddressProbes = 10
+
+// answeringNote describes the reservation made for a candidate that answered a probe.
+const answeringNote = "Answered a probe before allocation; in use outside the platform"
+
+// AllocateNextAvailable allocates the next free IP address from a pool. The search resumes
+// after the pool's last allocated address and wraps around to the start of the range, so
+// addresses released behind it are reused once the end is reached. The pool row is locked
+// to serialize concurrent allocations, and a transaction aborted by a deadlock with one is
+// retried.
 func (r *ipAllocationRepository) AllocateNextAvailable(ctx context.Context, poolID, hostname, resourceID string) (*model.IPAllocation, error) {
+	return r.allocateNext(ctx, poolID, hostname, resourceID, nil)
+}
+
+// AllocateProbed allocates like AllocateSpecific when ip is set and like AllocateNextAvailable
+// otherwise. When the pool verifies addresses, each candidate is first probed while the pool
+// is locked, so nothing is recorded for an address until it is known to be quiet. A requested
+// address that answers fails with ErrIPInUse. A next candidate that answers is reserved, as it
+// is in use outside the platform, and the search moves on; after maxAddressProbes of them it
+// fails with ErrAddressesAnswering, keeping the reservations. Allocations from the pool wait
+// for the probes, which the probe's own timeout bounds.
+func (r *ipAllocationRepository) AllocateProbed(ctx context.Context, poolID, ip, hostname, resourceID string, probe AddressProbe) (*model.IPAllocation, error) {
+	if ip != "" {
+		return r.allocateSpecific(ctx, poolID, ip, hostname, resourceID, probe)
+	}
+	return r.allocateNext(ctx, poolID, hostname, resourceID, probe)
+}
+
+// allocateNext implements AllocateNextAvailable, probing candidates with probe when the pool
+// verifies addresses.
+func (r *ipAllocationRepository) allocateNext(ctx context.Context, poolID, hostname, resourceID string, probe AddressProbe) (*model.IPAllocation, error) {
 	var allocation *model.IPAllocation
+	// failure is returned once a transaction that reserved answering addresses commits
+	var failure error
 
-	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Get the pool
-		var pool model.IPPool
-		if err := tx.First(&pool, "id = ?", poolID).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return ErrNotFound
-			}
+	err := transactionWithRetry(ctx, r.db, func(tx *gorm.DB) error {
+		allocation, failure = nil, nil
+		pool, err := lockPool(tx, poolID)
+		if err != nil {
+			return err
+		}
+		if err := checkHostnameFree(tx, poolID, hostname); err != nil {
+			return err
+		}
+		nextIP, existing, err := nextQuietAddress(ctx, tx, pool, probe, &failure)
+		if err != nil || nextIP == nil {
 			return err
 		}
 
-		// Get all allocated IPs in this pool
-		var allocatedIPs []string
-		if err := tx.Model(&model.IPAllocation{}).
-			Where("ip_pool_id = ? AND status != ?", poolID, "available").
-			Pluck("ip_address", &allocatedIPs).Error; err != nil {
+		now := model.Now()
+		var resID *string
+		if resourceID != "" {
+			resID = &resourceID
+		}
+		if existing != nil {
+			// A released row for the address is reused, since addresses are unique
+			existing.Status = model.IPStatusAllocated
+			existing.Hostname = hostname
+			existing.ResourceID = resID
+			existing.AllocatedAt = &now
+			allocation = existing
+			err = tx.Save(allocation).Error
+		} else {
+			allocation = &model.IPAllocation{
+				IPPoolID:    poolID,
+				IPAddress:   nextIP.String(),
+				Hostname:    hostname,
+				ResourceID:  resID,
+				Status:      model.IPStatusAllocated,
+				AllocatedAt: &now,
+			}
+			err = tx.Create(allocation).Error
+		}
+		if err != nil {
 			return err
 		}
+		return tx.Model(&model.IPPool{}).Where("id = ?", poolID).
+			UpdateColumn("last_allocated_ip", allocation.IPAddress).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	if failure != nil {
+		return nil, failure
+	}
+	return allocation, nil
+}
+
+// nextFreeAddress returns the pool's next free address and its released row, if it has one.
+// The search resumes after the pool's last allocated address and wraps around to the start of
+// the range. A nil address means the pool is full.
+func nextFreeAddress(tx *gorm.DB, pool *model.IPPool) (net.IP, *model.IPAllocation, error) {
+	startIP := net.ParseIP(pool.StartIP)
+	endIP := net.ParseIP(pool.EndIP)
+	if startIP == nil || endIP == nil {
+		return nil, nil, errors.New("invalid IP range in pool")
+	}
+
+	first, last, size := ipToInt(startIP), ipToInt(endIP), addressLen(startIP)
+	if hint := net.ParseIP(pool.LastAllocatedIP); hint != nil && ipInRange(hint, startIP, endIP) {
+		resume := new(big.Int).Add(ipToInt(hint), big.NewInt(1))
+		nextIP, existing, err := findFreeAddress(tx, pool.ID, resume, last, size)
+		if err != nil || nextIP != nil {
+			return nextIP, existing, err
+		}
+		last = ipToInt(hint) // then wrap around to the start of the range
+	}
+	return findFreeAddress(tx, pool.ID, first, last, size)
+}
 
-		// Convert to map for quick lookup
-		allocatedMap := make(map[string]bool)
-		for _, ip := range allocatedIPs {
-			allocatedMap[ip] = true
+// nextQuietAddress returns the pool's next free address like nextFreeAddress. When the pool
+// verifies addresses, each candidate is probed first; one that answers is reserved and the
+// search moves on, failing with ErrAddressesAnswering after maxAddressProbes of them. A failure
+// after reservations were made is kept in failure and a nil address is returned, so the
+// caller commits them; see commitAfterReserving.
+func nextQuietAddress(ctx context.Context, tx *gorm.DB, pool *model.IPPool, probe AddressProbe, failure *error) (net.IP, *model.IPAllocation, error) {
+	verify := probe != nil && pool.VerifyBeforeAllocate
+	for answered := 0; ; answered++ {
+		nextIP, existing, err := nextFreeAddress(tx, pool)
+		if err != nil {
+			return nil, nil, err
+		}
+		if nextIP == nil {
+			used, err := usedAddresses(tx, pool.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, commitAfterReserving(answered, failure, newPoolExhaustedError(pool, used))
 		}
+		if !verify || !probe(ctx, nextIP) {
+			return nextIP, existing, nil
+		}
+		// The reservation hides the address from the next search
+		if err := reserveAnswering(tx, pool.ID, nextIP, existing); err != nil {
+			return nil, nil, err
+		}
+		if answered+1 >= maxAddressProbes {
+			return nil, nil, commitAfterReserving(answered+1, failure,
+				fmt.Errorf("%w: %d addresses in pool %s", ErrAddressesAnswering, maxAddressProbes, pool.ID))
+		}
+	}
+}
+
+// reserveAnswering reserves ip, which answered a probe, reusing the pool's released row for
+// it when there is one.
+func reserveAnswering(tx *gorm.DB, poolID string, ip net.IP, existing *model.IPAllocation) error {
+	if existing != nil {
+		existing.Status = model.IPStatusReserved
+		existing.Hostname = ""
+		existing.ResourceID = nil
+		existing.AllocatedAt = nil
+		existing.Description = answeringNote
+		return tx.Save(existing).Error
+	}
+	return tx.Create(&model.IPAllocation{
+		IPPoolID:    poolID,
+		IPAddress:   ip.String(),
+		Status:      model.IPStatusReserved,
+		Description: answeringNote,
+	}).Error
+}
+
+// commitAfterReserving returns err to roll back the transaction, unless answering addresses
+// were reserved: then err is kept in failure and the transaction commits, so the reservations
+// outlive the failed allocation.
+func commitAfterReserving(reserved int, failure *error, err error) error {
+	if reserved == 0 {
+		return err
+	}
+	*failure = err
+	return nil
+}
 
-		// Find next available IP
+// claimableRow returns the pool's released row for address, or nil when no row has it.
+// Addresses are unique across pools, and deleted rows still hold theirs in the unique index,
+// so any other row fails with ErrIPInUse.
+func claimableRow(tx *gorm.DB, poolID, address string) (*model.IPAllocation, error) {
+	var existing model.IPAllocation
+	err := tx.Unscoped().First(&existing, "ip_address = ?", address).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if existing.IPPoolID != poolID || existing.Status != model.IPStatusAvailable || existing.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w: %s", ErrIPInUse, address)
+	}
+	return &existing, nil
+}
+
+// lockPool loads a pool for update, so allocations from it run one at a time.
+func lockPool(tx *gorm.DB, poolID string) (*model.IPPool, error) {
+	var pool model.IPPool
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pool, "id = ?", poolID).Error; err != nil {
+		return nil, wrapGet(err)
+	}
+	return &pool, nil
+}
+
+// findFreeAddress returns the first address in [from, to] that is free in the pool, looking
+// addresses up in batches. Addresses are integers and size is their length in bytes, so IPv4
+// and IPv6 ranges of any size are walked alike. An address is free when no row has it, or
+// when the pool's own row for it is released; that row is returned so it can be reused. A
+// nil address means every address in the range is taken.
+func findFreeAddress(tx *gorm.DB, poolID string, from, to *big.Int, size int) (net.IP, *model.IPAllocation, error) {
+	next := new(big.Int).Set(from)
+	one := big.NewInt(1)
+	for batchSize := allocationScanMinBatch; next.Cmp(to) <= 0; batchSize = min(2*batchSize, allocationScanMaxBatch) {
+		batch := make([]string, 0, batchSize)
+		for len(batch) < batchSize && next.Cmp(to) <= 0 {
+			batch = append(batch, intToIP(next, size).String())
+			next.Add(next, one)
+		}
+
+		// Deleted rows are included: they still hold their address in the unique index
+		var rows []*model.IPAllocation
+		if err := tx.Unscoped().Where("ip_address IN ?", batch).Find(&rows).Error; err != nil {
+			return nil, nil, err
+		}
+		existing := make(map[string]*model.IPAllocation, len(rows))
+		for _, row := range rows {
+			existing[row.IPAddress] = row
+		}
+		for _, address := range batch {
+			row, ok := existing[address]
+			if !ok {
+				return net.ParseIP(address), nil, nil
+			}
+			if row.IPPoolID == poolID && row.Status == model.IPStatusAvailable && !row.DeletedAt.Valid {
+				return net.ParseIP(address), row, nil
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+// AllocateSpecific allocates the address ip from a pool. It fails with ErrIPOutOfRange when
+// ip is outside the pool's range and ErrIPInUse when it is allocated or reserved, here or in
+// another pool. A released row for the address is reused. A transaction aborted by a
+// deadlock with a concurrent allocation is retried.
+func (r *ipAllocationRepository) AllocateSpecific(ctx context.Context, poolID, ip, hostname, resourceID string) (*model.IPAllocation, error) {
+	return r.allocateSpecific(ctx, poolID, ip, hostname, resourceID, nil)
+}
+
+// allocateSpecific implements AllocateSpecific, probing ip with probe once it is known to be
+// free when the pool verifies addresses.
+func (r *ipAllocationRepository) allocateSpecific(ctx context.Context, poolID, ip, hostname, resourceID string, probe AddressProbe) (*model.IPAllocation, error) {
+	requested := net.ParseIP(ip)
+	if requested == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+	address := requested.String()
+
+	var allocation *model.IPAllocation
+	err := transactionWithRetry(ctx, r.db, func(tx *gorm.DB) error {
+		pool, err := lockPool(tx, poolID)
+		if err != nil {
+			return err
+		}
 		startIP := net.ParseIP(pool.StartIP)
 		endIP := net.ParseIP(pool.EndIP)
 		if startIP == nil || endIP == nil {
 			return errors.New("invalid IP range in pool")
 		}
-
-		var nextIP net.IP
-		for ip := dupIP(startIP); !ip.Equal(endIP); incrementIP(ip) {
-			ipStr := ip.String()
-			if !allocatedMap[ipStr] {
-				nextIP = make(net.IP, len(ip))
-				copy(nextIP, ip)
-				break
-			}
+		if !ipInRange(requested, startIP, endIP) {
+			return fmt.Errorf("%w: %s is outside %s-%s", ErrIPOutOfRange, address, pool.StartIP, pool.EndIP)
 		}
-
-		if nextIP == nil {
-			// Check the end IP too
-			if !allocatedMap[endIP.String()] {
-				nextIP = endIP
-			} else {
-				return errors.New("no available IP addresses in pool")
-			}
+		if err := checkHostnameFree(tx, poolID, hostname); err != nil {
+			return err
 		}
 
-		// Create the allocation
-		now := time.Now()
 		var resID *string
 		if resourceID != "" {
 			resID = &resourceID
 		}
+		now := model.Now()
+
+		existing, err := claimableRow(tx, poolID, address)
+		if err != nil {
+			return err
+		}
+		if probe != nil && pool.VerifyBeforeAllocate && probe(ctx, requested) {
+			return fmt.Errorf("%w: %s answers on the network", ErrIPInUse, address)
+		}
+
+		if existing != nil {
+			existing.Status = model.IPStatusAllocated
+			existing.Hostname = hostname
+			existing.ResourceID = resID
+			existing.AllocatedAt = &now
+			allocation = existing
+			return tx.Save(allocation).Error
+		}
 		allocation = &model.IPAllocation{
 			IPPoolID:    poolID,
-			IPAddress:   nextIP.String(),
+			IPAddress:   address,
 			Hostname:    hostname,
 			ResourceID:  resID,
-			Status:      "allocated",
+			Status:      model.IPStatusAllocated,
 			AllocatedAt: &now,
 		}
-
 		return tx.Create(allocation).Error
 	})
 
 	if err != nil {
 		return nil, err
 	}
-
 	return allocation, nil
 }
 
+// ReserveRange marks every address from startIP to endIP in a pool as reserved, so the
+// allocator never hands them out; note is kept as each reservation's description. The range
+// must lie within the pool's and hold at most maxReservationSize addresses. Nothing is
+// reserved when any address in it is allocated or reserved, here or in another pool, which
+// fails with ErrIPInUse. Released rows for the addresses are reused.
+func (r *ipAllocationRepository) ReserveRange(ctx context.Context, poolID, startIP, endIP, note string) error {
+	start, end := net.ParseIP(startIP), net.ParseIP(endIP)
+	if start == nil || end == nil || addressLen(start) != addressLen(end) {
+		return fmt.Errorf("%w: %s-%s", ErrInvalidRange, startIP, endIP)
+	}
+	if size := rangeSize(start, end); size == 0 || size > maxReservationSize {
+		return fmt.Errorf("%w: %s-%s must hold between 1 and %d addresses", ErrInvalidRange, startIP, endIP, maxReservationSize)
+	}
+
+	return transactionWithRetry(ctx, r.db, func(tx *gorm.DB) error {
+		pool, err := lockPool(tx, poolID)
+		if err != nil {
+			return err
+		}
+		poolStart, poolEnd := net.ParseIP(pool.StartIP), net.ParseIP(pool.EndIP)
+		if poolStart == nil || poolEnd == nil {
+			return errors.New("invalid IP range in pool")
+		}
+		if !ipInRange(start, poolStart, poolEnd) || !ipInRange(end, poolStart, poolEnd) {
+			return fmt.Errorf("%w: %s-%s is outside %s-%s", ErrIPOutOfRange, startIP, endIP, pool.StartIP, pool.EndIP)
+		}
+
+		now := model.Now()
+		next, last, size := ipToInt(start), ipToInt(end), addressLen(start)
+		one := big.NewInt(1)
+		for next.Cmp(last) <= 0 {
+			batch := make([]string, 0, allocationScanMaxBatch)
+			for len(batch) < allocationScanMaxBatch && next.Cmp(last) <= 0 {
+				batch = append(batch, intToIP(next, size).String())
+				next.Add(next, one)
+			}
+			if err := reserveAddresses(tx, poolID, batch, note, &now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// reserveAddresses marks addresses as reserved in a pool, reusing the pool's released rows
+// for them and creating the others.
+func reserveAddresses(tx *gorm.DB, poolID string, addresses []string, note string, reservedAt *time.Time) error {
+	// Deleted rows are included: they still hold their address in the unique index
+	var rows []*model.IPAllocation
+	if err := tx.Unscoped().Where("ip_address IN ?", addresses).Find(&rows).Error; err != nil {
+		return err
+	}
+	existing := make(map[string]*model.IPAllocation, len(rows))
+	for _, row := range rows {
+		if row.IPPoolID != poolID || row.Status != model.IPStatusAvailable || row.DeletedAt.Valid {
+			return fmt.Errorf("%w: %s", ErrIPInUse, row.IPAddress)
+		}
+		existing[row.IPAddress] = row
+	}
+
+	var created []*model.IPAllocation
+	for _, address := range addresses {
+		if row, ok := existing[address]; ok {
+			row.Status = model.IPStatusReserved
+			row.Hostname = ""
+			row.ResourceID = nil
+			row.Description = note
+			row.AllocatedAt = reservedAt
+			if err := tx.Save(row).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		created = append(created, &model.IPAllocation{
+			IPPoolID:    poolID,
+			IPAddress:   address,
+			Status:      model.IPStatusReserved,
+			Description: note,
+			AllocatedAt: reservedAt,
+		})
+	}
+	if len(created) == 0 {
+		return nil
+	}
+	return tx.Create(&created).Error
+}
+
+// ListHostnames returns the hostnames of the active allocations in a pool that start with prefix.
+func (r *ipAllocationRepository) ListHostnames(ctx context.Context, poolID, prefix string) ([]string, error) {
+	var hostnames []string
+	err := r.db.WithContext(ctx).Model(&model.IPAllocation{}).
+		Where("ip_pool_id = ? AND status != ? AND hostname LIKE ?", poolID, model.IPStatusAvailable, prefix+"%").
+		Pluck("hostname", &hostnames).Error
+	return hostnames, err
+}
+
 // Release releases an IP allocation back to the pool.
 func (r *ipAllocationRepository) Release(ctx context.Context, id string) error {
 	result := r.db.WithContext(ctx).Model(&model.IPAllocation{}).
@@ -289,54 +780,271 @@ func (r *ipAllocationRepository) Release(ctx context.Context, id string) error {
 	return nil
 }
 
-// GetAvailableCount returns the count of available IPs in a pool.
-func (r *ipAllocationRepository) GetAvailableCount(ctx context.Context, poolID string) (int64, error) {
-	// Get the pool
-	var pool model.IPPool
-	if err := r.db.WithContext(ctx).First(&pool, "id = ?", poolID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return 0, ErrNotFound
+// Reallocate moves an allocation to a new IP in the same pool within a single transaction.
+// The old address is released and the new one (or the next free address when newIP is empty)
+// is bound to the same hostname and resource. Nothing changes if the new address is taken.
+// Like the allocations, it locks the pool, is retried when aborted by a deadlock, and records
+// the new address as the pool's last allocated one.
+func (r *ipAllocationRepository) Reallocate(ctx context.Context, id, newIP string) (*model.IPAllocation, error) {
+	return r.reallocate(ctx, id, newIP, nil)
+}
+
+// ReallocateProbed reallocates like Reallocate, probing the new address first when the pool
+// verifies addresses, as AllocateProbed does: a requested address that answers fails with
+// ErrIPInUse, and a next candidate that answers is reserved while the search moves on.
+func (r *ipAllocationRepository) ReallocateProbed(ctx context.Context, id, newIP string, probe AddressProbe) (*model.IPAllocation, error) {
+	return r.reallocate(ctx, id, newIP, probe)
+}
+
+// reallocate implements Reallocate, probing the new address with probe when the pool
+// verifies addresses.
+func (r *ipAllocationRepository) reallocate(ctx context.Context, id, newIP string, probe AddressProbe) (*model.IPAllocation, error) {
+	var moved *model.IPAllocation
+	// failure is returned once a transaction that reserved answering addresses commits
+	var failure error
+
+	err := transactionWithRetry(ctx, r.db, func(tx *gorm.DB) error {
+		moved, failure = nil, nil
+		var current model.IPAllocation
+		if err := tx.First(&current, "id = ?", id).Error; err != nil {
+			return wrapGet(err)
 		}
-		return 0, err
+		pool, err := lockPool(tx, current.IPPoolID)
+		if err != nil {
+			return err
+		}
+		// Read again under the lock, since the allocation may have moved meanwhile
+		if err := tx.First(&current, "id = ?", id).Error; err != nil {
+			return wrapGet(err)
+		}
+		if current.Status == model.IPStatusAvailable {
+			return ErrNotAllocated
+		}
+
+		target, existing, err := selectReallocationIP(ctx, tx, pool, newIP, probe, &failure)
+		if err != nil || target == nil {
+			return err
+		}
+
+		if err := tx.Model(&model.IPAllocation{}).Where("id = ?", current.ID).
+			Updates(map[string]interface{}{
+				"status":       model.IPStatusAvailable,
+				"hostname":     "",
+				"resource_id":  nil,
+				"allocated_at": nil,
+			}).Error; err != nil {
+			return err
+		}
+
+		now := model.Now()
+		if existing != nil {
+			existing.Status = model.IPStatusAllocated
+			existing.Hostname = current.Hostname
+			existing.ResourceID = current.ResourceID
+			existing.AllocatedAt = &now
+			moved = existing
+			err = tx.Save(moved).Error
+		} else {
+			moved = &model.IPAllocation{
+				IPPoolID:    current.IPPoolID,
+				IPAddress:   target.String(),
+				Hostname:    current.Hostname,
+				ResourceID:  current.ResourceID,
+				Status:      model.IPStatusAllocated,
+				AllocatedAt: &now,
+				Description: current.Description,
+			}
+			err = tx.Create(moved).Error
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.IPPool{}).Where("id = ?", pool.ID).
+			UpdateColumn("last_allocated_ip", moved.IPAddress).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	if failure != nil {
+		return nil, failure
 	}
+	return moved, nil
+}
 
-	// Calculate total IPs in range
+// selectReallocationIP validates the requested target address, or picks the pool's next free
+// one when none is requested, and returns the released row for it if there is one. The
+// allocation's current address is allocated, so it is never picked. Candidates are probed as
+// in nextQuietAddress, which also explains failure.
+func selectReallocationIP(ctx context.Context, tx *gorm.DB, pool *model.IPPool, newIP string, probe AddressProbe, failure *error) (net.IP, *model.IPAllocation, error) {
 	startIP := net.ParseIP(pool.StartIP)
 	endIP := net.ParseIP(pool.EndIP)
 	if startIP == nil || endIP == nil {
-		return 0, errors.New("invalid IP range in pool")
+		return nil, nil, errors.New("invalid IP range in pool")
 	}
 
-	totalIPs := int64(0)
-	for ip := dupIP(startIP); !ip.Equal(endIP); incrementIP(ip) {
-		totalIPs++
+	if newIP == "" {
+		return nextQuietAddress(ctx, tx, pool, probe, failure)
+	}
+
+	ip := net.ParseIP(newIP)
+	if ip == nil {
+		return nil, nil, fmt.Errorf("invalid IP address: %s", newIP)
+	}
+	if !ipInRange(ip, startIP, endIP) {
+		return nil, nil, fmt.Errorf("%w: %s is outside %s-%s", ErrIPOutOfRange, ip, pool.StartIP, pool.EndIP)
+	}
+	existing, err := claimableRow(tx, pool.ID, ip.String())
+	if err != nil {
+		return nil, nil, err
 	}
-	totalIPs++ // Include the end IP
+	if probe != nil && pool.VerifyBeforeAllocate && probe(ctx, ip) {
+		return nil, nil, fmt.Errorf("%w: %s answers on the network", ErrIPInUse, ip)
+	}
+	return ip, existing, nil
+}
 
-	// Get allocated count
-	var allocatedCount int64
-	if err := r.db.WithContext(ctx).Model(&model.IPAllocation{}).
-		Where("ip_pool_id = ? AND status != ?", poolID, "available").
-		Count(&allocatedCount).Error; err != nil {
+// GetAvailableCount returns how many addresses in a pool's range are neither allocated nor
+// reserved. The range size is capped as in GetUtilization, so huge IPv6 ranges report a
+// large but finite count.
+func (r *ipAllocationRepository) GetAvailableCount(ctx context.Context, poolID string) (int64, error) {
+	utilization, err := r.GetUtilization(ctx, poolID)
+	if err != nil {
 		return 0, err
 	}
+	return utilization.Total - utilization.Used(), nil
+}
 
-	return totalIPs - allocatedCount, nil
+// GetUtilization counts the allocated and reserved addresses within a pool's range.
+func (r *ipAllocationRepository) GetUtilization(ctx context.Context, poolID string) (*PoolUtilization, error) {
+	var pool model.IPPool
+	if err := r.db.WithContext(ctx).First(&pool, "id = ?", poolID).Error; err != nil {
+		return nil, wrapGet(err)
+	}
+	used, err := usedAddresses(r.db.WithContext(ctx), poolID)
+	if err != nil {
+		return nil, err
+	}
+	utilization := poolUtilization(&pool, used)
+	return &utilization, nil
+}
+
+// checkHostnameFree returns ErrHostnameTaken if an active allocation in the pool already
+// uses hostname. Allocations without a hostname never conflict.
+func checkHostnameFree(tx *gorm.DB, poolID, hostname string) error {
+	if hostname == "" {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&model.IPAllocation{}).
+		Where("ip_pool_id = ? AND status != ? AND hostname = ?", poolID, model.IPStatusAvailable, hostname).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrHostnameTaken, hostname)
+	}
+	return nil
 }
 
-// dupIP creates a copy of an IP address.
-func dupIP(ip net.IP) net.IP {
-	dup := make(net.IP, len(ip))
-	copy(dup, ip)
-	return dup
+// usedAddress is an address in a pool that is not available for allocation.
+type usedAddress struct {
+	IPAddress string
+	Status    model.IPAllocationStatus
 }
 
-// incrementIP increments an IP address by 1.
-func incrementIP(ip net.IP) {
-	for j := len(ip) - 1; j >= 0; j-- {
-		ip[j]++
-		if ip[j] > 0 {
-			break
+// usedAddresses returns the allocated and reserved addresses of a pool.
+func usedAddresses(tx *gorm.DB, poolID string) ([]usedAddress, error) {
+	var used []usedAddress
+	err := tx.Model(&model.IPAllocation{}).
+		Select("ip_address", "status").
+		Where("ip_pool_id = ? AND status != ?", poolID, model.IPStatusAvailable).
+		Find(&used).Error
+	return used, err
+}
+
+// newPoolExhaustedError summarizes the usage of a pool whose range has no free address.
+func newPoolExhaustedError(pool *model.IPPool, used []usedAddress) *PoolExhaustedError {
+	utilization := poolUtilization(pool, used)
+	return &PoolExhaustedError{
+		PoolID:    pool.ID,
+		PoolName:  pool.Name,
+		StartIP:   pool.StartIP,
+		EndIP:     pool.EndIP,
+		Total:     utilization.Total,
+		Allocated: utilization.Allocated,
+		Reserved:  utilization.Reserved,
+	}
+}
+
+// poolUtilization counts the used addresses of pool by status. Addresses outside the
+// current range (left behind when a pool is shrunk) are not counted.
+func poolUtilization(pool *model.IPPool, used []usedAddress) PoolUtilization {
+	startIP := net.ParseIP(pool.StartIP)
+	endIP := net.ParseIP(pool.EndIP)
+	utilization := PoolUtilization{
+		PoolID:   pool.ID,
+		PoolName: pool.Name,
+		ZoneID:   pool.ZoneID,
+		Total:    rangeSize(startIP, endIP),
+	}
+	for _, addr := range used {
+		ip := net.ParseIP(addr.IPAddress)
+		if ip == nil || !ipInRange(ip, startIP, endIP) {
+			continue
+		}
+		if addr.Status == model.IPStatusReserved {
+			utilization.Reserved++
+		} else {
+			utilization.Allocated++
 		}
 	}
+	return utilization
+}
+
+// maxPoolSize caps the reported size of a range, keeping counts of IPv6 ranges exact as
+// JSON numbers in JavaScript clients (2^53 - 1).
+const maxPoolSize = 1<<53 - 1
+
+// rangeSize returns the number of addresses in [start, end], capped at maxPoolSize, or 0 for
+// an inverted range.
+func rangeSize(start, end net.IP) int64 {
+	size := new(big.Int).Sub(ipToInt(end), ipToInt(start))
+	if size.Sign() < 0 {
+		return 0
+	}
+	size.Add(size, big.NewInt(1))
+	if size.Cmp(big.NewInt(maxPoolSize)) > 0 {
+		return maxPoolSize
+	}
+	return size.Int64()
+}
+
+// ipInRange reports whether ip lies within [start, end].
+func ipInRange(ip, start, end net.IP) bool {
+	ip16 := ip.To16()
+	return bytes.Compare(ip16, start.To16()) >= 0 && bytes.Compare(ip16, end.To16()) <= 0
+}
+
+// addressLen returns the length in bytes of ip's family: 4 for IPv4, 16 for IPv6.
+func addressLen(ip net.IP) int {
+	if ip.To4() != nil {
+		return net.IPv4len
+	}
+	return net.IPv6len
+}
+
+// ipToInt returns ip as an unsigned integer, IPv4 addresses from their 4-byte form.
+func ipToInt(ip net.IP) *big.Int {
+	if v4 := ip.To4(); v4 != nil {
+		return new(big.Int).SetBytes(v4)
+	}
+	return new(big.Int).SetBytes(ip.To16())
+}
+
+// intToIP returns the address of length size whose integer value is n.
+func intToIP(n *big.Int, size int) net.IP {
+	ip := make(net.IP, size)
+	n.FillBytes(ip)
+	return ip
 }
@@ -0,0 +1,748 @@
+// Package repository provides IPAM repository tests.
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an isolated in-memory sqlite database with the given models migrated.
+func newTestDB(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(models...))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() }) //nolint:errcheck // best-effort cleanup
+	return db
+}
+
+func newIPAMTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	return newTestDB(t, &model.IPPool{}, &model.IPAllocation{})
+}
+
+func createTestPool(t testing.TB, db *gorm.DB, name, zoneID string) *model.IPPool {
+	t.Helper()
+	pool := &model.IPPool{
+		Name:        name,
+		CIDR:        "10.0.0.0/16",
+		Gateway:     "10.0.0.1",
+		StartIP:     "10.0.0.10",
+		EndIP:       "10.0.255.250",
+		ZoneID:      zoneID,
+		NetworkType: model.NetworkTypePrivate,
+		Status:      1,
+	}
+	require.NoError(t, db.Create(pool).Error)
+	return pool
+}
+
+func createTestAllocation(t *testing.T, db *gorm.DB, poolID, ip, hostname string, status model.IPAllocationStatus) *model.IPAllocation {
+	t.Helper()
+	allocation := &model.IPAllocation{IPPoolID: poolID, IPAddress: ip, Hostname: hostname, Status: status}
+	require.NoError(t, db.Create(allocation).Error)
+	return allocation
+}
+
+func TestIPAllocationRepository_List(t *testing.T) {
+	db := newIPAMTestDB(t)
+	repo := NewIPAllocationRepository(db)
+	ctx := context.Background()
+
+	poolA := createTestPool(t, db, "pool-a", "zone-a")
+	poolB := createTestPool(t, db, "pool-b", "zone-b")
+
+	createTestAllocation(t, db, poolA.ID, "10.0.0.10", "web-01", model.IPStatusAllocated)
+	createTestAllocation(t, db, poolA.ID, "10.0.0.11", "db-01", model.IPStatusAllocated)
+	createTestAllocation(t, db, poolB.ID, "10.0.1.10", "web-02", model.IPStatusAllocated)
+	createTestAllocation(t, db, poolB.ID, "10.0.1.11", "", model.IPStatusReserved)
+
+	t.Run("hostname filter spans pools", func(t *testing.T) {
+		allocations, total, err := repo.List(ctx, IPAllocationFilters{Hostname: "web"}, 0, 50)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		require.Len(t, allocations, 2)
+
+		pools := map[string]bool{}
+		for _, a := range allocations {
+			assert.Contains(t, a.Hostname, "web")
+			require.NotNil(t, a.IPPool)
+			pools[a.IPPool.ID] = true
+		}
+		assert.Len(t, pools, 2)
+	})
+
+	t.Run("status filter", func(t *testing.T) {
+		allocations, total, err := repo.List(ctx, IPAllocationFilters{Status: string(model.IPStatusReserved)}, 0, 50)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, "10.0.1.11", allocations[0].IPAddress)
+	})
+
+	t.Run("zone filter joins through pool", func(t *testing.T) {
+		allocations, total, err := repo.List(ctx, IPAllocationFilters{ZoneID: "zone-a", Status: string(model.IPStatusAllocated)}, 0, 50)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		for _, a := range allocations {
+			assert.Equal(t, poolA.ID, a.IPPoolID)
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		allocations, total, err := repo.List(ctx, IPAllocationFilters{}, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+		assert.Len(t, allocations, 2)
+	})
+}
+
+func TestIPAllocationRepository_Reallocate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("moves hostname and resource to the requested IP", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+
+		resourceID := "res-1"
+		old := &model.IPAllocation{IPPoolID: pool.ID, IPAddress: "10.0.0.20", Hostname: "app-01", ResourceID: &resourceID, Status: model.IPStatusAllocated}
+		require.NoError(t, db.Create(old).Error)
+
+		moved, err := repo.Reallocate(ctx, old.ID, "10.0.0.30")
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.30", moved.IPAddress)
+		assert.Equal(t, "app-01", moved.Hostname)
+		require.NotNil(t, moved.ResourceID)
+		assert.Equal(t, resourceID, *moved.ResourceID)
+
+		released, err := repo.GetByID(ctx, old.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.IPStatusAvailable, released.Status)
+		assert.Empty(t, released.Hostname)
+	})
+
+	t.Run("next available skips the current address", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+		old := createTestAllocation(t, db, pool.ID, "10.0.0.10", "app-01", model.IPStatusAllocated)
+
+		moved, err := repo.Reallocate(ctx, old.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.11", moved.IPAddress)
+	})
+
+	t.Run("reuses a previously released row", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+		old := createTestAllocation(t, db, pool.ID, "10.0.0.20", "app-01", model.IPStatusAllocated)
+		freed := createTestAllocation(t, db, pool.ID, "10.0.0.21", "", model.IPStatusAvailable)
+
+		moved, err := repo.Reallocate(ctx, old.ID, "10.0.0.21")
+		require.NoError(t, err)
+		assert.Equal(t, freed.ID, moved.ID)
+		assert.Equal(t, model.IPStatusAllocated, moved.Status)
+	})
+
+	t.Run("rolls back when the new IP is taken", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+		old := createTestAllocation(t, db, pool.ID, "10.0.0.20", "app-01", model.IPStatusAllocated)
+		createTestAllocation(t, db, pool.ID, "10.0.0.30", "app-02", model.IPStatusAllocated)
+
+		_, err := repo.Reallocate(ctx, old.ID, "10.0.0.30")
+		require.ErrorIs(t, err, ErrIPInUse)
+
+		unchanged, err := repo.GetByID(ctx, old.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.IPStatusAllocated, unchanged.Status)
+		assert.Equal(t, "app-01", unchanged.Hostname)
+	})
+
+	t.Run("rejects IP outside the pool range", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+		old := createTestAllocation(t, db, pool.ID, "10.0.0.20", "app-01", model.IPStatusAllocated)
+
+		_, err := repo.Reallocate(ctx, old.ID, "192.168.1.1")
+		require.ErrorIs(t, err, ErrIPOutOfRange)
+	})
+
+	t.Run("resumes after the pool's last allocated address", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+		old := createTestAllocation(t, db, pool.ID, "10.0.0.10", "app-01", model.IPStatusAllocated)
+		require.NoError(t, db.Model(pool).UpdateColumn("last_allocated_ip", "10.0.0.40").Error)
+
+		moved, err := repo.Reallocate(ctx, old.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.41", moved.IPAddress)
+
+		var reloaded model.IPPool
+		require.NoError(t, db.First(&reloaded, "id = ?", pool.ID).Error)
+		assert.Equal(t, "10.0.0.41", reloaded.LastAllocatedIP)
+	})
+
+	t.Run("rejects an address held by a deleted row", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+		old := createTestAllocation(t, db, pool.ID, "10.0.0.20", "app-01", model.IPStatusAllocated)
+		deleted := createTestAllocation(t, db, pool.ID, "10.0.0.30", "", model.IPStatusAvailable)
+		require.NoError(t, db.Delete(deleted).Error)
+
+		_, err := repo.Reallocate(ctx, old.ID, "10.0.0.30")
+		require.ErrorIs(t, err, ErrIPInUse)
+
+		unchanged, err := repo.GetByID(ctx, old.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.IPStatusAllocated, unchanged.Status)
+	})
+
+	t.Run("rejects a released allocation", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+		released := createTestAllocation(t, db, pool.ID, "10.0.0.20", "", model.IPStatusAvailable)
+
+		_, err := repo.Reallocate(ctx, released.ID, "10.0.0.30")
+		require.ErrorIs(t, err, ErrNotAllocated)
+	})
+}
+
+func TestIPAllocationRepository_AllocateSpecific(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("free address in range", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+
+		allocation, err := repo.AllocateSpecific(ctx, pool.ID, "10.0.0.42", "app-01", "res-1")
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.42", allocation.IPAddress)
+		assert.Equal(t, model.IPStatusAllocated, allocation.Status)
+		require.NotNil(t, allocation.ResourceID)
+		assert.Equal(t, "res-1", *allocation.ResourceID)
+
+		stored, err := repo.GetByIPAddress(ctx, pool.ID, "10.0.0.42")
+		require.NoError(t, err)
+		assert.Equal(t, allocation.ID, stored.ID)
+	})
+
+	t.Run("released address reuses its row", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+		freed := createTestAllocation(t, db, pool.ID, "10.0.0.42", "", model.IPStatusAvailable)
+
+		allocation, err := repo.AllocateSpecific(ctx, pool.ID, "10.0.0.42", "app-01", "")
+		require.NoError(t, err)
+		assert.Equal(t, freed.ID, allocation.ID)
+		assert.Equal(t, model.IPStatusAllocated, allocation.Status)
+		assert.Equal(t, "app-01", allocation.Hostname)
+	})
+
+	t.Run("already taken", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+		createTestAllocation(t, db, pool.ID, "10.0.0.42", "app-01", model.IPStatusAllocated)
+		createTestAllocation(t, db, pool.ID, "10.0.0.43", "", model.IPStatusReserved)
+
+		_, err := repo.AllocateSpecific(ctx, pool.ID, "10.0.0.42", "app-02", "")
+		require.ErrorIs(t, err, ErrIPInUse)
+		_, err = repo.AllocateSpecific(ctx, pool.ID, "10.0.0.43", "app-02", "")
+		require.ErrorIs(t, err, ErrIPInUse, "reserved")
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		db := newIPAMTestDB(t)
+		repo := NewIPAllocationRepository(db)
+		pool := createTestPool(t, db, "pool", "zone")
+
+		for _, ip := range []string{"10.0.0.9", "10.0.255.251", "192.168.1.1"} {
+			_, err := repo.AllocateSpecific(ctx, pool.ID, ip, "app-01", "")
+			require.ErrorIs(t, err, ErrIPOutOfRange, ip)
+		}
+		_, err := repo.AllocateSpecific(ctx, pool.ID, "10.0.0.300", "app-01", "")
+		require.Error(t, err, "not an address")
+
+		var count int64
+		require.NoError(t, db.Model(&model.IPAllocation{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
+
+func TestIPAllocationRepository_AllocateNextAvailableExhausted(t *testing.T) {
+	ctx := context.Background()
+	db := newIPAMTestDB(t)
+	repo := NewIPAllocationRepository(db)
+	pool := createTestPool(t, db, "small", "zone")
+	require.NoError(t, db.Model(pool).Updates(map[string]interface{}{"start_ip": "10.0.0.10", "end_ip": "10.0.0.12"}).Error)
+
+	createTestAllocation(t, db, pool.ID, "10.0.0.10", "app-01", model.IPStatusAllocated)
+	createTestAllocation(t, db, pool.ID, "10.0.0.11", "", model.IPStatusReserved)
+	createTestAllocation(t, db, pool.ID, "10.0.0.12", "app-02", model.IPStatusAllocated)
+	// Leftover from a wider range; not part of the current pool usage.
+	createTestAllocation(t, db, pool.ID, "10.0.0.50", "app-03", model.IPStatusAllocated)
+
+	_, err := repo.AllocateNextAvailable(ctx, pool.ID, "app-04", "")
+	require.ErrorIs(t, err, ErrPoolExhausted)
+
+	var exhausted *PoolExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+	assert.Equal(t, &PoolExhaustedError{
+		PoolID:    pool.ID,
+		PoolName:  "small",
+		StartIP:   "10.0.0.10",
+		EndIP:     "10.0.0.12",
+		Total:     3,
+		Allocated: 2,
+		Reserved:  1,
+	}, exhausted)
+	assert.Contains(t, err.Error(), "3 addresses, 2 allocated, 1 reserved")
+}
+
+func TestIPAllocationRepository_AllocateNextAvailableResumes(t *testing.T) {
+	ctx := context.Background()
+	db := newIPAMTestDB(t)
+	repo := NewIPAllocationRepository(db)
+	pool := createTestPool(t, db, "small", "zone")
+	require.NoError(t, db.Model(pool).Updates(map[string]interface{}{"start_ip": "10.0.0.10", "end_ip": "10.0.0.13"}).Error)
+
+	allocate := func() string {
+		t.Helper()
+		allocation, err := repo.AllocateNextAvailable(ctx, pool.ID, "", "")
+		require.NoError(t, err)
+		return allocation.IPAddress
+	}
+	first := allocate()
+	assert.Equal(t, "10.0.0.10", first)
+	assert.Equal(t, "10.0.0.11", allocate())
+
+	var released model.IPAllocation
+	require.NoError(t, db.First(&released, "ip_address = ?", first).Error)
+	require.NoError(t, repo.Release(ctx, released.ID))
+	assert.Equal(t, "10.0.0.12", allocate(), "the search resumes after the last allocated address")
+	createTestAllocation(t, db, pool.ID, "10.0.0.13", "", model.IPStatusReserved)
+
+	reused, err := repo.AllocateNextAvailable(ctx, pool.ID, "web-01", "res-1")
+	require.NoError(t, err)
+	assert.Equal(t, released.ID, reused.ID, "the search wraps around to the released row")
+	assert.Equal(t, model.IPStatusAllocated, reused.Status)
+	assert.Equal(t, "web-01", reused.Hostname)
+
+	_, err = repo.AllocateNextAvailable(ctx, pool.ID, "", "")
+	require.ErrorIs(t, err, ErrPoolExhausted)
+
+	t.Run("a hint outside a changed range is ignored", func(t *testing.T) {
+		require.NoError(t, db.Model(pool).Updates(map[string]interface{}{"start_ip": "10.0.0.20", "end_ip": "10.0.0.29"}).Error)
+		assert.Equal(t, "10.0.0.20", allocate())
+	})
+}
+
+func TestIPAllocationRepository_IPv6(t *testing.T) {
+	ctx := context.Background()
+	db := newIPAMTestDB(t)
+	repo := NewIPAllocationRepository(db)
+	pool := createTestPool(t, db, "v6", "zone")
+	require.NoError(t, db.Model(pool).Updates(map[string]interface{}{
+		"start_ip": "2001:db8::fe", "end_ip": "2001:db8::101",
+	}).Error)
+
+	var addresses []string
+	for range 4 {
+		allocation, err := repo.AllocateNextAvailable(ctx, pool.ID, "", "")
+		require.NoError(t, err)
+		addresses = append(addresses, allocation.IPAddress)
+	}
+	assert.Equal(t, []string{"2001:db8::fe", "2001:db8::ff", "2001:db8::100", "2001:db8::101"}, addresses, "the count carries across bytes")
+	_, err := repo.AllocateNextAvailable(ctx, pool.ID, "", "")
+	require.ErrorIs(t, err, ErrPoolExhausted)
+
+	released, err := repo.GetByIPAddress(ctx, pool.ID, "2001:db8::ff")
+	require.NoError(t, err)
+	require.NoError(t, repo.Release(ctx, released.ID))
+	available, err := repo.GetAvailableCount(ctx, pool.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), available)
+
+	reused, err := repo.AllocateNextAvailable(ctx, pool.ID, "web-01", "")
+	require.NoError(t, err)
+	assert.Equal(t, released.ID, reused.ID)
+	assert.Equal(t, "2001:db8::ff", reused.IPAddress)
+
+	_, err = repo.AllocateSpecific(ctx, pool.ID, "2001:db8::1:0", "", "")
+	require.ErrorIs(t, err, ErrIPOutOfRange)
+
+	t.Run("a whole /64 is counted and allocated from without walking it", func(t *testing.T) {
+		require.NoError(t, db.Model(pool).Updates(map[string]interface{}{
+			"start_ip": "2001:db8::1", "end_ip": "2001:db8::ffff:ffff:ffff:ffff", "last_allocated_ip": "",
+		}).Error)
+		utilization, err := repo.GetUtilization(ctx, pool.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(maxPoolSize), utilization.Total, "2^64 addresses are capped")
+
+		allocation, err := repo.AllocateNextAvailable(ctx, pool.ID, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, "2001:db8::1", allocation.IPAddress)
+		available, err := repo.GetAvailableCount(ctx, pool.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(maxPoolSize-5), available)
+	})
+}
+
+// BenchmarkAllocateNextAvailable allocates from a /20 pool whose start is already taken to
+// the given utilization; each allocation is deleted again so the utilization stays put.
+func BenchmarkAllocateNextAvailable(b *testing.B) {
+	for _, utilization := range []int{0, 50, 90} {
+		b.Run(fmt.Sprintf("utilization-%d", utilization), func(b *testing.B) {
+			ctx := context.Background()
+			db := newIPAMTestDB(b)
+			repo := NewIPAllocationRepository(db)
+			pool := createTestPool(b, db, "large", "zone")
+			require.NoError(b, db.Model(pool).Updates(map[string]interface{}{"start_ip": "10.1.0.0", "end_ip": "10.1.15.255"}).Error)
+
+			taken := make([]*model.IPAllocation, 4096*utilization/100)
+			for i := range taken {
+				taken[i] = &model.IPAllocation{
+					IPPoolID: pool.ID, IPAddress: fmt.Sprintf("10.1.%d.%d", i/256, i%256), Status: model.IPStatusAllocated,
+				}
+			}
+			if len(taken) > 0 {
+				require.NoError(b, db.CreateInBatches(taken, 500).Error)
+			}
+
+			b.ResetTimer()
+			for range b.N {
+				allocation, err := repo.AllocateNextAvailable(ctx, pool.ID, "", "")
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				if err := db.Unscoped().Delete(allocation).Error; err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+func TestIPAllocationRepository_GetUtilization(t *testing.T) {
+	ctx := context.Background()
+	db := newIPAMTestDB(t)
+	repo := NewIPAllocationRepository(db)
+	pool := createTestPool(t, db, "small", "zone")
+	require.NoError(t, db.Model(pool).Updates(map[string]interface{}{"start_ip": "10.0.0.10", "end_ip": "10.0.0.13"}).Error)
+
+	createTestAllocation(t, db, pool.ID, "10.0.0.10", "app-01", model.IPStatusAllocated)
+	createTestAllocation(t, db, pool.ID, "10.0.0.11", "", model.IPStatusReserved)
+	createTestAllocation(t, db, pool.ID, "10.0.0.12", "", model.IPStatusAvailable)
+	createTestAllocation(t, db, pool.ID, "10.0.0.50", "app-03", model.IPStatusAllocated)
+
+	utilization, err := repo.GetUtilization(ctx, pool.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &PoolUtilization{PoolID: pool.ID, PoolName: "small", ZoneID: "zone", Total: 4, Allocated: 1, Reserved: 1}, utilization)
+	assert.InDelta(t, 50.0, utilization.Percent(), 0.001)
+
+	_, err = repo.GetUtilization(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestIPAllocationRepository_AllocateProbed(t *testing.T) {
+	ctx := context.Background()
+	db := newIPAMTestDB(t)
+	repo := NewIPAllocationRepository(db)
+	newPool := func(name, start, end string, verify bool) *model.IPPool {
+		pool := createTestPool(t, db, name, "zone")
+		require.NoError(t, db.Model(pool).Updates(map[string]interface{}{
+			"start_ip": start, "end_ip": end, "verify_before_allocate": verify,
+		}).Error)
+		return pool
+	}
+	// answering probes every address it is given and reports those in busy as answering
+	answering := func(busy ...string) (AddressProbe, *[]string) {
+		var probed []string
+		return func(_ context.Context, ip net.IP) bool {
+			probed = append(probed, ip.String())
+			return slices.Contains(busy, ip.String())
+		}, &probed
+	}
+
+	t.Run("answering candidate is reserved before anything is allocated", func(t *testing.T) {
+		pool := newPool("verified", "10.0.1.10", "10.0.1.20", true)
+		probe, probed := answering("10.0.1.10")
+
+		allocation, err := repo.AllocateProbed(ctx, pool.ID, "", "web-01", "", probe)
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.1.11", allocation.IPAddress)
+		assert.Equal(t, []string{"10.0.1.10", "10.0.1.11"}, *probed)
+
+		reserved, err := repo.GetByIPAddress(ctx, pool.ID, "10.0.1.10")
+		require.NoError(t, err)
+		assert.Equal(t, model.IPStatusReserved, reserved.Status)
+		assert.Empty(t, reserved.Hostname, "the reservation is not bound to the request")
+		assert.Nil(t, reserved.AllocatedAt, "the address was never recorded as handed out")
+		assert.Equal(t, answeringNote, reserved.Description)
+	})
+
+	t.Run("too many answering candidates keeps their reservations", func(t *testing.T) {
+		pool := newPool("noisy", "10.0.2.10", "10.0.2.40", true)
+		probe, probed := answering(func() []string {
+			busy := make([]string, 0, maxAddressProbes)
+			for i := range maxAddressProbes {
+				busy = append(busy, fmt.Sprintf("10.0.2.%d", 10+i))
+			}
+			return busy
+		}()...)
+
+		_, err := repo.AllocateProbed(ctx, pool.ID, "", "", "", probe)
+		require.ErrorIs(t, err, ErrAddressesAnswering)
+		assert.Len(t, *probed, maxAddressProbes)
+
+		_, total, err := repo.List(ctx, IPAllocationFilters{PoolID: pool.ID, Status: string(model.IPStatusReserved)}, 0, 50)
+		require.NoError(t, err)
+		assert.Equal(t, int64(maxAddressProbes), total)
+		_, allocated, err := repo.List(ctx, IPAllocationFilters{PoolID: pool.ID, Status: string(model.IPStatusAllocated)}, 0, 50)
+		require.NoError(t, err)
+		assert.Zero(t, allocated)
+
+		probe, _ = answering()
+		allocation, err := repo.AllocateProbed(ctx, pool.ID, "", "", "", probe)
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.2.20", allocation.IPAddress, "the next attempt skips the reservations")
+	})
+
+	t.Run("answering requested address is not allocated", func(t *testing.T) {
+		pool := newPool("requested", "10.0.3.10", "10.0.3.20", true)
+		probe, _ := answering("10.0.3.15")
+
+		_, err := repo.AllocateProbed(ctx, pool.ID, "10.0.3.15", "", "", probe)
+		require.ErrorIs(t, err, ErrIPInUse)
+		_, err = repo.GetByIPAddress(ctx, pool.ID, "10.0.3.15")
+		require.ErrorIs(t, err, ErrNotFound, "nothing is recorded for it")
+
+		allocation, err := repo.AllocateProbed(ctx, pool.ID, "10.0.3.16", "", "", probe)
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.3.16", allocation.IPAddress)
+	})
+
+	t.Run("pools that do not verify are not probed", func(t *testing.T) {
+		pool := newPool("unverified", "10.0.4.10", "10.0.4.20", false)
+		probe, probed := answering("10.0.4.10")
+
+		allocation, err := repo.AllocateProbed(ctx, pool.ID, "", "", "", probe)
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.4.10", allocation.IPAddress)
+		assert.Empty(t, *probed)
+	})
+
+	t.Run("reallocation probes the new address", func(t *testing.T) {
+		pool := newPool("moving", "10.0.5.10", "10.0.5.20", true)
+		old := createTestAllocation(t, db, pool.ID, "10.0.5.10", "app-01", model.IPStatusAllocated)
+		probe, probed := answering("10.0.5.11", "10.0.5.15")
+
+		_, err := repo.ReallocateProbed(ctx, old.ID, "10.0.5.15", probe)
+		require.ErrorIs(t, err, ErrIPInUse)
+		unchanged, err := repo.GetByID(ctx, old.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.IPStatusAllocated, unchanged.Status, "the allocation stays where it is")
+
+		moved, err := repo.ReallocateProbed(ctx, old.ID, "", probe)
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.5.12", moved.IPAddress)
+		assert.Equal(t, "app-01", moved.Hostname)
+		assert.Equal(t, []string{"10.0.5.15", "10.0.5.11", "10.0.5.12"}, *probed)
+
+		reserved, err := repo.GetByIPAddress(ctx, pool.ID, "10.0.5.11")
+		require.NoError(t, err)
+		assert.Equal(t, model.IPStatusReserved, reserved.Status)
+	})
+}
+
+func TestIPAllocationRepository_ReserveRange(t *testing.T) {
+	ctx := context.Background()
+	db := newIPAMTestDB(t)
+	repo := NewIPAllocationRepository(db)
+	pool := createTestPool(t, db, "small", "zone")
+	require.NoError(t, db.Model(pool).Updates(map[string]interface{}{"start_ip": "10.0.0.10", "end_ip": "10.0.0.20"}).Error)
+	released := createTestAllocation(t, db, pool.ID, "10.0.0.12", "", model.IPStatusAvailable)
+
+	require.NoError(t, repo.ReserveRange(ctx, pool.ID, "10.0.0.10", "10.0.0.14", "switch management"))
+
+	reservations, total, err := repo.List(ctx, IPAllocationFilters{PoolID: pool.ID, Status: string(model.IPStatusReserved)}, 0, 50)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	for _, reservation := range reservations {
+		assert.Equal(t, "switch management", reservation.Description)
+	}
+	reused, err := repo.GetByID(ctx, released.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.IPStatusReserved, reused.Status, "the released row is reused")
+
+	available, err := repo.GetAvailableCount(ctx, pool.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), available)
+
+	var allocated []string
+	for range 6 {
+		allocation, err := repo.AllocateNextAvailable(ctx, pool.ID, "", "")
+		require.NoError(t, err)
+		allocated = append(allocated, allocation.IPAddress)
+	}
+	assert.Equal(t, []string{"10.0.0.15", "10.0.0.16", "10.0.0.17", "10.0.0.18", "10.0.0.19", "10.0.0.20"}, allocated,
+		"the allocator skips the reserved slice")
+	_, err = repo.AllocateNextAvailable(ctx, pool.ID, "", "")
+	require.ErrorIs(t, err, ErrPoolExhausted)
+
+	t.Run("rejected ranges reserve nothing", func(t *testing.T) {
+		other := createTestPool(t, db, "other", "zone")
+		require.NoError(t, db.Model(other).Updates(map[string]interface{}{"start_ip": "10.0.1.10", "end_ip": "10.0.1.20"}).Error)
+		createTestAllocation(t, db, other.ID, "10.0.1.15", "app-01", model.IPStatusAllocated)
+
+		tests := []struct {
+			name       string
+			start, end string
+			wantErr    error
+		}{
+			{name: "overlaps an allocation", start: "10.0.1.10", end: "10.0.1.15", wantErr: ErrIPInUse},
+			{name: "outside the pool", start: "10.0.1.18", end: "10.0.1.30", wantErr: ErrIPOutOfRange},
+			{name: "reversed", start: "10.0.1.12", end: "10.0.1.11", wantErr: ErrInvalidRange},
+			{name: "mixed families", start: "10.0.1.11", end: "fd00::1", wantErr: ErrInvalidRange},
+			{name: "too large", start: "10.0.0.0", end: "10.0.255.255", wantErr: ErrInvalidRange},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				require.ErrorIs(t, repo.ReserveRange(ctx, other.ID, tt.start, tt.end, ""), tt.wantErr)
+				_, total, err := repo.List(ctx, IPAllocationFilters{PoolID: other.ID, Status: string(model.IPStatusReserved)}, 0, 50)
+				require.NoError(t, err)
+				assert.Zero(t, total)
+			})
+		}
+
+		require.ErrorIs(t, repo.ReserveRange(ctx, "missing", "10.0.1.10", "10.0.1.11", ""), ErrNotFound)
+	})
+}
+
+func TestIPAllocationRepository_HostnameUniqueness(t *testing.T) {
+	ctx := context.Background()
+	db := newIPAMTestDB(t)
+	repo := NewIPAllocationRepository(db)
+	pool := createTestPool(t, db, "pool", "zone")
+	other := createTestPool(t, db, "other", "zone")
+	require.NoError(t, db.Model(other).Updates(map[string]interface{}{"start_ip": "10.0.1.10", "end_ip": "10.0.1.20"}).Error)
+	createTestAllocation(t, db, pool.ID, "10.0.0.10", "web-01", model.IPStatusAllocated)
+
+	t.Run("duplicate hostname in the pool is rejected", func(t *testing.T) {
+		_, err := repo.AllocateNextAvailable(ctx, pool.ID, "web-01", "")
+		require.ErrorIs(t, err, ErrHostnameTaken)
+
+		err = repo.Create(ctx, &model.IPAllocation{IPPoolID: pool.ID, IPAddress: "10.0.0.20", Hostname: "web-01", Status: model.IPStatusAllocated})
+		require.ErrorIs(t, err, ErrHostnameTaken)
+	})
+
+	t.Run("same hostname in another pool is allowed", func(t *testing.T) {
+		allocation, err := repo.AllocateNextAvailable(ctx, other.ID, "web-01", "")
+		require.NoError(t, err)
+		assert.Equal(t, "web-01", allocation.Hostname)
+	})
+
+	t.Run("hostname of a released allocation can be reused", func(t *testing.T) {
+		released := createTestAllocation(t, db, pool.ID, "10.0.0.30", "web-02", model.IPStatusAllocated)
+		require.NoError(t, repo.Release(ctx, released.ID))
+
+		_, err := repo.AllocateNextAvailable(ctx, pool.ID, "web-02", "")
+		require.NoError(t, err)
+	})
+
+	t.Run("lists active hostnames by prefix", func(t *testing.T) {
+		hostnames, err := repo.ListHostnames(ctx, pool.ID, "web-")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"web-01", "web-02"}, hostnames)
+	})
+}
+
+func TestIPPoolRepository_ZoneDefault(t *testing.T) {
+	ctx := context.Background()
+	db := newIPAMTestDB(t)
+	repo := NewIPPoolRepository(db)
+
+	defaultOf := func(zoneID string) []string {
+		var ids []string
+		require.NoError(t, db.Model(&model.IPPool{}).Where("zone_id = ? AND is_default = ?", zoneID, true).Pluck("id", &ids).Error)
+		return ids
+	}
+
+	first := &model.IPPool{Name: "first", CIDR: "10.1.0.0/24", Gateway: "10.1.0.1", StartIP: "10.1.0.10", EndIP: "10.1.0.20", ZoneID: "zone-a", IsDefault: true}
+	require.NoError(t, repo.Create(ctx, first))
+	other := &model.IPPool{Name: "other-zone", CIDR: "10.2.0.0/24", Gateway: "10.2.0.1", StartIP: "10.2.0.10", EndIP: "10.2.0.20", ZoneID: "zone-b", IsDefault: true}
+	require.NoError(t, repo.Create(ctx, other))
+	assert.Equal(t, []string{first.ID}, defaultOf("zone-a"))
+
+	t.Run("creating a new default unsets the previous one in the zone only", func(t *testing.T) {
+		second := &model.IPPool{Name: "second", CIDR: "10.1.1.0/24", Gateway: "10.1.1.1", StartIP: "10.1.1.10", EndIP: "10.1.1.20", ZoneID: "zone-a", IsDefault: true}
+		require.NoError(t, repo.Create(ctx, second))
+		assert.Equal(t, []string{second.ID}, defaultOf("zone-a"))
+		assert.Equal(t, []string{other.ID}, defaultOf("zone-b"))
+	})
+
+	t.Run("updating a pool to default unsets the previous one", func(t *testing.T) {
+		first.IsDefault = true
+		require.NoError(t, repo.Update(ctx, first))
+		assert.Equal(t, []string{first.ID}, defaultOf("zone-a"))
+	})
+
+	t.Run("saving the default again keeps it", func(t *testing.T) {
+		first.Description = "primary"
+		require.NoError(t, repo.Update(ctx, first))
+		assert.Equal(t, []string{first.ID}, defaultOf("zone-a"))
+	})
+}
+
+func TestIPPoolRepository_GetUsage(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &model.IPPool{}, &model.IPAllocation{}, &model.Resource{})
+	repo := NewIPPoolRepository(db)
+	pool := createTestPool(t, db, "pool", "zone")
+	empty := createTestPool(t, db, "empty", "zone")
+
+	vm := &model.Resource{Name: "vm-1", Type: "vm", Provider: "pve", OwnerID: "user-1", Environment: "dev", Status: "running"}
+	require.NoError(t, db.Create(vm).Error)
+	held := &model.IPAllocation{IPPoolID: pool.ID, IPAddress: "10.0.0.10", Hostname: "vm-1", ResourceID: &vm.ID, Status: model.IPStatusAllocated}
+	require.NoError(t, db.Create(held).Error)
+	createTestAllocation(t, db, pool.ID, "10.0.0.11", "", model.IPStatusReserved)
+	createTestAllocation(t, db, pool.ID, "10.0.0.12", "", model.IPStatusAvailable)
+
+	usage, err := repo.GetUsage(ctx, pool.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), usage.Allocations.Count)
+	assert.ElementsMatch(t, []string{"10.0.0.10", "10.0.0.11"}, []string{usage.Allocations.Items[0].Name, usage.Allocations.Items[1].Name})
+	assert.Equal(t, int64(1), usage.Resources.Count)
+	assert.Equal(t, "vm-1", usage.Resources.Items[0].Name)
+
+	usage, err = repo.GetUsage(ctx, empty.ID)
+	require.NoError(t, err)
+	assert.False(t, usage.InUse())
+	assert.Empty(t, usage.Allocations.Items)
+}
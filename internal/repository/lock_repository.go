@@ -0,0 +1,68 @@
+// Package repository provides data access layer implementations.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LockRepository defines the interface for resource lock leases.
+type LockRepository interface {
+	// TryAcquire takes the lease on key for owner, replacing an expired lease.
+	// It reports false when another owner holds an unexpired lease.
+	TryAcquire(ctx context.Context, key, owner string, expiresAt time.Time) (bool, error)
+	// Renew extends owner's lease on key to expiresAt. It reports false when
+	// owner no longer holds the lease.
+	Renew(ctx context.Context, key, owner string, expiresAt time.Time) (bool, error)
+	// Release drops the lease on key if owner still holds it.
+	Release(ctx context.Context, key, owner string) error
+}
+
+type lockRepository struct {
+	db *gorm.DB
+}
+
+// NewLockRepository creates a new lock repository.
+func NewLockRepository(db *gorm.DB) LockRepository {
+	return &lockRepository{db: db}
+}
+
+func (r *lockRepository) TryAcquire(ctx context.Context, key, owner string, expiresAt time.Time) (bool, error) {
+	acquired := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Reclaim a lease left behind by a holder that never released it
+		if err := tx.Where("lock_key = ? AND expires_at < ?", key, time.Now()).
+			Delete(&model.ResourceLock{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.ResourceLock{
+			LockKey:   key,
+			Owner:     owner,
+			ExpiresAt: expiresAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		acquired = result.RowsAffected == 1
+		return nil
+	})
+	return acquired, err
+}
+
+func (r *lockRepository) Renew(ctx context.Context, key, owner string, expiresAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.ResourceLock{}).
+		Where("lock_key = ? AND owner = ?", key, owner).
+		Update("expires_at", expiresAt)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *lockRepository) Release(ctx context.Context, key, owner string) error {
+	return r.db.WithContext(ctx).
+		Where("lock_key = ? AND owner = ?", key, owner).
+		Delete(&model.ResourceLock{}).Error
+}
@@ -0,0 +1,87 @@
+// Package repository provides lock repository tests.
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockRepository_TryAcquire(t *testing.T) {
+	db := newTestDB(t, &model.ResourceLock{})
+	repo := NewLockRepository(db)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	acquired, err := repo.TryAcquire(ctx, "resource-request:1", "owner-a", expiresAt)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = repo.TryAcquire(ctx, "resource-request:1", "owner-b", expiresAt)
+	require.NoError(t, err)
+	assert.False(t, acquired, "held lease blocks another owner")
+
+	acquired, err = repo.TryAcquire(ctx, "resource-request:2", "owner-b", expiresAt)
+	require.NoError(t, err)
+	assert.True(t, acquired, "other keys are independent")
+
+	require.NoError(t, repo.Release(ctx, "resource-request:1", "owner-b"))
+	acquired, err = repo.TryAcquire(ctx, "resource-request:1", "owner-b", expiresAt)
+	require.NoError(t, err)
+	assert.False(t, acquired, "only the holder can release")
+
+	require.NoError(t, repo.Release(ctx, "resource-request:1", "owner-a"))
+	acquired, err = repo.TryAcquire(ctx, "resource-request:1", "owner-b", expiresAt)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestLockRepository_ReclaimsExpiredLease(t *testing.T) {
+	db := newTestDB(t, &model.ResourceLock{})
+	repo := NewLockRepository(db)
+	ctx := context.Background()
+
+	acquired, err := repo.TryAcquire(ctx, "resource-request:1", "crashed", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, err = repo.TryAcquire(ctx, "resource-request:1", "owner-b", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	var lock model.ResourceLock
+	require.NoError(t, db.First(&lock, "lock_key = ?", "resource-request:1").Error)
+	assert.Equal(t, "owner-b", lock.Owner)
+}
+
+func TestLockRepository_Renew(t *testing.T) {
+	db := newTestDB(t, &model.ResourceLock{})
+	repo := NewLockRepository(db)
+	ctx := context.Background()
+
+	acquired, err := repo.TryAcquire(ctx, "resource-request:1", "owner-a", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	renewed, err := repo.Renew(ctx, "resource-request:1", "owner-b", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, renewed, "only the holder can renew")
+
+	extended := time.Now().Add(time.Hour)
+	renewed, err = repo.Renew(ctx, "resource-request:1", "owner-a", extended)
+	require.NoError(t, err)
+	assert.True(t, renewed)
+
+	var lock model.ResourceLock
+	require.NoError(t, db.First(&lock, "lock_key = ?", "resource-request:1").Error)
+	assert.WithinDuration(t, extended, lock.ExpiresAt, time.Second)
+
+	require.NoError(t, repo.Release(ctx, "resource-request:1", "owner-a"))
+	renewed, err = repo.Renew(ctx, "resource-request:1", "owner-a", extended)
+	require.NoError(t, err)
+	assert.False(t, renewed, "a released lease is not renewed")
+}
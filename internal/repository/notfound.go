@@ -0,0 +1,38 @@
+// Package repository provides data access layer implementations.
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// wrapGet maps gorm's record-not-found error to ErrNotFound and returns any other error,
+// including nil, unchanged.
+func wrapGet(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// firstOrNotFound loads the first T that query matches with conds, returning ErrNotFound
+// when there is none.
+func firstOrNotFound[T any](query *gorm.DB, conds ...interface{}) (*T, error) {
+	var record T
+	if err := query.First(&record, conds...).Error; err != nil {
+		return nil, wrapGet(err)
+	}
+	return &record, nil
+}
+
+// wrapDelete returns the error of a delete, or ErrNotFound when it matched no rows.
+func wrapDelete(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Package repository provides not-found mapping tests.
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestWrapGet(t *testing.T) {
+	other := errors.New("connection reset")
+
+	assert.Equal(t, ErrNotFound, wrapGet(gorm.ErrRecordNotFound))
+	assert.Equal(t, ErrNotFound, wrapGet(fmt.Errorf("load pool: %w", gorm.ErrRecordNotFound)))
+	assert.Equal(t, other, wrapGet(other))
+	assert.NoError(t, wrapGet(nil))
+}
+
+func TestFirstOrNotFound(t *testing.T) {
+	db := newTestDB(t, &model.GitRepository{})
+	repo := &model.GitRepository{Name: "modules", URL: "https://git.example.com/modules.git", Type: model.GitRepoTypeModules}
+	require.NoError(t, db.Create(repo).Error)
+
+	found, err := firstOrNotFound[model.GitRepository](db, "id = ?", repo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "modules", found.Name)
+
+	_, err = firstOrNotFound[model.GitRepository](db, "id = ?", "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = firstOrNotFound[model.GitRepository](db, "no_such_column = ?", 1)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound, "other errors pass through")
+}
+
+func TestRepositoryDeleteMissingRecord(t *testing.T) {
+	db := newTestDB(t, &model.GitRepository{})
+	repos := NewGitRepoRepository(db)
+	ctx := context.Background()
+
+	repo := &model.GitRepository{Name: "modules", URL: "https://git.example.com/modules.git", Type: model.GitRepoTypeModules}
+	require.NoError(t, repos.Create(ctx, repo))
+
+	require.NoError(t, repos.Delete(ctx, repo.ID))
+	assert.ErrorIs(t, repos.Delete(ctx, repo.ID), ErrNotFound)
+
+	_, err := repos.GetByID(ctx, repo.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,11 @@
+// Package repository provides data access layer implementations.
+package repository
+
+// Sort orders shared by list queries. Each ends with the primary key so rows that tie on the
+// leading column, such as rows written by one bulk import, keep the same position on every
+// query and pagination neither repeats nor drops them.
+const (
+	orderNewestFirst = "created_at DESC, id DESC"
+	orderOldestFirst = "created_at ASC, id ASC"
+	orderByName      = "name ASC, id ASC"
+)
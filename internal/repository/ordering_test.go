@@ -0,0 +1,92 @@
+// Package repository provides list ordering tests.
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pageThrough collects the IDs of every page returned by list.
+func pageThrough(t *testing.T, pageSize, total int, list func(page int) []string) []string {
+	t.Helper()
+	var ids []string
+	for page := 1; (page-1)*pageSize < total; page++ {
+		ids = append(ids, list(page)...)
+	}
+	return ids
+}
+
+func TestListOrdering_CreatedAtTies(t *testing.T) {
+	db := newTestDB(t, &model.Region{}, &model.Zone{})
+	repo := NewRegionRepository(db)
+	ctx := context.Background()
+
+	// A bulk import writes every row with the same timestamp
+	importedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	const count = 7
+	var want []string
+	for i := 0; i < count; i++ {
+		region := &model.Region{Name: fmt.Sprintf("region-%d", i), Code: fmt.Sprintf("r%d", i), DisplayName: "Region"}
+		region.CreatedAt = importedAt
+		require.NoError(t, db.Create(region).Error)
+		want = append(want, region.ID)
+	}
+	// Newest first breaks the tie by descending ID
+	sort.Sort(sort.Reverse(sort.StringSlice(want)))
+
+	list := func(page int) []string {
+		regions, total, err := repo.List(ctx, page, 3, InfraListOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(count), total)
+		ids := make([]string, 0, len(regions))
+		for _, region := range regions {
+			ids = append(ids, region.ID)
+		}
+		return ids
+	}
+
+	for run := 0; run < 3; run++ {
+		assert.Equal(t, want, pageThrough(t, 3, count, list), "run %d", run)
+	}
+}
+
+func TestListOrdering_NameTies(t *testing.T) {
+	db := newTestDB(t, &model.VMTemplate{}, &model.Zone{})
+	repo := NewVMTemplateRepository(db)
+	ctx := context.Background()
+
+	const count = 5
+	var want []string
+	for i := 0; i < count; i++ {
+		template := &model.VMTemplate{
+			Name:         "Ubuntu 24.04",
+			TemplateName: fmt.Sprintf("ubuntu-2404-%d", i),
+			Provider:     "pve",
+			OSType:       "linux",
+		}
+		require.NoError(t, db.Create(template).Error)
+		want = append(want, template.ID)
+	}
+	sort.Strings(want)
+
+	list := func(page int) []string {
+		templates, _, err := repo.List(ctx, "pve", "", "", (page-1)*2, 2)
+		require.NoError(t, err)
+		ids := make([]string, 0, len(templates))
+		for _, template := range templates {
+			ids = append(ids, template.ID)
+		}
+		return ids
+	}
+
+	for run := 0; run < 3; run++ {
+		assert.Equal(t, want, pageThrough(t, 2, count, list), "run %d", run)
+	}
+}
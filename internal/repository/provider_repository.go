@@ -3,7 +3,6 @@ package repository
 
 import (
 	"context"
-	"errors"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/gorm"
@@ -13,9 +12,18 @@ import (
 type ProviderRepository interface {
 	Create(ctx context.Context, provider *model.ProviderConfig) error
 	GetByID(ctx context.Context, id string) (*model.ProviderConfig, error)
-	List(ctx context.Context, providerType string, offset, limit int) ([]*model.ProviderConfig, int64, error)
+	List(ctx context.Context, filters ProviderFilters, offset, limit int) ([]*model.ProviderConfig, int64, error)
 	Update(ctx context.Context, provider *model.ProviderConfig) error
 	Delete(ctx context.Context, id string) error
+	GetUsage(ctx context.Context, id string) (*Usage, error)
+}
+
+// ProviderFilters defines filters for provider config queries.
+type ProviderFilters struct {
+	Type string
+	// ZoneID matches providers whose credential is scoped to the zone, plus
+	// providers with a global (zone-less) credential or none at all.
+	ZoneID string
 }
 
 type providerRepository struct {
@@ -34,31 +42,29 @@ func (r *providerRepository) Create(ctx context.Context, provider *model.Provide
 
 // GetByID retrieves a provider config by ID.
 func (r *providerRepository) GetByID(ctx context.Context, id string) (*model.ProviderConfig, error) {
-	var provider model.ProviderConfig
-	if err := r.db.WithContext(ctx).Preload("Credential").First(&provider, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &provider, nil
+	return firstOrNotFound[model.ProviderConfig](r.db.WithContext(ctx).Preload("Credential"), "id = ?", id)
 }
 
-// List retrieves provider configs with optional filtering.
-func (r *providerRepository) List(ctx context.Context, providerType string, offset, limit int) ([]*model.ProviderConfig, int64, error) {
+// List retrieves provider configs with optional type and zone filtering.
+func (r *providerRepository) List(ctx context.Context, filters ProviderFilters, offset, limit int) ([]*model.ProviderConfig, int64, error) {
 	var providers []*model.ProviderConfig
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&model.ProviderConfig{})
-	if providerType != "" {
-		query = query.Where("type = ?", providerType)
+	if filters.Type != "" {
+		query = query.Where("provider_configs.type = ?", filters.Type)
+	}
+	if filters.ZoneID != "" {
+		query = query.
+			Joins("LEFT JOIN credentials ON credentials.id = provider_configs.credential_id AND credentials.deleted_at IS NULL").
+			Where("credentials.zone_id = ? OR credentials.zone_id IS NULL", filters.ZoneID)
 	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	if err := query.Preload("Credential").Offset(offset).Limit(limit).Order("created_at DESC").Find(&providers).Error; err != nil {
+	if err := query.Preload("Credential").Offset(offset).Limit(limit).Order("provider_configs.created_at DESC, provider_configs.id DESC").Find(&providers).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -72,5 +78,25 @@ func (r *providerRepository) Update(ctx context.Context, provider *model.Provide
 
 // Delete soft deletes a provider config.
 func (r *providerRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.ProviderConfig{}, "id = ?", id).Error
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.ProviderConfig{}, "id = ?", id))
+}
+
+// GetUsage reports the credentials linked to a provider, the resource requests made with
+// those credentials and the resources they created.
+func (r *providerRepository) GetUsage(ctx context.Context, id string) (*Usage, error) {
+	db := r.db.WithContext(ctx)
+	credentials, err := loadUsageGroup(
+		db.Model(&model.Credential{}).Where("provider_id = ?", id),
+		"credentials", "id, name",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{Credentials: credentials}
+	linked := db.Model(&model.Credential{}).Select("id").Where("provider_id = ?", id)
+	if err := requestUsage(db, usage, "credential_id IN (?)", linked); err != nil {
+		return nil, err
+	}
+	return usage, nil
 }
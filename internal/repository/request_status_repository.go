@@ -0,0 +1,54 @@
+// Package repository provides data access layer implementations.
+package repository
+
+import (
+	"context"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"gorm.io/gorm"
+)
+
+// RequestStatusEventRepository defines the interface for resource request status history.
+type RequestStatusEventRepository interface {
+	// Create appends event to its request's history and sets its sequence number.
+	Create(ctx context.Context, event *model.RequestStatusEvent) error
+	// ListByRequest returns a request's status history in the order it happened.
+	ListByRequest(ctx context.Context, requestID string) ([]*model.RequestStatusEvent, error)
+}
+
+type requestStatusEventRepository struct {
+	db *gorm.DB
+}
+
+// NewRequestStatusEventRepository creates a new request status event repository.
+func NewRequestStatusEventRepository(db *gorm.DB) RequestStatusEventRepository {
+	return &requestStatusEventRepository{db: db}
+}
+
+// Create appends event to its request's history. Sequence numbers are unique per request,
+// so an event written concurrently for the same request fails instead of sharing a position.
+func (r *requestStatusEventRepository) Create(ctx context.Context, event *model.RequestStatusEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var last int
+		if err := tx.Model(&model.RequestStatusEvent{}).
+			Where("request_id = ?", event.RequestID).
+			Select("COALESCE(MAX(sequence), 0)").
+			Scan(&last).Error; err != nil {
+			return err
+		}
+		event.Sequence = last + 1
+		return tx.Create(event).Error
+	})
+}
+
+// ListByRequest returns a request's status history in the order it happened.
+func (r *requestStatusEventRepository) ListByRequest(ctx context.Context, requestID string) ([]*model.RequestStatusEvent, error) {
+	var history []*model.RequestStatusEvent
+	if err := r.db.WithContext(ctx).
+		Where("request_id = ?", requestID).
+		Order("sequence ASC").
+		Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -0,0 +1,35 @@
+// Package repository provides request status history tests.
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestStatusEventRepository(t *testing.T) {
+	db := newTestDB(t, &model.RequestStatusEvent{})
+	repo := NewRequestStatusEventRepository(db)
+	ctx := context.Background()
+
+	steps := [][2]string{{"", "pending"}, {"pending", "approved"}, {"approved", "provisioning"}, {"provisioning", "completed"}}
+	for _, step := range steps {
+		require.NoError(t, repo.Create(ctx, &model.RequestStatusEvent{RequestID: "req-1", FromStatus: step[0], ToStatus: step[1]}))
+		// Another request's history does not affect the sequence
+		require.NoError(t, repo.Create(ctx, &model.RequestStatusEvent{RequestID: "req-2", FromStatus: step[0], ToStatus: step[1]}))
+	}
+
+	history, err := repo.ListByRequest(ctx, "req-1")
+	require.NoError(t, err)
+	require.Len(t, history, len(steps))
+	for i, event := range history {
+		assert.Equal(t, i+1, event.Sequence)
+		assert.Equal(t, steps[i][1], event.ToStatus)
+	}
+
+	err = db.Create(&model.RequestStatusEvent{RequestID: "req-1", Sequence: 2, ToStatus: "failed"}).Error
+	assert.Error(t, err, "sequence numbers are unique per request")
+}
@@ -4,20 +4,38 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/database"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ResourceRepository defines the interface for resource data access.
 type ResourceRepository interface {
 	Create(ctx context.Context, resource *model.Resource) error
 	GetByID(ctx context.Context, id string) (*model.Resource, error)
+	// GetByExternalID retrieves the resource a provider knows by externalID.
+	GetByExternalID(ctx context.Context, provider, externalID string) (*model.Resource, error)
+	// ExistsByName reports whether the owner has a resource in environment named name, compared
+	// case-insensitively. The resource with ID excludeID is not counted.
+	ExistsByName(ctx context.Context, ownerID, environment, name, excludeID string) (bool, error)
 	Update(ctx context.Context, resource *model.Resource) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, filters ResourceFilters, offset, limit int) ([]*model.Resource, int64, error)
+	Search(ctx context.Context, q string, filters ResourceFilters, offset, limit int) ([]*model.Resource, int64, error)
 }
 
+// ErrResourceInUse indicates a resource cannot be deleted because it is still provisioning,
+// running or degraded.
+var ErrResourceInUse = errors.New("resource is in use")
+
+// resourceInUseStatuses are the resource statuses that block deletion.
+var resourceInUseStatuses = []string{"provisioning", "running", "degraded"}
+
 // ResourceFilters defines filters for resource queries.
 type ResourceFilters struct {
 	Type        string
@@ -45,15 +63,24 @@ func (r *resourceRepository) Create(ctx context.Context, resource *model.Resourc
 }
 
 func (r *resourceRepository) GetByID(ctx context.Context, id string) (*model.Resource, error) {
-	var resource model.Resource
-	result := r.db.WithContext(ctx).Preload("Owner").First(&resource, "id = ?", id)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, result.Error
+	return firstOrNotFound[model.Resource](r.db.WithContext(ctx).Preload("Owner"), "id = ?", id)
+}
+
+func (r *resourceRepository) GetByExternalID(ctx context.Context, provider, externalID string) (*model.Resource, error) {
+	return firstOrNotFound[model.Resource](r.db.WithContext(ctx), "provider = ? AND external_id = ?", provider, externalID)
+}
+
+func (r *resourceRepository) ExistsByName(ctx context.Context, ownerID, environment, name, excludeID string) (bool, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&model.Resource{}).
+		Where("owner_id = ? AND environment = ? AND LOWER(name) = ?", ownerID, environment, strings.ToLower(name))
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
 	}
-	return &resource, nil
+	return count > 0, nil
 }
 
 func (r *resourceRepository) Update(ctx context.Context, resource *model.Resource) error {
@@ -61,24 +88,112 @@ func (r *resourceRepository) Update(ctx context.Context, resource *model.Resourc
 	return result.Error
 }
 
+// Delete removes a resource and releases the IP allocations bound to it in one transaction.
+// Resources that are provisioning or running are refused with ErrResourceInUse.
 func (r *resourceRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Delete(&model.Resource{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		resource, err := firstOrNotFound[model.Resource](tx.Select("id", "status"), "id = ?", id)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(resourceInUseStatuses, resource.Status) {
+			return fmt.Errorf("%w: status is %s", ErrResourceInUse, resource.Status)
+		}
+
+		if err := tx.Model(&model.IPAllocation{}).
+			Where("resource_id = ? AND status = ?", id, model.IPStatusAllocated).
+			Updates(map[string]interface{}{
+				"status":       model.IPStatusAvailable,
+				"hostname":     "",
+				"resource_id":  "",
+				"allocated_at": nil,
+			}).Error; err != nil {
+			return err
+		}
+
+		// The status condition keeps a resource that started provisioning since it was read.
+		result := tx.Where("status NOT IN ?", resourceInUseStatuses).Delete(&model.Resource{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrResourceInUse
+		}
+		return nil
+	})
+}
+
+func (r *resourceRepository) List(ctx context.Context, filters ResourceFilters, offset, limit int) ([]*model.Resource, int64, error) {
+	var resources []*model.Resource
+	var total int64
+
+	query := applyResourceFilters(r.db.WithContext(ctx).Scopes(database.ReadReplica).Model(&model.Resource{}), filters)
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
+
+	// Get paginated results
+	result := query.Preload("Owner").Offset(offset).Limit(limit).Order(orderNewestFirst).Find(&resources)
+	if result.Error != nil {
+		return nil, 0, result.Error
 	}
-	return nil
+
+	return resources, total, nil
 }
 
-func (r *resourceRepository) List(ctx context.Context, filters ResourceFilters, offset, limit int) ([]*model.Resource, int64, error) {
+// Search returns the resources matching q, best matches first. q is compared
+// case-insensitively against the name, hostname, IP address, tags and spec values (via
+// the search_text column), and an IP address also finds the resource holding that
+// allocation. Exact IP and name matches rank above prefix, tag and substring matches.
+func (r *resourceRepository) Search(ctx context.Context, q string, filters ResourceFilters, offset, limit int) ([]*model.Resource, int64, error) {
 	var resources []*model.Resource
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&model.Resource{})
+	q = strings.ToLower(strings.TrimSpace(q))
+	contains := "%" + escapeLike(q) + "%"
+	allocated := r.db.Model(&model.IPAllocation{}).
+		Select("resource_id").
+		Where("ip_address = ? AND status = ?", q, model.IPStatusAllocated)
 
-	// Apply filters
+	query := applyResourceFilters(r.db.WithContext(ctx).Scopes(database.ReadReplica).Model(&model.Resource{}), filters).
+		Where(r.db.Where("search_text LIKE ? ESCAPE '!'", contains).Or("id IN (?)", allocated))
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	rank := clause.Expr{
+		SQL: `CASE
+			WHEN ip_address = ? OR id IN (?) THEN 0
+			WHEN LOWER(name) = ? THEN 1
+			WHEN LOWER(name) LIKE ? ESCAPE '!' THEN 2
+			WHEN LOWER(host_name) = ? THEN 3
+			WHEN LOWER(tags) LIKE ? ESCAPE '!' THEN 4
+			WHEN LOWER(name) LIKE ? ESCAPE '!' THEN 5
+			ELSE 6
+		END, ` + orderNewestFirst,
+		Vars: []interface{}{
+			q, allocated,
+			q,
+			escapeLike(q) + "%",
+			q,
+			`%"` + escapeLike(q) + `"%`,
+			contains,
+		},
+		WithoutParentheses: true,
+	}
+	result := query.Preload("Owner").Clauses(clause.OrderBy{Expression: rank}).Offset(offset).Limit(limit).Find(&resources)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return resources, total, nil
+}
+
+// applyResourceFilters narrows query to the resources matching filters.
+func applyResourceFilters(query *gorm.DB, filters ResourceFilters) *gorm.DB {
 	if filters.Type != "" {
 		query = query.Where("type = ?", filters.Type)
 	}
@@ -94,19 +209,13 @@ func (r *resourceRepository) List(ctx context.Context, filters ResourceFilters,
 	if filters.OwnerID != "" {
 		query = query.Where("owner_id = ?", filters.OwnerID)
 	}
+	return query
+}
 
-	// Get total count
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated results
-	result := query.Preload("Owner").Offset(offset).Limit(limit).Find(&resources)
-	if result.Error != nil {
-		return nil, 0, result.Error
-	}
-
-	return resources, total, nil
+// escapeLike escapes the LIKE wildcards in s, using '!' as the escape character. '!' is
+// used rather than a backslash because MySQL and SQLite disagree on backslash in literals.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
 }
 
 // ResourceRequestRepository defines the interface for resource request data access.
@@ -143,8 +252,7 @@ func (r *resourceRequestRepository) Create(ctx context.Context, request *model.R
 }
 
 func (r *resourceRequestRepository) GetByID(ctx context.Context, id string) (*model.ResourceRequest, error) {
-	var request model.ResourceRequest
-	result := r.db.WithContext(ctx).
+	query := r.db.WithContext(ctx).
 		Preload("Requester").
 		Preload("Approver").
 		Preload("Region").
@@ -155,38 +263,27 @@ func (r *resourceRequestRepository) GetByID(ctx context.Context, id string) (*mo
 		Preload("TfProvider.Registry").
 		Preload("TfModule").
 		Preload("TfModule.Registry").
-		Preload("TfModule.Provider").
-		First(&request, "id = ?", id)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, result.Error
-	}
-	return &request, nil
+		Preload("TfModule.Provider")
+	return firstOrNotFound[model.ResourceRequest](query, "id = ?", id)
 }
 
+// Update saves request. Saving is idempotent, so a save aborted by a deadlock with a
+// concurrent update, such as two approvers acting at once, is retried.
 func (r *resourceRequestRepository) Update(ctx context.Context, request *model.ResourceRequest) error {
-	result := r.db.WithContext(ctx).Save(request)
-	return result.Error
+	return withRetryOnSerialization(ctx, func() error {
+		return r.db.WithContext(ctx).Save(request).Error
+	})
 }
 
 func (r *resourceRequestRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Delete(&model.ResourceRequest{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.ResourceRequest{}, "id = ?", id))
 }
 
 func (r *resourceRequestRepository) List(ctx context.Context, filters RequestFilters, offset, limit int) ([]*model.ResourceRequest, int64, error) {
 	var requests []*model.ResourceRequest
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&model.ResourceRequest{})
+	query := r.db.WithContext(ctx).Scopes(database.ReadReplica).Model(&model.ResourceRequest{})
 
 	// Apply filters
 	if filters.Status != "" {
@@ -216,7 +313,7 @@ func (r *resourceRequestRepository) List(ctx context.Context, filters RequestFil
 		Preload("TfProvider.Registry").
 		Preload("TfModule").
 		Preload("TfModule.Registry").
-		Offset(offset).Limit(limit).Order("created_at DESC").Find(&requests)
+		Offset(offset).Limit(limit).Order(orderNewestFirst).Find(&requests)
 	if result.Error != nil {
 		return nil, 0, result.Error
 	}
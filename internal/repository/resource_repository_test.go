@@ -0,0 +1,192 @@
+// Package repository provides resource repository tests.
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceRepository_Delete(t *testing.T) {
+	db := newTestDB(t, &model.Resource{}, &model.IPPool{}, &model.IPAllocation{})
+	repo := NewResourceRepository(db)
+	ctx := context.Background()
+
+	createResource := func(name, status string) *model.Resource {
+		resource := &model.Resource{Name: name, Type: "vm", Provider: "pve", Status: status, OwnerID: "owner-1", Environment: "dev"}
+		require.NoError(t, db.Create(resource).Error)
+		return resource
+	}
+	bindAllocation := func(allocation *model.IPAllocation, resourceID string) {
+		require.NoError(t, db.Model(allocation).Update("resource_id", resourceID).Error)
+	}
+
+	pool := createTestPool(t, db, "pool-a", "zone-a")
+
+	t.Run("releases the resource's allocations", func(t *testing.T) {
+		stopped := createResource("vm-stopped", "stopped")
+		allocation := createTestAllocation(t, db, pool.ID, "10.0.0.10", "vm-stopped", model.IPStatusAllocated)
+		bindAllocation(allocation, stopped.ID)
+
+		require.NoError(t, repo.Delete(ctx, stopped.ID))
+
+		_, err := repo.GetByID(ctx, stopped.ID)
+		require.ErrorIs(t, err, ErrNotFound)
+		var released model.IPAllocation
+		require.NoError(t, db.First(&released, "id = ?", allocation.ID).Error)
+		assert.Equal(t, model.IPStatusAvailable, released.Status)
+		assert.Empty(t, released.Hostname)
+		assert.Nil(t, released.AllocatedAt)
+	})
+
+	t.Run("refuses resources in use and keeps their allocations", func(t *testing.T) {
+		for status, ip := range map[string]string{"provisioning": "10.0.0.20", "running": "10.0.0.21", "degraded": "10.0.0.22"} {
+			resource := createResource("vm-"+status, status)
+			allocation := createTestAllocation(t, db, pool.ID, ip, "vm-"+status, model.IPStatusAllocated)
+			bindAllocation(allocation, resource.ID)
+
+			err := repo.Delete(ctx, resource.ID)
+			require.ErrorIs(t, err, ErrResourceInUse)
+
+			_, err = repo.GetByID(ctx, resource.ID)
+			require.NoError(t, err)
+			var kept model.IPAllocation
+			require.NoError(t, db.First(&kept, "id = ?", allocation.ID).Error)
+			assert.Equal(t, model.IPStatusAllocated, kept.Status)
+		}
+	})
+
+	t.Run("missing resource", func(t *testing.T) {
+		require.ErrorIs(t, repo.Delete(ctx, "missing"), ErrNotFound)
+	})
+}
+
+func TestResourceRepository_Search(t *testing.T) {
+	db := newTestDB(t, &model.Resource{}, &model.User{}, &model.IPPool{}, &model.IPAllocation{})
+	repo := NewResourceRepository(db)
+	ctx := context.Background()
+
+	create := func(resource *model.Resource) *model.Resource {
+		resource.Type, resource.Provider, resource.Status, resource.OwnerID = "vm", "pve", "running", "owner-1"
+		if resource.Environment == "" {
+			resource.Environment = "dev"
+		}
+		require.NoError(t, repo.Create(ctx, resource))
+		return resource
+	}
+	names := func(resources []*model.Resource) []string {
+		out := make([]string, 0, len(resources))
+		for _, r := range resources {
+			out = append(out, r.Name)
+		}
+		return out
+	}
+
+	web := create(&model.Resource{Name: "web-01", IPAddress: "10.0.0.5", Tags: `["frontend","team-blue"]`})
+	create(&model.Resource{Name: "db-01", Spec: `{"cpu":4,"outputs":{"vm_ip":"10.0.0.50"}}`, Tags: `["Backend","team-blue"]`})
+	create(&model.Resource{Name: "cache-01", Spec: `{"cpu":2,"os_image":"ubuntu-22.04"}`, Environment: "prod"})
+	worker := create(&model.Resource{Name: "worker-01"})
+	pool := createTestPool(t, db, "pool-a", "zone-a")
+	allocation := createTestAllocation(t, db, pool.ID, "10.0.1.7", "worker-01", model.IPStatusAllocated)
+	require.NoError(t, db.Model(allocation).Update("resource_id", worker.ID).Error)
+
+	t.Run("by IP address", func(t *testing.T) {
+		found, total, err := repo.Search(ctx, "10.0.0.5", ResourceFilters{}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, []string{"web-01", "db-01"}, names(found), "the exact IP ranks above the output containing it")
+	})
+
+	t.Run("by allocated IP", func(t *testing.T) {
+		found, _, err := repo.Search(ctx, "10.0.1.7", ResourceFilters{}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"worker-01"}, names(found))
+	})
+
+	t.Run("by tag value", func(t *testing.T) {
+		found, total, err := repo.Search(ctx, "team-blue", ResourceFilters{}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.ElementsMatch(t, []string{"web-01", "db-01"}, names(found))
+
+		found, _, err = repo.Search(ctx, "BACKEND", ResourceFilters{}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"db-01"}, names(found), "matching is case-insensitive")
+	})
+
+	t.Run("by spec value", func(t *testing.T) {
+		found, _, err := repo.Search(ctx, "ubuntu-22", ResourceFilters{}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cache-01"}, names(found))
+
+		found, _, err = repo.Search(ctx, "cpu=4", ResourceFilters{}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"db-01"}, names(found))
+	})
+
+	t.Run("name matches rank first and filters apply", func(t *testing.T) {
+		found, _, err := repo.Search(ctx, "01", ResourceFilters{}, 0, 10)
+		require.NoError(t, err)
+		assert.Len(t, found, 4)
+
+		found, _, err = repo.Search(ctx, "01", ResourceFilters{Environment: "prod"}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cache-01"}, names(found))
+	})
+
+	t.Run("wildcards are literal", func(t *testing.T) {
+		found, total, err := repo.Search(ctx, "%", ResourceFilters{}, 0, 10)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, found)
+	})
+
+	t.Run("search text follows updates", func(t *testing.T) {
+		web.Tags = `["frontend","team-red"]`
+		require.NoError(t, repo.Update(ctx, web))
+
+		found, _, err := repo.Search(ctx, "team-blue", ResourceFilters{}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"db-01"}, names(found))
+	})
+}
+
+func TestResourceRepository_ExistsByName(t *testing.T) {
+	db := newTestDB(t, &model.Resource{}, &model.IPAllocation{})
+	repo := NewResourceRepository(db)
+	ctx := context.Background()
+
+	resource := &model.Resource{Name: "Web-01", Type: "vm", Provider: "pve", Status: "stopped", OwnerID: "owner-1", Environment: "dev"}
+	require.NoError(t, db.Create(resource).Error)
+
+	tests := []struct {
+		name        string
+		ownerID     string
+		environment string
+		resource    string
+		excludeID   string
+		want        bool
+	}{
+		{name: "same name", ownerID: "owner-1", environment: "dev", resource: "Web-01", want: true},
+		{name: "differs only in case", ownerID: "owner-1", environment: "dev", resource: "WEB-01", want: true},
+		{name: "another owner", ownerID: "owner-2", environment: "dev", resource: "Web-01", want: false},
+		{name: "another environment", ownerID: "owner-1", environment: "prod", resource: "Web-01", want: false},
+		{name: "excluded resource", ownerID: "owner-1", environment: "dev", resource: "web-01", excludeID: resource.ID, want: false},
+		{name: "another name", ownerID: "owner-1", environment: "dev", resource: "Web-02", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exists, err := repo.ExistsByName(ctx, tt.ownerID, tt.environment, tt.resource, tt.excludeID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, exists)
+		})
+	}
+
+	require.NoError(t, repo.Delete(ctx, resource.ID))
+	exists, err := repo.ExistsByName(ctx, "owner-1", "dev", "Web-01", "")
+	require.NoError(t, err)
+	assert.False(t, exists, "deleted resources free their name")
+}
@@ -0,0 +1,80 @@
+// Package repository provides data access layer implementations.
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// ErrTransactionConflict indicates the database kept aborting a transaction to resolve a
+// deadlock or serialization conflict with concurrent ones. Trying again later may succeed.
+var ErrTransactionConflict = errors.New("transaction conflicted with concurrent updates")
+
+// MySQL error numbers for transactions the server rolled back so another could proceed.
+const (
+	mysqlLockWaitTimeout = 1205
+	mysqlDeadlock        = 1213
+)
+
+// mysqlSerializationState is the SQLSTATE class for serialization failures.
+const mysqlSerializationState = "40001"
+
+// Bounds for retrying a transaction aborted by a deadlock or serialization conflict.
+const (
+	maxSerializationAttempts = 4
+	serializationRetryDelay  = 10 * time.Millisecond // Doubled per retry, plus up to as much jitter
+)
+
+// isSerializationFailure reports whether err is a deadlock or serialization error, after
+// which the whole transaction can be run again.
+func isSerializationFailure(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case mysqlDeadlock, mysqlLockWaitTimeout:
+		return true
+	}
+	return string(mysqlErr.SQLState[:]) == mysqlSerializationState
+}
+
+// withRetryOnSerialization runs fn, and runs it again while it fails with a deadlock or
+// serialization error, up to maxSerializationAttempts times with a jittered pause between
+// attempts. fn must be safe to repeat, as a transaction the database rolled back is. When
+// every attempt fails, the error wraps ErrTransactionConflict.
+func withRetryOnSerialization(ctx context.Context, fn func() error) error {
+	delay := serializationRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isSerializationFailure(err) {
+			return err
+		}
+		if attempt >= maxSerializationAttempts {
+			return fmt.Errorf("%w: gave up after %d attempts: %w", ErrTransactionConflict, attempt, err)
+		}
+
+		timer := time.NewTimer(delay + rand.N(delay)) // #nosec G404 -- jitter needs no cryptographic randomness
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ErrTransactionConflict, err)
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// transactionWithRetry runs fn in a transaction on db, running the whole transaction again
+// when the database aborts it to resolve a deadlock or serialization conflict.
+func transactionWithRetry(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return withRetryOnSerialization(ctx, func() error {
+		return db.WithContext(ctx).Transaction(fn)
+	})
+}
@@ -0,0 +1,112 @@
+// Package repository provides deadlock retry tests.
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var errTestDeadlock = &mysql.MySQLError{Number: mysqlDeadlock, Message: "Deadlock found when trying to get lock; try restarting transaction"}
+
+// failWrites makes the next failures create and update statements on db fail with err, as a
+// database resolving a deadlock would, and returns the number of writes attempted so far.
+func failWrites(t *testing.T, db *gorm.DB, failures int, err error) *int {
+	t.Helper()
+	attempts := 0
+	fail := func(tx *gorm.DB) {
+		attempts++
+		if attempts <= failures {
+			_ = tx.AddError(err) //nolint:errcheck // AddError returns the error it records
+		}
+	}
+	require.NoError(t, db.Callback().Create().Before("gorm:create").Register("test:fail_create", fail))
+	require.NoError(t, db.Callback().Update().Before("gorm:update").Register("test:fail_update", fail))
+	return &attempts
+}
+
+func TestAllocateNextAvailable_RetriesDeadlock(t *testing.T) {
+	db := newIPAMTestDB(t)
+	pool := createTestPool(t, db, "pool", "zone-1")
+	attempts := failWrites(t, db, 1, errTestDeadlock)
+	repo := NewIPAllocationRepository(db)
+
+	allocation, err := repo.AllocateNextAvailable(context.Background(), pool.ID, "web-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.10", allocation.IPAddress)
+	assert.Equal(t, 3, *attempts, "the failed create, then the create and the pool's last allocated address")
+
+	var count int64
+	require.NoError(t, db.Model(&model.IPAllocation{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "the rolled back attempt leaves no allocation behind")
+}
+
+func TestResourceRequestUpdate_RetriesDeadlock(t *testing.T) {
+	db := newTestDB(t, &model.ResourceRequest{})
+	request := &model.ResourceRequest{Title: "web", Type: "vm", RequesterID: "user-1", Status: "pending"}
+	require.NoError(t, db.Create(request).Error)
+	attempts := failWrites(t, db, 2, errTestDeadlock)
+	repo := NewResourceRequestRepository(db)
+
+	request.Status = "approved"
+	require.NoError(t, repo.Update(context.Background(), request))
+	assert.Equal(t, 3, *attempts)
+
+	var saved model.ResourceRequest
+	require.NoError(t, db.First(&saved, "id = ?", request.ID).Error)
+	assert.Equal(t, "approved", saved.Status)
+}
+
+func TestWithRetryOnSerialization(t *testing.T) {
+	t.Run("gives up after the attempt limit", func(t *testing.T) {
+		calls := 0
+		err := withRetryOnSerialization(context.Background(), func() error {
+			calls++
+			return errTestDeadlock
+		})
+		require.ErrorIs(t, err, ErrTransactionConflict)
+		var mysqlErr *mysql.MySQLError
+		require.ErrorAs(t, err, &mysqlErr)
+		assert.Equal(t, maxSerializationAttempts, calls)
+	})
+
+	t.Run("other errors are returned without retrying", func(t *testing.T) {
+		calls := 0
+		duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+		err := withRetryOnSerialization(context.Background(), func() error {
+			calls++
+			return duplicate
+		})
+		assert.Equal(t, duplicate, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("cancellation stops the retries", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		calls := 0
+		err := withRetryOnSerialization(ctx, func() error {
+			calls++
+			return errTestDeadlock
+		})
+		require.ErrorIs(t, err, ErrTransactionConflict)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	serialization := &mysql.MySQLError{Number: 3101, SQLState: [5]byte{'4', '0', '0', '0', '1'}}
+	assert.True(t, isSerializationFailure(errTestDeadlock))
+	assert.True(t, isSerializationFailure(&mysql.MySQLError{Number: mysqlLockWaitTimeout}))
+	assert.True(t, isSerializationFailure(serialization))
+	assert.True(t, isSerializationFailure(errors.Join(errors.New("commit"), errTestDeadlock)), "wrapped errors are recognized")
+	assert.False(t, isSerializationFailure(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, isSerializationFailure(errors.New("deadlock")))
+	assert.False(t, isSerializationFailure(nil))
+}
@@ -4,6 +4,9 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/gorm"
@@ -19,8 +22,14 @@ type RoleRepository interface {
 	List(ctx context.Context, offset, limit int) ([]*model.Role, int64, error)
 	AddPermissions(ctx context.Context, roleID string, permissionIDs []string) error
 	RemovePermissions(ctx context.Context, roleID string, permissionIDs []string) error
+	SetPermissions(ctx context.Context, roleID string, permissionIDs []string) error
+	ListPermissions(ctx context.Context, roleID string) ([]*model.Permission, error)
 }
 
+// ErrUnknownPermission indicates a permission assignment names a permission ID that does
+// not exist.
+var ErrUnknownPermission = errors.New("unknown permission")
+
 type roleRepository struct {
 	db *gorm.DB
 }
@@ -42,27 +51,11 @@ func (r *roleRepository) Create(ctx context.Context, role *model.Role) error {
 }
 
 func (r *roleRepository) GetByID(ctx context.Context, id string) (*model.Role, error) {
-	var role model.Role
-	result := r.db.WithContext(ctx).Preload("Permissions").First(&role, "id = ?", id)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, result.Error
-	}
-	return &role, nil
+	return firstOrNotFound[model.Role](r.db.WithContext(ctx).Preload("Permissions"), "id = ?", id)
 }
 
 func (r *roleRepository) GetByCode(ctx context.Context, code string) (*model.Role, error) {
-	var role model.Role
-	result := r.db.WithContext(ctx).Preload("Permissions").First(&role, "code = ?", code)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, result.Error
-	}
-	return &role, nil
+	return firstOrNotFound[model.Role](r.db.WithContext(ctx).Preload("Permissions"), "code = ?", code)
 }
 
 func (r *roleRepository) Update(ctx context.Context, role *model.Role) error {
@@ -71,14 +64,7 @@ func (r *roleRepository) Update(ctx context.Context, role *model.Role) error {
 }
 
 func (r *roleRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Delete(&model.Role{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.Role{}, "id = ?", id))
 }
 
 func (r *roleRepository) List(ctx context.Context, offset, limit int) ([]*model.Role, int64, error) {
@@ -89,7 +75,7 @@ func (r *roleRepository) List(ctx context.Context, offset, limit int) ([]*model.
 		return nil, 0, err
 	}
 
-	result := r.db.WithContext(ctx).Preload("Permissions").Offset(offset).Limit(limit).Find(&roles)
+	result := r.db.WithContext(ctx).Preload("Permissions").Offset(offset).Limit(limit).Order(orderNewestFirst).Find(&roles)
 	if result.Error != nil {
 		return nil, 0, result.Error
 	}
@@ -97,30 +83,80 @@ func (r *roleRepository) List(ctx context.Context, offset, limit int) ([]*model.
 	return roles, total, nil
 }
 
+// AddPermissions grants the permissions to the role. Nothing is changed unless every ID
+// names an existing permission.
 func (r *roleRepository) AddPermissions(ctx context.Context, roleID string, permissionIDs []string) error {
-	role, err := r.GetByID(ctx, roleID)
-	if err != nil {
-		return err
-	}
+	return r.changePermissions(ctx, roleID, permissionIDs, func(assoc *gorm.Association, permissions []*model.Permission) error {
+		return assoc.Append(permissions)
+	})
+}
 
-	var permissions []*model.Permission
-	if err := r.db.WithContext(ctx).Where("id IN ?", permissionIDs).Find(&permissions).Error; err != nil {
-		return err
-	}
+// RemovePermissions revokes the permissions from the role. Nothing is changed unless every
+// ID names an existing permission.
+func (r *roleRepository) RemovePermissions(ctx context.Context, roleID string, permissionIDs []string) error {
+	return r.changePermissions(ctx, roleID, permissionIDs, func(assoc *gorm.Association, permissions []*model.Permission) error {
+		return assoc.Delete(permissions)
+	})
+}
 
-	return r.db.WithContext(ctx).Model(role).Association("Permissions").Append(permissions)
+// SetPermissions replaces the role's permissions with exactly the given ones. Nothing is
+// changed unless every ID names an existing permission.
+func (r *roleRepository) SetPermissions(ctx context.Context, roleID string, permissionIDs []string) error {
+	return r.changePermissions(ctx, roleID, permissionIDs, func(assoc *gorm.Association, permissions []*model.Permission) error {
+		if len(permissions) == 0 {
+			return assoc.Clear()
+		}
+		return assoc.Replace(permissions)
+	})
 }
 
-func (r *roleRepository) RemovePermissions(ctx context.Context, roleID string, permissionIDs []string) error {
-	role, err := r.GetByID(ctx, roleID)
+// ListPermissions returns the role's permissions ordered by code.
+func (r *roleRepository) ListPermissions(ctx context.Context, roleID string) ([]*model.Permission, error) {
+	role, err := firstOrNotFound[model.Role](r.db.WithContext(ctx).Select("id"), "id = ?", roleID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var permissions []*model.Permission
-	if err := r.db.WithContext(ctx).Where("id IN ?", permissionIDs).Find(&permissions).Error; err != nil {
-		return err
+	permissions := []*model.Permission{}
+	if err := r.db.WithContext(ctx).Model(role).Order("code").Association("Permissions").Find(&permissions); err != nil {
+		return nil, err
 	}
+	return permissions, nil
+}
+
+// changePermissions loads the role and the named permissions in one transaction, refuses
+// IDs that do not exist with ErrUnknownPermission, and applies change to the role's
+// permission association.
+func (r *roleRepository) changePermissions(ctx context.Context, roleID string, permissionIDs []string, change func(*gorm.Association, []*model.Permission) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role, err := firstOrNotFound[model.Role](tx, "id = ?", roleID)
+		if err != nil {
+			return err
+		}
+
+		ids := slices.Clone(permissionIDs)
+		slices.Sort(ids)
+		ids = slices.Compact(ids)
+		var permissions []*model.Permission
+		if len(ids) > 0 {
+			if err := tx.Where("id IN ?", ids).Find(&permissions).Error; err != nil {
+				return err
+			}
+		}
+		if len(permissions) != len(ids) {
+			found := make(map[string]bool, len(permissions))
+			for _, permission := range permissions {
+				found[permission.ID] = true
+			}
+			var missing []string
+			for _, id := range ids {
+				if !found[id] {
+					missing = append(missing, id)
+				}
+			}
+			return fmt.Errorf("%w: %s", ErrUnknownPermission, strings.Join(missing, ", "))
+		}
 
-	return r.db.WithContext(ctx).Model(role).Association("Permissions").Delete(permissions)
+		return change(tx.Model(role).Association("Permissions"), permissions)
+	})
 }
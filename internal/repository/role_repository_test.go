@@ -0,0 +1,74 @@
+// Package repository provides role repository tests.
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleRepository_Permissions(t *testing.T) {
+	db := newTestDB(t, &model.Role{}, &model.Permission{})
+	repo := NewRoleRepository(db)
+	ctx := context.Background()
+
+	permission := func(code string) *model.Permission {
+		p := &model.Permission{Name: code, Code: code, Resource: "resource", Action: "read"}
+		require.NoError(t, db.Create(p).Error)
+		return p
+	}
+	codes := func(permissions []*model.Permission) []string {
+		out := make([]string, 0, len(permissions))
+		for _, p := range permissions {
+			out = append(out, p.Code)
+		}
+		return out
+	}
+
+	read, write, del := permission("resource:read"), permission("resource:write"), permission("resource:delete")
+	role := &model.Role{Name: "Operator", Code: "operator", Status: 1}
+	require.NoError(t, repo.Create(ctx, role))
+
+	t.Run("adding valid permissions", func(t *testing.T) {
+		require.NoError(t, repo.AddPermissions(ctx, role.ID, []string{read.ID, write.ID, read.ID}))
+
+		permissions, err := repo.ListPermissions(ctx, role.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"resource:read", "resource:write"}, codes(permissions))
+	})
+
+	t.Run("unknown permission IDs are rejected without changes", func(t *testing.T) {
+		err := repo.AddPermissions(ctx, role.ID, []string{del.ID, "missing-id"})
+		require.ErrorIs(t, err, ErrUnknownPermission)
+		assert.Contains(t, err.Error(), "missing-id")
+
+		require.ErrorIs(t, repo.SetPermissions(ctx, role.ID, []string{"missing-id"}), ErrUnknownPermission)
+
+		permissions, err := repo.ListPermissions(ctx, role.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"resource:read", "resource:write"}, codes(permissions))
+	})
+
+	t.Run("set and remove", func(t *testing.T) {
+		require.NoError(t, repo.SetPermissions(ctx, role.ID, []string{del.ID, write.ID}))
+		require.NoError(t, repo.RemovePermissions(ctx, role.ID, []string{write.ID}))
+
+		permissions, err := repo.ListPermissions(ctx, role.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"resource:delete"}, codes(permissions))
+
+		require.NoError(t, repo.SetPermissions(ctx, role.ID, nil))
+		permissions, err = repo.ListPermissions(ctx, role.ID)
+		require.NoError(t, err)
+		assert.Empty(t, permissions)
+	})
+
+	t.Run("missing role", func(t *testing.T) {
+		require.ErrorIs(t, repo.AddPermissions(ctx, "missing", []string{read.ID}), ErrNotFound)
+		_, err := repo.ListPermissions(ctx, "missing")
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,56 @@
+// Package repository provides data access layer implementations.
+package repository
+
+import (
+	"context"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SequenceRepository defines the interface for named counters.
+type SequenceRepository interface {
+	// Next increments the counter for scope and returns its new value, starting at 1.
+	// Concurrent callers never receive the same value.
+	Next(ctx context.Context, scope string) (int64, error)
+	// Peek returns the value the next call to Next would return, without changing the
+	// counter. A concurrent Next may take it first.
+	Peek(ctx context.Context, scope string) (int64, error)
+}
+
+type sequenceRepository struct {
+	db *gorm.DB
+}
+
+// NewSequenceRepository creates a new sequence repository.
+func NewSequenceRepository(db *gorm.DB) SequenceRepository {
+	return &sequenceRepository{db: db}
+}
+
+func (r *sequenceRepository) Next(ctx context.Context, scope string) (int64, error) {
+	var sequence model.NameSequence
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.NameSequence{Scope: scope}).Error; err != nil {
+			return err
+		}
+		// The increment locks the row until commit, so the value read back is this caller's.
+		if err := tx.Model(&model.NameSequence{}).Where("scope = ?", scope).
+			Update("value", gorm.Expr("value + 1")).Error; err != nil {
+			return err
+		}
+		return tx.First(&sequence, "scope = ?", scope).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sequence.Value, nil
+}
+
+func (r *sequenceRepository) Peek(ctx context.Context, scope string) (int64, error) {
+	var sequence model.NameSequence
+	if err := r.db.WithContext(ctx).Where("scope = ?", scope).Limit(1).Find(&sequence).Error; err != nil {
+		return 0, err
+	}
+	return sequence.Value + 1, nil
+}
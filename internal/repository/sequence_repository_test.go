@@ -0,0 +1,87 @@
+// Package repository provides sequence repository tests.
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceRepository_Next(t *testing.T) {
+	db := newTestDB(t, &model.NameSequence{})
+	repo := NewSequenceRepository(db)
+	ctx := context.Background()
+
+	for want := int64(1); want <= 3; want++ {
+		got, err := repo.Next(ctx, "node/pve/vm")
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	got, err := repo.Next(ctx, "node/pve/container")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got, "scopes count independently")
+}
+
+func TestSequenceRepository_Peek(t *testing.T) {
+	db := newTestDB(t, &model.NameSequence{})
+	repo := NewSequenceRepository(db)
+	ctx := context.Background()
+
+	got, err := repo.Peek(ctx, "node/pve/vm")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got, "unused scopes start at 1")
+
+	for range 2 {
+		_, err = repo.Next(ctx, "node/pve/vm")
+		require.NoError(t, err)
+	}
+	for range 2 {
+		got, err = repo.Peek(ctx, "node/pve/vm")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), got, "peeking does not advance the counter")
+	}
+
+	_, err = repo.Peek(ctx, "node/pve/container")
+	require.NoError(t, err)
+	var count int64
+	require.NoError(t, db.Model(&model.NameSequence{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "peeking an unused scope creates no row")
+}
+
+func TestSequenceRepository_NextConcurrent(t *testing.T) {
+	db := newTestDB(t, &model.NameSequence{})
+	// SQLite's shared cache fails conflicting writers instead of making them wait the way
+	// MySQL's row lock does, so callers queue for a single connection here.
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	repo := NewSequenceRepository(db)
+	ctx := context.Background()
+
+	const callers = 20
+	values := make([]int64, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], errs[i] = repo.Next(ctx, "node/pve/vm")
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	for i, value := range values {
+		assert.Equal(t, int64(i+1), value)
+	}
+}
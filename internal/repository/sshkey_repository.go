@@ -3,7 +3,6 @@ package repository
 
 import (
 	"context"
-	"errors"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/gorm"
@@ -36,14 +35,7 @@ func (r *sshKeyRepository) Create(ctx context.Context, sshKey *model.SSHKey) err
 
 // GetByID retrieves an SSH key by ID.
 func (r *sshKeyRepository) GetByID(ctx context.Context, id string) (*model.SSHKey, error) {
-	var sshKey model.SSHKey
-	if err := r.db.WithContext(ctx).Preload("CreatedBy").First(&sshKey, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &sshKey, nil
+	return firstOrNotFound[model.SSHKey](r.db.WithContext(ctx).Preload("CreatedBy"), "id = ?", id)
 }
 
 // List retrieves SSH keys with pagination.
@@ -57,7 +49,7 @@ func (r *sshKeyRepository) List(ctx context.Context, offset, limit int) ([]*mode
 		return nil, 0, err
 	}
 
-	if err := query.Preload("CreatedBy").Offset(offset).Limit(limit).Order("created_at DESC").Find(&sshKeys).Error; err != nil {
+	if err := query.Preload("CreatedBy").Offset(offset).Limit(limit).Order(orderNewestFirst).Find(&sshKeys).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -71,26 +63,12 @@ func (r *sshKeyRepository) Update(ctx context.Context, sshKey *model.SSHKey) err
 
 // Delete deletes an SSH key by ID.
 func (r *sshKeyRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Delete(&model.SSHKey{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.SSHKey{}, "id = ?", id))
 }
 
 // GetDefault retrieves the default SSH key.
 func (r *sshKeyRepository) GetDefault(ctx context.Context) (*model.SSHKey, error) {
-	var sshKey model.SSHKey
-	if err := r.db.WithContext(ctx).Preload("CreatedBy").First(&sshKey, "is_default = ?", true).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &sshKey, nil
+	return firstOrNotFound[model.SSHKey](r.db.WithContext(ctx).Preload("CreatedBy"), "is_default = ?", true)
 }
 
 // SetDefault sets an SSH key as the default.
@@ -3,7 +3,6 @@ package repository
 
 import (
 	"context"
-	"errors"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/gorm"
@@ -36,26 +35,12 @@ func (r *vmTemplateRepository) Create(ctx context.Context, template *model.VMTem
 
 // GetByID retrieves a VM template by ID.
 func (r *vmTemplateRepository) GetByID(ctx context.Context, id string) (*model.VMTemplate, error) {
-	var template model.VMTemplate
-	if err := r.db.WithContext(ctx).Preload("Zone").First(&template, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &template, nil
+	return firstOrNotFound[model.VMTemplate](r.db.WithContext(ctx).Preload("Zone"), "id = ?", id)
 }
 
 // GetByName retrieves a VM template by template name and provider.
 func (r *vmTemplateRepository) GetByName(ctx context.Context, templateName, provider string) (*model.VMTemplate, error) {
-	var template model.VMTemplate
-	if err := r.db.WithContext(ctx).Preload("Zone").First(&template, "template_name = ? AND provider = ?", templateName, provider).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
-	}
-	return &template, nil
+	return firstOrNotFound[model.VMTemplate](r.db.WithContext(ctx).Preload("Zone"), "template_name = ? AND provider = ?", templateName, provider)
 }
 
 // List retrieves VM templates with optional filtering.
@@ -78,7 +63,7 @@ func (r *vmTemplateRepository) List(ctx context.Context, provider, osType, zoneI
 		return nil, 0, err
 	}
 
-	if err := query.Preload("Zone").Offset(offset).Limit(limit).Order("name ASC").Find(&templates).Error; err != nil {
+	if err := query.Preload("Zone").Offset(offset).Limit(limit).Order(orderByName).Find(&templates).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -92,20 +77,13 @@ func (r *vmTemplateRepository) Update(ctx context.Context, template *model.VMTem
 
 // Delete deletes a VM template by ID.
 func (r *vmTemplateRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Delete(&model.VMTemplate{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.VMTemplate{}, "id = ?", id))
 }
 
 // ListByProvider retrieves all VM templates for a specific provider.
 func (r *vmTemplateRepository) ListByProvider(ctx context.Context, provider string) ([]*model.VMTemplate, error) {
 	var templates []*model.VMTemplate
-	if err := r.db.WithContext(ctx).Preload("Zone").Where("provider = ? AND status = ?", provider, "active").Find(&templates).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Zone").Where("provider = ? AND status = ?", provider, "active").Order(orderByName).Find(&templates).Error; err != nil {
 		return nil, err
 	}
 	return templates, nil
@@ -0,0 +1,84 @@
+// Package repository provides data access layer implementations.
+package repository
+
+import (
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"gorm.io/gorm"
+)
+
+// usageListLimit caps how many dependents of each kind a usage report lists; the count
+// is always exact.
+const usageListLimit = 50
+
+// UsageRef identifies one record that depends on another.
+type UsageRef struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status,omitempty"`
+}
+
+// UsageGroup counts dependents of one kind and lists the most recent of them.
+type UsageGroup struct {
+	Count int64      `json:"count"`
+	Items []UsageRef `json:"items"`
+}
+
+// Usage reports what references an entity. Groups that do not apply to the entity are nil.
+type Usage struct {
+	Providers   *UsageGroup `json:"providers,omitempty"`
+	Credentials *UsageGroup `json:"credentials,omitempty"`
+	Requests    *UsageGroup `json:"requests,omitempty"`
+	Resources   *UsageGroup `json:"resources,omitempty"`
+	Allocations *UsageGroup `json:"allocations,omitempty"`
+}
+
+// InUse reports whether anything references the entity.
+func (u *Usage) InUse() bool {
+	for _, group := range []*UsageGroup{u.Providers, u.Credentials, u.Requests, u.Resources, u.Allocations} {
+		if group != nil && group.Count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// loadUsageGroup counts the rows matched by query and lists up to usageListLimit of them.
+// columns must select id, name and optionally status.
+func loadUsageGroup(query *gorm.DB, table, columns string) (*UsageGroup, error) {
+	query = query.Session(&gorm.Session{})
+	group := &UsageGroup{Items: []UsageRef{}}
+	if err := query.Count(&group.Count).Error; err != nil {
+		return nil, err
+	}
+	if group.Count == 0 {
+		return group, nil
+	}
+	if err := query.Select(columns).Order(table + ".created_at DESC, " + table + ".id DESC").Limit(usageListLimit).Find(&group.Items).Error; err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// requestUsage reports the resource requests matched by where and the resources they created.
+func requestUsage(db *gorm.DB, usage *Usage, where string, args ...interface{}) error {
+	requests, err := loadUsageGroup(
+		db.Model(&model.ResourceRequest{}).Where(where, args...),
+		"resource_requests", "id, title AS name, status",
+	)
+	if err != nil {
+		return err
+	}
+	usage.Requests = requests
+
+	created := db.Model(&model.ResourceRequest{}).Select("resource_id").
+		Where("resource_id IS NOT NULL").Where(where, args...)
+	resources, err := loadUsageGroup(
+		db.Model(&model.Resource{}).Where("id IN (?)", created),
+		"resources", "id, name, status",
+	)
+	if err != nil {
+		return err
+	}
+	usage.Resources = resources
+	return nil
+}
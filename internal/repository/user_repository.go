@@ -4,7 +4,6 @@ package repository
 import (
 	"context"
 	"errors"
-	"time"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"gorm.io/gorm"
@@ -22,7 +21,9 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id string) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByExternalID(ctx context.Context, source model.UserSource, externalID string) (*model.User, error)
 	Update(ctx context.Context, user *model.User) error
+	ReplaceRoles(ctx context.Context, user *model.User, roles []model.Role) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, offset, limit int) ([]*model.User, int64, error)
 	UpdateLastLogin(ctx context.Context, id, ip string) error
@@ -49,39 +50,21 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
-	var user model.User
-	result := r.db.WithContext(ctx).Preload("Roles").First(&user, "id = ?", id)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, result.Error
-	}
-	return &user, nil
+	return firstOrNotFound[model.User](r.db.WithContext(ctx).Preload("Roles"), "id = ?", id)
 }
 
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
-	var user model.User
-	result := r.db.WithContext(ctx).Preload("Roles").First(&user, "username = ?", username)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, result.Error
-	}
-	return &user, nil
+	return firstOrNotFound[model.User](r.db.WithContext(ctx).Preload("Roles"), "username = ?", username)
 }
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
-	var user model.User
-	result := r.db.WithContext(ctx).Preload("Roles").First(&user, "email = ?", email)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, result.Error
-	}
-	return &user, nil
+	return firstOrNotFound[model.User](r.db.WithContext(ctx).Preload("Roles"), "email = ?", email)
+}
+
+// GetByExternalID retrieves the user that the given SSO or directory source identifies as
+// externalID.
+func (r *userRepository) GetByExternalID(ctx context.Context, source model.UserSource, externalID string) (*model.User, error) {
+	return firstOrNotFound[model.User](r.db.WithContext(ctx).Preload("Roles"), "source = ? AND external_id = ?", source, externalID)
 }
 
 func (r *userRepository) Update(ctx context.Context, user *model.User) error {
@@ -89,15 +72,13 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 	return result.Error
 }
 
+// ReplaceRoles sets the user's roles to exactly roles, removing any others.
+func (r *userRepository) ReplaceRoles(ctx context.Context, user *model.User, roles []model.Role) error {
+	return r.db.WithContext(ctx).Model(user).Association("Roles").Replace(roles)
+}
+
 func (r *userRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Delete(&model.User{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return wrapDelete(r.db.WithContext(ctx).Delete(&model.User{}, "id = ?", id))
 }
 
 func (r *userRepository) List(ctx context.Context, offset, limit int) ([]*model.User, int64, error) {
@@ -110,7 +91,7 @@ func (r *userRepository) List(ctx context.Context, offset, limit int) ([]*model.
 	}
 
 	// Get paginated results
-	result := r.db.WithContext(ctx).Preload("Roles").Offset(offset).Limit(limit).Find(&users)
+	result := r.db.WithContext(ctx).Preload("Roles").Offset(offset).Limit(limit).Order(orderNewestFirst).Find(&users)
 	if result.Error != nil {
 		return nil, 0, result.Error
 	}
@@ -119,7 +100,7 @@ func (r *userRepository) List(ctx context.Context, offset, limit int) ([]*model.
 }
 
 func (r *userRepository) UpdateLastLogin(ctx context.Context, id, ip string) error {
-	now := time.Now()
+	now := model.Now()
 	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
 		"last_login_at": now,
 		"last_login_ip": ip,
@@ -2,11 +2,18 @@
 package router
 
 import (
+	"text/template"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/blobstore"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/events"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/handler"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/middleware"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/notification"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/secrets"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/service"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
 	"github.com/gin-gonic/gin"
@@ -15,7 +22,7 @@ import (
 )
 
 // New creates a new configured Gin router with all dependencies.
-func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
+func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config, terragruntTemplate *template.Template, nodePathTemplates *service.NodePathTemplates, commitTemplate *service.CommitMessageTemplate) *gin.Engine {
 	// Set Gin mode based on environment
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
@@ -26,6 +33,7 @@ func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
 	roleRepo := repository.NewRoleRepository(db)
 	resourceRepo := repository.NewResourceRepository(db)
 	resourceRequestRepo := repository.NewResourceRequestRepository(db)
+	requestStatusRepo := repository.NewRequestStatusEventRepository(db)
 	auditRepo := repository.NewAuditRepository(db)
 	providerRepo := repository.NewProviderRepository(db)
 	credentialRepo := repository.NewCredentialRepository(db)
@@ -36,28 +44,66 @@ func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
 	tfModuleRepo := repository.NewTerraformModuleRepository(db)
 	gitRepoRepo := repository.NewGitRepoRepository(db)
 	nodeConfigRepo := repository.NewNodeConfigRepository(db)
+	sequenceRepo := repository.NewSequenceRepository(db)
 	sshKeyRepo := repository.NewSSHKeyRepository(db)
 	ipPoolRepo := repository.NewIPPoolRepository(db)
 	ipAllocationRepo := repository.NewIPAllocationRepository(db)
 	vmTemplateRepo := repository.NewVMTemplateRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	delegationRepo := repository.NewDelegationRepository(db)
 
 	// Initialize Terraform executor
-	terraformExecutor := terraform.NewExecutor(logger)
+	terraformExecutor := terraform.NewExecutor(logger, terraform.DefaultSpecDefaults().Merge(cfg.Terraform.SpecDefaults), cfg.Terraform.SecretsInFiles, terraform.Workspace{
+		Root:            cfg.Terraform.WorkDir,
+		Cleanup:         cfg.Terraform.Cleanup,
+		ProviderCleanup: cfg.Terraform.ProviderCleanup,
+	})
 
 	// Initialize notification service
 	notificationService := notification.NewService(db, logger)
 
+	// Initialize event bus; subscribers are registered here as reactions are added
+	eventBus := events.NewBus(logger)
+	notification.NewWebhookNotifier(cfg.Notification.Webhooks, nil, logger).Subscribe(eventBus)
+
+	// Provisioning locks live in the database so every replica honours them
+	resourceLocker := service.NewDBResourceLocker(repository.NewLockRepository(db), constants.ResourceLockTTL, logger)
+
+	// Attachment files live on local disk; links are stored inline
+	attachmentStore, err := blobstore.NewFileStore(cfg.Attachment.Dir)
+	if err != nil {
+		logger.Fatal("failed to initialize attachment store", zap.String("dir", cfg.Attachment.Dir), zap.Error(err))
+	}
+
+	// Push webhook secrets are stored encrypted; without a key none can be set
+	var webhookSecrets *secrets.Box
+	if cfg.Git.WebhookSecretKey != "" {
+		webhookSecrets, err = secrets.NewBox(cfg.Git.WebhookSecretKey)
+		if err != nil {
+			logger.Fatal("failed to initialize webhook secret encryption", zap.Error(err))
+		}
+	}
+
 	// Initialize services
-	authService := service.NewAuthService(userRepo, cfg)
+	authService := service.NewAuthService(userRepo, roleRepo, cfg)
 	userService := service.NewUserService(userRepo, roleRepo, logger)
-	resourceService := service.NewResourceService(resourceRepo, resourceRequestRepo, gitRepoRepo, terraformExecutor, notificationService, logger)
+	delegationService := service.NewDelegationService(delegationRepo, userRepo, roleRepo, logger)
+	resourceService := service.NewResourceService(resourceRepo, resourceRequestRepo, requestStatusRepo, gitRepoRepo, terraformExecutor, notificationService, service.NewApprovalPolicy(cfg.Approval.AutoApproveRules, cfg.Approval.Environments), delegationService, cfg.Request.MaxQuantity, cfg.Resource.ImmutableFields, eventBus, resourceLocker, userService, logger)
 	roleService := service.NewRoleService(roleRepo, logger)
-	settingsService := service.NewSettingsService(providerRepo, credentialRepo, logger)
+	settingsService := service.NewSettingsService(providerRepo, credentialRepo, service.NewMemoryConnectionTestCache(), logger)
+	maintenanceService := service.NewMaintenanceService(cfg.Server.Maintenance, cfg.Server.MaintenanceMessage, logger)
 	infraService := service.NewInfraService(regionRepo, zoneRepo, tfRegistryRepo, tfProviderRepo, tfModuleRepo, logger)
-	gitService := service.NewGitService(gitRepoRepo, nodeConfigRepo, tfModuleRepo, logger)
+	gitService := service.NewGitService(gitRepoRepo, nodeConfigRepo, sequenceRepo, tfModuleRepo, eventBus, terragruntTemplate, nodePathTemplates, commitTemplate, webhookSecrets, logger)
 	sshKeyService := service.NewSSHKeyService(sshKeyRepo, logger)
-	ipamService := service.NewIPAMService(ipPoolRepo, ipAllocationRepo, logger)
+	ipamService := service.NewIPAMService(ipPoolRepo, ipAllocationRepo, cfg.IPAM.Hostname, cfg.IPAM.UtilizationAlertPercent, eventBus,
+		service.NewAddressProber(cfg.IPAM.ProbePort, time.Duration(cfg.IPAM.ProbeTimeout)*time.Millisecond, logger), logger)
+	artifactService := service.NewArtifactService(nodeConfigRepo, terraformExecutor, service.NewArtifactURLSigner(cfg.JWT.Secret, constants.ArtifactURLTTL), logger)
+	resourceImportService := service.NewResourceImportService(resourceRepo, credentialRepo, ipamService, terraformExecutor, logger)
 	vmTemplateService := service.NewVMTemplateService(vmTemplateRepo, logger)
+	attachmentService := service.NewAttachmentService(attachmentRepo, resourceRequestRepo, attachmentStore, service.AttachmentLimits{
+		MaxFileSize:  cfg.Attachment.MaxFileSize,
+		AllowedTypes: cfg.Attachment.AllowedTypes,
+	}, logger)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService, logger)
@@ -65,12 +111,17 @@ func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
 	resourceHandler := handler.NewResourceHandler(resourceService, logger)
 	roleHandler := handler.NewRoleHandler(roleService, logger)
 	healthHandler := handler.NewHealthHandler(db, logger)
-	settingsHandler := handler.NewSettingsHandler(settingsService, logger)
+	settingsHandler := handler.NewSettingsHandler(settingsService, auditRepo, logger)
+	maintenanceHandler := handler.NewMaintenanceHandler(maintenanceService, logger)
 	gitHandler := handler.NewGitHandler(gitService, logger)
 	infraHandler := handler.NewInfraHandler(infraService, logger)
 	sshKeyHandler := handler.NewSSHKeyHandler(sshKeyService, logger)
 	ipamHandler := handler.NewIPAMHandler(ipamService, logger)
 	vmTemplateHandler := handler.NewVMTemplateHandler(vmTemplateService, logger)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService, logger)
+	delegationHandler := handler.NewDelegationHandler(delegationService, logger)
+	resourceImportHandler := handler.NewResourceImportHandler(resourceImportService, logger)
+	artifactHandler := handler.NewArtifactHandler(artifactService, logger)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(authService, logger)
@@ -95,15 +146,31 @@ func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
 	auth := v1.Group("/auth")
 	auth.POST("/login", authHandler.Login)
 	auth.POST("/refresh", authHandler.RefreshToken)
+	auth.GET("/oidc/login", authHandler.SSOLogin)
+	auth.GET("/oidc/callback", authHandler.SSOCallback)
+
+	// Git host webhooks authenticate with the repository's webhook signature. A delivery syncs
+	// modules into the database, so it is turned away during maintenance like any other write.
+	v1.POST("/git/webhook/:repo_id", middleware.Maintenance(maintenanceService), gitHandler.ReceiveWebhook)
+
+	// Artifact downloads authenticate with the signature of a URL issued to a signed-in user
+	v1.GET("/artifacts/node-configs/:id", artifactHandler.Download)
 
 	// Protected routes
 	protected := v1.Group("")
 	protected.Use(authMiddleware.Authenticate())
 	protected.Use(auditMiddleware.Audit())
+	protected.Use(middleware.Maintenance(maintenanceService, "/api/v1/admin/maintenance", "/api/v1/auth/logout"))
 
 	// Auth routes
 	protected.POST("/auth/logout", authHandler.Logout)
 
+	// Admin routes
+	admin := protected.Group("/admin")
+	admin.Use(authMiddleware.RequireRole("admin"))
+	admin.GET("/maintenance", maintenanceHandler.GetMaintenance)
+	admin.PUT("/maintenance", maintenanceHandler.SetMaintenance)
+
 	// User routes
 	users := protected.Group("/users")
 	users.GET("", userHandler.List)
@@ -111,6 +178,8 @@ func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
 	users.GET("/me", userHandler.GetCurrentUser)
 	users.PUT("/me", userHandler.UpdateCurrentUser)
 	users.PUT("/me/password", userHandler.ChangePassword)
+	users.GET("/me/preferences", userHandler.GetPreferences)
+	users.PUT("/me/preferences", userHandler.UpdatePreferences)
 	users.GET("/:id", userHandler.GetByID)
 	users.PUT("/:id", userHandler.Update)
 	users.DELETE("/:id", userHandler.Delete)
@@ -122,11 +191,18 @@ func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
 	roles.GET("/:id", roleHandler.GetByID)
 	roles.PUT("/:id", roleHandler.Update)
 	roles.DELETE("/:id", roleHandler.Delete)
+	roles.GET("/:id/permissions", roleHandler.ListPermissions)
+	roles.PUT("/:id/permissions", roleHandler.SetPermissions)
+	roles.POST("/:id/permissions", roleHandler.AddPermissions)
+	roles.DELETE("/:id/permissions", roleHandler.RemovePermissions)
 
 	// Resource routes
 	resources := protected.Group("/resources")
 	resources.GET("", resourceHandler.List)
+	resources.GET("/search", resourceHandler.Search)
 	resources.POST("", resourceHandler.Create)
+	resources.POST("/bulk-delete", resourceHandler.BulkDelete)
+	resources.POST("/import", resourceImportHandler.Import)
 	resources.GET("/:id", resourceHandler.GetByID)
 	resources.PUT("/:id", resourceHandler.Update)
 	resources.DELETE("/:id", resourceHandler.Delete)
@@ -135,11 +211,28 @@ func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
 	requests := protected.Group("/resource-requests")
 	requests.GET("", resourceHandler.ListRequests)
 	requests.POST("", resourceHandler.CreateRequest)
+	requests.POST("/bulk-delete", resourceHandler.BulkDeleteRequests)
 	requests.GET("/:id", resourceHandler.GetRequest)
+	requests.GET("/:id/history", resourceHandler.GetRequestHistory)
+	requests.GET("/:id/outputs/raw", resourceHandler.GetRequestOutputsRaw)
 	requests.POST("/:id/approve", resourceHandler.ApproveRequest)
 	requests.POST("/:id/reject", resourceHandler.RejectRequest)
 	requests.POST("/:id/retry", resourceHandler.RetryRequest)
 	requests.DELETE("/:id", resourceHandler.DeleteRequest)
+	requests.GET("/:id/attachments", attachmentHandler.List)
+	requests.POST("/:id/attachments/links", attachmentHandler.AddLink)
+	requests.POST("/:id/attachments/files", attachmentHandler.AddFile)
+	requests.GET("/:id/attachments/:attachment_id/download", attachmentHandler.Download)
+	requests.DELETE("/:id/attachments/:attachment_id", attachmentHandler.Remove)
+
+	// Approval delegation routes
+	delegations := protected.Group("/delegations")
+	delegations.GET("", delegationHandler.List)
+	delegations.POST("", delegationHandler.Create)
+	delegations.DELETE("/:id", delegationHandler.Delete)
+
+	// Supported provider types and their capabilities
+	protected.GET("/providers/supported", settingsHandler.ListSupportedProviders)
 
 	// Settings routes - providers
 	providers := protected.Group("/settings/providers")
@@ -147,6 +240,7 @@ func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
 	providers.POST("", settingsHandler.CreateProvider)
 	providers.POST("/test-connection", settingsHandler.TestProviderConnection)
 	providers.GET("/:id", settingsHandler.GetProvider)
+	providers.GET("/:id/usage", settingsHandler.GetProviderUsage)
 	providers.PUT("/:id", settingsHandler.UpdateProvider)
 	providers.DELETE("/:id", settingsHandler.DeleteProvider)
 
@@ -156,6 +250,8 @@ func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
 	credentials.POST("", settingsHandler.CreateCredential)
 	credentials.POST("/test-connection", settingsHandler.TestCredentialConnection)
 	credentials.GET("/:id", settingsHandler.GetCredential)
+	credentials.GET("/:id/usage", settingsHandler.GetCredentialUsage)
+	credentials.GET("/:id/secret", authMiddleware.RequireRole("admin"), settingsHandler.RevealCredential)
 	credentials.PUT("/:id", settingsHandler.UpdateCredential)
 	credentials.DELETE("/:id", settingsHandler.DeleteCredential)
 
@@ -208,16 +304,23 @@ func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
 	gitRepos.PUT("/:id", gitHandler.UpdateRepository)
 	gitRepos.DELETE("/:id", gitHandler.DeleteRepository)
 	gitRepos.POST("/:id/test", gitHandler.TestConnection)
+	gitRepos.POST("/:id/validate", gitHandler.ValidateStorageRepo)
 
 	// Git modules routes (scan Terraform modules from git repository)
 	gitModules := protected.Group("/git/modules")
 	gitModules.GET("", gitHandler.ListModulesFromGit)
 	gitModules.POST("/sync", gitHandler.SyncModulesFromGit)
+	gitModules.POST("/clear-cache", authMiddleware.RequireRole("admin"), gitHandler.ClearModulesCache)
 
 	// Node config routes
 	nodeConfigs := protected.Group("/git/node-configs")
 	nodeConfigs.GET("", gitHandler.ListNodeConfigs)
+	nodeConfigs.POST("/retry-failed", gitHandler.RetryFailedNodeConfigs)
+	nodeConfigs.POST("/preview", gitHandler.PreviewNodeConfig)
 	nodeConfigs.GET("/:id", gitHandler.GetNodeConfig)
+	nodeConfigs.GET("/:id/diff", gitHandler.DiffNodeConfig)
+	nodeConfigs.GET("/:id/history", gitHandler.ListNodeConfigHistory)
+	nodeConfigs.POST("/:id/artifacts/url", artifactHandler.CreateDownloadURL)
 	nodeConfigs.GET("/by-request/:request_id", gitHandler.GetNodeConfigByRequest)
 	nodeConfigs.POST("/:id/commit", gitHandler.CommitNodeConfig)
 
@@ -236,16 +339,23 @@ func New(db *gorm.DB, logger *zap.Logger, cfg *config.Config) *gin.Engine {
 	ipPools.GET("", ipamHandler.ListIPPools)
 	ipPools.POST("", ipamHandler.CreateIPPool)
 	ipPools.GET("/:id", ipamHandler.GetIPPool)
+	ipPools.GET("/:id/usage", ipamHandler.GetIPPoolUsage)
 	ipPools.PUT("/:id", ipamHandler.UpdateIPPool)
 	ipPools.DELETE("/:id", ipamHandler.DeleteIPPool)
 	ipPools.GET("/:id/allocations", ipamHandler.ListIPAllocations)
+	ipPools.GET("/:id/reservations", ipamHandler.ListIPReservations)
+	ipPools.POST("/:id/reservations", ipamHandler.ReserveIPRange)
 
 	// IPAM routes - IP allocations
 	ipAllocations := protected.Group("/ipam/allocations")
 	ipAllocations.POST("", ipamHandler.AllocateIP)
 	ipAllocations.DELETE("/:id", ipamHandler.ReleaseIP)
+	ipAllocations.POST("/:id/reallocate", ipamHandler.ReallocateIP)
 	ipAllocations.GET("/resource/:resource_id", ipamHandler.GetAllocationsByResource)
 
+	// Operator-wide IP inventory across pools
+	protected.GET("/ip-allocations", ipamHandler.ListAllIPAllocations)
+
 	// VM Template routes
 	vmTemplates := protected.Group("/infra/vm-templates")
 	vmTemplates.GET("", vmTemplateHandler.ListVMTemplates)
@@ -3,6 +3,7 @@ package sanitize
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
@@ -137,27 +138,52 @@ func ValidateGitURL(rawURL string) (string, error) {
 	return rawURL, nil
 }
 
+// ErrInvalidGitRef is returned when a branch, tag or other ref name is not a valid git ref
+// or could be mistaken for a command-line option.
+var ErrInvalidGitRef = errors.New("invalid git ref")
+
+// gitRefInvalidChars matches characters git does not allow anywhere in a ref name: ASCII
+// control characters, space, DEL, the characters ~ ^ : ? * [ and backslash.
+var gitRefInvalidChars = regexp.MustCompile(`[\x00-\x20\x7f~^:?*\[\\]`)
+
+// maxGitRefLength bounds ref names well below what any git host accepts.
+const maxGitRefLength = 255
+
 // ValidateGitBranch validates a git branch name for safe use in command execution.
+// An empty name means the default branch, main.
 func ValidateGitBranch(branch string) (string, error) {
 	if branch == "" {
 		return "main", nil
 	}
+	return ValidateGitRef(branch)
+}
 
-	// Git branch names cannot contain these characters
-	invalidChars := regexp.MustCompile(`[\s~^:?*\[\]\\]`)
-	if invalidChars.MatchString(branch) {
-		return "", errors.New("invalid branch name")
-	}
-
-	// Cannot start or end with /
-	if strings.HasPrefix(branch, "/") || strings.HasSuffix(branch, "/") {
-		return "", errors.New("invalid branch name")
-	}
-
-	// Cannot contain ..
-	if strings.Contains(branch, "..") {
-		return "", errors.New("invalid branch name")
+// ValidateGitRef validates a branch or tag name for safe use in command execution and
+// module sources. It applies the rules of git check-ref-format, and also rejects names
+// starting with "-", which git would parse as an option such as --upload-pack.
+func ValidateGitRef(ref string) (string, error) {
+	switch {
+	case ref == "":
+		return "", fmt.Errorf("%w: name is empty", ErrInvalidGitRef)
+	case len(ref) > maxGitRefLength:
+		return "", fmt.Errorf("%w: name is longer than %d characters", ErrInvalidGitRef, maxGitRefLength)
+	case strings.HasPrefix(ref, "-"):
+		return "", fmt.Errorf("%w: name cannot start with '-'", ErrInvalidGitRef)
+	case gitRefInvalidChars.MatchString(ref):
+		return "", fmt.Errorf("%w: name contains a space, control character or one of ~^:?*[\\", ErrInvalidGitRef)
+	case strings.Contains(ref, ".."):
+		return "", fmt.Errorf("%w: name cannot contain '..'", ErrInvalidGitRef)
+	case strings.Contains(ref, "@{") || ref == "@":
+		return "", fmt.Errorf("%w: name cannot be '@' or contain '@{'", ErrInvalidGitRef)
+	case strings.HasPrefix(ref, "/") || strings.HasSuffix(ref, "/") || strings.Contains(ref, "//"):
+		return "", fmt.Errorf("%w: name cannot start or end with '/' or contain '//'", ErrInvalidGitRef)
+	case strings.HasSuffix(ref, "."):
+		return "", fmt.Errorf("%w: name cannot end with '.'", ErrInvalidGitRef)
+	}
+	for _, component := range strings.Split(ref, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return "", fmt.Errorf("%w: path components cannot start with '.' or end with '.lock'", ErrInvalidGitRef)
+		}
 	}
-
-	return branch, nil
+	return ref, nil
 }
@@ -0,0 +1,114 @@
+// Package sanitize provides input sanitization tests.
+package sanitize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateGitBranch(t *testing.T) {
+	t.Run("empty name is the default branch", func(t *testing.T) {
+		branch, err := ValidateGitBranch("")
+		require.NoError(t, err)
+		assert.Equal(t, "main", branch)
+	})
+
+	valid := []string{
+		"main",
+		"release/2026.10",
+		"feature/add-vm_templates",
+		"v1.2.3",
+		"user@host",
+		"a-b.c",
+		strings.Repeat("a", maxGitRefLength),
+	}
+	for _, name := range valid {
+		t.Run("valid "+name[:min(len(name), 20)], func(t *testing.T) {
+			branch, err := ValidateGitBranch(name)
+			require.NoError(t, err)
+			assert.Equal(t, name, branch)
+		})
+	}
+
+	invalid := map[string]string{
+		"upload-pack option":    "--upload-pack=touch /tmp/pwned",
+		"config option":         "-c core.sshCommand=sh",
+		"leading dash":          "-main",
+		"double dot":            "main..evil",
+		"parent traversal":      "../../etc/passwd",
+		"newline":               "main\nrm -rf /",
+		"carriage return":       "main\r",
+		"nul":                   "main\x00",
+		"tab":                   "main\tx",
+		"delete":                "main\x7f",
+		"space":                 "my branch",
+		"tilde":                 "main~1",
+		"caret":                 "main^",
+		"colon":                 "refs:heads",
+		"question mark":         "main?",
+		"asterisk":              "feature/*",
+		"open bracket":          "main[0]",
+		"backslash":             `feature\x`,
+		"reflog syntax":         "main@{1}",
+		"lone at":               "@",
+		"leading slash":         "/main",
+		"trailing slash":        "main/",
+		"double slash":          "feature//x",
+		"trailing dot":          "main.",
+		"hidden component":      "feature/.hidden",
+		"lock suffix":           "main.lock",
+		"lock suffix component": "refs/x.lock/y",
+		"longer than the limit": strings.Repeat("a", maxGitRefLength+1),
+	}
+	for name, branch := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := ValidateGitBranch(branch)
+			assert.ErrorIs(t, err, ErrInvalidGitRef)
+		})
+	}
+}
+
+func TestValidateGitRef(t *testing.T) {
+	_, err := ValidateGitRef("")
+	require.ErrorIs(t, err, ErrInvalidGitRef, "refs have no default")
+
+	for _, tag := range []string{"v1.0.0", "1.2", "release-2026-10", "modules/vm/v2"} {
+		ref, err := ValidateGitRef(tag)
+		require.NoError(t, err, tag)
+		assert.Equal(t, tag, ref)
+	}
+	for _, tag := range []string{"--output=/etc/passwd", "-v1", "v1..v2", "v1.0\n", "v1 .0"} {
+		_, err := ValidateGitRef(tag)
+		assert.ErrorIs(t, err, ErrInvalidGitRef, "%q", tag)
+	}
+}
+
+func TestValidateGitURL(t *testing.T) {
+	for _, rawURL := range []string{
+		"https://git.example.com/lab/modules.git",
+		"ssh://git@git.example.com/lab/modules.git",
+	} {
+		got, err := ValidateGitURL(rawURL)
+		require.NoError(t, err, rawURL)
+		assert.Equal(t, rawURL, got)
+	}
+	for _, rawURL := range []string{
+		"",
+		"file:///etc/passwd",
+		"https://git.example.com/lab/modules.git; rm -rf /",
+		"https://git.example.com/$(id)",
+		"ext::sh -c touch% /tmp/pwned",
+	} {
+		_, err := ValidateGitURL(rawURL)
+		assert.ErrorIs(t, err, ErrInvalidGitURL, "%q", rawURL)
+	}
+}
+
+func TestForLog(t *testing.T) {
+	assert.Equal(t, "line one line two", ForLog("line one\nline two"))
+	assert.Equal(t, "ab", ForLog("a\x00\x1bb"))
+	assert.True(t, strings.HasSuffix(ForLog(strings.Repeat("x", 600)), "...[truncated]"))
+}
@@ -0,0 +1,77 @@
+// Package secrets encrypts values, such as webhook secrets and credentials, before they are
+// stored.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealedPrefix marks a sealed value and the scheme it was sealed with.
+const sealedPrefix = "v1:"
+
+// ErrInvalidSealed is returned when a value is not sealed or was sealed with another key.
+var ErrInvalidSealed = errors.New("invalid sealed value")
+
+// Box seals and opens values with AES-256-GCM.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox creates a box keyed with key. The AES key is the SHA-256 of key, so any non-empty
+// passphrase can be used; changing it makes values sealed before unreadable.
+func NewBox(key string) (*Box, error) {
+	if key == "" {
+		return nil, errors.New("encryption key is empty")
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal encrypts plaintext under a random nonce and returns it in a form safe to store as
+// text.
+func (b *Box) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// IsSealed reports whether value has the form of a value returned by Seal.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// Open decrypts a value returned by Seal.
+func (b *Box) Open(sealed string) (string, error) {
+	encoded, ok := strings.CutPrefix(sealed, sealedPrefix)
+	if !ok {
+		return "", ErrInvalidSealed
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", ErrInvalidSealed
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrInvalidSealed
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,64 @@
+// Package secrets provides sealing tests.
+package secrets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBox_SealOpen(t *testing.T) {
+	box, err := NewBox("passphrase")
+	require.NoError(t, err)
+
+	sealed, err := box.Seal("webhook-secret")
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "webhook-secret")
+
+	again, err := box.Seal("webhook-secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "each seal uses a fresh nonce")
+
+	opened, err := box.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "webhook-secret", opened)
+}
+
+func TestBox_OpenRejectsForeignValues(t *testing.T) {
+	box, err := NewBox("passphrase")
+	require.NoError(t, err)
+	other, err := NewBox("another passphrase")
+	require.NoError(t, err)
+
+	sealed, err := box.Seal("webhook-secret")
+	require.NoError(t, err)
+	flipped := byte('A')
+	if sealed[len(sealed)-2] == flipped {
+		flipped = 'B'
+	}
+	tampered := sealed[:len(sealed)-2] + string(flipped) + sealed[len(sealed)-1:]
+
+	for name, value := range map[string]string{
+		"plaintext":      "webhook-secret",
+		"not base64":     sealedPrefix + "!!!",
+		"too short":      sealedPrefix + "AAAA",
+		"tampered":       tampered,
+		"empty":          "",
+		"unknown scheme": "v0:" + strings.TrimPrefix(sealed, sealedPrefix),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := box.Open(value)
+			assert.ErrorIs(t, err, ErrInvalidSealed)
+		})
+	}
+
+	_, err = other.Open(sealed)
+	assert.ErrorIs(t, err, ErrInvalidSealed, "sealed with another key")
+}
+
+func TestNewBox_EmptyKey(t *testing.T) {
+	_, err := NewBox("")
+	assert.Error(t, err)
+}
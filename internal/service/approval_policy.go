@@ -0,0 +1,137 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+// autoApproverPrefix prefixes the rule name recorded as the synthetic approver.
+const autoApproverPrefix = "rule:"
+
+// environmentApproverPrefix prefixes the environment recorded as the synthetic approver
+// when the environment does not require approval.
+const environmentApproverPrefix = "environment:"
+
+// ApprovalPolicy decides whether a new resource request can skip manual review.
+type ApprovalPolicy struct {
+	rules        []config.AutoApproveRule
+	environments map[string]config.EnvironmentApproval
+}
+
+// NewApprovalPolicy creates an approval policy from the configured auto-approve rules and
+// per-environment approval requirements.
+func NewApprovalPolicy(rules []config.AutoApproveRule, environments map[string]config.EnvironmentApproval) *ApprovalPolicy {
+	return &ApprovalPolicy{rules: rules, environments: environments}
+}
+
+// ApprovalDecision is how an approval policy treats a new request.
+type ApprovalDecision struct {
+	Policy       string // One of the model.ApprovalPolicy values
+	AutoApproved bool
+	Approver     string // Synthetic approver of an auto-approved request
+	Reason       string
+}
+
+// Decide returns how a new request is approved. An environment's approval requirement takes
+// precedence over the rules, so a gated environment is never auto-approved by a rule. A nil
+// policy holds every request for manual review.
+func (p *ApprovalPolicy) Decide(input *CreateRequestInput) ApprovalDecision {
+	if p != nil && input != nil {
+		if env, ok := p.environments[input.Environment]; ok {
+			if env.RequiresApproval {
+				return ApprovalDecision{Policy: model.ApprovalPolicyEnvironmentGated}
+			}
+			return ApprovalDecision{
+				Policy:       model.ApprovalPolicyEnvironmentAuto,
+				AutoApproved: true,
+				Approver:     environmentApproverPrefix + input.Environment,
+				Reason:       fmt.Sprintf("Auto-approved: environment %q does not require approval", input.Environment),
+			}
+		}
+	}
+	if ruleName, ok := p.Match(input); ok {
+		return ApprovalDecision{
+			Policy:       model.ApprovalPolicyRule,
+			AutoApproved: true,
+			Approver:     autoApproverPrefix + ruleName,
+			Reason:       fmt.Sprintf("Auto-approved by rule %q", ruleName),
+		}
+	}
+	return ApprovalDecision{Policy: model.ApprovalPolicyManual}
+}
+
+// Match returns the name of the first rule that auto-approves the request.
+// A nil policy never matches, so every request stays manual.
+func (p *ApprovalPolicy) Match(input *CreateRequestInput) (string, bool) {
+	if p == nil || input == nil {
+		return "", false
+	}
+
+	spec := map[string]interface{}{}
+	if input.Spec != "" {
+		if err := json.Unmarshal([]byte(input.Spec), &spec); err != nil {
+			return "", false
+		}
+	}
+	// Overrides replace spec inputs when the configuration is generated, so the limits are
+	// checked against what will actually be provisioned
+	for name, value := range input.VarOverrides {
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			spec[name] = number
+		} else {
+			spec[name] = value
+		}
+	}
+
+	for i := range p.rules {
+		if ruleMatches(&p.rules[i], input, spec) {
+			return p.rules[i].Name, true
+		}
+	}
+	return "", false
+}
+
+// ruleMatches reports whether every condition set on the rule holds for the request.
+func ruleMatches(rule *config.AutoApproveRule, input *CreateRequestInput, spec map[string]interface{}) bool {
+	if len(rule.Environments) > 0 && !slices.Contains(rule.Environments, input.Environment) {
+		return false
+	}
+	if len(rule.Providers) > 0 && !slices.Contains(rule.Providers, input.Provider) {
+		return false
+	}
+	if len(rule.RequesterRoles) > 0 && !hasAnyRole(input.RequesterRoles, rule.RequesterRoles) {
+		return false
+	}
+	if rule.MaxQuantity > 0 && input.Quantity > rule.MaxQuantity {
+		return false
+	}
+	return withinLimit(spec, "cpu", rule.MaxCPU) &&
+		withinLimit(spec, "memory", rule.MaxMemory) &&
+		withinLimit(spec, "disk", rule.MaxDisk)
+}
+
+// withinLimit checks a numeric spec value against a limit. A missing or non-numeric
+// value never satisfies a limit, since the module default could be larger.
+func withinLimit(spec map[string]interface{}, key string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+	value, ok := spec[key].(float64)
+	return ok && value <= float64(limit)
+}
+
+// hasAnyRole reports whether roles contains at least one of the allowed roles.
+func hasAnyRole(roles, allowed []string) bool {
+	for _, role := range roles {
+		if slices.Contains(allowed, role) {
+			return true
+		}
+	}
+	return false
+}
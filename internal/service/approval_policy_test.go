@@ -0,0 +1,319 @@
+// Package service provides approval policy tests.
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/events"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockResourceRequestRepository is a mock implementation of ResourceRequestRepository.
+type MockResourceRequestRepository struct {
+	mock.Mock
+}
+
+func (m *MockResourceRequestRepository) Create(ctx context.Context, request *model.ResourceRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockResourceRequestRepository) GetByID(ctx context.Context, id string) (*model.ResourceRequest, error) {
+	args := m.Called(ctx, id)
+	request, ok := args.Get(0).(*model.ResourceRequest)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return request, args.Error(1)
+}
+
+func (m *MockResourceRequestRepository) Update(ctx context.Context, request *model.ResourceRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockResourceRequestRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockResourceRequestRepository) List(ctx context.Context, filters repository.RequestFilters, offset, limit int) ([]*model.ResourceRequest, int64, error) {
+	args := m.Called(ctx, filters, offset, limit)
+	requests, ok := args.Get(0).([]*model.ResourceRequest)
+	if !ok {
+		return nil, 0, args.Error(2)
+	}
+	total, ok := args.Get(1).(int64)
+	if !ok {
+		return requests, 0, args.Error(2)
+	}
+	return requests, total, args.Error(2)
+}
+
+var devSmallVMRule = config.AutoApproveRule{
+	Name:         "dev-small-vm",
+	Environments: []string{"dev"},
+	Providers:    []string{"pve"},
+	MaxCPU:       4,
+	MaxMemory:    8192,
+	MaxQuantity:  2,
+}
+
+func TestApprovalPolicy_Match(t *testing.T) {
+	policy := NewApprovalPolicy([]config.AutoApproveRule{
+		devSmallVMRule,
+		{Name: "ops", RequesterRoles: []string{"ops"}},
+	}, nil)
+
+	tests := []struct {
+		name     string
+		input    CreateRequestInput
+		wantRule string
+		wantOK   bool
+	}{
+		{
+			name:     "small dev vm",
+			input:    CreateRequestInput{Environment: "dev", Provider: "pve", Spec: `{"cpu":2,"memory":4096}`, Quantity: 1},
+			wantRule: "dev-small-vm",
+			wantOK:   true,
+		},
+		{
+			name:  "cpu over limit",
+			input: CreateRequestInput{Environment: "dev", Provider: "pve", Spec: `{"cpu":8,"memory":4096}`, Quantity: 1},
+		},
+		{
+			name: "override over limit",
+			input: CreateRequestInput{Environment: "dev", Provider: "pve", Spec: `{"cpu":2,"memory":4096}`, Quantity: 1,
+				VarOverrides: map[string]string{"memory": "65536"}},
+		},
+		{
+			name: "override within limit",
+			input: CreateRequestInput{Environment: "dev", Provider: "pve", Spec: `{"cpu":2,"memory":4096}`, Quantity: 1,
+				VarOverrides: map[string]string{"cpu": "4"}},
+			wantRule: "dev-small-vm",
+			wantOK:   true,
+		},
+		{
+			name: "non-numeric override of a limited input",
+			input: CreateRequestInput{Environment: "dev", Provider: "pve", Spec: `{"cpu":2,"memory":4096}`, Quantity: 1,
+				VarOverrides: map[string]string{"cpu": "var.big"}},
+		},
+		{
+			name:  "missing spec value",
+			input: CreateRequestInput{Environment: "dev", Provider: "pve", Spec: `{"cpu":2}`, Quantity: 1},
+		},
+		{
+			name:  "quantity over limit",
+			input: CreateRequestInput{Environment: "dev", Provider: "pve", Spec: `{"cpu":2,"memory":4096}`, Quantity: 3},
+		},
+		{
+			name:  "other environment",
+			input: CreateRequestInput{Environment: "prod", Provider: "pve", Spec: `{"cpu":2,"memory":4096}`, Quantity: 1},
+		},
+		{
+			name:     "requester role",
+			input:    CreateRequestInput{Environment: "prod", RequesterRoles: []string{"user", "ops"}},
+			wantRule: "ops",
+			wantOK:   true,
+		},
+		{
+			name:  "invalid spec",
+			input: CreateRequestInput{Environment: "dev", Provider: "pve", Spec: `not json`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := policy.Match(&tt.input)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantRule, rule)
+		})
+	}
+
+	var nilPolicy *ApprovalPolicy
+	_, ok := nilPolicy.Match(&CreateRequestInput{})
+	assert.False(t, ok)
+}
+
+func TestResourceService_CreateRequestAutoApproval(t *testing.T) {
+	newService := func(requestRepo *MockResourceRequestRepository, provisioned chan string, bus *events.Bus) *resourceService {
+		svc, ok := NewResourceService(nil, requestRepo, nil, nil, nil, nil,
+			NewApprovalPolicy([]config.AutoApproveRule{devSmallVMRule}, nil), nil, 0, nil, bus, nil, nil, zap.NewNop()).(*resourceService)
+		require.True(t, ok)
+		svc.provision = func(_ context.Context, request *model.ResourceRequest) error {
+			provisioned <- request.ID
+			return nil
+		}
+		return svc
+	}
+
+	t.Run("matching request is approved and provisioned", func(t *testing.T) {
+		requestRepo := new(MockResourceRequestRepository)
+		provisioned := make(chan string, 1)
+		bus := events.NewBus(zap.NewNop())
+		var published []events.Type
+		record := func(_ context.Context, event events.Event) { published = append(published, event.Type) }
+		bus.Subscribe(events.RequestCreated, record)
+		bus.Subscribe(events.RequestApproved, record)
+		svc := newService(requestRepo, provisioned, bus)
+
+		requestRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.ResourceRequest")).
+			Run(func(args mock.Arguments) {
+				args.Get(1).(*model.ResourceRequest).ID = "req-1"
+			}).Return(nil)
+
+		request, err := svc.CreateRequest(context.Background(), &CreateRequestInput{
+			Title: "dev vm", Type: "vm", Environment: "dev", Provider: "pve",
+			Spec: `{"cpu":2,"memory":2048}`, Quantity: 1, RequesterID: "user-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "approved", request.Status)
+		assert.Equal(t, "rule:dev-small-vm", request.AutoApprovedBy)
+		assert.NotNil(t, request.ApprovedAt)
+		assert.Equal(t, "req-1", <-provisioned)
+		assert.Equal(t, []events.Type{events.RequestCreated, events.RequestApproved}, published)
+	})
+
+	t.Run("oversized override stays pending", func(t *testing.T) {
+		requestRepo := new(MockResourceRequestRepository)
+		provisioned := make(chan string, 1)
+		svc := newService(requestRepo, provisioned, nil)
+
+		requestRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.ResourceRequest")).Return(nil)
+
+		request, err := svc.CreateRequest(context.Background(), &CreateRequestInput{
+			Title: "dev vm", Type: "vm", Environment: "dev", Provider: "pve",
+			Spec: `{"cpu":2,"memory":2048}`, VarOverrides: map[string]string{"cpu": "64"}, Quantity: 1, RequesterID: "user-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "pending", request.Status, "the small spec does not hide the override")
+		assert.Empty(t, request.AutoApprovedBy)
+		assert.Empty(t, provisioned)
+	})
+
+	t.Run("non-matching request stays pending", func(t *testing.T) {
+		requestRepo := new(MockResourceRequestRepository)
+		provisioned := make(chan string, 1)
+		svc := newService(requestRepo, provisioned, nil)
+
+		requestRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.ResourceRequest")).Return(nil)
+
+		request, err := svc.CreateRequest(context.Background(), &CreateRequestInput{
+			Title: "prod vm", Type: "vm", Environment: "prod", Provider: "pve",
+			Spec: `{"cpu":2,"memory":2048}`, Quantity: 1, RequesterID: "user-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "pending", request.Status)
+		assert.Empty(t, request.AutoApprovedBy)
+		assert.Empty(t, provisioned)
+	})
+}
+
+func TestApprovalPolicy_Decide(t *testing.T) {
+	policy := NewApprovalPolicy([]config.AutoApproveRule{{Name: "small-vms", Providers: []string{"pve"}}}, map[string]config.EnvironmentApproval{
+		"dev":  {RequiresApproval: false},
+		"prod": {RequiresApproval: true},
+	})
+
+	tests := []struct {
+		name         string
+		input        CreateRequestInput
+		wantPolicy   string
+		wantApprover string
+	}{
+		{
+			name:         "auto-approve environment",
+			input:        CreateRequestInput{Environment: "dev", Provider: "vsphere"},
+			wantPolicy:   model.ApprovalPolicyEnvironmentAuto,
+			wantApprover: "environment:dev",
+		},
+		{
+			name:       "gated environment overrides a matching rule",
+			input:      CreateRequestInput{Environment: "prod", Provider: "pve"},
+			wantPolicy: model.ApprovalPolicyEnvironmentGated,
+		},
+		{
+			name:         "unlisted environment follows the rules",
+			input:        CreateRequestInput{Environment: "test", Provider: "pve"},
+			wantPolicy:   model.ApprovalPolicyRule,
+			wantApprover: "rule:small-vms",
+		},
+		{
+			name:       "unlisted environment without a rule",
+			input:      CreateRequestInput{Environment: "test", Provider: "vsphere"},
+			wantPolicy: model.ApprovalPolicyManual,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Decide(&tt.input)
+			assert.Equal(t, tt.wantPolicy, decision.Policy)
+			assert.Equal(t, tt.wantApprover != "", decision.AutoApproved)
+			assert.Equal(t, tt.wantApprover, decision.Approver)
+		})
+	}
+
+	var nilPolicy *ApprovalPolicy
+	assert.Equal(t, ApprovalDecision{Policy: model.ApprovalPolicyManual}, nilPolicy.Decide(&CreateRequestInput{}))
+}
+
+func TestResourceService_CreateRequestEnvironmentApproval(t *testing.T) {
+	newService := func(requestRepo *MockResourceRequestRepository, provisioned chan string) *resourceService {
+		policy := NewApprovalPolicy(nil, map[string]config.EnvironmentApproval{
+			"dev":  {RequiresApproval: false},
+			"prod": {RequiresApproval: true},
+		})
+		svc, ok := NewResourceService(nil, requestRepo, nil, nil, nil, nil, policy, nil, 0, nil, nil, nil, nil, zap.NewNop()).(*resourceService)
+		require.True(t, ok)
+		svc.provision = func(_ context.Context, request *model.ResourceRequest) error {
+			provisioned <- request.ID
+			return nil
+		}
+		return svc
+	}
+
+	t.Run("request in an auto-approve environment is created approved", func(t *testing.T) {
+		requestRepo := new(MockResourceRequestRepository)
+		provisioned := make(chan string, 1)
+		svc := newService(requestRepo, provisioned)
+		requestRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.ResourceRequest")).
+			Run(func(args mock.Arguments) {
+				args.Get(1).(*model.ResourceRequest).ID = "req-dev"
+			}).Return(nil)
+
+		request, err := svc.CreateRequest(context.Background(), &CreateRequestInput{
+			Title: "dev vm", Type: "vm", Environment: "dev", Provider: "pve", Quantity: 1, RequesterID: "user-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "approved", request.Status)
+		assert.Equal(t, model.ApprovalPolicyEnvironmentAuto, request.ApprovalPolicy)
+		assert.Equal(t, "environment:dev", request.AutoApprovedBy)
+		assert.NotNil(t, request.ApprovedAt)
+		assert.Equal(t, "req-dev", <-provisioned)
+	})
+
+	t.Run("request in a gated environment stays pending", func(t *testing.T) {
+		requestRepo := new(MockResourceRequestRepository)
+		provisioned := make(chan string, 1)
+		svc := newService(requestRepo, provisioned)
+		requestRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.ResourceRequest")).Return(nil)
+
+		request, err := svc.CreateRequest(context.Background(), &CreateRequestInput{
+			Title: "prod vm", Type: "vm", Environment: "prod", Provider: "pve", Quantity: 1, RequesterID: "user-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "pending", request.Status)
+		assert.Equal(t, model.ApprovalPolicyEnvironmentGated, request.ApprovalPolicy)
+		assert.Empty(t, request.AutoApprovedBy)
+		assert.Nil(t, request.ApprovedAt)
+		assert.Empty(t, provisioned)
+	})
+}
@@ -0,0 +1,263 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/blobstore"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Attachment errors.
+var (
+	ErrInvalidAttachment    = errors.New("invalid attachment")
+	ErrAttachmentTooLarge   = errors.New("attachment exceeds the maximum file size")
+	ErrAttachmentTypeDenied = errors.New("attachment file type is not allowed")
+)
+
+// maxAttachmentNameLength bounds attachment display names.
+const maxAttachmentNameLength = 255
+
+// sniffLength is how much of an upload is inspected to detect its content type.
+const sniffLength = 512
+
+// AttachmentService defines the interface for resource request attachments.
+type AttachmentService interface {
+	AddLink(ctx context.Context, input *AddLinkAttachmentInput) (*model.RequestAttachment, error)
+	AddFile(ctx context.Context, input *AddFileAttachmentInput) (*model.RequestAttachment, error)
+	List(ctx context.Context, requestID string) ([]*model.RequestAttachment, error)
+	Open(ctx context.Context, requestID, id string) (*model.RequestAttachment, io.ReadCloser, error)
+	Remove(ctx context.Context, requestID, id string) error
+}
+
+// AddLinkAttachmentInput represents input for attaching a link to a request.
+type AddLinkAttachmentInput struct {
+	RequestID string
+	Name      string
+	URL       string
+	AddedByID string
+}
+
+// AddFileAttachmentInput represents input for attaching a file to a request.
+type AddFileAttachmentInput struct {
+	RequestID string
+	Name      string
+	Content   io.Reader
+	AddedByID string
+}
+
+// AttachmentLimits restricts uploaded files.
+type AttachmentLimits struct {
+	MaxFileSize  int64
+	AllowedTypes []string
+}
+
+type attachmentService struct {
+	attachmentRepo      repository.AttachmentRepository
+	resourceRequestRepo repository.ResourceRequestRepository
+	store               blobstore.Store
+	limits              AttachmentLimits
+	logger              *zap.Logger
+}
+
+// NewAttachmentService creates a new attachment service. Zero limits fall back to the defaults.
+func NewAttachmentService(
+	attachmentRepo repository.AttachmentRepository,
+	resourceRequestRepo repository.ResourceRequestRepository,
+	store blobstore.Store,
+	limits AttachmentLimits,
+	logger *zap.Logger,
+) AttachmentService {
+	if limits.MaxFileSize <= 0 {
+		limits.MaxFileSize = constants.DefaultAttachmentMaxSize
+	}
+	if len(limits.AllowedTypes) == 0 {
+		limits.AllowedTypes = constants.DefaultAttachmentTypes()
+	}
+	return &attachmentService{
+		attachmentRepo:      attachmentRepo,
+		resourceRequestRepo: resourceRequestRepo,
+		store:               store,
+		limits:              limits,
+		logger:              logger,
+	}
+}
+
+// AddLink attaches a link to a request. Only http and https URLs are accepted.
+func (s *attachmentService) AddLink(ctx context.Context, input *AddLinkAttachmentInput) (*model.RequestAttachment, error) {
+	if _, err := s.resourceRequestRepo.GetByID(ctx, input.RequestID); err != nil {
+		return nil, err
+	}
+
+	link := strings.TrimSpace(input.URL)
+	if len(link) > constants.MaxAttachmentLinkLength {
+		return nil, fmt.Errorf("%w: url is too long", ErrInvalidAttachment)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an http or https link", ErrInvalidAttachment)
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		name = link
+	}
+	if len(name) > maxAttachmentNameLength {
+		name = name[:maxAttachmentNameLength]
+	}
+
+	attachment := &model.RequestAttachment{
+		RequestID: input.RequestID,
+		Kind:      model.AttachmentKindLink,
+		Name:      name,
+		URL:       link,
+		AddedByID: input.AddedByID,
+	}
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		return nil, fmt.Errorf("failed to save attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+// AddFile stores a file in the blob store and attaches it to a request. The content type is
+// detected from the file itself, not taken from the client.
+func (s *attachmentService) AddFile(ctx context.Context, input *AddFileAttachmentInput) (*model.RequestAttachment, error) {
+	if _, err := s.resourceRequestRepo.GetByID(ctx, input.RequestID); err != nil {
+		return nil, err
+	}
+
+	name := path.Base(strings.ReplaceAll(strings.TrimSpace(input.Name), "\\", "/"))
+	if name == "" || name == "." || name == "/" {
+		return nil, fmt.Errorf("%w: file name is required", ErrInvalidAttachment)
+	}
+	if len(name) > maxAttachmentNameLength {
+		return nil, fmt.Errorf("%w: file name is too long", ErrInvalidAttachment)
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(input.Content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidAttachment)
+	}
+	contentType := http.DetectContentType(head[:n])
+	if !s.typeAllowed(contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentTypeDenied, contentType)
+	}
+
+	// Read one byte past the limit so oversized uploads are detected without buffering them
+	content := io.LimitReader(io.MultiReader(bytes.NewReader(head[:n]), input.Content), s.limits.MaxFileSize+1)
+	ref, size, err := s.store.Put(ctx, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	if size > s.limits.MaxFileSize {
+		s.deleteBlob(ctx, ref)
+		return nil, ErrAttachmentTooLarge
+	}
+
+	attachment := &model.RequestAttachment{
+		RequestID:   input.RequestID,
+		Kind:        model.AttachmentKindFile,
+		Name:        name,
+		BlobRef:     ref,
+		ContentType: contentType,
+		Size:        size,
+		AddedByID:   input.AddedByID,
+	}
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		s.deleteBlob(ctx, ref)
+		return nil, fmt.Errorf("failed to save attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+// List retrieves the attachments of a request.
+func (s *attachmentService) List(ctx context.Context, requestID string) ([]*model.RequestAttachment, error) {
+	if _, err := s.resourceRequestRepo.GetByID(ctx, requestID); err != nil {
+		return nil, err
+	}
+	return s.attachmentRepo.ListByRequest(ctx, requestID)
+}
+
+// Open returns a file attachment of a request with a reader for its content.
+func (s *attachmentService) Open(ctx context.Context, requestID, id string) (*model.RequestAttachment, io.ReadCloser, error) {
+	attachment, err := s.get(ctx, requestID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if attachment.Kind != model.AttachmentKindFile {
+		return nil, nil, fmt.Errorf("%w: attachment is a link", ErrInvalidAttachment)
+	}
+
+	content, err := s.store.Open(ctx, attachment.BlobRef)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return nil, nil, repository.ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	return attachment, content, nil
+}
+
+// Remove deletes an attachment of a request and its stored file.
+func (s *attachmentService) Remove(ctx context.Context, requestID, id string) error {
+	attachment, err := s.get(ctx, requestID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.attachmentRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if attachment.Kind == model.AttachmentKindFile {
+		s.deleteBlob(ctx, attachment.BlobRef)
+	}
+	return nil
+}
+
+// get loads an attachment and checks that it belongs to the request.
+func (s *attachmentService) get(ctx context.Context, requestID, id string) (*model.RequestAttachment, error) {
+	attachment, err := s.attachmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.RequestID != requestID {
+		return nil, repository.ErrNotFound
+	}
+	return attachment, nil
+}
+
+// typeAllowed reports whether the media type of contentType is in the allowed list.
+func (s *attachmentService) typeAllowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range s.limits.AllowedTypes {
+		if strings.EqualFold(strings.TrimSpace(allowed), mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteBlob removes a stored file, logging instead of failing since the record is what matters.
+func (s *attachmentService) deleteBlob(ctx context.Context, ref string) {
+	if err := s.store.Delete(ctx, ref); err != nil {
+		s.logger.Warn("failed to delete attachment blob", zap.String("ref", ref), zap.Error(err))
+	}
+}
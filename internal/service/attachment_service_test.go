@@ -0,0 +1,217 @@
+// Package service provides attachment service tests.
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/blobstore"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryBlobStore is an in-memory blobstore.Store.
+type memoryBlobStore struct {
+	blobs map[string][]byte
+	next  int
+}
+
+func newMemoryBlobStore() *memoryBlobStore {
+	return &memoryBlobStore{blobs: map[string][]byte{}}
+}
+
+func (s *memoryBlobStore) Put(_ context.Context, r io.Reader) (string, int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", 0, err
+	}
+	s.next++
+	ref := "blob-" + strconv.Itoa(s.next)
+	s.blobs[ref] = data
+	return ref, int64(len(data)), nil
+}
+
+func (s *memoryBlobStore) Open(_ context.Context, ref string) (io.ReadCloser, error) {
+	data, ok := s.blobs[ref]
+	if !ok {
+		return nil, blobstore.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryBlobStore) Delete(_ context.Context, ref string) error {
+	delete(s.blobs, ref)
+	return nil
+}
+
+// memoryAttachmentRepository is an in-memory repository.AttachmentRepository.
+type memoryAttachmentRepository struct {
+	attachments []*model.RequestAttachment
+}
+
+func (r *memoryAttachmentRepository) Create(_ context.Context, attachment *model.RequestAttachment) error {
+	attachment.ID = "attachment-" + strconv.Itoa(len(r.attachments)+1)
+	r.attachments = append(r.attachments, attachment)
+	return nil
+}
+
+func (r *memoryAttachmentRepository) GetByID(_ context.Context, id string) (*model.RequestAttachment, error) {
+	for _, attachment := range r.attachments {
+		if attachment.ID == id {
+			return attachment, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryAttachmentRepository) ListByRequest(_ context.Context, requestID string) ([]*model.RequestAttachment, error) {
+	var out []*model.RequestAttachment
+	for _, attachment := range r.attachments {
+		if attachment.RequestID == requestID {
+			out = append(out, attachment)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryAttachmentRepository) Delete(_ context.Context, id string) error {
+	for i, attachment := range r.attachments {
+		if attachment.ID == id {
+			r.attachments = append(r.attachments[:i], r.attachments[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func newTestAttachmentService(t *testing.T, limits AttachmentLimits) (AttachmentService, *memoryAttachmentRepository, *memoryBlobStore) {
+	t.Helper()
+	requestRepo := new(MockResourceRequestRepository)
+	requestRepo.On("GetByID", mock.Anything, "req-1").Return(&model.ResourceRequest{BaseModel: model.BaseModel{ID: "req-1"}}, nil)
+	requestRepo.On("GetByID", mock.Anything, mock.Anything).Return(nil, repository.ErrNotFound)
+
+	attachmentRepo := &memoryAttachmentRepository{}
+	store := newMemoryBlobStore()
+	return NewAttachmentService(attachmentRepo, requestRepo, store, limits, zap.NewNop()), attachmentRepo, store
+}
+
+// pngHeader is the PNG signature followed by an IHDR chunk, enough for content sniffing.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+
+func TestAttachmentService_AddLinkAndFile(t *testing.T) {
+	svc, _, store := newTestAttachmentService(t, AttachmentLimits{})
+	ctx := context.Background()
+
+	link, err := svc.AddLink(ctx, &AddLinkAttachmentInput{
+		RequestID: "req-1",
+		Name:      "Design doc",
+		URL:       " https://docs.example.com/design ",
+		AddedByID: "user-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.AttachmentKindLink, link.Kind)
+	assert.Equal(t, "https://docs.example.com/design", link.URL)
+	assert.Empty(t, link.BlobRef)
+
+	file, err := svc.AddFile(ctx, &AddFileAttachmentInput{
+		RequestID: "req-1",
+		Name:      `C:\uploads\diagram.png`,
+		Content:   bytes.NewReader(pngHeader),
+		AddedByID: "user-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.AttachmentKindFile, file.Kind)
+	assert.Equal(t, "diagram.png", file.Name, "client paths are stripped from file names")
+	assert.Equal(t, "image/png", file.ContentType)
+	assert.Equal(t, int64(len(pngHeader)), file.Size)
+	assert.Equal(t, pngHeader, store.blobs[file.BlobRef])
+
+	attachments, err := svc.List(ctx, "req-1")
+	require.NoError(t, err)
+	require.Len(t, attachments, 2)
+	assert.Equal(t, link.ID, attachments[0].ID)
+	assert.Equal(t, file.ID, attachments[1].ID)
+
+	_, content, err := svc.Open(ctx, "req-1", file.ID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.Equal(t, pngHeader, data)
+
+	_, _, err = svc.Open(ctx, "req-1", link.ID)
+	assert.ErrorIs(t, err, ErrInvalidAttachment, "links have no content to download")
+	_, _, err = svc.Open(ctx, "req-2", file.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound, "attachments are scoped to their request")
+
+	require.NoError(t, svc.Remove(ctx, "req-1", file.ID))
+	assert.Empty(t, store.blobs, "removing a file deletes its blob")
+	attachments, err = svc.List(ctx, "req-1")
+	require.NoError(t, err)
+	assert.Len(t, attachments, 1)
+}
+
+func TestAttachmentService_Limits(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("oversized file is rejected and its blob removed", func(t *testing.T) {
+		svc, attachmentRepo, store := newTestAttachmentService(t, AttachmentLimits{MaxFileSize: 16})
+		_, err := svc.AddFile(ctx, &AddFileAttachmentInput{
+			RequestID: "req-1",
+			Name:      "notes.txt",
+			Content:   strings.NewReader(strings.Repeat("a", 17)),
+		})
+		assert.ErrorIs(t, err, ErrAttachmentTooLarge)
+		assert.Empty(t, store.blobs)
+		assert.Empty(t, attachmentRepo.attachments)
+	})
+
+	t.Run("file at the limit is accepted", func(t *testing.T) {
+		svc, _, _ := newTestAttachmentService(t, AttachmentLimits{MaxFileSize: 16})
+		file, err := svc.AddFile(ctx, &AddFileAttachmentInput{
+			RequestID: "req-1",
+			Name:      "notes.txt",
+			Content:   strings.NewReader(strings.Repeat("a", 16)),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(16), file.Size)
+	})
+
+	t.Run("type is detected from content, not the name", func(t *testing.T) {
+		svc, _, store := newTestAttachmentService(t, AttachmentLimits{AllowedTypes: []string{"image/png"}})
+		_, err := svc.AddFile(ctx, &AddFileAttachmentInput{
+			RequestID: "req-1",
+			Name:      "diagram.png",
+			Content:   strings.NewReader("#!/bin/sh\necho hi\n"),
+		})
+		assert.ErrorIs(t, err, ErrAttachmentTypeDenied)
+		assert.Empty(t, store.blobs)
+	})
+
+	t.Run("empty file is rejected", func(t *testing.T) {
+		svc, _, _ := newTestAttachmentService(t, AttachmentLimits{})
+		_, err := svc.AddFile(ctx, &AddFileAttachmentInput{RequestID: "req-1", Name: "empty.txt", Content: strings.NewReader("")})
+		assert.ErrorIs(t, err, ErrInvalidAttachment)
+	})
+
+	t.Run("non-http links are rejected", func(t *testing.T) {
+		svc, _, _ := newTestAttachmentService(t, AttachmentLimits{})
+		for _, link := range []string{"javascript:alert(1)", "file:///etc/passwd", "docs/design.md"} {
+			_, err := svc.AddLink(ctx, &AddLinkAttachmentInput{RequestID: "req-1", URL: link})
+			assert.ErrorIs(t, err, ErrInvalidAttachment, link)
+		}
+	})
+
+	t.Run("unknown request", func(t *testing.T) {
+		svc, _, _ := newTestAttachmentService(t, AttachmentLimits{})
+		_, err := svc.AddLink(ctx, &AddLinkAttachmentInput{RequestID: "missing", URL: "https://example.com"})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -29,6 +30,11 @@ type AuthService interface {
 	RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error)
 	Logout(ctx context.Context, accessToken string) error
 	ValidateToken(ctx context.Context, tokenString string) (*Claims, error)
+
+	// SSOAuthURL returns the OIDC provider URL that starts an SSO login.
+	SSOAuthURL(ctx context.Context, state, nonce string) (string, error)
+	// SSOLogin completes an SSO login with the authorization code from the provider callback.
+	SSOLogin(ctx context.Context, code, nonce, clientIP string) (*TokenPair, error)
 }
 
 // TokenPair represents access and refresh tokens.
@@ -94,17 +100,29 @@ func (tb *tokenBlacklist) cleanup() {
 
 type authService struct {
 	userRepo  repository.UserRepository
+	roleRepo  repository.RoleRepository
 	cfg       *config.Config
 	blacklist *tokenBlacklist
+	oidc      *oidcProvider // nil when SSO is disabled
+	ldapDial  ldapDialer    // nil when LDAP is disabled
 }
 
-// NewAuthService creates a new authentication service.
-func NewAuthService(userRepo repository.UserRepository, cfg *config.Config) AuthService {
-	return &authService{
+// NewAuthService creates a new authentication service. roleRepo supplies the roles of users
+// provisioned on their first SSO or LDAP login.
+func NewAuthService(userRepo repository.UserRepository, roleRepo repository.RoleRepository, cfg *config.Config) AuthService {
+	s := &authService{
 		userRepo:  userRepo,
+		roleRepo:  roleRepo,
 		cfg:       cfg,
 		blacklist: newTokenBlacklist(),
 	}
+	if cfg.SSO.Enabled {
+		s.oidc = newOIDCProvider(cfg.SSO, nil)
+	}
+	if cfg.LDAP.Enabled {
+		s.ldapDial = newLDAPDialer(cfg.LDAP)
+	}
+	return s
 }
 
 func (s *authService) Login(ctx context.Context, username, password, clientIP string) (*TokenPair, error) {
@@ -115,21 +133,34 @@ func (s *authService) Login(ctx context.Context, username, password, clientIP st
 
 	// Get user by username
 	user, err := s.userRepo.GetByUsername(ctx, username)
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrInvalidCredentials
-		}
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
 		return nil, err
 	}
 
-	// Check user status
-	if user.Status == 0 {
-		return nil, ErrUserDisabled
-	}
+	// LDAP users, and names not known locally, are checked against the directory when LDAP
+	// is enabled. Local accounts such as the initial admin keep their local password.
+	if s.ldapDial != nil && (user == nil || user.Source == model.UserSourceLDAP) {
+		user, err = s.ldapLogin(ctx, username, password)
+		if err != nil {
+			return nil, err
+		}
+		if user.Status == 0 {
+			return nil, ErrUserDisabled
+		}
+	} else {
+		if user == nil {
+			return nil, ErrInvalidCredentials
+		}
 
-	// Verify password
-	if pwdErr := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); pwdErr != nil {
-		return nil, ErrInvalidCredentials
+		// Check user status
+		if user.Status == 0 {
+			return nil, ErrUserDisabled
+		}
+
+		// Verify password
+		if pwdErr := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); pwdErr != nil {
+			return nil, ErrInvalidCredentials
+		}
 	}
 
 	// Generate token pair
@@ -147,6 +178,57 @@ func (s *authService) Login(ctx context.Context, username, password, clientIP st
 	return tokenPair, nil
 }
 
+func (s *authService) SSOAuthURL(ctx context.Context, state, nonce string) (string, error) {
+	if s.oidc == nil {
+		return "", ErrSSODisabled
+	}
+	authURL, err := s.oidc.authCodeURL(ctx, state, nonce)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSSOLoginFailed, err)
+	}
+	return authURL, nil
+}
+
+func (s *authService) SSOLogin(ctx context.Context, code, nonce, clientIP string) (*TokenPair, error) {
+	if s.oidc == nil {
+		return nil, ErrSSODisabled
+	}
+	if code == "" || nonce == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	claims, err := s.oidc.exchange(ctx, code, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSSOLoginFailed, err)
+	}
+
+	user, err := s.resolveExternalUser(ctx, externalIdentity{
+		Source:        model.UserSourceOIDC,
+		ExternalID:    claims.Subject,
+		Username:      claims.PreferredUsername,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		DisplayName:   claims.Name,
+		LinkLocal:     s.cfg.SSO.LinkLocalAccounts,
+	}, s.cfg.SSO.DefaultRole)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSSOLoginFailed, err)
+	}
+	if user.Status == 0 {
+		return nil, ErrUserDisabled
+	}
+
+	tokenPair, err := s.generateTokenPair(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateLastLogin(ctx, user.ID, clientIP); err != nil {
+		// Log error but don't fail login
+		_ = err
+	}
+	return tokenPair, nil
+}
+
 func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
 	// Parse and validate refresh token
 	claims := &Claims{}
@@ -221,7 +303,7 @@ func (s *authService) ValidateToken(_ context.Context, tokenString string) (*Cla
 }
 
 func (s *authService) generateTokenPair(user *model.User) (*TokenPair, error) {
-	now := time.Now()
+	now := model.Now()
 	accessExpiry := now.Add(time.Duration(s.cfg.JWT.AccessTokenTTL) * time.Minute)
 	refreshExpiry := now.Add(time.Duration(s.cfg.JWT.RefreshTokenTTL) * time.Hour)
 
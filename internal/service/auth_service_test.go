@@ -61,11 +61,25 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*mod
 	return user, args.Error(1)
 }
 
+func (m *MockUserRepository) GetByExternalID(ctx context.Context, source model.UserSource, externalID string) (*model.User, error) {
+	args := m.Called(ctx, source, externalID)
+	user, ok := args.Get(0).(*model.User)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return user, args.Error(1)
+}
+
 func (m *MockUserRepository) Update(ctx context.Context, user *model.User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) ReplaceRoles(ctx context.Context, user *model.User, roles []model.Role) error {
+	args := m.Called(ctx, user, roles)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+// builtinCommitMessageTemplate reproduces the messages used when none is configured.
+const builtinCommitMessageTemplate = `{{ if eq .Action "pending" }}Add pending node config: {{ .Node }}{{ else }}{{ .Message }}{{ end }}`
+
+// Actions of the commits made for node configs.
+const (
+	commitActionPending = "pending" // The config written when its request is created, before approval
+	commitActionCommit  = "commit"  // A config committed on demand with a user-supplied message
+)
+
+// CommitMessageFields are the fields available to commit message templates.
+type CommitMessageFields struct {
+	Node        string // Node name
+	Path        string // Config path in the storage repository
+	RequestID   string // Resource request ID, empty when the config has no request
+	Requester   string // Username of the requester, or their ID when the user is not loaded
+	Environment string
+	Action      string // "pending" or "commit"
+	Message     string // Message given for the commit; empty for pending commits
+}
+
+// CommitMessageTemplate renders the message of each node config commit.
+type CommitMessageTemplate struct {
+	tmpl *template.Template
+}
+
+// defaultCommitMessageTemplate is the parsed built-in template.
+var defaultCommitMessageTemplate = mustParseCommitMessageTemplate(builtinCommitMessageTemplate)
+
+func mustParseCommitMessageTemplate(text string) *CommitMessageTemplate {
+	t, err := ParseCommitMessageTemplate(text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// ParseCommitMessageTemplate parses a commit message template, using the built-in one when
+// text is empty. The template is rendered for a sample pending and regular commit, so one
+// that fails to render or renders an empty message is rejected at load time.
+func ParseCommitMessageTemplate(text string) (*CommitMessageTemplate, error) {
+	if strings.TrimSpace(text) == "" {
+		text = builtinCommitMessageTemplate
+	}
+	tmpl, err := template.New("commit_message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit message template: %w", err)
+	}
+	t := &CommitMessageTemplate{tmpl: tmpl}
+
+	sample := CommitMessageFields{
+		Node:        "node-001",
+		Path:        "proxmox-ve/instance/vm/node-001",
+		RequestID:   "00000000-0000-0000-0000-000000000000",
+		Requester:   "admin",
+		Environment: "dev",
+	}
+	pending, commit := sample, sample
+	pending.Action = commitActionPending
+	commit.Action, commit.Message = commitActionCommit, "Update node config"
+	for _, fields := range []CommitMessageFields{pending, commit} {
+		if _, err := t.Render(fields); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Render returns the commit message for fields, with surrounding whitespace removed.
+func (t *CommitMessageTemplate) Render(fields CommitMessageFields) (string, error) {
+	var buf strings.Builder
+	if err := t.tmpl.Execute(&buf, fields); err != nil {
+		return "", fmt.Errorf("invalid commit message template: %w", err)
+	}
+	message := strings.TrimSpace(buf.String())
+	if message == "" {
+		return "", errors.New("invalid commit message template: it renders an empty message")
+	}
+	return message, nil
+}
+
+// commitMessageFields returns the template fields for a commit of config. request is the
+// config's resource request, or nil when it is not known.
+func commitMessageFields(config *model.NodeConfig, request *model.ResourceRequest, action, message string) CommitMessageFields {
+	fields := CommitMessageFields{Node: config.Name, Path: config.Path, RequestID: config.ResourceRequestID, Action: action, Message: message}
+	if request != nil {
+		fields.RequestID = request.ID
+		fields.Environment = request.Environment
+		fields.Requester = request.RequesterID
+		if request.Requester != nil {
+			fields.Requester = request.Requester.Username
+		}
+	}
+	return fields
+}
@@ -0,0 +1,130 @@
+// Package service provides commit message template tests.
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseCommitMessageTemplate(t *testing.T) {
+	config := &model.NodeConfig{Name: "minio-001", Path: "proxmox-ve/instance/vm/minio-001", ResourceRequestID: "1234abcd"}
+	request := &model.ResourceRequest{
+		BaseModel:   model.BaseModel{ID: "1234abcd"},
+		Environment: "staging",
+		RequesterID: "user-1",
+		Requester:   &model.User{Username: "alice"},
+	}
+
+	t.Run("empty template keeps the built-in messages", func(t *testing.T) {
+		tmpl, err := ParseCommitMessageTemplate(" ")
+		require.NoError(t, err)
+
+		message, err := tmpl.Render(commitMessageFields(config, request, commitActionPending, ""))
+		require.NoError(t, err)
+		assert.Equal(t, "Add pending node config: minio-001", message)
+
+		message, err = tmpl.Render(commitMessageFields(config, request, commitActionCommit, "Resize minio-001"))
+		require.NoError(t, err)
+		assert.Equal(t, "Resize minio-001", message)
+	})
+
+	t.Run("custom template renders pending and regular commits", func(t *testing.T) {
+		tmpl, err := ParseCommitMessageTemplate(
+			"{{ .Environment }}({{ .Node }}): {{ if .Message }}{{ .Message }}{{ else }}{{ .Action }} config{{ end }}\n\nRequest: {{ .RequestID }}\nRequested-by: {{ .Requester }}\n",
+		)
+		require.NoError(t, err)
+
+		message, err := tmpl.Render(commitMessageFields(config, request, commitActionPending, ""))
+		require.NoError(t, err)
+		assert.Equal(t, "staging(minio-001): pending config\n\nRequest: 1234abcd\nRequested-by: alice", message)
+
+		message, err = tmpl.Render(commitMessageFields(config, request, commitActionCommit, "resize to 4 cores"))
+		require.NoError(t, err)
+		assert.Equal(t, "staging(minio-001): resize to 4 cores\n\nRequest: 1234abcd\nRequested-by: alice", message)
+	})
+
+	t.Run("requester falls back to the ID", func(t *testing.T) {
+		tmpl, err := ParseCommitMessageTemplate(`{{ .Node }} by {{ .Requester }}`)
+		require.NoError(t, err)
+
+		withoutUser := *request
+		withoutUser.Requester = nil
+		message, err := tmpl.Render(commitMessageFields(config, &withoutUser, commitActionPending, ""))
+		require.NoError(t, err)
+		assert.Equal(t, "minio-001 by user-1", message)
+	})
+
+	invalid := []struct {
+		name string
+		tmpl string
+	}{
+		{name: "syntax error", tmpl: `{{ .Node `},
+		{name: "unknown field", tmpl: `{{ .Title }}`},
+		{name: "empty message", tmpl: `{{ if eq .Action "pending" }}{{ .Node }}{{ end }}`},
+		{name: "blank message", tmpl: `{{ "  " }}`},
+	}
+	for _, tt := range invalid {
+		t.Run("rejects "+tt.name, func(t *testing.T) {
+			_, err := ParseCommitMessageTemplate(tt.tmpl)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid commit message template")
+		})
+	}
+}
+
+func TestGitService_CommitMessageTemplate(t *testing.T) {
+	repoURL, _ := setupSSHRemote(t)
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	storageRepo := &model.GitRepository{
+		BaseModel: model.BaseModel{ID: "storage-1"}, URL: repoURL, Branch: "main",
+		AuthType: model.GitAuthTypeSSHKey, SSHKey: testSSHKey,
+	}
+	request := &model.ResourceRequest{BaseModel: model.BaseModel{ID: "req-1"}, Environment: "dev", Requester: &model.User{Username: "alice"}}
+	config := &model.NodeConfig{
+		BaseModel:         model.BaseModel{ID: "cfg-1"},
+		Name:              "vm-001",
+		Path:              "nodes/vm-001",
+		ResourceRequestID: request.ID,
+		ResourceRequest:   request,
+		StorageRepoID:     storageRepo.ID,
+		TerragruntConfig:  "inputs = {\n  name = \"vm-001\"\n}\n",
+	}
+	tmpl, err := ParseCommitMessageTemplate(`{{ .Action }}({{ .Environment }}/{{ .Node }}): {{ or .Message "new node" }} [{{ .RequestID }}] by {{ .Requester }}`)
+	require.NoError(t, err)
+	svc := &gitService{
+		logger:         zap.NewNop(),
+		workDir:        t.TempDir(),
+		nodeConfigRepo: &memoryNodeConfigRepository{configs: map[string]*model.NodeConfig{config.ID: config}},
+		gitRepoRepo:    &stubGitRepoRepository{repos: map[string]*model.GitRepository{storageRepo.ID: storageRepo}},
+		commitTemplate: tmpl,
+	}
+	ctx := context.Background()
+
+	checkout := filepath.Join(t.TempDir(), "checkout")
+	lastMessage := func() string {
+		t.Helper()
+		require.NoError(t, svc.CloneRepository(ctx, storageRepo, checkout))
+		message, err := svc.git(ctx, checkout, "log", "-1", "--format=%s")
+		require.NoError(t, err)
+		return message
+	}
+
+	_, err = svc.commitPendingConfig(ctx, config, request, storageRepo)
+	require.NoError(t, err)
+	assert.Equal(t, "pending(dev/vm-001): new node [req-1] by alice\n", lastMessage())
+
+	config.TerragruntConfig = "inputs = {\n  name = \"vm-001\"\n  cores = 4\n}\n"
+	_, err = svc.CommitNodeConfig(ctx, config.ID, "resize")
+	require.NoError(t, err)
+	assert.Equal(t, "commit(dev/vm-001): resize [req-1] by alice\n", lastMessage())
+}
@@ -0,0 +1,101 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+// ConnectionTestCache remembers recent connection test results, so a provider endpoint that
+// is tested repeatedly, such as on every page load, is only contacted once per window.
+type ConnectionTestCache interface {
+	// Get returns the result stored under key unless it has expired. An empty result is a
+	// successful test; otherwise it describes the error of the failed one.
+	Get(ctx context.Context, key string) (string, bool)
+	// Set stores result under key for ttl.
+	Set(ctx context.Context, key, result string, ttl time.Duration)
+}
+
+type cachedConnectionTest struct {
+	result  string
+	expires time.Time
+}
+
+// memoryConnectionTestCache holds results in process memory; each replica tests on its own.
+type memoryConnectionTestCache struct {
+	mu      sync.Mutex
+	results map[string]cachedConnectionTest
+	now     func() time.Time
+}
+
+// NewMemoryConnectionTestCache creates a connection test cache local to the current process.
+func NewMemoryConnectionTestCache() ConnectionTestCache {
+	return &memoryConnectionTestCache{results: make(map[string]cachedConnectionTest), now: time.Now}
+}
+
+func (c *memoryConnectionTestCache) Get(_ context.Context, key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.results[key]
+	if !ok {
+		return "", false
+	}
+	if !c.now().Before(cached.expires) {
+		delete(c.results, key)
+		return "", false
+	}
+	return cached.result, true
+}
+
+func (c *memoryConnectionTestCache) Set(_ context.Context, key, result string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for k, cached := range c.results {
+		if !now.Before(cached.expires) {
+			delete(c.results, k)
+		}
+	}
+	c.results[key] = cachedConnectionTest{result: result, expires: now.Add(ttl)}
+}
+
+// connectionTestKey identifies a connection test by provider type, endpoint, options and
+// the credential used. The credential's secrets are hashed into the key rather than stored,
+// and a changed secret gives a new key, so editing a credential is tested afresh.
+func connectionTestKey(providerType, endpoint string, credential *model.Credential, opts connectionOptions) string {
+	parts := []string{providerType, endpoint, fmt.Sprintf("%#v", opts)}
+	if credential != nil {
+		parts = append(parts, credential.Endpoint, credential.AccessKey, credential.SecretKey, credential.Token)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return "connection-test:" + hex.EncodeToString(sum[:])
+}
+
+// testConnectionCached runs the connection test for providerType unless a result for the
+// same endpoint and credential is cached. force skips the cache lookup; the fresh result
+// is cached either way.
+func (s *settingsService) testConnectionCached(ctx context.Context, providerType, endpoint string, credential *model.Credential, opts connectionOptions, force bool) error {
+	if s.connectionCache == nil {
+		return s.probe(ctx, providerType, endpoint, credential, opts)
+	}
+
+	key := connectionTestKey(providerType, endpoint, credential, opts)
+	if !force {
+		if result, ok := s.connectionCache.Get(ctx, key); ok {
+			return connectionTestError(result)
+		}
+	}
+
+	err := s.probe(ctx, providerType, endpoint, credential, opts)
+	s.connectionCache.Set(ctx, key, connectionTestResult(err), s.connectionCacheTTL)
+	return err
+}
@@ -0,0 +1,108 @@
+// Package service provides connection test cache tests.
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// countingProbe counts connection tests and fails them with err when set.
+type countingProbe struct {
+	calls int
+	err   error
+}
+
+func (p *countingProbe) probe(context.Context, string, string, *model.Credential, connectionOptions) error {
+	p.calls++
+	return p.err
+}
+
+func TestSettingsService_ConnectionTestCache(t *testing.T) {
+	ctx := context.Background()
+	newService := func(probe *countingProbe) SettingsService {
+		svc := NewSettingsService(nil, nil, NewMemoryConnectionTestCache(), zap.NewNop())
+		svc.(*settingsService).probe = probe.probe
+		return svc
+	}
+	input := func(secret string, force bool) *TestCredentialConnectionInput {
+		return &TestCredentialConnectionInput{Type: "pve", Endpoint: "https://pve.example.com:8006", AccessKey: "root@pam", SecretKey: secret, Force: force}
+	}
+
+	t.Run("a second test within the TTL is served from the cache", func(t *testing.T) {
+		probe := &countingProbe{}
+		svc := newService(probe)
+		require.NoError(t, svc.TestCredentialConnection(ctx, input("secret", false)))
+		require.NoError(t, svc.TestCredentialConnection(ctx, input("secret", false)))
+		assert.Equal(t, 1, probe.calls)
+	})
+
+	t.Run("force bypasses the cache", func(t *testing.T) {
+		probe := &countingProbe{}
+		svc := newService(probe)
+		require.NoError(t, svc.TestCredentialConnection(ctx, input("secret", false)))
+		require.NoError(t, svc.TestCredentialConnection(ctx, input("secret", true)))
+		assert.Equal(t, 2, probe.calls)
+	})
+
+	t.Run("another credential is tested afresh", func(t *testing.T) {
+		probe := &countingProbe{}
+		svc := newService(probe)
+		require.NoError(t, svc.TestCredentialConnection(ctx, input("secret", false)))
+		require.NoError(t, svc.TestCredentialConnection(ctx, input("rotated", false)))
+		assert.Equal(t, 2, probe.calls)
+	})
+
+	t.Run("failures are cached too", func(t *testing.T) {
+		probe := &countingProbe{err: errors.New("connection refused")}
+		svc := newService(probe)
+		require.EqualError(t, svc.TestCredentialConnection(ctx, input("secret", false)), "connection refused")
+		require.EqualError(t, svc.TestCredentialConnection(ctx, input("secret", false)), "connection refused")
+		assert.Equal(t, 1, probe.calls)
+	})
+
+	t.Run("a cached failure keeps its kind", func(t *testing.T) {
+		probe := &countingProbe{err: &ConnectionError{Kind: ConnectionErrorAuth, Err: errors.New("endpoint returned 401 Unauthorized")}}
+		svc := newService(probe)
+		first := svc.TestCredentialConnection(ctx, input("secret", false))
+		cached := svc.TestCredentialConnection(ctx, input("secret", false))
+		assert.Equal(t, 1, probe.calls)
+
+		var connErr *ConnectionError
+		require.ErrorAs(t, cached, &connErr)
+		assert.Equal(t, ConnectionErrorAuth, connErr.Kind)
+		assert.Equal(t, first.Error(), cached.Error())
+	})
+}
+
+func TestMemoryConnectionTestCache_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := &memoryConnectionTestCache{results: make(map[string]cachedConnectionTest), now: func() time.Time { return now }}
+
+	cache.Set(ctx, "key", "", 30*time.Second)
+	result, ok := cache.Get(ctx, "key")
+	require.True(t, ok)
+	assert.Empty(t, result)
+
+	now = now.Add(30 * time.Second)
+	_, ok = cache.Get(ctx, "key")
+	assert.False(t, ok, "the result expires after the TTL")
+}
+
+func TestConnectionTestKey(t *testing.T) {
+	credential := &model.Credential{AccessKey: "root@pam", SecretKey: "secret"}
+	key := connectionTestKey("pve", "https://pve.example.com", credential, connectionOptions{})
+	assert.NotContains(t, key, "secret", "secrets are hashed")
+	assert.Equal(t, key, connectionTestKey("pve", "https://pve.example.com", &model.Credential{AccessKey: "root@pam", SecretKey: "secret"}, connectionOptions{}))
+	assert.NotEqual(t, key, connectionTestKey("pve", "https://other.example.com", credential, connectionOptions{}))
+	assert.NotEqual(t, key, connectionTestKey("pve", "https://pve.example.com", nil, connectionOptions{}))
+	assert.NotEqual(t, key, connectionTestKey("pve", "https://pve.example.com", credential, connectionOptions{Insecure: true}))
+	assert.NotEqual(t, key, connectionTestKey("pve", "https://pve.example.com", credential, connectionOptions{Project: "lab"}))
+}
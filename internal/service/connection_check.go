@@ -0,0 +1,203 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+)
+
+// ConnectionErrorKind classifies why a provider connection test failed.
+type ConnectionErrorKind string
+
+// ConnectionErrorKind constants.
+const (
+	ConnectionErrorDNS      ConnectionErrorKind = "dns"      // The endpoint's host name does not resolve
+	ConnectionErrorTLS      ConnectionErrorKind = "tls"      // The TLS handshake or certificate check failed
+	ConnectionErrorAuth     ConnectionErrorKind = "auth"     // The endpoint rejected the credential
+	ConnectionErrorServer   ConnectionErrorKind = "server"   // The endpoint answered with a 5xx status
+	ConnectionErrorNetwork  ConnectionErrorKind = "network"  // The endpoint could not be reached or timed out
+	ConnectionErrorResponse ConnectionErrorKind = "response" // The endpoint answered, but not as the provider does
+)
+
+var connectionErrorPrefixes = map[ConnectionErrorKind]string{
+	ConnectionErrorDNS:      "DNS lookup failed",
+	ConnectionErrorTLS:      "TLS error",
+	ConnectionErrorAuth:     "authentication failed",
+	ConnectionErrorServer:   "server error",
+	ConnectionErrorNetwork:  "endpoint unreachable",
+	ConnectionErrorResponse: "unexpected response",
+}
+
+// ConnectionError is returned by a connection test that failed, with the kind of failure.
+type ConnectionError struct {
+	Kind ConnectionErrorKind
+	Err  error
+}
+
+func (e *ConnectionError) Error() string {
+	return connectionErrorPrefixes[e.Kind] + ": " + e.Err.Error()
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// connectionOptions are the settings of a provider that change how its endpoint is tested.
+type connectionOptions struct {
+	// Insecure skips verification of the endpoint's TLS certificate, for servers with a
+	// self-signed one. AllowUnverifiedSSL, the name the vSphere terraform provider uses, does
+	// the same.
+	Insecure           bool `json:"insecure"`
+	AllowUnverifiedSSL bool `json:"allow_unverified_ssl"`
+
+	// OpenStack settings, named as in clouds.yaml. AuthType is "v3applicationcredential" for
+	// an application credential and password auth otherwise. Project, or the older
+	// TenantName, scopes the token; without either it is unscoped. The domains default to
+	// "Default".
+	AuthType          string `json:"auth_type"`
+	Project           string `json:"project"`
+	TenantName        string `json:"tenant_name"`
+	UserDomainName    string `json:"user_domain_name"`
+	ProjectDomainName string `json:"project_domain_name"`
+}
+
+// parseConnectionOptions reads the connection options from a provider's JSON config. An
+// empty config has none set.
+func parseConnectionOptions(config string) (connectionOptions, error) {
+	var opts connectionOptions
+	if strings.TrimSpace(config) == "" {
+		return opts, nil
+	}
+	if err := json.Unmarshal([]byte(config), &opts); err != nil {
+		return opts, fmt.Errorf("invalid provider config: %w", err)
+	}
+	return opts, nil
+}
+
+// apiBaseURL returns the URL of the API at apiPath on endpoint, which may be given with or
+// without that path.
+func apiBaseURL(endpoint, apiPath string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("invalid endpoint %q: an http or https URL is required", endpoint)
+	}
+	path := strings.TrimSuffix(strings.TrimRight(u.Path, "/"), apiPath)
+	return u.Scheme + "://" + u.Host + path + apiPath, nil
+}
+
+// connectionTestClient returns an HTTP client for a connection test. Certificates are
+// verified unless opts skip verification; redirects are not followed, so a credential is
+// never sent to another host.
+func connectionTestClient(opts connectionOptions) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:errcheck,forcetypeassert // DefaultTransport is an *http.Transport
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: opts.Insecure || opts.AllowUnverifiedSSL, // #nosec G402 -- only when the provider is configured to skip verification
+	}
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// classifyTransportError wraps an error of a request that got no response in a
+// ConnectionError of the matching kind.
+func classifyTransportError(err error) error {
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	switch {
+	case errors.As(err, &dnsErr):
+		return &ConnectionError{Kind: ConnectionErrorDNS, Err: dnsErr}
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr),
+		errors.As(err, &invalidCert), errors.As(err, &recordErr), errors.As(err, &alertErr):
+		return &ConnectionError{Kind: ConnectionErrorTLS, Err: err}
+	default:
+		return &ConnectionError{Kind: ConnectionErrorNetwork, Err: err}
+	}
+}
+
+// classifyStatus returns the ConnectionError for a response with an error status, or nil
+// for a successful one.
+func classifyStatus(resp *http.Response) error {
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return &ConnectionError{Kind: ConnectionErrorAuth, Err: fmt.Errorf("endpoint returned %s", resp.Status)}
+	case resp.StatusCode >= 500:
+		return &ConnectionError{Kind: ConnectionErrorServer, Err: fmt.Errorf("endpoint returned %s", resp.Status)}
+	default:
+		return &ConnectionError{Kind: ConnectionErrorResponse, Err: fmt.Errorf("endpoint returned %s", resp.Status)}
+	}
+}
+
+// connectionTestRequest sends a connection test request and reads the response body.
+func connectionTestRequest(ctx context.Context, client *http.Client, method, target string, header http.Header, body io.Reader) (*connectionTestResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, classifyTransportError(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // read-only response body
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, constants.ConnectionTestMaxResponseSize))
+	if err != nil {
+		return nil, classifyTransportError(err)
+	}
+	return &connectionTestResponse{Response: resp, body: data}, nil
+}
+
+// connectionTestResponse is a response to a connection test request with its body read.
+type connectionTestResponse struct {
+	*http.Response
+	body []byte
+}
+
+// connectionTestResult encodes the outcome of a connection test for the cache: empty for
+// success, and the message prefixed with the kind of a ConnectionError.
+func connectionTestResult(err error) string {
+	if err == nil {
+		return ""
+	}
+	var connErr *ConnectionError
+	if errors.As(err, &connErr) {
+		return string(connErr.Kind) + "\x00" + connErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// connectionTestError decodes a result encoded by connectionTestResult.
+func connectionTestError(result string) error {
+	if result == "" {
+		return nil
+	}
+	if kind, message, ok := strings.Cut(result, "\x00"); ok {
+		return &ConnectionError{Kind: ConnectionErrorKind(kind), Err: errors.New(message)}
+	}
+	return errors.New(result)
+}
@@ -0,0 +1,44 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+// contextKey is the type for values stored in a request context by this package.
+type contextKey string
+
+const userIDContextKey contextKey = "user_id"
+
+// WithUserID returns a copy of ctx carrying the authenticated user ID.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// UserIDFromContext returns the authenticated user ID stored in ctx, or an empty string.
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDContextKey).(string)
+	return userID
+}
+
+// stampCreated records the context user as creator and last modifier of a record.
+func stampCreated(ctx context.Context, stamp *model.AuditStamp) {
+	userID := UserIDFromContext(ctx)
+	if userID == "" {
+		return
+	}
+	stamp.CreatedByID = &userID
+	stamp.UpdatedByID = &userID
+}
+
+// stampUpdated records the context user as last modifier of a record.
+// Updates made without an authenticated user (e.g. background jobs) keep the previous stamp.
+func stampUpdated(ctx context.Context, stamp *model.AuditStamp) {
+	userID := UserIDFromContext(ctx)
+	if userID == "" {
+		return
+	}
+	stamp.UpdatedByID = &userID
+}
@@ -0,0 +1,184 @@
+// Package service provides audit stamp tests.
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockResourceRepository is a mock implementation of ResourceRepository.
+type MockResourceRepository struct {
+	mock.Mock
+}
+
+func (m *MockResourceRepository) Create(ctx context.Context, resource *model.Resource) error {
+	args := m.Called(ctx, resource)
+	return args.Error(0)
+}
+
+func (m *MockResourceRepository) GetByID(ctx context.Context, id string) (*model.Resource, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	resource, ok := args.Get(0).(*model.Resource)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return resource, args.Error(1)
+}
+
+func (m *MockResourceRepository) GetByExternalID(ctx context.Context, provider, externalID string) (*model.Resource, error) {
+	args := m.Called(ctx, provider, externalID)
+	resource, ok := args.Get(0).(*model.Resource)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return resource, args.Error(1)
+}
+
+func (m *MockResourceRepository) ExistsByName(ctx context.Context, ownerID, environment, name, excludeID string) (bool, error) {
+	args := m.Called(ctx, ownerID, environment, name, excludeID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockResourceRepository) Update(ctx context.Context, resource *model.Resource) error {
+	args := m.Called(ctx, resource)
+	return args.Error(0)
+}
+
+func (m *MockResourceRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockResourceRepository) List(ctx context.Context, filters repository.ResourceFilters, offset, limit int) ([]*model.Resource, int64, error) {
+	args := m.Called(ctx, filters, offset, limit)
+	resources, ok := args.Get(0).([]*model.Resource)
+	if !ok {
+		return nil, 0, args.Error(2)
+	}
+	total, ok := args.Get(1).(int64)
+	if !ok {
+		return resources, 0, args.Error(2)
+	}
+	return resources, total, args.Error(2)
+}
+
+func (m *MockResourceRepository) Search(ctx context.Context, q string, filters repository.ResourceFilters, offset, limit int) ([]*model.Resource, int64, error) {
+	args := m.Called(ctx, q, filters, offset, limit)
+	resources, ok := args.Get(0).([]*model.Resource)
+	if !ok {
+		return nil, 0, args.Error(2)
+	}
+	total, ok := args.Get(1).(int64)
+	if !ok {
+		return resources, 0, args.Error(2)
+	}
+	return resources, total, args.Error(2)
+}
+
+func TestUserIDFromContext(t *testing.T) {
+	assert.Empty(t, UserIDFromContext(context.Background()))
+	assert.Equal(t, "user-1", UserIDFromContext(WithUserID(context.Background(), "user-1")))
+}
+
+func TestResourceService_AuditStamp(t *testing.T) {
+	t.Run("create sets created_by and updated_by from context user", func(t *testing.T) {
+		repo := new(MockResourceRepository)
+		svc := NewResourceService(repo, nil, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, zap.NewNop())
+		ctx := WithUserID(context.Background(), "creator-id")
+
+		repo.On("ExistsByName", ctx, "owner-id", "dev", "vm-1", "").Return(false, nil)
+		repo.On("Create", ctx, mock.AnythingOfType("*model.Resource")).Return(nil)
+
+		resource, err := svc.Create(ctx, &CreateResourceInput{Name: "vm-1", Type: "vm", Provider: "pve", Environment: "dev", OwnerID: "owner-id"})
+		require.NoError(t, err)
+		require.NotNil(t, resource.CreatedByID)
+		require.NotNil(t, resource.UpdatedByID)
+		assert.Equal(t, "creator-id", *resource.CreatedByID)
+		assert.Equal(t, "creator-id", *resource.UpdatedByID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("update sets updated_by and keeps created_by", func(t *testing.T) {
+		repo := new(MockResourceRepository)
+		svc := NewResourceService(repo, nil, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, zap.NewNop())
+		ctx := WithUserID(context.Background(), "editor-id")
+
+		creator := "creator-id"
+		existing := &model.Resource{
+			BaseModel:  model.BaseModel{ID: "res-1"},
+			AuditStamp: model.AuditStamp{CreatedByID: &creator, UpdatedByID: &creator},
+			Name:       "vm-1",
+		}
+		repo.On("GetByID", ctx, "res-1").Return(existing, nil)
+		repo.On("ExistsByName", ctx, "", "", "vm-2", "res-1").Return(false, nil)
+		repo.On("Update", ctx, existing).Return(nil)
+
+		resource, err := svc.Update(ctx, "res-1", map[string]interface{}{"name": "vm-2"})
+		require.NoError(t, err)
+		assert.Equal(t, "creator-id", *resource.CreatedByID)
+		assert.Equal(t, "editor-id", *resource.UpdatedByID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("update without context user keeps previous stamp", func(t *testing.T) {
+		repo := new(MockResourceRepository)
+		svc := NewResourceService(repo, nil, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, zap.NewNop())
+		ctx := context.Background()
+
+		creator := "creator-id"
+		existing := &model.Resource{
+			BaseModel:  model.BaseModel{ID: "res-1"},
+			AuditStamp: model.AuditStamp{CreatedByID: &creator, UpdatedByID: &creator},
+		}
+		repo.On("GetByID", ctx, "res-1").Return(existing, nil)
+		repo.On("Update", ctx, existing).Return(nil)
+
+		resource, err := svc.Update(ctx, "res-1", map[string]interface{}{"status": "running"})
+		require.NoError(t, err)
+		assert.Equal(t, "creator-id", *resource.UpdatedByID)
+	})
+}
+
+func TestIPAMService_PoolAuditStamp(t *testing.T) {
+	poolRepo := new(MockIPPoolRepository)
+	svc := NewIPAMService(poolRepo, nil, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+
+	createCtx := WithUserID(context.Background(), "creator-id")
+	poolRepo.On("List", createCtx, repository.IPPoolFilters{ZoneID: "zone-1"}, 0, -1).Return([]*model.IPPool{}, int64(0), nil)
+	poolRepo.On("Create", createCtx, mock.AnythingOfType("*model.IPPool")).Return(nil)
+
+	pool, err := svc.CreatePool(createCtx, &CreateIPPoolInput{
+		Name:    "mgmt",
+		CIDR:    "10.0.0.0/24",
+		Gateway: "10.0.0.1",
+		StartIP: "10.0.0.10",
+		EndIP:   "10.0.0.20",
+		ZoneID:  "zone-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "creator-id", *pool.CreatedByID)
+	assert.Equal(t, "creator-id", *pool.UpdatedByID)
+
+	pool.ID = "pool-1"
+	updateCtx := WithUserID(context.Background(), "editor-id")
+	poolRepo.On("GetByID", updateCtx, "pool-1").Return(pool, nil)
+	poolRepo.On("Update", updateCtx, pool).Return(nil)
+
+	name := "management"
+	updated, err := svc.UpdatePool(updateCtx, "pool-1", &UpdateIPPoolInput{Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, "creator-id", *updated.CreatedByID)
+	assert.Equal(t, "editor-id", *updated.UpdatedByID)
+	poolRepo.AssertExpectations(t)
+}
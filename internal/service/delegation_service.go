@@ -0,0 +1,221 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+	"go.uber.org/zap"
+)
+
+// approvePermission is the permission code that grants authority to approve requests.
+const approvePermission = "request:approve"
+
+// adminRoleCode is the code of the role that holds every permission.
+const adminRoleCode = "admin"
+
+// ErrNotAuthorizedToApprove indicates the user can neither approve requests nor act for
+// someone who can.
+var ErrNotAuthorizedToApprove = errors.New("not authorized to approve requests")
+
+// ErrInvalidDelegation indicates a delegation that cannot be created as given.
+var ErrInvalidDelegation = errors.New("invalid delegation")
+
+// ApprovalAuthorizer decides whether a user may approve a resource request.
+type ApprovalAuthorizer interface {
+	// AuthorizeApproval returns the ID of the user whose authority approverID uses through
+	// a delegation, or "" when approverID holds the authority itself. It returns
+	// ErrNotAuthorizedToApprove when neither applies.
+	AuthorizeApproval(ctx context.Context, approverID string, request *model.ResourceRequest) (string, error)
+}
+
+// DelegationService manages approval delegations and checks approval authority.
+type DelegationService interface {
+	ApprovalAuthorizer
+	Create(ctx context.Context, input *CreateDelegationInput) (*model.Delegation, error)
+	ListForUser(ctx context.Context, userID string) ([]*model.Delegation, error)
+	Delete(ctx context.Context, id, userID string) error
+}
+
+// delegationService implements DelegationService.
+type delegationService struct {
+	delegationRepo repository.DelegationRepository
+	userRepo       repository.UserRepository
+	roleRepo       repository.RoleRepository
+	logger         *zap.Logger
+
+	// now returns the current time; replaced in tests.
+	now func() time.Time
+}
+
+// NewDelegationService creates a new delegation service.
+func NewDelegationService(
+	delegationRepo repository.DelegationRepository,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	logger *zap.Logger,
+) DelegationService {
+	return &delegationService{
+		delegationRepo: delegationRepo,
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		logger:         logger,
+		now:            model.Now,
+	}
+}
+
+// CreateDelegationInput represents input for delegation creation.
+type CreateDelegationInput struct {
+	FromUserID string
+	ToUserID   string
+	Scope      string // Environment name; empty means every environment
+	StartsAt   time.Time
+	EndsAt     time.Time
+	Reason     string
+}
+
+// Create delegates FromUserID's approval authority to ToUserID. Only users who can approve
+// requests themselves can delegate, and a delegate cannot pass the authority on.
+func (s *delegationService) Create(ctx context.Context, input *CreateDelegationInput) (*model.Delegation, error) {
+	if input == nil {
+		return nil, errors.New("input cannot be nil")
+	}
+	if input.ToUserID == "" {
+		return nil, fmt.Errorf("%w: delegate is required", ErrInvalidDelegation)
+	}
+	if input.ToUserID == input.FromUserID {
+		return nil, fmt.Errorf("%w: cannot delegate to yourself", ErrInvalidDelegation)
+	}
+	if !input.EndsAt.After(input.StartsAt) {
+		return nil, fmt.Errorf("%w: end must be after start", ErrInvalidDelegation)
+	}
+	if !input.EndsAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: end must be in the future", ErrInvalidDelegation)
+	}
+
+	canApprove, err := s.canApprove(ctx, input.FromUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !canApprove {
+		return nil, ErrNotAuthorizedToApprove
+	}
+	if _, err := s.userRepo.GetByID(ctx, input.ToUserID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: delegate not found", ErrInvalidDelegation)
+		}
+		return nil, err
+	}
+
+	scope := input.Scope
+	if scope == "" {
+		scope = model.DelegationScopeAll
+	}
+	delegation := &model.Delegation{
+		FromUserID: input.FromUserID,
+		ToUserID:   input.ToUserID,
+		Scope:      scope,
+		StartsAt:   input.StartsAt.UTC(),
+		EndsAt:     input.EndsAt.UTC(),
+		Reason:     input.Reason,
+	}
+	if err := s.delegationRepo.Create(ctx, delegation); err != nil {
+		s.logger.Error("failed to create delegation", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("approval authority delegated",
+		zap.String("from_user_id", sanitize.ForLog(delegation.FromUserID)),
+		zap.String("to_user_id", sanitize.ForLog(delegation.ToUserID)),
+		zap.String("scope", sanitize.ForLog(delegation.Scope)),
+	)
+	return s.delegationRepo.GetByID(ctx, delegation.ID)
+}
+
+// ListForUser returns the delegations userID granted or received.
+func (s *delegationService) ListForUser(ctx context.Context, userID string) ([]*model.Delegation, error) {
+	return s.delegationRepo.ListByUser(ctx, userID)
+}
+
+// Delete revokes a delegation. Only the user who granted it can revoke it; to anyone else
+// it does not exist.
+func (s *delegationService) Delete(ctx context.Context, id, userID string) error {
+	delegation, err := s.delegationRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if delegation.FromUserID != userID {
+		return repository.ErrNotFound
+	}
+	return s.delegationRepo.Delete(ctx, id)
+}
+
+// AuthorizeApproval checks approverID's own authority first, then the delegations to
+// approverID that are active now and cover the request's environment. A delegation only
+// counts while the delegator can still approve, so revoking their role also ends it.
+func (s *delegationService) AuthorizeApproval(ctx context.Context, approverID string, request *model.ResourceRequest) (string, error) {
+	canApprove, err := s.canApprove(ctx, approverID)
+	if err != nil {
+		return "", err
+	}
+	if canApprove {
+		return "", nil
+	}
+
+	delegations, err := s.delegationRepo.ListActiveTo(ctx, approverID, s.now())
+	if err != nil {
+		return "", err
+	}
+	for _, delegation := range delegations {
+		if !delegation.Covers(request.Environment, s.now()) {
+			continue
+		}
+		canApprove, err := s.canApprove(ctx, delegation.FromUserID)
+		if err != nil {
+			return "", err
+		}
+		if canApprove {
+			return delegation.FromUserID, nil
+		}
+	}
+	return "", ErrNotAuthorizedToApprove
+}
+
+// canApprove reports whether userID is an enabled user whose roles grant the approve
+// permission. Unknown users cannot approve.
+func (s *delegationService) canApprove(ctx context.Context, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if user.Status == 0 {
+		return false, nil
+	}
+
+	for _, role := range user.Roles {
+		if role.Code == adminRoleCode {
+			return true, nil
+		}
+		permissions, err := s.roleRepo.ListPermissions(ctx, role.ID)
+		if err != nil {
+			return false, err
+		}
+		for _, permission := range permissions {
+			if permission.Code == approvePermission {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
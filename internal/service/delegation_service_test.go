@@ -0,0 +1,288 @@
+// Package service provides approval delegation tests.
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/notification"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryDelegationRepository keeps delegations in memory.
+type memoryDelegationRepository struct {
+	mu          sync.Mutex
+	delegations []*model.Delegation
+}
+
+func (r *memoryDelegationRepository) Create(_ context.Context, delegation *model.Delegation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if delegation.ID == "" {
+		delegation.ID = "delegation-" + delegation.FromUserID + "-" + delegation.ToUserID
+	}
+	r.delegations = append(r.delegations, delegation)
+	return nil
+}
+
+func (r *memoryDelegationRepository) GetByID(_ context.Context, id string) (*model.Delegation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delegation := range r.delegations {
+		if delegation.ID == id {
+			return delegation, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryDelegationRepository) ListByUser(_ context.Context, userID string) ([]*model.Delegation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*model.Delegation
+	for _, delegation := range r.delegations {
+		if delegation.FromUserID == userID || delegation.ToUserID == userID {
+			out = append(out, delegation)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryDelegationRepository) ListActiveTo(_ context.Context, toUserID string, at time.Time) ([]*model.Delegation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*model.Delegation
+	for _, delegation := range r.delegations {
+		if delegation.ToUserID == toUserID && !at.Before(delegation.StartsAt) && at.Before(delegation.EndsAt) {
+			out = append(out, delegation)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryDelegationRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, delegation := range r.delegations {
+		if delegation.ID == id {
+			r.delegations = append(r.delegations[:i], r.delegations[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+var delegationNow = time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+
+// newDelegationTestService returns a delegation service where "lead" holds the operator
+// role and can approve, while "deputy" and "other" only hold the user role.
+func newDelegationTestService(t *testing.T, delegations ...*model.Delegation) *delegationService {
+	t.Helper()
+	operator := model.Role{BaseModel: model.BaseModel{ID: "role-operator"}, Code: "operator"}
+	user := model.Role{BaseModel: model.BaseModel{ID: "role-user"}, Code: "user"}
+
+	userRepo := new(MockUserRepository)
+	for id, role := range map[string]model.Role{"lead": operator, "deputy": user, "other": user} {
+		userRepo.On("GetByID", mock.Anything, id).
+			Return(&model.User{BaseModel: model.BaseModel{ID: id}, Status: 1, Roles: []model.Role{role}}, nil).Maybe()
+	}
+	roleRepo := new(MockRoleRepository)
+	roleRepo.On("ListPermissions", mock.Anything, "role-operator").
+		Return([]*model.Permission{{Code: "request:read"}, {Code: approvePermission}}, nil).Maybe()
+	roleRepo.On("ListPermissions", mock.Anything, "role-user").
+		Return([]*model.Permission{{Code: "request:read"}, {Code: "request:create"}}, nil).Maybe()
+
+	svc, ok := NewDelegationService(&memoryDelegationRepository{delegations: delegations}, userRepo, roleRepo, zap.NewNop()).(*delegationService)
+	require.True(t, ok)
+	svc.now = func() time.Time { return delegationNow }
+	return svc
+}
+
+func TestDelegationService_AuthorizeApproval(t *testing.T) {
+	delegation := func(scope string, start, end time.Duration) *model.Delegation {
+		return &model.Delegation{
+			FromUserID: "lead",
+			ToUserID:   "deputy",
+			Scope:      scope,
+			StartsAt:   delegationNow.Add(start),
+			EndsAt:     delegationNow.Add(end),
+		}
+	}
+	request := &model.ResourceRequest{BaseModel: model.BaseModel{ID: "req-1"}, Environment: "dev", RequesterID: "other"}
+
+	tests := []struct {
+		name           string
+		approverID     string
+		delegations    []*model.Delegation
+		wantOnBehalfOf string
+		wantErr        error
+	}{
+		{name: "own authority", approverID: "lead"},
+		{name: "no authority", approverID: "deputy", wantErr: ErrNotAuthorizedToApprove},
+		{
+			name:           "in-window delegation",
+			approverID:     "deputy",
+			delegations:    []*model.Delegation{delegation(model.DelegationScopeAll, -time.Hour, 24*time.Hour)},
+			wantOnBehalfOf: "lead",
+		},
+		{
+			name:        "expired delegation",
+			approverID:  "deputy",
+			delegations: []*model.Delegation{delegation(model.DelegationScopeAll, -48*time.Hour, -time.Hour)},
+			wantErr:     ErrNotAuthorizedToApprove,
+		},
+		{
+			name:        "delegation not yet started",
+			approverID:  "deputy",
+			delegations: []*model.Delegation{delegation(model.DelegationScopeAll, time.Hour, 24*time.Hour)},
+			wantErr:     ErrNotAuthorizedToApprove,
+		},
+		{
+			name:           "scoped to the request environment",
+			approverID:     "deputy",
+			delegations:    []*model.Delegation{delegation("dev", -time.Hour, time.Hour)},
+			wantOnBehalfOf: "lead",
+		},
+		{
+			name:        "scoped to another environment",
+			approverID:  "deputy",
+			delegations: []*model.Delegation{delegation("production", -time.Hour, time.Hour)},
+			wantErr:     ErrNotAuthorizedToApprove,
+		},
+		{
+			name:       "delegator cannot approve",
+			approverID: "deputy",
+			delegations: []*model.Delegation{{
+				FromUserID: "other",
+				ToUserID:   "deputy",
+				Scope:      model.DelegationScopeAll,
+				StartsAt:   delegationNow.Add(-time.Hour),
+				EndsAt:     delegationNow.Add(time.Hour),
+			}},
+			wantErr: ErrNotAuthorizedToApprove,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newDelegationTestService(t, tt.delegations...)
+
+			onBehalfOf, err := svc.AuthorizeApproval(context.Background(), tt.approverID, request)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOnBehalfOf, onBehalfOf)
+		})
+	}
+}
+
+func TestResourceService_ApproveRequestWithDelegation(t *testing.T) {
+	newRequest := func() *model.ResourceRequest {
+		return &model.ResourceRequest{BaseModel: model.BaseModel{ID: "req-1"}, Status: "pending", Environment: "dev", RequesterID: "other"}
+	}
+	newService := func(t *testing.T, requestRepo *MockResourceRequestRepository, delegation *model.Delegation) *resourceService {
+		t.Helper()
+		svc, ok := NewResourceService(nil, requestRepo, nil, nil, nil, notification.NewService(nil, zap.NewNop()),
+			nil, newDelegationTestService(t, delegation), 0, nil, nil, nil, nil, zap.NewNop()).(*resourceService)
+		require.True(t, ok)
+		svc.provision = func(context.Context, *model.ResourceRequest) error { return nil }
+		return svc
+	}
+
+	t.Run("in-window delegation approves on behalf of the delegator", func(t *testing.T) {
+		request := newRequest()
+		requestRepo := new(MockResourceRequestRepository)
+		requestRepo.On("GetByID", mock.Anything, "req-1").Return(request, nil)
+		requestRepo.On("Update", mock.Anything, request).Return(nil)
+		svc := newService(t, requestRepo, &model.Delegation{
+			FromUserID: "lead",
+			ToUserID:   "deputy",
+			Scope:      model.DelegationScopeAll,
+			StartsAt:   delegationNow.Add(-time.Hour),
+			EndsAt:     delegationNow.Add(7 * 24 * time.Hour),
+		})
+
+		approved, err := svc.ApproveRequest(context.Background(), "req-1", "deputy", "covering for lead")
+		require.NoError(t, err)
+		assert.Equal(t, "approved", approved.Status)
+		require.NotNil(t, approved.ApproverID)
+		assert.Equal(t, "deputy", *approved.ApproverID)
+		require.NotNil(t, approved.ApprovedOnBehalfOfID)
+		assert.Equal(t, "lead", *approved.ApprovedOnBehalfOfID)
+	})
+
+	t.Run("expired delegation does not grant authority", func(t *testing.T) {
+		request := newRequest()
+		requestRepo := new(MockResourceRequestRepository)
+		requestRepo.On("GetByID", mock.Anything, "req-1").Return(request, nil)
+		svc := newService(t, requestRepo, &model.Delegation{
+			FromUserID: "lead",
+			ToUserID:   "deputy",
+			Scope:      model.DelegationScopeAll,
+			StartsAt:   delegationNow.Add(-7 * 24 * time.Hour),
+			EndsAt:     delegationNow.Add(-time.Minute),
+		})
+
+		_, err := svc.ApproveRequest(context.Background(), "req-1", "deputy", "")
+		assert.ErrorIs(t, err, ErrNotAuthorizedToApprove)
+		assert.Equal(t, "pending", request.Status)
+		requestRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDelegationService_Create(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   CreateDelegationInput
+		wantErr error
+	}{
+		{
+			name:  "approver delegates",
+			input: CreateDelegationInput{FromUserID: "lead", ToUserID: "deputy", StartsAt: delegationNow, EndsAt: delegationNow.Add(time.Hour)},
+		},
+		{
+			name:    "delegator cannot approve",
+			input:   CreateDelegationInput{FromUserID: "other", ToUserID: "deputy", StartsAt: delegationNow, EndsAt: delegationNow.Add(time.Hour)},
+			wantErr: ErrNotAuthorizedToApprove,
+		},
+		{
+			name:    "self delegation",
+			input:   CreateDelegationInput{FromUserID: "lead", ToUserID: "lead", StartsAt: delegationNow, EndsAt: delegationNow.Add(time.Hour)},
+			wantErr: ErrInvalidDelegation,
+		},
+		{
+			name:    "end before start",
+			input:   CreateDelegationInput{FromUserID: "lead", ToUserID: "deputy", StartsAt: delegationNow, EndsAt: delegationNow.Add(-time.Hour)},
+			wantErr: ErrInvalidDelegation,
+		},
+		{
+			name:    "already ended",
+			input:   CreateDelegationInput{FromUserID: "lead", ToUserID: "deputy", StartsAt: delegationNow.Add(-2 * time.Hour), EndsAt: delegationNow.Add(-time.Hour)},
+			wantErr: ErrInvalidDelegation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newDelegationTestService(t)
+
+			delegation, err := svc.Create(context.Background(), &tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, model.DelegationScopeAll, delegation.Scope)
+			assert.Equal(t, "deputy", delegation.ToUserID)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+)
+
+// ErrLocalAccountLinkRefused is returned when an external identity's email belongs to a local
+// account that the identity's provider may not take over.
+var ErrLocalAccountLinkRefused = errors.New("email belongs to a local account")
+
+// maxUsernameAttempts bounds how many numbered variants of a username are tried when
+// provisioning a user whose preferred username is taken.
+const maxUsernameAttempts = 20
+
+// maxUsernameLength matches the width of the username column.
+const maxUsernameLength = 64
+
+// externalIdentity is a user as asserted by an SSO or directory provider.
+type externalIdentity struct {
+	Source        model.UserSource
+	ExternalID    string // Stable ID of the user at the provider, such as the OIDC subject
+	Username      string // Preferred username; the local part of Email is used when empty
+	Email         string
+	EmailVerified bool
+	DisplayName   string
+
+	// LinkLocal allows a local account with the same verified email to be linked. Only set it
+	// for providers configured to take over local accounts.
+	LinkLocal bool
+}
+
+// resolveExternalUser returns the platform user for identity. A user already linked to the
+// identity is returned as is. Otherwise a user with the same verified email is linked to it,
+// or a new user with the default role is provisioned.
+func (s *authService) resolveExternalUser(ctx context.Context, identity externalIdentity, defaultRole string) (*model.User, error) {
+	user, err := s.userRepo.GetByExternalID(ctx, identity.Source, identity.ExternalID)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if email == "" {
+		return nil, errors.New("identity has no email address")
+	}
+
+	if identity.EmailVerified {
+		user, err := s.userRepo.GetByEmail(ctx, email)
+		switch {
+		case err == nil:
+			return s.linkExternalUser(ctx, user, identity)
+		case !errors.Is(err, repository.ErrNotFound):
+			return nil, err
+		}
+	}
+
+	return s.provisionExternalUser(ctx, identity, email, defaultRole)
+}
+
+// linkExternalUser records identity on an existing user found by email. A user already
+// linked to a different identity is refused, as is a local account unless the identity may
+// link one. A local administrator is never linked, so a provider cannot take one over.
+func (s *authService) linkExternalUser(ctx context.Context, user *model.User, identity externalIdentity) (*model.User, error) {
+	if user.ExternalID == identity.ExternalID {
+		return user, nil
+	}
+	if user.ExternalID != "" {
+		return nil, fmt.Errorf("user %s is linked to another identity", user.Username)
+	}
+	if user.Source == model.UserSourceLocal {
+		if !identity.LinkLocal {
+			return nil, fmt.Errorf("%w: %s", ErrLocalAccountLinkRefused, user.Username)
+		}
+		for _, role := range user.Roles {
+			if role.Code == adminRoleCode {
+				return nil, fmt.Errorf("%w: %s is an administrator", ErrLocalAccountLinkRefused, user.Username)
+			}
+		}
+	}
+	user.ExternalID = identity.ExternalID
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to link user: %w", err)
+	}
+	return user, nil
+}
+
+// provisionExternalUser creates a user for identity with the default role and no password.
+func (s *authService) provisionExternalUser(ctx context.Context, identity externalIdentity, email, defaultRole string) (*model.User, error) {
+	role, err := s.roleRepo.GetByCode(ctx, defaultRole)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load default role %q: %w", defaultRole, err)
+	}
+
+	preferred := identity.Username
+	if preferred == "" {
+		preferred, _, _ = strings.Cut(email, "@")
+	}
+	username, err := s.freeUsername(ctx, preferred)
+	if err != nil {
+		return nil, err
+	}
+
+	displayName := identity.DisplayName
+	if displayName == "" {
+		displayName = username
+	}
+	user := &model.User{
+		Username:    username,
+		Email:       email,
+		DisplayName: displayName,
+		Source:      identity.Source,
+		ExternalID:  identity.ExternalID,
+		Status:      1,
+		Roles:       []model.Role{*role},
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to provision user: %w", err)
+	}
+	return user, nil
+}
+
+// freeUsername turns preferred into a valid username and, when it is taken, appends the
+// first free number.
+func (s *authService) freeUsername(ctx context.Context, preferred string) (string, error) {
+	base := normalizeUsername(preferred)
+	for attempt := 1; attempt <= maxUsernameAttempts; attempt++ {
+		candidate := base
+		if attempt > 1 {
+			suffix := fmt.Sprintf("-%d", attempt)
+			candidate = base[:min(len(base), maxUsernameLength-len(suffix))] + suffix
+		}
+		_, err := s.userRepo.GetByUsername(ctx, candidate)
+		if errors.Is(err, repository.ErrNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("no free username based on %q", base)
+}
+
+// normalizeUsername lowercases name and replaces characters other than letters, digits,
+// dots, underscores and hyphens with hyphens.
+func normalizeUsername(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	username := strings.Trim(b.String(), "-.")
+	if len(username) > maxUsernameLength {
+		username = username[:maxUsernameLength]
+	}
+	if len(username) < 3 {
+		username = strings.TrimSuffix("user-"+username, "-")
+	}
+	return username
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+	"go.uber.org/zap"
+)
+
+// Commit checkout errors.
+var (
+	ErrInvalidCommitSHA = errors.New("invalid commit SHA")
+	ErrCommitNotFound   = errors.New("commit not found")
+)
+
+// CommitNotFoundError reports a commit that does not exist in a checkout. It unwraps to
+// ErrCommitNotFound.
+type CommitNotFoundError struct {
+	SHA string
+}
+
+// Error implements the error interface.
+func (e *CommitNotFoundError) Error() string {
+	return fmt.Sprintf("commit %s not found in repository", e.SHA)
+}
+
+// Unwrap returns ErrCommitNotFound so callers can match with errors.Is.
+func (e *CommitNotFoundError) Unwrap() error {
+	return ErrCommitNotFound
+}
+
+// commitSHAPattern matches a full or abbreviated SHA-1 or SHA-256 object name. Only hex
+// digits are allowed, so a SHA can never be read as an option or a revision expression.
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+
+// CheckoutCommit checks out the commit sha in the checkout at repoPath, leaving HEAD
+// detached. It returns a *CommitNotFoundError when the checkout has no such commit.
+func (s *gitService) CheckoutCommit(ctx context.Context, repoPath, sha string) error {
+	if !commitSHAPattern.MatchString(sha) {
+		return fmt.Errorf("%w: %q", ErrInvalidCommitSHA, sanitize.ForLog(sha))
+	}
+
+	if _, err := s.git(ctx, repoPath, "cat-file", "-e", sha+"^{commit}"); err != nil {
+		return &CommitNotFoundError{SHA: sha}
+	}
+
+	output, err := s.git(ctx, repoPath, "checkout", "--quiet", "--detach", sha)
+	if err != nil {
+		s.logger.Error("git checkout failed",
+			zap.String("path", sanitize.Path(repoPath)),
+			zap.String("output", sanitize.CommandOutput(output)),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to check out commit %s: %s", sha, sanitize.CommandOutput(output))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Package service provides commit checkout and node config diff tests.
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// commitTestFile writes content to name in the checkout at dir, commits it and returns the
+// commit SHA.
+func commitTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	writeTestFile(t, filepath.Join(dir, name), content)
+	runTestGit(t, dir, "add", name)
+	runTestGit(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-m", "update "+name)
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	require.NoError(t, err)
+	return strings.TrimSpace(string(output))
+}
+
+func TestGitService_CheckoutCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repoPath := t.TempDir()
+	runTestGit(t, repoPath, "init", "-b", "main")
+	first := commitTestFile(t, repoPath, "terragrunt.hcl", "cores = 2\n")
+	commitTestFile(t, repoPath, "terragrunt.hcl", "cores = 4\n")
+	svc := &gitService{logger: zap.NewNop()}
+
+	t.Run("checks out an earlier commit", func(t *testing.T) {
+		require.NoError(t, svc.CheckoutCommit(context.Background(), repoPath, first))
+		assert.Equal(t, "cores = 2\n", readTestFile(t, filepath.Join(repoPath, "terragrunt.hcl")))
+
+		require.NoError(t, svc.CheckoutCommit(context.Background(), repoPath, first[:12]), "abbreviated SHAs are accepted")
+	})
+
+	t.Run("unknown commit", func(t *testing.T) {
+		missing := strings.Repeat("0", 40)
+		err := svc.CheckoutCommit(context.Background(), repoPath, missing)
+		require.ErrorIs(t, err, ErrCommitNotFound)
+		var notFound *CommitNotFoundError
+		require.True(t, errors.As(err, &notFound))
+		assert.Equal(t, missing, notFound.SHA)
+	})
+
+	t.Run("rejects anything but a hex SHA", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: scriptedGitRunner(nil, &calls)}
+		for _, sha := range []string{"", "abc12", "--orphan=x", "HEAD~1", "main", first + "0" + first, "1234567^{tree}"} {
+			assert.ErrorIs(t, svc.CheckoutCommit(context.Background(), repoPath, sha), ErrInvalidCommitSHA, sha)
+		}
+		assert.Empty(t, calls)
+	})
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Run("equal files have no diff", func(t *testing.T) {
+		diff, err := unifiedDiff("a/f", "b/f", "x\n", "x\n")
+		require.NoError(t, err)
+		assert.Empty(t, diff)
+	})
+
+	t.Run("changes are grouped into hunks with context", func(t *testing.T) {
+		from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+		to := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+		diff, err := unifiedDiff("a/f", "b/f", from, to)
+		require.NoError(t, err)
+		assert.Equal(t, `--- a/f
++++ b/f
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`, diff)
+	})
+
+	t.Run("new file", func(t *testing.T) {
+		diff, err := unifiedDiff("a/f", "b/f", "", "a\nb\n")
+		require.NoError(t, err)
+		assert.Equal(t, "--- a/f\n+++ b/f\n@@ -0,0 +1,2 @@\n+a\n+b\n", diff)
+	})
+}
+
+func TestGitService_DiffNodeConfig(t *testing.T) {
+	repoURL, _ := setupSSHRemote(t)
+	bare := strings.TrimPrefix(repoURL, "ssh://git@git.example.test")
+	work := filepath.Join(t.TempDir(), "work")
+	runTestGit(t, filepath.Dir(work), "clone", bare, work)
+
+	configFile := "live/proxmox-ve/instance/vm/web-001/terragrunt.hcl"
+	pending := commitTestFile(t, work, configFile, "inputs = {\n  cores = 2\n  memory = 2048\n}\n")
+	deployed := commitTestFile(t, work, configFile, "inputs = {\n  cores = 4\n  memory = 2048\n}\n")
+	runTestGit(t, work, "push", "origin", "main")
+
+	storageRepo := &model.GitRepository{
+		BaseModel: model.BaseModel{ID: "storage-1"},
+		URL:       repoURL, Branch: "main", BasePath: "live",
+		AuthType: model.GitAuthTypeSSHKey, SSHKey: testSSHKey,
+	}
+	config := &model.NodeConfig{
+		BaseModel:        model.BaseModel{ID: "cfg-1"},
+		Path:             "proxmox-ve/instance/vm/web-001",
+		StorageRepo:      storageRepo,
+		PendingCommitSHA: pending,
+		CommitSHA:        deployed,
+	}
+	workDir := t.TempDir()
+	svc := &gitService{logger: zap.NewNop(), workDir: workDir, nodeConfigRepo: &statusNodeConfigRepository{config: config}}
+
+	diff, err := svc.DiffNodeConfig(context.Background(), "cfg-1")
+	require.NoError(t, err)
+	assert.Equal(t, configFile, diff.Path)
+	assert.Equal(t, pending, diff.FromSHA)
+	assert.Equal(t, deployed, diff.ToSHA)
+	assert.Equal(t, "--- a/"+configFile+"\n+++ b/"+configFile+"\n@@ -1,4 +1,4 @@\n inputs = {\n-  cores = 2\n+  cores = 4\n   memory = 2048\n }\n", diff.Diff)
+	entries, err := os.ReadDir(filepath.Join(workDir, storageRepo.ID))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the clone is removed after the diff")
+
+	t.Run("deployed commit missing from the repository", func(t *testing.T) {
+		config.CommitSHA = strings.Repeat("a", 40)
+		_, err := svc.DiffNodeConfig(context.Background(), "cfg-1")
+		assert.ErrorIs(t, err, ErrCommitNotFound)
+	})
+
+	t.Run("config without both commits", func(t *testing.T) {
+		config.CommitSHA = ""
+		_, err := svc.DiffNodeConfig(context.Background(), "cfg-1")
+		assert.ErrorIs(t, err, ErrNodeConfigNotCommitted)
+	})
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+)
+
+// ErrGitCloneTimeout indicates a git clone was stopped because it ran past the clone
+// timeout, as opposed to failing on authentication or the network.
+var ErrGitCloneTimeout = errors.New("git clone timed out")
+
+// GitCloneTimeoutError reports a clone that ran out of time, with the last progress line
+// git printed so the caller can tell a slow transfer from a stalled one. It unwraps to
+// ErrGitCloneTimeout and context.DeadlineExceeded.
+type GitCloneTimeoutError struct {
+	Timeout      time.Duration
+	LastProgress string
+}
+
+// Error implements the error interface.
+func (e *GitCloneTimeoutError) Error() string {
+	if e.LastProgress == "" {
+		return fmt.Sprintf("git clone timed out after %s before reporting any progress", e.Timeout)
+	}
+	return fmt.Sprintf("git clone timed out after %s; last progress: %s", e.Timeout, e.LastProgress)
+}
+
+// Unwrap returns ErrGitCloneTimeout and context.DeadlineExceeded so callers can match
+// either with errors.Is.
+func (e *GitCloneTimeoutError) Unwrap() []error {
+	return []error{ErrGitCloneTimeout, context.DeadlineExceeded}
+}
+
+// CloneOptions tunes how CloneRepository clones. The zero value clones every branch with
+// full history within the service's clone timeout.
+type CloneOptions struct {
+	Depth        int           // Truncates history to this many commits when positive
+	Timeout      time.Duration // Replaces the service's clone timeout when positive
+	SingleBranch bool          // Fetches only the repository's configured branch
+}
+
+// defaultCloneOptions apply when CloneRepository is called without options: the full
+// history of the configured branch.
+var defaultCloneOptions = CloneOptions{SingleBranch: true}
+
+// args returns the git clone flags for o, after the branch selection.
+func (o CloneOptions) args() []string {
+	var args []string
+	if o.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+	if o.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(o.Depth))
+	}
+	return args
+}
+
+// cloneGit runs git clone with args against repo's remote, reporting progress and bounded
+// by the clone timeout. Its output has progress updates collapsed to their final state.
+// When the timeout stops the clone, the error is a *GitCloneTimeoutError; cancellation or
+// a deadline of ctx itself is returned as git's failure.
+func (s *gitService) cloneGit(ctx context.Context, repo *model.GitRepository, args ...string) (string, error) {
+	return s.cloneGitWithin(ctx, repo, 0, args...)
+}
+
+// cloneGitWithin is cloneGit with timeout in place of the service's clone timeout when
+// positive.
+func (s *gitService) cloneGitWithin(ctx context.Context, repo *model.GitRepository, timeout time.Duration, args ...string) (string, error) {
+	if timeout <= 0 {
+		timeout = s.cloneTimeout
+	}
+	if timeout <= 0 {
+		timeout = constants.DefaultGitCloneTimeout
+	}
+	cloneCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output, err := s.remoteGit(cloneCtx, repo, "", append([]string{"clone", "--progress"}, args...)...)
+	output = collapseProgress(output)
+	if err != nil && ctx.Err() == nil && errors.Is(cloneCtx.Err(), context.DeadlineExceeded) {
+		return output, &GitCloneTimeoutError{Timeout: timeout, LastProgress: sanitize.ForLog(lastLine(output))}
+	}
+	return output, err
+}
+
+// cloneFailure returns the error for a failed clone: a timeout as itself, anything else
+// with git's output, which carries the authentication or network error git reported.
+func cloneFailure(message, output string, err error) error {
+	if errors.Is(err, ErrGitCloneTimeout) {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+	return fmt.Errorf("%s: %s", message, sanitize.CommandOutput(output))
+}
+
+// collapseProgress keeps only the final state of each output line. Git redraws progress
+// in place by ending updates with a carriage return, so a long clone prints thousands of
+// them on what is displayed as one line.
+func collapseProgress(output string) string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if cr := strings.LastIndexByte(line, '\r'); cr >= 0 {
+			line = line[cr+1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lastLine returns the last non-blank line of output, trimmed.
+func lastLine(output string) string {
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+// Package service provides git clone timeout tests.
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// slowCloneRunner prints progress the way git does, redrawing each line with a carriage
+// return, then stalls until the command is killed.
+func slowCloneRunner(calls *[]string) gitRunner {
+	return func(ctx context.Context, _ string, _ []string, args ...string) (string, error) {
+		*calls = append(*calls, args...)
+		output := "Cloning into '/tmp/modules'...\n" +
+			"remote: Enumerating objects: 90210, done.\n" +
+			"Receiving objects:  12% (10825/90210), 110.00 MiB | 2.10 MiB/s\r" +
+			"Receiving objects:  41% (36987/90210), 402.00 MiB | 2.05 MiB/s\r"
+		<-ctx.Done()
+		return output, errors.New("signal: killed")
+	}
+}
+
+func TestGitService_CloneTimeout(t *testing.T) {
+	repo := &model.GitRepository{Name: "modules", URL: "https://git.example.com/lab/modules.git", Branch: "main"}
+
+	t.Run("slow clone times out with its last progress", func(t *testing.T) {
+		var args []string
+		svc := &gitService{logger: zap.NewNop(), runGit: slowCloneRunner(&args), cloneTimeout: 20 * time.Millisecond}
+
+		err := svc.CloneRepository(context.Background(), repo, filepath.Join(t.TempDir(), "clone"))
+		require.ErrorIs(t, err, ErrGitCloneTimeout)
+		var timeoutErr *GitCloneTimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		assert.Equal(t, 20*time.Millisecond, timeoutErr.Timeout)
+		assert.Equal(t, "Receiving objects:  41% (36987/90210), 402.00 MiB | 2.05 MiB/s", timeoutErr.LastProgress)
+		assert.Contains(t, err.Error(), "timed out after 20ms")
+		assert.Contains(t, args, "--progress")
+	})
+
+	t.Run("caller cancellation is not reported as a timeout", func(t *testing.T) {
+		var args []string
+		svc := &gitService{logger: zap.NewNop(), runGit: slowCloneRunner(&args), cloneTimeout: time.Hour}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := svc.CloneRepository(ctx, repo, filepath.Join(t.TempDir(), "clone"))
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrGitCloneTimeout)
+	})
+
+	t.Run("authentication failure keeps git's message", func(t *testing.T) {
+		svc := &gitService{logger: zap.NewNop(), cloneTimeout: time.Hour, runGit: func(context.Context, string, []string, ...string) (string, error) {
+			return "Cloning into '/tmp/modules'...\nremote: HTTP Basic: Access denied\nfatal: Authentication failed for 'https://git.example.com/lab/modules.git/'\n", errors.New("exit status 128")
+		}}
+
+		err := svc.CloneRepository(context.Background(), repo, filepath.Join(t.TempDir(), "clone"))
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrGitCloneTimeout)
+		assert.Contains(t, err.Error(), "Authentication failed")
+	})
+}
+
+func TestGitService_CloneOptions(t *testing.T) {
+	repo := &model.GitRepository{Name: "modules", URL: "https://git.example.com/lab/modules.git", Branch: "main"}
+
+	t.Run("default is a full clone of the branch", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: scriptedGitRunner(nil, &calls)}
+
+		require.NoError(t, svc.CloneRepository(context.Background(), repo, filepath.Join(t.TempDir(), "clone")))
+		require.Len(t, calls, 1)
+		assert.Contains(t, calls[0], "--single-branch")
+		assert.NotContains(t, calls[0], "--depth")
+	})
+
+	t.Run("depth is passed to git", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: scriptedGitRunner(nil, &calls)}
+
+		opts := CloneOptions{Depth: 1, SingleBranch: true}
+		require.NoError(t, svc.CloneRepository(context.Background(), repo, filepath.Join(t.TempDir(), "clone"), opts))
+		require.Len(t, calls, 1)
+		assert.Contains(t, calls[0], "--single-branch --depth 1 ")
+	})
+
+	t.Run("a short timeout stops the clone with a deadline error", func(t *testing.T) {
+		var args []string
+		svc := &gitService{logger: zap.NewNop(), runGit: slowCloneRunner(&args), cloneTimeout: time.Hour}
+
+		start := time.Now()
+		err := svc.CloneRepository(context.Background(), repo, filepath.Join(t.TempDir(), "clone"), CloneOptions{Timeout: 20 * time.Millisecond})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorIs(t, err, ErrGitCloneTimeout)
+		assert.Less(t, time.Since(start), time.Minute)
+		assert.NotContains(t, args, "--single-branch", "options replace the defaults")
+	})
+}
+
+func TestCollapseProgress(t *testing.T) {
+	output := "Cloning into 'x'...\nCounting:  50% (1/2)\rCounting: 100% (2/2), done.\r\nReceiving:  10%\rReceiving:  20%\r"
+	collapsed := collapseProgress(output)
+	assert.Equal(t, "Cloning into 'x'...\nCounting: 100% (2/2), done.\nReceiving:  20%", collapsed)
+	assert.Equal(t, "Receiving:  20%", lastLine(collapsed))
+	assert.Empty(t, lastLine("\n  \n"))
+}
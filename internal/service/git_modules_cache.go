@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+	"go.uber.org/zap"
+)
+
+// modulesCacheRoot is the directory, under the work directory, of the cached clones that
+// module discovery scans.
+const modulesCacheRoot = "modules"
+
+// modulesCacheDir returns the directory of the cached clone of the modules repository with
+// ID repoID.
+func (s *gitService) modulesCacheDir(repoID string) string {
+	return filepath.Join(s.workDir, modulesCacheRoot, repoID)
+}
+
+// cachedCloneMatches reports whether the clone at repoPath was made from repo's current URL
+// and branch. A clone git cannot read is reported as not matching, so it is cloned again.
+func (s *gitService) cachedCloneMatches(ctx context.Context, repo *model.GitRepository, repoPath string) bool {
+	branch, err := sanitize.ValidateGitBranch(repo.Branch)
+	if err != nil {
+		return false
+	}
+	url, urlErr := s.git(ctx, repoPath, "remote", "get-url", "origin")
+	head, headErr := s.git(ctx, repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	if urlErr != nil || headErr != nil {
+		return false
+	}
+	if strings.TrimSpace(url) == repo.URL && strings.TrimSpace(head) == branch {
+		return true
+	}
+	s.logger.Info("modules cache is from another URL or branch, cloning again", zap.String("repo_id", repo.ID))
+	return false
+}
+
+// touchModulesCache marks the clone at repoPath as used now; idle clones are told apart by
+// the modification time of their directory.
+func touchModulesCache(repoPath string) {
+	now := time.Now()
+	_ = os.Chtimes(repoPath, now, now) //nolint:errcheck // an unmarked clone is only removed sooner
+}
+
+// removeModulesCache removes the cached clone of the modules repository with ID repoID, if
+// there is one, waiting for any scan using it to finish.
+func (s *gitService) removeModulesCache(repoID string) bool {
+	repoPath := s.modulesCacheDir(repoID)
+	unlock := s.repoLocks.lock(repoPath)
+	defer unlock()
+
+	if _, err := os.Stat(repoPath); err != nil {
+		return false
+	}
+	if err := os.RemoveAll(repoPath); err != nil {
+		s.logger.Warn("failed to remove modules cache", zap.String("repo_id", repoID), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// cachedModuleRepoIDs returns the IDs of the modules repositories that have a cached clone.
+func (s *gitService) cachedModuleRepoIDs() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.workDir, modulesCacheRoot))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
+		}
+	}
+	return ids, nil
+}
+
+// ClearModulesCache removes every cached modules clone, so the next scan clones afresh, and
+// returns how many were removed.
+func (s *gitService) ClearModulesCache(_ context.Context) (int, error) {
+	ids, err := s.cachedModuleRepoIDs()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, id := range ids {
+		if s.removeModulesCache(id) {
+			removed++
+		}
+	}
+	s.logger.Info("modules cache cleared", zap.Int("removed", removed))
+	return removed, nil
+}
+
+// sweepModulesCache removes the cached clones of modules repositories that no longer exist or
+// are no longer modules repositories, and those unused for longer than the idle timeout as of
+// now. It returns how many were removed.
+func (s *gitService) sweepModulesCache(ctx context.Context, now time.Time) int {
+	ids, err := s.cachedModuleRepoIDs()
+	if err != nil {
+		s.logger.Warn("failed to list modules cache", zap.Error(err))
+		return 0
+	}
+	removed := 0
+	for _, id := range ids {
+		if s.modulesCacheStale(ctx, id, now) && s.removeModulesCache(id) {
+			removed++
+		}
+	}
+	return removed
+}
+
+// modulesCacheStale reports whether the cached clone of the repository with ID repoID should
+// be removed as of now.
+func (s *gitService) modulesCacheStale(ctx context.Context, repoID string, now time.Time) bool {
+	repo, err := s.gitRepoRepo.GetByID(ctx, repoID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return true
+	}
+	if err != nil {
+		s.logger.Warn("failed to check modules cache", zap.String("repo_id", repoID), zap.Error(err))
+		return false
+	}
+	if repo.Type != model.GitRepoTypeModules {
+		return true
+	}
+	if s.modulesCacheIdle <= 0 {
+		return false
+	}
+	info, err := os.Stat(s.modulesCacheDir(repoID))
+	return err == nil && now.Sub(info.ModTime()) > s.modulesCacheIdle
+}
+
+// cleanupModulesCache sweeps the modules cache periodically for as long as the process runs.
+func (s *gitService) cleanupModulesCache() {
+	ticker := time.NewTicker(constants.ModulesCacheSweepInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if removed := s.sweepModulesCache(context.Background(), now); removed > 0 {
+			s.logger.Info("removed stale modules cache", zap.Int("removed", removed))
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package service provides modules cache tests.
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// cachingGitRunner clones by creating an empty checkout and remembering the URL and branch
+// it came from, which remote get-url and rev-parse then report. The URL of each clone is
+// recorded in clones.
+func cachingGitRunner(t *testing.T, clones *[]string) gitRunner {
+	t.Helper()
+	sources := map[string][2]string{}
+	return func(_ context.Context, dir string, _ []string, args ...string) (string, error) {
+		switch strings.Join(args[:min(len(args), 2)], " ") {
+		case "clone --progress":
+			target, url := args[len(args)-1], args[len(args)-2]
+			require.NoError(t, os.MkdirAll(filepath.Join(target, ".git"), 0o750))
+			sources[target] = [2]string{url, args[3]} // --branch <name>
+			*clones = append(*clones, url)
+			return "", nil
+		case "remote get-url":
+			return sources[dir][0] + "\n", nil
+		case "rev-parse --abbrev-ref":
+			return sources[dir][1] + "\n", nil
+		case "pull":
+			return "", nil
+		}
+		t.Fatalf("unexpected git %s", strings.Join(args, " "))
+		return "", nil
+	}
+}
+
+func newModulesCacheService(t *testing.T, clones *[]string) (*gitService, *stubGitRepoRepository) {
+	t.Helper()
+	repos := &stubGitRepoRepository{repos: map[string]*model.GitRepository{
+		"modules-1": {
+			BaseModel: model.BaseModel{ID: "modules-1"}, Type: model.GitRepoTypeModules,
+			URL: "https://git.example.com/lab/modules.git", Branch: "main", IsDefault: true,
+		},
+	}}
+	svc := &gitService{logger: zap.NewNop(), gitRepoRepo: repos, workDir: t.TempDir(), runGit: cachingGitRunner(t, clones)}
+	return svc, repos
+}
+
+func TestGitService_ModulesCacheFollowsRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("changed URL or branch is cloned again on the next scan", func(t *testing.T) {
+		var clones []string
+		svc, repos := newModulesCacheService(t, &clones)
+
+		_, err := svc.ListModulesFromGit(ctx)
+		require.NoError(t, err)
+		_, err = svc.ListModulesFromGit(ctx)
+		require.NoError(t, err)
+		assert.Len(t, clones, 1, "an up-to-date clone is reused")
+
+		repos.repos["modules-1"].URL = "https://git.example.com/lab/modules-v2.git"
+		_, err = svc.ListModulesFromGit(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://git.example.com/lab/modules.git", "https://git.example.com/lab/modules-v2.git"}, clones)
+
+		repos.repos["modules-1"].Branch = "release"
+		_, err = svc.ListModulesFromGit(ctx)
+		require.NoError(t, err)
+		assert.Len(t, clones, 3)
+	})
+
+	t.Run("updating the URL removes the clone", func(t *testing.T) {
+		var clones []string
+		svc, _ := newModulesCacheService(t, &clones)
+		_, err := svc.ListModulesFromGit(ctx)
+		require.NoError(t, err)
+
+		description := "shared modules"
+		_, err = svc.UpdateRepository(ctx, "modules-1", &UpdateGitRepoInput{Description: &description})
+		require.NoError(t, err)
+		assert.DirExists(t, svc.modulesCacheDir("modules-1"), "other changes keep the clone")
+
+		url := "https://git.example.com/lab/modules-v2.git"
+		_, err = svc.UpdateRepository(ctx, "modules-1", &UpdateGitRepoInput{URL: &url})
+		require.NoError(t, err)
+		assert.NoDirExists(t, svc.modulesCacheDir("modules-1"))
+	})
+
+	t.Run("clear cache", func(t *testing.T) {
+		var clones []string
+		svc, _ := newModulesCacheService(t, &clones)
+		removed, err := svc.ClearModulesCache(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, removed, "nothing is cached yet")
+
+		_, err = svc.ListModulesFromGit(ctx)
+		require.NoError(t, err)
+		removed, err = svc.ClearModulesCache(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, removed)
+
+		_, err = svc.ListModulesFromGit(ctx)
+		require.NoError(t, err)
+		assert.Len(t, clones, 2)
+	})
+}
+
+func TestGitService_SweepModulesCache(t *testing.T) {
+	ctx := context.Background()
+	var clones []string
+	svc, repos := newModulesCacheService(t, &clones)
+	svc.modulesCacheIdle = time.Hour
+	_, err := svc.ListModulesFromGit(ctx)
+	require.NoError(t, err)
+
+	// A clone of a repository that was deleted is removed whatever its age
+	orphan := svc.modulesCacheDir("modules-deleted")
+	require.NoError(t, os.MkdirAll(filepath.Join(orphan, ".git"), 0o750))
+
+	assert.Equal(t, 1, svc.sweepModulesCache(ctx, time.Now()))
+	assert.NoDirExists(t, orphan)
+	assert.DirExists(t, svc.modulesCacheDir("modules-1"), "a recently used clone is kept")
+
+	assert.Equal(t, 1, svc.sweepModulesCache(ctx, time.Now().Add(2*time.Hour)))
+	assert.NoDirExists(t, svc.modulesCacheDir("modules-1"), "an idle clone is removed")
+
+	t.Run("idle clones are kept without a timeout", func(t *testing.T) {
+		svc.modulesCacheIdle = 0
+		_, err := svc.ListModulesFromGit(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, svc.sweepModulesCache(ctx, time.Now().Add(365*24*time.Hour)))
+
+		repos.repos["modules-1"].Type = model.GitRepoTypeStorage
+		assert.Equal(t, 1, svc.sweepModulesCache(ctx, time.Now()), "no longer a modules repository")
+	})
+}
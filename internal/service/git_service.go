@@ -4,6 +4,7 @@ package service
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,9 +15,13 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/events"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/secrets"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
 	"go.uber.org/zap"
 )
 
@@ -31,39 +36,99 @@ type GitService interface {
 	// Repository management
 	ListRepositories(ctx context.Context, page, pageSize int) ([]model.GitRepository, int64, error)
 	GetRepository(ctx context.Context, id string) (*model.GitRepository, error)
-	GetDefaultRepository(ctx context.Context, repoType model.GitRepoType) (*model.GitRepository, error)
+	GetDefaultRepository(ctx context.Context, repoType model.GitRepoType, environment string) (*model.GitRepository, error)
 	CreateRepository(ctx context.Context, input *CreateGitRepoInput) (*model.GitRepository, error)
 	UpdateRepository(ctx context.Context, id string, input *UpdateGitRepoInput) (*model.GitRepository, error)
 	DeleteRepository(ctx context.Context, id string) error
 	TestConnection(ctx context.Context, id string) error
 	TestConnectionDirect(ctx context.Context, input *TestConnectionInput) error
+	ValidateStorageRepo(ctx context.Context, id string) (*StorageRepoValidation, error)
 
 	// Node config management
 	CreateNodeConfig(ctx context.Context, request *model.ResourceRequest) (*model.NodeConfig, error)
+	PreviewNodeConfig(ctx context.Context, request *model.ResourceRequest) (*NodeConfigPreview, error)
 	UpdateNodeConfigStatus(ctx context.Context, configID string, status model.NodeConfigStatus, log string) error
 	CommitNodeConfig(ctx context.Context, configID string, message string) (string, error)
 	GetNodeConfig(ctx context.Context, id string) (*model.NodeConfig, error)
 	GetNodeConfigByRequest(ctx context.Context, requestID string) (*model.NodeConfig, error)
 	ListNodeConfigs(ctx context.Context, repoID string, page, pageSize int) ([]model.NodeConfig, int64, error)
+	RetryFailedNodeConfigs(ctx context.Context, filter NodeConfigRetryFilter) (*NodeConfigRetrySummary, error)
+	DiffNodeConfig(ctx context.Context, id string) (*NodeConfigDiff, error)
+	ListNodeConfigHistory(ctx context.Context, configID string, page, pageSize int) ([]NodeConfigCommit, int64, error)
 
 	// Git operations
-	CloneRepository(ctx context.Context, repo *model.GitRepository, targetPath string) error
-	PullChanges(ctx context.Context, repoPath string) error
-	CommitAndPush(ctx context.Context, repoPath string, files []string, message string) (string, error)
+	CloneRepository(ctx context.Context, repo *model.GitRepository, targetPath string, opts ...CloneOptions) error
+	PullChanges(ctx context.Context, repo *model.GitRepository, repoPath string) error
+	CommitAndPush(ctx context.Context, repo *model.GitRepository, repoPath string, files []string, message string) (string, error)
+	CheckoutCommit(ctx context.Context, repoPath, sha string) error
 
 	// Module operations
 	ListModulesFromGit(ctx context.Context) ([]GitModule, error)
 	SyncModulesFromGit(ctx context.Context) ([]GitModule, error)
+	ClearModulesCache(ctx context.Context) (int, error)
+
+	// Webhooks
+	HandleWebhook(ctx context.Context, repoID string, delivery *GitWebhookDelivery) (*GitWebhookResult, error)
+}
+
+// NodeConfigPreview is the node config a request would be given, rendered without saving
+// or committing anything.
+type NodeConfigPreview struct {
+	Name             string `json:"name"`
+	Path             string `json:"path"`
+	StorageRepoID    string `json:"storage_repo_id,omitempty"` // Empty when no default storage repo is configured
+	TerragruntConfig string `json:"terragrunt_config"`
+}
+
+// NodeConfigRetryFilter selects the failed node configs to retry.
+type NodeConfigRetryFilter struct {
+	StorageRepoID string
+	Provider      string
+	FailedFor     time.Duration // Only configs failed at least this long; zero for any
+	Limit         int           // Capped at constants.MaxNodeConfigRetryBatch
+}
+
+// NodeConfigRetrySummary reports the outcome of a bulk node config retry.
+type NodeConfigRetrySummary struct {
+	Matched int               `json:"matched"`
+	Retried []string          `json:"retried"`
+	Skipped []string          `json:"skipped"`          // No longer failed when the retry was attempted
+	Errors  map[string]string `json:"errors,omitempty"` // Config ID to error for configs that could not be reset
 }
 
 // GitModule represents a Terraform module discovered from a git repository.
 type GitModule struct {
-	Name        string   `json:"name"`
-	Path        string   `json:"path"`
-	Description string   `json:"description,omitempty"`
-	Source      string   `json:"source"`
-	Variables   []string `json:"variables,omitempty"`
-	Outputs     []string `json:"outputs,omitempty"`
+	Name        string           `json:"name"`
+	Path        string           `json:"path"`
+	Description string           `json:"description,omitempty"`
+	Source      string           `json:"source"`
+	Variables   []ModuleVariable `json:"variables,omitempty"`
+	Outputs     []string         `json:"outputs,omitempty"`
+	// ParseWarnings lists the module's files that were left out because they could not be
+	// read or parsed.
+	ParseWarnings []model.ModuleParseWarning `json:"parse_warnings,omitempty"`
+
+	// Metadata from the README front-matter
+	DisplayName string   `json:"display_name,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Icon        string   `json:"icon,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// applyMetadata copies the README metadata onto a stored module.
+func (m *GitModule) applyMetadata(module *model.TerraformModule) {
+	module.DisplayName = m.DisplayName
+	module.Category = m.Category
+	module.Icon = m.Icon
+	module.Tags = strings.Join(m.Tags, ",")
+}
+
+// moduleVariables returns the module's variables, as an empty list when it declares none.
+func (m *GitModule) moduleVariables() []ModuleVariable {
+	if m.Variables == nil {
+		return []ModuleVariable{}
+	}
+	return m.Variables
 }
 
 // CreateGitRepoInput represents input for creating a git repository.
@@ -79,6 +144,9 @@ type CreateGitRepoInput struct {
 	BasePath    string
 	Description string
 	IsDefault   bool
+	Environment string // Empty for a default shared by all environments
+	// WebhookSecret signs the repository's push webhooks; none are accepted when empty.
+	WebhookSecret string
 }
 
 // UpdateGitRepoInput represents input for updating a git repository.
@@ -94,6 +162,9 @@ type UpdateGitRepoInput struct {
 	Description *string
 	Status      *int8
 	IsDefault   *bool
+	Environment *string
+	// WebhookSecret replaces the push webhook secret; an empty string disables the webhook.
+	WebhookSecret *string
 }
 
 // TestConnectionInput represents input for testing a git connection.
@@ -107,31 +178,97 @@ type TestConnectionInput struct {
 }
 
 type gitService struct {
-	gitRepoRepo    repository.GitRepoRepository
-	nodeConfigRepo repository.NodeConfigRepository
-	tfModuleRepo   repository.TerraformModuleRepository
-	logger         *zap.Logger
-	workDir        string // Base directory for git operations
+	gitRepoRepo        repository.GitRepoRepository
+	nodeConfigRepo     repository.NodeConfigRepository
+	sequenceRepo       repository.SequenceRepository // Numbers node names per provider and type
+	tfModuleRepo       repository.TerraformModuleRepository
+	logger             *zap.Logger
+	eventBus           *events.Bus
+	terragruntTemplate *template.Template     // Renders each node's terragrunt.hcl
+	nodePathTemplates  *NodePathTemplates     // Names each node and its config path
+	commitTemplate     *CommitMessageTemplate // Renders the message of each node config commit
+	runGit             gitRunner              // Runs git commands; replaced in tests
+	workDir            string                 // Base directory for git operations
+	repoLocks          keyedMutex             // Serializes pushes to a repository and use of its module cache
+	cloneTimeout       time.Duration          // Bounds each clone; the default applies when zero
+	modulesCacheIdle   time.Duration          // Unused modules clones are removed after this; zero keeps them
+	moduleMarkers      []string               // File names that identify a directory as a Terraform module
+	webhookSecrets     *secrets.Box           // Seals webhook secrets; nil when no key is configured
+	webhookDeliveries  webhookDeliveryLog     // Push signatures already accepted
 }
 
+// defaultModuleMarkers are the file names that mark a directory as a Terraform module.
+var defaultModuleMarkers = []string{"main.tf", "variables.tf", "outputs.tf"}
+
 // NewGitService creates a new git service.
 func NewGitService(
 	gitRepoRepo repository.GitRepoRepository,
 	nodeConfigRepo repository.NodeConfigRepository,
+	sequenceRepo repository.SequenceRepository,
 	tfModuleRepo repository.TerraformModuleRepository,
+	eventBus *events.Bus,
+	terragruntTemplate *template.Template,
+	nodePathTemplates *NodePathTemplates,
+	commitTemplate *CommitMessageTemplate,
+	webhookSecrets *secrets.Box,
 	logger *zap.Logger,
 ) GitService {
 	workDir := os.Getenv("GIT_WORK_DIR")
 	if workDir == "" {
 		workDir = "/tmp/git-repos"
 	}
-	return &gitService{
-		gitRepoRepo:    gitRepoRepo,
-		nodeConfigRepo: nodeConfigRepo,
-		tfModuleRepo:   tfModuleRepo,
-		logger:         logger,
-		workDir:        workDir,
+	moduleMarkers := defaultModuleMarkers
+	if markers := os.Getenv("GIT_MODULE_MARKER_FILES"); markers != "" {
+		moduleMarkers = parseModuleMarkers(markers)
+	}
+	cloneTimeout := constants.DefaultGitCloneTimeout
+	if value := os.Getenv("GIT_CLONE_TIMEOUT"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			cloneTimeout = parsed
+		} else {
+			logger.Warn("ignoring invalid GIT_CLONE_TIMEOUT", zap.String("value", sanitize.ForLog(value)))
+		}
+	}
+	modulesCacheIdle := constants.DefaultModulesCacheIdleTimeout
+	if value := os.Getenv("GIT_MODULES_CACHE_IDLE_TIMEOUT"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed >= 0 {
+			modulesCacheIdle = parsed
+		} else {
+			logger.Warn("ignoring invalid GIT_MODULES_CACHE_IDLE_TIMEOUT", zap.String("value", sanitize.ForLog(value)))
+		}
+	}
+	s := &gitService{
+		gitRepoRepo:        gitRepoRepo,
+		nodeConfigRepo:     nodeConfigRepo,
+		sequenceRepo:       sequenceRepo,
+		tfModuleRepo:       tfModuleRepo,
+		eventBus:           eventBus,
+		terragruntTemplate: terragruntTemplate,
+		nodePathTemplates:  nodePathTemplates,
+		commitTemplate:     commitTemplate,
+		logger:             logger,
+		workDir:            workDir,
+		cloneTimeout:       cloneTimeout,
+		modulesCacheIdle:   modulesCacheIdle,
+		moduleMarkers:      moduleMarkers,
+		webhookSecrets:     webhookSecrets,
+	}
+	go s.cleanupModulesCache()
+	return s
+}
+
+// parseModuleMarkers parses a comma-separated list of module marker file names.
+func parseModuleMarkers(value string) []string {
+	var markers []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			markers = append(markers, name)
+		}
 	}
+	if len(markers) == 0 {
+		return defaultModuleMarkers
+	}
+	return markers
 }
 
 // ListRepositories lists all git repositories with pagination.
@@ -144,9 +281,10 @@ func (s *gitService) GetRepository(ctx context.Context, id string) (*model.GitRe
 	return s.gitRepoRepo.GetByID(ctx, id)
 }
 
-// GetDefaultRepository retrieves the default repository for a given type.
-func (s *gitService) GetDefaultRepository(ctx context.Context, repoType model.GitRepoType) (*model.GitRepository, error) {
-	return s.gitRepoRepo.GetDefaultByType(ctx, repoType)
+// GetDefaultRepository retrieves the default repository for a given type and environment,
+// falling back to the global default.
+func (s *gitService) GetDefaultRepository(ctx context.Context, repoType model.GitRepoType, environment string) (*model.GitRepository, error) {
+	return s.gitRepoRepo.GetDefaultByType(ctx, repoType, environment)
 }
 
 // CreateRepository creates a new git repository.
@@ -176,21 +314,29 @@ func (s *gitService) CreateRepository(ctx context.Context, input *CreateGitRepoI
 		authType = model.GitAuthTypeNone
 	}
 
-	repo := &model.GitRepository{
-		Name:        input.Name,
-		Type:        input.Type,
-		URL:         input.URL,
-		Branch:      branch,
-		AuthType:    authType,
-		Username:    input.Username,
-		Token:       input.Token,
-		SSHKey:      input.SSHKey,
-		BasePath:    basePath,
-		Description: input.Description,
-		IsDefault:   input.IsDefault,
-		Status:      1,
+	webhookSecret, err := s.sealWebhookSecret(input.WebhookSecret)
+	if err != nil {
+		return nil, err
 	}
 
+	repo := &model.GitRepository{
+		Name:          input.Name,
+		Type:          input.Type,
+		URL:           input.URL,
+		Branch:        branch,
+		AuthType:      authType,
+		Username:      input.Username,
+		Token:         input.Token,
+		SSHKey:        input.SSHKey,
+		BasePath:      basePath,
+		Description:   input.Description,
+		IsDefault:     input.IsDefault,
+		Environment:   input.Environment,
+		Status:        1,
+		WebhookSecret: webhookSecret,
+	}
+	stampCreated(ctx, &repo.AuditStamp)
+
 	if err := s.gitRepoRepo.Create(ctx, repo); err != nil {
 		s.logger.Error("failed to create git repository", zap.Error(err))
 		return nil, errors.New("failed to create git repository")
@@ -210,6 +356,7 @@ func (s *gitService) UpdateRepository(ctx context.Context, id string, input *Upd
 		return nil, err
 	}
 
+	oldURL, oldBranch := repo.URL, repo.Branch
 	if input.Name != nil {
 		repo.Name = *input.Name
 	}
@@ -243,11 +390,24 @@ func (s *gitService) UpdateRepository(ctx context.Context, id string, input *Upd
 	if input.IsDefault != nil {
 		repo.IsDefault = *input.IsDefault
 	}
+	if input.Environment != nil {
+		repo.Environment = *input.Environment
+	}
+	if input.WebhookSecret != nil {
+		if repo.WebhookSecret, err = s.sealWebhookSecret(*input.WebhookSecret); err != nil {
+			return nil, err
+		}
+	}
+	stampUpdated(ctx, &repo.AuditStamp)
 
 	if err := s.gitRepoRepo.Update(ctx, repo); err != nil {
 		s.logger.Error("failed to update git repository", zap.Error(err))
 		return nil, errors.New("failed to update git repository")
 	}
+	// A modules clone from the old URL or branch would keep serving what it had
+	if repo.URL != oldURL || repo.Branch != oldBranch {
+		s.removeModulesCache(repo.ID)
+	}
 
 	return repo, nil
 }
@@ -262,7 +422,11 @@ func (s *gitService) DeleteRepository(ctx context.Context, id string) error {
 		return err
 	}
 
-	return s.gitRepoRepo.Delete(ctx, id)
+	if err := s.gitRepoRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.removeModulesCache(id)
+	return nil
 }
 
 // TestConnection tests the connection to a git repository.
@@ -289,22 +453,18 @@ func (s *gitService) TestConnection(ctx context.Context, id string) error {
 	defer os.RemoveAll(tempDir) //nolint:errcheck // best effort cleanup
 
 	// Try to clone with depth 1 to test connection
-	cloneURL := s.buildAuthenticatedURL(repo)
-	args := []string{"clone", "--depth", "1", "--branch", branch, cloneURL, tempDir}
-
-	cmd := exec.CommandContext(ctx, "git", args...) // #nosec G204 --  URL and branch validated above
-	output, err := cmd.CombinedOutput()
+	output, err := s.cloneGit(ctx, repo, "--depth", "1", "--branch", branch, repo.URL, tempDir)
 	if err != nil {
 		s.logger.Error("git clone test failed",
 			zap.String("repo", sanitize.ForLog(repo.Name)),
-			zap.String("output", sanitize.CommandOutput(string(output))),
+			zap.String("output", sanitize.CommandOutput(output)),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to connect to repository: %s", sanitize.CommandOutput(string(output)))
+		return cloneFailure("failed to connect to repository", output, err)
 	}
 
 	// Update last sync time
-	now := time.Now()
+	now := model.Now()
 	repo.LastSyncAt = &now
 	if err := s.gitRepoRepo.Update(ctx, repo); err != nil {
 		s.logger.Warn("failed to update last sync time", zap.Error(err))
@@ -352,19 +512,14 @@ func (s *gitService) TestConnectionDirect(ctx context.Context, input *TestConnec
 	defer os.RemoveAll(tempDir) //nolint:errcheck // best effort cleanup
 
 	// Try to clone with depth 1 to test connection
-	// Note: cloneURL is built from validated URL with credentials added
-	cloneURL := s.buildAuthenticatedURL(repo)
-	args := []string{"clone", "--depth", "1", "--branch", branch, cloneURL, tempDir}
-
-	cmd := exec.CommandContext(ctx, "git", args...) // #nosec G204 --  URL and branch validated above
-	output, err := cmd.CombinedOutput()
+	output, err := s.cloneGit(ctx, repo, "--depth", "1", "--branch", branch, validatedURL, tempDir)
 	if err != nil {
 		s.logger.Error("git clone test failed",
 			zap.String("url", sanitize.URL(input.URL)),
-			zap.String("output", sanitize.CommandOutput(string(output))),
+			zap.String("output", sanitize.CommandOutput(output)),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to connect to repository: %s", sanitize.CommandOutput(string(output)))
+		return cloneFailure("failed to connect to repository", output, err)
 	}
 
 	return nil
@@ -376,31 +531,39 @@ func (s *gitService) CreateNodeConfig(ctx context.Context, request *model.Resour
 		return nil, errors.New("request cannot be nil")
 	}
 
-	// Get the default storage repository
-	storageRepo, err := s.gitRepoRepo.GetDefaultByType(ctx, model.GitRepoTypeStorage)
+	// Get the default storage repository for the request's environment
+	storageRepo, err := s.gitRepoRepo.GetDefaultByType(ctx, model.GitRepoTypeStorage, request.Environment)
 	if err != nil {
 		return nil, fmt.Errorf("no default storage repository configured: %w", err)
 	}
 
 	// Get the default modules repository (optional)
 	var moduleRepoID *string
-	moduleRepo, err := s.gitRepoRepo.GetDefaultByType(ctx, model.GitRepoTypeModules)
+	moduleRepo, err := s.gitRepoRepo.GetDefaultByType(ctx, model.GitRepoTypeModules, request.Environment)
 	if err == nil {
 		moduleRepoID = &moduleRepo.ID
 	}
 
-	// Generate the config path
-	configPath := s.generateConfigPath(request, storageRepo)
+	// Generate a config path not used by another node in the storage repo
+	nodeName, configPath, err := s.resolveNodePath(ctx, request, storageRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies, err := s.resolveDependencies(ctx, request, configPath, storageRepo)
+	if err != nil {
+		return nil, err
+	}
 
 	// Generate terragrunt config
-	terragruntConfig, err := s.generateTerragruntConfig(request, moduleRepo)
+	terragruntConfig, err := s.generateTerragruntConfig(request, nodeName, moduleRepo, dependencies)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate terragrunt config: %w", err)
 	}
 
 	// Create the node config record
 	config := &model.NodeConfig{
-		Name:              s.generateNodeName(request),
+		Name:              nodeName,
 		Path:              configPath,
 		ResourceRequestID: request.ID,
 		StorageRepoID:     storageRepo.ID,
@@ -416,7 +579,7 @@ func (s *gitService) CreateNodeConfig(ctx context.Context, request *model.Resour
 	}
 
 	// Commit the pending config to storage repo
-	commitSHA, commitErr := s.commitPendingConfig(ctx, config, storageRepo)
+	commitSHA, commitErr := s.commitPendingConfig(ctx, config, request, storageRepo)
 	if commitErr != nil {
 		s.logger.Warn("failed to commit pending config", zap.Error(commitErr))
 		// Don't fail the creation, just log
@@ -427,9 +590,67 @@ func (s *gitService) CreateNodeConfig(ctx context.Context, request *model.Resour
 		}
 	}
 
+	s.publishNodeConfigEvent(ctx, events.NodeConfigCreated, config)
+
 	return config, nil
 }
 
+// PreviewNodeConfig renders the terragrunt.hcl and path CreateNodeConfig would give request,
+// with no database writes and no git operations. The node number is the one the next
+// config would take. Without a default storage repository the path is not checked against
+// existing nodes, so a request whose module is set inline can be previewed before any
+// storage repository is configured.
+func (s *gitService) PreviewNodeConfig(ctx context.Context, request *model.ResourceRequest) (*NodeConfigPreview, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+
+	if request.TfModule == nil && request.TfModuleID != nil && *request.TfModuleID != "" {
+		module, err := s.tfModuleRepo.GetByID(ctx, *request.TfModuleID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get terraform module: %w", err)
+		}
+		request.TfModule = module
+	}
+
+	storageRepo, err := s.gitRepoRepo.GetDefaultByType(ctx, model.GitRepoTypeStorage, request.Environment)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("failed to get default storage repository: %w", err)
+		}
+		storageRepo = nil
+	}
+
+	// The default modules repository supplies the source when the request names no module
+	var moduleRepo *model.GitRepository
+	if request.TfModule == nil {
+		if repo, repoErr := s.gitRepoRepo.GetDefaultByType(ctx, model.GitRepoTypeModules, request.Environment); repoErr == nil {
+			moduleRepo = repo
+		}
+	}
+
+	nodeName, configPath, err := s.previewNodePath(ctx, request, storageRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies, err := s.resolveDependencies(ctx, request, configPath, storageRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	terragruntConfig, err := s.generateTerragruntConfig(request, nodeName, moduleRepo, dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate terragrunt config: %w", err)
+	}
+
+	preview := &NodeConfigPreview{Name: nodeName, Path: configPath, TerragruntConfig: terragruntConfig}
+	if storageRepo != nil {
+		preview.StorageRepoID = storageRepo.ID
+	}
+	return preview, nil
+}
+
 // UpdateNodeConfigStatus updates the status of a node configuration.
 func (s *gitService) UpdateNodeConfigStatus(ctx context.Context, configID string, status model.NodeConfigStatus, log string) error {
 	config, err := s.nodeConfigRepo.GetByID(ctx, configID)
@@ -444,14 +665,95 @@ func (s *gitService) UpdateNodeConfigStatus(ctx context.Context, configID string
 
 	//nolint:staticcheck // if-else chain is clearer here
 	if status == model.NodeConfigStatusActive {
-		now := time.Now()
+		now := model.Now()
 		config.ProvisionedAt = &now
+		if addresses := terraform.CreatedResourceAddresses(log); len(addresses) > 0 {
+			config.ResourceAddresses = addresses
+		}
 	} else if status == model.NodeConfigStatusDestroyed {
-		now := time.Now()
+		now := model.Now()
 		config.DestroyedAt = &now
 	}
 
-	return s.nodeConfigRepo.Update(ctx, config)
+	if err := s.nodeConfigRepo.Update(ctx, config); err != nil {
+		return err
+	}
+
+	s.publishNodeConfigEvent(ctx, events.NodeConfigStatusChanged, config)
+	return nil
+}
+
+// RetryFailedNodeConfigs moves the failed node configs matching filter back to approved and
+// publishes a NodeConfigRetryRequested event for each, which the provisioning subscribers pick
+// up. Each config is reset only if it is still failed, so configs that recovered or were
+// retried concurrently since they were listed are skipped.
+func (s *gitService) RetryFailedNodeConfigs(ctx context.Context, filter NodeConfigRetryFilter) (*NodeConfigRetrySummary, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > constants.MaxNodeConfigRetryBatch {
+		limit = constants.MaxNodeConfigRetryBatch
+	}
+	filters := repository.NodeConfigFilters{
+		Status:        model.NodeConfigStatusFailed,
+		StorageRepoID: filter.StorageRepoID,
+		Provider:      filter.Provider,
+	}
+	if filter.FailedFor > 0 {
+		filters.UpdatedBefore = time.Now().Add(-filter.FailedFor)
+	}
+
+	configs, err := s.nodeConfigRepo.List(ctx, filters, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed node configs: %w", err)
+	}
+
+	summary := &NodeConfigRetrySummary{
+		Matched: len(configs),
+		Retried: []string{},
+		Skipped: []string{},
+	}
+	for i := range configs {
+		config := &configs[i]
+		reset, err := s.nodeConfigRepo.ResetForRetry(ctx, config.ID)
+		if err != nil {
+			s.logger.Error("failed to reset node config for retry", zap.String("config_id", config.ID), zap.Error(err))
+			if summary.Errors == nil {
+				summary.Errors = map[string]string{}
+			}
+			summary.Errors[config.ID] = "failed to reset node config"
+			continue
+		}
+		if !reset {
+			summary.Skipped = append(summary.Skipped, config.ID)
+			continue
+		}
+
+		config.Status = model.NodeConfigStatusApproved
+		config.ErrorMessage = ""
+		s.publishNodeConfigEvent(ctx, events.NodeConfigRetryRequested, config)
+		summary.Retried = append(summary.Retried, config.ID)
+	}
+
+	s.logger.Info("retried failed node configs",
+		zap.Int("matched", summary.Matched),
+		zap.Int("retried", len(summary.Retried)),
+		zap.Int("skipped", len(summary.Skipped)),
+		zap.Int("errors", len(summary.Errors)),
+	)
+	return summary, nil
+}
+
+// publishNodeConfigEvent publishes a domain event about a node configuration.
+func (s *gitService) publishNodeConfigEvent(ctx context.Context, eventType events.Type, config *model.NodeConfig) {
+	s.eventBus.Publish(ctx, events.Event{
+		Type:         eventType,
+		ActorID:      UserIDFromContext(ctx),
+		RequestID:    config.ResourceRequestID,
+		NodeConfigID: config.ID,
+		Data: map[string]interface{}{
+			"status": string(config.Status),
+			"path":   config.Path,
+		},
+	})
 }
 
 // CommitNodeConfig commits the node configuration to the storage repository.
@@ -463,31 +765,36 @@ func (s *gitService) CommitNodeConfig(ctx context.Context, configID string, mess
 		return "", err
 	}
 
+	if err := scanNodeConfigSecrets(config); err != nil {
+		return "", err
+	}
+
 	storageRepo, err := s.gitRepoRepo.GetByID(ctx, config.StorageRepoID)
 	if err != nil {
 		return "", err
 	}
 
 	// Clone the repo
-	repoPath := filepath.Join(s.workDir, storageRepo.ID)
+	repoPath := s.operationDir(storageRepo.ID, "commit")
 	if cloneErr := s.CloneRepository(ctx, storageRepo, repoPath); cloneErr != nil {
 		return "", fmt.Errorf("failed to clone repository: %w", cloneErr)
 	}
 	defer os.RemoveAll(repoPath) //nolint:errcheck // best effort cleanup
 
-	// Write the config file
-	configFilePath := filepath.Join(repoPath, storageRepo.BasePath, config.Path, "terragrunt.hcl")
-	if mkdirErr := os.MkdirAll(filepath.Dir(configFilePath), dirPerm); mkdirErr != nil {
-		return "", fmt.Errorf("failed to create directory: %w", mkdirErr)
+	// Write the config files
+	files, err := writeNodeConfigFiles(repoPath, storageRepo, config)
+	if err != nil {
+		return "", err
 	}
 
-	if writeErr := os.WriteFile(configFilePath, []byte(config.TerragruntConfig), filePerm); writeErr != nil {
-		return "", fmt.Errorf("failed to write config file: %w", writeErr)
+	message, err = s.commitMessageTemplate().Render(commitMessageFields(config, config.ResourceRequest, commitActionCommit, message))
+	if err != nil {
+		return "", err
 	}
 
-	// Commit and push
-	commitSHA, err := s.CommitAndPush(ctx, repoPath, []string{configFilePath}, message)
-	if err != nil {
+	// Commit and push. An unchanged config is already committed at HEAD
+	commitSHA, err := s.CommitAndPush(ctx, storageRepo, repoPath, files, message)
+	if err != nil && !errors.Is(err, ErrNothingToCommit) {
 		return "", err
 	}
 
@@ -515,8 +822,14 @@ func (s *gitService) ListNodeConfigs(ctx context.Context, repoID string, page, p
 	return s.nodeConfigRepo.ListByStorageRepo(ctx, repoID, page, pageSize)
 }
 
-// CloneRepository clones a git repository to the target path.
-func (s *gitService) CloneRepository(ctx context.Context, repo *model.GitRepository, targetPath string) error {
+// CloneRepository clones a git repository to the target path. Without options it clones the
+// full history of the configured branch; the first of opts replaces that when given.
+func (s *gitService) CloneRepository(ctx context.Context, repo *model.GitRepository, targetPath string, opts ...CloneOptions) error {
+	options := defaultCloneOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
 	// Validate URL and branch
 	if _, urlErr := sanitize.ValidateGitURL(repo.URL); urlErr != nil {
 		return fmt.Errorf("invalid repository URL: %w", urlErr)
@@ -531,137 +844,394 @@ func (s *gitService) CloneRepository(ctx context.Context, repo *model.GitReposit
 		return fmt.Errorf("failed to remove existing directory: %w", rmErr)
 	}
 
-	// Build the clone command
-	cloneURL := s.buildAuthenticatedURL(repo)
-	args := []string{"clone", "--branch", branch, "--single-branch", cloneURL, targetPath}
-
-	cmd := exec.CommandContext(ctx, "git", args...) // #nosec G204 --  URL and branch validated above
-	output, err := cmd.CombinedOutput()
+	args := append([]string{"--branch", branch}, options.args()...)
+	output, err := s.cloneGitWithin(ctx, repo, options.Timeout, append(args, repo.URL, targetPath)...)
 	if err != nil {
 		s.logger.Error("git clone failed",
 			zap.String("repo", sanitize.ForLog(repo.Name)),
-			zap.String("output", sanitize.CommandOutput(string(output))),
+			zap.String("output", sanitize.CommandOutput(output)),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to clone repository: %s", sanitize.CommandOutput(string(output)))
+		return cloneFailure("failed to clone repository", output, err)
 	}
 
 	return nil
 }
 
-// PullChanges pulls the latest changes from the remote repository.
-func (s *gitService) PullChanges(ctx context.Context, repoPath string) error {
-	cmd := exec.CommandContext(ctx, "git", "pull")
-	cmd.Dir = repoPath
-	output, err := cmd.CombinedOutput()
+// PullChanges pulls the latest changes from repo's remote into the checkout at repoPath.
+func (s *gitService) PullChanges(ctx context.Context, repo *model.GitRepository, repoPath string) error {
+	output, err := s.remoteGit(ctx, repo, repoPath, "pull")
 	if err != nil {
 		s.logger.Error("git pull failed",
 			zap.String("path", sanitize.Path(repoPath)),
-			zap.String("output", sanitize.CommandOutput(string(output))),
+			zap.String("output", sanitize.CommandOutput(output)),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to pull changes: %s", sanitize.CommandOutput(string(output)))
+		return fmt.Errorf("failed to pull changes: %s", sanitize.CommandOutput(output))
 	}
 	return nil
 }
 
-// CommitAndPush commits changes and pushes to the remote repository.
-func (s *gitService) CommitAndPush(ctx context.Context, repoPath string, files []string, message string) (string, error) {
-	// Add files
+// ErrPushConflict indicates a push kept being rejected because the remote moved ahead and
+// the local commit could not be rebased onto it.
+var ErrPushConflict = errors.New("push conflict")
+
+// ErrNothingToCommit indicates the files to commit match what is already committed, so no
+// commit was made. CommitAndPush returns it with the SHA of the current HEAD.
+var ErrNothingToCommit = errors.New("nothing to commit")
+
+// maxPushAttempts bounds how often a push rejected as non-fast-forward is retried after
+// rebasing onto the remote.
+const maxPushAttempts = 3
+
+// CommitAndPush commits changes and pushes them to repo's remote. When the checkout is on a
+// detached HEAD (a tag or commit), the commit goes to a new branch named after the starting
+// commit, since there is no branch to push it to otherwise. When the remote moved ahead, the
+// commit is rebased onto it and the push retried; the returned SHA is the one pushed. Files
+// whose content matches what is already committed are not committed again: nothing is
+// pushed and the SHA of the current HEAD is returned with ErrNothingToCommit. All files go
+// into one commit, and files outside the checkout are rejected with ErrInvalidNodeFile.
+func (s *gitService) CommitAndPush(ctx context.Context, repo *model.GitRepository, repoPath string, files []string, message string) (string, error) {
+	relPaths := make([]string, 0, len(files))
 	for _, file := range files {
-		relPath, err := filepath.Rel(repoPath, file)
+		relPath, err := repoRelPath(repoPath, file)
 		if err != nil {
-			relPath = file
+			return "", err
+		}
+		relPaths = append(relPaths, relPath)
+	}
+
+	// Lists modified, staged and untracked files; empty when all match HEAD
+	output, err := s.git(ctx, repoPath, append([]string{"status", "--porcelain", "--"}, relPaths...)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to check for changes: %s", sanitize.CommandOutput(output))
+	}
+	if strings.TrimSpace(output) == "" {
+		s.logger.Info("files are unchanged, skipping commit", zap.String("path", sanitize.Path(repoPath)))
+		return s.nothingToCommit(ctx, repoPath)
+	}
+
+	branch, err := s.prepareCommitBranch(ctx, repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	// Add files
+	for _, relPath := range relPaths {
+		if output, err := s.git(ctx, repoPath, "add", relPath); err != nil {
+			return "", fmt.Errorf("failed to add file %s: %s", relPath, output)
 		}
-		cmd := exec.CommandContext(ctx, "git", "add", relPath) // #nosec G204 --  args are controlled internally
-		cmd.Dir = repoPath
-		if output, err := cmd.CombinedOutput(); err != nil {
-			return "", fmt.Errorf("failed to add file %s: %s", relPath, string(output))
+	}
+
+	// Commit. Staged content can still match HEAD, such as when only line endings differed
+	// before git normalized them
+	if output, err := s.git(ctx, repoPath, "commit", "-m", message); err != nil {
+		if isNothingToCommit(output) {
+			return s.nothingToCommit(ctx, repoPath)
 		}
+		return "", fmt.Errorf("failed to commit: %s", output)
 	}
 
-	// Commit
-	// codeql[go/command-injection] safe: arguments are controlled internally and trusted
-	cmd := exec.CommandContext(ctx, "git", "commit", "-m", message) // #nosec G204 --  commit message trusted
-	cmd.Dir = repoPath
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("failed to commit: %s", string(output))
+	if err := s.pushWithRebase(ctx, repo, repoPath, branch); err != nil {
+		return "", err
 	}
+	return s.headSHA(ctx, repoPath)
+}
 
-	// Get the commit SHA
-	cmd = exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
-	cmd.Dir = repoPath
-	output, err := cmd.Output()
+// nothingToCommit returns the SHA of the current HEAD with ErrNothingToCommit.
+func (s *gitService) nothingToCommit(ctx context.Context, repoPath string) (string, error) {
+	sha, err := s.headSHA(ctx, repoPath)
+	if err != nil {
+		return "", err
+	}
+	return sha, ErrNothingToCommit
+}
+
+// isNothingToCommit reports whether git commit output says there were no changes to commit.
+func isNothingToCommit(output string) bool {
+	return strings.Contains(output, "nothing to commit") || strings.Contains(output, "nothing added to commit")
+}
+
+// headSHA returns the SHA of the commit checked out at repoPath.
+func (s *gitService) headSHA(ctx context.Context, repoPath string) (string, error) {
+	output, err := s.git(ctx, repoPath, "rev-parse", "HEAD")
 	if err != nil {
 		return "", fmt.Errorf("failed to get commit SHA: %w", err)
 	}
-	commitSHA := strings.TrimSpace(string(output))
+	return strings.TrimSpace(output), nil
+}
 
-	// Push
-	// codeql[go/command-injection] safe: executing static command
-	cmd = exec.CommandContext(ctx, "git", "push")
-	cmd.Dir = repoPath
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("failed to push: %s", string(output))
+// pushWithRebase pushes the checkout at repoPath. branch is the new branch to publish, or
+// empty to push the current branch to its upstream. A non-fast-forward rejection rebases the
+// local commits onto the remote and retries, up to maxPushAttempts pushes in total. A rebase
+// that conflicts is aborted, leaving the local commit as it was. Pushes to the same repository
+// are serialized, so concurrent commits rebase at most once instead of racing each other.
+func (s *gitService) pushWithRebase(ctx context.Context, repo *model.GitRepository, repoPath, branch string) error {
+	unlock := s.repoLocks.lock(repo.URL)
+	defer unlock()
+
+	pushArgs := []string{"push"}
+	pullArgs := []string{"pull", "--rebase"}
+	if branch != "" {
+		pushArgs = append(pushArgs, "--set-upstream", "origin", branch)
+		pullArgs = append(pullArgs, "origin", branch)
+	}
+
+	for attempt := 1; ; attempt++ {
+		output, err := s.remoteGit(ctx, repo, repoPath, pushArgs...)
+		if err == nil {
+			return nil
+		}
+		if !isNonFastForward(output) {
+			return fmt.Errorf("failed to push: %s", sanitize.CommandOutput(output))
+		}
+		if attempt >= maxPushAttempts {
+			return fmt.Errorf("%w: remote kept moving ahead after %d push attempts", ErrPushConflict, attempt)
+		}
+
+		s.logger.Warn("push rejected because the remote moved ahead, rebasing and retrying",
+			zap.String("path", sanitize.Path(repoPath)),
+			zap.Int("attempt", attempt),
+		)
+		if output, err := s.remoteGit(ctx, repo, repoPath, pullArgs...); err != nil {
+			if abortOutput, abortErr := s.git(ctx, repoPath, "rebase", "--abort"); abortErr != nil {
+				s.logger.Warn("failed to abort rebase",
+					zap.String("path", sanitize.Path(repoPath)),
+					zap.String("output", sanitize.CommandOutput(abortOutput)),
+				)
+			}
+			return fmt.Errorf("%w: could not rebase onto the remote: %s", ErrPushConflict, sanitize.CommandOutput(output))
+		}
 	}
+}
 
-	return commitSHA, nil
+// isNonFastForward reports whether push output says the remote has commits the local
+// branch lacks.
+func isNonFastForward(output string) bool {
+	return strings.Contains(output, "non-fast-forward") || strings.Contains(output, "fetch first")
+}
+
+// detachedBranchPrefix prefixes branches created for commits made on a detached HEAD.
+const detachedBranchPrefix = "vc-lab/detached-"
+
+// prepareCommitBranch makes sure the checkout at repoPath is on a branch. It returns the new
+// branch a detached HEAD was moved to, or empty when the checkout was already on a branch. The
+// new branch is derived from the HEAD commit, so the same checkout always gets the same name.
+func (s *gitService) prepareCommitBranch(ctx context.Context, repoPath string) (string, error) {
+	output, err := s.git(ctx, repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve current branch: %s", sanitize.CommandOutput(output))
+	}
+	if strings.TrimSpace(output) != "HEAD" {
+		return "", nil
+	}
+
+	output, err = s.git(ctx, repoPath, "rev-parse", "--short=12", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve detached HEAD: %s", sanitize.CommandOutput(output))
+	}
+	branch := detachedBranchPrefix + strings.TrimSpace(output)
+
+	if output, err := s.git(ctx, repoPath, "checkout", "-b", branch); err != nil {
+		return "", fmt.Errorf("failed to create branch %s from detached HEAD: %s", branch, sanitize.CommandOutput(output))
+	}
+	s.logger.Warn("repository is on a detached HEAD, committing to a new branch",
+		zap.String("path", sanitize.Path(repoPath)),
+		zap.String("branch", branch),
+	)
+	return branch, nil
 }
 
 // Helper functions
 
-//nolint:nestif // auth type handling requires nested checks
-func (s *gitService) buildAuthenticatedURL(repo *model.GitRepository) string {
-	// If using SSH key auth type, return the URL as-is (assuming SSH URL format)
-	if repo.AuthType == model.GitAuthTypeSSHKey && repo.SSHKey != "" {
-		return repo.URL
+// gitRunner runs a git command in dir with env added to the process environment and returns
+// its combined output.
+type gitRunner func(ctx context.Context, dir string, env []string, args ...string) (string, error)
+
+// execGit runs git as a subprocess.
+func execGit(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	// codeql[go/command-injection] safe: callers validate URLs and branches; other arguments are controlled internally
+	cmd := exec.CommandContext(ctx, "git", args...) // #nosec G204 -- callers validate URL and branch
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
 	}
+	// Killing git on cancellation leaves its remote helper holding the output pipe open
+	cmd.WaitDelay = constants.GitWaitDelay
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// git runs a local git command through the configured runner.
+func (s *gitService) git(ctx context.Context, dir string, args ...string) (string, error) {
+	return s.gitWithEnv(ctx, dir, nil, args...)
+}
 
-	// If no auth or public repo, return URL as-is
-	if repo.AuthType == model.GitAuthTypeNone || repo.AuthType == "" {
-		return repo.URL
+// remoteGit runs a git command that talks to repo's remote, authenticated with its credentials.
+// An SSH key is written to a temporary file for the duration of the command.
+func (s *gitService) remoteGit(ctx context.Context, repo *model.GitRepository, dir string, args ...string) (string, error) {
+	env := gitAuthEnv(repo)
+	if usesSSHKey(repo) {
+		keyEnv, cleanup, err := writeSSHKey(repo.SSHKey)
+		if err != nil {
+			return "", err
+		}
+		defer cleanup()
+		env = append(env, keyEnv...)
 	}
+	return s.gitWithEnv(ctx, dir, env, args...)
+}
 
-	// If using token or password auth, embed credentials in HTTPS URL
-	if (repo.AuthType == model.GitAuthTypeToken || repo.AuthType == model.GitAuthTypePassword) && repo.Token != "" {
-		// Parse the URL and add credentials
-		if strings.HasPrefix(repo.URL, "https://") {
-			parts := strings.SplitN(repo.URL, "://", 2)
-			if len(parts) == 2 {
-				username := repo.Username
-				if username == "" {
-					// For token auth, use a default username (works for GitHub, GitLab, etc.)
-					username = "git"
-				}
-				return fmt.Sprintf("https://%s:%s@%s", username, repo.Token, parts[1])
+// gitWithEnv runs a git command through the configured runner with env added.
+func (s *gitService) gitWithEnv(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	if s.runGit == nil {
+		return execGit(ctx, dir, env, args...)
+	}
+	return s.runGit(ctx, dir, env, args...)
+}
+
+// defaultGitUsername is sent with token auth when the repository has no username; GitHub,
+// GitLab and Gitea accept any username alongside a token.
+const defaultGitUsername = "git"
+
+// gitAuthEnv returns the environment that authenticates git against repo. Token and password
+// credentials are passed as an http.extraHeader through GIT_CONFIG_* variables, so they never
+// appear in the remote URL, the command line or the clone's .git/config. Credentials are only
+// sent to HTTPS remotes.
+func gitAuthEnv(repo *model.GitRepository) []string {
+	if repo == nil || repo.Token == "" || !strings.HasPrefix(repo.URL, "https://") {
+		return nil
+	}
+	if repo.AuthType != model.GitAuthTypeToken && repo.AuthType != model.GitAuthTypePassword {
+		return nil
+	}
+	username := repo.Username
+	if username == "" {
+		username = defaultGitUsername
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + repo.Token))
+	return []string{
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + credentials,
+	}
+}
+
+// Constants for node path generation.
+const (
+	maxSlugLength     = 20 // Reasonable max length for the title slug
+	maxPathCandidates = 16
+	defaultNodeSlug   = "node" // Used when the title has no path-safe characters
+)
+
+// ErrConfigPathCollision is returned when no unused node config path can be found.
+var ErrConfigPathCollision = errors.New("no unused node config path available")
+
+// resolveNodePath returns a node name and config path that no other node config
+// in the storage repository uses. Nodes are numbered by one sequence per provider and
+// resource type, so repeated titles get consecutive numbers, and named by the configured
+// node path templates. A number whose path is already taken, such as by a node added by
+// hand, is skipped.
+func (s *gitService) resolveNodePath(ctx context.Context, request *model.ResourceRequest, storageRepo *model.GitRepository) (string, string, error) {
+	return s.findNodePath(ctx, request, storageRepo, func(scope string) (int64, error) {
+		return s.sequenceRepo.Next(ctx, scope)
+	})
+}
+
+// previewNodePath returns the node name and config path resolveNodePath would return next,
+// without taking a number from the sequence. Paths are not checked when storageRepo is nil.
+func (s *gitService) previewNodePath(ctx context.Context, request *model.ResourceRequest, storageRepo *model.GitRepository) (string, string, error) {
+	var next int64
+	return s.findNodePath(ctx, request, storageRepo, func(scope string) (int64, error) {
+		if next == 0 {
+			peeked, err := s.sequenceRepo.Peek(ctx, scope)
+			if err != nil {
+				return 0, err
 			}
+			next = peeked
+		} else {
+			next++
 		}
+		return next, nil
+	})
+}
+
+// findNodePath renders the node path for successive numbers from number until one is
+// unused in storageRepo, or takes the first when storageRepo is nil.
+func (s *gitService) findNodePath(ctx context.Context, request *model.ResourceRequest, storageRepo *model.GitRepository, number func(scope string) (int64, error)) (string, string, error) {
+	fields := nodePathFields(request)
+	scope := "node/" + fields.Provider + "/" + fields.Type
+
+	for range maxPathCandidates {
+		sequence, err := number(scope)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to number node: %w", err)
+		}
+		fields.Sequence = sequence
+		nodeName, configPath, err := s.pathTemplates().Render(fields)
+		if err != nil {
+			return "", "", err
+		}
+		if storageRepo == nil {
+			return nodeName, configPath, nil
+		}
+
+		exists, err := s.nodeConfigRepo.ExistsByPath(ctx, storageRepo.ID, configPath)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to check node config path: %w", err)
+		}
+		if !exists {
+			return nodeName, configPath, nil
+		}
+		s.logger.Info("node config path already in use",
+			zap.String("path", sanitize.Path(configPath)),
+			zap.String("request_id", sanitize.ForLog(request.ID)),
+		)
+	}
+	return "", "", ErrConfigPathCollision
+}
+
+// pathTemplates returns the configured node path templates or the built-in ones.
+func (s *gitService) pathTemplates() *NodePathTemplates {
+	if s.nodePathTemplates != nil {
+		return s.nodePathTemplates
 	}
+	return defaultNodePathTemplates
+}
 
-	return repo.URL
+// nodePathFields returns the template fields of a request's node, without its number.
+func nodePathFields(request *model.ResourceRequest) NodePathFields {
+	provider, resourceType := nodeScope(request)
+	fields := NodePathFields{
+		Provider:    provider,
+		Type:        resourceType,
+		Environment: request.Environment,
+		Title:       nodeSlug(request.Title),
+		ID:          request.ID,
+	}
+	if request.Zone != nil {
+		fields.Zone = request.Zone.Code
+	}
+	return fields
 }
 
-func (s *gitService) generateConfigPath(request *model.ResourceRequest, _ *model.GitRepository) string {
-	// Generate path like: proxmox-ve/instance/{type}/{name}
-	provider := request.Provider
+// nodeScope returns the provider and resource type a request's node is filed under.
+func nodeScope(request *model.ResourceRequest) (provider, resourceType string) {
+	provider = request.Provider
 	if provider == "" {
 		provider = "default"
 	}
-
-	resourceType := request.Type
+	resourceType = request.Type
 	if resourceType == "" {
 		resourceType = "vm"
 	}
-
-	nodeName := s.generateNodeName(request)
-
-	return filepath.Join(provider, "instance", resourceType, nodeName)
+	return provider, resourceType
 }
 
-func (s *gitService) generateNodeName(request *model.ResourceRequest) string {
-	// Generate a unique node name from the request
-	// Format: {title-slug}-{short-id}
-	title := strings.ToLower(request.Title)
+// nodeSlug lowercases the title and keeps only characters safe for a path segment.
+func nodeSlug(title string) string {
+	title = strings.ToLower(title)
 	title = strings.ReplaceAll(title, " ", "-")
 	// Remove special characters
 	var result []rune
@@ -671,30 +1241,53 @@ func (s *gitService) generateNodeName(request *model.ResourceRequest) string {
 		}
 	}
 	slug := string(result)
-	if len(slug) > 20 { //nolint:mnd // reasonable max length for slug
-		slug = slug[:20]
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
 	}
-
-	shortID := request.ID
-	if len(shortID) > 8 { //nolint:mnd // 8 chars of UUID is enough for uniqueness
-		shortID = shortID[:8]
+	if slug == "" {
+		slug = defaultNodeSlug
 	}
-
-	return fmt.Sprintf("%s-%s", slug, shortID)
+	return slug
 }
 
-func (s *gitService) generateTerragruntConfig(request *model.ResourceRequest, moduleRepo *model.GitRepository) (string, error) {
+func (s *gitService) generateTerragruntConfig(request *model.ResourceRequest, nodeName string, moduleRepo *model.GitRepository, dependencies []terragruntDependency) (string, error) {
 	// Parse the spec to get variables
 	var vars map[string]interface{}
-	if err := json.Unmarshal([]byte(request.Spec), &vars); err != nil {
+	if err := json.Unmarshal([]byte(request.Spec), &vars); err != nil || vars == nil {
 		vars = make(map[string]interface{})
 	}
 
+	// Overrides take precedence over the spec and are validated with it below
+	if err := validateVarOverrides(request.VarOverrides); err != nil {
+		return "", err
+	}
+	mergeVarOverrides(vars, request.VarOverrides)
+
+	// Everything in the inputs is committed to the storage repo in plaintext
+	if err := scanSpecSecrets(vars); err != nil {
+		return "", err
+	}
+	mergeDependencyInputs(vars, request.Dependencies)
+
+	// Reject inputs the module cannot accept before they reach the storage repo, and leave
+	// out those the module's defaults already cover
+	if request.TfModule != nil {
+		if variables, ok := parseModuleVariables(request.TfModule.Variables); ok {
+			if err := validateModuleInputs(vars, variables); err != nil {
+				return "", err
+			}
+			omitDefaultedInputs(vars, variables)
+		}
+	}
+
 	// Build the module source
 	moduleSource := ""
 	if request.TfModule != nil {
 		moduleSource = request.TfModule.Source
 		if request.TfModule.Version != "" {
+			if err := validateModuleVersion(request.TfModule.Version); err != nil {
+				return "", err
+			}
 			moduleSource = fmt.Sprintf("%s?ref=%s", moduleSource, request.TfModule.Version)
 		}
 	} else if moduleRepo != nil {
@@ -702,88 +1295,67 @@ func (s *gitService) generateTerragruntConfig(request *model.ResourceRequest, mo
 		moduleSource = moduleRepo.URL
 	}
 
-	// Template for terragrunt.hcl
-	tmpl := `# Terragrunt configuration for {{ .NodeName }}
-# Generated by VC Lab Platform
-# Request ID: {{ .RequestID }}
-# Created: {{ .CreatedAt }}
-
-terraform {
-  source = "{{ .ModuleSource }}"
-}
-
-include "root" {
-  path = find_in_parent_folders()
-}
-
-inputs = {
-{{- range $key, $value := .Vars }}
-  {{ $key }} = {{ $value | formatValue }}
-{{- end }}
-}
-`
-
-	funcMap := template.FuncMap{
-		"formatValue": func(v interface{}) string {
-			switch val := v.(type) {
-			case string:
-				return fmt.Sprintf("%q", val)
-			case float64:
-				if val == float64(int(val)) {
-					return fmt.Sprintf("%d", int(val))
-				}
-				return fmt.Sprintf("%f", val)
-			case bool:
-				return fmt.Sprintf("%t", val)
-			default:
-				b, _ := json.Marshal(val) //nolint:errcheck // will not fail
-				return string(b)
-			}
-		},
-	}
-
-	t, err := template.New("terragrunt").Funcs(funcMap).Parse(tmpl)
-	if err != nil {
-		return "", err
-	}
-
 	data := map[string]interface{}{
-		"NodeName":     s.generateNodeName(request),
+		"NodeName":     nodeName,
 		"RequestID":    request.ID,
-		"CreatedAt":    time.Now().Format(time.RFC3339),
+		"CreatedAt":    model.Now().Format(time.RFC3339),
 		"ModuleSource": moduleSource,
 		"Vars":         vars,
+		"Dependencies": dependencies,
 	}
 
 	var buf bytes.Buffer
-	if err := t.Execute(&buf, data); err != nil {
+	if err := s.configTemplate().Execute(&buf, data); err != nil {
 		return "", err
 	}
 
 	return buf.String(), nil
 }
 
-func (s *gitService) commitPendingConfig(ctx context.Context, config *model.NodeConfig, storageRepo *model.GitRepository) (string, error) {
+// commitMessageTemplate returns the configured commit message template or the built-in one.
+func (s *gitService) commitMessageTemplate() *CommitMessageTemplate {
+	if s.commitTemplate != nil {
+		return s.commitTemplate
+	}
+	return defaultCommitMessageTemplate
+}
+
+// configTemplate returns the configured terragrunt template or the built-in one.
+func (s *gitService) configTemplate() *template.Template {
+	if s.terragruntTemplate != nil {
+		return s.terragruntTemplate
+	}
+	return defaultTerragruntTemplate
+}
+
+func (s *gitService) commitPendingConfig(ctx context.Context, config *model.NodeConfig, request *model.ResourceRequest, storageRepo *model.GitRepository) (string, error) {
+	if err := scanNodeConfigSecrets(config); err != nil {
+		return "", err
+	}
+	message, err := s.commitMessageTemplate().Render(commitMessageFields(config, request, commitActionPending, ""))
+	if err != nil {
+		return "", err
+	}
+
 	// Clone the repo
-	repoPath := filepath.Join(s.workDir, storageRepo.ID, fmt.Sprintf("pending-%s", config.ID))
+	repoPath := s.operationDir(storageRepo.ID, "pending")
 	if err := s.CloneRepository(ctx, storageRepo, repoPath); err != nil {
 		return "", fmt.Errorf("failed to clone repository: %w", err)
 	}
 	defer os.RemoveAll(repoPath) //nolint:errcheck // best effort cleanup
 
-	// Write the config file
-	configFilePath := filepath.Join(repoPath, storageRepo.BasePath, config.Path, "terragrunt.hcl")
-	if err := os.MkdirAll(filepath.Dir(configFilePath), dirPerm); err != nil {
-		return "", fmt.Errorf("failed to create directory: %w", err)
-	}
-
-	if err := os.WriteFile(configFilePath, []byte(config.TerragruntConfig), filePerm); err != nil {
-		return "", fmt.Errorf("failed to write config file: %w", err)
+	// Write the config files
+	files, err := writeNodeConfigFiles(repoPath, storageRepo, config)
+	if err != nil {
+		return "", err
 	}
 
 	// Commit and push
-	message := fmt.Sprintf("Add pending node config: %s", config.Name)
-	return s.CommitAndPush(ctx, repoPath, []string{configFilePath}, message)
+	commitSHA, err := s.CommitAndPush(ctx, storageRepo, repoPath, files, message)
+	if errors.Is(err, ErrNothingToCommit) {
+		return commitSHA, nil
+	}
+	return commitSHA, err
 }
 
 // ListModulesFromGit lists Terraform modules from the default modules git repository.
@@ -805,29 +1377,34 @@ const (
 // scanModulesFromGit scans the modules repository for Terraform modules.
 func (s *gitService) scanModulesFromGit(ctx context.Context, forceRefresh bool) ([]GitModule, error) {
 	// Get the default modules repository
-	moduleRepo, err := s.gitRepoRepo.GetDefaultByType(ctx, model.GitRepoTypeModules)
+	moduleRepo, err := s.gitRepoRepo.GetDefaultByType(ctx, model.GitRepoTypeModules, "")
 	if err != nil {
 		return nil, fmt.Errorf("no default modules repository configured: %w", err)
 	}
 
-	// Determine the local path for the cloned repository
-	repoPath := filepath.Join(s.workDir, "modules", moduleRepo.ID)
+	// The clone is cached between scans and shared by concurrent ones
+	repoPath := s.modulesCacheDir(moduleRepo.ID)
+	unlock := s.repoLocks.lock(repoPath)
+	defer unlock()
 
-	// Check if we need to clone or pull
-	if _, statErr := os.Stat(filepath.Join(repoPath, ".git")); os.IsNotExist(statErr) || forceRefresh {
+	// Clone when there is no cached clone or it was made from another URL or branch than the
+	// repository now has
+	if _, statErr := os.Stat(filepath.Join(repoPath, ".git")); os.IsNotExist(statErr) || forceRefresh || !s.cachedCloneMatches(ctx, moduleRepo, repoPath) {
 		// Clone the repository
-		if cloneErr := s.CloneRepository(ctx, moduleRepo, repoPath); cloneErr != nil {
+		// Module discovery only reads the tip of the branch
+		if cloneErr := s.CloneRepository(ctx, moduleRepo, repoPath, CloneOptions{Depth: 1, SingleBranch: true}); cloneErr != nil {
 			return nil, fmt.Errorf("failed to clone modules repository: %w", cloneErr)
 		}
 	} else {
 		// Pull latest changes
-		if pullErr := s.PullChanges(ctx, repoPath); pullErr != nil {
+		if pullErr := s.PullChanges(ctx, moduleRepo, repoPath); pullErr != nil {
 			s.logger.Warn("failed to pull changes, using cached version", zap.Error(pullErr))
 		}
 	}
+	touchModulesCache(repoPath)
 
 	// Update last sync time
-	now := time.Now()
+	now := model.Now()
 	moduleRepo.LastSyncAt = &now
 	if updateErr := s.gitRepoRepo.Update(ctx, moduleRepo); updateErr != nil {
 		s.logger.Warn("failed to update last sync time", zap.Error(updateErr))
@@ -887,14 +1464,16 @@ func (s *gitService) scanTerraformModules(basePath, repoURL string) ([]GitModule
 }
 
 // processModuleDirectory checks if a directory is a Terraform module and returns the module info.
+// A directory only counts as a module when it contains one of the configured marker files, so a
+// parent directory holding a stray .tf file is still walked for nested modules.
 func (s *gitService) processModuleDirectory(path, basePath, repoURL string, info os.FileInfo) (*GitModule, bool) {
-	hasTfFiles, modErr := s.hasTerraformFiles(path)
+	isModule, modErr := s.isModuleDirectory(path)
 	if modErr != nil {
 		s.logger.Warn("error checking terraform files", zap.String("path", sanitize.Path(path)), zap.Error(modErr))
 		return nil, false
 	}
 
-	if !hasTfFiles {
+	if !isModule {
 		return nil, false
 	}
 
@@ -904,122 +1483,82 @@ func (s *gitService) processModuleDirectory(path, basePath, repoURL string, info
 		return nil, false
 	}
 
+	readme := s.readModuleReadme(path)
+	files, warnings := readModuleFiles(path)
+	if len(warnings) > 0 {
+		s.logger.Warn("module files could not be parsed",
+			zap.String("path", sanitize.Path(path)),
+			zap.Int("files", len(warnings)),
+		)
+	}
 	module := &GitModule{
-		Name:        info.Name(),
-		Path:        relPath,
-		Source:      fmt.Sprintf("%s//%s", repoURL, relPath),
-		Description: s.extractModuleDescription(path),
-		Variables:   s.extractVariableNames(path),
-		Outputs:     s.extractOutputNames(path),
+		Name:          info.Name(),
+		Path:          relPath,
+		Source:        fmt.Sprintf("%s//%s", repoURL, relPath),
+		Description:   readme.Description,
+		DisplayName:   readme.DisplayName,
+		Category:      readme.Category,
+		Icon:          readme.Icon,
+		Tags:          readme.Tags,
+		Variables:     s.extractVariables(files),
+		Outputs:       s.extractOutputNames(files),
+		ParseWarnings: warnings,
 	}
 
 	// Don't recurse into module subdirectories (modules don't contain modules)
 	return module, true
 }
 
-// hasTerraformFiles checks if a directory contains Terraform (.tf) files.
-func (s *gitService) hasTerraformFiles(dirPath string) (bool, error) {
+// isModuleDirectory checks if a directory contains any of the module marker files.
+func (s *gitService) isModuleDirectory(dirPath string) (bool, error) {
+	markers := s.moduleMarkers
+	if len(markers) == 0 {
+		markers = defaultModuleMarkers
+	}
+
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
 		return false, err
 	}
 
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tf") {
-			return true, nil
+		if entry.IsDir() {
+			continue
+		}
+		for _, marker := range markers {
+			if entry.Name() == marker {
+				return true, nil
+			}
 		}
 	}
 
 	return false, nil
 }
 
-// extractModuleDescription tries to extract a description from README.md or module header.
-func (s *gitService) extractModuleDescription(modulePath string) string {
-	// Try README.md first
+// readModuleReadme reads the module's README.md metadata, if the file exists.
+func (s *gitService) readModuleReadme(modulePath string) moduleReadme {
 	readmePath := filepath.Join(modulePath, "README.md")
 	content, err := os.ReadFile(readmePath) // #nosec G304 --  path is constructed from controlled input
 	if err != nil {
-		return ""
+		return moduleReadme{}
 	}
-
-	lines := strings.Split(string(content), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		// Skip headers
-		if strings.HasPrefix(line, "#") {
-			continue
-		}
-		// Return first non-empty paragraph
-		if line != "" {
-			if len(line) > maxDescriptionLength {
-				return line[:maxDescriptionLength] + "..."
-			}
-			return line
-		}
-	}
-
-	return ""
+	return parseModuleReadme(string(content))
 }
 
-// extractVariableNames extracts variable names from variables.tf or *.tf files.
-func (s *gitService) extractVariableNames(modulePath string) []string {
-	var variables []string
-
-	// Check for variables.tf first
-	entries, err := os.ReadDir(modulePath)
-	if err != nil {
-		return variables
-	}
-
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tf") {
-			continue
-		}
-
-		filePath := filepath.Join(modulePath, entry.Name())
-		content, err := os.ReadFile(filePath) // #nosec G304 --  path is constructed from controlled input
-		if err != nil {
-			continue
-		}
-
-		// Simple regex-free variable extraction
-		lines := strings.Split(string(content), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if strings.HasPrefix(line, "variable ") {
-				// Extract variable name from 'variable "name" {'
-				parts := strings.SplitN(line, "\"", splitParts)
-				if len(parts) >= 2 {
-					variables = append(variables, parts[1])
-				}
-			}
-		}
+// extractVariables extracts variable declarations from the module's *.tf files.
+func (s *gitService) extractVariables(files []moduleFile) []ModuleVariable {
+	var variables []ModuleVariable
+	for _, file := range files {
+		variables = append(variables, parseVariableBlocks(file.content)...)
 	}
-
 	return variables
 }
 
-// extractOutputNames extracts output names from outputs.tf or *.tf files.
-func (s *gitService) extractOutputNames(modulePath string) []string {
+// extractOutputNames extracts output names from the module's *.tf files.
+func (s *gitService) extractOutputNames(files []moduleFile) []string {
 	var outputs []string
-
-	entries, err := os.ReadDir(modulePath)
-	if err != nil {
-		return outputs
-	}
-
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tf") {
-			continue
-		}
-
-		filePath := filepath.Join(modulePath, entry.Name())
-		content, err := os.ReadFile(filePath) // #nosec G304 --  path is constructed from controlled input
-		if err != nil {
-			continue
-		}
-
-		lines := strings.Split(string(content), "\n")
+	for _, file := range files {
+		lines := strings.Split(file.content, "\n")
 		for _, line := range lines {
 			line = strings.TrimSpace(line)
 			if strings.HasPrefix(line, "output ") {
@@ -1031,7 +1570,6 @@ func (s *gitService) extractOutputNames(modulePath string) []string {
 			}
 		}
 	}
-
 	return outputs
 }
 
@@ -1040,14 +1578,22 @@ func (s *gitService) extractOutputNames(modulePath string) []string {
 //nolint:unparam // error return is for future use and consistency
 func (s *gitService) syncModulesToDatabase(ctx context.Context, gitModules []GitModule) error {
 	for _, gm := range gitModules {
-		// Check if module with this source already exists
-		existingModule, err := s.tfModuleRepo.GetBySource(ctx, gm.Source)
+		// Check if module with this source already exists, including rows synced before
+		// sources were normalized
+		source := terraform.NormalizeModuleSource(gm.Source)
+		existingModule, err := s.tfModuleRepo.GetBySource(ctx, source)
+		if errors.Is(err, repository.ErrNotFound) && source != gm.Source {
+			existingModule, err = s.tfModuleRepo.GetBySource(ctx, gm.Source)
+		}
 		if err == nil && existingModule != nil {
 			// Module exists, update it
 			existingModule.Name = gm.Name
+			existingModule.Source = source
 			existingModule.Description = gm.Description
-			variablesJSON, _ := json.Marshal(gm.Variables) //nolint:errcheck // will not fail with slice
+			gm.applyMetadata(existingModule)
+			variablesJSON, _ := json.Marshal(gm.moduleVariables()) //nolint:errcheck // will not fail with slice
 			existingModule.Variables = string(variablesJSON)
+			existingModule.ParseWarnings = gm.ParseWarnings
 			if updateErr := s.tfModuleRepo.Update(ctx, existingModule); updateErr != nil {
 				s.logger.Warn("failed to update terraform module",
 					zap.String("name", sanitize.ForLog(gm.Name)),
@@ -1058,14 +1604,16 @@ func (s *gitService) syncModulesToDatabase(ctx context.Context, gitModules []Git
 		}
 
 		// Create new module
-		variablesJSON, _ := json.Marshal(gm.Variables) //nolint:errcheck // will not fail with slice
+		variablesJSON, _ := json.Marshal(gm.moduleVariables()) //nolint:errcheck // will not fail with slice
 		newModule := &model.TerraformModule{
-			Name:        gm.Name,
-			Source:      gm.Source,
-			Description: gm.Description,
-			Variables:   string(variablesJSON),
-			Status:      1, // active
+			Name:          gm.Name,
+			Source:        source,
+			Description:   gm.Description,
+			Variables:     string(variablesJSON),
+			Status:        1, // active
+			ParseWarnings: gm.ParseWarnings,
 		}
+		gm.applyMetadata(newModule)
 		if createErr := s.tfModuleRepo.Create(ctx, newModule); createErr != nil {
 			s.logger.Warn("failed to create terraform module",
 				zap.String("name", sanitize.ForLog(gm.Name)),
@@ -1074,7 +1622,7 @@ func (s *gitService) syncModulesToDatabase(ctx context.Context, gitModules []Git
 		} else {
 			s.logger.Info("synced terraform module to database",
 				zap.String("name", sanitize.ForLog(gm.Name)),
-				zap.String("source", sanitize.URL(gm.Source)),
+				zap.String("source", sanitize.URL(source)),
 			)
 		}
 	}
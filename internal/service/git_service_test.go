@@ -0,0 +1,1099 @@
+// Package service provides git service tests.
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/events"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), dirPerm))
+	require.NoError(t, os.WriteFile(path, []byte(content), filePerm))
+}
+
+func moduleNames(modules []GitModule) []string {
+	names := make([]string, 0, len(modules))
+	for _, m := range modules {
+		names = append(names, m.Path)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestGitService_ScanTerraformModules(t *testing.T) {
+	t.Run("stray tf file does not hide submodules", func(t *testing.T) {
+		base := t.TempDir()
+		writeTestFile(t, filepath.Join(base, "compute", "versions.tf"), "terraform {}\n")
+		writeTestFile(t, filepath.Join(base, "compute", "vm", "main.tf"), "resource \"null_resource\" \"vm\" {}\n")
+		writeTestFile(t, filepath.Join(base, "compute", "vm", "variables.tf"), "variable \"cores\" {}\n")
+		writeTestFile(t, filepath.Join(base, "compute", "lxc", "outputs.tf"), "output \"id\" {}\n")
+
+		svc := &gitService{logger: zap.NewNop(), moduleMarkers: defaultModuleMarkers}
+		modules, err := svc.scanTerraformModules(base, "https://git.example.com/modules.git")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{filepath.Join("compute", "lxc"), filepath.Join("compute", "vm")}, moduleNames(modules))
+		for _, m := range modules {
+			if m.Name == "vm" {
+				assert.Equal(t, []ModuleVariable{{Name: "cores", Required: true}}, m.Variables)
+			}
+		}
+	})
+
+	t.Run("module directory is not recursed", func(t *testing.T) {
+		base := t.TempDir()
+		writeTestFile(t, filepath.Join(base, "network", "main.tf"), "")
+		writeTestFile(t, filepath.Join(base, "network", "examples", "main.tf"), "")
+
+		svc := &gitService{logger: zap.NewNop(), moduleMarkers: defaultModuleMarkers}
+		modules, err := svc.scanTerraformModules(base, "https://git.example.com/modules.git")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"network"}, moduleNames(modules))
+	})
+
+	t.Run("custom marker files", func(t *testing.T) {
+		base := t.TempDir()
+		writeTestFile(t, filepath.Join(base, "storage", "module.tf"), "")
+		writeTestFile(t, filepath.Join(base, "other", "main.tf"), "")
+
+		svc := &gitService{logger: zap.NewNop(), moduleMarkers: parseModuleMarkers(" module.tf , ")}
+		modules, err := svc.scanTerraformModules(base, "https://git.example.com/modules.git")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"storage"}, moduleNames(modules))
+	})
+}
+
+func TestGitService_ScanModuleParseWarnings(t *testing.T) {
+	base := t.TempDir()
+	writeTestFile(t, filepath.Join(base, "vm", "main.tf"), "resource \"null_resource\" \"vm\" {}\n")
+	writeTestFile(t, filepath.Join(base, "vm", "variables.tf"), "variable \"cores\" {\n  default = 2\n}\n")
+	writeTestFile(t, filepath.Join(base, "vm", "network.tf"), "variable \"bridge\" {\n  default = \"vmbr0\n}\n\noutput \"ip\" {\n  value = \"\"\n}\n")
+	writeTestFile(t, filepath.Join(base, "vm", "outputs.tf"), "output \"id\" {\n  value = null_resource.vm.id\n}\n")
+
+	svc := &gitService{logger: zap.NewNop(), moduleMarkers: defaultModuleMarkers}
+	modules, err := svc.scanTerraformModules(base, "https://git.example.com/modules.git")
+	require.NoError(t, err)
+	require.Len(t, modules, 1)
+
+	module := modules[0]
+	assert.Equal(t, []ModuleVariable{{Name: "cores", Default: float64(2)}}, module.Variables, "good files are still parsed")
+	assert.Equal(t, []string{"id"}, module.Outputs)
+	require.Len(t, module.ParseWarnings, 1)
+	assert.Equal(t, "network.tf", module.ParseWarnings[0].File)
+	assert.Equal(t, "line 2: string is never closed", module.ParseWarnings[0].Message)
+
+	repo := newStubModuleRepository()
+	svc.tfModuleRepo = repo
+	require.NoError(t, svc.syncModulesToDatabase(context.Background(), modules))
+	stored, err := repo.GetBySource(context.Background(), "git::https://git.example.com/modules.git//vm")
+	require.NoError(t, err)
+	assert.Equal(t, module.ParseWarnings, stored.ParseWarnings)
+
+	// Fixing the file clears the warning on the next sync
+	writeTestFile(t, filepath.Join(base, "vm", "network.tf"), "variable \"bridge\" {\n  default = \"vmbr0\"\n}\n")
+	modules, err = svc.scanTerraformModules(base, "https://git.example.com/modules.git")
+	require.NoError(t, err)
+	assert.Empty(t, modules[0].ParseWarnings)
+	assert.Len(t, modules[0].Variables, 2)
+	require.NoError(t, svc.syncModulesToDatabase(context.Background(), modules))
+	assert.Empty(t, stored.ParseWarnings)
+}
+
+func TestCheckTerraformSyntax(t *testing.T) {
+	require.NoError(t, checkTerraformSyntax(testVariablesTF))
+	require.NoError(t, checkTerraformSyntax(readTestFile(t, filepath.Join("testdata", "variables_complex.tf"))))
+	require.NoError(t, checkTerraformSyntax("locals {\n  name = \"${var.env == \"prod\" ? \"p\" : \"np\"}-vm\" # \"quoted\" {\n  /* } */ literal = \"$${not} \\\"escaped\\\"\"\n}\n"))
+
+	for name, tc := range map[string]struct {
+		content string
+		message string
+	}{
+		"unclosed block":         {"variable \"a\" {\n  type = string\n", "line 1: bracket is never closed, '}' expected"},
+		"stray closer":           {"variable \"a\" {}\n}\n", "line 2: unexpected '}'"},
+		"mismatched brackets":    {"locals {\n  a = [1, 2}\n}\n", "line 2: unexpected '}'"},
+		"unclosed string":        {"locals {\n  a = \"x\n}\n", "line 2: string is never closed"},
+		"unclosed interpolation": {"locals {\n  a = \"${var.x\"\n}\n", "line 2: string is never closed"},
+		"unclosed heredoc":       {"locals {\n  a = <<EOT\nx\n}\n", "line 2: heredoc is never closed"},
+		"unclosed comment":       {"/* note\nvariable \"a\" {}\n", "line 1: comment is never closed"},
+		"invalid utf-8":          {"variable \"\xff\" {}\n", "file is not valid UTF-8"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := checkTerraformSyntax(tc.content)
+			require.Error(t, err)
+			assert.Equal(t, tc.message, err.Error())
+		})
+	}
+}
+
+func TestParseModuleMarkers(t *testing.T) {
+	assert.Equal(t, []string{"a.tf", "b.tf"}, parseModuleMarkers("a.tf, b.tf"))
+	assert.Equal(t, defaultModuleMarkers, parseModuleMarkers(" , "))
+}
+
+const testVariablesTF = `variable "cores" {
+  type = number
+}
+
+variable "memory" {
+  type    = number
+  default = 2048
+}
+
+variable "tags" {
+  type = map(string)
+  default = {
+    env = "dev"
+  }
+}
+
+variable "hostname" {}
+`
+
+func TestParseVariableBlocks(t *testing.T) {
+	assert.Equal(t, []ModuleVariable{
+		{Name: "cores", Type: "number", Required: true},
+		{Name: "memory", Type: "number", Required: false, Default: float64(2048)},
+		{Name: "tags", Type: "map(string)", Required: false, Default: map[string]interface{}{"env": "dev"}},
+		{Name: "hostname", Required: true},
+	}, parseVariableBlocks(testVariablesTF))
+
+	t.Run("collections, descriptions and flags", func(t *testing.T) {
+		variables := parseVariableBlocks(readTestFile(t, filepath.Join("testdata", "variables_complex.tf")))
+		byName := make(map[string]ModuleVariable, len(variables))
+		for _, v := range variables {
+			byName[v.Name] = v
+		}
+		require.Len(t, byName, 9)
+
+		assert.Equal(t, ModuleVariable{
+			Name: "disks", Type: "list(object({ size = number, storage = string }))",
+			Description: "Disks to attach, in order.",
+			Default: []interface{}{
+				map[string]interface{}{"size": float64(20), "storage": "local-lvm"},
+				map[string]interface{}{"size": float64(100), "storage": "ceph"},
+			},
+		}, byName["disks"])
+		assert.Equal(t, map[string]interface{}{
+			"owner": "platform", "cost-center": "lab", "backup": true,
+		}, byName["labels"].Default)
+		assert.Equal(t, []interface{}{"10.0.0.53", "10.0.1.53"}, byName["dns_servers"].Default)
+		assert.Equal(t, "object({ vlan = number, bridge = optional(string, \"vmbr0\") })", byName["network"].Type)
+		assert.Equal(t, map[string]interface{}{"vlan": float64(100), "bridge": "vmbr1"}, byName["network"].Default)
+
+		assert.True(t, byName["root_password"].Sensitive)
+		assert.True(t, byName["root_password"].Required)
+		assert.Equal(t, "Initial root password.", byName["root_password"].Description)
+
+		assert.Equal(t, "#cloud-config\npackages:\n  - qemu-guest-agent\n", byName["user_data"].Default)
+		assert.Equal(t, "Cloud-init user data.\nRendered as is.\n", byName["user_data"].Description)
+
+		assert.False(t, byName["name_prefix"].Required, "an expression default still makes the variable optional")
+		assert.Nil(t, byName["name_prefix"].Default)
+		assert.False(t, byName["optional_null"].Required)
+		assert.Nil(t, byName["optional_null"].Default)
+		assert.Equal(t, "string", byName["validated"].Type)
+		assert.True(t, byName["validated"].Required, "the validation block does not count as a default")
+	})
+}
+
+func TestGitService_GenerateTerragruntConfigValidatesInputs(t *testing.T) {
+	base := t.TempDir()
+	writeTestFile(t, filepath.Join(base, "vm", "variables.tf"), testVariablesTF)
+
+	svc := &gitService{logger: zap.NewNop(), moduleMarkers: defaultModuleMarkers}
+	modules, err := svc.scanTerraformModules(base, "https://git.example.com/modules.git")
+	require.NoError(t, err)
+	require.Len(t, modules, 1)
+	var required []string
+	for _, v := range modules[0].Variables {
+		if v.Required {
+			required = append(required, v.Name)
+		}
+	}
+	assert.Equal(t, []string{"cores", "hostname"}, required)
+
+	variablesJSON, err := json.Marshal(modules[0].moduleVariables())
+	require.NoError(t, err)
+	module := &model.TerraformModule{Source: modules[0].Source, Variables: string(variablesJSON)}
+
+	t.Run("declared inputs pass", func(t *testing.T) {
+		request := &model.ResourceRequest{Title: "vm", Spec: `{"cores":2,"hostname":"vm-1"}`, TfModule: module}
+		config, err := svc.generateTerragruntConfig(request, "vm-1", nil, nil)
+		require.NoError(t, err)
+		assert.Contains(t, config, "cores    = 2")
+	})
+
+	t.Run("unknown input and missing required variable are rejected", func(t *testing.T) {
+		request := &model.ResourceRequest{Title: "vm", Spec: `{"cores":2,"disk":20}`, TfModule: module}
+		_, err := svc.generateTerragruntConfig(request, "vm-1", nil, nil)
+		require.ErrorIs(t, err, ErrInvalidModuleInputs)
+
+		var inputErr *ModuleInputError
+		require.True(t, errors.As(err, &inputErr))
+		assert.Equal(t, []string{"disk"}, inputErr.Unknown)
+		assert.Equal(t, []string{"hostname"}, inputErr.Missing)
+	})
+
+	t.Run("defaulted variables left to the module are not emitted", func(t *testing.T) {
+		request := &model.ResourceRequest{Title: "vm", Spec: `{"cores":2,"hostname":"vm-1","memory":2048,"tags":null}`, TfModule: module}
+		config, err := svc.generateTerragruntConfig(request, "vm-1", nil, nil)
+		require.NoError(t, err)
+		assert.Contains(t, config, "hostname = \"vm-1\"")
+		assert.NotContains(t, config, "memory")
+		assert.NotContains(t, config, "tags")
+
+		request.Spec = `{"cores":2,"hostname":"vm-1","memory":4096}`
+		config, err = svc.generateTerragruntConfig(request, "vm-1", nil, nil)
+		require.NoError(t, err)
+		assert.Contains(t, config, "memory   = 4096")
+	})
+
+	t.Run("required variable set to null is missing", func(t *testing.T) {
+		request := &model.ResourceRequest{Title: "vm", Spec: `{"cores":2,"hostname":null}`, TfModule: module}
+		_, err := svc.generateTerragruntConfig(request, "vm-1", nil, nil)
+
+		var inputErr *ModuleInputError
+		require.True(t, errors.As(err, &inputErr))
+		assert.Equal(t, []string{"hostname"}, inputErr.Missing)
+	})
+
+	t.Run("legacy variable names only flag unknown inputs", func(t *testing.T) {
+		legacy := &model.TerraformModule{Source: module.Source, Variables: `["cores","hostname"]`}
+		request := &model.ResourceRequest{Title: "vm", Spec: `{"cores":2}`, TfModule: legacy}
+		_, err := svc.generateTerragruntConfig(request, "vm-1", nil, nil)
+		require.NoError(t, err)
+	})
+}
+
+// pathNodeConfigRepository reports a fixed set of node config paths as taken and returns
+// the node configs of requests from byRequest.
+type pathNodeConfigRepository struct {
+	repository.NodeConfigRepository
+	taken     map[string]bool
+	byRequest map[string]*model.NodeConfig
+}
+
+func (r *pathNodeConfigRepository) ExistsByPath(_ context.Context, storageRepoID, path string) (bool, error) {
+	return r.taken[storageRepoID+":"+path], nil
+}
+
+func (r *pathNodeConfigRepository) GetByResourceRequestID(_ context.Context, requestID string) (*model.NodeConfig, error) {
+	config, ok := r.byRequest[requestID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return config, nil
+}
+
+// memorySequenceRepository hands out per-scope counters from memory.
+type memorySequenceRepository struct {
+	mu     sync.Mutex
+	values map[string]int64
+}
+
+func (r *memorySequenceRepository) Next(_ context.Context, scope string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.values == nil {
+		r.values = make(map[string]int64)
+	}
+	r.values[scope]++
+	return r.values[scope], nil
+}
+
+func (r *memorySequenceRepository) Peek(_ context.Context, scope string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.values[scope] + 1, nil
+}
+
+func TestGitService_PreviewNodeConfig(t *testing.T) {
+	ctx := context.Background()
+	newRequest := func() *model.ResourceRequest {
+		return &model.ResourceRequest{Title: "Minio Node", Provider: "pve", Type: "vm", Environment: "dev", Spec: `{"cores":2}`}
+	}
+
+	t.Run("inline module without a storage repo", func(t *testing.T) {
+		sequences := &memorySequenceRepository{}
+		svc := &gitService{
+			logger:         zap.NewNop(),
+			gitRepoRepo:    &stubGitRepoRepository{repos: map[string]*model.GitRepository{}},
+			nodeConfigRepo: &pathNodeConfigRepository{taken: map[string]bool{}},
+			sequenceRepo:   sequences,
+		}
+		request := newRequest()
+		request.TfModule = &model.TerraformModule{Source: "git::https://git.example.com/modules.git//vm", Version: "v1.2.0"}
+
+		preview, err := svc.PreviewNodeConfig(ctx, request)
+		require.NoError(t, err)
+		assert.Equal(t, "minio-node-001", preview.Name)
+		assert.Equal(t, filepath.Join("pve", "instance", "vm", "minio-node-001"), preview.Path)
+		assert.Empty(t, preview.StorageRepoID)
+		assert.Contains(t, preview.TerragruntConfig, `source = "git::https://git.example.com/modules.git//vm?ref=v1.2.0"`)
+		assert.Contains(t, preview.TerragruntConfig, "cores = 2")
+		assert.Empty(t, sequences.values, "previewing takes no number")
+	})
+
+	t.Run("registered module and taken paths", func(t *testing.T) {
+		modules := newStubModuleRepository()
+		modules.bySource["git::https://git.example.com/modules.git//ct"] = &model.TerraformModule{
+			BaseModel: model.BaseModel{ID: "module-1"}, Source: "git::https://git.example.com/modules.git//ct",
+		}
+		storage := &model.GitRepository{BaseModel: model.BaseModel{ID: "storage-1"}, Type: model.GitRepoTypeStorage, IsDefault: true}
+		svc := &gitService{
+			logger:       zap.NewNop(),
+			gitRepoRepo:  &stubGitRepoRepository{repos: map[string]*model.GitRepository{storage.ID: storage}},
+			tfModuleRepo: modules,
+			nodeConfigRepo: &pathNodeConfigRepository{taken: map[string]bool{
+				"storage-1:" + filepath.Join("pve", "instance", "vm", "minio-node-001"): true,
+				"storage-1:" + filepath.Join("pve", "instance", "vm", "minio-node-002"): true,
+			}},
+			sequenceRepo: &memorySequenceRepository{},
+		}
+		request := newRequest()
+		moduleID := "module-1"
+		request.TfModuleID = &moduleID
+
+		for range 2 {
+			preview, err := svc.PreviewNodeConfig(ctx, request)
+			require.NoError(t, err)
+			assert.Equal(t, "minio-node-003", preview.Name)
+			assert.Equal(t, "storage-1", preview.StorageRepoID)
+			assert.Contains(t, preview.TerragruntConfig, `source = "git::https://git.example.com/modules.git//ct"`)
+		}
+
+		missing := "module-2"
+		request = newRequest()
+		request.TfModuleID = &missing
+		_, err := svc.PreviewNodeConfig(ctx, request)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("invalid inputs are reported", func(t *testing.T) {
+		svc := &gitService{
+			logger:       zap.NewNop(),
+			gitRepoRepo:  &stubGitRepoRepository{repos: map[string]*model.GitRepository{}},
+			sequenceRepo: &memorySequenceRepository{},
+		}
+		request := newRequest()
+		request.VarOverrides = map[string]string{"pm_password": "hunter2"}
+
+		_, err := svc.PreviewNodeConfig(ctx, request)
+		require.ErrorIs(t, err, ErrInvalidVarOverride)
+	})
+}
+
+func TestGitService_ResolveNodePath(t *testing.T) {
+	storageRepo := &model.GitRepository{BaseModel: model.BaseModel{ID: "storage-1"}}
+	newRequest := func(id, title string) *model.ResourceRequest {
+		return &model.ResourceRequest{BaseModel: model.BaseModel{ID: id}, Title: title, Provider: "proxmox-ve", Type: "vm"}
+	}
+	request := newRequest("1234abcd-5678-90ef-1234-567890abcdef", "Minio Node")
+
+	t.Run("repeated titles get consecutive numbers", func(t *testing.T) {
+		svc := &gitService{logger: zap.NewNop(), nodeConfigRepo: &pathNodeConfigRepository{taken: map[string]bool{}}, sequenceRepo: &memorySequenceRepository{}}
+
+		var names []string
+		for _, id := range []string{"1234abcd-0000", "1234abcd-1111", "1234abcd-2222"} {
+			name, path, err := svc.resolveNodePath(context.Background(), newRequest(id, "Minio Node"), storageRepo)
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join("proxmox-ve", "instance", "vm", name), path)
+			names = append(names, name)
+		}
+		assert.Equal(t, []string{"minio-node-001", "minio-node-002", "minio-node-003"}, names)
+
+		name, _, err := svc.resolveNodePath(context.Background(), &model.ResourceRequest{Title: "Minio Node", Provider: "proxmox-ve", Type: "container"}, storageRepo)
+		require.NoError(t, err)
+		assert.Equal(t, "minio-node-001", name, "each provider and type is numbered separately")
+	})
+
+	t.Run("taken paths are skipped", func(t *testing.T) {
+		repo := &pathNodeConfigRepository{taken: map[string]bool{
+			"storage-1:" + filepath.Join("proxmox-ve", "instance", "vm", "minio-node-001"): true,
+		}}
+		svc := &gitService{logger: zap.NewNop(), nodeConfigRepo: repo, sequenceRepo: &memorySequenceRepository{}}
+
+		name, path, err := svc.resolveNodePath(context.Background(), request, storageRepo)
+		require.NoError(t, err)
+		assert.Equal(t, "minio-node-002", name)
+		assert.Equal(t, filepath.Join("proxmox-ve", "instance", "vm", name), path)
+	})
+
+	t.Run("other storage repo does not collide", func(t *testing.T) {
+		repo := &pathNodeConfigRepository{taken: map[string]bool{
+			"storage-2:" + filepath.Join("proxmox-ve", "instance", "vm", "minio-node-001"): true,
+		}}
+		svc := &gitService{logger: zap.NewNop(), nodeConfigRepo: repo, sequenceRepo: &memorySequenceRepository{}}
+
+		name, _, err := svc.resolveNodePath(context.Background(), request, storageRepo)
+		require.NoError(t, err)
+		assert.Equal(t, "minio-node-001", name)
+	})
+
+	t.Run("concurrent requests get distinct names", func(t *testing.T) {
+		svc := &gitService{logger: zap.NewNop(), nodeConfigRepo: &pathNodeConfigRepository{taken: map[string]bool{}}, sequenceRepo: &memorySequenceRepository{}}
+
+		const requests = 20
+		names := make([]string, requests)
+		var wg sync.WaitGroup
+		for i := range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				name, _, err := svc.resolveNodePath(context.Background(), newRequest(fmt.Sprintf("req-%d", i), "web"), storageRepo)
+				assert.NoError(t, err)
+				names[i] = name
+			}()
+		}
+		wg.Wait()
+
+		sort.Strings(names)
+		for i, name := range names {
+			assert.Equal(t, fmt.Sprintf("web-%03d", i+1), name)
+		}
+	})
+
+	t.Run("all candidates taken", func(t *testing.T) {
+		taken := map[string]bool{}
+		for n := 1; n <= maxPathCandidates; n++ {
+			taken["storage-1:"+filepath.Join("proxmox-ve", "instance", "vm", fmt.Sprintf("minio-node-%03d", n))] = true
+		}
+		svc := &gitService{logger: zap.NewNop(), nodeConfigRepo: &pathNodeConfigRepository{taken: taken}, sequenceRepo: &memorySequenceRepository{}}
+
+		_, _, err := svc.resolveNodePath(context.Background(), request, storageRepo)
+		assert.ErrorIs(t, err, ErrConfigPathCollision)
+	})
+}
+
+func TestGitService_ScanModuleReadme(t *testing.T) {
+	base := t.TempDir()
+	writeTestFile(t, filepath.Join(base, "vm", "main.tf"), "")
+	writeTestFile(t, filepath.Join(base, "vm", "README.md"), `---
+display_name: Proxmox Virtual Machine
+category: compute
+icon: server
+tags: [proxmox, " vm ", ""]
+---
+# Proxmox VM
+
+Creates a QEMU virtual machine from a cloud-init template.
+`)
+	writeTestFile(t, filepath.Join(base, "dns", "main.tf"), "")
+	writeTestFile(t, filepath.Join(base, "dns", "README.md"), "# DNS record\n\nManages a PowerDNS record.\n\n---\n\nMore details.\n")
+
+	svc := &gitService{logger: zap.NewNop(), moduleMarkers: defaultModuleMarkers}
+	modules, err := svc.scanTerraformModules(base, "https://git.example.com/modules.git")
+	require.NoError(t, err)
+	require.Len(t, modules, 2)
+
+	byName := map[string]GitModule{}
+	for _, m := range modules {
+		byName[m.Name] = m
+	}
+
+	vm := byName["vm"]
+	assert.Equal(t, "Proxmox Virtual Machine", vm.DisplayName)
+	assert.Equal(t, "compute", vm.Category)
+	assert.Equal(t, "server", vm.Icon)
+	assert.Equal(t, []string{"proxmox", "vm"}, vm.Tags)
+	assert.Equal(t, "Creates a QEMU virtual machine from a cloud-init template.", vm.Description)
+
+	dns := byName["dns"]
+	assert.Empty(t, dns.DisplayName)
+	assert.Empty(t, dns.Tags)
+	assert.Equal(t, "Manages a PowerDNS record.", dns.Description)
+
+	stored := &model.TerraformModule{}
+	vm.applyMetadata(stored)
+	assert.Equal(t, "proxmox,vm", stored.Tags)
+}
+
+func TestParseModuleReadme(t *testing.T) {
+	t.Run("front-matter description wins", func(t *testing.T) {
+		meta := parseModuleReadme("---\ndescription: From front-matter\n---\nBody line\n")
+		assert.Equal(t, "From front-matter", meta.Description)
+	})
+
+	t.Run("malformed front-matter falls back to body", func(t *testing.T) {
+		meta := parseModuleReadme("---\ntags: [unclosed\n---\nBody line\n")
+		assert.Empty(t, meta.Tags)
+		assert.Equal(t, "Body line", meta.Description)
+	})
+
+	t.Run("unterminated front-matter is treated as body", func(t *testing.T) {
+		meta := parseModuleReadme("---\ncategory: compute\n")
+		assert.Empty(t, meta.Category)
+	})
+}
+
+// scriptedGitRunner answers git commands from a table keyed by the joined arguments and records
+// every call. Commands missing from the table succeed with empty output.
+func scriptedGitRunner(outputs map[string]string, calls *[]string) gitRunner {
+	return func(_ context.Context, _ string, _ []string, args ...string) (string, error) {
+		call := strings.Join(args, " ")
+		*calls = append(*calls, call)
+		return outputs[call], nil
+	}
+}
+
+func TestGitService_CommitAndPush(t *testing.T) {
+	repoPath := t.TempDir()
+	file := filepath.Join(repoPath, "live", "vm", "terragrunt.hcl")
+	statusCall := "status --porcelain -- " + filepath.Join("live", "vm", "terragrunt.hcl")
+	modified := " M " + filepath.Join("live", "vm", "terragrunt.hcl") + "\n"
+
+	t.Run("unchanged files are not committed", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: scriptedGitRunner(map[string]string{
+			"rev-parse HEAD": "abc123\n",
+		}, &calls)}
+
+		sha, err := svc.CommitAndPush(context.Background(), &model.GitRepository{}, repoPath, []string{file}, "add vm")
+		require.ErrorIs(t, err, ErrNothingToCommit)
+		assert.Equal(t, "abc123", sha, "the SHA is the existing HEAD")
+		assert.Equal(t, []string{statusCall, "rev-parse HEAD"}, calls)
+	})
+
+	t.Run("commit finding nothing to commit is not a failure", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: func(_ context.Context, _ string, _ []string, args ...string) (string, error) {
+			call := strings.Join(args, " ")
+			calls = append(calls, call)
+			switch call {
+			case statusCall:
+				return modified, nil
+			case "rev-parse --abbrev-ref HEAD":
+				return "main\n", nil
+			case "rev-parse HEAD":
+				return "abc123\n", nil
+			case "commit -m add vm":
+				return "On branch main\nnothing to commit, working tree clean\n", errors.New("exit status 1")
+			}
+			return "", nil
+		}}
+
+		sha, err := svc.CommitAndPush(context.Background(), &model.GitRepository{}, repoPath, []string{file}, "add vm")
+		require.ErrorIs(t, err, ErrNothingToCommit)
+		assert.Equal(t, "abc123", sha)
+		assert.NotContains(t, calls, "push")
+	})
+
+	t.Run("new file is committed", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: scriptedGitRunner(map[string]string{
+			statusCall:                    "?? " + filepath.Join("live", "vm") + "/\n",
+			"rev-parse --abbrev-ref HEAD": "main\n",
+			"rev-parse HEAD":              "abc123\n",
+		}, &calls)}
+
+		_, err := svc.CommitAndPush(context.Background(), &model.GitRepository{}, repoPath, []string{file}, "add vm")
+		require.NoError(t, err)
+		assert.Contains(t, calls, "commit -m add vm")
+		assert.Contains(t, calls, "push")
+	})
+
+	t.Run("branch checkout pushes to its upstream", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: scriptedGitRunner(map[string]string{
+			statusCall:                    modified,
+			"rev-parse --abbrev-ref HEAD": "main\n",
+			"rev-parse HEAD":              "abc123\n",
+		}, &calls)}
+
+		sha, err := svc.CommitAndPush(context.Background(), &model.GitRepository{}, repoPath, []string{file}, "add vm")
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", sha)
+		assert.Equal(t, []string{
+			statusCall,
+			"rev-parse --abbrev-ref HEAD",
+			"add " + filepath.Join("live", "vm", "terragrunt.hcl"),
+			"commit -m add vm",
+			"push",
+			"rev-parse HEAD",
+		}, calls)
+	})
+
+	t.Run("detached HEAD commits to a new branch", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: scriptedGitRunner(map[string]string{
+			statusCall:                    modified,
+			"rev-parse --abbrev-ref HEAD": "HEAD\n",
+			"rev-parse --short=12 HEAD":   "0123456789ab\n",
+			"rev-parse HEAD":              "def456\n",
+		}, &calls)}
+
+		sha, err := svc.CommitAndPush(context.Background(), &model.GitRepository{}, repoPath, []string{file}, "add vm")
+		require.NoError(t, err)
+		assert.Equal(t, "def456", sha)
+		assert.Equal(t, []string{
+			statusCall,
+			"rev-parse --abbrev-ref HEAD",
+			"rev-parse --short=12 HEAD",
+			"checkout -b vc-lab/detached-0123456789ab",
+			"add " + filepath.Join("live", "vm", "terragrunt.hcl"),
+			"commit -m add vm",
+			"push --set-upstream origin vc-lab/detached-0123456789ab",
+			"rev-parse HEAD",
+		}, calls)
+	})
+
+	t.Run("failed branch creation stops before committing", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: func(_ context.Context, _ string, _ []string, args ...string) (string, error) {
+			call := strings.Join(args, " ")
+			calls = append(calls, call)
+			switch call {
+			case statusCall:
+				return modified, nil
+			case "rev-parse --abbrev-ref HEAD":
+				return "HEAD\n", nil
+			case "rev-parse --short=12 HEAD":
+				return "0123456789ab\n", nil
+			case "checkout -b vc-lab/detached-0123456789ab":
+				return "fatal: a branch named 'vc-lab/detached-0123456789ab' already exists", errors.New("exit status 128")
+			}
+			return "", nil
+		}}
+
+		_, err := svc.CommitAndPush(context.Background(), &model.GitRepository{}, repoPath, []string{file}, "add vm")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "detached HEAD")
+		assert.NotContains(t, calls, "commit -m add vm")
+	})
+
+	// rejectingPushRunner rejects the given number of pushes as non-fast-forward and
+	// reports a new HEAD once the commit has been rebased.
+	rejectingPushRunner := func(calls *[]string, rejections int, rebaseErr error) gitRunner {
+		head := "abc123\n"
+		return func(_ context.Context, _ string, _ []string, args ...string) (string, error) {
+			call := strings.Join(args, " ")
+			*calls = append(*calls, call)
+			switch call {
+			case statusCall:
+				return modified, nil
+			case "rev-parse --abbrev-ref HEAD":
+				return "main\n", nil
+			case "rev-parse HEAD":
+				return head, nil
+			case "push":
+				if rejections > 0 {
+					rejections--
+					return " ! [rejected]        main -> main (fetch first)\n" +
+						"hint: Updates were rejected because the remote contains work that you do not have locally.", errors.New("exit status 1")
+				}
+			case "pull --rebase":
+				if rebaseErr != nil {
+					return "CONFLICT (content): Merge conflict in live/vm/terragrunt.hcl", rebaseErr
+				}
+				head = "fed789\n"
+			}
+			return "", nil
+		}
+	}
+
+	t.Run("rejected push is rebased and retried", func(t *testing.T) {
+		writeTestFile(t, file, "inputs = {}\n")
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: rejectingPushRunner(&calls, 1, nil)}
+
+		sha, err := svc.CommitAndPush(context.Background(), &model.GitRepository{}, repoPath, []string{file}, "add vm")
+		require.NoError(t, err)
+		assert.Equal(t, "fed789", sha, "the SHA is the rebased commit that was pushed")
+		assert.Equal(t, []string{
+			statusCall,
+			"rev-parse --abbrev-ref HEAD",
+			"add " + filepath.Join("live", "vm", "terragrunt.hcl"),
+			"commit -m add vm",
+			"push",
+			"pull --rebase",
+			"push",
+			"rev-parse HEAD",
+		}, calls)
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "inputs = {}\n", string(content), "the committed file is left untouched")
+	})
+
+	t.Run("push still rejected after retries is a conflict", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: rejectingPushRunner(&calls, maxPushAttempts, nil)}
+
+		_, err := svc.CommitAndPush(context.Background(), &model.GitRepository{}, repoPath, []string{file}, "add vm")
+		require.ErrorIs(t, err, ErrPushConflict)
+		pushes := 0
+		for _, call := range calls {
+			if call == "push" {
+				pushes++
+			}
+		}
+		assert.Equal(t, maxPushAttempts, pushes)
+	})
+
+	t.Run("conflicting rebase is aborted", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: rejectingPushRunner(&calls, 1, errors.New("exit status 1"))}
+
+		_, err := svc.CommitAndPush(context.Background(), &model.GitRepository{}, repoPath, []string{file}, "add vm")
+		require.ErrorIs(t, err, ErrPushConflict)
+		assert.Contains(t, err.Error(), "Merge conflict")
+		assert.Equal(t, "rebase --abort", calls[len(calls)-1])
+	})
+
+	t.Run("other push failures are not retried", func(t *testing.T) {
+		var calls []string
+		svc := &gitService{logger: zap.NewNop(), runGit: func(_ context.Context, _ string, _ []string, args ...string) (string, error) {
+			call := strings.Join(args, " ")
+			calls = append(calls, call)
+			if call == "push" {
+				return "fatal: Authentication failed", errors.New("exit status 128")
+			}
+			return "main\n", nil
+		}}
+
+		_, err := svc.CommitAndPush(context.Background(), &model.GitRepository{}, repoPath, []string{file}, "add vm")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrPushConflict)
+		assert.NotContains(t, calls, "pull --rebase")
+	})
+}
+
+// gitCall records the arguments and extra environment of one git invocation.
+type gitCall struct {
+	args []string
+	env  []string
+}
+
+// recordingGitRunner records every call and reports a modified file for status, so that
+// commits go ahead.
+func recordingGitRunner(calls *[]gitCall) gitRunner {
+	return func(_ context.Context, _ string, env []string, args ...string) (string, error) {
+		*calls = append(*calls, gitCall{args: args, env: env})
+		if args[0] == "status" {
+			return " M main.tf\n", nil
+		}
+		return "", nil
+	}
+}
+
+func TestGitService_RemoteAuth(t *testing.T) {
+	header := "GIT_CONFIG_VALUE_0=Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte("deploy:s3cret"))
+
+	t.Run("token clone passes credentials as a header, not in the URL", func(t *testing.T) {
+		var calls []gitCall
+		svc := &gitService{logger: zap.NewNop(), runGit: recordingGitRunner(&calls)}
+		repo := &model.GitRepository{
+			URL:      "https://git.example.com/lab/infra.git",
+			Branch:   "main",
+			AuthType: model.GitAuthTypeToken,
+			Username: "deploy",
+			Token:    "s3cret",
+		}
+
+		require.NoError(t, svc.CloneRepository(context.Background(), repo, filepath.Join(t.TempDir(), "clone")))
+		require.Len(t, calls, 1)
+		assert.Contains(t, calls[0].args, "https://git.example.com/lab/infra.git")
+		for _, arg := range calls[0].args {
+			assert.NotContains(t, arg, "s3cret")
+			assert.NotContains(t, arg, "deploy@")
+		}
+		assert.Contains(t, calls[0].env, "GIT_CONFIG_KEY_0=http.extraHeader")
+		assert.Contains(t, calls[0].env, header)
+		assert.Contains(t, calls[0].env, "GIT_TERMINAL_PROMPT=0")
+	})
+
+	t.Run("password auth without username uses the default username", func(t *testing.T) {
+		env := gitAuthEnv(&model.GitRepository{
+			URL:      "https://git.example.com/lab/infra.git",
+			AuthType: model.GitAuthTypePassword,
+			Token:    "pw",
+		})
+		assert.Contains(t, env, "GIT_CONFIG_VALUE_0=Authorization: Basic "+base64.StdEncoding.EncodeToString([]byte("git:pw")))
+	})
+
+	t.Run("no credentials for public, ssh or plain http remotes", func(t *testing.T) {
+		assert.Nil(t, gitAuthEnv(&model.GitRepository{URL: "https://git.example.com/lab/infra.git", AuthType: model.GitAuthTypeNone}))
+		assert.Nil(t, gitAuthEnv(&model.GitRepository{URL: "git@git.example.com:lab/infra.git", AuthType: model.GitAuthTypeSSHKey, SSHKey: "key"}))
+		assert.Nil(t, gitAuthEnv(&model.GitRepository{URL: "http://git.example.com/lab/infra.git", AuthType: model.GitAuthTypeToken, Token: "s3cret"}))
+	})
+
+	t.Run("push and pull are authenticated, local commands are not", func(t *testing.T) {
+		var calls []gitCall
+		svc := &gitService{logger: zap.NewNop(), runGit: recordingGitRunner(&calls)}
+		repo := &model.GitRepository{
+			URL:      "https://git.example.com/lab/infra.git",
+			AuthType: model.GitAuthTypeToken,
+			Username: "deploy",
+			Token:    "s3cret",
+		}
+		repoPath := t.TempDir()
+
+		require.NoError(t, svc.PullChanges(context.Background(), repo, repoPath))
+		_, err := svc.CommitAndPush(context.Background(), repo, repoPath, []string{filepath.Join(repoPath, "main.tf")}, "update")
+		require.NoError(t, err)
+
+		for _, call := range calls {
+			switch call.args[0] {
+			case "pull", "push":
+				assert.Contains(t, call.env, header, "git %s", call.args[0])
+			default:
+				assert.Empty(t, call.env, "git %s", call.args[0])
+			}
+		}
+	})
+}
+
+// retryNodeConfigRepository lists a fixed set of failed configs and resets those still failed.
+type retryNodeConfigRepository struct {
+	repository.NodeConfigRepository
+	listed  []model.NodeConfig
+	failed  map[string]bool
+	filters repository.NodeConfigFilters
+	limit   int
+}
+
+func (r *retryNodeConfigRepository) List(_ context.Context, filters repository.NodeConfigFilters, limit int) ([]model.NodeConfig, error) {
+	r.filters = filters
+	r.limit = limit
+	return r.listed, nil
+}
+
+func (r *retryNodeConfigRepository) ResetForRetry(_ context.Context, id string) (bool, error) {
+	if !r.failed[id] {
+		return false, nil
+	}
+	r.failed[id] = false
+	return true, nil
+}
+
+func TestGitService_RetryFailedNodeConfigs(t *testing.T) {
+	repo := &retryNodeConfigRepository{
+		listed: []model.NodeConfig{
+			{BaseModel: model.BaseModel{ID: "cfg-1"}, ResourceRequestID: "req-1", Status: model.NodeConfigStatusFailed},
+			{BaseModel: model.BaseModel{ID: "cfg-2"}, ResourceRequestID: "req-2", Status: model.NodeConfigStatusFailed},
+			{BaseModel: model.BaseModel{ID: "cfg-3"}, ResourceRequestID: "req-3", Status: model.NodeConfigStatusFailed},
+		},
+		// cfg-2 recovered after it was listed
+		failed: map[string]bool{"cfg-1": true, "cfg-3": true},
+	}
+	bus := events.NewBus(zap.NewNop())
+	var queued []string
+	bus.Subscribe(events.NodeConfigRetryRequested, func(_ context.Context, event events.Event) {
+		assert.Equal(t, string(model.NodeConfigStatusApproved), event.Data["status"])
+		queued = append(queued, event.NodeConfigID)
+	})
+	svc := &gitService{logger: zap.NewNop(), nodeConfigRepo: repo, eventBus: bus}
+
+	summary, err := svc.RetryFailedNodeConfigs(context.Background(), NodeConfigRetryFilter{
+		StorageRepoID: "storage-1",
+		Provider:      "pve",
+		FailedFor:     time.Hour,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, summary.Matched)
+	assert.Equal(t, []string{"cfg-1", "cfg-3"}, summary.Retried)
+	assert.Equal(t, []string{"cfg-2"}, summary.Skipped)
+	assert.Empty(t, summary.Errors)
+	assert.Equal(t, []string{"cfg-1", "cfg-3"}, queued, "only reset configs are queued")
+
+	assert.Equal(t, model.NodeConfigStatusFailed, repo.filters.Status)
+	assert.Equal(t, "storage-1", repo.filters.StorageRepoID)
+	assert.Equal(t, "pve", repo.filters.Provider)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), repo.filters.UpdatedBefore, time.Minute)
+	assert.Equal(t, constants.MaxNodeConfigRetryBatch, repo.limit)
+}
+
+func TestGitService_GenerateTerragruntConfigVarOverrides(t *testing.T) {
+	svc := &gitService{logger: zap.NewNop()}
+	module := &model.TerraformModule{
+		Source:    "git::https://git.example.com/modules.git//vm",
+		Variables: `[{"name":"cores","required":true},{"name":"image","required":false},{"name":"pm_password","required":false}]`,
+	}
+
+	t.Run("allowed override is applied to inputs", func(t *testing.T) {
+		request := &model.ResourceRequest{
+			Title:        "vm",
+			Spec:         `{"cores":2,"image":"ubuntu-22.04"}`,
+			VarOverrides: map[string]string{"image": "ami-0abc1234"},
+			TfModule:     module,
+		}
+		config, err := svc.generateTerragruntConfig(request, "vm-1", nil, nil)
+		require.NoError(t, err)
+		assert.Contains(t, config, `image = "ami-0abc1234"`)
+		assert.NotContains(t, config, "ubuntu-22.04")
+	})
+
+	t.Run("credential variable cannot be overridden", func(t *testing.T) {
+		request := &model.ResourceRequest{
+			Title:        "vm",
+			Spec:         `{"cores":2}`,
+			VarOverrides: map[string]string{"pm_password": "hunter2"},
+			TfModule:     module,
+		}
+		_, err := svc.generateTerragruntConfig(request, "vm-1", nil, nil)
+		require.ErrorIs(t, err, ErrInvalidVarOverride)
+	})
+
+	t.Run("override must be a module variable", func(t *testing.T) {
+		request := &model.ResourceRequest{
+			Title:        "vm",
+			Spec:         `{"cores":2}`,
+			VarOverrides: map[string]string{"ami": "ami-0abc1234"},
+			TfModule:     module,
+		}
+		_, err := svc.generateTerragruntConfig(request, "vm-1", nil, nil)
+		require.ErrorIs(t, err, ErrInvalidModuleInputs)
+	})
+}
+
+func TestValidateVarOverrides(t *testing.T) {
+	require.NoError(t, validateVarOverrides(nil))
+	require.NoError(t, validateVarOverrides(map[string]string{"image": "ami-1", "disk_size": "40"}))
+
+	for _, name := range []string{"proxmox_password", "api_token", "PM_API_TOKEN", "aws_secret_key", "db_password", "not valid", "1image"} {
+		assert.ErrorIs(t, validateVarOverrides(map[string]string{name: "x"}), ErrInvalidVarOverride, name)
+	}
+}
+
+// statusNodeConfigRepository holds a single node config in memory.
+type statusNodeConfigRepository struct {
+	repository.NodeConfigRepository
+	config *model.NodeConfig
+}
+
+func (r *statusNodeConfigRepository) GetByID(_ context.Context, _ string) (*model.NodeConfig, error) {
+	return r.config, nil
+}
+
+func (r *statusNodeConfigRepository) Update(_ context.Context, config *model.NodeConfig) error {
+	r.config = config
+	return nil
+}
+
+func TestGitService_UpdateNodeConfigStatusRecordsAddresses(t *testing.T) {
+	repo := &statusNodeConfigRepository{config: &model.NodeConfig{
+		BaseModel: model.BaseModel{ID: "cfg-1"},
+		Status:    model.NodeConfigStatusProvisioning,
+	}}
+	svc := &gitService{logger: zap.NewNop(), nodeConfigRepo: repo}
+
+	log := "module.vm.proxmox_vm_qemu.this[0]: Creating...\n" +
+		"module.vm.proxmox_vm_qemu.this[0]: Creation complete after 40s [id=pve/qemu/101]\n" +
+		"Apply complete! Resources: 1 added, 0 changed, 0 destroyed.\n"
+	require.NoError(t, svc.UpdateNodeConfigStatus(context.Background(), "cfg-1", model.NodeConfigStatusActive, log))
+
+	assert.Equal(t, []string{"module.vm.proxmox_vm_qemu.this[0]"}, repo.config.ResourceAddresses)
+	assert.Equal(t, log, repo.config.ProvisionLog)
+	assert.NotNil(t, repo.config.ProvisionedAt)
+}
+
+func TestGitService_NodeDependencies(t *testing.T) {
+	ctx := context.Background()
+	storage := &model.GitRepository{BaseModel: model.BaseModel{ID: "storage-1"}, Type: model.GitRepoTypeStorage, IsDefault: true}
+	network := &model.NodeConfig{Name: "network-001", Path: filepath.Join("pve", "instance", "network", "network-001"), StorageRepoID: storage.ID}
+	elsewhere := &model.NodeConfig{Name: "network-002", Path: filepath.Join("pve", "instance", "network", "network-002"), StorageRepoID: "storage-2"}
+	svc := &gitService{
+		logger:      zap.NewNop(),
+		gitRepoRepo: &stubGitRepoRepository{repos: map[string]*model.GitRepository{storage.ID: storage}},
+		nodeConfigRepo: &pathNodeConfigRepository{
+			taken:     map[string]bool{},
+			byRequest: map[string]*model.NodeConfig{"request-net": network, "request-other": elsewhere},
+		},
+		sequenceRepo: &memorySequenceRepository{},
+	}
+	newRequest := func(dependencies ...model.NodeDependency) *model.ResourceRequest {
+		return &model.ResourceRequest{
+			BaseModel: model.BaseModel{ID: "request-vm"},
+			Title:     "web", Provider: "pve", Type: "vm", Environment: "dev", Spec: `{"cores":2}`,
+			TfModule: &model.TerraformModule{
+				Source:    "git::https://git.example.com/modules.git//vm",
+				Variables: `[{"name":"cores"},{"name":"subnet_id","required":true}]`,
+			},
+			Dependencies: dependencies,
+		}
+	}
+
+	t.Run("dependency block references the sibling node's outputs", func(t *testing.T) {
+		request := newRequest(model.NodeDependency{Name: "net", RequestID: "request-net", Inputs: map[string]string{"subnet_id": "subnet_id"}})
+		preview, err := svc.PreviewNodeConfig(ctx, request)
+		require.NoError(t, err)
+		assert.Contains(t, preview.TerragruntConfig, "dependency \"net\" {\n  config_path = \"../../network/network-001\"\n}\n")
+		assert.Contains(t, preview.TerragruntConfig, "subnet_id = dependency.net.outputs.subnet_id\n")
+	})
+
+	t.Run("no dependencies leaves the layout unchanged", func(t *testing.T) {
+		request := newRequest()
+		request.Spec = `{"cores":2,"subnet_id":"subnet-1"}`
+		preview, err := svc.PreviewNodeConfig(ctx, request)
+		require.NoError(t, err)
+		assert.NotContains(t, preview.TerragruntConfig, "dependency")
+		assert.Contains(t, preview.TerragruntConfig, "}\n\ninputs = {")
+	})
+
+	t.Run("a dependency without a node config is rejected", func(t *testing.T) {
+		request := newRequest(model.NodeDependency{Name: "net", RequestID: "request-missing", Inputs: map[string]string{"subnet_id": "subnet_id"}})
+		_, err := svc.PreviewNodeConfig(ctx, request)
+		require.ErrorIs(t, err, ErrInvalidDependency)
+		assert.Contains(t, err.Error(), "request-missing")
+	})
+
+	t.Run("a dependency in another storage repository is rejected", func(t *testing.T) {
+		request := newRequest(model.NodeDependency{Name: "net", RequestID: "request-other", Inputs: map[string]string{"subnet_id": "subnet_id"}})
+		_, err := svc.PreviewNodeConfig(ctx, request)
+		require.ErrorIs(t, err, ErrInvalidDependency)
+	})
+}
+
+func TestValidateDependencies(t *testing.T) {
+	dep := func(name, requestID string, inputs map[string]string) model.NodeDependency {
+		return model.NodeDependency{Name: name, RequestID: requestID, Inputs: inputs}
+	}
+	tests := []struct {
+		name         string
+		dependencies []model.NodeDependency
+		wantErr      bool
+	}{
+		{name: "none", dependencies: nil},
+		{name: "ordering only", dependencies: []model.NodeDependency{dep("net", "request-1", nil)}},
+		{name: "valid", dependencies: []model.NodeDependency{dep("net", "request-1", map[string]string{"subnet_id": "id"}), dep("db", "request-2", map[string]string{"db_host": "address"})}},
+		{name: "invalid name", dependencies: []model.NodeDependency{dep("my net", "request-1", nil)}, wantErr: true},
+		{name: "duplicate name", dependencies: []model.NodeDependency{dep("net", "request-1", nil), dep("net", "request-2", nil)}, wantErr: true},
+		{name: "no request", dependencies: []model.NodeDependency{dep("net", "", nil)}, wantErr: true},
+		{name: "self", dependencies: []model.NodeDependency{dep("net", "request-self", nil)}, wantErr: true},
+		{name: "invalid output", dependencies: []model.NodeDependency{dep("net", "request-1", map[string]string{"subnet_id": "id}"})}, wantErr: true},
+		{name: "credential input", dependencies: []model.NodeDependency{dep("net", "request-1", map[string]string{"pm_password": "password"})}, wantErr: true},
+		{name: "input fed twice", dependencies: []model.NodeDependency{dep("a", "request-1", map[string]string{"x": "x"}), dep("b", "request-2", map[string]string{"x": "x"})}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDependencies("request-self", tt.dependencies)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidDependency)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+// sshKeyFileName is the name of the private key file written for an SSH git command.
+const sshKeyFileName = "id_git"
+
+// usesSSHKey reports whether git commands against repo authenticate with its SSH key.
+func usesSSHKey(repo *model.GitRepository) bool {
+	return repo != nil && repo.AuthType == model.GitAuthTypeSSHKey && strings.TrimSpace(repo.SSHKey) != ""
+}
+
+// writeSSHKey writes key to a private file in a new temporary directory and returns the
+// environment that makes git's ssh use it, with a cleanup func that removes the directory.
+// Each command gets its own directory so the key never lands in the shared work directory
+// and concurrent commands cannot remove each other's key.
+func writeSSHKey(key string) (env []string, cleanup func(), err error) {
+	dir, err := os.MkdirTemp("", "git-ssh-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create ssh key directory: %w", err)
+	}
+	cleanup = func() {
+		os.RemoveAll(dir) //nolint:errcheck,gosec // best effort cleanup
+	}
+
+	// OpenSSH rejects keys without a trailing newline or with Windows line endings
+	key = strings.TrimSpace(strings.ReplaceAll(key, "\r\n", "\n")) + "\n"
+	keyFile := filepath.Join(dir, sshKeyFileName)
+	if err := os.WriteFile(keyFile, []byte(key), 0o600); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write ssh key: %w", err)
+	}
+
+	return []string{"GIT_SSH_COMMAND=" + sshCommand(keyFile)}, cleanup, nil
+}
+
+// sshCommand returns the GIT_SSH_COMMAND that authenticates with keyFile. Git runs it
+// through the shell, so the path is quoted.
+func sshCommand(keyFile string) string {
+	quoted := "'" + strings.ReplaceAll(keyFile, "'", `'\''`) + "'"
+	return "ssh -i " + quoted + " -o StrictHostKeyChecking=accept-new"
+}
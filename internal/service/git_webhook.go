@@ -0,0 +1,178 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/notification"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Webhook errors.
+var (
+	// ErrInvalidWebhookSignature is returned when a delivery is not signed with the
+	// repository's webhook secret. Unknown repositories and repositories without a secret
+	// give the same error, so the endpoint does not reveal which exist.
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	// ErrWebhookReplayed is returned when a push delivery's signature was already accepted.
+	ErrWebhookReplayed = errors.New("webhook delivery was already received")
+	// ErrInvalidWebhookPayload is returned when a correctly signed delivery cannot be parsed.
+	ErrInvalidWebhookPayload = errors.New("invalid webhook payload")
+	// ErrWebhookKeyMissing is returned when a webhook secret is set but no key is configured
+	// to encrypt it.
+	ErrWebhookKeyMissing = errors.New("no webhook secret key is configured")
+)
+
+// gitPushEvent is the event name git hosts send for a push.
+const gitPushEvent = "push"
+
+// GitWebhookDelivery is a webhook delivery received from a git host. Hosts must sign it as
+// GitHub does.
+type GitWebhookDelivery struct {
+	Event     string // X-GitHub-Event; only push events trigger a resync
+	Signature string // X-Hub-Signature-256: "sha256=" and the hex HMAC-SHA256 of Body
+	Body      []byte
+}
+
+// GitWebhookResult reports what a webhook delivery did.
+type GitWebhookResult struct {
+	Synced  bool   `json:"synced"`
+	Modules int    `json:"modules"`           // Modules found by the resync
+	Ignored string `json:"ignored,omitempty"` // Why the delivery did not trigger a resync
+}
+
+// gitPushPayload is the part of a push event payload the resync looks at.
+type gitPushPayload struct {
+	Ref string `json:"ref"`
+}
+
+// webhookDeliveryLog remembers the signatures of accepted deliveries until they expire.
+type webhookDeliveryLog struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// record remembers key until now+window and reports whether it was not already remembered.
+func (l *webhookDeliveryLog) record(key string, now time.Time, window time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.seen == nil {
+		l.seen = make(map[string]time.Time)
+	}
+	for k, expires := range l.seen {
+		if !now.Before(expires) {
+			delete(l.seen, k)
+		}
+	}
+	if _, ok := l.seen[key]; ok {
+		return false
+	}
+	l.seen[key] = now.Add(window)
+	return true
+}
+
+// forget drops key, so the delivery it was recorded for can be sent again.
+func (l *webhookDeliveryLog) forget(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.seen, key)
+}
+
+// sealWebhookSecret encrypts a webhook secret for storage. An empty secret stays empty,
+// which disables the repository's webhook.
+func (s *gitService) sealWebhookSecret(secret string) (string, error) {
+	if secret == "" {
+		return "", nil
+	}
+	if s.webhookSecrets == nil {
+		return "", ErrWebhookKeyMissing
+	}
+	sealed, err := s.webhookSecrets.Seal(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+	return sealed, nil
+}
+
+// verifyWebhookSignature checks that signature is the HMAC-SHA256 of body keyed with repo's
+// webhook secret.
+func (s *gitService) verifyWebhookSignature(repo *model.GitRepository, signature string, body []byte) error {
+	if repo.WebhookSecret == "" || s.webhookSecrets == nil {
+		return ErrInvalidWebhookSignature
+	}
+	secret, err := s.webhookSecrets.Open(repo.WebhookSecret)
+	if err != nil {
+		s.logger.Error("failed to decrypt webhook secret", zap.String("repo_id", repo.ID), zap.Error(err))
+		return ErrInvalidWebhookSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(notification.Sign(secret, body))) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// HandleWebhook verifies a delivery for the repository with ID repoID and resyncs modules
+// when it is a push to the branch of the default modules repository. Each push signature is
+// accepted once within constants.GitWebhookReplayWindow, unless the resync it triggered
+// failed.
+func (s *gitService) HandleWebhook(ctx context.Context, repoID string, delivery *GitWebhookDelivery) (*GitWebhookResult, error) {
+	repo, err := s.gitRepoRepo.GetByID(ctx, repoID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidWebhookSignature
+		}
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+	if err := s.verifyWebhookSignature(repo, delivery.Signature, delivery.Body); err != nil {
+		return nil, err
+	}
+
+	var payload gitPushPayload
+	if err := json.Unmarshal(delivery.Body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+	}
+	if delivery.Event != gitPushEvent {
+		return &GitWebhookResult{Ignored: fmt.Sprintf("%q events do not trigger a resync", delivery.Event)}, nil
+	}
+	if payload.Ref == "" {
+		return nil, fmt.Errorf("%w: push event has no ref", ErrInvalidWebhookPayload)
+	}
+
+	key := repo.ID + ":" + delivery.Signature
+	if !s.webhookDeliveries.record(key, time.Now(), constants.GitWebhookReplayWindow) {
+		return nil, ErrWebhookReplayed
+	}
+
+	if payload.Ref != "refs/heads/"+repo.Branch {
+		return &GitWebhookResult{Ignored: "push is not to branch " + repo.Branch}, nil
+	}
+	if repo.Type != model.GitRepoTypeModules {
+		return &GitWebhookResult{Ignored: "not a modules repository"}, nil
+	}
+	defaultRepo, err := s.gitRepoRepo.GetDefaultByType(ctx, model.GitRepoTypeModules, "")
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		s.webhookDeliveries.forget(key)
+		return nil, fmt.Errorf("failed to get default modules repository: %w", err)
+	}
+	if err != nil || defaultRepo.ID != repo.ID {
+		return &GitWebhookResult{Ignored: "not the default modules repository"}, nil
+	}
+
+	modules, err := s.SyncModulesFromGit(ctx)
+	if err != nil {
+		s.webhookDeliveries.forget(key)
+		return nil, err
+	}
+	s.logger.Info("modules resynced by push webhook", zap.String("repo_id", repo.ID), zap.Int("modules", len(modules)))
+	return &GitWebhookResult{Synced: true, Modules: len(modules)}, nil
+}
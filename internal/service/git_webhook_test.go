@@ -0,0 +1,141 @@
+// Package service provides git webhook tests.
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/notification"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGitService_HandleWebhook(t *testing.T) {
+	box, err := secrets.NewBox("webhook-key")
+	require.NoError(t, err)
+	sealed, err := box.Seal("hook-secret")
+	require.NoError(t, err)
+
+	newService := func(t *testing.T, calls *[][]string) *gitService {
+		repos := &stubGitRepoRepository{repos: map[string]*model.GitRepository{
+			"modules-1": {
+				BaseModel: model.BaseModel{ID: "modules-1"}, Type: model.GitRepoTypeModules,
+				URL: "https://git.example.com/lab/modules.git", Branch: "main", IsDefault: true, WebhookSecret: sealed,
+			},
+			"modules-2": {
+				BaseModel: model.BaseModel{ID: "modules-2"}, Type: model.GitRepoTypeModules,
+				URL: "https://git.example.com/lab/other.git", Branch: "main",
+			},
+		}}
+		return &gitService{
+			logger: zap.NewNop(), gitRepoRepo: repos, workDir: t.TempDir(), webhookSecrets: box,
+			runGit: fixtureCloneRunner(t, map[string]string{"README.md": "# modules\n"}, calls),
+		}
+	}
+	push := []byte(`{"ref":"refs/heads/main","after":"0123456789abcdef"}`)
+	delivery := func(event string, body []byte, secret string) *GitWebhookDelivery {
+		return &GitWebhookDelivery{Event: event, Signature: notification.Sign(secret, body), Body: body}
+	}
+	ctx := context.Background()
+
+	t.Run("valid push resyncs modules", func(t *testing.T) {
+		var calls [][]string
+		result, err := newService(t, &calls).HandleWebhook(ctx, "modules-1", delivery("push", push, "hook-secret"))
+		require.NoError(t, err)
+		assert.True(t, result.Synced)
+		require.Len(t, calls, 1)
+		assert.Equal(t, "clone", calls[0][0])
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		var calls [][]string
+		svc := newService(t, &calls)
+
+		_, err := svc.HandleWebhook(ctx, "modules-1", delivery("push", push, "wrong-secret"))
+		require.ErrorIs(t, err, ErrInvalidWebhookSignature)
+
+		tampered := delivery("push", push, "hook-secret")
+		tampered.Body = []byte(`{"ref":"refs/heads/main","after":"fedcba9876543210"}`)
+		_, err = svc.HandleWebhook(ctx, "modules-1", tampered)
+		require.ErrorIs(t, err, ErrInvalidWebhookSignature)
+
+		_, err = svc.HandleWebhook(ctx, "modules-1", &GitWebhookDelivery{Event: "push", Body: push})
+		require.ErrorIs(t, err, ErrInvalidWebhookSignature, "unsigned")
+		assert.Empty(t, calls)
+	})
+
+	t.Run("repository without a secret or unknown", func(t *testing.T) {
+		var calls [][]string
+		svc := newService(t, &calls)
+
+		_, err := svc.HandleWebhook(ctx, "modules-2", delivery("push", push, ""))
+		require.ErrorIs(t, err, ErrInvalidWebhookSignature)
+		_, err = svc.HandleWebhook(ctx, "missing", delivery("push", push, "hook-secret"))
+		require.ErrorIs(t, err, ErrInvalidWebhookSignature)
+	})
+
+	t.Run("replayed signature", func(t *testing.T) {
+		var calls [][]string
+		svc := newService(t, &calls)
+
+		_, err := svc.HandleWebhook(ctx, "modules-1", delivery("push", push, "hook-secret"))
+		require.NoError(t, err)
+		_, err = svc.HandleWebhook(ctx, "modules-1", delivery("push", push, "hook-secret"))
+		require.ErrorIs(t, err, ErrWebhookReplayed)
+		assert.Len(t, calls, 1, "the replay does not resync")
+	})
+
+	t.Run("unparseable payload", func(t *testing.T) {
+		var calls [][]string
+		svc := newService(t, &calls)
+
+		_, err := svc.HandleWebhook(ctx, "modules-1", delivery("push", []byte("ref=refs/heads/main"), "hook-secret"))
+		require.ErrorIs(t, err, ErrInvalidWebhookPayload)
+		_, err = svc.HandleWebhook(ctx, "modules-1", delivery("push", []byte(`{"zen":"keep it simple"}`), "hook-secret"))
+		require.ErrorIs(t, err, ErrInvalidWebhookPayload, "push without a ref")
+	})
+
+	t.Run("other events and branches are ignored", func(t *testing.T) {
+		var calls [][]string
+		svc := newService(t, &calls)
+
+		result, err := svc.HandleWebhook(ctx, "modules-1", delivery("ping", []byte(`{"zen":"keep it simple"}`), "hook-secret"))
+		require.NoError(t, err)
+		assert.False(t, result.Synced)
+		assert.Contains(t, result.Ignored, "ping")
+
+		result, err = svc.HandleWebhook(ctx, "modules-1", delivery("push", []byte(`{"ref":"refs/heads/feature"}`), "hook-secret"))
+		require.NoError(t, err)
+		assert.False(t, result.Synced)
+		assert.Empty(t, calls)
+	})
+}
+
+func TestGitService_WebhookSecretIsSealed(t *testing.T) {
+	box, err := secrets.NewBox("webhook-key")
+	require.NoError(t, err)
+	repos := &stubGitRepoRepository{repos: map[string]*model.GitRepository{
+		"modules-1": {BaseModel: model.BaseModel{ID: "modules-1"}, Type: model.GitRepoTypeModules, Branch: "main"},
+	}}
+	svc := &gitService{logger: zap.NewNop(), gitRepoRepo: repos, webhookSecrets: box}
+
+	secret := "hook-secret"
+	repo, err := svc.UpdateRepository(context.Background(), "modules-1", &UpdateGitRepoInput{WebhookSecret: &secret})
+	require.NoError(t, err)
+	assert.NotContains(t, repo.WebhookSecret, secret)
+	opened, err := box.Open(repo.WebhookSecret)
+	require.NoError(t, err)
+	assert.Equal(t, secret, opened)
+
+	svc.webhookSecrets = nil
+	_, err = svc.UpdateRepository(context.Background(), "modules-1", &UpdateGitRepoInput{WebhookSecret: &secret})
+	require.ErrorIs(t, err, ErrWebhookKeyMissing)
+
+	disable := ""
+	repo, err = svc.UpdateRepository(context.Background(), "modules-1", &UpdateGitRepoInput{WebhookSecret: &disable})
+	require.NoError(t, err)
+	assert.Empty(t, repo.WebhookSecret)
+}
@@ -0,0 +1,38 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// operationDir returns a path under the work directory that no other clone uses, so
+// concurrent operations on the same repository never share a working tree.
+func (s *gitService) operationDir(repoID, operation string) string {
+	return filepath.Join(s.workDir, repoID, operation+"-"+uuid.New().String())
+}
+
+// keyedMutex hands out one mutex per key. The zero value is ready to use.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock blocks until the mutex for key is held and returns the function that releases it.
+func (k *keyedMutex) lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*sync.Mutex)
+	}
+	m, ok := k.locks[key]
+	if !ok {
+		m = &sync.Mutex{}
+		k.locks[key] = m
+	}
+	k.mu.Unlock()
+
+	m.Lock()
+	return m.Unlock
+}
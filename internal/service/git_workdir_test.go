@@ -0,0 +1,219 @@
+// Package service provides git work directory isolation tests.
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryNodeConfigRepository keeps node configs in memory and is safe for concurrent use.
+type memoryNodeConfigRepository struct {
+	repository.NodeConfigRepository
+	mu      sync.Mutex
+	configs map[string]*model.NodeConfig
+}
+
+func (r *memoryNodeConfigRepository) GetByID(_ context.Context, id string) (*model.NodeConfig, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	config, ok := r.configs[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	clone := *config
+	return &clone, nil
+}
+
+func (r *memoryNodeConfigRepository) Update(_ context.Context, config *model.NodeConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *config
+	r.configs[config.ID] = &clone
+	return nil
+}
+
+func TestGitService_ConcurrentCommits(t *testing.T) {
+	repoURL, _ := setupSSHRemote(t)
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	const commits = 20
+	storageRepo := &model.GitRepository{
+		BaseModel: model.BaseModel{ID: "storage-1"}, URL: repoURL, Branch: "main",
+		AuthType: model.GitAuthTypeSSHKey, SSHKey: testSSHKey,
+	}
+	configRepo := &memoryNodeConfigRepository{configs: make(map[string]*model.NodeConfig)}
+	for i := range commits {
+		id := fmt.Sprintf("cfg-%02d", i)
+		configRepo.configs[id] = &model.NodeConfig{
+			BaseModel:        model.BaseModel{ID: id},
+			StorageRepoID:    storageRepo.ID,
+			Path:             "nodes/" + id,
+			TerragruntConfig: fmt.Sprintf("inputs = {\n  name = %q\n}\n", id),
+		}
+	}
+	svc := &gitService{
+		logger:         zap.NewNop(),
+		workDir:        t.TempDir(),
+		nodeConfigRepo: configRepo,
+		gitRepoRepo:    &stubGitRepoRepository{repos: map[string]*model.GitRepository{storageRepo.ID: storageRepo}},
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, commits)
+	for i := range commits {
+		wg.Go(func() {
+			_, errs[i] = svc.CommitNodeConfig(context.Background(), fmt.Sprintf("cfg-%02d", i), "add node")
+		})
+	}
+	wg.Wait()
+	for i, err := range errs {
+		require.NoError(t, err, "commit %d", i)
+	}
+
+	checkout := filepath.Join(t.TempDir(), "checkout")
+	require.NoError(t, svc.CloneRepository(context.Background(), storageRepo, checkout))
+	for id, config := range configRepo.configs {
+		assert.NotEmpty(t, config.CommitSHA, id)
+		assert.Equal(t, config.TerragruntConfig, readTestFile(t, filepath.Join(checkout, config.Path, "terragrunt.hcl")), id)
+	}
+}
+
+func TestGitService_CommitNodeConfigExtraFiles(t *testing.T) {
+	repoURL, _ := setupSSHRemote(t)
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	storageRepo := &model.GitRepository{
+		BaseModel: model.BaseModel{ID: "storage-1"}, URL: repoURL, Branch: "main",
+		AuthType: model.GitAuthTypeSSHKey, SSHKey: testSSHKey,
+	}
+	configRepo := &memoryNodeConfigRepository{configs: map[string]*model.NodeConfig{
+		"cfg-1": {
+			BaseModel:        model.BaseModel{ID: "cfg-1"},
+			StorageRepoID:    storageRepo.ID,
+			Path:             "nodes/vm-001",
+			TerragruntConfig: "inputs = {\n  name = \"vm-001\"\n}\n",
+			ExtraFiles: map[string]string{
+				".terraform-version":     "1.9.8\n",
+				"env/inputs.auto.tfvars": "cores = 2\n",
+			},
+		},
+	}}
+	svc := &gitService{
+		logger:         zap.NewNop(),
+		workDir:        t.TempDir(),
+		nodeConfigRepo: configRepo,
+		gitRepoRepo:    &stubGitRepoRepository{repos: map[string]*model.GitRepository{storageRepo.ID: storageRepo}},
+	}
+	ctx := context.Background()
+
+	sha, err := svc.CommitNodeConfig(ctx, "cfg-1", "add vm-001")
+	require.NoError(t, err)
+
+	checkout := filepath.Join(t.TempDir(), "checkout")
+	require.NoError(t, svc.CloneRepository(ctx, storageRepo, checkout))
+	head, err := svc.headSHA(ctx, checkout)
+	require.NoError(t, err)
+	assert.Equal(t, sha, head)
+
+	changed, err := svc.git(ctx, checkout, "show", "--name-only", "--format=", sha)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"nodes/vm-001/.terraform-version",
+		"nodes/vm-001/env/inputs.auto.tfvars",
+		"nodes/vm-001/terragrunt.hcl",
+	}, strings.Fields(changed), "one commit holds every file")
+	assert.Equal(t, "cores = 2\n", readTestFile(t, filepath.Join(checkout, "nodes", "vm-001", "env", "inputs.auto.tfvars")))
+
+	for _, name := range []string{"../../../outside.tf", "../../.git/config", "/etc/hosts", "terragrunt.hcl"} {
+		configRepo.configs["cfg-1"].ExtraFiles = map[string]string{name: "x"}
+		_, err := svc.CommitNodeConfig(ctx, "cfg-1", "escape")
+		require.ErrorIs(t, err, ErrInvalidNodeFile, name)
+	}
+}
+
+func TestWriteRepoFile_SymbolicLinks(t *testing.T) {
+	repoPath := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(repoPath, "link")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "target"), filepath.Join(repoPath, "file.tf")))
+
+	err := writeRepoFile(repoPath, filepath.Join(repoPath, "link", "nodes", "vm", "terragrunt.hcl"), "x")
+	require.ErrorIs(t, err, ErrInvalidNodeFile)
+	err = writeRepoFile(repoPath, filepath.Join(repoPath, "file.tf"), "x")
+	require.ErrorIs(t, err, ErrInvalidNodeFile)
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "nothing is written through the links")
+
+	require.NoError(t, writeRepoFile(repoPath, filepath.Join(repoPath, "nodes", "vm", "terragrunt.hcl"), "x"))
+	assert.Equal(t, "x", readTestFile(t, filepath.Join(repoPath, "nodes", "vm", "terragrunt.hcl")))
+}
+
+func TestGitService_CommitIdenticalContent(t *testing.T) {
+	repoURL, _ := setupSSHRemote(t)
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	storageRepo := &model.GitRepository{
+		BaseModel: model.BaseModel{ID: "storage-1"}, URL: repoURL, Branch: "main",
+		AuthType: model.GitAuthTypeSSHKey, SSHKey: testSSHKey,
+	}
+	configRepo := &memoryNodeConfigRepository{configs: map[string]*model.NodeConfig{
+		"cfg-1": {
+			BaseModel:        model.BaseModel{ID: "cfg-1"},
+			StorageRepoID:    storageRepo.ID,
+			Path:             "nodes/vm-001",
+			TerragruntConfig: "inputs = {\n  name = \"vm-001\"\n}\n",
+		},
+	}}
+	svc := &gitService{
+		logger:         zap.NewNop(),
+		workDir:        t.TempDir(),
+		nodeConfigRepo: configRepo,
+		gitRepoRepo:    &stubGitRepoRepository{repos: map[string]*model.GitRepository{storageRepo.ID: storageRepo}},
+	}
+	ctx := context.Background()
+
+	t.Run("CommitAndPush reports the second commit as nothing to commit", func(t *testing.T) {
+		checkout := filepath.Join(t.TempDir(), "checkout")
+		require.NoError(t, svc.CloneRepository(ctx, storageRepo, checkout))
+		file := filepath.Join(checkout, "nodes", "vm-002", "terragrunt.hcl")
+
+		writeTestFile(t, file, "inputs = {}\n")
+		first, err := svc.CommitAndPush(ctx, storageRepo, checkout, []string{file}, "add vm-002")
+		require.NoError(t, err)
+
+		writeTestFile(t, file, "inputs = {}\n")
+		second, err := svc.CommitAndPush(ctx, storageRepo, checkout, []string{file}, "add vm-002")
+		require.ErrorIs(t, err, ErrNothingToCommit)
+		assert.Equal(t, first, second, "the SHA is the existing HEAD")
+	})
+
+	t.Run("CommitNodeConfig returns the existing SHA", func(t *testing.T) {
+		first, err := svc.CommitNodeConfig(ctx, "cfg-1", "add vm-001")
+		require.NoError(t, err)
+		second, err := svc.CommitNodeConfig(ctx, "cfg-1", "add vm-001")
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+		assert.Equal(t, first, configRepo.configs["cfg-1"].CommitSHA)
+	})
+}
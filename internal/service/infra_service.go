@@ -4,25 +4,31 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
 	"go.uber.org/zap"
 )
 
+// ErrModuleSourceExists is returned when a module with the same normalized source exists.
+var ErrModuleSourceExists = errors.New("a module with this source already exists")
+
 // InfraService defines the interface for infrastructure management.
 type InfraService interface {
 	// Region operations
-	ListRegions(ctx context.Context, page, pageSize int) ([]model.Region, int64, error)
-	ListAllRegions(ctx context.Context) ([]model.Region, error)
+	ListRegions(ctx context.Context, page, pageSize int, opts repository.InfraListOptions) ([]model.Region, int64, error)
+	ListAllRegions(ctx context.Context, opts repository.InfraListOptions) ([]model.Region, error)
 	GetRegion(ctx context.Context, id string) (*model.Region, error)
 	CreateRegion(ctx context.Context, input *CreateRegionInput) (*model.Region, error)
 	UpdateRegion(ctx context.Context, id string, input *UpdateRegionInput) (*model.Region, error)
 	DeleteRegion(ctx context.Context, id string) error
 
 	// Zone operations
-	ListZones(ctx context.Context, page, pageSize int) ([]model.Zone, int64, error)
-	ListZonesByRegion(ctx context.Context, regionID string) ([]model.Zone, error)
+	ListZones(ctx context.Context, page, pageSize int, opts repository.InfraListOptions) ([]model.Zone, int64, error)
+	ListZonesByRegion(ctx context.Context, regionID string, opts repository.InfraListOptions) ([]model.Zone, error)
 	GetZone(ctx context.Context, id string) (*model.Zone, error)
 	CreateZone(ctx context.Context, input *CreateZoneInput) (*model.Zone, error)
 	UpdateZone(ctx context.Context, id string, input *UpdateZoneInput) (*model.Zone, error)
@@ -181,13 +187,13 @@ func NewInfraService(
 }
 
 // ListRegions retrieves regions with pagination.
-func (s *infraService) ListRegions(ctx context.Context, page, pageSize int) ([]model.Region, int64, error) {
-	return s.regionRepo.List(ctx, page, pageSize)
+func (s *infraService) ListRegions(ctx context.Context, page, pageSize int, opts repository.InfraListOptions) ([]model.Region, int64, error) {
+	return s.regionRepo.List(ctx, page, pageSize, opts)
 }
 
 // ListAllRegions retrieves all active regions.
-func (s *infraService) ListAllRegions(ctx context.Context) ([]model.Region, error) {
-	return s.regionRepo.ListAll(ctx)
+func (s *infraService) ListAllRegions(ctx context.Context, opts repository.InfraListOptions) ([]model.Region, error) {
+	return s.regionRepo.ListAll(ctx, opts)
 }
 
 // GetRegion retrieves a region by ID.
@@ -282,16 +288,16 @@ func (s *infraService) DeleteRegion(ctx context.Context, id string) error {
 }
 
 // ListZones retrieves zones with pagination.
-func (s *infraService) ListZones(ctx context.Context, page, pageSize int) ([]model.Zone, int64, error) {
-	return s.zoneRepo.List(ctx, page, pageSize)
+func (s *infraService) ListZones(ctx context.Context, page, pageSize int, opts repository.InfraListOptions) ([]model.Zone, int64, error) {
+	return s.zoneRepo.List(ctx, page, pageSize, opts)
 }
 
 // ListZonesByRegion retrieves zones by region ID.
-func (s *infraService) ListZonesByRegion(ctx context.Context, regionID string) ([]model.Zone, error) {
+func (s *infraService) ListZonesByRegion(ctx context.Context, regionID string, opts repository.InfraListOptions) ([]model.Zone, error) {
 	if regionID == "" {
 		return nil, errors.New("region_id cannot be empty")
 	}
-	return s.zoneRepo.ListByRegion(ctx, regionID)
+	return s.zoneRepo.ListByRegion(ctx, regionID, opts)
 }
 
 // GetZone retrieves a zone by ID.
@@ -624,13 +630,28 @@ func (s *infraService) CreateModule(ctx context.Context, input *CreateModuleInpu
 	if input.Name == "" {
 		return nil, errors.New("name is required")
 	}
-	if input.Source == "" {
+	source := terraform.NormalizeModuleSource(input.Source)
+	if source == "" {
 		return nil, errors.New("source is required")
 	}
 
+	// Manual modules share the source format of git-synced ones so a later sync updates them
+	// instead of adding a duplicate.
+	if err := validateModuleVersion(input.Version); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.moduleRepo.GetBySource(ctx, source)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrModuleSourceExists
+	}
+
 	module := &model.TerraformModule{
 		Name:        input.Name,
-		Source:      input.Source,
+		Source:      source,
 		Version:     input.Version,
 		RegistryID:  input.RegistryID,
 		ProviderID:  input.ProviderID,
@@ -647,6 +668,18 @@ func (s *infraService) CreateModule(ctx context.Context, input *CreateModuleInpu
 	return module, nil
 }
 
+// validateModuleVersion checks a module version, which becomes the ?ref= of the module
+// source and so must be a valid git tag or branch. Modules need not have a version.
+func validateModuleVersion(version string) error {
+	if version == "" {
+		return nil
+	}
+	if _, err := sanitize.ValidateGitRef(version); err != nil {
+		return fmt.Errorf("invalid module version: %w", err)
+	}
+	return nil
+}
+
 func (s *infraService) UpdateModule(ctx context.Context, id string, input *UpdateModuleInput) (*model.TerraformModule, error) {
 	if id == "" {
 		return nil, errors.New("id cannot be empty")
@@ -661,9 +694,12 @@ func (s *infraService) UpdateModule(ctx context.Context, id string, input *Updat
 		module.Name = *input.Name
 	}
 	if input.Source != nil {
-		module.Source = *input.Source
+		module.Source = terraform.NormalizeModuleSource(*input.Source)
 	}
 	if input.Version != nil {
+		if err := validateModuleVersion(*input.Version); err != nil {
+			return nil, err
+		}
 		module.Version = *input.Version
 	}
 	if input.RegistryID != nil {
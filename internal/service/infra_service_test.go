@@ -0,0 +1,117 @@
+// Package service provides infrastructure service tests.
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubModuleRepository keeps terraform modules in memory, keyed by source.
+type stubModuleRepository struct {
+	repository.TerraformModuleRepository
+	bySource map[string]*model.TerraformModule
+}
+
+func newStubModuleRepository() *stubModuleRepository {
+	return &stubModuleRepository{bySource: map[string]*model.TerraformModule{}}
+}
+
+func (r *stubModuleRepository) Create(_ context.Context, module *model.TerraformModule) error {
+	r.bySource[module.Source] = module
+	return nil
+}
+
+func (r *stubModuleRepository) Update(_ context.Context, module *model.TerraformModule) error {
+	for source, m := range r.bySource {
+		if m == module {
+			delete(r.bySource, source)
+		}
+	}
+	r.bySource[module.Source] = module
+	return nil
+}
+
+func (r *stubModuleRepository) GetByID(_ context.Context, id string) (*model.TerraformModule, error) {
+	for _, module := range r.bySource {
+		if module.ID == id {
+			return module, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *stubModuleRepository) GetBySource(_ context.Context, source string) (*model.TerraformModule, error) {
+	module, ok := r.bySource[source]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return module, nil
+}
+
+func TestInfraService_CreateModuleNormalizesSource(t *testing.T) {
+	ctx := context.Background()
+	const syncedSource = "git::https://git.example.com/lab/modules.git//compute/vm"
+
+	t.Run("https source matches the git-synced module", func(t *testing.T) {
+		repo := newStubModuleRepository()
+		git := &gitService{logger: zap.NewNop(), tfModuleRepo: repo}
+		require.NoError(t, git.syncModulesToDatabase(ctx, []GitModule{
+			{Name: "vm", Source: "https://git.example.com/lab/modules//compute/vm"},
+		}))
+		require.Contains(t, repo.bySource, syncedSource)
+
+		svc := NewInfraService(nil, nil, nil, nil, repo, zap.NewNop())
+		_, err := svc.CreateModule(ctx, &CreateModuleInput{Name: "vm", Source: " https://git.example.com/lab/modules//compute/vm "})
+		require.ErrorIs(t, err, ErrModuleSourceExists)
+		assert.Len(t, repo.bySource, 1)
+	})
+
+	t.Run("new module is stored in normalized form", func(t *testing.T) {
+		repo := newStubModuleRepository()
+		svc := NewInfraService(nil, nil, nil, nil, repo, zap.NewNop())
+
+		module, err := svc.CreateModule(ctx, &CreateModuleInput{Name: "vm", Source: "https://git.example.com/lab/modules//compute/vm"})
+		require.NoError(t, err)
+		assert.Equal(t, syncedSource, module.Source)
+
+		// A later sync updates the manual module rather than adding another.
+		git := &gitService{logger: zap.NewNop(), tfModuleRepo: repo}
+		require.NoError(t, git.syncModulesToDatabase(ctx, []GitModule{
+			{Name: "vm", Source: "https://git.example.com/lab/modules//compute/vm", Description: "synced"},
+		}))
+		require.Len(t, repo.bySource, 1)
+		assert.Equal(t, "synced", repo.bySource[syncedSource].Description)
+	})
+
+	t.Run("sync migrates a module stored before normalization", func(t *testing.T) {
+		repo := newStubModuleRepository()
+		legacy := &model.TerraformModule{Name: "vm", Source: "https://git.example.com/lab/modules//compute/vm"}
+		repo.bySource[legacy.Source] = legacy
+
+		git := &gitService{logger: zap.NewNop(), tfModuleRepo: repo}
+		require.NoError(t, git.syncModulesToDatabase(ctx, []GitModule{
+			{Name: "vm", Source: "https://git.example.com/lab/modules//compute/vm"},
+		}))
+		require.Len(t, repo.bySource, 1)
+		assert.Same(t, legacy, repo.bySource[syncedSource])
+	})
+}
+
+func TestInfraService_CreateModuleValidatesVersion(t *testing.T) {
+	ctx := context.Background()
+	svc := NewInfraService(nil, nil, nil, nil, newStubModuleRepository(), zap.NewNop())
+
+	_, err := svc.CreateModule(ctx, &CreateModuleInput{Name: "vm", Source: "https://git.example.com/lab/modules//vm", Version: "--upload-pack=touch /tmp/pwned"})
+	require.ErrorIs(t, err, sanitize.ErrInvalidGitRef)
+
+	module, err := svc.CreateModule(ctx, &CreateModuleInput{Name: "vm", Source: "https://git.example.com/lab/modules//vm", Version: "v1.2.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0", module.Version)
+}
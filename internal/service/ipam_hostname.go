@@ -0,0 +1,109 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+// ErrInvalidHostname indicates a hostname that is not a valid RFC 1123 host name.
+var ErrInvalidHostname = errors.New("invalid hostname")
+
+// RFC 1123 host name limits.
+const (
+	maxHostnameLength = 253
+	maxLabelLength    = 63
+)
+
+// Defaults for generated hostnames.
+const (
+	defaultHostnamePrefix      = "host"
+	defaultHostnameIndexDigits = 3
+)
+
+// normalizeHostname lowercases hostname and checks it against the RFC 1123 rules: dot-separated
+// labels of 1 to 63 letters, digits and hyphens that neither start nor end with a hyphen, and
+// at most 253 characters in total.
+func normalizeHostname(hostname string) (string, error) {
+	hostname = strings.ToLower(hostname)
+	if len(hostname) > maxHostnameLength {
+		return "", fmt.Errorf("%w: longer than %d characters", ErrInvalidHostname, maxHostnameLength)
+	}
+	for _, label := range strings.Split(hostname, ".") {
+		if label == "" || len(label) > maxLabelLength {
+			return "", fmt.Errorf("%w: %q: each label must be 1 to %d characters", ErrInvalidHostname, hostname, maxLabelLength)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return "", fmt.Errorf("%w: %q: labels cannot start or end with a hyphen", ErrInvalidHostname, hostname)
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return "", fmt.Errorf("%w: %q: only letters, digits, hyphens and dots are allowed", ErrInvalidHostname, hostname)
+			}
+		}
+	}
+	return hostname, nil
+}
+
+// hostnameLabel turns s into something usable inside a hostname label: lowercase, with runs of
+// other characters collapsed to a hyphen.
+func hostnameLabel(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+			b.WriteByte('-')
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// resolveHostname returns the hostname to give an allocation in pool: the requested one
+// after validation, or a generated one when none was requested and generation is enabled.
+func (s *ipamService) resolveHostname(ctx context.Context, pool *model.IPPool, requested string) (string, error) {
+	if requested != "" {
+		return normalizeHostname(requested)
+	}
+	if !s.hostnames.Generate {
+		return "", nil
+	}
+	return s.generateHostname(ctx, pool)
+}
+
+// generateHostname returns <prefix>-<zone code>-<index> for the first index after the highest
+// one already used in the pool.
+func (s *ipamService) generateHostname(ctx context.Context, pool *model.IPPool) (string, error) {
+	prefix := hostnameLabel(s.hostnames.Prefix)
+	if prefix == "" {
+		prefix = defaultHostnamePrefix
+	}
+	if pool.Zone != nil {
+		if zone := hostnameLabel(pool.Zone.Code); zone != "" {
+			prefix += "-" + zone
+		}
+	}
+	prefix += "-"
+
+	used, err := s.allocationRepo.ListHostnames(ctx, pool.ID, prefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to list hostnames: %w", err)
+	}
+	next := 1
+	for _, hostname := range used {
+		if index, err := strconv.Atoi(strings.TrimPrefix(hostname, prefix)); err == nil && index >= next {
+			next = index + 1
+		}
+	}
+
+	digits := s.hostnames.IndexDigits
+	if digits <= 0 {
+		digits = defaultHostnameIndexDigits
+	}
+	return normalizeHostname(fmt.Sprintf("%s%0*d", prefix, digits, next))
+}
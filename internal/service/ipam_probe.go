@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AddressProber checks whether an address is in use on the network before it is allocated
+// from a pool that verifies addresses.
+type AddressProber interface {
+	// Responds reports whether a host answers at ip.
+	Responds(ctx context.Context, ip net.IP) bool
+}
+
+// ICMP echo message types.
+const (
+	icmpv4EchoRequest = 8
+	icmpv4EchoReply   = 0
+	icmpv6EchoRequest = 128
+	icmpv6EchoReply   = 129
+)
+
+// networkProber sends an ICMP echo request to an address and waits for the reply. Raw ICMP
+// sockets need CAP_NET_RAW, so when one cannot be opened it dials a TCP port instead.
+type networkProber struct {
+	port    int
+	timeout time.Duration
+	logger  *zap.Logger
+
+	// ping sends an ICMP echo request; replaced in tests.
+	ping func(ctx context.Context, ip net.IP) (bool, error)
+}
+
+// NewAddressProber creates a prober that pings each address, or dials TCP port on it when
+// the process may not send ICMP. timeout bounds each probe.
+func NewAddressProber(port int, timeout time.Duration, logger *zap.Logger) AddressProber {
+	return &networkProber{port: port, timeout: timeout, logger: logger, ping: pingICMP}
+}
+
+// Responds implements AddressProber.
+func (p *networkProber) Responds(ctx context.Context, ip net.IP) bool {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	answered, err := p.ping(ctx, ip)
+	if err == nil {
+		return answered
+	}
+	p.logger.Debug("ICMP probe unavailable, dialing TCP", zap.String("ip", ip.String()), zap.Error(err))
+	return p.dialTCP(ctx, ip)
+}
+
+// dialTCP reports whether a host at ip accepts or refuses a connection to the probe port;
+// either way something is there. A timeout or an unreachable network means nothing answered.
+func (p *networkProber) dialTCP(ctx context.Context, ip net.IP) bool {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(p.port)))
+	if err == nil {
+		_ = conn.Close() //nolint:errcheck // the connection only proved the host is there
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// pingICMP sends one ICMP echo request to ip over a raw socket and reports whether a reply
+// arrives before ctx is done. It fails when the socket cannot be opened or written.
+func pingICMP(ctx context.Context, ip net.IP) (bool, error) {
+	network, request, reply := "ip4:icmp", byte(icmpv4EchoRequest), byte(icmpv4EchoReply)
+	if ip.To4() == nil {
+		network, request, reply = "ip6:ipv6-icmp", icmpv6EchoRequest, icmpv6EchoReply
+	}
+	conn, err := net.ListenPacket(network, "")
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close() //nolint:errcheck // nothing is written after the probe
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return false, err
+		}
+	}
+
+	id := uint16(os.Getpid()) // #nosec G115 -- the identifier only tells this process's replies apart
+	var seqBytes [2]byte
+	if _, err := rand.Read(seqBytes[:]); err != nil {
+		return false, err
+	}
+	seq := binary.BigEndian.Uint16(seqBytes[:])
+	if _, err := conn.WriteTo(echoRequest(request, id, seq), &net.IPAddr{IP: ip}); err != nil {
+		return false, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			// The deadline passed without a reply
+			return false, nil
+		}
+		addr, ok := from.(*net.IPAddr)
+		if ok && addr.IP.Equal(ip) && n >= 8 && buf[0] == reply &&
+			binary.BigEndian.Uint16(buf[4:6]) == id && binary.BigEndian.Uint16(buf[6:8]) == seq {
+			return true, nil
+		}
+	}
+}
+
+// echoRequest builds an ICMP echo request of type kind. The checksum is filled in for
+// ICMPv4; the kernel computes it for ICMPv6.
+func echoRequest(kind byte, id, seq uint16) []byte {
+	msg := make([]byte, 8)
+	msg[0] = kind
+	binary.BigEndian.PutUint16(msg[4:6], id)
+	binary.BigEndian.PutUint16(msg[6:8], seq)
+	if kind == icmpv4EchoRequest {
+		binary.BigEndian.PutUint16(msg[2:4], icmpChecksum(msg))
+	}
+	return msg
+}
+
+// icmpChecksum returns the Internet checksum of msg (RFC 1071).
+func icmpChecksum(msg []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(msg); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(msg[i : i+2]))
+	}
+	if len(msg)%2 == 1 {
+		sum += uint32(msg[len(msg)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
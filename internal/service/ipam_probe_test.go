@@ -0,0 +1,120 @@
+// Package service provides address probe tests.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubProber answers for the addresses in responding and records every probe.
+type stubProber struct {
+	responding map[string]bool
+	probed     []string
+}
+
+func (p *stubProber) Responds(_ context.Context, ip net.IP) bool {
+	p.probed = append(p.probed, ip.String())
+	return p.responding[ip.String()]
+}
+
+func TestIPAMService_AllocateWithProber(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("allocations are probed by the repository", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		allocRepo := new(MockIPAllocationRepository)
+		prober := &stubProber{responding: map[string]bool{"10.0.0.10": true}}
+		svc := NewIPAMService(poolRepo, allocRepo, config.HostnameConfig{}, 0, nil, prober, zap.NewNop())
+
+		var probe repository.AddressProbe
+		allocRepo.On("AllocateProbed", ctx, "pool-1", "", "web-01", "res-1", mock.Anything).Run(func(args mock.Arguments) {
+			probe, _ = args.Get(5).(repository.AddressProbe) //nolint:errcheck // checked below
+		}).Return(&model.IPAllocation{IPPoolID: "pool-1", IPAddress: "10.0.0.11"}, nil)
+
+		allocation, err := svc.AllocateIP(ctx, &AllocateIPInput{PoolID: "pool-1", Hostname: "web-01", ResourceID: "res-1"})
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.11", allocation.IPAddress)
+		require.NotNil(t, probe)
+		assert.True(t, probe(ctx, net.ParseIP("10.0.0.10")), "the repository probes with the service's prober")
+		poolRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		allocRepo.AssertNotCalled(t, "AllocateNextAvailable", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("zone allocations are probed too", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		allocRepo := new(MockIPAllocationRepository)
+		svc := NewIPAMService(poolRepo, allocRepo, config.HostnameConfig{}, 0, nil, &stubProber{}, zap.NewNop())
+
+		pools := []*model.IPPool{
+			{BaseModel: model.BaseModel{ID: "pool-busy"}, NetworkType: model.NetworkTypePrivate, IsDefault: true},
+			{BaseModel: model.BaseModel{ID: "pool-quiet"}, NetworkType: model.NetworkTypePrivate},
+		}
+		poolRepo.On("List", ctx, mock.Anything, 0, mock.Anything).Return(pools, int64(2), nil)
+		allocRepo.On("AllocateProbed", ctx, "pool-busy", "", "", "", mock.Anything).
+			Return(nil, fmt.Errorf("%w: 10 addresses in pool pool-busy", repository.ErrAddressesAnswering))
+		allocRepo.On("AllocateProbed", ctx, "pool-quiet", "", "", "", mock.Anything).
+			Return(&model.IPAllocation{IPPoolID: "pool-quiet", IPAddress: "10.1.0.10"}, nil)
+
+		allocation, err := svc.AllocateIPInZone(ctx, &AllocateIPInZoneInput{ZoneID: "zone-1", NetworkType: model.NetworkTypePrivate})
+		require.NoError(t, err)
+		assert.Equal(t, "pool-quiet", allocation.IPPoolID, "a pool whose candidates answer is skipped")
+	})
+
+	t.Run("reallocations are probed by the repository", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		allocRepo := new(MockIPAllocationRepository)
+		prober := &stubProber{responding: map[string]bool{"10.0.0.20": true}}
+		svc := NewIPAMService(poolRepo, allocRepo, config.HostnameConfig{}, 0, nil, prober, zap.NewNop())
+
+		var probe repository.AddressProbe
+		allocRepo.On("ReallocateProbed", ctx, "alloc-1", "10.0.0.20", mock.Anything).Run(func(args mock.Arguments) {
+			probe, _ = args.Get(3).(repository.AddressProbe) //nolint:errcheck // checked below
+		}).Return(nil, fmt.Errorf("%w: 10.0.0.20 answers on the network", repository.ErrIPInUse))
+
+		_, err := svc.ReallocateIP(ctx, "alloc-1", "10.0.0.20")
+		require.ErrorIs(t, err, repository.ErrIPInUse)
+		require.NotNil(t, probe)
+		assert.True(t, probe(ctx, net.ParseIP("10.0.0.20")))
+		allocRepo.AssertNotCalled(t, "Reallocate", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestNetworkProber_TCPFallback(t *testing.T) {
+	ctx := context.Background()
+	noICMP := func(context.Context, net.IP) (bool, error) { return false, errors.New("operation not permitted") }
+	newProber := func(port int) *networkProber {
+		return &networkProber{port: port, timeout: time.Second, logger: zap.NewNop(), ping: noICMP}
+	}
+	loopback := net.ParseIP("127.0.0.1")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	assert.True(t, newProber(port).Responds(ctx, loopback), "an accepted connection")
+
+	require.NoError(t, listener.Close())
+	assert.True(t, newProber(port).Responds(ctx, loopback), "a refused connection still means a host is there")
+
+	t.Run("ICMP answer is used when available", func(t *testing.T) {
+		prober := newProber(port)
+		prober.ping = func(context.Context, net.IP) (bool, error) { return false, nil }
+		assert.False(t, prober.Responds(ctx, loopback), "no echo reply, so TCP is not tried")
+	})
+}
+
+func TestEchoRequestChecksum(t *testing.T) {
+	msg := echoRequest(icmpv4EchoRequest, 0x1234, 0x0001)
+	assert.Equal(t, uint16(0), icmpChecksum(msg), "a message with its checksum sums to zero")
+}
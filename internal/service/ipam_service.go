@@ -2,32 +2,93 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"net"
+	"sort"
+	"sync"
 
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/events"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
 	"go.uber.org/zap"
 )
 
+// IPAM errors.
+var (
+	ErrInvalidNetworkType = errors.New("invalid network type")
+	ErrNoMatchingPool     = errors.New("no active IP pool matches the zone and network type")
+	ErrInvalidVLANTag     = errors.New("invalid VLAN tag")
+	ErrGatewayOutsideCIDR = errors.New("gateway is not within CIDR range")
+	ErrInvalidPoolRange   = errors.New("invalid IP pool range")
+	ErrPoolOverlap        = errors.New("IP pool range overlaps an existing pool")
+	ErrInvalidIPAddress   = errors.New("invalid IP address")
+)
+
+// ZoneExhaustedError reports that every active pool matching a zone allocation is full,
+// with the usage of each pool. It unwraps to repository.ErrPoolExhausted.
+type ZoneExhaustedError struct {
+	ZoneID      string                           `json:"zone_id"`
+	NetworkType string                           `json:"network_type"`
+	Pools       []*repository.PoolExhaustedError `json:"pools"`
+}
+
+// Error implements the error interface.
+func (e *ZoneExhaustedError) Error() string {
+	var total, allocated, reserved int64
+	for _, pool := range e.Pools {
+		total += pool.Total
+		allocated += pool.Allocated
+		reserved += pool.Reserved
+	}
+	return fmt.Sprintf("no available IP addresses in zone %s for network type %s: %d pools with %d addresses, %d allocated, %d reserved",
+		e.ZoneID, e.NetworkType, len(e.Pools), total, allocated, reserved)
+}
+
+// Unwrap returns repository.ErrPoolExhausted so callers can match with errors.Is.
+func (e *ZoneExhaustedError) Unwrap() error {
+	return repository.ErrPoolExhausted
+}
+
 // IPAMService defines the interface for IP Address Management operations.
 type IPAMService interface {
 	// Pool operations
-	ListPools(ctx context.Context, zoneID string, page, pageSize int) ([]*model.IPPool, int64, error)
+	ListPools(ctx context.Context, filters IPPoolFilters, page, pageSize int) ([]*model.IPPool, int64, error)
 	GetPool(ctx context.Context, id string) (*model.IPPool, error)
 	CreatePool(ctx context.Context, input *CreateIPPoolInput) (*model.IPPool, error)
 	UpdatePool(ctx context.Context, id string, input *UpdateIPPoolInput) (*model.IPPool, error)
 	DeletePool(ctx context.Context, id string) error
+	GetPoolUsage(ctx context.Context, id string) (*repository.Usage, error)
 
 	// Allocation operations
-	ListAllocations(ctx context.Context, poolID string, page, pageSize int) ([]*model.IPAllocation, int64, error)
+	ListAllocations(ctx context.Context, filters IPAllocationFilters, page, pageSize int) ([]*model.IPAllocation, int64, error)
 	GetAllocation(ctx context.Context, id string) (*model.IPAllocation, error)
 	AllocateIP(ctx context.Context, input *AllocateIPInput) (*model.IPAllocation, error)
+	AllocateIPInZone(ctx context.Context, input *AllocateIPInZoneInput) (*model.IPAllocation, error)
 	ReleaseIP(ctx context.Context, id string) error
+	ReallocateIP(ctx context.Context, allocationID, newIP string) (*model.IPAllocation, error)
 	GetAllocationsByResource(ctx context.Context, resourceID string) ([]*model.IPAllocation, error)
 	GetAvailableCount(ctx context.Context, poolID string) (int64, error)
+	ReserveRange(ctx context.Context, poolID, startIP, endIP, note string) error
+}
+
+// IPPoolFilters represents filters for IP pool listing.
+type IPPoolFilters struct {
+	ZoneID      string
+	NetworkType string
+}
+
+// IPAllocationFilters represents filters for IP allocation listing across pools.
+type IPAllocationFilters struct {
+	PoolID     string
+	Status     string
+	Hostname   string
+	ResourceID string
+	ZoneID     string
 }
 
 // CreateIPPoolInput represents input for creating an IP pool.
@@ -41,7 +102,11 @@ type CreateIPPoolInput struct {
 	EndIP       string
 	ZoneID      string
 	NetworkType string
+	Bridge      string
 	Description string
+	IsDefault   bool
+
+	VerifyBeforeAllocate bool
 }
 
 // UpdateIPPoolInput represents input for updating an IP pool.
@@ -50,8 +115,12 @@ type UpdateIPPoolInput struct {
 	Gateway     *string
 	DNS         *string
 	VLANTag     *int
+	Bridge      *string
 	Description *string
 	Status      *int8
+	IsDefault   *bool
+
+	VerifyBeforeAllocate *bool
 }
 
 // AllocateIPInput represents input for allocating an IP address.
@@ -62,29 +131,63 @@ type AllocateIPInput struct {
 	IPAddress  string // Optional: specific IP to allocate, empty for next available
 }
 
+// AllocateIPInZoneInput represents input for allocating an IP address from any matching pool in a zone.
+type AllocateIPInZoneInput struct {
+	ZoneID      string
+	NetworkType string
+	Hostname    string
+	ResourceID  string
+}
+
 type ipamService struct {
 	poolRepo       repository.IPPoolRepository
 	allocationRepo repository.IPAllocationRepository
+	hostnames      config.HostnameConfig
+	alertPercent   int
+	eventBus       *events.Bus
+	prober         AddressProber
 	logger         *zap.Logger
+
+	alertMu sync.Mutex
+	alerted map[string]bool // Pools at or above alertPercent that have been alerted on
 }
 
-// NewIPAMService creates a new IPAM service.
+// NewIPAMService creates a new IPAM service. When alertPercent is positive, an
+// events.IPPoolUtilizationHigh event is published on eventBus each time a pool's
+// utilization crosses it. Pools that verify addresses have each candidate probed with
+// prober before it is handed out; a nil prober skips the check.
 func NewIPAMService(
 	poolRepo repository.IPPoolRepository,
 	allocationRepo repository.IPAllocationRepository,
+	hostnames config.HostnameConfig,
+	alertPercent int,
+	eventBus *events.Bus,
+	prober AddressProber,
 	logger *zap.Logger,
 ) IPAMService {
 	return &ipamService{
 		poolRepo:       poolRepo,
 		allocationRepo: allocationRepo,
+		hostnames:      hostnames,
+		alertPercent:   alertPercent,
+		eventBus:       eventBus,
+		prober:         prober,
 		logger:         logger,
+		alerted:        make(map[string]bool),
 	}
 }
 
 // ListPools retrieves IP pools with pagination.
-func (s *ipamService) ListPools(ctx context.Context, zoneID string, page, pageSize int) ([]*model.IPPool, int64, error) {
+func (s *ipamService) ListPools(ctx context.Context, filters IPPoolFilters, page, pageSize int) ([]*model.IPPool, int64, error) {
+	if filters.NetworkType != "" && !model.IsValidNetworkType(filters.NetworkType) {
+		return nil, 0, fmt.Errorf("%w: %s", ErrInvalidNetworkType, filters.NetworkType)
+	}
+
 	offset := (page - 1) * pageSize
-	return s.poolRepo.List(ctx, zoneID, offset, pageSize)
+	return s.poolRepo.List(ctx, repository.IPPoolFilters{
+		ZoneID:      filters.ZoneID,
+		NetworkType: filters.NetworkType,
+	}, offset, pageSize)
 }
 
 // GetPool retrieves an IP pool by ID.
@@ -92,37 +195,34 @@ func (s *ipamService) GetPool(ctx context.Context, id string) (*model.IPPool, er
 	return s.poolRepo.GetByID(ctx, id)
 }
 
+// GetPoolUsage reports the allocations and resources that depend on an IP pool.
+func (s *ipamService) GetPoolUsage(ctx context.Context, id string) (*repository.Usage, error) {
+	if _, err := s.poolRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.poolRepo.GetUsage(ctx, id)
+}
+
 // CreatePool creates a new IP pool.
 func (s *ipamService) CreatePool(ctx context.Context, input *CreateIPPoolInput) (*model.IPPool, error) {
-	// Validate CIDR
-	_, ipNet, err := net.ParseCIDR(input.CIDR)
-	if err != nil {
-		return nil, fmt.Errorf("invalid CIDR: %w", err)
+	if err := validatePoolAddresses(input.CIDR, input.Gateway, input.StartIP, input.EndIP); err != nil {
+		return nil, err
 	}
 
-	// Validate start and end IP
-	startIP := net.ParseIP(input.StartIP)
-	if startIP == nil {
-		return nil, errors.New("invalid start IP address")
+	networkType := input.NetworkType
+	if networkType == "" {
+		networkType = model.NetworkTypePrivate
 	}
-
-	endIP := net.ParseIP(input.EndIP)
-	if endIP == nil {
-		return nil, errors.New("invalid end IP address")
+	if !model.IsValidNetworkType(networkType) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidNetworkType, networkType)
 	}
 
-	// Check if IPs are within CIDR range
-	if !ipNet.Contains(startIP) {
-		return nil, errors.New("start IP is not within CIDR range")
-	}
-	if !ipNet.Contains(endIP) {
-		return nil, errors.New("end IP is not within CIDR range")
+	vlanTag, err := normalizeVLANTag(input.VLANTag)
+	if err != nil {
+		return nil, err
 	}
-
-	// Validate gateway
-	gateway := net.ParseIP(input.Gateway)
-	if gateway == nil {
-		return nil, errors.New("invalid gateway IP address")
+	if err := s.checkPoolOverlap(ctx, input.ZoneID, input.StartIP, input.EndIP); err != nil {
+		return nil, err
 	}
 
 	pool := &model.IPPool{
@@ -130,14 +230,19 @@ func (s *ipamService) CreatePool(ctx context.Context, input *CreateIPPoolInput)
 		CIDR:        input.CIDR,
 		Gateway:     input.Gateway,
 		DNS:         input.DNS,
-		VLANTag:     input.VLANTag,
+		VLANTag:     vlanTag,
 		StartIP:     input.StartIP,
 		EndIP:       input.EndIP,
 		ZoneID:      input.ZoneID,
-		NetworkType: input.NetworkType,
+		NetworkType: networkType,
+		Bridge:      input.Bridge,
 		Description: input.Description,
 		Status:      1, // 1: active
+		IsDefault:   input.IsDefault,
+
+		VerifyBeforeAllocate: input.VerifyBeforeAllocate,
 	}
+	stampCreated(ctx, &pool.AuditStamp)
 
 	if err := s.poolRepo.Create(ctx, pool); err != nil {
 		return nil, fmt.Errorf("failed to create IP pool: %w", err)
@@ -157,9 +262,8 @@ func (s *ipamService) UpdatePool(ctx context.Context, id string, input *UpdateIP
 		pool.Name = *input.Name
 	}
 	if input.Gateway != nil {
-		gateway := net.ParseIP(*input.Gateway)
-		if gateway == nil {
-			return nil, errors.New("invalid gateway IP address")
+		if err := validatePoolAddresses(pool.CIDR, *input.Gateway, pool.StartIP, pool.EndIP); err != nil {
+			return nil, err
 		}
 		pool.Gateway = *input.Gateway
 	}
@@ -167,7 +271,14 @@ func (s *ipamService) UpdatePool(ctx context.Context, id string, input *UpdateIP
 		pool.DNS = *input.DNS
 	}
 	if input.VLANTag != nil {
-		pool.VLANTag = *input.VLANTag
+		vlanTag, err := normalizeVLANTag(*input.VLANTag)
+		if err != nil {
+			return nil, err
+		}
+		pool.VLANTag = vlanTag
+	}
+	if input.Bridge != nil {
+		pool.Bridge = *input.Bridge
 	}
 	if input.Description != nil {
 		pool.Description = *input.Description
@@ -175,6 +286,13 @@ func (s *ipamService) UpdatePool(ctx context.Context, id string, input *UpdateIP
 	if input.Status != nil {
 		pool.Status = *input.Status
 	}
+	if input.IsDefault != nil {
+		pool.IsDefault = *input.IsDefault
+	}
+	if input.VerifyBeforeAllocate != nil {
+		pool.VerifyBeforeAllocate = *input.VerifyBeforeAllocate
+	}
+	stampUpdated(ctx, &pool.AuditStamp)
 
 	if err := s.poolRepo.Update(ctx, pool); err != nil {
 		return nil, fmt.Errorf("failed to update IP pool: %w", err)
@@ -183,15 +301,102 @@ func (s *ipamService) UpdatePool(ctx context.Context, id string, input *UpdateIP
 	return pool, nil
 }
 
+// validatePoolAddresses checks that a pool's gateway and allocation range are valid addresses
+// inside its CIDR.
+func validatePoolAddresses(cidr, gatewayIP, startIP, endIP string) error {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR: %w", err)
+	}
+
+	start := net.ParseIP(startIP)
+	if start == nil {
+		return errors.New("invalid start IP address")
+	}
+	end := net.ParseIP(endIP)
+	if end == nil {
+		return errors.New("invalid end IP address")
+	}
+	if !ipNet.Contains(start) {
+		return errors.New("start IP is not within CIDR range")
+	}
+	if !ipNet.Contains(end) {
+		return errors.New("end IP is not within CIDR range")
+	}
+	if bytes.Compare(start.To16(), end.To16()) > 0 {
+		return fmt.Errorf("%w: start IP %s is after end IP %s", ErrInvalidPoolRange, startIP, endIP)
+	}
+
+	gateway := net.ParseIP(gatewayIP)
+	if gateway == nil {
+		return errors.New("invalid gateway IP address")
+	}
+	if !ipNet.Contains(gateway) {
+		return fmt.Errorf("%w: %s is outside %s", ErrGatewayOutsideCIDR, gatewayIP, cidr)
+	}
+	return nil
+}
+
+// checkPoolOverlap returns ErrPoolOverlap if the range from startIP to endIP shares an address
+// with the range of a pool already in the zone.
+func (s *ipamService) checkPoolOverlap(ctx context.Context, zoneID, startIP, endIP string) error {
+	// A limit of -1 lists every pool in the zone, not a page of them
+	pools, _, err := s.poolRepo.List(ctx, repository.IPPoolFilters{ZoneID: zoneID}, 0, -1)
+	if err != nil {
+		return fmt.Errorf("failed to list IP pools: %w", err)
+	}
+	start, end := net.ParseIP(startIP), net.ParseIP(endIP)
+	for _, pool := range pools {
+		poolStart, poolEnd := net.ParseIP(pool.StartIP), net.ParseIP(pool.EndIP)
+		if poolStart == nil || poolEnd == nil {
+			continue
+		}
+		if isIPInRange(start, poolStart, poolEnd) || isIPInRange(poolStart, start, end) {
+			return fmt.Errorf("%w: %s-%s overlaps pool %q (%s-%s)",
+				ErrPoolOverlap, startIP, endIP, pool.Name, pool.StartIP, pool.EndIP)
+		}
+	}
+	return nil
+}
+
+// normalizeVLANTag validates a pool VLAN tag. 0 means untagged; -1, which older pools used for
+// the same thing, is accepted and stored as 0.
+func normalizeVLANTag(tag int) (int, error) {
+	if tag == model.VLANUntagged || tag == -1 {
+		return model.VLANUntagged, nil
+	}
+	if tag < model.MinVLANTag || tag > model.MaxVLANTag {
+		return 0, fmt.Errorf("%w: %d must be between %d and %d, or %d for untagged",
+			ErrInvalidVLANTag, tag, model.MinVLANTag, model.MaxVLANTag, model.VLANUntagged)
+	}
+	return tag, nil
+}
+
 // DeletePool deletes an IP pool.
 func (s *ipamService) DeletePool(ctx context.Context, id string) error {
 	return s.poolRepo.Delete(ctx, id)
 }
 
-// ListAllocations retrieves IP allocations for a pool.
-func (s *ipamService) ListAllocations(ctx context.Context, poolID string, page, pageSize int) ([]*model.IPAllocation, int64, error) {
+// ListAllocations retrieves IP allocations, optionally scoped to a pool, zone, resource or status.
+func (s *ipamService) ListAllocations(ctx context.Context, filters IPAllocationFilters, page, pageSize int) ([]*model.IPAllocation, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = constants.DefaultPageSize
+	}
+	if pageSize > constants.MaxPageSize {
+		pageSize = constants.MaxPageSize
+	}
+
 	offset := (page - 1) * pageSize
-	return s.allocationRepo.ListByPool(ctx, poolID, offset, pageSize)
+	return s.allocationRepo.List(ctx, repository.IPAllocationFilters{
+		PoolID:     filters.PoolID,
+		Status:     filters.Status,
+		Hostname:   filters.Hostname,
+		ResourceID: filters.ResourceID,
+		ZoneID:     filters.ZoneID,
+	}, offset, pageSize)
 }
 
 // GetAllocation retrieves an IP allocation by ID.
@@ -199,64 +404,149 @@ func (s *ipamService) GetAllocation(ctx context.Context, id string) (*model.IPAl
 	return s.allocationRepo.GetByID(ctx, id)
 }
 
-// AllocateIP allocates an IP address from a pool.
-//
-//nolint:nestif // nested conditions needed for IP validation and allocation logic
+// AllocateIP allocates an IP address from a pool: the requested address when one is given,
+// and the next available one otherwise.
 func (s *ipamService) AllocateIP(ctx context.Context, input *AllocateIPInput) (*model.IPAllocation, error) {
-	if input.IPAddress != "" {
-		// Check if the specific IP is available
-		existing, err := s.allocationRepo.GetByIPAddress(ctx, input.PoolID, input.IPAddress)
-		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+	hostname := input.Hostname
+	if hostname != "" {
+		var err error
+		if hostname, err = normalizeHostname(hostname); err != nil {
 			return nil, err
 		}
-		if existing != nil && existing.Status != "available" {
-			return nil, errors.New("IP address is already allocated")
-		}
-
-		// Validate the IP is within the pool range
+	} else if s.hostnames.Generate {
 		pool, err := s.poolRepo.GetByID(ctx, input.PoolID)
 		if err != nil {
 			return nil, err
 		}
-
-		ip := net.ParseIP(input.IPAddress)
-		if ip == nil {
-			return nil, errors.New("invalid IP address")
+		if hostname, err = s.generateHostname(ctx, pool); err != nil {
+			return nil, err
 		}
+	}
+
+	allocation, err := s.allocate(ctx, input.PoolID, input.IPAddress, hostname, input.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+	s.checkUtilization(ctx, input.PoolID)
+	return allocation, nil
+}
 
-		startIP := net.ParseIP(pool.StartIP)
-		endIP := net.ParseIP(pool.EndIP)
-		if !isIPInRange(ip, startIP, endIP) {
-			return nil, errors.New("IP address is not within pool range")
+// AllocateIPInZone allocates the next available IP from the active pools in a zone that
+// serve the requested network type, trying each pool until one has a free address. The
+// zone's default pool is tried first.
+func (s *ipamService) AllocateIPInZone(ctx context.Context, input *AllocateIPInZoneInput) (*model.IPAllocation, error) {
+	if input.ZoneID == "" {
+		return nil, errors.New("zone ID is required")
+	}
+	if !model.IsValidNetworkType(input.NetworkType) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidNetworkType, input.NetworkType)
+	}
+	if input.Hostname != "" {
+		if _, err := normalizeHostname(input.Hostname); err != nil {
+			return nil, err
 		}
+	}
 
-		// Allocate the specific IP
-		var resID *string
-		if input.ResourceID != "" {
-			resID = &input.ResourceID
+	active := int8(1)
+	pools, _, err := s.poolRepo.List(ctx, repository.IPPoolFilters{
+		ZoneID:      input.ZoneID,
+		NetworkType: input.NetworkType,
+		Status:      &active,
+	}, 0, constants.MaxPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IP pools: %w", err)
+	}
+	if len(pools) == 0 {
+		return nil, ErrNoMatchingPool
+	}
+	sort.SliceStable(pools, func(i, j int) bool { return pools[i].IsDefault && !pools[j].IsDefault })
+
+	var lastErr error
+	exhausted := &ZoneExhaustedError{ZoneID: input.ZoneID, NetworkType: input.NetworkType}
+	for _, pool := range pools {
+		hostname, err := s.resolveHostname(ctx, pool, input.Hostname)
+		if err != nil {
+			return nil, err
 		}
-		allocation := &model.IPAllocation{
-			IPPoolID:   input.PoolID,
-			IPAddress:  input.IPAddress,
-			Hostname:   input.Hostname,
-			ResourceID: resID,
-			Status:     "allocated",
+		allocation, allocErr := s.allocate(ctx, pool.ID, "", hostname, input.ResourceID)
+		if allocErr == nil {
+			s.checkUtilization(ctx, pool.ID)
+			return allocation, nil
 		}
-
-		if err := s.allocationRepo.Create(ctx, allocation); err != nil {
-			return nil, fmt.Errorf("failed to allocate IP: %w", err)
+		s.logger.Debug("pool has no free address, trying next",
+			zap.String("pool_id", pool.ID),
+			zap.Error(allocErr),
+		)
+		var poolErr *repository.PoolExhaustedError
+		if errors.As(allocErr, &poolErr) {
+			exhausted.Pools = append(exhausted.Pools, poolErr)
+			continue
 		}
+		lastErr = allocErr
+	}
 
-		return allocation, nil
+	if lastErr != nil {
+		return nil, fmt.Errorf("failed to allocate IP in zone: %w", lastErr)
 	}
+	return nil, exhausted
+}
 
-	// Allocate next available IP
-	return s.allocationRepo.AllocateNextAvailable(ctx, input.PoolID, input.Hostname, input.ResourceID)
+// allocate allocates ip from a pool, or its next available address when ip is empty. With a
+// prober, the repository probes the candidates of pools that verify addresses before
+// recording them.
+func (s *ipamService) allocate(ctx context.Context, poolID, ip, hostname, resourceID string) (*model.IPAllocation, error) {
+	if s.prober != nil {
+		return s.allocationRepo.AllocateProbed(ctx, poolID, ip, hostname, resourceID, s.prober.Responds)
+	}
+	if ip != "" {
+		return s.allocationRepo.AllocateSpecific(ctx, poolID, ip, hostname, resourceID)
+	}
+	return s.allocationRepo.AllocateNextAvailable(ctx, poolID, hostname, resourceID)
 }
 
 // ReleaseIP releases an allocated IP address.
 func (s *ipamService) ReleaseIP(ctx context.Context, id string) error {
-	return s.allocationRepo.Release(ctx, id)
+	if s.alertPercent <= 0 {
+		return s.allocationRepo.Release(ctx, id)
+	}
+
+	allocation, err := s.allocationRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.allocationRepo.Release(ctx, id); err != nil {
+		return err
+	}
+	s.checkUtilization(ctx, allocation.IPPoolID)
+	return nil
+}
+
+// ReallocateIP moves an allocation to a new IP address (or the next free one when newIP is empty)
+// in the same pool, keeping its hostname and resource binding.
+func (s *ipamService) ReallocateIP(ctx context.Context, allocationID, newIP string) (*model.IPAllocation, error) {
+	if allocationID == "" {
+		return nil, errors.New("allocation ID is required")
+	}
+	if newIP != "" && net.ParseIP(newIP) == nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidIPAddress, newIP)
+	}
+
+	var allocation *model.IPAllocation
+	var err error
+	if s.prober != nil {
+		allocation, err = s.allocationRepo.ReallocateProbed(ctx, allocationID, newIP, s.prober.Responds)
+	} else {
+		allocation, err = s.allocationRepo.Reallocate(ctx, allocationID, newIP)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("reallocated IP address",
+		zap.String("allocation_id", allocation.ID),
+		zap.String("ip_address", allocation.IPAddress),
+	)
+	return allocation, nil
 }
 
 // GetAllocationsByResource retrieves all IP allocations for a resource.
@@ -269,16 +559,70 @@ func (s *ipamService) GetAvailableCount(ctx context.Context, poolID string) (int
 	return s.allocationRepo.GetAvailableCount(ctx, poolID)
 }
 
-// isIPInRange checks if an IP is within the given range.
-func isIPInRange(ip, start, end net.IP) bool {
-	ip = ip.To16()
-	start = start.To16()
-	end = end.To16()
+// ReserveRange marks the addresses from startIP to endIP in a pool as reserved, so the
+// allocator never hands them out; note records what they are kept for. Reservations are listed
+// as allocations with the reserved status and lifted by releasing them.
+func (s *ipamService) ReserveRange(ctx context.Context, poolID, startIP, endIP, note string) error {
+	if err := s.allocationRepo.ReserveRange(ctx, poolID, startIP, endIP, note); err != nil {
+		return err
+	}
+	s.checkUtilization(ctx, poolID)
+	return nil
+}
 
-	for i := 0; i < len(ip); i++ {
-		if ip[i] < start[i] || ip[i] > end[i] {
-			return false
-		}
+// checkUtilization publishes events.IPPoolUtilizationHigh when a pool's utilization has
+// reached the alert threshold and the pool has not been alerted on since it was last below
+// it. Dropping below the threshold re-arms the alert. Failures are logged rather than
+// returned, as the allocation or release that triggered the check has already happened.
+func (s *ipamService) checkUtilization(ctx context.Context, poolID string) {
+	if s.alertPercent <= 0 {
+		return
+	}
+	utilization, err := s.allocationRepo.GetUtilization(ctx, poolID)
+	if err != nil {
+		s.logger.Warn("failed to check IP pool utilization", zap.String("pool_id", poolID), zap.Error(err))
+		return
 	}
-	return true
+
+	percent := utilization.Percent()
+	above := percent >= float64(s.alertPercent)
+	s.alertMu.Lock()
+	crossed := above && !s.alerted[poolID]
+	if above {
+		s.alerted[poolID] = true
+	} else {
+		delete(s.alerted, poolID)
+	}
+	s.alertMu.Unlock()
+	if !crossed {
+		return
+	}
+
+	s.logger.Warn("IP pool utilization crossed alert threshold",
+		zap.String("pool_id", poolID),
+		zap.String("pool_name", utilization.PoolName),
+		zap.Int64("used", utilization.Used()),
+		zap.Int64("total", utilization.Total),
+		zap.Int("threshold_percent", s.alertPercent),
+	)
+	s.eventBus.Publish(ctx, events.Event{
+		Type: events.IPPoolUtilizationHigh,
+		Data: map[string]interface{}{
+			"pool_id":           utilization.PoolID,
+			"pool_name":         utilization.PoolName,
+			"zone_id":           utilization.ZoneID,
+			"total":             utilization.Total,
+			"allocated":         utilization.Allocated,
+			"reserved":          utilization.Reserved,
+			"percent":           percent,
+			"threshold_percent": s.alertPercent,
+		},
+	})
+}
+
+// isIPInRange checks if an IP is within the given range. Addresses compare as big-endian
+// numbers in their 16-byte form, so IPv4 and IPv6 ranges work alike.
+func isIPInRange(ip, start, end net.IP) bool {
+	ip = ip.To16()
+	return bytes.Compare(ip, start.To16()) >= 0 && bytes.Compare(ip, end.To16()) <= 0
 }
@@ -0,0 +1,720 @@
+// Package service provides IPAM service tests.
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/events"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockIPPoolRepository is a mock implementation of IPPoolRepository.
+type MockIPPoolRepository struct {
+	mock.Mock
+}
+
+func (m *MockIPPoolRepository) Create(ctx context.Context, pool *model.IPPool) error {
+	args := m.Called(ctx, pool)
+	return args.Error(0)
+}
+
+func (m *MockIPPoolRepository) GetByID(ctx context.Context, id string) (*model.IPPool, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	pool, ok := args.Get(0).(*model.IPPool)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return pool, args.Error(1)
+}
+
+func (m *MockIPPoolRepository) List(ctx context.Context, filters repository.IPPoolFilters, offset, limit int) ([]*model.IPPool, int64, error) {
+	args := m.Called(ctx, filters, offset, limit)
+	pools, ok := args.Get(0).([]*model.IPPool)
+	if !ok {
+		return nil, 0, args.Error(2)
+	}
+	total, ok := args.Get(1).(int64)
+	if !ok {
+		return pools, 0, args.Error(2)
+	}
+	return pools, total, args.Error(2)
+}
+
+func (m *MockIPPoolRepository) Update(ctx context.Context, pool *model.IPPool) error {
+	args := m.Called(ctx, pool)
+	return args.Error(0)
+}
+
+func (m *MockIPPoolRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockIPPoolRepository) GetUsage(ctx context.Context, id string) (*repository.Usage, error) {
+	args := m.Called(ctx, id)
+	usage, ok := args.Get(0).(*repository.Usage)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return usage, args.Error(1)
+}
+
+// MockIPAllocationRepository is a mock implementation of IPAllocationRepository.
+type MockIPAllocationRepository struct {
+	mock.Mock
+}
+
+func (m *MockIPAllocationRepository) allocation(args mock.Arguments) (*model.IPAllocation, error) {
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	allocation, ok := args.Get(0).(*model.IPAllocation)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return allocation, args.Error(1)
+}
+
+func (m *MockIPAllocationRepository) Create(ctx context.Context, allocation *model.IPAllocation) error {
+	args := m.Called(ctx, allocation)
+	return args.Error(0)
+}
+
+func (m *MockIPAllocationRepository) GetByID(ctx context.Context, id string) (*model.IPAllocation, error) {
+	return m.allocation(m.Called(ctx, id))
+}
+
+func (m *MockIPAllocationRepository) GetByIPAddress(ctx context.Context, poolID, ipAddress string) (*model.IPAllocation, error) {
+	return m.allocation(m.Called(ctx, poolID, ipAddress))
+}
+
+func (m *MockIPAllocationRepository) List(ctx context.Context, filters repository.IPAllocationFilters, offset, limit int) ([]*model.IPAllocation, int64, error) {
+	args := m.Called(ctx, filters, offset, limit)
+	allocations, ok := args.Get(0).([]*model.IPAllocation)
+	if !ok {
+		return nil, 0, args.Error(2)
+	}
+	total, ok := args.Get(1).(int64)
+	if !ok {
+		return allocations, 0, args.Error(2)
+	}
+	return allocations, total, args.Error(2)
+}
+
+func (m *MockIPAllocationRepository) ListByPool(ctx context.Context, poolID string, offset, limit int) ([]*model.IPAllocation, int64, error) {
+	args := m.Called(ctx, poolID, offset, limit)
+	allocations, ok := args.Get(0).([]*model.IPAllocation)
+	if !ok {
+		return nil, 0, args.Error(2)
+	}
+	total, ok := args.Get(1).(int64)
+	if !ok {
+		return allocations, 0, args.Error(2)
+	}
+	return allocations, total, args.Error(2)
+}
+
+func (m *MockIPAllocationRepository) ListByResource(ctx context.Context, resourceID string) ([]*model.IPAllocation, error) {
+	args := m.Called(ctx, resourceID)
+	allocations, ok := args.Get(0).([]*model.IPAllocation)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return allocations, args.Error(1)
+}
+
+func (m *MockIPAllocationRepository) Update(ctx context.Context, allocation *model.IPAllocation) error {
+	args := m.Called(ctx, allocation)
+	return args.Error(0)
+}
+
+func (m *MockIPAllocationRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockIPAllocationRepository) AllocateNextAvailable(ctx context.Context, poolID, hostname, resourceID string) (*model.IPAllocation, error) {
+	return m.allocation(m.Called(ctx, poolID, hostname, resourceID))
+}
+
+func (m *MockIPAllocationRepository) AllocateProbed(ctx context.Context, poolID, ip, hostname, resourceID string, probe repository.AddressProbe) (*model.IPAllocation, error) {
+	return m.allocation(m.Called(ctx, poolID, ip, hostname, resourceID, probe))
+}
+
+func (m *MockIPAllocationRepository) AllocateSpecific(ctx context.Context, poolID, ip, hostname, resourceID string) (*model.IPAllocation, error) {
+	return m.allocation(m.Called(ctx, poolID, ip, hostname, resourceID))
+}
+
+func (m *MockIPAllocationRepository) ReserveRange(ctx context.Context, poolID, startIP, endIP, note string) error {
+	args := m.Called(ctx, poolID, startIP, endIP, note)
+	return args.Error(0)
+}
+
+func (m *MockIPAllocationRepository) ListHostnames(ctx context.Context, poolID, prefix string) ([]string, error) {
+	args := m.Called(ctx, poolID, prefix)
+	hostnames, ok := args.Get(0).([]string)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return hostnames, args.Error(1)
+}
+
+func (m *MockIPAllocationRepository) Release(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockIPAllocationRepository) ReallocateProbed(ctx context.Context, id, newIP string, probe repository.AddressProbe) (*model.IPAllocation, error) {
+	return m.allocation(m.Called(ctx, id, newIP, probe))
+}
+
+func (m *MockIPAllocationRepository) Reallocate(ctx context.Context, id, newIP string) (*model.IPAllocation, error) {
+	return m.allocation(m.Called(ctx, id, newIP))
+}
+
+func (m *MockIPAllocationRepository) GetAvailableCount(ctx context.Context, poolID string) (int64, error) {
+	args := m.Called(ctx, poolID)
+	count, ok := args.Get(0).(int64)
+	if !ok {
+		return 0, args.Error(1)
+	}
+	return count, args.Error(1)
+}
+
+func (m *MockIPAllocationRepository) GetUtilization(ctx context.Context, poolID string) (*repository.PoolUtilization, error) {
+	args := m.Called(ctx, poolID)
+	utilization, ok := args.Get(0).(*repository.PoolUtilization)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return utilization, args.Error(1)
+}
+
+func TestIPAMService_ListPoolsByNetworkType(t *testing.T) {
+	t.Run("filters by network type", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		svc := NewIPAMService(poolRepo, nil, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+		ctx := context.Background()
+
+		public := []*model.IPPool{{BaseModel: model.BaseModel{ID: "pool-public"}, NetworkType: model.NetworkTypePublic}}
+		poolRepo.On("List", ctx, repository.IPPoolFilters{ZoneID: "zone-1", NetworkType: model.NetworkTypePublic}, 0, 20).
+			Return(public, int64(1), nil)
+
+		pools, total, err := svc.ListPools(ctx, IPPoolFilters{ZoneID: "zone-1", NetworkType: model.NetworkTypePublic}, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, "pool-public", pools[0].ID)
+		poolRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects unknown network type", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		svc := NewIPAMService(poolRepo, nil, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+
+		_, _, err := svc.ListPools(context.Background(), IPPoolFilters{NetworkType: "dmz"}, 1, 20)
+		require.ErrorIs(t, err, ErrInvalidNetworkType)
+		poolRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestIPAMService_AllocateIPInZone(t *testing.T) {
+	active := int8(1)
+
+	t.Run("draws only from pools of the requested network type", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		allocRepo := new(MockIPAllocationRepository)
+		svc := NewIPAMService(poolRepo, allocRepo, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+		ctx := context.Background()
+
+		filters := repository.IPPoolFilters{ZoneID: "zone-1", NetworkType: model.NetworkTypePublic, Status: &active}
+		pools := []*model.IPPool{
+			{BaseModel: model.BaseModel{ID: "public-full"}, NetworkType: model.NetworkTypePublic},
+			{BaseModel: model.BaseModel{ID: "public-free"}, NetworkType: model.NetworkTypePublic},
+		}
+		poolRepo.On("List", ctx, filters, 0, mock.Anything).Return(pools, int64(2), nil)
+		allocRepo.On("AllocateNextAvailable", ctx, "public-full", "web-1", "").
+			Return(nil, errors.New("no available IP addresses in pool"))
+		allocRepo.On("AllocateNextAvailable", ctx, "public-free", "web-1", "").
+			Return(&model.IPAllocation{IPPoolID: "public-free", IPAddress: "203.0.113.10"}, nil)
+
+		allocation, err := svc.AllocateIPInZone(ctx, &AllocateIPInZoneInput{
+			ZoneID:      "zone-1",
+			NetworkType: model.NetworkTypePublic,
+			Hostname:    "web-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "public-free", allocation.IPPoolID)
+		poolRepo.AssertExpectations(t)
+		allocRepo.AssertExpectations(t)
+	})
+
+	t.Run("tries the zone's default pool first", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		allocRepo := new(MockIPAllocationRepository)
+		svc := NewIPAMService(poolRepo, allocRepo, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+		ctx := context.Background()
+
+		pools := []*model.IPPool{
+			{BaseModel: model.BaseModel{ID: "private-a"}, NetworkType: model.NetworkTypePrivate},
+			{BaseModel: model.BaseModel{ID: "private-default"}, NetworkType: model.NetworkTypePrivate, IsDefault: true},
+		}
+		poolRepo.On("List", ctx, mock.Anything, 0, mock.Anything).Return(pools, int64(2), nil)
+		allocRepo.On("AllocateNextAvailable", ctx, "private-default", "app-1", "").
+			Return(&model.IPAllocation{IPPoolID: "private-default", IPAddress: "10.0.0.10"}, nil)
+
+		allocation, err := svc.AllocateIPInZone(ctx, &AllocateIPInZoneInput{
+			ZoneID:      "zone-1",
+			NetworkType: model.NetworkTypePrivate,
+			Hostname:    "app-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "private-default", allocation.IPPoolID)
+		allocRepo.AssertNotCalled(t, "AllocateNextAvailable", ctx, "private-a", mock.Anything, mock.Anything)
+	})
+
+	t.Run("all pools exhausted reports their usage", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		allocRepo := new(MockIPAllocationRepository)
+		svc := NewIPAMService(poolRepo, allocRepo, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+		ctx := context.Background()
+
+		pools := []*model.IPPool{
+			{BaseModel: model.BaseModel{ID: "mgmt-a"}, NetworkType: model.NetworkTypeManagement},
+			{BaseModel: model.BaseModel{ID: "mgmt-b"}, NetworkType: model.NetworkTypeManagement},
+		}
+		poolRepo.On("List", ctx, mock.Anything, 0, mock.Anything).Return(pools, int64(2), nil)
+		allocRepo.On("AllocateNextAvailable", ctx, "mgmt-a", "node-1", "").
+			Return(nil, &repository.PoolExhaustedError{PoolID: "mgmt-a", Total: 10, Allocated: 8, Reserved: 2})
+		allocRepo.On("AllocateNextAvailable", ctx, "mgmt-b", "node-1", "").
+			Return(nil, &repository.PoolExhaustedError{PoolID: "mgmt-b", Total: 5, Allocated: 5})
+
+		_, err := svc.AllocateIPInZone(ctx, &AllocateIPInZoneInput{
+			ZoneID:      "zone-1",
+			NetworkType: model.NetworkTypeManagement,
+			Hostname:    "node-1",
+		})
+		require.ErrorIs(t, err, repository.ErrPoolExhausted)
+
+		var exhausted *ZoneExhaustedError
+		require.ErrorAs(t, err, &exhausted)
+		require.Len(t, exhausted.Pools, 2)
+		assert.Equal(t, "mgmt-b", exhausted.Pools[1].PoolID)
+		assert.Contains(t, err.Error(), "2 pools with 15 addresses, 13 allocated, 2 reserved")
+	})
+
+	t.Run("no matching pool", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		svc := NewIPAMService(poolRepo, new(MockIPAllocationRepository), config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+		ctx := context.Background()
+
+		poolRepo.On("List", ctx, mock.Anything, 0, mock.Anything).Return([]*model.IPPool{}, int64(0), nil)
+
+		_, err := svc.AllocateIPInZone(ctx, &AllocateIPInZoneInput{ZoneID: "zone-1", NetworkType: model.NetworkTypeStorage})
+		require.ErrorIs(t, err, ErrNoMatchingPool)
+	})
+
+	t.Run("rejects unknown network type", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		svc := NewIPAMService(poolRepo, new(MockIPAllocationRepository), config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+
+		_, err := svc.AllocateIPInZone(context.Background(), &AllocateIPInZoneInput{ZoneID: "zone-1", NetworkType: "vmbr0"})
+		require.ErrorIs(t, err, ErrInvalidNetworkType)
+		poolRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestIPAMService_CreatePoolNetworkType(t *testing.T) {
+	poolRepo := new(MockIPPoolRepository)
+	svc := NewIPAMService(poolRepo, nil, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+	ctx := context.Background()
+	poolRepo.On("List", ctx, repository.IPPoolFilters{ZoneID: "zone-1"}, 0, -1).Return([]*model.IPPool{}, int64(0), nil)
+	poolRepo.On("Create", ctx, mock.AnythingOfType("*model.IPPool")).Return(nil)
+
+	input := &CreateIPPoolInput{
+		Name:    "pool",
+		CIDR:    "10.0.0.0/24",
+		Gateway: "10.0.0.1",
+		StartIP: "10.0.0.10",
+		EndIP:   "10.0.0.20",
+		ZoneID:  "zone-1",
+	}
+	pool, err := svc.CreatePool(ctx, input)
+	require.NoError(t, err)
+	assert.Equal(t, model.NetworkTypePrivate, pool.NetworkType)
+
+	input.NetworkType = "bogus"
+	_, err = svc.CreatePool(ctx, input)
+	require.ErrorIs(t, err, ErrInvalidNetworkType)
+}
+
+func TestIPAMService_PoolVLANTag(t *testing.T) {
+	poolRepo := new(MockIPPoolRepository)
+	svc := NewIPAMService(poolRepo, nil, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+	ctx := context.Background()
+	poolRepo.On("List", ctx, repository.IPPoolFilters{ZoneID: "zone-1"}, 0, -1).Return([]*model.IPPool{}, int64(0), nil)
+	poolRepo.On("Create", ctx, mock.AnythingOfType("*model.IPPool")).Return(nil)
+	poolRepo.On("Update", ctx, mock.AnythingOfType("*model.IPPool")).Return(nil)
+	poolRepo.On("GetByID", ctx, "pool-1").Return(&model.IPPool{BaseModel: model.BaseModel{ID: "pool-1"}, VLANTag: 100}, nil)
+	poolRepo.On("GetByID", ctx, "pool-2").Return(&model.IPPool{BaseModel: model.BaseModel{ID: "pool-2"}, VLANTag: 200}, nil)
+
+	newInput := func(tag int) *CreateIPPoolInput {
+		return &CreateIPPoolInput{
+			Name:    "pool",
+			CIDR:    "10.0.0.0/24",
+			Gateway: "10.0.0.1",
+			StartIP: "10.0.0.10",
+			EndIP:   "10.0.0.20",
+			ZoneID:  "zone-1",
+			VLANTag: tag,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		tag     int
+		want    int
+		wantErr bool
+	}{
+		{name: "valid tag", tag: 100, want: 100},
+		{name: "lowest tag", tag: model.MinVLANTag, want: model.MinVLANTag},
+		{name: "highest tag", tag: model.MaxVLANTag, want: model.MaxVLANTag},
+		{name: "zero is untagged", tag: 0, want: model.VLANUntagged},
+		{name: "legacy -1 is untagged", tag: -1, want: model.VLANUntagged},
+		{name: "reserved 4095", tag: 4095, wantErr: true},
+		{name: "above range", tag: 5000, wantErr: true},
+		{name: "negative", tag: -2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run("create "+tt.name, func(t *testing.T) {
+			pool, err := svc.CreatePool(ctx, newInput(tt.tag))
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidVLANTag)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, pool.VLANTag)
+		})
+		t.Run("update "+tt.name, func(t *testing.T) {
+			tag := tt.tag
+			pool, err := svc.UpdatePool(ctx, "pool-1", &UpdateIPPoolInput{VLANTag: &tag})
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidVLANTag)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, pool.VLANTag)
+		})
+	}
+
+	t.Run("update without a tag keeps the current one", func(t *testing.T) {
+		name := "renamed"
+		pool, err := svc.UpdatePool(ctx, "pool-2", &UpdateIPPoolInput{Name: &name})
+		require.NoError(t, err)
+		assert.Equal(t, 200, pool.VLANTag)
+	})
+}
+
+func TestIPAMService_PoolGateway(t *testing.T) {
+	ctx := context.Background()
+	newPool := func() *model.IPPool {
+		return &model.IPPool{
+			BaseModel: model.BaseModel{ID: "pool-1"},
+			CIDR:      "10.0.0.0/24",
+			Gateway:   "10.0.0.1",
+			StartIP:   "10.0.0.10",
+			EndIP:     "10.0.0.20",
+		}
+	}
+
+	t.Run("create rejects a gateway outside the CIDR", func(t *testing.T) {
+		svc := NewIPAMService(new(MockIPPoolRepository), nil, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+		_, err := svc.CreatePool(ctx, &CreateIPPoolInput{
+			Name:    "pool",
+			CIDR:    "10.0.0.0/24",
+			Gateway: "10.0.1.1",
+			StartIP: "10.0.0.10",
+			EndIP:   "10.0.0.20",
+			ZoneID:  "zone-1",
+		})
+		require.ErrorIs(t, err, ErrGatewayOutsideCIDR)
+	})
+
+	t.Run("update rejects a gateway outside the CIDR", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		poolRepo.On("GetByID", ctx, "pool-1").Return(newPool(), nil)
+		svc := NewIPAMService(poolRepo, nil, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+
+		gateway := "192.168.1.1"
+		_, err := svc.UpdatePool(ctx, "pool-1", &UpdateIPPoolInput{Gateway: &gateway})
+		require.ErrorIs(t, err, ErrGatewayOutsideCIDR)
+		poolRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("update rejects an invalid gateway", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		poolRepo.On("GetByID", ctx, "pool-1").Return(newPool(), nil)
+		svc := NewIPAMService(poolRepo, nil, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+
+		gateway := "not-an-ip"
+		_, err := svc.UpdatePool(ctx, "pool-1", &UpdateIPPoolInput{Gateway: &gateway})
+		require.Error(t, err)
+		poolRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("update accepts a gateway inside the CIDR", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		poolRepo.On("GetByID", ctx, "pool-1").Return(newPool(), nil)
+		poolRepo.On("Update", ctx, mock.AnythingOfType("*model.IPPool")).Return(nil)
+		svc := NewIPAMService(poolRepo, nil, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+
+		gateway := "10.0.0.254"
+		pool, err := svc.UpdatePool(ctx, "pool-1", &UpdateIPPoolInput{Gateway: &gateway})
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.254", pool.Gateway)
+	})
+}
+
+func TestIPAMService_CreatePoolRange(t *testing.T) {
+	ctx := context.Background()
+	existing := []*model.IPPool{
+		{BaseModel: model.BaseModel{ID: "pool-1"}, Name: "servers", ZoneID: "zone-1", StartIP: "10.0.0.10", EndIP: "10.0.0.100"},
+		{BaseModel: model.BaseModel{ID: "pool-2"}, Name: "servers-v6", ZoneID: "zone-1", StartIP: "fd00::10", EndIP: "fd00::ff"},
+	}
+
+	tests := []struct {
+		name    string
+		cidr    string
+		gateway string
+		start   string
+		end     string
+		wantErr error
+	}{
+		{name: "range after the existing pool", cidr: "10.0.0.0/24", gateway: "10.0.0.1", start: "10.0.0.101", end: "10.0.0.200"},
+		{name: "range before the existing pool", cidr: "10.0.0.0/24", gateway: "10.0.0.1", start: "10.0.0.2", end: "10.0.0.9"},
+		{name: "single address", cidr: "10.0.0.0/24", gateway: "10.0.0.1", start: "10.0.0.150", end: "10.0.0.150"},
+		{name: "IPv6 range beside the existing pool", cidr: "fd00::/64", gateway: "fd00::1", start: "fd00::100", end: "fd00::1ff"},
+		{name: "overlaps the end", cidr: "10.0.0.0/24", gateway: "10.0.0.1", start: "10.0.0.100", end: "10.0.0.200", wantErr: ErrPoolOverlap},
+		{name: "overlaps the start", cidr: "10.0.0.0/24", gateway: "10.0.0.1", start: "10.0.0.2", end: "10.0.0.10", wantErr: ErrPoolOverlap},
+		{name: "inside the existing pool", cidr: "10.0.0.0/24", gateway: "10.0.0.1", start: "10.0.0.20", end: "10.0.0.30", wantErr: ErrPoolOverlap},
+		{name: "contains the existing pool", cidr: "10.0.0.0/24", gateway: "10.0.0.1", start: "10.0.0.2", end: "10.0.0.250", wantErr: ErrPoolOverlap},
+		{name: "overlaps an IPv6 pool", cidr: "fd00::/64", gateway: "fd00::1", start: "fd00::80", end: "fd00::1ff", wantErr: ErrPoolOverlap},
+		{name: "reversed start and end", cidr: "10.0.0.0/24", gateway: "10.0.0.1", start: "10.0.0.200", end: "10.0.0.150", wantErr: ErrInvalidPoolRange},
+		{name: "gateway outside the subnet", cidr: "10.0.0.0/24", gateway: "10.0.1.1", start: "10.0.0.150", end: "10.0.0.200", wantErr: ErrGatewayOutsideCIDR},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poolRepo := new(MockIPPoolRepository)
+			poolRepo.On("List", ctx, repository.IPPoolFilters{ZoneID: "zone-1"}, 0, -1).Return(existing, int64(len(existing)), nil)
+			poolRepo.On("Create", ctx, mock.AnythingOfType("*model.IPPool")).Return(nil)
+			svc := NewIPAMService(poolRepo, nil, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+
+			_, err := svc.CreatePool(ctx, &CreateIPPoolInput{
+				Name:    "pool",
+				CIDR:    tt.cidr,
+				Gateway: tt.gateway,
+				StartIP: tt.start,
+				EndIP:   tt.end,
+				ZoneID:  "zone-1",
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				poolRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("the overlap names the existing pool", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		poolRepo.On("List", ctx, repository.IPPoolFilters{ZoneID: "zone-1"}, 0, -1).Return(existing, int64(len(existing)), nil)
+		svc := NewIPAMService(poolRepo, nil, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+
+		_, err := svc.CreatePool(ctx, &CreateIPPoolInput{
+			Name: "pool", CIDR: "10.0.0.0/24", Gateway: "10.0.0.1", StartIP: "10.0.0.50", EndIP: "10.0.0.60", ZoneID: "zone-1",
+		})
+		require.ErrorIs(t, err, ErrPoolOverlap)
+		assert.Contains(t, err.Error(), `pool "servers" (10.0.0.10-10.0.0.100)`)
+	})
+}
+
+func TestIPAMService_AllocationHostnames(t *testing.T) {
+	ctx := context.Background()
+	pool := &model.IPPool{
+		BaseModel: model.BaseModel{ID: "pool-1"},
+		Zone:      &model.Zone{Code: "SH_Zone-A"},
+	}
+
+	t.Run("invalid hostnames are rejected", func(t *testing.T) {
+		allocRepo := new(MockIPAllocationRepository)
+		svc := NewIPAMService(new(MockIPPoolRepository), allocRepo, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+
+		for _, hostname := range []string{
+			"web_1",
+			"-web",
+			"web-",
+			"web..lab",
+			"web 1",
+			strings.Repeat("a", 64),
+			strings.Repeat("a.", 127) + "a",
+		} {
+			_, err := svc.AllocateIP(ctx, &AllocateIPInput{PoolID: "pool-1", Hostname: hostname})
+			assert.ErrorIs(t, err, ErrInvalidHostname, hostname)
+		}
+		allocRepo.AssertNotCalled(t, "AllocateNextAvailable", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("valid hostnames are lowercased", func(t *testing.T) {
+		allocRepo := new(MockIPAllocationRepository)
+		svc := NewIPAMService(new(MockIPPoolRepository), allocRepo, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+		allocRepo.On("AllocateNextAvailable", ctx, "pool-1", "web-01.lab.example", "").
+			Return(&model.IPAllocation{Hostname: "web-01.lab.example"}, nil)
+
+		_, err := svc.AllocateIP(ctx, &AllocateIPInput{PoolID: "pool-1", Hostname: "Web-01.Lab.Example"})
+		require.NoError(t, err)
+		allocRepo.AssertExpectations(t)
+	})
+
+	t.Run("generated hostname follows prefix, zone and index", func(t *testing.T) {
+		poolRepo := new(MockIPPoolRepository)
+		allocRepo := new(MockIPAllocationRepository)
+		svc := NewIPAMService(poolRepo, allocRepo, config.HostnameConfig{Generate: true, Prefix: "vm", IndexDigits: 3}, 0, nil, nil, zap.NewNop())
+		poolRepo.On("GetByID", ctx, "pool-1").Return(pool, nil)
+		allocRepo.On("ListHostnames", ctx, "pool-1", "vm-sh-zone-a-").
+			Return([]string{"vm-sh-zone-a-001", "vm-sh-zone-a-007", "vm-sh-zone-a-custom"}, nil)
+		allocRepo.On("AllocateNextAvailable", ctx, "pool-1", "vm-sh-zone-a-008", "").
+			Return(&model.IPAllocation{Hostname: "vm-sh-zone-a-008"}, nil)
+
+		allocation, err := svc.AllocateIP(ctx, &AllocateIPInput{PoolID: "pool-1"})
+		require.NoError(t, err)
+		assert.Equal(t, "vm-sh-zone-a-008", allocation.Hostname)
+		allocRepo.AssertExpectations(t)
+	})
+
+	t.Run("no hostname is generated unless enabled", func(t *testing.T) {
+		allocRepo := new(MockIPAllocationRepository)
+		svc := NewIPAMService(new(MockIPPoolRepository), allocRepo, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+		allocRepo.On("AllocateNextAvailable", ctx, "pool-1", "", "").Return(&model.IPAllocation{}, nil)
+
+		_, err := svc.AllocateIP(ctx, &AllocateIPInput{PoolID: "pool-1"})
+		require.NoError(t, err)
+		allocRepo.AssertNotCalled(t, "ListHostnames", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestIPAMService_UtilizationAlert(t *testing.T) {
+	ctx := context.Background()
+	allocRepo := new(MockIPAllocationRepository)
+	bus := events.NewBus(zap.NewNop())
+	var alerts []events.Event
+	bus.Subscribe(events.IPPoolUtilizationHigh, func(_ context.Context, event events.Event) {
+		alerts = append(alerts, event)
+	})
+	svc := NewIPAMService(new(MockIPPoolRepository), allocRepo, config.HostnameConfig{}, 80, bus, nil, zap.NewNop())
+
+	allocRepo.On("AllocateNextAvailable", ctx, "pool-1", "", "").Return(&model.IPAllocation{IPPoolID: "pool-1"}, nil)
+	allocRepo.On("GetByID", ctx, "alloc-1").Return(&model.IPAllocation{IPPoolID: "pool-1"}, nil)
+	allocRepo.On("Release", ctx, "alloc-1").Return(nil)
+	usage := func(used int64) {
+		allocRepo.On("GetUtilization", ctx, "pool-1").
+			Return(&repository.PoolUtilization{PoolID: "pool-1", PoolName: "lab", Total: 10, Allocated: used}, nil).Once()
+	}
+	allocate := func() {
+		t.Helper()
+		_, err := svc.AllocateIP(ctx, &AllocateIPInput{PoolID: "pool-1"})
+		require.NoError(t, err)
+	}
+
+	usage(7)
+	allocate()
+	assert.Empty(t, alerts, "below the threshold")
+
+	usage(8)
+	allocate()
+	require.Len(t, alerts, 1, "reaching the threshold alerts")
+	assert.Equal(t, "pool-1", alerts[0].Data["pool_id"])
+	assert.InDelta(t, 80.0, alerts[0].Data["percent"], 0.001)
+	assert.Equal(t, 80, alerts[0].Data["threshold_percent"])
+
+	usage(9)
+	allocate()
+	assert.Len(t, alerts, 1, "staying above the threshold does not alert again")
+
+	usage(7)
+	require.NoError(t, svc.ReleaseIP(ctx, "alloc-1"))
+	usage(7)
+	allocate()
+	assert.Len(t, alerts, 1, "dropping below the threshold does not alert")
+
+	usage(8)
+	allocate()
+	assert.Len(t, alerts, 2, "crossing the threshold again alerts again")
+	allocRepo.AssertExpectations(t)
+}
+
+func TestIPAMService_UtilizationAlertDisabled(t *testing.T) {
+	ctx := context.Background()
+	allocRepo := new(MockIPAllocationRepository)
+	svc := NewIPAMService(new(MockIPPoolRepository), allocRepo, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+	allocRepo.On("AllocateNextAvailable", ctx, "pool-1", "", "").Return(&model.IPAllocation{}, nil)
+	allocRepo.On("Release", ctx, "alloc-1").Return(nil)
+
+	_, err := svc.AllocateIP(ctx, &AllocateIPInput{PoolID: "pool-1"})
+	require.NoError(t, err)
+	require.NoError(t, svc.ReleaseIP(ctx, "alloc-1"))
+	allocRepo.AssertNotCalled(t, "GetUtilization", mock.Anything, mock.Anything)
+	allocRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestIPAMService_AllocateRequestedIP(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("requested address is allocated as is", func(t *testing.T) {
+		allocRepo := new(MockIPAllocationRepository)
+		svc := NewIPAMService(new(MockIPPoolRepository), allocRepo, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+		allocRepo.On("AllocateSpecific", ctx, "pool-1", "10.0.0.42", "web-01", "res-1").
+			Return(&model.IPAllocation{IPPoolID: "pool-1", IPAddress: "10.0.0.42"}, nil)
+
+		allocation, err := svc.AllocateIP(ctx, &AllocateIPInput{PoolID: "pool-1", IPAddress: "10.0.0.42", Hostname: "web-01", ResourceID: "res-1"})
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.42", allocation.IPAddress)
+		allocRepo.AssertNotCalled(t, "AllocateNextAvailable", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("taken address is reported", func(t *testing.T) {
+		allocRepo := new(MockIPAllocationRepository)
+		svc := NewIPAMService(new(MockIPPoolRepository), allocRepo, config.HostnameConfig{}, 0, nil, nil, zap.NewNop())
+		allocRepo.On("AllocateSpecific", ctx, "pool-1", "10.0.0.42", "", "").Return(nil, repository.ErrIPInUse)
+
+		_, err := svc.AllocateIP(ctx, &AllocateIPInput{PoolID: "pool-1", IPAddress: "10.0.0.42"})
+		assert.ErrorIs(t, err, repository.ErrIPInUse)
+	})
+}
+
+func TestIsIPInRange(t *testing.T) {
+	tests := []struct {
+		ip, start, end string
+		want           bool
+	}{
+		{"10.0.1.5", "10.0.0.10", "10.0.255.250", true},
+		{"10.0.0.9", "10.0.0.10", "10.0.255.250", false},
+		{"2001:db8::1:0", "2001:db8::fe", "2001:db8::ffff:ffff", true},
+		{"2001:db8::fd", "2001:db8::fe", "2001:db8::ffff:ffff", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isIPInRange(net.ParseIP(tt.ip), net.ParseIP(tt.start), net.ParseIP(tt.end)), tt.ip)
+	}
+}
@@ -0,0 +1,144 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/ldap"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+// ErrLDAPUnavailable is returned when the LDAP server cannot be reached or refuses the
+// service account, as opposed to rejecting the user's credentials.
+var ErrLDAPUnavailable = errors.New("LDAP server is unavailable")
+
+// ldapConn is the part of an LDAP connection that login uses.
+type ldapConn interface {
+	Bind(dn, password string) error
+	Search(req ldap.SearchRequest) ([]*ldap.Entry, error)
+	Close() error
+}
+
+// ldapDialer opens a connection to the configured LDAP server.
+type ldapDialer func(ctx context.Context) (ldapConn, error)
+
+// newLDAPDialer returns a dialer for cfg's server.
+func newLDAPDialer(cfg config.LDAPConfig) ldapDialer {
+	return func(ctx context.Context) (ldapConn, error) {
+		return ldap.Dial(ctx, cfg.URL, constants.LDAPTimeout)
+	}
+}
+
+// ldapLogin authenticates username against the directory and returns the matching platform
+// user, provisioning or linking one on first login.
+func (s *authService) ldapLogin(ctx context.Context, username, password string) (*model.User, error) {
+	cfg := s.cfg.LDAP
+	conn, err := s.ldapDial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLDAPUnavailable, err)
+	}
+	defer conn.Close() //nolint:errcheck // nothing to recover on close
+
+	if cfg.BindDN != "" {
+		if err := conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("%w: service account bind failed: %w", ErrLDAPUnavailable, err)
+		}
+	}
+
+	entries, err := conn.Search(ldap.SearchRequest{
+		BaseDN:     cfg.BaseDN,
+		Filter:     strings.ReplaceAll(cfg.UserFilter, "{username}", ldap.EscapeFilter(username)),
+		Attributes: []string{cfg.EmailAttribute, cfg.DisplayNameAttribute, cfg.GroupAttribute},
+		SizeLimit:  2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: user search failed: %w", ErrLDAPUnavailable, err)
+	}
+	// An unknown name and an ambiguous one are both refused without trying the password.
+	if len(entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	entry := entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if errors.Is(err, ldap.ErrInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: %w", ErrLDAPUnavailable, err)
+	}
+
+	// The directory is managed by administrators, so its email addresses are trusted to link
+	// other directory and SSO users. A local account is never linked: whoever can edit a
+	// directory entry's mail attribute would otherwise take it over, roles included.
+	user, err := s.resolveExternalUser(ctx, externalIdentity{
+		Source:        model.UserSourceLDAP,
+		ExternalID:    entry.DN,
+		Username:      username,
+		Email:         entry.Value(cfg.EmailAttribute),
+		EmailVerified: true,
+		DisplayName:   entry.Value(cfg.DisplayNameAttribute),
+	}, cfg.DefaultRole)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.GroupRoles) > 0 {
+		if err := s.syncLDAPRoles(ctx, user, entry.Values(cfg.GroupAttribute)); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// syncLDAPRoles replaces the user's roles with those mapped from groups, or the default
+// role when no group is mapped.
+func (s *authService) syncLDAPRoles(ctx context.Context, user *model.User, groups []string) error {
+	codes := ldapRoleCodes(s.cfg.LDAP, groups)
+
+	current := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		current = append(current, role.Code)
+	}
+	slices.Sort(current)
+	if slices.Equal(current, codes) {
+		return nil
+	}
+
+	roles := make([]model.Role, 0, len(codes))
+	for _, code := range codes {
+		role, err := s.roleRepo.GetByCode(ctx, code)
+		if err != nil {
+			return fmt.Errorf("failed to load role %q: %w", code, err)
+		}
+		roles = append(roles, *role)
+	}
+	if err := s.userRepo.ReplaceRoles(ctx, user, roles); err != nil {
+		return fmt.Errorf("failed to update roles: %w", err)
+	}
+	user.Roles = roles
+	return nil
+}
+
+// ldapRoleCodes returns the sorted role codes mapped from groups. Group DNs are compared
+// case-insensitively.
+func ldapRoleCodes(cfg config.LDAPConfig, groups []string) []string {
+	var codes []string
+	for groupDN, code := range cfg.GroupRoles {
+		for _, group := range groups {
+			if strings.EqualFold(strings.TrimSpace(group), strings.TrimSpace(groupDN)) && !slices.Contains(codes, code) {
+				codes = append(codes, code)
+			}
+		}
+	}
+	if len(codes) == 0 {
+		codes = []string{cfg.DefaultRole}
+	}
+	slices.Sort(codes)
+	return codes
+}
@@ -0,0 +1,265 @@
+// Package service provides LDAP login tests.
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/ldap"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testLDAPUserDN = "uid=jdoe,ou=people,dc=example,dc=com"
+
+// fakeLDAPConn is a directory holding the service account and one user entry.
+type fakeLDAPConn struct {
+	entries  []*ldap.Entry
+	password string // the user's password
+	binds    []string
+	filters  []string
+	closed   bool
+}
+
+func (c *fakeLDAPConn) Bind(dn, password string) error {
+	c.binds = append(c.binds, dn)
+	switch {
+	case dn == "cn=svc,dc=example,dc=com" && password == "svc-pass":
+		return nil
+	case dn == testLDAPUserDN && password == c.password:
+		return nil
+	}
+	return ldap.ErrInvalidCredentials
+}
+
+func (c *fakeLDAPConn) Search(req ldap.SearchRequest) ([]*ldap.Entry, error) {
+	c.filters = append(c.filters, req.Filter)
+	return c.entries, nil
+}
+
+func (c *fakeLDAPConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func newFakeLDAPConn(groups ...string) *fakeLDAPConn {
+	return &fakeLDAPConn{
+		password: "directory-pass",
+		entries: []*ldap.Entry{{
+			DN: testLDAPUserDN,
+			Attributes: map[string][]string{
+				"mail":        {"jdoe@example.com"},
+				"displayName": {"Jane Doe"},
+				"memberOf":    groups,
+			},
+		}},
+	}
+}
+
+func newLDAPTestService(conn *fakeLDAPConn, userRepo *MockUserRepository, roleRepo *MockRoleRepository, groupRoles map[string]string) *authService {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{Secret: "test-secret-key-that-is-long-enough-32chars", AccessTokenTTL: 15, RefreshTokenTTL: 1, Issuer: "test"},
+		LDAP: config.LDAPConfig{
+			Enabled:              true,
+			BindDN:               "cn=svc,dc=example,dc=com",
+			BindPassword:         "svc-pass",
+			BaseDN:               "ou=people,dc=example,dc=com",
+			UserFilter:           "(&(objectClass=person)(uid={username}))",
+			EmailAttribute:       "mail",
+			DisplayNameAttribute: "displayName",
+			GroupAttribute:       "memberOf",
+			GroupRoles:           groupRoles,
+			DefaultRole:          "user",
+		},
+	}
+	return &authService{
+		userRepo:  userRepo,
+		roleRepo:  roleRepo,
+		cfg:       cfg,
+		blacklist: &tokenBlacklist{tokens: make(map[string]time.Time)},
+		ldapDial: func(context.Context) (ldapConn, error) {
+			if conn == nil {
+				return nil, errors.New("connection refused")
+			}
+			return conn, nil
+		},
+	}
+}
+
+func TestAuthService_LDAPLogin(t *testing.T) {
+	ctx := context.Background()
+	userRole := &model.Role{BaseModel: model.BaseModel{ID: "role-user"}, Code: "user"}
+	adminRole := &model.Role{BaseModel: model.BaseModel{ID: "role-admin"}, Code: "admin"}
+
+	t.Run("successful bind provisions the user", func(t *testing.T) {
+		conn := newFakeLDAPConn()
+		userRepo, roleRepo := new(MockUserRepository), new(MockRoleRepository)
+		svc := newLDAPTestService(conn, userRepo, roleRepo, nil)
+
+		userRepo.On("GetByUsername", ctx, "jdoe").Return(nil, repository.ErrNotFound)
+		userRepo.On("GetByExternalID", ctx, model.UserSourceLDAP, testLDAPUserDN).Return(nil, repository.ErrNotFound)
+		userRepo.On("GetByEmail", ctx, "jdoe@example.com").Return(nil, repository.ErrNotFound)
+		roleRepo.On("GetByCode", ctx, "user").Return(userRole, nil)
+		var created *model.User
+		userRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Run(func(args mock.Arguments) {
+			created, _ = args.Get(1).(*model.User) //nolint:errcheck // type checked by the matcher
+			created.ID = "user-new"
+		}).Return(nil)
+		userRepo.On("UpdateLastLogin", ctx, "user-new", "10.0.0.1").Return(nil)
+
+		tokens, err := svc.Login(ctx, "jdoe", "directory-pass", "10.0.0.1")
+		require.NoError(t, err)
+
+		require.NotNil(t, created)
+		assert.Equal(t, "jdoe", created.Username)
+		assert.Equal(t, "Jane Doe", created.DisplayName)
+		assert.Equal(t, model.UserSourceLDAP, created.Source)
+		assert.Equal(t, testLDAPUserDN, created.ExternalID)
+		assert.Equal(t, []string{"cn=svc,dc=example,dc=com", testLDAPUserDN}, conn.binds)
+		assert.Equal(t, []string{"(&(objectClass=person)(uid=jdoe))"}, conn.filters)
+		assert.True(t, conn.closed)
+
+		claims, err := svc.ValidateToken(ctx, tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user"}, claims.Roles)
+	})
+
+	t.Run("failed bind is rejected", func(t *testing.T) {
+		conn := newFakeLDAPConn()
+		userRepo := new(MockUserRepository)
+		svc := newLDAPTestService(conn, userRepo, new(MockRoleRepository), nil)
+
+		userRepo.On("GetByUsername", ctx, "jdoe").Return(nil, repository.ErrNotFound)
+
+		_, err := svc.Login(ctx, "jdoe", "wrong", "")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+		userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown directory user is rejected", func(t *testing.T) {
+		conn := newFakeLDAPConn()
+		conn.entries = nil
+		userRepo := new(MockUserRepository)
+		svc := newLDAPTestService(conn, userRepo, new(MockRoleRepository), nil)
+
+		userRepo.On("GetByUsername", ctx, "nobody*").Return(nil, repository.ErrNotFound)
+
+		_, err := svc.Login(ctx, "nobody*", "directory-pass", "")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.Equal(t, []string{"(&(objectClass=person)(uid=nobody\\2a))"}, conn.filters, "the username is escaped")
+	})
+
+	t.Run("unreachable server", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		svc := newLDAPTestService(nil, userRepo, new(MockRoleRepository), nil)
+
+		userRepo.On("GetByUsername", ctx, "jdoe").Return(nil, repository.ErrNotFound)
+
+		_, err := svc.Login(ctx, "jdoe", "directory-pass", "")
+		require.ErrorIs(t, err, ErrLDAPUnavailable)
+	})
+
+	t.Run("local users keep their local password", func(t *testing.T) {
+		conn := newFakeLDAPConn()
+		userRepo := new(MockUserRepository)
+		svc := newLDAPTestService(conn, userRepo, new(MockRoleRepository), nil)
+
+		hash, err := bcrypt.GenerateFromPassword([]byte("local-pass"), bcrypt.MinCost)
+		require.NoError(t, err)
+		admin := &model.User{BaseModel: model.BaseModel{ID: "admin-1"}, Username: "admin", PasswordHash: string(hash), Source: model.UserSourceLocal, Status: 1}
+		userRepo.On("GetByUsername", ctx, "admin").Return(admin, nil)
+		userRepo.On("UpdateLastLogin", ctx, "admin-1", "").Return(nil)
+
+		_, err = svc.Login(ctx, "admin", "local-pass", "")
+		require.NoError(t, err)
+		assert.Empty(t, conn.binds, "the directory is not consulted")
+	})
+
+	t.Run("local account with the directory email is not linked", func(t *testing.T) {
+		conn := newFakeLDAPConn("cn=lab-admins,ou=groups,dc=example,dc=com")
+		userRepo := new(MockUserRepository)
+		svc := newLDAPTestService(conn, userRepo, new(MockRoleRepository), map[string]string{
+			"cn=lab-admins,ou=groups,dc=example,dc=com": "admin",
+		})
+
+		admin := &model.User{BaseModel: model.BaseModel{ID: "admin-1"}, Username: "admin", Email: "jdoe@example.com", Source: model.UserSourceLocal, Status: 1}
+		userRepo.On("GetByUsername", ctx, "jdoe").Return(nil, repository.ErrNotFound)
+		userRepo.On("GetByExternalID", ctx, model.UserSourceLDAP, testLDAPUserDN).Return(nil, repository.ErrNotFound)
+		userRepo.On("GetByEmail", ctx, "jdoe@example.com").Return(admin, nil)
+
+		_, err := svc.Login(ctx, "jdoe", "directory-pass", "")
+		require.ErrorIs(t, err, ErrLocalAccountLinkRefused)
+		assert.Empty(t, admin.ExternalID)
+		userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		userRepo.AssertNotCalled(t, "ReplaceRoles", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("groups are mapped to roles", func(t *testing.T) {
+		conn := newFakeLDAPConn("CN=Lab-Admins,OU=Groups,DC=example,DC=com", "cn=unmapped,ou=groups,dc=example,dc=com")
+		userRepo, roleRepo := new(MockUserRepository), new(MockRoleRepository)
+		svc := newLDAPTestService(conn, userRepo, roleRepo, map[string]string{
+			"cn=lab-admins,ou=groups,dc=example,dc=com": "admin",
+		})
+
+		existing := &model.User{
+			BaseModel:  model.BaseModel{ID: "user-1"},
+			Username:   "jdoe",
+			Source:     model.UserSourceLDAP,
+			ExternalID: testLDAPUserDN,
+			Status:     1,
+			Roles:      []model.Role{*userRole},
+		}
+		userRepo.On("GetByUsername", ctx, "jdoe").Return(existing, nil)
+		userRepo.On("GetByExternalID", ctx, model.UserSourceLDAP, testLDAPUserDN).Return(existing, nil)
+		roleRepo.On("GetByCode", ctx, "admin").Return(adminRole, nil)
+		userRepo.On("ReplaceRoles", ctx, existing, []model.Role{*adminRole}).Return(nil)
+		userRepo.On("UpdateLastLogin", ctx, "user-1", "").Return(nil)
+
+		tokens, err := svc.Login(ctx, "jdoe", "directory-pass", "")
+		require.NoError(t, err)
+		claims, err := svc.ValidateToken(ctx, tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, claims.Roles)
+	})
+
+	t.Run("roles already matching the groups are left alone", func(t *testing.T) {
+		conn := newFakeLDAPConn("cn=lab-admins,ou=groups,dc=example,dc=com")
+		userRepo := new(MockUserRepository)
+		svc := newLDAPTestService(conn, userRepo, new(MockRoleRepository), map[string]string{
+			"cn=lab-admins,ou=groups,dc=example,dc=com": "admin",
+		})
+
+		existing := &model.User{BaseModel: model.BaseModel{ID: "user-1"}, Username: "jdoe", Source: model.UserSourceLDAP, Status: 1, Roles: []model.Role{*adminRole}}
+		userRepo.On("GetByUsername", ctx, "jdoe").Return(existing, nil)
+		userRepo.On("GetByExternalID", ctx, model.UserSourceLDAP, testLDAPUserDN).Return(existing, nil)
+		userRepo.On("UpdateLastLogin", ctx, "user-1", "").Return(nil)
+
+		_, err := svc.Login(ctx, "jdoe", "directory-pass", "")
+		require.NoError(t, err)
+		userRepo.AssertNotCalled(t, "ReplaceRoles", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestLDAPRoleCodes(t *testing.T) {
+	cfg := config.LDAPConfig{
+		DefaultRole: "user",
+		GroupRoles: map[string]string{
+			"cn=admins,dc=example,dc=com":    "admin",
+			"cn=ops,dc=example,dc=com":       "operator",
+			"cn=operators,dc=example,dc=com": "operator",
+		},
+	}
+
+	assert.Equal(t, []string{"user"}, ldapRoleCodes(cfg, nil))
+	assert.Equal(t, []string{"user"}, ldapRoleCodes(cfg, []string{"cn=other,dc=example,dc=com"}))
+	assert.Equal(t, []string{"admin", "operator"}, ldapRoleCodes(cfg, []string{
+		"cn=ops,dc=example,dc=com", "CN=Admins,DC=example,DC=com", "cn=operators,dc=example,dc=com",
+	}))
+}
@@ -0,0 +1,55 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+	"go.uber.org/zap"
+)
+
+// MaintenanceService holds the platform's read-only maintenance mode. While it is enabled,
+// the API refuses requests that could change state so reads stay available during
+// migrations and incidents.
+type MaintenanceService interface {
+	Status() MaintenanceStatus
+	SetEnabled(ctx context.Context, enabled bool) MaintenanceStatus
+}
+
+// MaintenanceStatus reports whether maintenance mode is enabled and the message given to
+// refused requests.
+type MaintenanceStatus struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+}
+
+type maintenanceService struct {
+	enabled atomic.Bool
+	message string
+	logger  *zap.Logger
+}
+
+// NewMaintenanceService creates a maintenance service starting in the configured state.
+// The mode is kept in memory, so a toggle applies to this instance until it restarts.
+func NewMaintenanceService(enabled bool, message string, logger *zap.Logger) MaintenanceService {
+	s := &maintenanceService{message: message, logger: logger}
+	s.enabled.Store(enabled)
+	return s
+}
+
+// Status returns the current maintenance mode.
+func (s *maintenanceService) Status() MaintenanceStatus {
+	return MaintenanceStatus{Enabled: s.enabled.Load(), Message: s.message}
+}
+
+// SetEnabled turns maintenance mode on or off and returns the new status.
+func (s *maintenanceService) SetEnabled(ctx context.Context, enabled bool) MaintenanceStatus {
+	if s.enabled.Swap(enabled) != enabled {
+		s.logger.Warn("maintenance mode changed",
+			zap.Bool("enabled", enabled),
+			zap.String("user_id", sanitize.ForLog(UserIDFromContext(ctx))),
+		)
+	}
+	return s.Status()
+}
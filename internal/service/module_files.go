@@ -0,0 +1,151 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+// moduleFile is a Terraform file of a module.
+type moduleFile struct {
+	name    string
+	content string
+}
+
+// readModuleFiles reads the *.tf files of the module at modulePath, in name order. A file
+// that cannot be read or is malformed is left out, so no declarations are misread from it,
+// and a warning naming it is returned instead.
+func readModuleFiles(modulePath string) ([]moduleFile, []model.ModuleParseWarning) {
+	entries, err := os.ReadDir(modulePath)
+	if err != nil {
+		return nil, []model.ModuleParseWarning{{Message: fmt.Sprintf("failed to list module files: %v", err)}}
+	}
+
+	var files []moduleFile
+	var warnings []model.ModuleParseWarning
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tf") {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(modulePath, entry.Name())) // #nosec G304 --  path is constructed from controlled input
+		if err != nil {
+			warnings = append(warnings, model.ModuleParseWarning{File: entry.Name(), Message: fmt.Sprintf("failed to read file: %v", errors.Unwrap(err))})
+			continue
+		}
+		if err := checkTerraformSyntax(string(content)); err != nil {
+			warnings = append(warnings, model.ModuleParseWarning{File: entry.Name(), Message: err.Error()})
+			continue
+		}
+		files = append(files, moduleFile{name: entry.Name(), content: string(content)})
+	}
+	return files, warnings
+}
+
+// checkTerraformSyntax reports the first structural error in Terraform source: invalid
+// UTF-8, a string, heredoc or comment that is never closed, or unbalanced brackets. It
+// does not check the language beyond that, but catches the files the declaration scan
+// would misread.
+func checkTerraformSyntax(content string) error {
+	if !utf8.ValidString(content) {
+		return errors.New("file is not valid UTF-8")
+	}
+	c := &syntaxChecker{hclParser: hclParser{src: content}}
+	return c.scan(0, 0)
+}
+
+// syntaxChecker walks Terraform source, matching brackets and the ends of strings,
+// heredocs and comments.
+type syntaxChecker struct {
+	hclParser
+}
+
+// closingBrackets maps each opening bracket to the one that closes it.
+var closingBrackets = map[byte]byte{'{': '}', '[': ']', '(': ')'}
+
+// scan reads up to and past closer, the bracket closing the one opened on line openLine, or
+// to the end of the source when closer is zero.
+func (c *syntaxChecker) scan(closer byte, openLine int) error {
+	for !c.eof() {
+		start := c.pos
+		switch ch := c.peek(); {
+		case ch == '#' || c.hasPrefix("//"):
+			for !c.eof() && c.peek() != '\n' {
+				c.pos++
+			}
+		case c.hasPrefix("/*"):
+			end := strings.Index(c.src[c.pos+2:], "*/")
+			if end < 0 {
+				return c.errorf(start, "comment is never closed")
+			}
+			c.pos += end + len("/**/")
+		case ch == '"':
+			if err := c.template(); err != nil {
+				return err
+			}
+		case c.hasPrefix("<<"):
+			if _, ok := c.heredoc(); !ok {
+				return c.errorf(start, "heredoc is never closed")
+			}
+		case closingBrackets[ch] != 0:
+			c.pos++
+			if err := c.scan(closingBrackets[ch], c.line(start)); err != nil {
+				return err
+			}
+		case ch == '}' || ch == ']' || ch == ')':
+			if ch != closer {
+				return c.errorf(start, "unexpected %q", ch)
+			}
+			c.pos++
+			return nil
+		default:
+			c.pos++
+		}
+	}
+	if closer != 0 {
+		return fmt.Errorf("line %d: bracket is never closed, %q expected", openLine, closer)
+	}
+	return nil
+}
+
+// template reads a quoted string, including the expressions of its interpolations and
+// directives, which may hold strings of their own.
+func (c *syntaxChecker) template() error {
+	start := c.pos
+	c.pos++ // opening quote
+	for !c.eof() && c.peek() != '\n' {
+		switch {
+		case c.peek() == '"':
+			c.pos++
+			return nil
+		case c.peek() == '\\':
+			c.pos = min(c.pos+2, len(c.src))
+		case c.hasPrefix("$${") || c.hasPrefix("%%{"):
+			c.pos += 3
+		case c.hasPrefix("${") || c.hasPrefix("%{"):
+			open := c.pos
+			c.pos += 2
+			if err := c.scan('}', c.line(open)); err != nil {
+				return err
+			}
+		default:
+			c.pos++
+		}
+	}
+	return c.errorf(start, "string is never closed")
+}
+
+// line returns the line number of the byte at pos.
+func (c *syntaxChecker) line(pos int) int {
+	return strings.Count(c.src[:pos], "\n") + 1
+}
+
+func (c *syntaxChecker) errorf(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", c.line(pos), fmt.Sprintf(format, args...))
+}
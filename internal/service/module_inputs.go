@@ -0,0 +1,149 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
+)
+
+// ErrInvalidModuleInputs is returned when a request spec does not fit the module's variables.
+var ErrInvalidModuleInputs = errors.New("spec does not match module variables")
+
+// ErrInvalidVarOverride is returned when a requested variable override is not allowed.
+var ErrInvalidVarOverride = errors.New("invalid variable override")
+
+// ModuleVariable describes a variable declared by a Terraform module.
+type ModuleVariable struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"` // Type constraint as written, such as map(string)
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	Sensitive   bool   `json:"sensitive,omitempty"`
+	// Default is the variable's default value when it is written as a literal. Numbers are
+	// float64, lists []interface{} and maps and objects map[string]interface{}, as in specs.
+	Default interface{} `json:"default,omitempty"`
+}
+
+// ModuleInputError lists the spec keys the module does not declare and the
+// required module variables the spec does not set.
+type ModuleInputError struct {
+	Unknown []string
+	Missing []string
+}
+
+// Error implements the error interface.
+func (e *ModuleInputError) Error() string {
+	var parts []string
+	if len(e.Unknown) > 0 {
+		parts = append(parts, "unknown inputs: "+strings.Join(e.Unknown, ", "))
+	}
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing required variables: "+strings.Join(e.Missing, ", "))
+	}
+	return fmt.Sprintf("%s (%s)", ErrInvalidModuleInputs, strings.Join(parts, "; "))
+}
+
+// Unwrap allows errors.Is to match ErrInvalidModuleInputs.
+func (e *ModuleInputError) Unwrap() error {
+	return ErrInvalidModuleInputs
+}
+
+// parseModuleVariables decodes the variables stored on a Terraform module. Both
+// a list of variable objects and a plain list of names are accepted; plain
+// names carry no required flag. It returns false when the variables are unknown.
+func parseModuleVariables(raw string) ([]ModuleVariable, bool) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, false
+	}
+
+	var variables []ModuleVariable
+	if err := json.Unmarshal([]byte(raw), &variables); err == nil {
+		return variables, len(variables) > 0
+	}
+
+	var names []string
+	if err := json.Unmarshal([]byte(raw), &names); err != nil {
+		return nil, false
+	}
+	for _, name := range names {
+		variables = append(variables, ModuleVariable{Name: name})
+	}
+	return variables, len(variables) > 0
+}
+
+// validateModuleInputs checks spec inputs against the module's declared variables.
+func validateModuleInputs(inputs map[string]interface{}, variables []ModuleVariable) error {
+	declared := make(map[string]bool, len(variables))
+	for _, v := range variables {
+		declared[v.Name] = true
+	}
+
+	inputErr := &ModuleInputError{}
+	for key := range inputs {
+		if !declared[key] {
+			inputErr.Unknown = append(inputErr.Unknown, key)
+		}
+	}
+	// A required variable set to null is as unset as one left out of the spec.
+	for _, v := range variables {
+		if value, ok := inputs[v.Name]; v.Required && (!ok || value == nil) {
+			inputErr.Missing = append(inputErr.Missing, v.Name)
+		}
+	}
+
+	if len(inputErr.Unknown) == 0 && len(inputErr.Missing) == 0 {
+		return nil
+	}
+	sort.Strings(inputErr.Unknown)
+	sort.Strings(inputErr.Missing)
+	return inputErr
+}
+
+// omitDefaultedInputs removes the inputs of optional variables that are null or equal to the
+// module's default, so the module's own default applies and is not pinned in the generated
+// configuration. Inputs of required variables are always kept.
+func omitDefaultedInputs(inputs map[string]interface{}, variables []ModuleVariable) {
+	for _, v := range variables {
+		value, ok := inputs[v.Name]
+		if !ok || v.Required {
+			continue
+		}
+		if value == nil || (v.Default != nil && reflect.DeepEqual(value, v.Default)) {
+			delete(inputs, v.Name)
+		}
+	}
+}
+
+// validateVarOverrides checks the names of requested variable overrides. Credential variables
+// are rejected outright: they are filled from the request's credential and letting a requester
+// set them would point provisioning at an arbitrary endpoint or account.
+func validateVarOverrides(overrides map[string]string) error {
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !terraform.IsValidVariableName(name) {
+			return fmt.Errorf("%w: %q is not a valid variable name", ErrInvalidVarOverride, name)
+		}
+		if terraform.IsCredentialVariable(name) {
+			return fmt.Errorf("%w: %q is a credential variable", ErrInvalidVarOverride, name)
+		}
+	}
+	return nil
+}
+
+// mergeVarOverrides applies overrides on top of the spec inputs.
+func mergeVarOverrides(inputs map[string]interface{}, overrides map[string]string) {
+	for name, value := range overrides {
+		inputs[name] = value
+	}
+}
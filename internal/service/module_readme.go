@@ -0,0 +1,83 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterDelimiter opens and closes a README's YAML front-matter block.
+const frontMatterDelimiter = "---"
+
+// moduleReadme holds the metadata read from a module's README.md.
+type moduleReadme struct {
+	DisplayName string   `yaml:"display_name"`
+	Description string   `yaml:"description"`
+	Category    string   `yaml:"category"`
+	Icon        string   `yaml:"icon"`
+	Tags        []string `yaml:"tags"`
+}
+
+// parseModuleReadme reads the optional YAML front-matter of a README. The
+// description falls back to the first paragraph line of the body when the
+// front-matter does not set one.
+func parseModuleReadme(content string) moduleReadme {
+	var meta moduleReadme
+
+	body := content
+	if frontMatter, rest, ok := splitFrontMatter(content); ok {
+		if err := yaml.Unmarshal([]byte(frontMatter), &meta); err != nil {
+			// Malformed front-matter is treated as absent
+			meta = moduleReadme{}
+		}
+		body = rest
+	}
+
+	tags := meta.Tags[:0]
+	for _, tag := range meta.Tags {
+		// Tags are stored comma-separated, so commas cannot appear inside one
+		if tag = strings.TrimSpace(strings.ReplaceAll(tag, ",", " ")); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	meta.Tags = tags
+
+	meta.Description = strings.TrimSpace(meta.Description)
+	if meta.Description == "" {
+		meta.Description = firstParagraphLine(body)
+	}
+	if len(meta.Description) > maxDescriptionLength {
+		meta.Description = meta.Description[:maxDescriptionLength] + "..."
+	}
+	return meta
+}
+
+// splitFrontMatter separates a leading "---" delimited block from the rest of the document.
+func splitFrontMatter(content string) (string, string, bool) {
+	content = strings.TrimPrefix(content, "\uFEFF")
+	lines := strings.Split(content, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != frontMatterDelimiter {
+		return "", content, false
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == frontMatterDelimiter {
+			return strings.Join(lines[1:i], "\n"), strings.Join(lines[i+1:], "\n"), true
+		}
+	}
+	return "", content, false
+}
+
+// firstParagraphLine returns the first non-empty line that is not a header.
+func firstParagraphLine(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		// Skip headers
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return line
+	}
+	return ""
+}
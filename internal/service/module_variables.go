@@ -0,0 +1,496 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// parseVariableBlocks extracts variable declarations from Terraform source. A variable
+// without a default value is required. Defaults written as literals, including lists, maps
+// and objects spread over several lines, are decoded; a default computed by an expression
+// makes the variable optional with an unknown default.
+func parseVariableBlocks(content string) []ModuleVariable {
+	p := &hclParser{src: content}
+	var variables []ModuleVariable
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return variables
+		}
+		ident := p.ident()
+		if ident == "" {
+			p.skipToken()
+			continue
+		}
+
+		var labels []string
+		for {
+			p.skipSpace(false)
+			if p.peek() != '"' {
+				break
+			}
+			label, ok := p.quotedString()
+			if !ok {
+				break
+			}
+			labels = append(labels, label.(string)) //nolint:errcheck,forcetypeassert // quotedString returns a string when ok
+		}
+		p.skipSpace(false)
+		if p.peek() != '{' {
+			continue
+		}
+		p.pos++
+		if ident == "variable" && len(labels) == 1 {
+			variables = append(variables, p.variableBody(labels[0]))
+		} else {
+			p.skipBlock()
+		}
+	}
+}
+
+// hclParser reads the subset of HCL that variable declarations use. It never fails: input it
+// cannot make sense of is skipped.
+type hclParser struct {
+	src string
+	pos int
+}
+
+func (p *hclParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *hclParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *hclParser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(p.src[p.pos:], prefix)
+}
+
+// skipSpace skips blanks and comments, and newlines too when newlines is set.
+func (p *hclParser) skipSpace(newlines bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\n' && newlines:
+			p.pos++
+		case c == '#' || p.hasPrefix("//"):
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		case p.hasPrefix("/*"):
+			end := strings.Index(p.src[p.pos+2:], "*/")
+			if end < 0 {
+				p.pos = len(p.src)
+			} else {
+				p.pos += end + len("/**/")
+			}
+		default:
+			return
+		}
+	}
+}
+
+// ident reads an identifier, or returns empty when there is none at the current position.
+func (p *hclParser) ident() string {
+	start := p.pos
+	for !p.eof() {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !unicode.IsLetter(r) && r != '_' && (p.pos == start || (!unicode.IsDigit(r) && r != '-')) {
+			break
+		}
+		p.pos += size
+	}
+	return p.src[start:p.pos]
+}
+
+// skipToken skips one token: a string, heredoc or comment whole, anything else one byte.
+func (p *hclParser) skipToken() {
+	switch {
+	case p.peek() == '"':
+		p.quotedString()
+	case p.hasPrefix("<<"):
+		if _, ok := p.heredoc(); !ok {
+			p.pos += 2
+		}
+	case p.peek() == '#' || p.hasPrefix("//") || p.hasPrefix("/*"):
+		p.skipSpace(false)
+	default:
+		p.pos++
+	}
+}
+
+// skipBlock skips to just past the brace closing the block whose opening brace was read.
+func (p *hclParser) skipBlock() {
+	depth := 1
+	for !p.eof() && depth > 0 {
+		switch p.peek() {
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			depth--
+		}
+		p.skipToken()
+	}
+}
+
+// skipExpr skips the expression of an attribute, stopping at the newline that ends it or
+// before the brace that closes the enclosing block.
+func (p *hclParser) skipExpr() {
+	depth := 0
+	for !p.eof() {
+		switch p.peek() {
+		case '\n':
+			if depth == 0 {
+				return
+			}
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			if depth == 0 {
+				return
+			}
+			depth--
+		}
+		p.skipToken()
+	}
+}
+
+// atExprEnd reports whether only a comment is left of the current attribute. A heredoc
+// ends its attribute with the line holding its closing marker.
+func (p *hclParser) atExprEnd() bool {
+	if p.pos > 0 && p.src[p.pos-1] == '\n' {
+		return true
+	}
+	p.skipSpace(false)
+	return p.eof() || p.peek() == '\n' || p.peek() == '}'
+}
+
+// variableBody reads the body of a variable block after its opening brace.
+func (p *hclParser) variableBody(name string) ModuleVariable {
+	variable := ModuleVariable{Name: name, Required: true}
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return variable
+		}
+		if p.peek() == '}' {
+			p.pos++
+			return variable
+		}
+		attr := p.ident()
+		if attr == "" {
+			p.skipToken()
+			continue
+		}
+		p.skipSpace(false)
+		if p.peek() != '=' {
+			p.skipNestedBlock()
+			continue
+		}
+		p.pos++
+		p.skipSpace(false)
+		p.variableAttribute(&variable, attr)
+	}
+}
+
+// skipNestedBlock skips a block inside a variable, such as validation, whose type was read.
+func (p *hclParser) skipNestedBlock() {
+	for p.peek() == '"' {
+		p.quotedString()
+		p.skipSpace(false)
+	}
+	if p.peek() == '{' {
+		p.pos++
+		p.skipBlock()
+		return
+	}
+	p.skipExpr()
+}
+
+// variableAttribute reads the value of attr into variable.
+func (p *hclParser) variableAttribute(variable *ModuleVariable, attr string) {
+	start := p.pos
+	switch attr {
+	case "type":
+		p.skipExpr()
+		variable.Type = compactType(p.src[start:p.pos])
+		return
+	case "default":
+		variable.Required = false
+		if value, ok := p.value(); ok && p.atExprEnd() {
+			variable.Default = value
+			return
+		}
+	case "description":
+		if value, ok := p.value(); ok && p.atExprEnd() {
+			variable.Description, _ = value.(string) //nolint:errcheck // a non-string description is ignored
+			return
+		}
+	case "sensitive":
+		if value, ok := p.value(); ok && p.atExprEnd() {
+			variable.Sensitive, _ = value.(bool) //nolint:errcheck // a non-bool flag is ignored
+			return
+		}
+	}
+	p.pos = start
+	p.skipExpr()
+}
+
+// compactType puts a type constraint written over several lines on one, separating the
+// attributes that had a line each with commas.
+func compactType(raw string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			last := b.String()[b.Len()-1]
+			if strings.IndexByte("{([,", last) < 0 && strings.IndexByte("})]", line[0]) < 0 {
+				b.WriteByte(',')
+			}
+			b.WriteByte(' ')
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// value reads a literal: a string, heredoc, number, bool, null, list or object. It reports
+// false for anything else, such as a reference, function call or interpolated string.
+func (p *hclParser) value() (interface{}, bool) {
+	switch c := p.peek(); {
+	case c == '"':
+		return p.quotedString()
+	case p.hasPrefix("<<"):
+		return p.heredoc()
+	case c == '[':
+		return p.list()
+	case c == '{':
+		return p.object()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	}
+	switch p.ident() {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	case "null":
+		return nil, true
+	}
+	return nil, false
+}
+
+// quotedString reads a double-quoted string. Interpolations and directives make it an
+// expression, which it reports as false after skipping the string.
+func (p *hclParser) quotedString() (interface{}, bool) {
+	p.pos++ // opening quote
+	var b strings.Builder
+	literal := true
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String(), literal
+		case c == '\n':
+			return nil, false
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			p.escape(&b)
+			continue
+		case p.hasPrefix("$${") || p.hasPrefix("%%{"):
+			b.WriteString(p.src[p.pos+1 : p.pos+3])
+			p.pos += 3
+			continue
+		case p.hasPrefix("${") || p.hasPrefix("%{"):
+			literal = false
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+	return nil, false
+}
+
+// escape decodes the escape sequence after a backslash.
+func (p *hclParser) escape(b *strings.Builder) {
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size <= len(p.src) {
+			if code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32); err == nil {
+				b.WriteRune(rune(code))
+				p.pos += size
+				return
+			}
+		}
+		b.WriteByte(c)
+	default:
+		b.WriteByte(c)
+	}
+}
+
+// heredoc reads a <<EOT or indented <<-EOT heredoc string.
+func (p *hclParser) heredoc() (interface{}, bool) {
+	start := p.pos
+	p.pos += len("<<")
+	indented := p.peek() == '-'
+	if indented {
+		p.pos++
+	}
+	marker := p.ident()
+	lineEnd := strings.IndexByte(p.src[p.pos:], '\n')
+	if marker == "" || lineEnd < 0 || strings.TrimSpace(p.src[p.pos:p.pos+lineEnd]) != "" {
+		p.pos = start
+		return nil, false
+	}
+	p.pos += lineEnd + 1
+
+	var lines []string
+	for !p.eof() {
+		end := strings.IndexByte(p.src[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		line := strings.TrimSuffix(p.src[p.pos:p.pos+end], "\r")
+		p.pos = min(p.pos+end+1, len(p.src))
+		if strings.TrimSpace(line) == marker {
+			if indented {
+				lines = trimCommonIndent(lines)
+			}
+			if len(lines) == 0 {
+				return "", true
+			}
+			return strings.Join(lines, "\n") + "\n", true
+		}
+		lines = append(lines, line)
+	}
+	p.pos = start
+	return nil, false
+}
+
+// trimCommonIndent removes the leading whitespace all non-blank lines share.
+func trimCommonIndent(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || width < indent {
+			indent = width
+		}
+	}
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		trimmed[i] = line
+	}
+	return trimmed
+}
+
+// number reads a number literal as a float64, matching how JSON specs decode.
+func (p *hclParser) number() (interface{}, bool) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for !p.eof() && strings.IndexByte("0123456789.eE+-", p.peek()) >= 0 {
+		if (p.peek() == '+' || p.peek() == '-') && p.src[p.pos-1] != 'e' && p.src[p.pos-1] != 'E' {
+			break
+		}
+		p.pos++
+	}
+	n, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return nil, false
+	}
+	return n, true
+}
+
+// list reads a list or tuple literal.
+func (p *hclParser) list() (interface{}, bool) {
+	p.pos++ // [
+	items := []interface{}{}
+	for {
+		p.skipSpace(true)
+		if p.peek() == ']' {
+			p.pos++
+			return items, true
+		}
+		item, ok := p.value()
+		if !ok {
+			return nil, false
+		}
+		items = append(items, item)
+		p.skipSpace(true)
+		if p.peek() == ',' {
+			p.pos++
+		} else if p.peek() != ']' {
+			return nil, false
+		}
+	}
+}
+
+// object reads a map or object literal. Keys may be bare or quoted, and assigned with = or :.
+func (p *hclParser) object() (interface{}, bool) {
+	p.pos++ // {
+	fields := map[string]interface{}{}
+	for {
+		p.skipSpace(true)
+		if p.peek() == '}' {
+			p.pos++
+			return fields, true
+		}
+		var key string
+		if p.peek() == '"' {
+			quoted, ok := p.quotedString()
+			if !ok {
+				return nil, false
+			}
+			key, _ = quoted.(string) //nolint:errcheck // quotedString returns a string when ok
+		} else if key = p.ident(); key == "" {
+			return nil, false
+		}
+		p.skipSpace(false)
+		if p.peek() != '=' && p.peek() != ':' {
+			return nil, false
+		}
+		p.pos++
+		p.skipSpace(false)
+		value, ok := p.value()
+		if !ok {
+			return nil, false
+		}
+		fields[key] = value
+		p.skipSpace(false)
+		if p.peek() == ',' {
+			p.pos++
+		} else if p.peek() != '\n' && p.peek() != '}' {
+			return nil, false
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNodeConfigNotCommitted indicates a node config lacks the pending or the committed
+// revision a diff compares.
+var ErrNodeConfigNotCommitted = errors.New("node config has no pending and committed revisions to compare")
+
+// diffContextLines is the number of unchanged lines shown around each change.
+const diffContextLines = 3
+
+// maxDiffLines bounds the size of the files a diff compares.
+const maxDiffLines = 5000
+
+// NodeConfigDiff is the change to a node's terragrunt.hcl between the revision committed
+// for approval and the revision deployed.
+type NodeConfigDiff struct {
+	ConfigID string `json:"config_id"`
+	Path     string `json:"path"`     // terragrunt.hcl path in the storage repository
+	FromSHA  string `json:"from_sha"` // Pending commit
+	ToSHA    string `json:"to_sha"`   // Deployed commit
+	Diff     string `json:"diff"`     // Unified diff; empty when the file is unchanged
+}
+
+// DiffNodeConfig clones the node config's storage repository and returns the unified diff
+// of its terragrunt.hcl from the pending commit to the deployed commit. A file missing at
+// either commit is compared as empty.
+func (s *gitService) DiffNodeConfig(ctx context.Context, id string) (*NodeConfigDiff, error) {
+	config, err := s.nodeConfigRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if config.PendingCommitSHA == "" || config.CommitSHA == "" {
+		return nil, ErrNodeConfigNotCommitted
+	}
+	storageRepo := config.StorageRepo
+	if storageRepo == nil {
+		if storageRepo, err = s.gitRepoRepo.GetByID(ctx, config.StorageRepoID); err != nil {
+			return nil, fmt.Errorf("failed to get storage repository: %w", err)
+		}
+	}
+
+	repoPath := s.operationDir(storageRepo.ID, "diff")
+	defer os.RemoveAll(repoPath) //nolint:errcheck // best effort cleanup
+	if err := s.CloneRepository(ctx, storageRepo, repoPath); err != nil {
+		return nil, fmt.Errorf("failed to clone repository: %w", err)
+	}
+
+	configPath := path.Join(filepath.ToSlash(storageRepo.BasePath), filepath.ToSlash(config.Path), "terragrunt.hcl")
+	from, err := s.readAtCommit(ctx, repoPath, config.PendingCommitSHA, configPath)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.readAtCommit(ctx, repoPath, config.CommitSHA, configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := unifiedDiff("a/"+configPath, "b/"+configPath, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeConfigDiff{
+		ConfigID: config.ID,
+		Path:     configPath,
+		FromSHA:  config.PendingCommitSHA,
+		ToSHA:    config.CommitSHA,
+		Diff:     diff,
+	}, nil
+}
+
+// readAtCommit checks out sha and returns the content of the file at relPath, or an empty
+// string when the commit has no such file.
+func (s *gitService) readAtCommit(ctx context.Context, repoPath, sha, relPath string) (string, error) {
+	if err := s.CheckoutCommit(ctx, repoPath, sha); err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(relPath))) // #nosec G304 -- path is built from the stored node config
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s at %s: %w", relPath, sha, err)
+	}
+	return string(content), nil
+}
+
+// diffOp is one line of a line diff: ' ' for a kept line, '-' for a removed one and '+'
+// for an added one.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns the unified diff from a to b with the given file labels, or an empty
+// string when they are equal.
+func unifiedDiff(fromLabel, toLabel, a, b string) (string, error) {
+	if a == b {
+		return "", nil
+	}
+	aLines, bLines := splitLines(a), splitLines(b)
+	if len(aLines) > maxDiffLines || len(bLines) > maxDiffLines {
+		return "", fmt.Errorf("file is too large to diff: more than %d lines", maxDiffLines)
+	}
+	ops := diffLines(aLines, bLines)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromLabel, toLabel)
+	for start := 0; start < len(ops); {
+		first := nextChange(ops, start)
+		if first < 0 {
+			break
+		}
+		// Extend the hunk while the next change is close enough to share context
+		last := first
+		for next := nextChange(ops, last+1); next >= 0 && next-last-1 <= 2*diffContextLines; next = nextChange(ops, last+1) {
+			last = next
+		}
+		hunkStart := max(first-diffContextLines, 0)
+		hunkEnd := min(last+1+diffContextLines, len(ops))
+		writeHunk(&out, ops, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+	return out.String(), nil
+}
+
+// splitLines splits text into lines, ignoring the newline that ends the last one.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the edit script from a to b that keeps their longest common
+// subsequence of lines.
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// nextChange returns the index of the first added or removed line at or after start, or
+// -1 when there is none.
+func nextChange(ops []diffOp, start int) int {
+	for i := start; i < len(ops); i++ {
+		if ops[i].kind != ' ' {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeHunk writes ops[start:end] as one hunk with its line range header.
+func writeHunk(out *strings.Builder, ops []diffOp, start, end int) {
+	var fromLine, toLine int // Lines of each side before the hunk
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			fromLine++
+		}
+		if op.kind != '-' {
+			toLine++
+		}
+	}
+	var fromCount, toCount int
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			fromCount++
+		}
+		if op.kind != '-' {
+			toCount++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount))
+	for _, op := range ops[start:end] {
+		out.WriteByte(op.kind)
+		out.WriteString(op.line)
+		out.WriteByte('\n')
+	}
+}
+
+// hunkRange formats one side of a hunk header. An empty range names the line before it.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
@@ -0,0 +1,134 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+// ErrInvalidNodeFile is returned when a node config file would be written outside the
+// storage repository checkout or into its .git directory.
+var ErrInvalidNodeFile = errors.New("invalid node config file path")
+
+// terragruntFileName is the file each node's generated configuration is committed as.
+const terragruntFileName = "terragrunt.hcl"
+
+// nodeConfigFiles returns the files committed for config by path relative to its node
+// directory, and their names in order. Extra files may not be absolute or replace the
+// generated terragrunt.hcl.
+func nodeConfigFiles(config *model.NodeConfig) (map[string]string, []string, error) {
+	files := map[string]string{terragruntFileName: config.TerragruntConfig}
+	for name, content := range config.ExtraFiles {
+		if name == "" || filepath.IsAbs(name) || filepath.Clean(name) == terragruntFileName {
+			return nil, nil, fmt.Errorf("%w: %q", ErrInvalidNodeFile, name)
+		}
+		files[name] = content
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return files, names, nil
+}
+
+// scanNodeConfigSecrets rejects a node config any of whose files holds a secret, since they
+// are committed to the storage repository in plaintext.
+func scanNodeConfigSecrets(config *model.NodeConfig) error {
+	files, names, err := nodeConfigFiles(config)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := scanConfigSecrets(files[name]); err != nil {
+			if name == terragruntFileName {
+				return err
+			}
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// writeNodeConfigFiles writes config's terragrunt.hcl and extra files into the checkout of
+// storageRepo at repoPath and returns the paths written, so they are committed together.
+// Every path is checked before any file is written.
+func writeNodeConfigFiles(repoPath string, storageRepo *model.GitRepository, config *model.NodeConfig) ([]string, error) {
+	files, names, err := nodeConfigFiles(config)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeDir := filepath.Join(repoPath, storageRepo.BasePath, config.Path)
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(nodeDir, name)
+		if _, err := repoRelPath(repoPath, path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+
+	for i, path := range paths {
+		if err := writeRepoFile(repoPath, path, files[names[i]]); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// repoRelPath returns path relative to the checkout at repoPath. Paths outside the
+// checkout, the checkout itself and paths inside its .git directory are rejected.
+func repoRelPath(repoPath, path string) (string, error) {
+	rel, err := filepath.Rel(repoPath, path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidNodeFile, err)
+	}
+	first, _, _ := strings.Cut(rel, string(filepath.Separator))
+	if first == ".." || first == "." || first == ".git" {
+		return "", fmt.Errorf("%w: %s is outside the repository", ErrInvalidNodeFile, rel)
+	}
+	return rel, nil
+}
+
+// writeRepoFile writes content to path, creating its directory. The part of the directory
+// that already exists must resolve inside the checkout at repoPath and path must not be a
+// symbolic link, so a link committed to the repository cannot redirect the write elsewhere.
+func writeRepoFile(repoPath, path, content string) error {
+	root, err := filepath.EvalSymlinks(repoPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve repository path: %w", err)
+	}
+	existing := filepath.Dir(path)
+	for {
+		if _, statErr := os.Lstat(existing); statErr == nil || existing == filepath.Dir(existing) {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	if rel, relErr := filepath.Rel(root, resolved); relErr != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%w: %s leads outside the repository", ErrInvalidNodeFile, filepath.Base(path))
+	}
+	if info, statErr := os.Lstat(path); statErr == nil && info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("%w: %s is a symbolic link", ErrInvalidNodeFile, filepath.Base(path))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), filePerm); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+)
+
+// Separators of the fields and records of the history log; neither occurs in commit metadata.
+const (
+	historyFieldSeparator  = "\x1f"
+	historyRecordSeparator = "\x1e"
+)
+
+// historyLogFormat is the git log format of a history entry: sha, author name, author email,
+// author date and message.
+const historyLogFormat = "--format=%H%x1f%an%x1f%ae%x1f%aI%x1f%B%x1e"
+
+// NodeConfigCommit is a commit that changed a node's config directory.
+type NodeConfigCommit struct {
+	SHA         string    `json:"sha"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"author_email"`
+	Date        time.Time `json:"date"`
+	Message     string    `json:"message"`
+}
+
+// ListNodeConfigHistory clones the node config's storage repository and returns a page of
+// the commits that changed its config directory, newest first, with the total number of
+// such commits.
+func (s *gitService) ListNodeConfigHistory(ctx context.Context, configID string, page, pageSize int) ([]NodeConfigCommit, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = constants.DefaultPageSize
+	}
+
+	config, err := s.nodeConfigRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, 0, err
+	}
+	storageRepo := config.StorageRepo
+	if storageRepo == nil {
+		if storageRepo, err = s.gitRepoRepo.GetByID(ctx, config.StorageRepoID); err != nil {
+			return nil, 0, fmt.Errorf("failed to get storage repository: %w", err)
+		}
+	}
+
+	repoPath := s.operationDir(storageRepo.ID, "history")
+	defer os.RemoveAll(repoPath) //nolint:errcheck // best effort cleanup
+	if err := s.CloneRepository(ctx, storageRepo, repoPath); err != nil {
+		return nil, 0, fmt.Errorf("failed to clone repository: %w", err)
+	}
+
+	configDir := path.Join(filepath.ToSlash(storageRepo.BasePath), filepath.ToSlash(config.Path))
+	output, err := s.git(ctx, repoPath, "rev-list", "--count", "HEAD", "--", configDir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count commits: %s", sanitize.CommandOutput(output))
+	}
+	total, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count commits: unexpected output %q", sanitize.CommandOutput(output))
+	}
+
+	skip := (page - 1) * pageSize
+	if int64(skip) >= total {
+		return []NodeConfigCommit{}, total, nil
+	}
+	output, err = s.git(ctx, repoPath, "log", "--skip="+strconv.Itoa(skip), "-n", strconv.Itoa(pageSize), historyLogFormat, "--", configDir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read history: %s", sanitize.CommandOutput(output))
+	}
+	commits, err := parseHistoryLog(output)
+	if err != nil {
+		return nil, 0, err
+	}
+	return commits, total, nil
+}
+
+// parseHistoryLog parses git log output written with historyLogFormat.
+func parseHistoryLog(output string) ([]NodeConfigCommit, error) {
+	commits := []NodeConfigCommit{}
+	for record := range strings.SplitSeq(output, historyRecordSeparator) {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, historyFieldSeparator, 5)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected git log record %q", sanitize.ForLog(record))
+		}
+		date, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of commit %s: %w", fields[0], err)
+		}
+		commits = append(commits, NodeConfigCommit{
+			SHA:         fields[0],
+			Author:      fields[1],
+			AuthorEmail: fields[2],
+			Date:        date.UTC(),
+			Message:     strings.TrimSpace(fields[4]),
+		})
+	}
+	return commits, nil
+}
@@ -0,0 +1,95 @@
+// Package service provides node config history tests.
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// historyGitRunner answers clone, rev-list --count and log as git would for a config
+// directory changed by commits, given newest first. The arguments of each log call are
+// recorded in logs.
+func historyGitRunner(t *testing.T, commits []NodeConfigCommit, logs *[][]string) gitRunner {
+	t.Helper()
+	return func(_ context.Context, _ string, _ []string, args ...string) (string, error) {
+		switch args[0] {
+		case "clone":
+			return "", nil
+		case "rev-list":
+			return strconv.Itoa(len(commits)) + "\n", nil
+		case "log":
+			*logs = append(*logs, args)
+			skip, err := strconv.Atoi(strings.TrimPrefix(args[1], "--skip="))
+			require.NoError(t, err)
+			limit, err := strconv.Atoi(args[3])
+			require.NoError(t, err)
+
+			var out strings.Builder
+			for _, commit := range commits[min(skip, len(commits)):min(skip+limit, len(commits))] {
+				fmt.Fprintf(&out, "%s\x1f%s\x1f%s\x1f%s\x1f%s\n\x1e\n",
+					commit.SHA, commit.Author, commit.AuthorEmail, commit.Date.Format(time.RFC3339), commit.Message)
+			}
+			return out.String(), nil
+		}
+		return "", fmt.Errorf("unexpected git %s", strings.Join(args, " "))
+	}
+}
+
+func TestGitService_ListNodeConfigHistory(t *testing.T) {
+	base := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	commits := make([]NodeConfigCommit, 5)
+	for i := range commits {
+		commits[i] = NodeConfigCommit{
+			SHA:         strings.Repeat(strconv.Itoa(5-i), 40),
+			Author:      "VC Lab Platform",
+			AuthorEmail: "platform@example.com",
+			Date:        base.Add(time.Duration(5-i) * time.Hour),
+			Message:     fmt.Sprintf("Update web-001 (%d)", 5-i),
+		}
+	}
+	commits[0].Message = "Resize web-001\n\nRequested in REQ-42"
+
+	config := &model.NodeConfig{
+		BaseModel: model.BaseModel{ID: "cfg-1"},
+		Path:      "proxmox-ve/instance/vm/web-001",
+		StorageRepo: &model.GitRepository{
+			BaseModel: model.BaseModel{ID: "storage-1"},
+			URL:       "https://git.example.com/infra/nodes.git", Branch: "main", BasePath: "live",
+		},
+	}
+	var logs [][]string
+	svc := &gitService{
+		logger:         zap.NewNop(),
+		workDir:        t.TempDir(),
+		nodeConfigRepo: &statusNodeConfigRepository{config: config},
+		runGit:         historyGitRunner(t, commits, &logs),
+	}
+	ctx := context.Background()
+
+	page, total, err := svc.ListNodeConfigHistory(ctx, "cfg-1", 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, commits[:2], page)
+	assert.Equal(t, []string{"log", "--skip=0", "-n", "2", historyLogFormat, "--", "live/proxmox-ve/instance/vm/web-001"}, logs[0])
+
+	page, _, err = svc.ListNodeConfigHistory(ctx, "cfg-1", 3, 2)
+	require.NoError(t, err)
+	assert.Equal(t, commits[4:], page, "the last page is partial")
+	assert.Equal(t, "--skip=4", logs[1][1])
+
+	logs = nil
+	page, total, err = svc.ListNodeConfigHistory(ctx, "cfg-1", 4, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Empty(t, page)
+	assert.Empty(t, logs, "git log is not run past the last page")
+}
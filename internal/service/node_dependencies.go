@@ -0,0 +1,128 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
+)
+
+// ErrInvalidDependency is returned when a request's node dependencies are malformed or
+// reference a node that does not exist.
+var ErrInvalidDependency = errors.New("invalid node dependency")
+
+// terragruntDependency is a dependency block of a generated terragrunt.hcl.
+type terragruntDependency struct {
+	Name       string
+	ConfigPath string // Directory of the dependency's terragrunt.hcl, relative to the node's
+}
+
+// validateDependencies checks the dependencies declared by the request with ID requestID.
+// Names become block labels and output names attribute references, so both must be
+// identifiers. Credential variables cannot be fed from a dependency, as they cannot be set
+// by a variable override.
+func validateDependencies(requestID string, dependencies []model.NodeDependency) error {
+	names := make(map[string]bool, len(dependencies))
+	inputs := make(map[string]string)
+	for _, dep := range dependencies {
+		if !terraform.IsValidVariableName(dep.Name) {
+			return fmt.Errorf("%w: %q is not a valid dependency name", ErrInvalidDependency, dep.Name)
+		}
+		if names[dep.Name] {
+			return fmt.Errorf("%w: dependency %q is declared twice", ErrInvalidDependency, dep.Name)
+		}
+		names[dep.Name] = true
+
+		if dep.RequestID == "" {
+			return fmt.Errorf("%w: dependency %q names no request", ErrInvalidDependency, dep.Name)
+		}
+		if requestID != "" && dep.RequestID == requestID {
+			return fmt.Errorf("%w: dependency %q is the request itself", ErrInvalidDependency, dep.Name)
+		}
+
+		keys := make([]string, 0, len(dep.Inputs))
+		for input := range dep.Inputs {
+			keys = append(keys, input)
+		}
+		sort.Strings(keys)
+		for _, input := range keys {
+			output := dep.Inputs[input]
+			switch {
+			case !terraform.IsValidVariableName(input):
+				return fmt.Errorf("%w: %q is not a valid variable name", ErrInvalidDependency, input)
+			case terraform.IsCredentialVariable(input):
+				return fmt.Errorf("%w: %q is a credential variable", ErrInvalidDependency, input)
+			case !terraform.IsValidVariableName(output):
+				return fmt.Errorf("%w: %q is not a valid output name", ErrInvalidDependency, output)
+			case inputs[input] != "":
+				return fmt.Errorf("%w: %q is fed by both %q and %q", ErrInvalidDependency, input, inputs[input], dep.Name)
+			}
+			inputs[input] = dep.Name
+		}
+	}
+	return nil
+}
+
+// checkRequestDependencies validates the dependencies of a new request and checks that each
+// names an existing request. The dependency's node config may not exist yet; it is looked
+// up when the request's own node config is created.
+func (s *resourceService) checkRequestDependencies(ctx context.Context, dependencies []model.NodeDependency) error {
+	if err := validateDependencies("", dependencies); err != nil {
+		return err
+	}
+	for _, dep := range dependencies {
+		if _, err := s.resourceRequestRepo.GetByID(ctx, dep.RequestID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%w: dependency %q: request %s does not exist", ErrInvalidDependency, dep.Name, dep.RequestID)
+			}
+			return fmt.Errorf("failed to get request of dependency %q: %w", dep.Name, err)
+		}
+	}
+	return nil
+}
+
+// resolveDependencies validates request's dependencies and locates the node config of each
+// one, for the node being created at configPath in storageRepo. A dependency must already
+// have a node config, in the same storage repository when storageRepo is known.
+func (s *gitService) resolveDependencies(ctx context.Context, request *model.ResourceRequest, configPath string, storageRepo *model.GitRepository) ([]terragruntDependency, error) {
+	if err := validateDependencies(request.ID, request.Dependencies); err != nil {
+		return nil, err
+	}
+
+	dependencies := make([]terragruntDependency, 0, len(request.Dependencies))
+	for _, dep := range request.Dependencies {
+		config, err := s.nodeConfigRepo.GetByResourceRequestID(ctx, dep.RequestID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: dependency %q: request %s has no node config", ErrInvalidDependency, dep.Name, dep.RequestID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node config of dependency %q: %w", dep.Name, err)
+		}
+		if storageRepo != nil && config.StorageRepoID != storageRepo.ID {
+			return nil, fmt.Errorf("%w: dependency %q is in another storage repository", ErrInvalidDependency, dep.Name)
+		}
+
+		rel, err := filepath.Rel(configPath, config.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: dependency %q: %v", ErrInvalidDependency, dep.Name, err)
+		}
+		dependencies = append(dependencies, terragruntDependency{Name: dep.Name, ConfigPath: filepath.ToSlash(rel)})
+	}
+	return dependencies, nil
+}
+
+// mergeDependencyInputs sets each input fed by a dependency to a reference to the
+// dependency's output. They take precedence over the spec and variable overrides.
+func mergeDependencyInputs(inputs map[string]interface{}, dependencies []model.NodeDependency) {
+	for _, dep := range dependencies {
+		for input, output := range dep.Inputs {
+			inputs[input] = terraform.HCLExpression("dependency." + dep.Name + ".outputs." + output)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// Built-in node name and config path layouts, used when none is configured.
+const (
+	builtinNodeNameTemplate   = `{{ .Title }}-{{ printf "%03d" .Sequence }}`
+	builtinConfigPathTemplate = `{{ .Provider }}/instance/{{ .Type }}/{{ .Name }}`
+)
+
+// ErrInvalidNodePath is returned when a node name or config path template renders a
+// name or path that cannot be used in the storage repository.
+var ErrInvalidNodePath = errors.New("invalid node path")
+
+// NodePathFields are the fields available to node name and config path templates.
+type NodePathFields struct {
+	Provider    string // Provider, "default" when the request has none
+	Type        string // Resource type, "vm" when the request has none
+	Environment string
+	Zone        string // Zone code, empty when the request has no zone
+	Title       string // Request title lowercased and reduced to path-safe characters
+	ID          string // Request ID
+	Sequence    int64  // Node number within the provider and type
+	Name        string // Rendered node name; config path templates only
+}
+
+// NodePathTemplates render the name and storage repository path of each node config.
+type NodePathTemplates struct {
+	name *template.Template
+	path *template.Template
+}
+
+// defaultNodePathTemplates are the parsed built-in templates.
+var defaultNodePathTemplates = mustParseNodePathTemplates(builtinNodeNameTemplate, builtinConfigPathTemplate)
+
+func mustParseNodePathTemplates(name, path string) *NodePathTemplates {
+	templates, err := ParseNodePathTemplates(name, path)
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}
+
+// ParseNodePathTemplates parses the node name and config path templates, using the
+// built-in layout for either one left empty. Both are checked against sample nodes, so a
+// template that fails to render, renders an unusable path or gives every node the same
+// path is rejected at load time.
+func ParseNodePathTemplates(name, configPath string) (*NodePathTemplates, error) {
+	if strings.TrimSpace(name) == "" {
+		name = builtinNodeNameTemplate
+	}
+	if strings.TrimSpace(configPath) == "" {
+		configPath = builtinConfigPathTemplate
+	}
+
+	nameTmpl, err := template.New("node_name").Option("missingkey=error").Parse(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node name template: %w", err)
+	}
+	pathTmpl, err := template.New("config_path").Option("missingkey=error").Parse(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config path template: %w", err)
+	}
+	templates := &NodePathTemplates{name: nameTmpl, path: pathTmpl}
+
+	sample := NodePathFields{
+		Provider:    "proxmox-ve",
+		Type:        "vm",
+		Environment: "dev",
+		Zone:        "zone-a",
+		Title:       "node",
+		ID:          "00000000-0000-0000-0000-000000000000",
+	}
+	first, second := sample, sample
+	first.Sequence, second.Sequence = 1, 2
+	firstName, firstPath, err := templates.Render(first)
+	if err != nil {
+		return nil, err
+	}
+	secondName, secondPath, err := templates.Render(second)
+	if err != nil {
+		return nil, err
+	}
+	if firstName == secondName {
+		return nil, errors.New("invalid node name template: it must include .Sequence")
+	}
+	if firstPath == secondPath {
+		return nil, errors.New("invalid config path template: it must include .Name or .Sequence")
+	}
+	return templates, nil
+}
+
+// Render returns the node name and config path for fields. The Name field is set from the
+// rendered name before the path is rendered.
+func (t *NodePathTemplates) Render(fields NodePathFields) (name, configPath string, err error) {
+	var buf strings.Builder
+	if err := t.name.Execute(&buf, fields); err != nil {
+		return "", "", fmt.Errorf("invalid node name template: %w", err)
+	}
+	name = strings.TrimSpace(buf.String())
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", "", fmt.Errorf("%w: node name %q is not a single path segment", ErrInvalidNodePath, name)
+	}
+
+	fields.Name = name
+	buf.Reset()
+	if err := t.path.Execute(&buf, fields); err != nil {
+		return "", "", fmt.Errorf("invalid config path template: %w", err)
+	}
+	configPath = strings.TrimSpace(buf.String())
+	if configPath == "" || strings.Contains(configPath, `\`) || path.IsAbs(configPath) {
+		return "", "", fmt.Errorf("%w: config path %q is not a relative path", ErrInvalidNodePath, configPath)
+	}
+	cleaned := path.Clean(configPath)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", "", fmt.Errorf("%w: config path %q leaves the storage repository", ErrInvalidNodePath, configPath)
+	}
+	return name, cleaned, nil
+}
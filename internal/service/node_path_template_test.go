@@ -0,0 +1,85 @@
+// Package service provides node path template tests.
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseNodePathTemplates(t *testing.T) {
+	storageRepo := &model.GitRepository{BaseModel: model.BaseModel{ID: "storage-1"}}
+	request := &model.ResourceRequest{
+		BaseModel:   model.BaseModel{ID: "1234abcd-5678-90ef-1234-567890abcdef"},
+		Title:       "Minio Node",
+		Provider:    "proxmox-ve",
+		Type:        "vm",
+		Environment: "staging",
+		Zone:        &model.Zone{Code: "zone-b"},
+	}
+
+	t.Run("empty templates keep the built-in layout", func(t *testing.T) {
+		templates, err := ParseNodePathTemplates("", " ")
+		require.NoError(t, err)
+
+		name, path, err := templates.Render(NodePathFields{Provider: "pve", Type: "vm", Title: "web", Sequence: 7})
+		require.NoError(t, err)
+		assert.Equal(t, "web-007", name)
+		assert.Equal(t, "pve/instance/vm/web-007", path)
+	})
+
+	t.Run("custom templates name nodes and paths", func(t *testing.T) {
+		templates, err := ParseNodePathTemplates(
+			`{{ .Environment }}-{{ .Title }}{{ .Sequence }}`,
+			`{{ .Zone }}/{{ .Environment }}/{{ .Type }}s/{{ .Name }}`,
+		)
+		require.NoError(t, err)
+		svc := &gitService{logger: zap.NewNop(), nodeConfigRepo: &pathNodeConfigRepository{taken: map[string]bool{}}, sequenceRepo: &memorySequenceRepository{}, nodePathTemplates: templates}
+
+		name, path, err := svc.resolveNodePath(context.Background(), request, storageRepo)
+		require.NoError(t, err)
+		assert.Equal(t, "staging-minio-node1", name)
+		assert.Equal(t, "zone-b/staging/vms/staging-minio-node1", path)
+
+		name, _, err = svc.resolveNodePath(context.Background(), request, storageRepo)
+		require.NoError(t, err)
+		assert.Equal(t, "staging-minio-node2", name)
+	})
+
+	t.Run("request ID is available", func(t *testing.T) {
+		templates, err := ParseNodePathTemplates(`{{ slice .ID 0 8 }}-{{ .Sequence }}`, `nodes/{{ .Name }}`)
+		require.NoError(t, err)
+
+		fields := nodePathFields(request)
+		fields.Sequence = 3
+		name, path, err := templates.Render(fields)
+		require.NoError(t, err)
+		assert.Equal(t, "1234abcd-3", name)
+		assert.Equal(t, "nodes/1234abcd-3", path)
+	})
+
+	tests := []struct {
+		name     string
+		nameTmpl string
+		pathTmpl string
+		want     string
+	}{
+		{"syntax error", `{{ .Title `, "", "invalid node name template"},
+		{"unknown field", `{{ .Hostname }}-{{ .Sequence }}`, "", "invalid node name template"},
+		{"name without sequence", `{{ .Title }}`, "", "must include .Sequence"},
+		{"name with a separator", `{{ .Type }}/{{ .Sequence }}`, "", "not a single path segment"},
+		{"path without the node", "", `{{ .Provider }}/instance`, "must include .Name or .Sequence"},
+		{"absolute path", "", `/srv/{{ .Name }}`, "not a relative path"},
+		{"path outside the repository", "", `../{{ .Name }}`, "leaves the storage repository"},
+	}
+	for _, tt := range tests {
+		t.Run("rejects "+tt.name, func(t *testing.T) {
+			_, err := ParseNodePathTemplates(tt.nameTmpl, tt.pathTmpl)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
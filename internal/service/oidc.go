@@ -0,0 +1,267 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SSO errors.
+var (
+	ErrSSODisabled    = errors.New("SSO is not enabled")
+	ErrSSOLoginFailed = errors.New("SSO login failed")
+)
+
+// maxOIDCResponseSize caps the size of responses read from the OIDC provider.
+const maxOIDCResponseSize = 1 << 20
+
+// oidcDiscovery is the part of the provider's discovery document the login flow uses.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcClaims are the ID token claims used to identify the user.
+type oidcClaims struct {
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+// oidcProvider runs the authorization code flow against an OpenID Connect provider. The
+// discovery document and signing keys are fetched on first use and cached; the keys are
+// fetched again when a token is signed with an unknown key.
+type oidcProvider struct {
+	cfg    config.SSOConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+}
+
+// newOIDCProvider creates a provider client for cfg.
+func newOIDCProvider(cfg config.SSOConfig, client *http.Client) *oidcProvider {
+	if client == nil {
+		client = &http.Client{Timeout: constants.OIDCHTTPTimeout}
+	}
+	return &oidcProvider{cfg: cfg, client: client}
+}
+
+// authCodeURL returns the provider URL that starts a login carrying state and nonce.
+func (p *oidcProvider) authCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.cfg.ClientID},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"scope":         {"openid email profile"},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// exchange redeems an authorization code and returns the verified claims of its ID token.
+func (p *oidcProvider) exchange(ctx context.Context, code, nonce string) (*oidcClaims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {p.cfg.RedirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &token)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	if status != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("token endpoint returned %d: %s %s", status, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return p.verifyIDToken(ctx, token.IDToken, nonce)
+}
+
+// verifyIDToken checks the ID token's signature, issuer, audience, expiry and nonce.
+func (p *oidcProvider) verifyIDToken(ctx context.Context, raw, nonce string) (*oidcClaims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &oidcClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string) //nolint:errcheck // a missing kid is looked up as ""
+		return p.signingKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid ID token: nonce does not match")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid ID token: no subject")
+	}
+	return claims, nil
+}
+
+// discover returns the provider's discovery document, fetching it on first use.
+func (p *oidcProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(p.cfg.ProviderURL, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	var discovery oidcDiscovery
+	status, err := p.doJSON(req, &discovery)
+	if err != nil {
+		return nil, fmt.Errorf("discovery request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("discovery returned %d", status)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match %q", discovery.Issuer, p.cfg.ProviderURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// signingKey returns the provider's RSA key with the given key ID.
+func (p *oidcProvider) signingKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	jwksURI := p.discovery.JWKSURI
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	keys, err := p.fetchKeys(ctx, jwksURI)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("no signing key with ID %q", kid)
+}
+
+// fetchKeys loads the RSA signing keys published at jwksURI, by key ID.
+func (p *oidcProvider) fetchKeys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	status, err := p.doJSON(req, &jwks)
+	if err != nil {
+		return nil, fmt.Errorf("JWKS request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("JWKS returned %d", status)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+// doJSON sends req and decodes a JSON response body into v, returning the status code.
+func (p *oidcProvider) doJSON(req *http.Request, v interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // read-only body
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOIDCResponseSize))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
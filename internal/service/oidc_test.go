@@ -0,0 +1,314 @@
+// Package service provides OIDC login tests.
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOIDCProvider serves discovery, JWKS and a token endpoint that answers the code
+// "good-code" with an ID token built from claims.
+type fakeOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p := &fakeOIDCProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		writeTestJSON(w, map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		writeTestJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kid": "key-1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, ok := r.BasicAuth()
+		if !ok || clientID != "vc-lab" || secret != "s3cret" || r.FormValue("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			writeTestJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, p.claims)
+		token.Header["kid"] = "key-1"
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		writeTestJSON(w, map[string]string{"access_token": "provider-token", "id_token": signed})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	p.claims = jwt.MapClaims{
+		"iss":                p.server.URL,
+		"aud":                "vc-lab",
+		"sub":                "subject-1",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"nonce":              "nonce-1",
+		"email":              "Jane.Doe@example.com",
+		"email_verified":     true,
+		"name":               "Jane Doe",
+		"preferred_username": "jdoe",
+	}
+	return p
+}
+
+func writeTestJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v) //nolint:errcheck // test server
+}
+
+func newSSOTestService(p *fakeOIDCProvider, userRepo *MockUserRepository, roleRepo *MockRoleRepository) *authService {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{Secret: "test-secret-key-that-is-long-enough-32chars", AccessTokenTTL: 15, RefreshTokenTTL: 1, Issuer: "test"},
+		SSO: config.SSOConfig{
+			Enabled:      true,
+			ProviderURL:  p.server.URL,
+			ClientID:     "vc-lab",
+			ClientSecret: "s3cret",
+			RedirectURL:  "https://lab.example.com/api/v1/auth/oidc/callback",
+			DefaultRole:  "user",
+		},
+	}
+	return &authService{
+		userRepo:  userRepo,
+		roleRepo:  roleRepo,
+		cfg:       cfg,
+		blacklist: &tokenBlacklist{tokens: make(map[string]time.Time)},
+		oidc:      newOIDCProvider(cfg.SSO, p.server.Client()),
+	}
+}
+
+func TestAuthService_SSOAuthURL(t *testing.T) {
+	p := newFakeOIDCProvider(t)
+	svc := newSSOTestService(p, new(MockUserRepository), new(MockRoleRepository))
+
+	authURL, err := svc.SSOAuthURL(context.Background(), "state-1", "nonce-1")
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, p.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	query := parsed.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "vc-lab", query.Get("client_id"))
+	assert.Equal(t, "https://lab.example.com/api/v1/auth/oidc/callback", query.Get("redirect_uri"))
+	assert.Equal(t, "state-1", query.Get("state"))
+	assert.Equal(t, "nonce-1", query.Get("nonce"))
+	assert.Contains(t, query.Get("scope"), "openid")
+}
+
+func TestAuthService_SSOLogin(t *testing.T) {
+	ctx := context.Background()
+	userRole := &model.Role{BaseModel: model.BaseModel{ID: "role-user"}, Code: "user"}
+
+	t.Run("provisions a new user with the default role", func(t *testing.T) {
+		p := newFakeOIDCProvider(t)
+		userRepo, roleRepo := new(MockUserRepository), new(MockRoleRepository)
+		svc := newSSOTestService(p, userRepo, roleRepo)
+
+		userRepo.On("GetByExternalID", ctx, model.UserSourceOIDC, "subject-1").Return(nil, repository.ErrNotFound)
+		userRepo.On("GetByEmail", ctx, "jane.doe@example.com").Return(nil, repository.ErrNotFound)
+		roleRepo.On("GetByCode", ctx, "user").Return(userRole, nil)
+		userRepo.On("GetByUsername", ctx, "jdoe").Return(&model.User{Username: "jdoe"}, nil)
+		userRepo.On("GetByUsername", ctx, "jdoe-2").Return(nil, repository.ErrNotFound)
+		var created *model.User
+		userRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Run(func(args mock.Arguments) {
+			created, _ = args.Get(1).(*model.User) //nolint:errcheck // type checked by the matcher
+			created.ID = "user-new"
+		}).Return(nil)
+		userRepo.On("UpdateLastLogin", ctx, "user-new", "10.0.0.1").Return(nil)
+
+		tokens, err := svc.SSOLogin(ctx, "good-code", "nonce-1", "10.0.0.1")
+		require.NoError(t, err)
+
+		require.NotNil(t, created)
+		assert.Equal(t, "jdoe-2", created.Username, "a taken username gets the first free number")
+		assert.Equal(t, "jane.doe@example.com", created.Email)
+		assert.Equal(t, "Jane Doe", created.DisplayName)
+		assert.Equal(t, model.UserSourceOIDC, created.Source)
+		assert.Equal(t, "subject-1", created.ExternalID)
+		assert.Empty(t, created.PasswordHash, "SSO users cannot log in with a password")
+
+		claims, err := svc.ValidateToken(ctx, tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "user-new", claims.UserID)
+		assert.Equal(t, []string{"user"}, claims.Roles)
+	})
+
+	t.Run("returns the user linked to the subject", func(t *testing.T) {
+		p := newFakeOIDCProvider(t)
+		userRepo := new(MockUserRepository)
+		svc := newSSOTestService(p, userRepo, new(MockRoleRepository))
+
+		linked := &model.User{BaseModel: model.BaseModel{ID: "user-1"}, Username: "jane", Source: model.UserSourceOIDC, ExternalID: "subject-1", Status: 1}
+		userRepo.On("GetByExternalID", ctx, model.UserSourceOIDC, "subject-1").Return(linked, nil)
+		userRepo.On("UpdateLastLogin", ctx, "user-1", "").Return(nil)
+
+		tokens, err := svc.SSOLogin(ctx, "good-code", "nonce-1", "")
+		require.NoError(t, err)
+		claims, err := svc.ValidateToken(ctx, tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "jane", claims.Username)
+		userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("links an existing user with the verified email", func(t *testing.T) {
+		p := newFakeOIDCProvider(t)
+		userRepo := new(MockUserRepository)
+		svc := newSSOTestService(p, userRepo, new(MockRoleRepository))
+		svc.cfg.SSO.LinkLocalAccounts = true
+
+		local := &model.User{BaseModel: model.BaseModel{ID: "user-2"}, Username: "jane", Email: "jane.doe@example.com", Source: model.UserSourceLocal, Status: 1}
+		userRepo.On("GetByExternalID", ctx, model.UserSourceOIDC, "subject-1").Return(nil, repository.ErrNotFound)
+		userRepo.On("GetByEmail", ctx, "jane.doe@example.com").Return(local, nil)
+		userRepo.On("Update", ctx, local).Return(nil)
+		userRepo.On("UpdateLastLogin", ctx, "user-2", "").Return(nil)
+
+		_, err := svc.SSOLogin(ctx, "good-code", "nonce-1", "")
+		require.NoError(t, err)
+		assert.Equal(t, "subject-1", local.ExternalID)
+		userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	refusedLinks := []struct {
+		name     string
+		linkable bool
+		user     *model.User
+	}{
+		{
+			name: "local account is not linked unless the provider may take one over",
+			user: &model.User{BaseModel: model.BaseModel{ID: "user-2"}, Username: "jane", Source: model.UserSourceLocal, Status: 1},
+		},
+		{
+			name:     "local administrator is never linked",
+			linkable: true,
+			user: &model.User{BaseModel: model.BaseModel{ID: "user-2"}, Username: "jane", Source: model.UserSourceLocal, Status: 1,
+				Roles: []model.Role{{Code: adminRoleCode}}},
+		},
+		{
+			name:     "account linked to another identity is not relinked",
+			linkable: true,
+			user:     &model.User{BaseModel: model.BaseModel{ID: "user-2"}, Username: "jane", Source: model.UserSourceLocal, ExternalID: "subject-9", Status: 1},
+		},
+	}
+	for _, tt := range refusedLinks {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newFakeOIDCProvider(t)
+			userRepo := new(MockUserRepository)
+			svc := newSSOTestService(p, userRepo, new(MockRoleRepository))
+			svc.cfg.SSO.LinkLocalAccounts = tt.linkable
+
+			userRepo.On("GetByExternalID", ctx, model.UserSourceOIDC, "subject-1").Return(nil, repository.ErrNotFound)
+			userRepo.On("GetByEmail", ctx, "jane.doe@example.com").Return(tt.user, nil)
+
+			_, err := svc.SSOLogin(ctx, "good-code", "nonce-1", "")
+			require.ErrorIs(t, err, ErrSSOLoginFailed)
+			userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("unverified email is not linked to an existing user", func(t *testing.T) {
+		p := newFakeOIDCProvider(t)
+		p.claims["email_verified"] = false
+		userRepo, roleRepo := new(MockUserRepository), new(MockRoleRepository)
+		svc := newSSOTestService(p, userRepo, roleRepo)
+
+		userRepo.On("GetByExternalID", ctx, model.UserSourceOIDC, "subject-1").Return(nil, repository.ErrNotFound)
+		roleRepo.On("GetByCode", ctx, "user").Return(userRole, nil)
+		userRepo.On("GetByUsername", ctx, "jdoe").Return(nil, repository.ErrNotFound)
+		userRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(repository.ErrDuplicateKey)
+
+		_, err := svc.SSOLogin(ctx, "good-code", "nonce-1", "")
+		require.ErrorIs(t, err, ErrSSOLoginFailed)
+		userRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("disabled user is refused", func(t *testing.T) {
+		p := newFakeOIDCProvider(t)
+		userRepo := new(MockUserRepository)
+		svc := newSSOTestService(p, userRepo, new(MockRoleRepository))
+
+		userRepo.On("GetByExternalID", ctx, model.UserSourceOIDC, "subject-1").
+			Return(&model.User{BaseModel: model.BaseModel{ID: "user-1"}, Status: 0}, nil)
+
+		_, err := svc.SSOLogin(ctx, "good-code", "nonce-1", "")
+		require.ErrorIs(t, err, ErrUserDisabled)
+	})
+
+	rejected := []struct {
+		name   string
+		code   string
+		nonce  string
+		claims jwt.MapClaims
+	}{
+		{name: "code rejected by the token endpoint", code: "bad-code", nonce: "nonce-1"},
+		{name: "nonce mismatch", code: "good-code", nonce: "other-nonce"},
+		{name: "token for another client", code: "good-code", nonce: "nonce-1", claims: jwt.MapClaims{"aud": "other-client"}},
+		{name: "token from another issuer", code: "good-code", nonce: "nonce-1", claims: jwt.MapClaims{"iss": "https://evil.example.com"}},
+		{name: "expired token", code: "good-code", nonce: "nonce-1", claims: jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newFakeOIDCProvider(t)
+			for claim, value := range tt.claims {
+				p.claims[claim] = value
+			}
+			userRepo := new(MockUserRepository)
+			svc := newSSOTestService(p, userRepo, new(MockRoleRepository))
+
+			_, err := svc.SSOLogin(ctx, tt.code, tt.nonce, "")
+			require.ErrorIs(t, err, ErrSSOLoginFailed)
+			userRepo.AssertNotCalled(t, "GetByExternalID", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("SSO disabled", func(t *testing.T) {
+		svc := &authService{cfg: &config.Config{}}
+		_, err := svc.SSOLogin(ctx, "good-code", "nonce-1", "")
+		require.ErrorIs(t, err, ErrSSODisabled)
+		_, err = svc.SSOAuthURL(ctx, "state", "nonce")
+		require.ErrorIs(t, err, ErrSSODisabled)
+	})
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	assert.Equal(t, "jane.doe", normalizeUsername("Jane.Doe"))
+	assert.Equal(t, "jane-doe", normalizeUsername("jane doe"))
+	assert.Equal(t, "user-ab", normalizeUsername("ab"))
+	assert.Equal(t, "user", normalizeUsername("@@"))
+	assert.Len(t, normalizeUsername(strings.Repeat("a", 100)), maxUsernameLength)
+}
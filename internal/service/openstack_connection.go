@@ -0,0 +1,144 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+const (
+	// keystoneAPIPath is the prefix of the Keystone v3 identity API.
+	keystoneAPIPath = "/v3"
+	// keystoneDefaultDomain is the domain of users and projects when none is configured.
+	keystoneDefaultDomain = "Default"
+	// keystoneAppCredentialAuth is the auth type of application credentials.
+	keystoneAppCredentialAuth = "v3applicationcredential"
+)
+
+// keystoneAuthRequest is the body of a Keystone v3 token request.
+type keystoneAuthRequest struct {
+	Auth keystoneAuth `json:"auth"`
+}
+
+type keystoneAuth struct {
+	Identity keystoneIdentity `json:"identity"`
+	Scope    *keystoneScope   `json:"scope,omitempty"`
+}
+
+type keystoneIdentity struct {
+	Methods               []string               `json:"methods"`
+	Password              *keystonePassword      `json:"password,omitempty"`
+	ApplicationCredential *keystoneAppCredential `json:"application_credential,omitempty"`
+}
+
+type keystonePassword struct {
+	User keystoneUser `json:"user"`
+}
+
+type keystoneUser struct {
+	Name     string         `json:"name"`
+	Domain   keystoneDomain `json:"domain"`
+	Password string         `json:"password"`
+}
+
+type keystoneAppCredential struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+type keystoneScope struct {
+	Project keystoneProject `json:"project"`
+}
+
+type keystoneProject struct {
+	Name   string         `json:"name"`
+	Domain keystoneDomain `json:"domain"`
+}
+
+type keystoneDomain struct {
+	Name string `json:"name"`
+}
+
+// testOpenStackConnection tests connection to an OpenStack cloud by requesting a Keystone
+// v3 token for the credential, at the credential's endpoint when it has one. The token is
+// scoped to the configured project, or unscoped when there is none, and is not kept.
+// Without a credential only reachability of the identity API is checked.
+func (s *settingsService) testOpenStackConnection(ctx context.Context, endpoint string, credential *model.Credential, opts connectionOptions) error {
+	if credential != nil && credential.Endpoint != "" {
+		endpoint = credential.Endpoint
+	}
+	base, err := apiBaseURL(endpoint, keystoneAPIPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.ConnectionTestTimeout)
+	defer cancel()
+	client := connectionTestClient(opts)
+
+	if credential == nil || credential.AccessKey == "" {
+		resp, err := connectionTestRequest(ctx, client, http.MethodGet, base, nil, nil)
+		if err != nil {
+			return err
+		}
+		if err := classifyStatus(resp.Response); err != nil {
+			return err
+		}
+		if !json.Valid(resp.body) || !bytes.Contains(resp.body, []byte(`"version"`)) {
+			return &ConnectionError{Kind: ConnectionErrorResponse, Err: errors.New("response is not from the Keystone v3 API")}
+		}
+		return nil
+	}
+
+	body, err := json.Marshal(keystoneAuthRequest{Auth: keystoneAuthFor(credential, opts)})
+	if err != nil {
+		return fmt.Errorf("failed to encode token request: %w", err)
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	resp, err := connectionTestRequest(ctx, client, http.MethodPost, base+"/auth/tokens", header, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if err := classifyStatus(resp.Response); err != nil {
+		return err
+	}
+	if resp.Header.Get("X-Subject-Token") == "" {
+		return &ConnectionError{Kind: ConnectionErrorResponse, Err: errors.New("response has no token")}
+	}
+	return nil
+}
+
+// keystoneAuthFor returns the auth section of a token request for credential: an
+// application credential when opts say so, and user name and password otherwise.
+func keystoneAuthFor(credential *model.Credential, opts connectionOptions) keystoneAuth {
+	if strings.EqualFold(opts.AuthType, keystoneAppCredentialAuth) {
+		// Application credentials carry their own project and cannot be rescoped
+		return keystoneAuth{Identity: keystoneIdentity{
+			Methods:               []string{"application_credential"},
+			ApplicationCredential: &keystoneAppCredential{ID: credential.AccessKey, Secret: credential.SecretKey},
+		}}
+	}
+
+	userDomain := cmp.Or(opts.UserDomainName, keystoneDefaultDomain)
+	auth := keystoneAuth{Identity: keystoneIdentity{
+		Methods: []string{"password"},
+		Password: &keystonePassword{User: keystoneUser{
+			Name: credential.AccessKey, Domain: keystoneDomain{Name: userDomain}, Password: credential.SecretKey,
+		}},
+	}}
+	if project := cmp.Or(opts.Project, opts.TenantName); project != "" {
+		auth.Scope = &keystoneScope{Project: keystoneProject{
+			Name: project, Domain: keystoneDomain{Name: cmp.Or(opts.ProjectDomainName, keystoneDefaultDomain)},
+		}}
+	}
+	return auth
+}
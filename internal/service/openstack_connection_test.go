@@ -0,0 +1,116 @@
+// Package service provides OpenStack connection tests.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeystone mimics the Keystone v3 version and token endpoints, accepting the password
+// of user "demo" and application credential "app-1". Each token request's auth section is
+// recorded in requests.
+func fakeKeystone(t *testing.T, requests *[]keystoneAuth) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/identity/v3":
+			_, _ = w.Write([]byte(`{"version":{"id":"v3.14","status":"stable","links":[]}}`)) //nolint:errcheck // test server
+		case r.Method == http.MethodPost && r.URL.Path == "/identity/v3/auth/tokens":
+			var body keystoneAuthRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			*requests = append(*requests, body.Auth)
+
+			identity := body.Auth.Identity
+			switch {
+			case identity.Password != nil && identity.Password.User.Name == "demo" && identity.Password.User.Password == "secret":
+			case identity.ApplicationCredential != nil && identity.ApplicationCredential.ID == "app-1" && identity.ApplicationCredential.Secret == "app-secret":
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":{"code":401,"title":"Unauthorized","message":"The request you have made requires authentication."}}`)) //nolint:errcheck // test server
+				return
+			}
+			w.Header().Set("X-Subject-Token", "gAAAAABtoken")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"token":{"methods":["password"],"expires_at":"2026-10-16T12:00:00.000000Z"}}`)) //nolint:errcheck // test server
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestSettingsService_TestOpenStackConnection(t *testing.T) {
+	ctx := context.Background()
+	svc := &settingsService{}
+	var requests []keystoneAuth
+	server := httptest.NewServer(fakeKeystone(t, &requests))
+	defer server.Close()
+	endpoint := server.URL + "/identity"
+	password := &model.Credential{AccessKey: "demo", SecretKey: "secret"}
+
+	t.Run("password gets an unscoped token", func(t *testing.T) {
+		requests = nil
+		require.NoError(t, svc.testOpenStackConnection(ctx, endpoint, password, connectionOptions{}))
+		require.NoError(t, svc.testOpenStackConnection(ctx, endpoint+"/v3/", password, connectionOptions{}), "the version path may be included")
+
+		require.Len(t, requests, 2)
+		assert.Equal(t, []string{"password"}, requests[0].Identity.Methods)
+		assert.Equal(t, "Default", requests[0].Identity.Password.User.Domain.Name)
+		assert.Nil(t, requests[0].Scope)
+	})
+
+	t.Run("configured project scopes the token", func(t *testing.T) {
+		requests = nil
+		require.NoError(t, svc.testOpenStackConnection(ctx, endpoint, password, connectionOptions{TenantName: "lab", UserDomainName: "ldap"}))
+		require.NoError(t, svc.testOpenStackConnection(ctx, endpoint, password, connectionOptions{Project: "infra", TenantName: "lab", ProjectDomainName: "ops"}))
+
+		require.Len(t, requests, 2)
+		require.NotNil(t, requests[0].Scope)
+		assert.Equal(t, "ldap", requests[0].Identity.Password.User.Domain.Name)
+		assert.Equal(t, keystoneProject{Name: "lab", Domain: keystoneDomain{Name: "Default"}}, requests[0].Scope.Project)
+		assert.Equal(t, keystoneProject{Name: "infra", Domain: keystoneDomain{Name: "ops"}}, requests[1].Scope.Project, "project wins over tenant_name")
+	})
+
+	t.Run("application credential", func(t *testing.T) {
+		requests = nil
+		appCredential := &model.Credential{AccessKey: "app-1", SecretKey: "app-secret"}
+		require.NoError(t, svc.testOpenStackConnection(ctx, endpoint, appCredential, connectionOptions{AuthType: "v3applicationcredential", Project: "lab"}))
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, []string{"application_credential"}, requests[0].Identity.Methods)
+		assert.Nil(t, requests[0].Scope, "application credentials are not rescoped")
+	})
+
+	t.Run("rejected credential", func(t *testing.T) {
+		err := svc.testOpenStackConnection(ctx, endpoint, &model.Credential{AccessKey: "demo", SecretKey: "wrong"}, connectionOptions{})
+		assert.Equal(t, ConnectionErrorAuth, connectionErrorKind(t, err))
+		assert.Contains(t, err.Error(), "401")
+	})
+
+	t.Run("credential endpoint is preferred", func(t *testing.T) {
+		credential := &model.Credential{Endpoint: endpoint, AccessKey: "demo", SecretKey: "secret"}
+		require.NoError(t, svc.testOpenStackConnection(ctx, "https://keystone.invalid:5000", credential, connectionOptions{}))
+	})
+
+	t.Run("reachability without a credential", func(t *testing.T) {
+		require.NoError(t, svc.testOpenStackConnection(ctx, endpoint, nil, connectionOptions{}))
+
+		err := svc.testOpenStackConnection(ctx, server.URL+"/other", nil, connectionOptions{})
+		assert.Equal(t, ConnectionErrorResponse, connectionErrorKind(t, err))
+	})
+
+	t.Run("unreachable endpoint", func(t *testing.T) {
+		closed := httptest.NewServer(fakeKeystone(t, &requests))
+		closed.Close()
+
+		err := svc.testOpenStackConnection(ctx, closed.URL, password, connectionOptions{})
+		assert.Equal(t, ConnectionErrorNetwork, connectionErrorKind(t, err))
+	})
+}
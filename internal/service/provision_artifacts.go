@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
+	"go.uber.org/zap"
+)
+
+// Artifact download errors.
+var (
+	ErrInvalidArtifactSignature = errors.New("invalid artifact download signature")
+	ErrArtifactURLExpired       = errors.New("artifact download URL has expired")
+)
+
+// artifactKeyLabel separates the artifact download key from other uses of the secret it is
+// derived from.
+const artifactKeyLabel = "vc-lab-platform artifact download"
+
+// ArtifactURLSigner signs artifact download URLs. A signature is the hex HMAC-SHA256 of the
+// node config ID and the expiry time, so neither can be changed without invalidating it.
+type ArtifactURLSigner struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewArtifactURLSigner creates a signer whose URLs are valid for ttl. The signing key is
+// derived from secret, so a URL only ever authorizes an artifact download.
+func NewArtifactURLSigner(secret string, ttl time.Duration) *ArtifactURLSigner {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(artifactKeyLabel))
+	return &ArtifactURLSigner{key: mac.Sum(nil), ttl: ttl, now: time.Now}
+}
+
+// Sign returns the expiry time, in Unix seconds, and the signature of a download URL for the
+// artifacts of the node config with ID nodeConfigID.
+func (s *ArtifactURLSigner) Sign(nodeConfigID string) (expires int64, signature string) {
+	expires = s.now().Add(s.ttl).Unix()
+	return expires, s.signature(nodeConfigID, expires)
+}
+
+// Verify checks a download URL's signature, then that it has not expired.
+func (s *ArtifactURLSigner) Verify(nodeConfigID string, expires int64, signature string) error {
+	if !hmac.Equal([]byte(signature), []byte(s.signature(nodeConfigID, expires))) {
+		return ErrInvalidArtifactSignature
+	}
+	if s.now().Unix() > expires {
+		return ErrArtifactURLExpired
+	}
+	return nil
+}
+
+// signature returns the signature of a download URL for nodeConfigID expiring at expires.
+func (s *ArtifactURLSigner) signature(nodeConfigID string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(nodeConfigID + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ArtifactDownload holds what a signed artifact download URL is made of.
+type ArtifactDownload struct {
+	NodeConfigID string
+	Expires      int64
+	Signature    string
+}
+
+// ArtifactService packages the generated terraform files and plan of node configs for
+// download through signed URLs.
+type ArtifactService interface {
+	SignDownload(ctx context.Context, nodeConfigID string) (*ArtifactDownload, error)
+	DownloadArchive(ctx context.Context, nodeConfigID string, expires int64, signature string) ([]byte, error)
+}
+
+// artifactService implements ArtifactService.
+type artifactService struct {
+	nodeConfigRepo    repository.NodeConfigRepository
+	terraformExecutor *terraform.Executor
+	signer            *ArtifactURLSigner
+	logger            *zap.Logger
+}
+
+// NewArtifactService creates a new artifact service.
+func NewArtifactService(nodeConfigRepo repository.NodeConfigRepository, terraformExecutor *terraform.Executor, signer *ArtifactURLSigner, logger *zap.Logger) ArtifactService {
+	return &artifactService{
+		nodeConfigRepo:    nodeConfigRepo,
+		terraformExecutor: terraformExecutor,
+		signer:            signer,
+		logger:            logger,
+	}
+}
+
+// SignDownload signs a download URL for the artifacts of the node config with ID nodeConfigID.
+func (s *artifactService) SignDownload(ctx context.Context, nodeConfigID string) (*ArtifactDownload, error) {
+	if _, err := s.nodeConfigRepo.GetByID(ctx, nodeConfigID); err != nil {
+		return nil, err
+	}
+	expires, signature := s.signer.Sign(nodeConfigID)
+	return &ArtifactDownload{NodeConfigID: nodeConfigID, Expires: expires, Signature: signature}, nil
+}
+
+// DownloadArchive verifies a signed download URL and returns a zip archive of the terraform
+// files generated for the node config's request and its saved plan, with secrets left out.
+func (s *artifactService) DownloadArchive(ctx context.Context, nodeConfigID string, expires int64, signature string) ([]byte, error) {
+	if err := s.signer.Verify(nodeConfigID, expires, signature); err != nil {
+		return nil, err
+	}
+	config, err := s.nodeConfigRepo.GetByID(ctx, nodeConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	workDir := s.terraformExecutor.WorkDir(config.ResourceRequestID)
+	if err := s.terraformExecutor.WriteArtifacts(ctx, workDir, &buf); err != nil {
+		return nil, err
+	}
+	s.logger.Info("artifacts downloaded", zap.String("node_config_id", nodeConfigID), zap.String("request_id", config.ResourceRequestID))
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,115 @@
+// Package service provides provisioning artifact download tests.
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestArtifactURLSigner(t *testing.T) {
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	signer := NewArtifactURLSigner("a-jwt-secret-of-at-least-32-characters", 10*time.Minute)
+	signer.now = func() time.Time { return now }
+	expires, signature := signer.Sign("config-1")
+	assert.Equal(t, now.Add(10*time.Minute).Unix(), expires)
+
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, signer.Verify("config-1", expires, signature))
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		assert.ErrorIs(t, signer.Verify("config-2", expires, signature), ErrInvalidArtifactSignature, "another node config")
+		assert.ErrorIs(t, signer.Verify("config-1", expires+3600, signature), ErrInvalidArtifactSignature, "a later expiry")
+		tampered := []byte(signature)
+		tampered[0] ^= 1
+		assert.ErrorIs(t, signer.Verify("config-1", expires, string(tampered)), ErrInvalidArtifactSignature)
+		assert.ErrorIs(t, signer.Verify("config-1", expires, ""), ErrInvalidArtifactSignature)
+
+		other := NewArtifactURLSigner("another-jwt-secret-of-32-characters!!", 10*time.Minute)
+		other.now = signer.now
+		_, otherSignature := other.Sign("config-1")
+		assert.ErrorIs(t, signer.Verify("config-1", expires, otherSignature), ErrInvalidArtifactSignature, "signed with another secret")
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		later := *signer
+		later.now = func() time.Time { return now.Add(10*time.Minute + time.Second) }
+		assert.ErrorIs(t, later.Verify("config-1", expires, signature), ErrArtifactURLExpired)
+
+		later.now = func() time.Time { return now.Add(10 * time.Minute) }
+		assert.NoError(t, later.Verify("config-1", expires, signature), "valid up to the expiry second")
+	})
+}
+
+func TestArtifactService_DownloadArchive(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	executor := terraform.NewExecutor(zap.NewNop(), nil, false, terraform.Workspace{Root: root})
+	workDir := executor.WorkDir("request-1")
+	require.NoError(t, os.MkdirAll(filepath.Join(workDir, ".git-home"), 0o700))
+	for name, content := range map[string]string{
+		"terragrunt.hcl":    "inputs = {\n  pm_password = \"pve-s3cret\"\n  cpu = 2\n}\n",
+		".terraformrc":      "credentials \"registry.example.com\" {\n  token = \"registry-t0ken\"\n}\n",
+		"terraform.tfstate": `{"resources": []}`,
+		".git-home/.netrc":  "machine git.example.com\npassword git-t0ken\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, name), []byte(content), 0o600))
+	}
+
+	configs := &memoryNodeConfigRepository{configs: map[string]*model.NodeConfig{
+		"config-1": {BaseModel: model.BaseModel{ID: "config-1"}, ResourceRequestID: "request-1"},
+		"config-2": {BaseModel: model.BaseModel{ID: "config-2"}, ResourceRequestID: "request-2"},
+	}}
+	svc := NewArtifactService(configs, executor, NewArtifactURLSigner("a-jwt-secret-of-at-least-32-characters", time.Minute), zap.NewNop())
+
+	t.Run("archive excludes secret files", func(t *testing.T) {
+		download, err := svc.SignDownload(ctx, "config-1")
+		require.NoError(t, err)
+		data, err := svc.DownloadArchive(ctx, "config-1", download.Expires, download.Signature)
+		require.NoError(t, err)
+
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		require.Len(t, archive.File, 1, "only the configuration: not .terraformrc, state or the git home")
+		assert.Equal(t, "terragrunt.hcl", archive.File[0].Name)
+		file, err := archive.File[0].Open()
+		require.NoError(t, err)
+		defer file.Close() //nolint:errcheck // read-only
+		var content bytes.Buffer
+		_, err = content.ReadFrom(file)
+		require.NoError(t, err)
+		assert.NotContains(t, content.String(), "pve-s3cret")
+		assert.Contains(t, content.String(), "cpu = 2")
+	})
+
+	t.Run("signature is checked before anything is read", func(t *testing.T) {
+		download, err := svc.SignDownload(ctx, "config-1")
+		require.NoError(t, err)
+		_, err = svc.DownloadArchive(ctx, "config-2", download.Expires, download.Signature)
+		assert.ErrorIs(t, err, ErrInvalidArtifactSignature)
+	})
+
+	t.Run("unknown node config", func(t *testing.T) {
+		_, err := svc.SignDownload(ctx, "config-missing")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("work directory removed", func(t *testing.T) {
+		download, err := svc.SignDownload(ctx, "config-2")
+		require.NoError(t, err)
+		_, err = svc.DownloadArchive(ctx, "config-2", download.Expires, download.Signature)
+		assert.ErrorIs(t, err, terraform.ErrWorkDirNotFound)
+	})
+}
@@ -0,0 +1,91 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+// pveAPIPath is the prefix of the Proxmox VE JSON API.
+const pveAPIPath = "/api2/json"
+
+// pveResponse is the envelope of every Proxmox VE API response.
+type pveResponse struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// testPVEConnection tests connection to a Proxmox VE server. An API token, whose ID has the
+// form user@realm!name, is checked by reading the server version; a user name and password
+// by requesting a login ticket. Without a credential only reachability is checked, so the
+// server refusing the anonymous request counts as success.
+func (s *settingsService) testPVEConnection(ctx context.Context, endpoint string, credential *model.Credential, opts connectionOptions) error {
+	base, err := apiBaseURL(endpoint, pveAPIPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.ConnectionTestTimeout)
+	defer cancel()
+	client := connectionTestClient(opts)
+
+	switch {
+	case credential == nil || credential.AccessKey == "":
+		resp, err := connectionTestRequest(ctx, client, http.MethodGet, base+"/version", nil, nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil
+		}
+		return pveCheckResponse(resp, "version")
+	case strings.Contains(credential.AccessKey, "!"):
+		secret := credential.SecretKey
+		if secret == "" {
+			secret = credential.Token
+		}
+		header := http.Header{"Authorization": {"PVEAPIToken=" + credential.AccessKey + "=" + secret}}
+		resp, err := connectionTestRequest(ctx, client, http.MethodGet, base+"/version", header, nil)
+		if err != nil {
+			return err
+		}
+		return pveCheckResponse(resp, "version")
+	default:
+		form := url.Values{"username": {credential.AccessKey}, "password": {credential.SecretKey}}
+		header := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+		resp, err := connectionTestRequest(ctx, client, http.MethodPost, base+"/access/ticket", header, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		return pveCheckResponse(resp, "ticket")
+	}
+}
+
+// pveCheckResponse checks that resp succeeded and that its data holds field, as the
+// version and ticket responses of Proxmox VE do. The ticket endpoint answers a failed login
+// with a 401, or on older releases a 200 with no data.
+func pveCheckResponse(resp *connectionTestResponse, field string) error {
+	if err := classifyStatus(resp.Response); err != nil {
+		return err
+	}
+
+	var envelope pveResponse
+	if err := json.Unmarshal(resp.body, &envelope); err != nil {
+		return &ConnectionError{Kind: ConnectionErrorResponse, Err: errors.New("response is not from the Proxmox VE API")}
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(envelope.Data, &data); err != nil || data[field] == nil {
+		if field == "ticket" {
+			return &ConnectionError{Kind: ConnectionErrorAuth, Err: errors.New("login was refused")}
+		}
+		return &ConnectionError{Kind: ConnectionErrorResponse, Err: fmt.Errorf("response has no %s", field)}
+	}
+	return nil
+}
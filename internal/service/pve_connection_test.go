@@ -0,0 +1,148 @@
+// Package service provides Proxmox VE connection tests.
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePVE mimics the version and ticket endpoints of the Proxmox VE API.
+func fakePVE(t *testing.T, status int) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"data":null}`)) //nolint:errcheck // test server
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api2/json/version":
+			if r.Header.Get("Authorization") != "PVEAPIToken=automation@pve!ci=0a1b2c3d" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"data":null}`)) //nolint:errcheck // test server
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"version":"8.2.4","release":"8.2","repoid":"faa83925c9641325"}}`)) //nolint:errcheck // test server
+		case r.Method == http.MethodPost && r.URL.Path == "/api2/json/access/ticket":
+			require.NoError(t, r.ParseForm())
+			if r.PostForm.Get("username") != "root@pam" || r.PostForm.Get("password") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"data":null}`)) //nolint:errcheck // test server
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"username":"root@pam","ticket":"PVE:root@pam:66B0A1B2::sig","CSRFPreventionToken":"66B0A1B2:token"}}`)) //nolint:errcheck // test server
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func connectionErrorKind(t *testing.T, err error) ConnectionErrorKind {
+	t.Helper()
+	var connErr *ConnectionError
+	require.ErrorAs(t, err, &connErr)
+	return connErr.Kind
+}
+
+func TestSettingsService_TestPVEConnection(t *testing.T) {
+	ctx := context.Background()
+	svc := &settingsService{}
+	password := &model.Credential{AccessKey: "root@pam", SecretKey: "secret"}
+	token := &model.Credential{AccessKey: "automation@pve!ci", SecretKey: "0a1b2c3d"}
+
+	server := httptest.NewServer(fakePVE(t, http.StatusOK))
+	defer server.Close()
+
+	t.Run("password login", func(t *testing.T) {
+		require.NoError(t, svc.testPVEConnection(ctx, server.URL, password, connectionOptions{}))
+		require.NoError(t, svc.testPVEConnection(ctx, server.URL+"/api2/json/", password, connectionOptions{}), "the API path may be included")
+
+		err := svc.testPVEConnection(ctx, server.URL, &model.Credential{AccessKey: "root@pam", SecretKey: "wrong"}, connectionOptions{})
+		assert.Equal(t, ConnectionErrorAuth, connectionErrorKind(t, err))
+	})
+
+	t.Run("API token", func(t *testing.T) {
+		require.NoError(t, svc.testPVEConnection(ctx, server.URL, token, connectionOptions{}))
+
+		err := svc.testPVEConnection(ctx, server.URL, &model.Credential{AccessKey: "automation@pve!ci", SecretKey: "revoked"}, connectionOptions{})
+		assert.Equal(t, ConnectionErrorAuth, connectionErrorKind(t, err))
+	})
+
+	t.Run("reachability without a credential", func(t *testing.T) {
+		require.NoError(t, svc.testPVEConnection(ctx, server.URL, nil, connectionOptions{}))
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		failing := httptest.NewServer(fakePVE(t, http.StatusServiceUnavailable))
+		defer failing.Close()
+
+		err := svc.testPVEConnection(ctx, failing.URL, password, connectionOptions{})
+		assert.Equal(t, ConnectionErrorServer, connectionErrorKind(t, err))
+		assert.Contains(t, err.Error(), "503")
+	})
+
+	t.Run("not a Proxmox VE server", func(t *testing.T) {
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("<html>It works!</html>")) //nolint:errcheck // test server
+		}))
+		defer other.Close()
+
+		err := svc.testPVEConnection(ctx, other.URL, token, connectionOptions{})
+		assert.Equal(t, ConnectionErrorResponse, connectionErrorKind(t, err))
+	})
+
+	t.Run("self-signed certificate", func(t *testing.T) {
+		tlsServer := httptest.NewTLSServer(fakePVE(t, http.StatusOK))
+		defer tlsServer.Close()
+
+		err := svc.testPVEConnection(ctx, tlsServer.URL, password, connectionOptions{})
+		assert.Equal(t, ConnectionErrorTLS, connectionErrorKind(t, err))
+
+		require.NoError(t, svc.testPVEConnection(ctx, tlsServer.URL, password, connectionOptions{Insecure: true}), "verification is skipped when configured")
+	})
+
+	t.Run("unreachable endpoint", func(t *testing.T) {
+		closed := httptest.NewServer(fakePVE(t, http.StatusOK))
+		closed.Close()
+
+		err := svc.testPVEConnection(ctx, closed.URL, password, connectionOptions{})
+		assert.Equal(t, ConnectionErrorNetwork, connectionErrorKind(t, err))
+	})
+
+	t.Run("invalid endpoint", func(t *testing.T) {
+		err := svc.testPVEConnection(ctx, "pve.example.com:8006", password, connectionOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid endpoint")
+	})
+}
+
+func TestClassifyTransportError(t *testing.T) {
+	dnsErr := &url.Error{Op: "Get", URL: "https://pve.invalid:8006/api2/json/version", Err: &net.OpError{
+		Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "pve.invalid", IsNotFound: true},
+	}}
+	assert.Equal(t, ConnectionErrorDNS, connectionErrorKind(t, classifyTransportError(dnsErr)))
+	assert.Equal(t, ConnectionErrorNetwork, connectionErrorKind(t, classifyTransportError(errors.New("connection reset by peer"))))
+}
+
+func TestParseConnectionOptions(t *testing.T) {
+	opts, err := parseConnectionOptions(`{"insecure": true, "node": "pve1"}`)
+	require.NoError(t, err)
+	assert.True(t, opts.Insecure)
+
+	opts, err = parseConnectionOptions("")
+	require.NoError(t, err)
+	assert.False(t, opts.Insecure)
+
+	_, err = parseConnectionOptions("insecure")
+	assert.Error(t, err)
+}
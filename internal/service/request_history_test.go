@@ -0,0 +1,139 @@
+// Package service provides request status history tests.
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/notification"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryStatusEventRepository keeps request status history in memory.
+type memoryStatusEventRepository struct {
+	mu     sync.Mutex
+	events []*model.RequestStatusEvent
+}
+
+func (r *memoryStatusEventRepository) Create(_ context.Context, event *model.RequestStatusEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event.Sequence = 1
+	for _, existing := range r.events {
+		if existing.RequestID == event.RequestID {
+			event.Sequence++
+		}
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *memoryStatusEventRepository) ListByRequest(_ context.Context, requestID string) ([]*model.RequestStatusEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var history []*model.RequestStatusEvent
+	for _, event := range r.events {
+		if event.RequestID == requestID {
+			history = append(history, event)
+		}
+	}
+	return history, nil
+}
+
+// transitions summarizes history as from→to pairs.
+func transitions(history []*model.RequestStatusEvent) []string {
+	out := make([]string, 0, len(history))
+	for _, event := range history {
+		out = append(out, event.FromStatus+"→"+event.ToStatus)
+	}
+	return out
+}
+
+func TestResourceService_RequestHistory(t *testing.T) {
+	requestRepo := new(MockResourceRequestRepository)
+	statusRepo := &memoryStatusEventRepository{}
+	svc, ok := NewResourceService(nil, requestRepo, statusRepo, nil, nil,
+		notification.NewService(nil, zap.NewNop()), nil, nil, 0, nil, nil, nil, nil, zap.NewNop()).(*resourceService)
+	require.True(t, ok)
+
+	provisioned := make(chan struct{})
+	svc.provision = func(ctx context.Context, request *model.ResourceRequest) error {
+		defer close(provisioned)
+		return svc.provisionResource(ctx, request)
+	}
+
+	var request *model.ResourceRequest
+	requestRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.ResourceRequest")).
+		Run(func(args mock.Arguments) {
+			request = args.Get(1).(*model.ResourceRequest) //nolint:errcheck,forcetypeassert // test mock
+			request.ID = "req-1"
+		}).Return(nil)
+	requestRepo.On("Update", mock.Anything, mock.AnythingOfType("*model.ResourceRequest")).Return(nil)
+	ctx := context.Background()
+
+	_, err := svc.CreateRequest(ctx, &CreateRequestInput{
+		Title:       "web",
+		Type:        "vm",
+		Environment: "dev",
+		Provider:    "pve",
+		Spec:        "not json", // provisioning fails before terraform runs
+		RequesterID: "user-1",
+	})
+	require.NoError(t, err)
+	requestRepo.On("GetByID", mock.Anything, "req-1").Return(request, nil)
+
+	_, err = svc.ApproveRequest(ctx, "req-1", "admin-1", "looks good")
+	require.NoError(t, err)
+	<-provisioned
+
+	history, err := svc.GetRequestHistory(ctx, "req-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"→pending", "pending→approved", "approved→provisioning", "provisioning→failed"}, transitions(history))
+	for i, event := range history {
+		assert.Equal(t, i+1, event.Sequence)
+		assert.Equal(t, "req-1", event.RequestID)
+	}
+	assert.Equal(t, "user-1", history[0].ActorID)
+	assert.Equal(t, "admin-1", history[1].ActorID)
+	assert.Equal(t, "looks good", history[1].Reason)
+	assert.Empty(t, history[2].ActorID, "provisioning transitions are made by the system")
+	assert.Contains(t, history[3].Reason, "failed to parse spec")
+}
+
+func TestResourceService_RequestHistoryAutoApproved(t *testing.T) {
+	requestRepo := new(MockResourceRequestRepository)
+	statusRepo := &memoryStatusEventRepository{}
+	policy := NewApprovalPolicy([]config.AutoApproveRule{{Name: "dev"}}, nil)
+	svc, ok := NewResourceService(nil, requestRepo, statusRepo, nil, nil, nil, policy, nil, 0, nil, nil, nil, nil, zap.NewNop()).(*resourceService)
+	require.True(t, ok)
+	svc.provision = func(context.Context, *model.ResourceRequest) error { return nil }
+
+	requestRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.ResourceRequest")).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*model.ResourceRequest).ID = "req-2" //nolint:errcheck,forcetypeassert // test mock
+		}).Return(nil)
+
+	_, err := svc.CreateRequest(context.Background(), &CreateRequestInput{Title: "web", Type: "vm", Environment: "dev", Provider: "pve", RequesterID: "user-1"})
+	require.NoError(t, err)
+
+	history, err := statusRepo.ListByRequest(context.Background(), "req-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"→pending", "pending→approved"}, transitions(history))
+	assert.Equal(t, "rule:dev", history[1].ActorID)
+}
+
+func TestResourceService_RequestHistoryNotFound(t *testing.T) {
+	requestRepo := new(MockResourceRequestRepository)
+	requestRepo.On("GetByID", mock.Anything, "missing").Return(nil, repository.ErrNotFound)
+	svc := NewResourceService(nil, requestRepo, &memoryStatusEventRepository{}, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, zap.NewNop())
+
+	_, err := svc.GetRequestHistory(context.Background(), "missing")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
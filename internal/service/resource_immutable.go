@@ -0,0 +1,81 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+// ErrImmutableField indicates an update tried to change a resource field that is fixed
+// after creation.
+var ErrImmutableField = errors.New("field cannot be changed after creation")
+
+// ImmutableFieldError names the immutable field an update tried to change. It unwraps to
+// ErrImmutableField.
+type ImmutableFieldError struct {
+	Field string
+}
+
+// Error implements the error interface.
+func (e *ImmutableFieldError) Error() string {
+	return fmt.Sprintf("%s cannot be changed after creation", e.Field)
+}
+
+// Unwrap returns ErrImmutableField so callers can match with errors.Is.
+func (e *ImmutableFieldError) Unwrap() error {
+	return ErrImmutableField
+}
+
+// checkImmutableFields rejects updates that change one of the configured immutable fields
+// of resource. Updates naming such a field with its current value are allowed, so clients
+// can send back the whole resource.
+func (s *resourceService) checkImmutableFields(resource *model.Resource, updates map[string]interface{}) error {
+	if len(s.immutableFields) == 0 || len(updates) == 0 {
+		return nil
+	}
+	current, err := jsonFields(resource)
+	if err != nil {
+		return err
+	}
+	for _, field := range s.immutableFields {
+		value, ok := updates[field]
+		if !ok {
+			continue
+		}
+		if !reflect.DeepEqual(normalizeJSON(value), current[field]) {
+			return &ImmutableFieldError{Field: field}
+		}
+	}
+	return nil
+}
+
+// jsonFields returns v's fields as they appear in its JSON encoding.
+func jsonFields(v any) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resource: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode resource: %w", err)
+	}
+	return fields, nil
+}
+
+// normalizeJSON round-trips value through JSON so it compares equal to a decoded field.
+// A value that cannot be encoded is returned unchanged.
+func normalizeJSON(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
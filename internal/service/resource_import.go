@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
+	"go.uber.org/zap"
+)
+
+// ResourceStatusImported marks a resource recorded from a provider inventory rather than
+// provisioned by the platform.
+const ResourceStatusImported = "imported"
+
+// Resource import errors.
+var (
+	ErrImportLimit        = errors.New("too many instances in import")
+	ErrInvalidImportInput = errors.New("invalid import input")
+)
+
+// ImportInstance describes one existing instance in a provider inventory.
+type ImportInstance struct {
+	ExternalID string
+	Name       string
+	IPAddress  string
+	Hostname   string
+	Spec       map[string]interface{}
+}
+
+// ImportResourcesInput represents input for importing the instances of a provider zone.
+type ImportResourcesInput struct {
+	Provider    string
+	ZoneID      string
+	Environment string
+	Type        string // defaults to vm
+	OwnerID     string
+	Instances   []ImportInstance
+
+	// TerraformImport runs terraform import for each instance so later plans manage it.
+	// CredentialID names the credential the provider is configured with.
+	TerraformImport bool
+	CredentialID    string
+}
+
+// ImportedResource reports a resource created by an import and the IP allocation its
+// address was reconciled into, if any.
+type ImportedResource struct {
+	Resource   *model.Resource     `json:"resource"`
+	Allocation *model.IPAllocation `json:"allocation,omitempty"`
+	// Warnings lists the steps that failed without failing the import of the instance.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ImportResult reports the outcome of an import for each instance.
+type ImportResult struct {
+	Imported []*ImportedResource `json:"imported"`
+	Errors   map[string]string   `json:"errors,omitempty"` // external ID to the reason it was not imported
+}
+
+// ResourceImportService records existing provider instances as platform resources.
+type ResourceImportService interface {
+	Import(ctx context.Context, input *ImportResourcesInput) (*ImportResult, error)
+}
+
+type resourceImportService struct {
+	resourceRepo      repository.ResourceRepository
+	credentialRepo    repository.CredentialRepository
+	ipamService       IPAMService
+	terraformExecutor *terraform.Executor
+	logger            *zap.Logger
+}
+
+// NewResourceImportService creates a new resource import service.
+func NewResourceImportService(
+	resourceRepo repository.ResourceRepository,
+	credentialRepo repository.CredentialRepository,
+	ipamService IPAMService,
+	terraformExecutor *terraform.Executor,
+	logger *zap.Logger,
+) ResourceImportService {
+	return &resourceImportService{
+		resourceRepo:      resourceRepo,
+		credentialRepo:    credentialRepo,
+		ipamService:       ipamService,
+		terraformExecutor: terraformExecutor,
+		logger:            logger,
+	}
+}
+
+// Import creates an imported resource for each instance and allocates its IP address from
+// the zone pool that covers it. Instances already recorded for the provider are skipped. A
+// missing pool, a failed allocation or a failed terraform import is reported as a warning
+// on the resource.
+func (s *resourceImportService) Import(ctx context.Context, input *ImportResourcesInput) (*ImportResult, error) {
+	if err := validateImportInput(input); err != nil {
+		return nil, err
+	}
+	resourceType := input.Type
+	if resourceType == "" {
+		resourceType = "vm"
+	}
+
+	var credential *model.Credential
+	if input.TerraformImport {
+		if s.terraformExecutor == nil {
+			return nil, fmt.Errorf("%w: terraform import is not available", ErrInvalidImportInput)
+		}
+		if input.CredentialID != "" {
+			var err error
+			if credential, err = s.credentialRepo.GetByID(ctx, input.CredentialID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	pools, _, err := s.ipamService.ListPools(ctx, IPPoolFilters{ZoneID: input.ZoneID}, 1, constants.MaxPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IP pools: %w", err)
+	}
+
+	result := &ImportResult{Imported: []*ImportedResource{}, Errors: map[string]string{}}
+	for i := range input.Instances {
+		instance := &input.Instances[i]
+		imported, err := s.importInstance(ctx, input, resourceType, instance)
+		if err != nil {
+			result.Errors[instance.ExternalID] = err.Error()
+			continue
+		}
+		s.reconcileIP(ctx, imported, pools)
+		if input.TerraformImport {
+			s.importState(ctx, imported, input, instance, credential)
+		}
+		result.Imported = append(result.Imported, imported)
+	}
+	return result, nil
+}
+
+func validateImportInput(input *ImportResourcesInput) error {
+	if input == nil {
+		return errors.New("input cannot be nil")
+	}
+	if input.Provider == "" || input.ZoneID == "" || input.Environment == "" {
+		return fmt.Errorf("%w: provider, zone and environment are required", ErrInvalidImportInput)
+	}
+	if len(input.Instances) > constants.MaxImportInstances {
+		return fmt.Errorf("%w: got %d, at most %d are allowed", ErrImportLimit, len(input.Instances), constants.MaxImportInstances)
+	}
+	seen := make(map[string]bool, len(input.Instances))
+	for _, instance := range input.Instances {
+		if instance.ExternalID == "" || instance.Name == "" {
+			return fmt.Errorf("%w: every instance needs an ID and a name", ErrInvalidImportInput)
+		}
+		if seen[instance.ExternalID] {
+			return fmt.Errorf("%w: instance %s is listed more than once", ErrInvalidImportInput, instance.ExternalID)
+		}
+		seen[instance.ExternalID] = true
+		if instance.IPAddress != "" && net.ParseIP(instance.IPAddress) == nil {
+			return fmt.Errorf("%w: invalid IP address %q for instance %s", ErrInvalidImportInput, instance.IPAddress, instance.ExternalID)
+		}
+	}
+	return nil
+}
+
+func (s *resourceImportService) importInstance(ctx context.Context, input *ImportResourcesInput, resourceType string, instance *ImportInstance) (*ImportedResource, error) {
+	existing, err := s.resourceRepo.GetByExternalID(ctx, input.Provider, instance.ExternalID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		s.logger.Error("failed to look up imported resource", zap.Error(err))
+		return nil, errors.New("failed to look up resource")
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("already imported as resource %s", existing.ID)
+	}
+
+	spec := "{}"
+	if len(instance.Spec) > 0 {
+		data, err := json.Marshal(instance.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spec: %w", err)
+		}
+		spec = string(data)
+	}
+
+	resource := &model.Resource{
+		Name:        instance.Name,
+		Type:        resourceType,
+		Provider:    input.Provider,
+		Environment: input.Environment,
+		Spec:        spec,
+		IPAddress:   instance.IPAddress,
+		HostName:    instance.Hostname,
+		OwnerID:     input.OwnerID,
+		ExternalID:  instance.ExternalID,
+		Status:      ResourceStatusImported,
+	}
+	stampCreated(ctx, &resource.AuditStamp)
+
+	if err := s.resourceRepo.Create(ctx, resource); err != nil {
+		s.logger.Error("failed to create imported resource", zap.Error(err))
+		return nil, errors.New("failed to create resource")
+	}
+	return &ImportedResource{Resource: resource}, nil
+}
+
+// reconcileIP allocates the resource's address from the first pool whose range covers it.
+func (s *resourceImportService) reconcileIP(ctx context.Context, imported *ImportedResource, pools []*model.IPPool) {
+	resource := imported.Resource
+	if resource.IPAddress == "" {
+		return
+	}
+	pool := poolCovering(pools, net.ParseIP(resource.IPAddress))
+	if pool == nil {
+		imported.Warnings = append(imported.Warnings, fmt.Sprintf("no IP pool in the zone covers %s", resource.IPAddress))
+		return
+	}
+
+	allocation, err := s.ipamService.AllocateIP(ctx, &AllocateIPInput{
+		PoolID:     pool.ID,
+		IPAddress:  resource.IPAddress,
+		Hostname:   resource.HostName,
+		ResourceID: resource.ID,
+	})
+	if err != nil {
+		imported.Warnings = append(imported.Warnings, fmt.Sprintf("failed to allocate %s from pool %s: %v", resource.IPAddress, pool.Name, err))
+		return
+	}
+	imported.Allocation = allocation
+}
+
+// poolCovering returns the pool whose start to end range contains ip, or nil.
+func poolCovering(pools []*model.IPPool, ip net.IP) *model.IPPool {
+	for _, pool := range pools {
+		start, end := net.ParseIP(pool.StartIP), net.ParseIP(pool.EndIP)
+		if start == nil || end == nil {
+			continue
+		}
+		if isIPInRange(ip, start, end) {
+			return pool
+		}
+	}
+	return nil
+}
+
+// importState binds the instance to the terraform configuration of its resource.
+//
+//nolint:contextcheck // terraform init doesn't use context
+func (s *resourceImportService) importState(ctx context.Context, imported *ImportedResource, input *ImportResourcesInput, instance *ImportInstance, credential *model.Credential) {
+	tfConfig := terraform.Config{
+		Provider:    input.Provider,
+		Environment: input.Environment,
+		Spec:        instance.Spec,
+	}
+	if credential != nil {
+		tfConfig.ClusterEndpoint = credential.Endpoint
+		tfConfig.ClusterUsername = credential.AccessKey
+		tfConfig.ClusterPassword = credential.SecretKey
+		tfConfig.ClusterToken = credential.Token
+	}
+
+	if err := s.runTerraformImport(ctx, imported.Resource, tfConfig); err != nil {
+		s.logger.Warn("terraform import failed",
+			zap.String("resource_id", sanitize.ForLog(imported.Resource.ID)),
+			zap.Error(err),
+		)
+		imported.Warnings = append(imported.Warnings, err.Error())
+	}
+}
+
+func (s *resourceImportService) runTerraformImport(ctx context.Context, resource *model.Resource, tfConfig terraform.Config) error {
+	address, err := terraform.InstanceAddress(tfConfig)
+	if err != nil {
+		return fmt.Errorf("terraform import: %w", err)
+	}
+	if tfConfig.Spec == nil {
+		tfConfig.Spec = map[string]interface{}{}
+	}
+
+	workDir := s.terraformExecutor.WorkDir(resource.ID)
+	defer func() {
+		if err := s.terraformExecutor.Cleanup(workDir, resource.Provider); err != nil {
+			s.logger.Warn("failed to clean up terraform work directory", zap.String("work_dir", workDir), zap.Error(err))
+		}
+	}()
+
+	if err := s.terraformExecutor.GenerateTFFiles(workDir, tfConfig); err != nil {
+		return fmt.Errorf("failed to generate terraform files: %w", err)
+	}
+	if err := s.terraformExecutor.InitWithConfig(workDir, tfConfig); err != nil {
+		return fmt.Errorf("terraform init failed: %w", err)
+	}
+	if result := s.terraformExecutor.ImportState(ctx, workDir, address, resource.ExternalID); !result.Success {
+		return fmt.Errorf("terraform import failed: %s", result.Error)
+	}
+
+	resource.ResourceAddresses = []string{address}
+	if err := s.resourceRepo.Update(ctx, resource); err != nil {
+		return fmt.Errorf("failed to record terraform address: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Package service provides resource import tests.
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/config"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestResourceImportService_Import(t *testing.T) {
+	ctx := context.Background()
+	pools := []*model.IPPool{
+		{BaseModel: model.BaseModel{ID: "pool-app"}, Name: "app", StartIP: "10.0.1.10", EndIP: "10.0.1.200"},
+		{BaseModel: model.BaseModel{ID: "pool-db"}, Name: "db", StartIP: "10.0.2.10", EndIP: "10.0.2.200"},
+	}
+	inventory := []ImportInstance{
+		{ExternalID: "pve-01/qemu/101", Name: "web-1", IPAddress: "10.0.1.21", Hostname: "web-1", Spec: map[string]interface{}{"cpu": 2, "memory": 4096}},
+		{ExternalID: "pve-01/qemu/102", Name: "db-1", IPAddress: "10.0.2.31", Hostname: "db-1"},
+		{ExternalID: "pve-01/qemu/103", Name: "edge-1", IPAddress: "192.168.9.5"},
+		{ExternalID: "pve-01/qemu/104", Name: "old-1", IPAddress: "10.0.1.22"},
+	}
+
+	resourceRepo := new(MockResourceRepository)
+	poolRepo := new(MockIPPoolRepository)
+	allocRepo := new(MockIPAllocationRepository)
+	svc := NewResourceImportService(resourceRepo, nil, NewIPAMService(poolRepo, allocRepo, config.HostnameConfig{}, 0, nil, nil, zap.NewNop()), nil, zap.NewNop())
+
+	poolRepo.On("List", ctx, repository.IPPoolFilters{ZoneID: "zone-1"}, 0, constants.MaxPageSize).Return(pools, int64(2), nil)
+	resourceRepo.On("GetByExternalID", ctx, "pve", "pve-01/qemu/104").
+		Return(&model.Resource{BaseModel: model.BaseModel{ID: "res-old"}}, nil)
+	resourceRepo.On("GetByExternalID", ctx, "pve", mock.Anything).Return(nil, repository.ErrNotFound)
+	resourceRepo.On("Create", ctx, mock.AnythingOfType("*model.Resource")).
+		Run(func(args mock.Arguments) {
+			resource := args.Get(1).(*model.Resource)
+			resource.ID = "res-" + resource.Name
+		}).Return(nil)
+	webID, dbID := "res-web-1", "res-db-1"
+	allocRepo.On("AllocateSpecific", ctx, "pool-app", "10.0.1.21", "web-1", webID).
+		Return(&model.IPAllocation{IPPoolID: "pool-app", IPAddress: "10.0.1.21", Hostname: "web-1", ResourceID: &webID}, nil)
+	allocRepo.On("AllocateSpecific", ctx, "pool-db", "10.0.2.31", "db-1", dbID).
+		Return(&model.IPAllocation{IPPoolID: "pool-db", IPAddress: "10.0.2.31", Hostname: "db-1", ResourceID: &dbID}, nil)
+
+	result, err := svc.Import(ctx, &ImportResourcesInput{
+		Provider:    "pve",
+		ZoneID:      "zone-1",
+		Environment: "dev",
+		OwnerID:     "user-1",
+		Instances:   inventory,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Imported, 3)
+	assert.Contains(t, result.Errors["pve-01/qemu/104"], "res-old", "an instance already recorded is not imported twice")
+
+	web := result.Imported[0]
+	assert.Equal(t, "web-1", web.Resource.Name)
+	assert.Equal(t, ResourceStatusImported, web.Resource.Status)
+	assert.Equal(t, "vm", web.Resource.Type)
+	assert.Equal(t, "pve-01/qemu/101", web.Resource.ExternalID)
+	assert.Equal(t, "user-1", web.Resource.OwnerID)
+	assert.JSONEq(t, `{"cpu":2,"memory":4096}`, web.Resource.Spec)
+	require.NotNil(t, web.Allocation)
+	assert.Equal(t, "pool-app", web.Allocation.IPPoolID)
+	assert.Equal(t, "10.0.1.21", web.Allocation.IPAddress)
+	assert.Equal(t, "res-web-1", *web.Allocation.ResourceID)
+	assert.Empty(t, web.Warnings)
+
+	db := result.Imported[1]
+	require.NotNil(t, db.Allocation)
+	assert.Equal(t, "pool-db", db.Allocation.IPPoolID, "each address is reconciled into the pool covering it")
+	assert.Equal(t, "db-1", db.Allocation.Hostname)
+
+	edge := result.Imported[2]
+	assert.Nil(t, edge.Allocation)
+	require.Len(t, edge.Warnings, 1)
+	assert.Contains(t, edge.Warnings[0], "no IP pool")
+
+	resourceRepo.AssertNumberOfCalls(t, "Create", 3)
+	allocRepo.AssertNumberOfCalls(t, "AllocateSpecific", 2)
+}
+
+func TestResourceImportService_ImportAllocationConflict(t *testing.T) {
+	ctx := context.Background()
+	pool := &model.IPPool{BaseModel: model.BaseModel{ID: "pool-app"}, Name: "app", StartIP: "10.0.1.10", EndIP: "10.0.1.200"}
+
+	resourceRepo := new(MockResourceRepository)
+	poolRepo := new(MockIPPoolRepository)
+	allocRepo := new(MockIPAllocationRepository)
+	svc := NewResourceImportService(resourceRepo, nil, NewIPAMService(poolRepo, allocRepo, config.HostnameConfig{}, 0, nil, nil, zap.NewNop()), nil, zap.NewNop())
+
+	poolRepo.On("List", ctx, repository.IPPoolFilters{ZoneID: "zone-1"}, 0, constants.MaxPageSize).Return([]*model.IPPool{pool}, int64(1), nil)
+	resourceRepo.On("GetByExternalID", ctx, "pve", "101").Return(nil, repository.ErrNotFound)
+	resourceRepo.On("Create", ctx, mock.AnythingOfType("*model.Resource")).Return(nil)
+	allocRepo.On("AllocateSpecific", ctx, "pool-app", "10.0.1.21", "", mock.Anything).
+		Return(nil, fmt.Errorf("%w: 10.0.1.21", repository.ErrIPInUse))
+
+	result, err := svc.Import(ctx, &ImportResourcesInput{
+		Provider: "pve", ZoneID: "zone-1", Environment: "dev", OwnerID: "user-1",
+		Instances: []ImportInstance{{ExternalID: "101", Name: "web-1", IPAddress: "10.0.1.21"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Imported, 1)
+	assert.Nil(t, result.Imported[0].Allocation)
+	require.Len(t, result.Imported[0].Warnings, 1, "the resource is kept when its address is already allocated")
+	assert.Contains(t, result.Imported[0].Warnings[0], "already allocated")
+}
+
+func TestResourceImportService_ImportValidation(t *testing.T) {
+	svc := NewResourceImportService(new(MockResourceRepository), nil, nil, nil, zap.NewNop())
+	valid := func() *ImportResourcesInput {
+		return &ImportResourcesInput{
+			Provider: "pve", ZoneID: "zone-1", Environment: "dev",
+			Instances: []ImportInstance{{ExternalID: "101", Name: "web-1", IPAddress: "10.0.1.21"}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*ImportResourcesInput)
+		want   error
+	}{
+		{"missing zone", func(in *ImportResourcesInput) { in.ZoneID = "" }, ErrInvalidImportInput},
+		{"missing instance ID", func(in *ImportResourcesInput) { in.Instances[0].ExternalID = "" }, ErrInvalidImportInput},
+		{"invalid address", func(in *ImportResourcesInput) { in.Instances[0].IPAddress = "10.0.1" }, ErrInvalidImportInput},
+		{"duplicate instance", func(in *ImportResourcesInput) { in.Instances = append(in.Instances, in.Instances[0]) }, ErrInvalidImportInput},
+		{"terraform import without an executor", func(in *ImportResourcesInput) { in.TerraformImport = true }, ErrInvalidImportInput},
+		{"too many instances", func(in *ImportResourcesInput) {
+			in.Instances = make([]ImportInstance, constants.MaxImportInstances+1)
+		}, ErrImportLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := valid()
+			tt.modify(input)
+			_, err := svc.Import(context.Background(), input)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
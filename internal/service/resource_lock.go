@@ -0,0 +1,124 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrResourceBusy is returned when another operation holds the resource's lock.
+var ErrResourceBusy = errors.New("resource is busy with another operation")
+
+// ResourceLocker serializes provisioning operations on a resource.
+type ResourceLocker interface {
+	// Acquire takes the lock on key or returns ErrResourceBusy. The returned
+	// function releases the lock and must be called exactly once.
+	Acquire(ctx context.Context, key string) (func(), error)
+}
+
+// memoryResourceLocker holds locks in process memory; suitable for a single replica.
+type memoryResourceLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+// NewMemoryResourceLocker creates a resource locker that only guards the current process.
+func NewMemoryResourceLocker() ResourceLocker {
+	return &memoryResourceLocker{held: make(map[string]bool)}
+}
+
+func (l *memoryResourceLocker) Acquire(_ context.Context, key string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[key] {
+		return nil, ErrResourceBusy
+	}
+	l.held[key] = true
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, key)
+	}, nil
+}
+
+// dbResourceLocker holds locks as leases in the shared database so that every
+// replica sees them.
+type dbResourceLocker struct {
+	repo   repository.LockRepository
+	ttl    time.Duration
+	logger *zap.Logger
+}
+
+// NewDBResourceLocker creates a resource locker backed by the resource_locks table.
+// A lease expires after ttl so a crashed holder cannot block the resource forever; a live
+// holder renews it every third of ttl, so an operation may run for longer.
+func NewDBResourceLocker(repo repository.LockRepository, ttl time.Duration, logger *zap.Logger) ResourceLocker {
+	return &dbResourceLocker{repo: repo, ttl: ttl, logger: logger}
+}
+
+func (l *dbResourceLocker) Acquire(ctx context.Context, key string) (func(), error) {
+	owner := uuid.New().String()
+	acquired, err := l.repo.TryAcquire(ctx, key, owner, time.Now().Add(l.ttl))
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrResourceBusy
+	}
+
+	// The lease outlives the request that took it, so neither renewal nor release may
+	// depend on its context
+	ctx = context.WithoutCancel(ctx)
+	stop := make(chan struct{})
+	go l.renew(ctx, key, owner, stop)
+
+	return func() {
+		close(stop)
+		if err := l.repo.Release(ctx, key, owner); err != nil {
+			l.logger.Error("failed to release resource lock", zap.String("key", sanitize.ForLog(key)), zap.Error(err))
+		}
+	}, nil
+}
+
+// renew extends owner's lease on key every third of the TTL until stop is closed. A failed
+// renewal is retried on the next tick; a lost lease is logged and ends the renewal.
+func (l *dbResourceLocker) renew(ctx context.Context, key, owner string, stop <-chan struct{}) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			renewed, err := l.repo.Renew(ctx, key, owner, time.Now().Add(l.ttl))
+			if err != nil {
+				l.logger.Warn("failed to renew resource lock", zap.String("key", sanitize.ForLog(key)), zap.Error(err))
+				continue
+			}
+			if !renewed {
+				l.logger.Error("resource lock lease was lost", zap.String("key", sanitize.ForLog(key)))
+				return
+			}
+		}
+	}
+}
+
+// provisionLockKey returns the lock key guarding a request's resource. A request
+// only ever creates its resource: the terraform workspace and state a run works
+// on are named after the request (see terraform.Executor.WorkDir), and the
+// resource record does not exist until the run completes. The request ID is
+// therefore the key of everything a provisioning run touches, from approval
+// through every retry.
+func provisionLockKey(request *model.ResourceRequest) string {
+	return "resource-request:" + request.ID
+}
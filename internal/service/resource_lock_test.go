@@ -0,0 +1,129 @@
+// Package service provides resource lock tests.
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMemoryResourceLocker(t *testing.T) {
+	locker := NewMemoryResourceLocker()
+	ctx := context.Background()
+
+	release, err := locker.Acquire(ctx, "a")
+	require.NoError(t, err)
+
+	_, err = locker.Acquire(ctx, "a")
+	require.ErrorIs(t, err, ErrResourceBusy)
+
+	releaseB, err := locker.Acquire(ctx, "b")
+	require.NoError(t, err)
+	releaseB()
+
+	release()
+	release, err = locker.Acquire(ctx, "a")
+	require.NoError(t, err)
+	release()
+}
+
+// countingLockRepository grants every lease and counts renewals.
+type countingLockRepository struct {
+	mu       sync.Mutex
+	renewals int
+	released bool
+}
+
+func (r *countingLockRepository) TryAcquire(context.Context, string, string, time.Time) (bool, error) {
+	return true, nil
+}
+
+func (r *countingLockRepository) Renew(context.Context, string, string, time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.renewals++
+	return !r.released, nil
+}
+
+func (r *countingLockRepository) Release(context.Context, string, string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.released = true
+	return nil
+}
+
+func (r *countingLockRepository) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.renewals
+}
+
+func TestDBResourceLocker_RenewsLease(t *testing.T) {
+	repo := &countingLockRepository{}
+	locker := NewDBResourceLocker(repo, 30*time.Millisecond, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	release, err := locker.Acquire(ctx, "resource-request:1")
+	require.NoError(t, err)
+	cancel() // the lease outlives the request that took it
+
+	require.Eventually(t, func() bool { return repo.count() >= 2 }, time.Second, 5*time.Millisecond,
+		"a live holder keeps renewing its lease")
+
+	release()
+	stopped := repo.count()
+	time.Sleep(50 * time.Millisecond)
+	assert.LessOrEqual(t, repo.count(), stopped+1, "renewal stops once the lock is released")
+}
+
+func TestResourceService_RetryRequestHoldsResourceLock(t *testing.T) {
+	requestRepo := new(MockResourceRequestRepository)
+	svc, ok := NewResourceService(nil, requestRepo, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, zap.NewNop()).(*resourceService)
+	require.True(t, ok)
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	done := make(chan struct{})
+	svc.provision = func(_ context.Context, request *model.ResourceRequest) error {
+		close(started)
+		<-finish
+		request.Status = "failed"
+		close(done)
+		return nil
+	}
+
+	request := &model.ResourceRequest{BaseModel: model.BaseModel{ID: "req-1"}, Status: "failed"}
+	requestRepo.On("GetByID", mock.Anything, "req-1").Return(request, nil)
+	requestRepo.On("Update", mock.Anything, request).Return(nil)
+
+	ctx := context.Background()
+	_, err := svc.RetryRequest(ctx, "req-1", "user-1")
+	require.NoError(t, err)
+	<-started
+
+	// Provisioning is still running; a second operation must be rejected
+	request.Status = "failed"
+	_, err = svc.RetryRequest(ctx, "req-1", "user-1")
+	require.ErrorIs(t, err, ErrResourceBusy)
+
+	close(finish)
+	<-done
+
+	// The lock is released once the first run returns
+	require.Eventually(t, func() bool {
+		release, lockErr := svc.locker.Acquire(ctx, provisionLockKey(request))
+		if lockErr != nil {
+			return false
+		}
+		release()
+		return true
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "failed", request.Status)
+}
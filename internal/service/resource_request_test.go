@@ -0,0 +1,262 @@
+// Package service provides resource request tests.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestResourceService_CreateRequestQuantity(t *testing.T) {
+	newInput := func(quantity int) *CreateRequestInput {
+		return &CreateRequestInput{
+			Title: "vms", Type: "vm", Environment: "dev", Provider: "pve",
+			Quantity: quantity, RequesterID: "user-1",
+		}
+	}
+
+	tests := []struct {
+		name        string
+		maxQuantity int
+		quantity    int
+		wantErr     bool
+	}{
+		{name: "within bounds", maxQuantity: 5, quantity: 3},
+		{name: "at the max", maxQuantity: 5, quantity: 5},
+		{name: "over the max", maxQuantity: 5, quantity: 6, wantErr: true},
+		{name: "default max when unset", quantity: constants.DefaultMaxRequestQuantity},
+		{name: "over the default max", quantity: constants.DefaultMaxRequestQuantity + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestRepo := new(MockResourceRequestRepository)
+			requestRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.ResourceRequest")).Return(nil)
+			svc := NewResourceService(nil, requestRepo, nil, nil, nil, nil, nil, nil, tt.maxQuantity, nil, nil, nil, nil, zap.NewNop())
+
+			request, err := svc.CreateRequest(context.Background(), newInput(tt.quantity))
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrQuantityExceeded)
+				assert.Contains(t, err.Error(), "at most")
+				requestRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.quantity, request.Quantity)
+			assert.Equal(t, "pending", request.Status)
+		})
+	}
+}
+
+func TestResourceService_CreateRequestDependencies(t *testing.T) {
+	ctx := context.Background()
+	requestRepo := new(MockResourceRequestRepository)
+	requestRepo.On("GetByID", ctx, "request-net").Return(&model.ResourceRequest{BaseModel: model.BaseModel{ID: "request-net"}}, nil)
+	requestRepo.On("GetByID", ctx, "request-missing").Return(nil, repository.ErrNotFound)
+	requestRepo.On("Create", ctx, mock.AnythingOfType("*model.ResourceRequest")).Return(nil)
+	svc := NewResourceService(nil, requestRepo, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, zap.NewNop())
+	newInput := func(requestID string) *CreateRequestInput {
+		return &CreateRequestInput{
+			Title: "web", Type: "vm", Environment: "dev", Provider: "pve", RequesterID: "user-1",
+			Dependencies: []model.NodeDependency{{Name: "net", RequestID: requestID, Inputs: map[string]string{"subnet_id": "subnet_id"}}},
+		}
+	}
+
+	request, err := svc.CreateRequest(ctx, newInput("request-net"))
+	require.NoError(t, err)
+	require.Len(t, request.Dependencies, 1)
+	assert.Equal(t, "request-net", request.Dependencies[0].RequestID)
+
+	_, err = svc.CreateRequest(ctx, newInput("request-missing"))
+	require.ErrorIs(t, err, ErrInvalidDependency)
+	assert.Contains(t, err.Error(), "does not exist")
+	requestRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestResourceService_BulkDelete(t *testing.T) {
+	ctx := context.Background()
+	resourceRepo := new(MockResourceRepository)
+	resourceRepo.On("Delete", ctx, "res-1").Return(nil)
+	resourceRepo.On("Delete", ctx, "res-busy").Return(fmt.Errorf("%w: status is running", repository.ErrResourceInUse))
+	resourceRepo.On("Delete", ctx, "res-missing").Return(repository.ErrNotFound)
+	resourceRepo.On("Delete", ctx, "res-broken").Return(errors.New("connection reset"))
+	resourceRepo.On("Delete", ctx, "res-2").Return(nil)
+	svc := NewResourceService(resourceRepo, nil, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, zap.NewNop())
+
+	result, err := svc.BulkDelete(ctx, []string{"res-1", "res-busy", "res-missing", "res-broken", "res-2", "res-1", ""})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"res-1", "res-2"}, result.Deleted)
+	assert.Equal(t, map[string]string{
+		"res-busy":    "resource is provisioning or running",
+		"res-missing": "resource not found",
+		"res-broken":  "failed to delete resource",
+	}, result.Errors)
+	resourceRepo.AssertNumberOfCalls(t, "Delete", 5)
+
+	t.Run("over the limit", func(t *testing.T) {
+		ids := make([]string, constants.MaxBulkDeleteIDs+1)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("res-%d", i)
+		}
+		_, err := svc.BulkDelete(ctx, ids)
+		require.ErrorIs(t, err, ErrBulkDeleteLimit)
+	})
+}
+
+func TestResourceService_SearchResources(t *testing.T) {
+	ctx := context.Background()
+	resourceRepo := new(MockResourceRepository)
+	svc := NewResourceService(resourceRepo, nil, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, zap.NewNop())
+
+	found := []*model.Resource{{Name: "web-01"}}
+	resourceRepo.On("Search", ctx, "10.0.0.5", repository.ResourceFilters{Environment: "prod"}, 20, 20).Return(found, int64(21), nil)
+
+	resources, total, err := svc.SearchResources(ctx, " 10.0.0.5 ", ResourceFilters{Environment: "prod"}, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, found, resources)
+	assert.Equal(t, int64(21), total)
+
+	_, _, err = svc.SearchResources(ctx, "  ", ResourceFilters{}, 1, 20)
+	require.ErrorIs(t, err, ErrEmptySearchQuery)
+	resourceRepo.AssertNumberOfCalls(t, "Search", 1)
+}
+
+func TestResourceService_UpdateImmutableFields(t *testing.T) {
+	ctx := context.Background()
+	newService := func() (ResourceService, *MockResourceRepository) {
+		resourceRepo := new(MockResourceRepository)
+		resourceRepo.On("GetByID", ctx, "res-1").Return(&model.Resource{
+			BaseModel: model.BaseModel{ID: "res-1"}, Name: "web-01", Type: "vm", Provider: "pve", Environment: "prod",
+		}, nil)
+		resourceRepo.On("ExistsByName", ctx, mock.Anything, mock.Anything, mock.Anything, "res-1").Return(false, nil)
+		resourceRepo.On("Update", ctx, mock.AnythingOfType("*model.Resource")).Return(nil)
+		return NewResourceService(resourceRepo, nil, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, zap.NewNop()), resourceRepo
+	}
+
+	t.Run("changing the provider is rejected", func(t *testing.T) {
+		svc, resourceRepo := newService()
+		_, err := svc.Update(ctx, "res-1", map[string]interface{}{"name": "web-02", "provider": "vmware"})
+		require.ErrorIs(t, err, ErrImmutableField)
+		assert.Equal(t, "provider cannot be changed after creation", err.Error())
+		resourceRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("changing the name is allowed", func(t *testing.T) {
+		svc, resourceRepo := newService()
+		_, err := svc.Update(ctx, "res-1", map[string]interface{}{"name": "web-02"})
+		require.NoError(t, err)
+		resourceRepo.AssertCalled(t, "Update", ctx, mock.MatchedBy(func(r *model.Resource) bool { return r.Name == "web-02" }))
+	})
+
+	t.Run("sending an immutable field unchanged is allowed", func(t *testing.T) {
+		svc, _ := newService()
+		_, err := svc.Update(ctx, "res-1", map[string]interface{}{"provider": "pve", "environment": "prod", "description": "web"})
+		require.NoError(t, err)
+	})
+
+	t.Run("an empty list allows every field", func(t *testing.T) {
+		resourceRepo := new(MockResourceRepository)
+		resourceRepo.On("GetByID", ctx, "res-1").Return(&model.Resource{Provider: "pve"}, nil)
+		resourceRepo.On("Update", ctx, mock.AnythingOfType("*model.Resource")).Return(nil)
+		svc := NewResourceService(resourceRepo, nil, nil, nil, nil, nil, nil, nil, 0, []string{}, nil, nil, nil, zap.NewNop())
+		_, err := svc.Update(ctx, "res-1", map[string]interface{}{"provider": "vmware"})
+		require.NoError(t, err)
+	})
+}
+
+func TestResourceService_UniqueNamesPerOwner(t *testing.T) {
+	ctx := context.Background()
+	resourceRepo := new(MockResourceRepository)
+	resourceRepo.On("ExistsByName", ctx, "owner-1", "dev", "Web-01", "").Return(true, nil)
+	resourceRepo.On("ExistsByName", ctx, "owner-2", "dev", "Web-01", "").Return(false, nil)
+	resourceRepo.On("ExistsByName", ctx, "owner-1", "dev", "web-02", "res-1").Return(true, nil)
+	resourceRepo.On("GetByID", ctx, "res-1").Return(&model.Resource{
+		BaseModel: model.BaseModel{ID: "res-1"}, Name: "web-01", OwnerID: "owner-1", Environment: "dev",
+	}, nil)
+	resourceRepo.On("Create", ctx, mock.AnythingOfType("*model.Resource")).Return(nil)
+	resourceRepo.On("Update", ctx, mock.AnythingOfType("*model.Resource")).Return(nil)
+	svc := NewResourceService(resourceRepo, nil, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, zap.NewNop())
+
+	t.Run("duplicate name within an owner is rejected", func(t *testing.T) {
+		_, err := svc.Create(ctx, &CreateResourceInput{Name: "Web-01", Type: "vm", Provider: "pve", Environment: "dev", OwnerID: "owner-1"})
+		require.ErrorIs(t, err, ErrResourceNameInUse)
+		assert.Contains(t, err.Error(), `"Web-01" in dev`)
+		resourceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("same name for another owner is allowed", func(t *testing.T) {
+		resource, err := svc.Create(ctx, &CreateResourceInput{Name: "Web-01", Type: "vm", Provider: "pve", Environment: "dev", OwnerID: "owner-2"})
+		require.NoError(t, err)
+		assert.Equal(t, "Web-01", resource.Name)
+	})
+
+	t.Run("renaming onto a taken name is rejected", func(t *testing.T) {
+		_, err := svc.Update(ctx, "res-1", map[string]interface{}{"name": "web-02"})
+		require.ErrorIs(t, err, ErrResourceNameInUse)
+		resourceRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("changing only the case of a name is allowed", func(t *testing.T) {
+		resource, err := svc.Update(ctx, "res-1", map[string]interface{}{"name": "WEB-01"})
+		require.NoError(t, err)
+		assert.Equal(t, "WEB-01", resource.Name)
+	})
+}
+
+func TestOutputStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs map[string]string
+		want    string
+	}{
+		{name: "ready", outputs: map[string]string{"vm_ip": "10.0.0.5", "ready": "true"}, want: "running"},
+		{name: "not ready", outputs: map[string]string{"vm_ip": "10.0.0.5", "ready": "false"}, want: "degraded"},
+		{name: "ready wins over status", outputs: map[string]string{"ready": "true", "status": "degraded"}, want: "running"},
+		{name: "degraded status", outputs: map[string]string{"status": "Degraded"}, want: "degraded"},
+		{name: "partial status", outputs: map[string]string{"status": "partial"}, want: "degraded"},
+		{name: "provider status is not a health report", outputs: map[string]string{"status": "poweredOn"}, want: "running"},
+		{name: "unparseable ready falls back to status", outputs: map[string]string{"ready": "soon", "status": "unhealthy"}, want: "degraded"},
+		{name: "no health outputs", outputs: map[string]string{"vm_ip": "10.0.0.5"}, want: "running"},
+		{name: "no outputs", outputs: nil, want: "running"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, outputStatus(tt.outputs))
+		})
+	}
+}
+
+func TestResourceService_BulkDeleteRequests(t *testing.T) {
+	ctx := context.Background()
+	requestRepo := new(MockResourceRequestRepository)
+	for id, status := range map[string]string{
+		"req-pending":      "pending",
+		"req-failed":       "failed",
+		"req-provisioning": "provisioning",
+		"req-completed":    "completed",
+	} {
+		requestRepo.On("GetByID", ctx, id).Return(&model.ResourceRequest{BaseModel: model.BaseModel{ID: id}, Status: status}, nil)
+	}
+	requestRepo.On("GetByID", ctx, "req-missing").Return(nil, repository.ErrNotFound)
+	requestRepo.On("Delete", ctx, "req-pending").Return(nil)
+	requestRepo.On("Delete", ctx, "req-failed").Return(nil)
+	svc := NewResourceService(nil, requestRepo, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, nil, zap.NewNop())
+
+	result, err := svc.BulkDeleteRequests(ctx, []string{"req-pending", "req-provisioning", "req-missing", "req-completed", "req-failed"}, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"req-pending", "req-failed"}, result.Deleted)
+	require.Len(t, result.Errors, 3)
+	assert.Equal(t, "request not found", result.Errors["req-missing"])
+	assert.Contains(t, result.Errors["req-provisioning"], "provisioning status")
+	assert.Contains(t, result.Errors["req-completed"], "completed status")
+	requestRepo.AssertNotCalled(t, "Delete", ctx, "req-provisioning")
+	requestRepo.AssertNotCalled(t, "Delete", ctx, "req-completed")
+}
@@ -7,9 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/events"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/notification"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
@@ -21,14 +23,35 @@ import (
 // ErrInvalidRequestStatus indicates an invalid request status transition.
 var ErrInvalidRequestStatus = errors.New("invalid request status")
 
+// ErrQuantityExceeded indicates a resource request asks for more nodes than allowed.
+var ErrQuantityExceeded = errors.New("request quantity exceeds the maximum")
+
+// ErrEmptySearchQuery indicates a resource search was made without a query.
+var ErrEmptySearchQuery = errors.New("search query cannot be empty")
+
+// ErrResourceNameInUse indicates the owner already has a resource of that name in the
+// environment.
+var ErrResourceNameInUse = errors.New("resource name already in use")
+
+// ErrBulkDeleteLimit indicates a bulk delete names more IDs than allowed.
+var ErrBulkDeleteLimit = errors.New("too many IDs in bulk delete")
+
+// BulkDeleteResult reports the outcome of a bulk delete for each requested ID.
+type BulkDeleteResult struct {
+	Deleted []string          `json:"deleted"`
+	Errors  map[string]string `json:"errors,omitempty"` // ID to the reason it was not deleted
+}
+
 // ResourceService provides resource-related business operations.
 type ResourceService interface {
 	// Resource operations
 	Create(ctx context.Context, input *CreateResourceInput) (*model.Resource, error)
 	GetByID(ctx context.Context, id string) (*model.Resource, error)
 	List(ctx context.Context, filters ResourceFilters, page, pageSize int) ([]*model.Resource, int64, error)
+	SearchResources(ctx context.Context, q string, filters ResourceFilters, page, pageSize int) ([]*model.Resource, int64, error)
 	Update(ctx context.Context, id string, updates map[string]interface{}) (*model.Resource, error)
 	Delete(ctx context.Context, id string) error
+	BulkDelete(ctx context.Context, ids []string) (*BulkDeleteResult, error)
 
 	// Resource request operations
 	CreateRequest(ctx context.Context, input *CreateRequestInput) (*model.ResourceRequest, error)
@@ -38,35 +61,76 @@ type ResourceService interface {
 	RejectRequest(ctx context.Context, id, approverID, reason string) (*model.ResourceRequest, error)
 	RetryRequest(ctx context.Context, id, userID string) (*model.ResourceRequest, error)
 	DeleteRequest(ctx context.Context, id, userID string) error
+	BulkDeleteRequests(ctx context.Context, ids []string, userID string) (*BulkDeleteResult, error)
+	GetRequestHistory(ctx context.Context, id string) ([]*model.RequestStatusEvent, error)
+	GetRequestOutputsRaw(ctx context.Context, id string) (json.RawMessage, error)
 }
 
 // resourceService implements ResourceService.
 type resourceService struct {
 	resourceRepo        repository.ResourceRepository
 	resourceRequestRepo repository.ResourceRequestRepository
+	statusEventRepo     repository.RequestStatusEventRepository
 	gitRepoRepo         repository.GitRepoRepository
 	terraformExecutor   *terraform.Executor
 	notificationService notification.Service
+	approvalPolicy      *ApprovalPolicy
+	approvers           ApprovalAuthorizer
+	maxQuantity         int
+	immutableFields     []string // JSON names of resource fields updates may not change
+	eventBus            *events.Bus
+	locker              ResourceLocker
+	preferences         UserPreferenceSource
 	logger              *zap.Logger
+
+	// provision runs the provisioning workflow; replaced in tests.
+	provision func(ctx context.Context, request *model.ResourceRequest) error
 }
 
 // NewResourceService creates a new resource service.
 func NewResourceService(
 	resourceRepo repository.ResourceRepository,
 	resourceRequestRepo repository.ResourceRequestRepository,
+	statusEventRepo repository.RequestStatusEventRepository,
 	gitRepoRepo repository.GitRepoRepository,
 	terraformExecutor *terraform.Executor,
 	notificationService notification.Service,
+	approvalPolicy *ApprovalPolicy,
+	approvers ApprovalAuthorizer,
+	maxQuantity int,
+	immutableFields []string,
+	eventBus *events.Bus,
+	locker ResourceLocker,
+	preferences UserPreferenceSource,
 	logger *zap.Logger,
 ) ResourceService {
-	return &resourceService{
+	if locker == nil {
+		locker = NewMemoryResourceLocker()
+	}
+	if maxQuantity <= 0 {
+		maxQuantity = constants.DefaultMaxRequestQuantity
+	}
+	if immutableFields == nil {
+		immutableFields = constants.DefaultImmutableResourceFields()
+	}
+	s := &resourceService{
 		resourceRepo:        resourceRepo,
 		resourceRequestRepo: resourceRequestRepo,
+		statusEventRepo:     statusEventRepo,
 		gitRepoRepo:         gitRepoRepo,
 		terraformExecutor:   terraformExecutor,
 		notificationService: notificationService,
+		approvalPolicy:      approvalPolicy,
+		approvers:           approvers,
+		maxQuantity:         maxQuantity,
+		immutableFields:     immutableFields,
+		eventBus:            eventBus,
+		locker:              locker,
+		preferences:         preferences,
 		logger:              logger,
 	}
+	s.provision = s.provisionResource
+	return s
 }
 
 // CreateResourceInput represents input for resource creation.
@@ -89,21 +153,35 @@ type ResourceFilters struct {
 	OwnerID     string
 }
 
+// toRepository converts f to the repository's filters.
+func (f ResourceFilters) toRepository() repository.ResourceFilters {
+	return repository.ResourceFilters{
+		Type:        f.Type,
+		Provider:    f.Provider,
+		Status:      f.Status,
+		Environment: f.Environment,
+		OwnerID:     f.OwnerID,
+	}
+}
+
 // CreateRequestInput represents input for resource request creation.
 type CreateRequestInput struct {
-	Title        string
-	Description  string
-	Type         string // vm, container, bare_metal
-	Environment  string
-	Provider     string
-	RegionID     *string
-	ZoneID       *string
-	TfProviderID *string // Selected Terraform provider
-	TfModuleID   *string // Selected Terraform module
-	CredentialID *string // Selected credential for access
-	Spec         string
-	Quantity     int
-	RequesterID  string
+	Title          string
+	Description    string
+	Type           string // vm, container, bare_metal
+	Environment    string
+	Provider       string
+	RegionID       *string
+	ZoneID         *string
+	TfProviderID   *string // Selected Terraform provider
+	TfModuleID     *string // Selected Terraform module
+	CredentialID   *string // Selected credential for access
+	Spec           string
+	VarOverrides   map[string]string // Module variables set beyond the spec
+	Dependencies   []model.NodeDependency
+	Quantity       int
+	RequesterID    string
+	RequesterRoles []string // Used to evaluate auto-approval rules
 }
 
 // RequestFilters represents filters for request listing.
@@ -124,8 +202,11 @@ func (s *resourceService) Create(ctx context.Context, input *CreateResourceInput
 	if input.Type == "" {
 		return nil, errors.New("type is required")
 	}
-	if input.Provider == "" {
-		return nil, errors.New("provider is required")
+	if err := s.applyResourceDefaults(ctx, input); err != nil {
+		return nil, err
+	}
+	if err := s.checkResourceName(ctx, input.OwnerID, input.Environment, input.Name, ""); err != nil {
+		return nil, err
 	}
 
 	resource := &model.Resource{
@@ -138,6 +219,7 @@ func (s *resourceService) Create(ctx context.Context, input *CreateResourceInput
 		OwnerID:     input.OwnerID,
 		Status:      "active",
 	}
+	stampCreated(ctx, &resource.AuditStamp)
 
 	if err := s.resourceRepo.Create(ctx, resource); err != nil {
 		s.logger.Error("failed to create resource", zap.Error(err))
@@ -147,6 +229,21 @@ func (s *resourceService) Create(ctx context.Context, input *CreateResourceInput
 	return resource, nil
 }
 
+// checkResourceName returns ErrResourceNameInUse when the owner already has another
+// resource named name in environment. Names differing only in case count as the same, so
+// name-based lookups stay unambiguous.
+func (s *resourceService) checkResourceName(ctx context.Context, ownerID, environment, name, excludeID string) error {
+	exists, err := s.resourceRepo.ExistsByName(ctx, ownerID, environment, name, excludeID)
+	if err != nil {
+		s.logger.Error("failed to check resource name", zap.Error(err))
+		return errors.New("failed to check resource name")
+	}
+	if exists {
+		return fmt.Errorf("%w: %q in %s", ErrResourceNameInUse, name, environment)
+	}
+	return nil
+}
+
 // GetByID gets a resource by ID.
 func (s *resourceService) GetByID(ctx context.Context, id string) (*model.Resource, error) {
 	if id == "" {
@@ -167,6 +264,24 @@ func (s *resourceService) GetByID(ctx context.Context, id string) (*model.Resour
 
 // List lists resources with filters and pagination.
 func (s *resourceService) List(ctx context.Context, filters ResourceFilters, page, pageSize int) ([]*model.Resource, int64, error) {
+	offset, limit := resourcePage(page, pageSize)
+	return s.resourceRepo.List(ctx, filters.toRepository(), offset, limit)
+}
+
+// SearchResources returns the resources matching q by name, hostname, IP address, tag or
+// spec value, best matches first.
+func (s *resourceService) SearchResources(ctx context.Context, q string, filters ResourceFilters, page, pageSize int) ([]*model.Resource, int64, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, 0, ErrEmptySearchQuery
+	}
+	offset, limit := resourcePage(page, pageSize)
+	return s.resourceRepo.Search(ctx, q, filters.toRepository(), offset, limit)
+}
+
+// resourcePage converts a page number and size into an offset and limit, clamping both
+// to their allowed ranges.
+func resourcePage(page, pageSize int) (offset, limit int) {
 	if page < 1 {
 		page = 1
 	}
@@ -176,18 +291,7 @@ func (s *resourceService) List(ctx context.Context, filters ResourceFilters, pag
 	if pageSize > constants.MaxPageSize {
 		pageSize = constants.MaxPageSize
 	}
-
-	offset := (page - 1) * pageSize
-
-	repoFilters := repository.ResourceFilters{
-		Type:        filters.Type,
-		Provider:    filters.Provider,
-		Status:      filters.Status,
-		Environment: filters.Environment,
-		OwnerID:     filters.OwnerID,
-	}
-
-	return s.resourceRepo.List(ctx, repoFilters, offset, pageSize)
+	return (page - 1) * pageSize, pageSize
 }
 
 // Update updates a resource.
@@ -204,9 +308,17 @@ func (s *resourceService) Update(ctx context.Context, id string, updates map[str
 		}
 		return nil, err
 	}
+	if err := s.checkImmutableFields(resource, updates); err != nil {
+		return nil, err
+	}
 
 	// Filter allowed updates and apply to resource
 	if name, ok := updates["name"].(string); ok && name != "" {
+		if !strings.EqualFold(name, resource.Name) {
+			if err := s.checkResourceName(ctx, resource.OwnerID, resource.Environment, name, resource.ID); err != nil {
+				return nil, err
+			}
+		}
 		resource.Name = name
 	}
 	if desc, ok := updates["description"].(string); ok {
@@ -221,6 +333,7 @@ func (s *resourceService) Update(ctx context.Context, id string, updates map[str
 	if tags, ok := updates["tags"].(string); ok {
 		resource.Tags = tags
 	}
+	stampUpdated(ctx, &resource.AuditStamp)
 
 	if err := s.resourceRepo.Update(ctx, resource); err != nil {
 		s.logger.Error("failed to update resource", zap.Error(err))
@@ -236,16 +349,11 @@ func (s *resourceService) Delete(ctx context.Context, id string) error {
 		return errors.New("id cannot be empty")
 	}
 
-	// Verify resource exists
-	_, err := s.resourceRepo.GetByID(ctx, id)
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return repository.ErrNotFound
-		}
-		return err
-	}
-
+	// The repository releases the resource's IP allocations and refuses resources in use
 	if err := s.resourceRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrResourceInUse) {
+			return err
+		}
 		s.logger.Error("failed to delete resource", zap.Error(err))
 		return errors.New("failed to delete resource")
 	}
@@ -253,6 +361,21 @@ func (s *resourceService) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// BulkDelete deletes each of the given resources with the same checks as Delete. Every
+// resource is deleted in its own transaction, so one that is refused does not stop the rest.
+func (s *resourceService) BulkDelete(ctx context.Context, ids []string) (*BulkDeleteResult, error) {
+	return bulkDelete(ids, func(id string) error {
+		err := s.Delete(ctx, id)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return errors.New("resource not found")
+		case errors.Is(err, repository.ErrResourceInUse):
+			return errors.New("resource is provisioning or running")
+		}
+		return err
+	})
+}
+
 // CreateRequest creates a resource request.
 func (s *resourceService) CreateRequest(ctx context.Context, input *CreateRequestInput) (*model.ResourceRequest, error) {
 	if input == nil {
@@ -267,6 +390,21 @@ func (s *resourceService) CreateRequest(ctx context.Context, input *CreateReques
 	if input.Type == "" {
 		return nil, errors.New("type is required")
 	}
+	if err := s.applyRequestDefaults(ctx, input); err != nil {
+		return nil, err
+	}
+	if input.Quantity > s.maxQuantity {
+		return nil, fmt.Errorf("%w: asked for %d nodes, at most %d are allowed per request", ErrQuantityExceeded, input.Quantity, s.maxQuantity)
+	}
+	if err := validateVarOverrides(input.VarOverrides); err != nil {
+		return nil, err
+	}
+	if err := scanSpecJSONSecrets(input.Spec); err != nil {
+		return nil, err
+	}
+	if err := s.checkRequestDependencies(ctx, input.Dependencies); err != nil {
+		return nil, err
+	}
 
 	request := &model.ResourceRequest{
 		Title:        input.Title,
@@ -280,16 +418,51 @@ func (s *resourceService) CreateRequest(ctx context.Context, input *CreateReques
 		TfModuleID:   input.TfModuleID,
 		CredentialID: input.CredentialID,
 		Spec:         input.Spec,
+		VarOverrides: input.VarOverrides,
+		Dependencies: input.Dependencies,
 		Quantity:     input.Quantity,
 		RequesterID:  input.RequesterID,
 		Status:       "pending",
 	}
+	stampCreated(ctx, &request.AuditStamp)
+
+	decision := s.approvalPolicy.Decide(input)
+	request.ApprovalPolicy = decision.Policy
+	if decision.AutoApproved {
+		now := model.Now()
+		request.Status = "approved"
+		request.ApprovedAt = &now
+		request.AutoApprovedBy = decision.Approver
+		request.Reason = decision.Reason
+	}
 
 	if err := s.resourceRequestRepo.Create(ctx, request); err != nil {
 		s.logger.Error("failed to create request", zap.Error(err))
 		return nil, errors.New("failed to create request")
 	}
 
+	s.recordStatusChange(ctx, request, "", "pending", input.RequesterID, "")
+	s.publishRequestEvent(ctx, events.RequestCreated, request)
+
+	if decision.AutoApproved {
+		s.recordStatusChange(ctx, request, "pending", request.Status, request.AutoApprovedBy, request.Reason)
+		s.publishRequestEvent(ctx, events.RequestApproved, request)
+		s.logger.Info("resource request auto-approved",
+			zap.String("request_id", sanitize.ForLog(request.ID)),
+			zap.String("approver", sanitize.ForLog(request.AutoApprovedBy)),
+		)
+		if s.notificationService != nil {
+			if err := s.notificationService.NotifyResourceRequestApproved(ctx, request.RequesterID, request.ID, request.Title, request.Reason); err != nil {
+				s.logger.Error("failed to send approval notification", zap.Error(err))
+			}
+		}
+		if release, err := s.lockProvisioning(ctx, request); err != nil {
+			s.logger.Error("auto-approved request was not provisioned", zap.String("request_id", sanitize.ForLog(request.ID)), zap.Error(err))
+		} else {
+			s.startProvisioning(ctx, request, release)
+		}
+	}
+
 	return request, nil
 }
 
@@ -355,32 +528,215 @@ func (s *resourceService) ApproveRequest(ctx context.Context, id, approverID, re
 		return nil, ErrInvalidRequestStatus
 	}
 
-	now := time.Now()
+	onBehalfOf, err := s.authorizeApproval(ctx, approverID, request)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := s.lockProvisioning(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	now := model.Now()
 	request.Status = "approved"
 	request.ApproverID = &approverID
+	request.ApprovedOnBehalfOfID = nil
+	if onBehalfOf != "" {
+		request.ApprovedOnBehalfOfID = &onBehalfOf
+	}
 	request.ApprovedAt = &now
 	request.Reason = reason
+	stampUpdated(ctx, &request.AuditStamp)
 
 	if err := s.resourceRequestRepo.Update(ctx, request); err != nil {
+		release()
 		s.logger.Error("failed to approve request", zap.Error(err))
+		if errors.Is(err, repository.ErrTransactionConflict) {
+			return nil, err
+		}
 		return nil, errors.New("failed to approve request")
 	}
+	s.recordStatusChange(ctx, request, "pending", request.Status, approverID, reason)
 
 	// Send approval notification
 	if err := s.notificationService.NotifyResourceRequestApproved(ctx, request.RequesterID, request.ID, request.Title, reason); err != nil {
 		s.logger.Error("failed to send approval notification", zap.Error(err))
 	}
 
-	// Start provisioning asynchronously
+	s.publishRequestEvent(ctx, events.RequestApproved, request)
+	s.startProvisioning(ctx, request, release)
+
+	return s.resourceRequestRepo.GetByID(ctx, id)
+}
+
+// authorizeApproval returns the user approverID approves on behalf of, if any. Without an
+// authorizer every caller may approve.
+func (s *resourceService) authorizeApproval(ctx context.Context, approverID string, request *model.ResourceRequest) (string, error) {
+	if s.approvers == nil {
+		return "", nil
+	}
+	onBehalfOf, err := s.approvers.AuthorizeApproval(ctx, approverID, request)
+	if err != nil {
+		return "", err
+	}
+	if onBehalfOf != "" {
+		s.logger.Info("request approved under delegation",
+			zap.String("request_id", sanitize.ForLog(request.ID)),
+			zap.String("approver_id", sanitize.ForLog(approverID)),
+			zap.String("on_behalf_of", sanitize.ForLog(onBehalfOf)),
+		)
+	}
+	return onBehalfOf, nil
+}
+
+// GetRequestOutputsRaw returns the terraform outputs of a completed request as the
+// unflattened `output -json` document, with sensitive values redacted.
+func (s *resourceService) GetRequestOutputsRaw(ctx context.Context, id string) (json.RawMessage, error) {
+	request, err := s.GetRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != "completed" {
+		return nil, fmt.Errorf("%w: outputs are only available for completed requests", ErrInvalidRequestStatus)
+	}
+	return s.terraformExecutor.GetOutputsRaw(ctx, s.terraformExecutor.WorkDir(request.ID))
+}
+
+// GetRequestHistory returns the status transitions of a resource request, oldest first.
+func (s *resourceService) GetRequestHistory(ctx context.Context, id string) ([]*model.RequestStatusEvent, error) {
+	if _, err := s.GetRequest(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.statusEventRepo.ListByRequest(ctx, id)
+}
+
+// recordStatusChange appends a transition to the request's status history. The status
+// itself is already saved, so a failure here is logged rather than returned.
+func (s *resourceService) recordStatusChange(ctx context.Context, request *model.ResourceRequest, from, to, actorID, reason string) {
+	if s.statusEventRepo == nil {
+		return
+	}
+	event := &model.RequestStatusEvent{
+		RequestID:  request.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Reason:     reason,
+	}
+	if err := s.statusEventRepo.Create(ctx, event); err != nil {
+		s.logger.Error("failed to record request status change",
+			zap.String("request_id", sanitize.ForLog(request.ID)),
+			zap.String("to", to),
+			zap.Error(err),
+		)
+	}
+}
+
+// publishRequestEvent publishes a domain event about a resource request.
+func (s *resourceService) publishRequestEvent(ctx context.Context, eventType events.Type, request *model.ResourceRequest) {
+	s.eventBus.Publish(ctx, requestEvent(ctx, eventType, request))
+}
+
+// publishProvisionedEvent publishes the completion of a request's provisioning with the
+// resource's name, IP address and outputs. Sensitive outputs are left out because the event
+// is delivered to external webhooks.
+func (s *resourceService) publishProvisionedEvent(ctx context.Context, request *model.ResourceRequest, resourceName string, outputs map[string]string, sensitive []string) {
+	event := requestEvent(ctx, events.ResourceProvisioned, request)
+	public := notification.PublicOutputs(outputs, sensitive)
+	event.Data["resource_name"] = resourceName
+	event.Data["ip"] = outputIP(public)
+	event.Data["outputs"] = public
+	s.eventBus.Publish(ctx, event)
+}
+
+// provisionedIPOutputs are the output names that carry a provisioned machine's address, in
+// order of preference.
+var provisionedIPOutputs = []string{"vm_ip", "instance_ip", "ip_address", "ip"}
+
+// outputIP returns the machine address found in outputs, or "" when there is none.
+func outputIP(outputs map[string]string) string {
+	for _, name := range provisionedIPOutputs {
+		if ip := outputs[name]; ip != "" {
+			return ip
+		}
+	}
+	return ""
+}
+
+// Statuses of a resource whose apply succeeded.
+const (
+	resourceStatusRunning  = "running"
+	resourceStatusDegraded = "degraded" // Applied, but the module reports it is not fully working
+)
+
+// degradedStatusOutputs are the values of a "status" output that mark a resource degraded,
+// compared case-insensitively.
+var degradedStatusOutputs = []string{"degraded", "unhealthy", "partial"}
+
+// outputStatus returns the status of a resource applied with outputs. A module reports its
+// health through a "ready" output, true or false, or else a "status" output; without either,
+// or with a value it does not recognize, the resource is running.
+func outputStatus(outputs map[string]string) string {
+	if ready, err := strconv.ParseBool(strings.TrimSpace(outputs["ready"])); err == nil {
+		if ready {
+			return resourceStatusRunning
+		}
+		return resourceStatusDegraded
+	}
+	status := strings.TrimSpace(outputs["status"])
+	for _, degraded := range degradedStatusOutputs {
+		if strings.EqualFold(status, degraded) {
+			return resourceStatusDegraded
+		}
+	}
+	return resourceStatusRunning
+}
+
+// requestEvent builds a domain event about a resource request.
+func requestEvent(ctx context.Context, eventType events.Type, request *model.ResourceRequest) events.Event {
+	event := events.Event{
+		Type:      eventType,
+		ActorID:   UserIDFromContext(ctx),
+		RequestID: request.ID,
+		Data: map[string]interface{}{
+			"requester_id": request.RequesterID,
+			"status":       request.Status,
+			"environment":  request.Environment,
+			"provider":     request.Provider,
+		},
+	}
+	if request.ResourceID != nil {
+		event.ResourceID = *request.ResourceID
+	}
+	return event
+}
+
+// lockProvisioning takes the lock that keeps a second provisioning run off the
+// same resource. It returns ErrResourceBusy if one is already running.
+func (s *resourceService) lockProvisioning(ctx context.Context, request *model.ResourceRequest) (func(), error) {
+	release, err := s.locker.Acquire(ctx, provisionLockKey(request))
+	if err != nil {
+		if errors.Is(err, ErrResourceBusy) {
+			return nil, err
+		}
+		s.logger.Error("failed to acquire resource lock", zap.String("request_id", sanitize.ForLog(request.ID)), zap.Error(err))
+		return nil, errors.New("failed to acquire resource lock")
+	}
+	return release, nil
+}
+
+// startProvisioning runs provisioning for an approved request asynchronously
+// and releases the request's lock when it finishes.
+func (s *resourceService) startProvisioning(ctx context.Context, request *model.ResourceRequest, release func()) {
 	// lgtm [go/uncontrolled-resource-consumption]
 	go func() { //nolint:contextcheck // intentionally using background context for async operation
+		defer release()
 		bgCtx := context.WithoutCancel(ctx)
-		if err := s.provisionResource(bgCtx, request); err != nil {
+		if err := s.provision(bgCtx, request); err != nil {
 			s.logger.Error("failed to provision resource", zap.String("request_id", sanitize.ForLog(request.ID)), zap.Error(err))
 		}
 	}()
-
-	return s.resourceRequestRepo.GetByID(ctx, id)
 }
 
 // RejectRequest rejects a resource request.
@@ -407,22 +763,26 @@ func (s *resourceService) RejectRequest(ctx context.Context, id, approverID, rea
 		return nil, ErrInvalidRequestStatus
 	}
 
-	now := time.Now()
+	now := model.Now()
 	request.Status = "rejected"
 	request.ApproverID = &approverID
 	request.RejectedAt = &now
 	request.Reason = reason
+	stampUpdated(ctx, &request.AuditStamp)
 
 	if err := s.resourceRequestRepo.Update(ctx, request); err != nil {
 		s.logger.Error("failed to reject request", zap.Error(err))
 		return nil, errors.New("failed to reject request")
 	}
+	s.recordStatusChange(ctx, request, "pending", request.Status, approverID, reason)
 
 	// Send rejection notification
 	if err := s.notificationService.NotifyResourceRequestRejected(ctx, request.RequesterID, request.ID, request.Title, reason); err != nil {
 		s.logger.Error("failed to send rejection notification", zap.Error(err))
 	}
 
+	s.publishRequestEvent(ctx, events.RequestRejected, request)
+
 	return s.resourceRequestRepo.GetByID(ctx, id)
 }
 
@@ -440,8 +800,15 @@ func (s *resourceService) RetryRequest(ctx context.Context, id, userID string) (
 		return nil, err
 	}
 
+	// Hold the lock across the status check so concurrent retries cannot both start
+	release, err := s.lockProvisioning(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
 	// Only failed requests can be retried
 	if request.Status != "failed" {
+		release()
 		return nil, ErrInvalidRequestStatus
 	}
 
@@ -451,28 +818,21 @@ func (s *resourceService) RetryRequest(ctx context.Context, id, userID string) (
 	request.ProvisionLog = ""
 	request.ProvisionStartedAt = nil
 	request.ProvisionCompletedAt = nil
+	stampUpdated(ctx, &request.AuditStamp)
 
 	if err := s.resourceRequestRepo.Update(ctx, request); err != nil {
+		release()
 		s.logger.Error("failed to reset request for retry", zap.Error(err))
 		return nil, errors.New("failed to reset request for retry")
 	}
+	s.recordStatusChange(ctx, request, "failed", request.Status, userID, "retry")
 
 	s.logger.Info("retrying resource provisioning",
 		zap.String("request_id", sanitize.ForLog(id)),
 		zap.String("user_id", sanitize.ForLog(userID)),
 	)
 
-	// Start provisioning in background
-	// lgtm [go/uncontrolled-resource-consumption]
-	go func() { //nolint:contextcheck // intentionally using background context for async operation
-		bgCtx := context.WithoutCancel(ctx)
-		if err := s.provisionResource(bgCtx, request); err != nil {
-			s.logger.Error("resource provisioning retry failed",
-				zap.String("request_id", sanitize.ForLog(id)),
-				zap.Error(err),
-			)
-		}
-	}()
+	s.startProvisioning(ctx, request, release)
 
 	return s.resourceRequestRepo.GetByID(ctx, id)
 }
@@ -494,7 +854,7 @@ func (s *resourceService) DeleteRequest(ctx context.Context, id, userID string)
 	// Only pending, rejected, or failed requests can be deleted
 	// Completed or provisioning requests cannot be deleted (resource already exists)
 	if request.Status == "provisioning" || request.Status == "completed" {
-		return errors.New("cannot delete request in current status")
+		return fmt.Errorf("%w: cannot delete request in %s status", ErrInvalidRequestStatus, request.Status)
 	}
 
 	s.logger.Info("deleting resource request",
@@ -506,6 +866,50 @@ func (s *resourceService) DeleteRequest(ctx context.Context, id, userID string)
 	return s.resourceRequestRepo.Delete(ctx, id)
 }
 
+// BulkDeleteRequests deletes each of the given requests with the same checks as
+// DeleteRequest, reporting the outcome per request.
+func (s *resourceService) BulkDeleteRequests(ctx context.Context, ids []string, userID string) (*BulkDeleteResult, error) {
+	return bulkDelete(ids, func(id string) error {
+		err := s.DeleteRequest(ctx, id, userID)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, repository.ErrNotFound):
+			return errors.New("request not found")
+		case errors.Is(err, ErrInvalidRequestStatus):
+			return err
+		}
+		s.logger.Error("failed to delete request", zap.String("request_id", sanitize.ForLog(id)), zap.Error(err))
+		return errors.New("failed to delete request")
+	})
+}
+
+// bulkDelete calls del once for each distinct, non-empty ID and collects the outcomes.
+func bulkDelete(ids []string, del func(id string) error) (*BulkDeleteResult, error) {
+	if len(ids) > constants.MaxBulkDeleteIDs {
+		return nil, fmt.Errorf("%w: got %d, at most %d are allowed", ErrBulkDeleteLimit, len(ids), constants.MaxBulkDeleteIDs)
+	}
+
+	result := &BulkDeleteResult{Deleted: []string{}}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if err := del(id); err != nil {
+			if result.Errors == nil {
+				result.Errors = make(map[string]string)
+			}
+			result.Errors[id] = err.Error()
+			continue
+		}
+		result.Deleted = append(result.Deleted, id)
+	}
+	return result, nil
+}
+
 // provisionResource handles the Terraform provisioning workflow.
 func (s *resourceService) provisionResource(ctx context.Context, request *model.ResourceRequest) error {
 	s.logger.Info("starting resource provisioning", zap.String("request_id", sanitize.ForLog(request.ID)))
@@ -519,13 +923,15 @@ func (s *resourceService) provisionResource(ctx context.Context, request *model.
 	request = fullRequest
 
 	// Update status to provisioning
-	now := time.Now()
+	now := model.Now()
+	previousStatus := request.Status
 	request.Status = "provisioning"
 	request.ProvisionStartedAt = &now
 	if err := s.resourceRequestRepo.Update(ctx, request); err != nil {
 		s.logger.Error("failed to update request status to provisioning", zap.Error(err))
 		return err
 	}
+	s.recordStatusChange(ctx, request, previousStatus, request.Status, "", "")
 
 	// Parse spec to get resource configuration
 	var spec map[string]interface{}
@@ -552,9 +958,10 @@ func (s *resourceService) provisionResource(ctx context.Context, request *model.
 // buildTerraformConfig creates a Terraform configuration from the request.
 func (s *resourceService) buildTerraformConfig(ctx context.Context, request *model.ResourceRequest, spec map[string]interface{}) terraform.Config {
 	tfConfig := terraform.Config{
-		Provider:    request.Provider,
-		Environment: request.Environment,
-		Spec:        spec,
+		Provider:     request.Provider,
+		Environment:  request.Environment,
+		Spec:         spec,
+		VarOverrides: request.VarOverrides,
 	}
 
 	s.logger.Info("provisioning configuration",
@@ -615,7 +1022,12 @@ func (s *resourceService) buildTerraformConfig(ctx context.Context, request *mod
 //
 //nolint:contextcheck // terraform executor methods don't use context
 func (s *resourceService) executeTerraformWorkflow(ctx context.Context, request *model.ResourceRequest, tfConfig terraform.Config) error {
-	workDir := fmt.Sprintf("/tmp/terraform/%s", request.ID)
+	workDir := s.terraformExecutor.WorkDir(request.ID)
+	defer func() {
+		if err := s.terraformExecutor.Cleanup(workDir, request.Provider); err != nil {
+			s.logger.Warn("failed to clean up terraform work directory", zap.String("work_dir", workDir), zap.Error(err))
+		}
+	}()
 
 	// Generate Terraform files
 	if err := s.terraformExecutor.GenerateTFFiles(workDir, tfConfig); err != nil {
@@ -642,7 +1054,7 @@ func (s *resourceService) executeTerraformWorkflow(ctx context.Context, request
 	}
 
 	// Get outputs and create resource record
-	outputs := s.terraformExecutor.GetOutputs(workDir)
+	outputs, sensitiveOutputs := s.terraformExecutor.GetOutputs(workDir)
 	outputsJSON, _ := json.Marshal(outputs) //nolint:errcheck // will not fail with map
 
 	resourceName := fmt.Sprintf("%s-%s", request.Title, request.ID[:8])
@@ -654,7 +1066,9 @@ func (s *resourceService) executeTerraformWorkflow(ctx context.Context, request
 		Spec:        string(outputsJSON),
 		Description: request.Description,
 		OwnerID:     request.RequesterID,
-		Status:      "running",
+		Status:      outputStatus(outputs),
+
+		ResourceAddresses: applyResult.CreatedAddresses,
 	}
 
 	if err := s.resourceRepo.Create(ctx, resource); err != nil {
@@ -662,7 +1076,7 @@ func (s *resourceService) executeTerraformWorkflow(ctx context.Context, request
 	}
 
 	// Update request with completion status
-	completedAt := time.Now()
+	completedAt := model.Now()
 	request.Status = "completed"
 	request.ProvisionCompletedAt = &completedAt
 	request.ProvisionLog = provisionLog
@@ -673,12 +1087,15 @@ func (s *resourceService) executeTerraformWorkflow(ctx context.Context, request
 		s.logger.Error("failed to update request completion status", zap.Error(err))
 		return err
 	}
+	s.recordStatusChange(ctx, request, "provisioning", request.Status, "", "")
 
 	// Send success notification
 	if err := s.notificationService.NotifyResourceProvisioned(ctx, request.RequesterID, request.ID, resourceName, outputs); err != nil {
 		s.logger.Error("failed to send provisioning success notification", zap.Error(err))
 	}
 
+	s.publishProvisionedEvent(ctx, request, resourceName, outputs, sensitiveOutputs)
+
 	s.logger.Info("resource provisioning completed", zap.String("request_id", sanitize.ForLog(request.ID)), zap.String("resource_id", sanitize.ForLog(resource.ID)))
 	return nil
 }
@@ -750,10 +1167,13 @@ func extractHostFromURL(rawURL string) string {
 func (s *resourceService) handleProvisioningError(ctx context.Context, request *model.ResourceRequest, err error) error {
 	s.logger.Error("provisioning failed", zap.String("request_id", sanitize.ForLog(request.ID)), zap.Error(err))
 
+	previousStatus := request.Status
 	request.Status = "failed"
 	request.ErrorMessage = err.Error()
 	if updateErr := s.resourceRequestRepo.Update(ctx, request); updateErr != nil {
 		s.logger.Error("failed to update request error status", zap.Error(updateErr))
+	} else {
+		s.recordStatusChange(ctx, request, previousStatus, request.Status, "", err.Error())
 	}
 
 	// Send failure notification
@@ -761,5 +1181,7 @@ func (s *resourceService) handleProvisioningError(ctx context.Context, request *
 		s.logger.Error("failed to send provisioning failure notification", zap.Error(notifyErr))
 	}
 
+	s.publishRequestEvent(ctx, events.ResourceProvisioningFailed, request)
+
 	return err
 }
@@ -18,6 +18,10 @@ type RoleService interface {
 	List(ctx context.Context, page, pageSize int) ([]*model.Role, int64, error)
 	Update(ctx context.Context, id string, updates map[string]interface{}) (*model.Role, error)
 	Delete(ctx context.Context, id string) error
+	ListPermissions(ctx context.Context, id string) ([]*model.Permission, error)
+	AddPermissions(ctx context.Context, id string, permissionIDs []string) ([]*model.Permission, error)
+	RemovePermissions(ctx context.Context, id string, permissionIDs []string) ([]*model.Permission, error)
+	SetPermissions(ctx context.Context, id string, permissionIDs []string) ([]*model.Permission, error)
 }
 
 // roleService implements RoleService.
@@ -128,3 +132,32 @@ func (s *roleService) Delete(ctx context.Context, id string) error {
 
 	return s.roleRepo.Delete(ctx, id)
 }
+
+// ListPermissions returns the permissions granted to a role.
+func (s *roleService) ListPermissions(ctx context.Context, id string) ([]*model.Permission, error) {
+	return s.roleRepo.ListPermissions(ctx, id)
+}
+
+// AddPermissions grants permissions to a role and returns its resulting permissions.
+func (s *roleService) AddPermissions(ctx context.Context, id string, permissionIDs []string) ([]*model.Permission, error) {
+	if err := s.roleRepo.AddPermissions(ctx, id, permissionIDs); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.ListPermissions(ctx, id)
+}
+
+// RemovePermissions revokes permissions from a role and returns its resulting permissions.
+func (s *roleService) RemovePermissions(ctx context.Context, id string, permissionIDs []string) ([]*model.Permission, error) {
+	if err := s.roleRepo.RemovePermissions(ctx, id, permissionIDs); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.ListPermissions(ctx, id)
+}
+
+// SetPermissions replaces a role's permissions and returns them.
+func (s *roleService) SetPermissions(ctx context.Context, id string, permissionIDs []string) ([]*model.Permission, error) {
+	if err := s.roleRepo.SetPermissions(ctx, id, permissionIDs); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.ListPermissions(ctx, id)
+}
@@ -0,0 +1,40 @@
+// Package service provides role service tests.
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRoleService_AddPermissions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns the updated permission set", func(t *testing.T) {
+		roleRepo := new(MockRoleRepository)
+		svc := NewRoleService(roleRepo, zap.NewNop())
+		current := []*model.Permission{{Code: "resource:read"}, {Code: "resource:write"}}
+		roleRepo.On("AddPermissions", ctx, "role-1", []string{"perm-write"}).Return(nil)
+		roleRepo.On("ListPermissions", ctx, "role-1").Return(current, nil)
+
+		permissions, err := svc.AddPermissions(ctx, "role-1", []string{"perm-write"})
+		require.NoError(t, err)
+		assert.Equal(t, current, permissions)
+	})
+
+	t.Run("unknown permission IDs are rejected", func(t *testing.T) {
+		roleRepo := new(MockRoleRepository)
+		svc := NewRoleService(roleRepo, zap.NewNop())
+		roleRepo.On("AddPermissions", ctx, "role-1", []string{"missing"}).Return(fmt.Errorf("%w: missing", repository.ErrUnknownPermission))
+
+		_, err := svc.AddPermissions(ctx, "role-1", []string{"missing"})
+		require.ErrorIs(t, err, repository.ErrUnknownPermission)
+		roleRepo.AssertNotCalled(t, "ListPermissions", ctx, "role-1")
+	})
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
+)
+
+// ErrSecretInSpec is returned when a request spec holds what looks like a password, key or
+// token, which would be committed to the storage repository in plaintext.
+var ErrSecretInSpec = errors.New("spec contains a likely secret")
+
+// SecretFinding names a spec field that looks like it holds a secret, and why.
+type SecretFinding struct {
+	Field  string
+	Reason string
+}
+
+// SecretInSpecError lists the spec fields that look like they hold secrets. It unwraps to
+// ErrSecretInSpec.
+type SecretInSpecError struct {
+	Findings []SecretFinding
+}
+
+// Error implements the error interface. Secret values are never included.
+func (e *SecretInSpecError) Error() string {
+	parts := make([]string, 0, len(e.Findings))
+	for _, finding := range e.Findings {
+		parts = append(parts, fmt.Sprintf("%s %s", finding.Field, finding.Reason))
+	}
+	return fmt.Sprintf("%s: %s; provider secrets belong in a credential, and other secrets must not be placed in the spec",
+		ErrSecretInSpec, strings.Join(parts, "; "))
+}
+
+// Unwrap returns ErrSecretInSpec so callers can match with errors.Is.
+func (e *SecretInSpecError) Unwrap() error {
+	return ErrSecretInSpec
+}
+
+// secretPatterns match well-known secret formats.
+var secretPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"an AWS access key ID", regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA|A3T[A-Z0-9])[A-Z0-9]{16}\b`)},
+	{"a private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"a GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"a GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{"a Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"a JSON web token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+}
+
+// Entropy scan settings. Random base64 keys and tokens of this length score well above the
+// threshold, while words, hostnames, UUIDs and hex digests stay below it.
+const (
+	minSecretLength  = 20
+	secretEntropyMin = 4.5 // bits per character
+)
+
+// secretCandidatePattern matches runs of the characters keys and tokens are made of.
+var secretCandidatePattern = regexp.MustCompile(fmt.Sprintf(`[A-Za-z0-9+/=_-]{%d,}`, minSecretLength))
+
+// publicKeyPrefixes start SSH public keys, which are high-entropy but not secret.
+var publicKeyPrefixes = []string{"ssh-", "ecdsa-sha2-", "sk-ssh-", "sk-ecdsa-"}
+
+// scanSpecSecrets returns a *SecretInSpecError when any value in vars looks like a secret:
+// a value set for a credential variable, which is only ever filled from the request's
+// credential, a string matching a known secret format, or a long high-entropy string.
+// Nested fields are named by their dotted path.
+func scanSpecSecrets(vars map[string]interface{}) error {
+	var findings []SecretFinding
+	scanSecretValue("", vars, &findings)
+	if len(findings) == 0 {
+		return nil
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Field < findings[j].Field })
+	return &SecretInSpecError{Findings: findings}
+}
+
+// scanSpecJSONSecrets scans a JSON spec document. Specs that are not JSON objects are left
+// to the spec validation to reject.
+func scanSpecJSONSecrets(spec string) error {
+	var vars map[string]interface{}
+	if err := json.Unmarshal([]byte(spec), &vars); err != nil {
+		return nil //nolint:nilerr // not a spec object; nothing to scan
+	}
+	return scanSpecSecrets(vars)
+}
+
+// scanConfigSecrets returns a *SecretInSpecError when a generated config holds a string
+// matching a known secret format. Lines are reported by number; the entropy check is left
+// out since module sources and refs are long random-looking strings.
+func scanConfigSecrets(config string) error {
+	var findings []SecretFinding
+	for i, line := range strings.Split(config, "\n") {
+		for _, known := range secretPatterns {
+			if known.pattern.MatchString(line) {
+				findings = append(findings, SecretFinding{Field: fmt.Sprintf("line %d", i+1), Reason: "looks like " + known.name})
+				break
+			}
+		}
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+	return &SecretInSpecError{Findings: findings}
+}
+
+func scanSecretValue(field string, value interface{}, findings *[]SecretFinding) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			path := key
+			if field != "" {
+				path = field + "." + key
+			}
+			if text, ok := item.(string); ok && text != "" && terraform.IsCredentialVariable(key) {
+				*findings = append(*findings, SecretFinding{Field: path, Reason: "is a credential field, filled from the request's credential"})
+				continue
+			}
+			scanSecretValue(path, item, findings)
+		}
+	case []interface{}:
+		for i, item := range v {
+			scanSecretValue(fmt.Sprintf("%s[%d]", field, i), item, findings)
+		}
+	case string:
+		if reason := secretReason(v); reason != "" {
+			*findings = append(*findings, SecretFinding{Field: field, Reason: reason})
+		}
+	}
+}
+
+// secretReason returns why text looks like a secret, or an empty string.
+func secretReason(text string) string {
+	for _, known := range secretPatterns {
+		if known.pattern.MatchString(text) {
+			return "looks like " + known.name
+		}
+	}
+	for _, prefix := range publicKeyPrefixes {
+		if strings.HasPrefix(strings.TrimSpace(text), prefix) {
+			return ""
+		}
+	}
+	for _, candidate := range secretCandidatePattern.FindAllString(text, -1) {
+		if isHex(candidate) {
+			continue
+		}
+		if shannonEntropy(candidate) >= secretEntropyMin {
+			return "is a high-entropy string"
+		}
+	}
+	return ""
+}
+
+// isHex reports whether text holds only hex digits and dashes, as checksums and UUIDs do.
+func isHex(text string) bool {
+	return strings.Trim(text, "0123456789abcdefABCDEF-") == ""
+}
+
+// shannonEntropy returns the Shannon entropy of text in bits per character.
+func shannonEntropy(text string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range text {
+		counts[r]++
+		total++
+	}
+	var entropy float64
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
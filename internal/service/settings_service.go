@@ -4,6 +4,8 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
 	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
@@ -16,37 +18,63 @@ type SettingsService interface {
 	// Provider operations
 	CreateProvider(ctx context.Context, input *CreateProviderInput) (*model.ProviderConfig, error)
 	GetProvider(ctx context.Context, id string) (*model.ProviderConfig, error)
-	ListProviders(ctx context.Context, providerType string, page, pageSize int) ([]*model.ProviderConfig, int64, error)
+	ListProviders(ctx context.Context, filters SettingsFilters, page, pageSize int) ([]*model.ProviderConfig, int64, error)
 	UpdateProvider(ctx context.Context, id string, input *UpdateProviderInput) (*model.ProviderConfig, error)
 	DeleteProvider(ctx context.Context, id string) error
+	GetProviderUsage(ctx context.Context, id string) (*repository.Usage, error)
 	TestProviderConnection(ctx context.Context, input *TestProviderConnectionInput) error
 
 	// Credential operations
 	CreateCredential(ctx context.Context, input *CreateCredentialInput) (*model.Credential, error)
 	GetCredential(ctx context.Context, id string) (*model.Credential, error)
-	ListCredentials(ctx context.Context, credentialType string, page, pageSize int) ([]*model.Credential, int64, error)
+	RevealCredential(ctx context.Context, id string) (*CredentialSecret, error)
+	ListCredentials(ctx context.Context, filters SettingsFilters, page, pageSize int) ([]*model.Credential, int64, error)
 	UpdateCredential(ctx context.Context, id string, input *UpdateCredentialInput) (*model.Credential, error)
 	DeleteCredential(ctx context.Context, id string) error
+	GetCredentialUsage(ctx context.Context, id string) (*repository.Usage, error)
 	TestCredentialConnection(ctx context.Context, input *TestCredentialConnectionInput) error
 }
 
+// ErrCredentialTypeMismatch is returned when a credential would be linked to a provider of
+// another type, or the credential or provider to link does not exist.
+var ErrCredentialTypeMismatch = errors.New("credential does not match provider type")
+
+// SettingsFilters represents filters for provider and credential listing.
+type SettingsFilters struct {
+	Type string
+	// ZoneID limits results to the zone plus global, zone-less entries.
+	ZoneID string
+}
+
+// connectionProbe contacts a provider endpoint to test that credential can reach it.
+type connectionProbe func(ctx context.Context, providerType, endpoint string, credential *model.Credential, opts connectionOptions) error
+
 type settingsService struct {
-	providerRepo   repository.ProviderRepository
-	credentialRepo repository.CredentialRepository
-	logger         *zap.Logger
+	providerRepo       repository.ProviderRepository
+	credentialRepo     repository.CredentialRepository
+	connectionCache    ConnectionTestCache // Nil tests every time
+	connectionCacheTTL time.Duration
+	probe              connectionProbe
+	logger             *zap.Logger
 }
 
-// NewSettingsService creates a new settings service.
+// NewSettingsService creates a new settings service. Connection test results are kept in
+// connectionCache for a short while; a nil cache tests the endpoint on every call.
 func NewSettingsService(
 	providerRepo repository.ProviderRepository,
 	credentialRepo repository.CredentialRepository,
+	connectionCache ConnectionTestCache,
 	logger *zap.Logger,
 ) SettingsService {
-	return &settingsService{
-		providerRepo:   providerRepo,
-		credentialRepo: credentialRepo,
-		logger:         logger,
-	}
+	s := &settingsService{
+		providerRepo:       providerRepo,
+		credentialRepo:     credentialRepo,
+		connectionCache:    connectionCache,
+		connectionCacheTTL: constants.ConnectionTestCacheTTL,
+		logger:             logger,
+	}
+	s.probe = s.testEndpoint
+	return s
 }
 
 // CreateProviderInput represents input for provider creation.
@@ -68,6 +96,8 @@ type UpdateProviderInput struct {
 	Config      *string
 	Status      *int8
 	IsDefault   *bool
+	// CredentialID links a credential when set; an empty string unlinks the current one.
+	CredentialID *string
 }
 
 // CreateCredentialInput represents input for credential creation.
@@ -96,12 +126,21 @@ type UpdateCredentialInput struct {
 	Status      *int8
 }
 
+// CredentialSecret holds the secret values of a credential, which are never part of the
+// credential's own JSON.
+type CredentialSecret struct {
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	Token     string `json:"token"`
+}
+
 // TestProviderConnectionInput represents input for testing provider connection.
 type TestProviderConnectionInput struct {
 	Type         string
 	Endpoint     string
 	CredentialID string
 	Config       string
+	Force        bool // Test even when a recent result is cached
 }
 
 // TestCredentialConnectionInput represents input for testing credential connection.
@@ -111,6 +150,7 @@ type TestCredentialConnectionInput struct {
 	AccessKey string
 	SecretKey string
 	Token     string
+	Force     bool // Test even when a recent result is cached
 }
 
 // CreateProvider creates a new provider configuration.
@@ -135,6 +175,9 @@ func (s *settingsService) CreateProvider(ctx context.Context, input *CreateProvi
 
 	var credentialID *string
 	if input.CredentialID != "" {
+		if err := s.checkProviderCredential(ctx, input.Type, input.CredentialID); err != nil {
+			return nil, err
+		}
 		credentialID = &input.CredentialID
 	}
 
@@ -148,6 +191,7 @@ func (s *settingsService) CreateProvider(ctx context.Context, input *CreateProvi
 		Status:       1,
 		CredentialID: credentialID,
 	}
+	stampCreated(ctx, &provider.AuditStamp)
 
 	if err := s.providerRepo.Create(ctx, provider); err != nil {
 		s.logger.Error("failed to create provider", zap.Error(err))
@@ -175,8 +219,21 @@ func (s *settingsService) GetProvider(ctx context.Context, id string) (*model.Pr
 	return provider, nil
 }
 
+// GetProviderUsage reports what depends on a provider.
+func (s *settingsService) GetProviderUsage(ctx context.Context, id string) (*repository.Usage, error) {
+	if _, err := s.GetProvider(ctx, id); err != nil {
+		return nil, err
+	}
+	usage, err := s.providerRepo.GetUsage(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to get provider usage", zap.Error(err))
+		return nil, errors.New("failed to get provider usage")
+	}
+	return usage, nil
+}
+
 // ListProviders lists providers with optional filtering.
-func (s *settingsService) ListProviders(ctx context.Context, providerType string, page, pageSize int) ([]*model.ProviderConfig, int64, error) {
+func (s *settingsService) ListProviders(ctx context.Context, filters SettingsFilters, page, pageSize int) ([]*model.ProviderConfig, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -188,7 +245,7 @@ func (s *settingsService) ListProviders(ctx context.Context, providerType string
 	}
 
 	offset := (page - 1) * pageSize
-	return s.providerRepo.List(ctx, providerType, offset, pageSize)
+	return s.providerRepo.List(ctx, repository.ProviderFilters{Type: filters.Type, ZoneID: filters.ZoneID}, offset, pageSize)
 }
 
 // UpdateProvider updates a provider configuration.
@@ -227,6 +284,18 @@ func (s *settingsService) UpdateProvider(ctx context.Context, id string, input *
 	if input.IsDefault != nil {
 		provider.IsDefault = *input.IsDefault
 	}
+	if input.CredentialID != nil {
+		if *input.CredentialID == "" {
+			provider.CredentialID = nil
+		} else {
+			if err := s.checkProviderCredential(ctx, provider.Type, *input.CredentialID); err != nil {
+				return nil, err
+			}
+			credentialID := *input.CredentialID
+			provider.CredentialID = &credentialID
+		}
+	}
+	stampUpdated(ctx, &provider.AuditStamp)
 
 	if err := s.providerRepo.Update(ctx, provider); err != nil {
 		s.logger.Error("failed to update provider", zap.Error(err))
@@ -274,6 +343,9 @@ func (s *settingsService) CreateCredential(ctx context.Context, input *CreateCre
 
 	var providerID *string
 	if input.ProviderID != "" {
+		if err := s.checkCredentialProvider(ctx, input.Type, input.ProviderID); err != nil {
+			return nil, err
+		}
 		providerID = &input.ProviderID
 	}
 
@@ -299,6 +371,40 @@ func (s *settingsService) CreateCredential(ctx context.Context, input *CreateCre
 	return credential, nil
 }
 
+// checkProviderCredential checks that the credential with ID credentialID exists and is of
+// providerType, so it can be linked to a provider of that type.
+func (s *settingsService) checkProviderCredential(ctx context.Context, providerType, credentialID string) error {
+	credential, err := s.credentialRepo.GetByID(ctx, credentialID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: credential %s does not exist", ErrCredentialTypeMismatch, credentialID)
+		}
+		s.logger.Error("failed to get credential", zap.Error(err))
+		return errors.New("failed to get credential")
+	}
+	if credential.Type != providerType {
+		return fmt.Errorf("%w: credential type %s, provider type %s", ErrCredentialTypeMismatch, credential.Type, providerType)
+	}
+	return nil
+}
+
+// checkCredentialProvider checks that the provider with ID providerID exists and is of
+// credentialType, so a credential of that type can be linked to it.
+func (s *settingsService) checkCredentialProvider(ctx context.Context, credentialType, providerID string) error {
+	provider, err := s.providerRepo.GetByID(ctx, providerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: provider %s does not exist", ErrCredentialTypeMismatch, providerID)
+		}
+		s.logger.Error("failed to get provider", zap.Error(err))
+		return errors.New("failed to get provider")
+	}
+	if provider.Type != credentialType {
+		return fmt.Errorf("%w: credential type %s, provider type %s", ErrCredentialTypeMismatch, credentialType, provider.Type)
+	}
+	return nil
+}
+
 // GetCredential retrieves a credential by ID.
 func (s *settingsService) GetCredential(ctx context.Context, id string) (*model.Credential, error) {
 	if id == "" {
@@ -317,8 +423,30 @@ func (s *settingsService) GetCredential(ctx context.Context, id string) (*model.
 	return credential, nil
 }
 
+// RevealCredential returns the decrypted secrets of a credential.
+func (s *settingsService) RevealCredential(ctx context.Context, id string) (*CredentialSecret, error) {
+	credential, err := s.GetCredential(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &CredentialSecret{AccessKey: credential.AccessKey, SecretKey: credential.SecretKey, Token: credential.Token}, nil
+}
+
+// GetCredentialUsage reports what depends on a credential.
+func (s *settingsService) GetCredentialUsage(ctx context.Context, id string) (*repository.Usage, error) {
+	if _, err := s.GetCredential(ctx, id); err != nil {
+		return nil, err
+	}
+	usage, err := s.credentialRepo.GetUsage(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to get credential usage", zap.Error(err))
+		return nil, errors.New("failed to get credential usage")
+	}
+	return usage, nil
+}
+
 // ListCredentials lists credentials with optional filtering.
-func (s *settingsService) ListCredentials(ctx context.Context, credentialType string, page, pageSize int) ([]*model.Credential, int64, error) {
+func (s *settingsService) ListCredentials(ctx context.Context, filters SettingsFilters, page, pageSize int) ([]*model.Credential, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -330,7 +458,7 @@ func (s *settingsService) ListCredentials(ctx context.Context, credentialType st
 	}
 
 	offset := (page - 1) * pageSize
-	return s.credentialRepo.List(ctx, credentialType, offset, pageSize)
+	return s.credentialRepo.List(ctx, repository.CredentialFilters{Type: filters.Type, ZoneID: filters.ZoneID}, offset, pageSize)
 }
 
 // UpdateCredential updates a credential.
@@ -427,52 +555,33 @@ func (s *settingsService) TestProviderConnection(ctx context.Context, input *Tes
 		}
 	}
 
-	// Test connection based on provider type.
-	// For now, we do a basic HTTP connectivity check.
-	// In the future, this can be extended with provider-specific validation.
-	switch input.Type {
-	case constants.ProviderTypePVE:
-		return s.testPVEConnection(ctx, input.Endpoint, credential)
-	case constants.ProviderTypeVMware:
-		return s.testVMwareConnection(ctx, input.Endpoint, credential)
-	case constants.ProviderTypeOpenStack:
-		return s.testOpenStackConnection(ctx, input.Endpoint, credential)
-	case constants.ProviderTypeAWS, constants.ProviderTypeAliyun, constants.ProviderTypeGCP, constants.ProviderTypeAzure:
-		// Cloud providers: credential is required.
-		if credential == nil {
-			return errors.New("credential is required for cloud providers")
-		}
-		return s.testCloudProviderConnection(ctx, input.Type, credential)
-	default:
+	provider, ok := constants.LookupProvider(input.Type)
+	if !ok {
 		return errors.New("unsupported provider type")
 	}
-}
-
-// testPVEConnection tests connection to a Proxmox VE server.
-func (s *settingsService) testPVEConnection(_ context.Context, endpoint string, credential *model.Credential) error {
-	// Basic connectivity check - try to reach the API endpoint.
-	// In production, this would use the PVE API client.
-	_ = endpoint
-	_ = credential
-	// For now, return success as a placeholder.
-	// TODO: Implement actual PVE API connectivity check.
-	return nil
-}
+	if provider.RequiresCredential && credential == nil {
+		return errors.New("credential is required for cloud providers")
+	}
 
-// testVMwareConnection tests connection to a VMware vCenter/ESXi server.
-func (s *settingsService) testVMwareConnection(_ context.Context, endpoint string, credential *model.Credential) error {
-	_ = endpoint
-	_ = credential
-	// TODO: Implement actual VMware API connectivity check.
-	return nil
+	opts, err := parseConnectionOptions(input.Config)
+	if err != nil {
+		return err
+	}
+	return s.testConnectionCached(ctx, input.Type, input.Endpoint, credential, opts, input.Force)
 }
 
-// testOpenStackConnection tests connection to an OpenStack cloud.
-func (s *settingsService) testOpenStackConnection(_ context.Context, endpoint string, credential *model.Credential) error {
-	_ = endpoint
-	_ = credential
-	// TODO: Implement actual OpenStack API connectivity check.
-	return nil
+// testEndpoint tests the connection based on provider type.
+func (s *settingsService) testEndpoint(ctx context.Context, providerType, endpoint string, credential *model.Credential, opts connectionOptions) error {
+	switch providerType {
+	case constants.ProviderTypePVE:
+		return s.testPVEConnection(ctx, endpoint, credential, opts)
+	case constants.ProviderTypeVMware:
+		return s.testVMwareConnection(ctx, endpoint, credential, opts)
+	case constants.ProviderTypeOpenStack:
+		return s.testOpenStackConnection(ctx, endpoint, credential, opts)
+	default:
+		return s.testCloudProviderConnection(ctx, providerType, credential)
+	}
 }
 
 // testCloudProviderConnection tests connection to a cloud provider (AWS, Aliyun, GCP, Azure).
@@ -504,17 +613,8 @@ func (s *settingsService) TestCredentialConnection(ctx context.Context, input *T
 		Token:     input.Token,
 	}
 
-	// Test connection based on credential type.
-	switch input.Type {
-	case constants.ProviderTypePVE:
-		return s.testPVEConnection(ctx, input.Endpoint, credential)
-	case constants.ProviderTypeVMware:
-		return s.testVMwareConnection(ctx, input.Endpoint, credential)
-	case constants.ProviderTypeOpenStack:
-		return s.testOpenStackConnection(ctx, input.Endpoint, credential)
-	case constants.ProviderTypeAWS, constants.ProviderTypeAliyun, constants.ProviderTypeGCP, constants.ProviderTypeAzure:
-		return s.testCloudProviderConnection(ctx, input.Type, credential)
-	default:
+	if _, ok := constants.LookupProvider(input.Type); !ok {
 		return errors.New("unsupported credential type")
 	}
+	return s.testConnectionCached(ctx, input.Type, input.Endpoint, credential, connectionOptions{}, input.Force)
 }
@@ -0,0 +1,147 @@
+// Package service provides settings service tests.
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryProviderRepository keeps providers by ID. Methods the tests do not use panic
+// through the nil embedded interface.
+type memoryProviderRepository struct {
+	repository.ProviderRepository
+	providers map[string]*model.ProviderConfig
+}
+
+func (r *memoryProviderRepository) Create(_ context.Context, provider *model.ProviderConfig) error {
+	r.providers[provider.ID] = provider
+	return nil
+}
+
+func (r *memoryProviderRepository) GetByID(_ context.Context, id string) (*model.ProviderConfig, error) {
+	provider, ok := r.providers[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return provider, nil
+}
+
+func (r *memoryProviderRepository) Update(_ context.Context, provider *model.ProviderConfig) error {
+	r.providers[provider.ID] = provider
+	return nil
+}
+
+// memoryCredentialRepository keeps credentials by ID.
+type memoryCredentialRepository struct {
+	repository.CredentialRepository
+	credentials map[string]*model.Credential
+}
+
+func (r *memoryCredentialRepository) Create(_ context.Context, credential *model.Credential) error {
+	r.credentials[credential.ID] = credential
+	return nil
+}
+
+func (r *memoryCredentialRepository) GetByID(_ context.Context, id string) (*model.Credential, error) {
+	credential, ok := r.credentials[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return credential, nil
+}
+
+func TestSettingsService_CredentialTypeMatchesProvider(t *testing.T) {
+	newService := func() (SettingsService, *memoryProviderRepository) {
+		providers := &memoryProviderRepository{providers: map[string]*model.ProviderConfig{
+			"prov-pve": {BaseModel: model.BaseModel{ID: "prov-pve"}, Name: "pve", Type: "pve", Endpoint: "https://pve.example.com"},
+		}}
+		credentials := &memoryCredentialRepository{credentials: map[string]*model.Credential{
+			"cred-pve":       {BaseModel: model.BaseModel{ID: "cred-pve"}, Name: "pve", Type: "pve"},
+			"cred-openstack": {BaseModel: model.BaseModel{ID: "cred-openstack"}, Name: "openstack", Type: "openstack"},
+		}}
+		return NewSettingsService(providers, credentials, nil, zap.NewNop()), providers
+	}
+	ctx := context.Background()
+
+	t.Run("provider created with a matching credential", func(t *testing.T) {
+		svc, _ := newService()
+		provider, err := svc.CreateProvider(ctx, &CreateProviderInput{
+			Name: "pve-2", Type: "pve", Endpoint: "https://pve2.example.com", CredentialID: "cred-pve",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, provider.CredentialID)
+		assert.Equal(t, "cred-pve", *provider.CredentialID)
+	})
+
+	t.Run("provider created with a mismatched credential", func(t *testing.T) {
+		svc, _ := newService()
+		_, err := svc.CreateProvider(ctx, &CreateProviderInput{
+			Name: "openstack", Type: "openstack", Endpoint: "https://os.example.com", CredentialID: "cred-pve",
+		})
+		require.ErrorIs(t, err, ErrCredentialTypeMismatch)
+		assert.Contains(t, err.Error(), "credential type pve, provider type openstack")
+	})
+
+	t.Run("provider created with a missing credential", func(t *testing.T) {
+		svc, _ := newService()
+		_, err := svc.CreateProvider(ctx, &CreateProviderInput{
+			Name: "pve-2", Type: "pve", Endpoint: "https://pve2.example.com", CredentialID: "cred-missing",
+		})
+		require.ErrorIs(t, err, ErrCredentialTypeMismatch)
+	})
+
+	t.Run("provider updated to link a credential", func(t *testing.T) {
+		svc, providers := newService()
+		matching, mismatched := "cred-pve", "cred-openstack"
+
+		_, err := svc.UpdateProvider(ctx, "prov-pve", &UpdateProviderInput{CredentialID: &mismatched})
+		require.ErrorIs(t, err, ErrCredentialTypeMismatch)
+		assert.Nil(t, providers.providers["prov-pve"].CredentialID)
+
+		provider, err := svc.UpdateProvider(ctx, "prov-pve", &UpdateProviderInput{CredentialID: &matching})
+		require.NoError(t, err)
+		require.NotNil(t, provider.CredentialID)
+		assert.Equal(t, "cred-pve", *provider.CredentialID)
+
+		unlink := ""
+		provider, err = svc.UpdateProvider(ctx, "prov-pve", &UpdateProviderInput{CredentialID: &unlink})
+		require.NoError(t, err)
+		assert.Nil(t, provider.CredentialID)
+	})
+
+	t.Run("credential created for a provider", func(t *testing.T) {
+		svc, _ := newService()
+		credential, err := svc.CreateCredential(ctx, &CreateCredentialInput{
+			Name: "pve-2", Type: "pve", ProviderID: "prov-pve", CreatedByID: "user-1",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, credential.ProviderID)
+		assert.Equal(t, "prov-pve", *credential.ProviderID)
+
+		_, err = svc.CreateCredential(ctx, &CreateCredentialInput{
+			Name: "openstack-2", Type: "openstack", ProviderID: "prov-pve", CreatedByID: "user-1",
+		})
+		require.ErrorIs(t, err, ErrCredentialTypeMismatch)
+	})
+}
+
+func TestSettingsService_RevealCredential(t *testing.T) {
+	credentials := &memoryCredentialRepository{credentials: map[string]*model.Credential{
+		"cred-pve": {BaseModel: model.BaseModel{ID: "cred-pve"}, Name: "pve", Type: "pve", AccessKey: "root@pam", SecretKey: "s3cret", Token: "api-token"},
+	}}
+	svc := NewSettingsService(nil, credentials, nil, zap.NewNop())
+	ctx := context.Background()
+
+	secret, err := svc.RevealCredential(ctx, "cred-pve")
+	require.NoError(t, err)
+	assert.Equal(t, &CredentialSecret{AccessKey: "root@pam", SecretKey: "s3cret", Token: "api-token"}, secret)
+
+	_, err = svc.RevealCredential(ctx, "cred-missing")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,132 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/sanitize"
+	"go.uber.org/zap"
+)
+
+// ErrNotStorageRepository is returned when a storage-only operation targets another repository type.
+var ErrNotStorageRepository = errors.New("repository is not a storage repository")
+
+// terragruntFile is the configuration file terragrunt reads in each directory.
+const terragruntFile = "terragrunt.hcl"
+
+// StorageRepoValidation is the result of checking a storage repository's layout.
+type StorageRepoValidation struct {
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+}
+
+// ValidateStorageRepo shallow-clones a storage repository and checks that it has
+// the root terragrunt.hcl that generated node configs include through
+// find_in_parent_folders.
+func (s *gitService) ValidateStorageRepo(ctx context.Context, id string) (*StorageRepoValidation, error) {
+	repo, err := s.gitRepoRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if repo.Type != model.GitRepoTypeStorage {
+		return nil, ErrNotStorageRepository
+	}
+
+	// Validate URL and branch from stored repository
+	if _, urlErr := sanitize.ValidateGitURL(repo.URL); urlErr != nil {
+		return nil, fmt.Errorf("invalid repository URL: %w", urlErr)
+	}
+	branch, branchErr := sanitize.ValidateGitBranch(repo.Branch)
+	if branchErr != nil {
+		return nil, fmt.Errorf("invalid branch name: %w", branchErr)
+	}
+
+	tempDir, err := os.MkdirTemp("", "git-validate-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir) //nolint:errcheck // best effort cleanup
+
+	if output, cloneErr := s.cloneGit(ctx, repo, "--depth", "1", "--branch", branch, "--single-branch", repo.URL, tempDir); cloneErr != nil {
+		s.logger.Error("git clone for validation failed",
+			zap.String("repo", sanitize.ForLog(repo.Name)),
+			zap.String("output", sanitize.CommandOutput(output)),
+			zap.Error(cloneErr),
+		)
+		return nil, cloneFailure("failed to clone repository", output, cloneErr)
+	}
+
+	problems := checkStorageLayout(tempDir, repo.BasePath)
+	return &StorageRepoValidation{Valid: len(problems) == 0, Problems: problems}, nil
+}
+
+// checkStorageLayout returns the problems found in a cloned storage repository.
+func checkStorageLayout(repoDir, basePath string) []string {
+	problems := []string{}
+
+	root := filepath.Join(repoDir, filepath.Clean("/"+basePath))
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		return append(problems, fmt.Sprintf("base path %q does not exist", basePath))
+	}
+
+	rootConfig := filepath.Join(root, terragruntFile)
+	content, err := os.ReadFile(rootConfig) // #nosec G304 -- path is inside the temporary clone
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("missing root %s in %q; node configs include it with find_in_parent_folders()", terragruntFile, displayPath(basePath)))
+	} else {
+		rootHCL := string(content)
+		if strings.Contains(rootHCL, "find_in_parent_folders") {
+			problems = append(problems, fmt.Sprintf("root %s must not include a parent configuration", terragruntFile))
+		}
+		if !strings.Contains(rootHCL, "remote_state") {
+			problems = append(problems, fmt.Sprintf("root %s does not configure remote_state", terragruntFile))
+		}
+	}
+
+	// Every nested terragrunt.hcl is a node config and must include the root
+	walkErr := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" || strings.HasPrefix(d.Name(), ".terragrunt-cache") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != terragruntFile || path == rootConfig {
+			return nil
+		}
+
+		child, readErr := os.ReadFile(path) // #nosec G304 -- path is inside the temporary clone
+		if readErr != nil {
+			return readErr
+		}
+		if !strings.Contains(string(child), "find_in_parent_folders") {
+			rel, _ := filepath.Rel(root, path) //nolint:errcheck // path is under root
+			problems = append(problems, fmt.Sprintf("%s does not include the root configuration", filepath.ToSlash(rel)))
+		}
+		return nil
+	})
+	if walkErr != nil {
+		problems = append(problems, fmt.Sprintf("failed to scan repository: %v", walkErr))
+	}
+
+	return problems
+}
+
+// displayPath renders an empty base path as the repository root.
+func displayPath(basePath string) string {
+	if basePath == "" {
+		return "/"
+	}
+	return basePath
+}
@@ -0,0 +1,151 @@
+// Package service provides storage repository validation tests.
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubGitRepoRepository serves git repositories from a map.
+type stubGitRepoRepository struct {
+	repository.GitRepoRepository
+	repos map[string]*model.GitRepository
+}
+
+func (r *stubGitRepoRepository) GetByID(_ context.Context, id string) (*model.GitRepository, error) {
+	repo, ok := r.repos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return repo, nil
+}
+
+// GetDefaultByType returns a default repository of repoType, ignoring environments.
+func (r *stubGitRepoRepository) GetDefaultByType(_ context.Context, repoType model.GitRepoType, _ string) (*model.GitRepository, error) {
+	for _, repo := range r.repos {
+		if repo.Type == repoType && repo.IsDefault {
+			return repo, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *stubGitRepoRepository) Update(_ context.Context, repo *model.GitRepository) error {
+	r.repos[repo.ID] = repo
+	return nil
+}
+
+// fixtureCloneRunner writes a fixture layout into the clone target instead of running git.
+func fixtureCloneRunner(t *testing.T, files map[string]string, calls *[][]string) gitRunner {
+	t.Helper()
+	return func(_ context.Context, _ string, _ []string, args ...string) (string, error) {
+		*calls = append(*calls, args)
+		if len(args) == 0 || args[0] != "clone" {
+			return "", errors.New("unexpected git command")
+		}
+		target := args[len(args)-1]
+		for name, content := range files {
+			writeTestFile(t, filepath.Join(target, name), content)
+		}
+		return "", nil
+	}
+}
+
+const validRootHCL = `remote_state {
+  backend = "s3"
+  config = {
+    bucket = "lab-state"
+    key    = "${path_relative_to_include()}/terraform.tfstate"
+  }
+}
+`
+
+const validChildHCL = `include "root" {
+  path = find_in_parent_folders()
+}
+`
+
+func TestGitService_ValidateStorageRepo(t *testing.T) {
+	repos := &stubGitRepoRepository{repos: map[string]*model.GitRepository{
+		"storage-1": {
+			BaseModel: model.BaseModel{ID: "storage-1"},
+			Name:      "nodes",
+			Type:      model.GitRepoTypeStorage,
+			URL:       "https://git.example.com/lab/nodes.git",
+			Branch:    "main",
+			BasePath:  "live",
+		},
+		"modules-1": {
+			BaseModel: model.BaseModel{ID: "modules-1"},
+			Type:      model.GitRepoTypeModules,
+			URL:       "https://git.example.com/lab/modules.git",
+			Branch:    "main",
+		},
+	}}
+
+	t.Run("valid layout", func(t *testing.T) {
+		var calls [][]string
+		svc := &gitService{logger: zap.NewNop(), gitRepoRepo: repos, runGit: fixtureCloneRunner(t, map[string]string{
+			"live/terragrunt.hcl": validRootHCL,
+			"live/proxmox-ve/instance/vm/minio-1234abcd/terragrunt.hcl": validChildHCL,
+		}, &calls)}
+
+		result, err := svc.ValidateStorageRepo(context.Background(), "storage-1")
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+		assert.Empty(t, result.Problems)
+
+		require.Len(t, calls, 1)
+		assert.Contains(t, calls[0], "--depth")
+	})
+
+	t.Run("missing root config", func(t *testing.T) {
+		var calls [][]string
+		svc := &gitService{logger: zap.NewNop(), gitRepoRepo: repos, runGit: fixtureCloneRunner(t, map[string]string{
+			"live/proxmox-ve/instance/vm/minio-1234abcd/terragrunt.hcl":  validChildHCL,
+			"live/proxmox-ve/instance/vm/legacy-5678efgh/terragrunt.hcl": "terraform {\n  source = \"../modules/vm\"\n}\n",
+		}, &calls)}
+
+		result, err := svc.ValidateStorageRepo(context.Background(), "storage-1")
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		require.Len(t, result.Problems, 2)
+		assert.Contains(t, result.Problems[0], "missing root terragrunt.hcl")
+		assert.Contains(t, result.Problems[1], "proxmox-ve/instance/vm/legacy-5678efgh/terragrunt.hcl")
+	})
+
+	t.Run("missing base path", func(t *testing.T) {
+		var calls [][]string
+		svc := &gitService{logger: zap.NewNop(), gitRepoRepo: repos, runGit: fixtureCloneRunner(t, map[string]string{
+			"terragrunt.hcl": validRootHCL,
+		}, &calls)}
+
+		result, err := svc.ValidateStorageRepo(context.Background(), "storage-1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{`base path "live" does not exist`}, result.Problems)
+	})
+
+	t.Run("modules repository is rejected", func(t *testing.T) {
+		var calls [][]string
+		svc := &gitService{logger: zap.NewNop(), gitRepoRepo: repos, runGit: fixtureCloneRunner(t, nil, &calls)}
+
+		_, err := svc.ValidateStorageRepo(context.Background(), "modules-1")
+		require.ErrorIs(t, err, ErrNotStorageRepository)
+		assert.Empty(t, calls)
+	})
+
+	t.Run("unknown repository", func(t *testing.T) {
+		svc := &gitService{logger: zap.NewNop(), gitRepoRepo: repos}
+
+		_, err := svc.ValidateStorageRepo(context.Background(), "missing")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
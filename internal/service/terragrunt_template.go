@@ -0,0 +1,87 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/terraform"
+)
+
+// builtinTerragruntTemplate is the terragrunt.hcl layout used when no template file is configured.
+const builtinTerragruntTemplate = `# Terragrunt configuration for {{ .NodeName }}
+# Generated by VC Lab Platform
+# Request ID: {{ .RequestID }}
+# Created: {{ .CreatedAt }}
+
+terraform {
+  source = "{{ .ModuleSource }}"
+}
+
+include "root" {
+  path = find_in_parent_folders()
+}
+{{ range .Dependencies }}
+dependency "{{ .Name }}" {
+  config_path = {{ .ConfigPath | formatValue }}
+}
+{{ end }}
+inputs = {{ .Vars | formatInputs }}
+`
+
+// defaultTerragruntTemplate is the parsed built-in template.
+var defaultTerragruntTemplate = template.Must(ParseTerragruntTemplate(builtinTerragruntTemplate))
+
+// terragruntFuncs are the functions available to terragrunt templates. formatValue renders
+// the value of one attribute of a top-level block such as inputs, and formatInputs renders
+// a whole object, such as .Vars, as the value of a top-level attribute.
+var terragruntFuncs = template.FuncMap{
+	"formatValue":  func(v interface{}) string { return terraform.FormatHCLValue(v, 1) },
+	"formatInputs": func(v interface{}) string { return terraform.FormatHCLValue(v, 0) },
+}
+
+// ParseTerragruntTemplate parses a terragrunt.hcl template and checks that it
+// renders against sample data, so unknown fields fail at load time rather than
+// when the first request is provisioned.
+func ParseTerragruntTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("terragrunt template is empty")
+	}
+
+	t, err := template.New("terragrunt").Funcs(terragruntFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid terragrunt template: %w", err)
+	}
+
+	sample := map[string]interface{}{
+		"NodeName":     "node-00000000",
+		"RequestID":    "00000000-0000-0000-0000-000000000000",
+		"CreatedAt":    "1970-01-01T00:00:00Z",
+		"ModuleSource": "git::https://git.example.com/modules.git//vm",
+		"Vars":         map[string]interface{}{"cores": float64(2), "name": "node", "tags": []interface{}{"lab"}},
+		"Dependencies": []terragruntDependency{{Name: "network", ConfigPath: "../network-001"}},
+	}
+	if err := t.Execute(io.Discard, sample); err != nil {
+		return nil, fmt.Errorf("invalid terragrunt template: %w", err)
+	}
+
+	return t, nil
+}
+
+// LoadTerragruntTemplate loads the terragrunt.hcl template from path, or the
+// built-in template when path is empty.
+func LoadTerragruntTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return defaultTerragruntTemplate, nil
+	}
+
+	content, err := os.ReadFile(path) // #nosec G304 -- path comes from server configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terragrunt template: %w", err)
+	}
+
+	return ParseTerragruntTemplate(string(content))
+}
@@ -0,0 +1,126 @@
+// Package service provides terragrunt template tests.
+package service
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLoadTerragruntTemplate(t *testing.T) {
+	t.Run("empty path uses built-in template", func(t *testing.T) {
+		tmpl, err := LoadTerragruntTemplate("")
+		require.NoError(t, err)
+		assert.Same(t, defaultTerragruntTemplate, tmpl)
+	})
+
+	t.Run("custom template renders node config", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "terragrunt.hcl.tmpl")
+		writeTestFile(t, path, `include "root" {
+  path = find_in_parent_folders("lab.hcl")
+}
+
+terraform {
+  source = "{{ .ModuleSource }}"
+}
+
+inputs = {
+  name = {{ .NodeName | formatValue }}
+{{- range $key, $value := .Vars }}
+  {{ $key }} = {{ $value | formatValue }}
+{{- end }}
+}
+`)
+
+		tmpl, err := LoadTerragruntTemplate(path)
+		require.NoError(t, err)
+
+		svc := &gitService{logger: zap.NewNop(), terragruntTemplate: tmpl}
+		request := &model.ResourceRequest{Title: "vm", Spec: `{"cores":4,"on_boot":true}`}
+		config, err := svc.generateTerragruntConfig(request, "vm-1234abcd", &model.GitRepository{URL: "git::https://git.example.com/modules.git"}, nil)
+		require.NoError(t, err)
+
+		assert.Contains(t, config, `path = find_in_parent_folders("lab.hcl")`)
+		assert.Contains(t, config, `source = "git::https://git.example.com/modules.git"`)
+		assert.Contains(t, config, `name = "vm-1234abcd"`)
+		assert.Contains(t, config, "cores = 4")
+		assert.Contains(t, config, "on_boot = true")
+		assert.NotContains(t, config, "Generated by VC Lab Platform")
+	})
+
+	t.Run("syntax error is rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "broken.tmpl")
+		writeTestFile(t, path, "inputs = {\n{{ range .Vars }}\n}\n")
+
+		_, err := LoadTerragruntTemplate(path)
+		assert.ErrorContains(t, err, "invalid terragrunt template")
+	})
+
+	t.Run("unknown field is rejected", func(t *testing.T) {
+		_, err := ParseTerragruntTemplate(`source = "{{ .ModuleURL }}"`)
+		assert.ErrorContains(t, err, "invalid terragrunt template")
+	})
+
+	t.Run("missing file is rejected", func(t *testing.T) {
+		_, err := LoadTerragruntTemplate(filepath.Join(t.TempDir(), "missing.tmpl"))
+		assert.Error(t, err)
+	})
+}
+
+// complexSpec exercises the values that naive formatting renders as invalid HCL.
+const complexSpec = `{
+	"cores": 2,
+	"name": "say \"hi\"",
+	"tags": ["lab", "web"],
+	"labels": {"env": "dev", "team-name": "infra", "with space": "y", "nested": {"z": "${var.x}"}},
+	"disks": [{"size": 20, "type": "ssd"}, {"size": 100, "opts": {"cache": "none"}}],
+	"cloud_init": "#cloud-config\nruncmd:\n  - echo ${HOME}\n",
+	"note": "a\nb"
+}`
+
+func TestGenerateTerragruntConfig_StructuredInputs(t *testing.T) {
+	svc := &gitService{logger: zap.NewNop()}
+	request := &model.ResourceRequest{Title: "vm", Spec: complexSpec}
+	config, err := svc.generateTerragruntConfig(request, "vm-1", &model.GitRepository{URL: "git::https://git.example.com/modules.git"}, nil)
+	require.NoError(t, err)
+
+	assert.Contains(t, config, "  cloud_init = <<EOT\n#cloud-config\nruncmd:\n  - echo $${HOME}\nEOT\n")
+	assert.Contains(t, config, "  disks = [\n    {\n      size = 20\n      type = \"ssd\"\n    },\n")
+	assert.Contains(t, config, "    \"with space\" = \"y\"\n")
+	assert.Contains(t, config, `z = "$${var.x}"`)
+	assert.Contains(t, config, `name = "say \"hi\""`)
+	assert.Contains(t, config, `note = "a\nb"`)
+	assert.Contains(t, config, `tags = ["lab", "web"]`)
+}
+
+// TestGenerateTerragruntConfig_TerraformFmt checks the generated configuration is already
+// in the canonical layout, which also proves it parses.
+func TestGenerateTerragruntConfig_TerraformFmt(t *testing.T) {
+	binary, err := exec.LookPath("terraform")
+	if err != nil {
+		if binary, err = exec.LookPath("tofu"); err != nil {
+			t.Skip("neither terraform nor tofu is installed")
+		}
+	}
+
+	svc := &gitService{logger: zap.NewNop()}
+	for name, spec := range map[string]string{"empty": `{}`, "scalars": `{"cores":2,"on_boot":true}`, "complex": complexSpec} {
+		t.Run(name, func(t *testing.T) {
+			request := &model.ResourceRequest{Title: "vm", Spec: spec}
+			config, err := svc.generateTerragruntConfig(request, "vm-1", &model.GitRepository{URL: "git::https://git.example.com/modules.git"}, nil)
+			require.NoError(t, err)
+
+			// fmt only reads .tf files; it checks the syntax without evaluating it
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "terragrunt.tf"), []byte(config), 0o600))
+			out, err := exec.Command(binary, "fmt", "-check", "-diff", dir).CombinedOutput() // #nosec G204 -- test binary from PATH
+			require.NoError(t, err, "generated config is not canonical:\n%s\n%s", out, config)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+# Variables of a VM module, covering the shapes module forms need to render.
+
+variable "disks" {
+  description = "Disks to attach, in order."
+  type        = list(object({ size = number, storage = string }))
+  default = [
+    {
+      size    = 20
+      storage = "local-lvm"
+    },
+    { size = 100, storage = "ceph" }, # trailing comma
+  ]
+}
+
+variable "labels" {
+  type = map(any)
+  default = {
+    owner         = "platform"
+    "cost-center" = "lab"
+    backup        = true
+  }
+}
+
+variable "dns_servers" {
+  type    = list(string)
+  default = ["10.0.0.53", "10.0.1.53"]
+}
+
+variable "network" {
+  type = object({
+    vlan   = number
+    bridge = optional(string, "vmbr0")
+  })
+  default = {
+    vlan   = 100
+    bridge = "vmbr1" // overridden bridge
+  }
+}
+
+variable "root_password" {
+  description = "Initial root password."
+  type        = string
+  sensitive   = true
+}
+
+variable "user_data" {
+  description = <<-EOT
+    Cloud-init user data.
+    Rendered as is.
+  EOT
+  type        = string
+  default     = <<EOT
+#cloud-config
+packages:
+  - qemu-guest-agent
+EOT
+}
+
+variable "name_prefix" {
+  type    = string
+  default = "${local.env}-vm"
+}
+
+variable "optional_null" {
+  default = null
+}
+
+/* A commented out block { with braces }
+variable "ignored" {}
+*/
+
+variable "validated" {
+  type = string
+
+  validation {
+    condition     = length(var.validated) > 0 && !contains(["}"], var.validated)
+    error_message = "The value must not be empty { or a brace }."
+  }
+}
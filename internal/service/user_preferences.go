@@ -0,0 +1,115 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"go.uber.org/zap"
+)
+
+// ErrMissingDefault indicates a field was left out of a create call and the user has no
+// preference to fill it from.
+var ErrMissingDefault = errors.New("field is required when no default preference is set")
+
+// UserPreferenceSource looks up the defaults a user has saved for new requests and resources.
+type UserPreferenceSource interface {
+	GetPreferences(ctx context.Context, userID string) (*model.UserPreferences, error)
+}
+
+// GetPreferences returns the saved defaults of a user.
+func (s *userService) GetPreferences(ctx context.Context, userID string) (*model.UserPreferences, error) {
+	if userID == "" {
+		return nil, errors.New("id cannot be empty")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := user.Preferences
+	return &prefs, nil
+}
+
+// UpdatePreferences replaces the saved defaults of a user. Empty values clear a default.
+func (s *userService) UpdatePreferences(ctx context.Context, userID string, input model.UserPreferences) (*model.UserPreferences, error) {
+	if userID == "" {
+		return nil, errors.New("id cannot be empty")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.DefaultZoneID != nil && strings.TrimSpace(*input.DefaultZoneID) == "" {
+		input.DefaultZoneID = nil
+	}
+	user.Preferences = input
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("failed to update user preferences", zap.Error(err))
+		return nil, errors.New("failed to update user preferences")
+	}
+	prefs := user.Preferences
+	return &prefs, nil
+}
+
+// userDefaults returns the saved preferences of userID, or nil when there is nothing to
+// apply. A failed lookup is logged and treated as no preferences so explicit values still work.
+func (s *resourceService) userDefaults(ctx context.Context, userID string) *model.UserPreferences {
+	if s.preferences == nil || userID == "" {
+		return nil
+	}
+	prefs, err := s.preferences.GetPreferences(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			s.logger.Warn("failed to load user preferences", zap.String("user_id", userID), zap.Error(err))
+		}
+		return nil
+	}
+	return prefs
+}
+
+// applyRequestDefaults fills the environment, provider and zone input omits from the
+// requester's preferences, and fails if environment or provider is still unset.
+func (s *resourceService) applyRequestDefaults(ctx context.Context, input *CreateRequestInput) error {
+	if prefs := s.userDefaults(ctx, input.RequesterID); prefs != nil {
+		if input.Environment == "" {
+			input.Environment = prefs.DefaultEnvironment
+		}
+		if input.Provider == "" {
+			input.Provider = prefs.DefaultProvider
+		}
+		if input.ZoneID == nil && input.RegionID == nil && prefs.DefaultZoneID != nil {
+			zoneID := *prefs.DefaultZoneID
+			input.ZoneID = &zoneID
+		}
+	}
+	return requireDefaulted(input.Environment, input.Provider)
+}
+
+// applyResourceDefaults fills the environment and provider input omits from the owner's
+// preferences, and fails if either is still unset.
+func (s *resourceService) applyResourceDefaults(ctx context.Context, input *CreateResourceInput) error {
+	if prefs := s.userDefaults(ctx, input.OwnerID); prefs != nil {
+		if input.Environment == "" {
+			input.Environment = prefs.DefaultEnvironment
+		}
+		if input.Provider == "" {
+			input.Provider = prefs.DefaultProvider
+		}
+	}
+	return requireDefaulted(input.Environment, input.Provider)
+}
+
+func requireDefaulted(environment, provider string) error {
+	if environment == "" {
+		return fmt.Errorf("%w: environment", ErrMissingDefault)
+	}
+	if provider == "" {
+		return fmt.Errorf("%w: provider", ErrMissingDefault)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Package service provides user preference tests.
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubPreferenceSource returns fixed preferences per user.
+type stubPreferenceSource map[string]*model.UserPreferences
+
+func (s stubPreferenceSource) GetPreferences(_ context.Context, userID string) (*model.UserPreferences, error) {
+	prefs, ok := s[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return prefs, nil
+}
+
+func TestResourceService_CreateRequestUserDefaults(t *testing.T) {
+	zoneID := "zone-1"
+	prefs := stubPreferenceSource{
+		"user-1": {DefaultEnvironment: "test", DefaultProvider: "vmware", DefaultZoneID: &zoneID},
+	}
+	newService := func() ResourceService {
+		requestRepo := new(MockResourceRequestRepository)
+		requestRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.ResourceRequest")).Return(nil)
+		return NewResourceService(nil, requestRepo, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, prefs, zap.NewNop())
+	}
+
+	t.Run("omitted fields use the requester's preferences", func(t *testing.T) {
+		request, err := newService().CreateRequest(context.Background(), &CreateRequestInput{
+			Title: "vms", Type: "vm", RequesterID: "user-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "vmware", request.Provider)
+		assert.Equal(t, "test", request.Environment)
+		require.NotNil(t, request.ZoneID)
+		assert.Equal(t, "zone-1", *request.ZoneID)
+	})
+
+	t.Run("explicit values override the preferences", func(t *testing.T) {
+		otherZone := "zone-2"
+		request, err := newService().CreateRequest(context.Background(), &CreateRequestInput{
+			Title: "vms", Type: "vm", Environment: "prod", Provider: "pve", ZoneID: &otherZone, RequesterID: "user-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "pve", request.Provider)
+		assert.Equal(t, "prod", request.Environment)
+		assert.Equal(t, "zone-2", *request.ZoneID)
+	})
+
+	t.Run("a chosen region keeps the default zone out", func(t *testing.T) {
+		regionID := "region-1"
+		request, err := newService().CreateRequest(context.Background(), &CreateRequestInput{
+			Title: "vms", Type: "vm", RegionID: &regionID, RequesterID: "user-1",
+		})
+		require.NoError(t, err)
+		assert.Nil(t, request.ZoneID)
+	})
+
+	t.Run("no preference and no value is rejected", func(t *testing.T) {
+		_, err := newService().CreateRequest(context.Background(), &CreateRequestInput{
+			Title: "vms", Type: "vm", Environment: "dev", RequesterID: "user-2",
+		})
+		require.ErrorIs(t, err, ErrMissingDefault)
+		assert.Contains(t, err.Error(), "provider")
+	})
+}
+
+func TestResourceService_CreateUserDefaults(t *testing.T) {
+	repo := new(MockResourceRepository)
+	repo.On("ExistsByName", mock.Anything, "owner-1", "staging", "vm-1", "").Return(false, nil)
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*model.Resource")).Return(nil)
+	prefs := stubPreferenceSource{"owner-1": {DefaultEnvironment: "staging", DefaultProvider: "openstack"}}
+	svc := NewResourceService(repo, nil, nil, nil, nil, nil, nil, nil, 0, nil, nil, nil, prefs, zap.NewNop())
+
+	resource, err := svc.Create(context.Background(), &CreateResourceInput{Name: "vm-1", Type: "vm", Provider: "pve", OwnerID: "owner-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "pve", resource.Provider)
+	assert.Equal(t, "staging", resource.Environment)
+}
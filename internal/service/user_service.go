@@ -25,6 +25,8 @@ type UserService interface {
 	ResetPassword(ctx context.Context, id, newPassword string) error
 	AssignRole(ctx context.Context, userID, roleID string) error
 	RemoveRole(ctx context.Context, userID, roleID string) error
+	GetPreferences(ctx context.Context, userID string) (*model.UserPreferences, error)
+	UpdatePreferences(ctx context.Context, userID string, input model.UserPreferences) (*model.UserPreferences, error)
 }
 
 // userService implements UserService.
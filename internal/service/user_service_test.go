@@ -82,6 +82,20 @@ func (m *MockRoleRepository) RemovePermissions(ctx context.Context, roleID strin
 	return args.Error(0)
 }
 
+func (m *MockRoleRepository) SetPermissions(ctx context.Context, roleID string, permissionIDs []string) error {
+	args := m.Called(ctx, roleID, permissionIDs)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) ListPermissions(ctx context.Context, roleID string) ([]*model.Permission, error) {
+	args := m.Called(ctx, roleID)
+	permissions, ok := args.Get(0).([]*model.Permission)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return permissions, args.Error(1)
+}
+
 // Ensure MockRoleRepository implements repository.RoleRepository.
 var _ repository.RoleRepository = (*MockRoleRepository)(nil)
 
@@ -0,0 +1,125 @@
+// Package service provides business logic implementations.
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+)
+
+const (
+	// vsphereSDKPath is the path of the vSphere Web Services (SOAP) API.
+	vsphereSDKPath = "/sdk"
+	// vsphereSOAPAction names the API version requests are made against; every vCenter
+	// and ESXi release since 7.0 accepts it.
+	vsphereSOAPAction = "urn:vim25/7.0"
+)
+
+// vsphereEnvelope is a vSphere SOAP response: the result of a call, or a fault.
+type vsphereEnvelope struct {
+	Body struct {
+		Fault *struct {
+			String string `xml:"faultstring"`
+			Detail struct {
+				Inner string `xml:",innerxml"`
+			} `xml:"detail"`
+		} `xml:"Fault"`
+		SessionManager string `xml:"RetrieveServiceContentResponse>returnval>sessionManager"`
+		SessionKey     string `xml:"LoginResponse>returnval>key"`
+	} `xml:"Body"`
+}
+
+// testVMwareConnection tests connection to a VMware vCenter/ESXi server by logging in to
+// its SOAP API with the credential's user name and password and logging out again.
+// Without a credential only the service content is read, which needs no session.
+func (s *settingsService) testVMwareConnection(ctx context.Context, endpoint string, credential *model.Credential, opts connectionOptions) error {
+	sdk, err := apiBaseURL(endpoint, vsphereSDKPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.ConnectionTestTimeout)
+	defer cancel()
+	client := connectionTestClient(opts)
+
+	content, _, err := vsphereCall(ctx, client, sdk, nil,
+		`<RetrieveServiceContent xmlns="urn:vim25"><_this type="ServiceInstance">ServiceInstance</_this></RetrieveServiceContent>`)
+	if err != nil {
+		return err
+	}
+	if content.Body.SessionManager == "" {
+		return &ConnectionError{Kind: ConnectionErrorResponse, Err: errors.New("response is not from the vSphere API")}
+	}
+	if credential == nil || credential.AccessKey == "" {
+		return nil
+	}
+
+	sessionManager := xmlEscape(content.Body.SessionManager)
+	login, cookies, err := vsphereCall(ctx, client, sdk, nil, fmt.Sprintf(
+		`<Login xmlns="urn:vim25"><_this type="SessionManager">%s</_this><userName>%s</userName><password>%s</password></Login>`,
+		sessionManager, xmlEscape(credential.AccessKey), xmlEscape(credential.SecretKey)))
+	if err != nil {
+		return err
+	}
+	if login.Body.SessionKey == "" {
+		return &ConnectionError{Kind: ConnectionErrorResponse, Err: errors.New("login response has no session")}
+	}
+
+	// The session is of no further use; leaving it open would hold one of the server's
+	// sessions until it times out
+	_, _, err = vsphereCall(ctx, client, sdk, cookies,
+		fmt.Sprintf(`<Logout xmlns="urn:vim25"><_this type="SessionManager">%s</_this></Logout>`, sessionManager))
+	return err
+}
+
+// vsphereCall sends a SOAP request with body to the vSphere API at sdk, with cookies, and
+// returns the parsed response and the cookies it set. A fault is returned as an error,
+// InvalidLogin as an authentication failure.
+func vsphereCall(ctx context.Context, client *http.Client, sdk string, cookies []*http.Cookie, body string) (*vsphereEnvelope, []*http.Cookie, error) {
+	envelope := `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"><soapenv:Body>` +
+		body + `</soapenv:Body></soapenv:Envelope>`
+	header := http.Header{
+		"Content-Type": {"text/xml; charset=utf-8"},
+		"Soapaction":   {vsphereSOAPAction},
+	}
+	for _, cookie := range cookies {
+		header.Add("Cookie", (&http.Cookie{Name: cookie.Name, Value: cookie.Value}).String())
+	}
+
+	resp, err := connectionTestRequest(ctx, client, http.MethodPost, sdk, header, bytes.NewReader([]byte(envelope)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Faults come with a 500 status, so the body is read before the status is checked
+	var parsed vsphereEnvelope
+	xmlErr := xml.Unmarshal(resp.body, &parsed)
+	if fault := parsed.Body.Fault; xmlErr == nil && fault != nil {
+		if strings.Contains(fault.Detail.Inner, "InvalidLogin") {
+			return nil, nil, &ConnectionError{Kind: ConnectionErrorAuth, Err: errors.New("incorrect user name or password")}
+		}
+		return nil, nil, &ConnectionError{Kind: ConnectionErrorResponse, Err: fmt.Errorf("vSphere fault: %s", fault.String)}
+	}
+	if err := classifyStatus(resp.Response); err != nil {
+		return nil, nil, err
+	}
+	if xmlErr != nil {
+		return nil, nil, &ConnectionError{Kind: ConnectionErrorResponse, Err: errors.New("response is not from the vSphere API")}
+	}
+	return &parsed, resp.Cookies(), nil
+}
+
+// xmlEscape escapes s for use as XML character data.
+func xmlEscape(s string) string {
+	var buf strings.Builder
+	_ = xml.EscapeText(&buf, []byte(s)) //nolint:errcheck // a strings.Builder never fails to write
+	return buf.String()
+}
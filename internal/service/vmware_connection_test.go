@@ -0,0 +1,134 @@
+// Package service provides VMware vSphere connection tests.
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const vsphereServiceContentResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<soapenv:Envelope xmlns:soapenc="http://schemas.xmlsoap.org/soap/encoding/" xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<soapenv:Body>
+<RetrieveServiceContentResponse xmlns="urn:vim25"><returnval><rootFolder type="Folder">group-d1</rootFolder><about><name>VMware vCenter Server</name><apiVersion>8.0.3.0</apiVersion></about><sessionManager type="SessionManager">SessionManager</sessionManager></returnval></RetrieveServiceContentResponse>
+</soapenv:Body>
+</soapenv:Envelope>`
+
+const vsphereLoginResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<soapenv:Body>
+<LoginResponse xmlns="urn:vim25"><returnval><key>52a1b6c2-0f3e-4d5b-9c8a-7e6f5d4c3b2a</key><userName>VSPHERE.LOCAL\Administrator</userName></returnval></LoginResponse>
+</soapenv:Body>
+</soapenv:Envelope>`
+
+const vsphereInvalidLoginFault = `<?xml version="1.0" encoding="UTF-8"?>
+<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<soapenv:Body>
+<soapenv:Fault><faultcode>ServerFaultCode</faultcode><faultstring>Cannot complete login due to an incorrect user name or password.</faultstring><detail><InvalidLoginFault xmlns="urn:vim25" xsi:type="InvalidLogin"></InvalidLoginFault></detail></soapenv:Fault>
+</soapenv:Body>
+</soapenv:Envelope>`
+
+const vsphereLogoutResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/">
+<soapenv:Body><LogoutResponse xmlns="urn:vim25"></LogoutResponse></soapenv:Body>
+</soapenv:Envelope>`
+
+// fakeVSphere mimics the SOAP API of vCenter, accepting the password of
+// administrator@vsphere.local. The names of the methods called are recorded in calls.
+func fakeVSphere(t *testing.T, calls *[]string) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/sdk" {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, vsphereSOAPAction, r.Header.Get("SOAPAction"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		request := string(body)
+
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		switch {
+		case strings.Contains(request, "<RetrieveServiceContent "):
+			*calls = append(*calls, "RetrieveServiceContent")
+			_, _ = w.Write([]byte(vsphereServiceContentResponse)) //nolint:errcheck // test server
+		case strings.Contains(request, "<Login "):
+			*calls = append(*calls, "Login")
+			if !strings.Contains(request, "<userName>administrator@vsphere.local</userName><password>s3cret&lt;&amp;&gt;</password>") {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(vsphereInvalidLoginFault)) //nolint:errcheck // test server
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "vmware_soap_session", Value: "52a1b6c2", Path: "/", HttpOnly: true, Secure: true})
+			_, _ = w.Write([]byte(vsphereLoginResponse)) //nolint:errcheck // test server
+		case strings.Contains(request, "<Logout "):
+			cookie, err := r.Cookie("vmware_soap_session")
+			if assert.NoError(t, err, "logout is sent with the session") {
+				assert.Equal(t, "52a1b6c2", cookie.Value)
+			}
+			*calls = append(*calls, "Logout")
+			_, _ = w.Write([]byte(vsphereLogoutResponse)) //nolint:errcheck // test server
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+}
+
+func TestSettingsService_TestVMwareConnection(t *testing.T) {
+	ctx := context.Background()
+	svc := &settingsService{}
+	var calls []string
+	server := httptest.NewServer(fakeVSphere(t, &calls))
+	defer server.Close()
+
+	t.Run("login and logout", func(t *testing.T) {
+		calls = nil
+		credential := &model.Credential{AccessKey: "administrator@vsphere.local", SecretKey: "s3cret<&>"}
+		require.NoError(t, svc.testVMwareConnection(ctx, server.URL, credential, connectionOptions{}))
+		assert.Equal(t, []string{"RetrieveServiceContent", "Login", "Logout"}, calls)
+	})
+
+	t.Run("invalid login", func(t *testing.T) {
+		calls = nil
+		credential := &model.Credential{AccessKey: "administrator@vsphere.local", SecretKey: "wrong"}
+		err := svc.testVMwareConnection(ctx, server.URL+"/sdk", credential, connectionOptions{})
+		assert.Equal(t, ConnectionErrorAuth, connectionErrorKind(t, err))
+		assert.Equal(t, "authentication failed: incorrect user name or password", err.Error())
+		assert.Equal(t, []string{"RetrieveServiceContent", "Login"}, calls)
+	})
+
+	t.Run("reachability without a credential", func(t *testing.T) {
+		calls = nil
+		require.NoError(t, svc.testVMwareConnection(ctx, server.URL, nil, connectionOptions{}))
+		assert.Equal(t, []string{"RetrieveServiceContent"}, calls)
+	})
+
+	t.Run("not a vSphere server", func(t *testing.T) {
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("<html>It works!</html>")) //nolint:errcheck // test server
+		}))
+		defer other.Close()
+
+		err := svc.testVMwareConnection(ctx, other.URL, nil, connectionOptions{})
+		assert.Equal(t, ConnectionErrorResponse, connectionErrorKind(t, err))
+	})
+
+	t.Run("unverified certificate is allowed when configured", func(t *testing.T) {
+		tlsServer := httptest.NewTLSServer(fakeVSphere(t, &calls))
+		defer tlsServer.Close()
+
+		err := svc.testVMwareConnection(ctx, tlsServer.URL, nil, connectionOptions{})
+		assert.Equal(t, ConnectionErrorTLS, connectionErrorKind(t, err))
+
+		opts, err := parseConnectionOptions(`{"allow_unverified_ssl": true}`)
+		require.NoError(t, err)
+		require.NoError(t, svc.testVMwareConnection(ctx, tlsServer.URL, nil, opts))
+	})
+}
@@ -0,0 +1,177 @@
+// Package terraform provides Terraform execution utilities.
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ArtifactPlanName is the archive entry the saved plan is rendered to by WriteArtifacts.
+const ArtifactPlanName = "plan.txt"
+
+// RedactedSecretValue replaces the secrets in the files of an artifacts archive.
+const RedactedSecretValue = "(redacted)"
+
+// stringAssignment matches a line of terraform.tfvars, terragrunt.hcl or .terraformrc that
+// sets a variable to a string literal; the second and third groups are its name and value.
+var stringAssignment = regexp.MustCompile(`(?m)^(\s*([A-Za-z_][A-Za-z0-9_-]*)\s*=\s*)"(.*)"[ \t]*$`)
+
+// minRedactedSecretLength is the shortest secret replaced wherever it appears; shorter ones
+// would redact ordinary text, so only their assignments are redacted.
+const minRedactedSecretLength = 4
+
+// isArtifactFile reports whether a file at the top level of a work directory goes into an
+// artifacts archive: the generated configuration, except .terraformrc, which carries the
+// registry token.
+func isArtifactFile(name string) bool {
+	return isConfigFile(name) && name != ".terraformrc"
+}
+
+// WriteArtifacts writes a zip archive of the configuration generated in workDir to w, for
+// review outside the server. The saved plan, if there is one, is added rendered as text under
+// ArtifactPlanName. Every secret passed to the runs in workDir is redacted (see runSecrets);
+// state, the git home and other files the runs leave behind are not included.
+func (e *Executor) WriteArtifacts(ctx context.Context, workDir string, w io.Writer) error {
+	entries, err := os.ReadDir(workDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrWorkDirNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read work directory: %w", err)
+	}
+
+	var names []string
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !isConfigFile(name) {
+			continue
+		}
+		content, readErr := os.ReadFile(filepath.Join(workDir, name)) // #nosec G304 --  name comes from the work directory listing
+		if readErr != nil {
+			return fmt.Errorf("failed to read %s: %w", name, readErr)
+		}
+		names = append(names, name)
+		files[name] = content
+	}
+	secrets, err := e.runSecrets(workDir, files)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		if !isArtifactFile(name) {
+			continue
+		}
+		if err := writeArtifact(archive, name, redactSecrets(files[name], secrets)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(workDir, planFile)); err == nil {
+		show := e.runCommand(ctx, workDir, "show plan",
+			[]string{"show", "-no-color", planFile},
+			[]string{"show", "--terragrunt-non-interactive", "-no-color", planFile},
+		)
+		if !show.Success {
+			return fmt.Errorf("failed to render plan: %s", show.Error)
+		}
+		if err := writeArtifact(archive, ArtifactPlanName, redactSecrets([]byte(show.Output), secrets)); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// runSecrets returns every secret passed to the runs in workDir, longest first: the provider
+// password and token kept in memory for them, every secret variable set in the generated
+// configuration files, including the registry token in .terraformrc, and the git token in the
+// git home's .netrc.
+func (e *Executor) runSecrets(workDir string, files map[string][]byte) ([]string, error) {
+	var secrets []string
+	add := func(value string) {
+		if value != "" && !slices.Contains(secrets, value) {
+			secrets = append(secrets, value)
+		}
+	}
+
+	for _, entry := range e.secretEnvFor(workDir) {
+		if _, value, ok := strings.Cut(entry, "="); ok {
+			add(value)
+		}
+	}
+	for _, content := range files {
+		for _, match := range stringAssignment.FindAllSubmatch(content, -1) {
+			if isSecretVariable(string(match[2])) {
+				add(unquoteHCL(string(match[3])))
+			}
+		}
+	}
+
+	netrc, err := os.ReadFile(filepath.Join(gitHome(workDir), ".netrc")) // #nosec G304 -- the path is built from the work directory
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read .netrc: %w", err)
+	}
+	fields := strings.Fields(string(netrc))
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "password" {
+			add(fields[i+1])
+		}
+	}
+
+	// Longer secrets go first, so one that contains another is replaced whole
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+	return secrets, nil
+}
+
+// unquoteHCL returns the value of a string literal's content as written by %q, or the
+// content itself when it does not unquote.
+func unquoteHCL(content string) string {
+	if value, err := strconv.Unquote(`"` + content + `"`); err == nil {
+		return value
+	}
+	return content
+}
+
+// redactSecrets replaces the value of every secret variable set in content, and then
+// every one of secrets that appears anywhere in it.
+func redactSecrets(content []byte, secrets []string) []byte {
+	content = stringAssignment.ReplaceAllFunc(content, func(line []byte) []byte {
+		match := stringAssignment.FindSubmatch(line)
+		if !isSecretVariable(string(match[2])) {
+			return line
+		}
+		return append(append([]byte{}, match[1]...), `"`+RedactedSecretValue+`"`...)
+	})
+	for _, secret := range secrets {
+		if len(secret) >= minRedactedSecretLength {
+			content = bytes.ReplaceAll(content, []byte(secret), []byte(RedactedSecretValue))
+		}
+	}
+	return content
+}
+
+// writeArtifact adds a file named name with content to archive.
+func writeArtifact(archive *zip.Writer, name string, content []byte) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	if _, err := file.Write(content); err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	return nil
+}
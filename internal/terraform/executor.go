@@ -5,12 +5,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -18,7 +22,34 @@ import (
 
 // Executor handles Terraform operations.
 type Executor struct {
-	logger *zap.Logger
+	logger       *zap.Logger
+	run          commandRunner
+	specDefaults SpecDefaults
+	workspace    Workspace
+
+	// secretsInFiles writes provider passwords and tokens into the generated files instead
+	// of passing them to commands as TF_VAR_ environment variables.
+	secretsInFiles bool
+	secretsMu      sync.Mutex
+	secretEnv      map[string][]string // Work dir to TF_VAR_ entries for its secrets
+}
+
+// commandRunner runs an external command in dir and returns its captured stdout and stderr.
+type commandRunner func(ctx context.Context, dir string, env []string, name string, args ...string) (string, string, error)
+
+// execRunner runs commands with os/exec.
+func execRunner(ctx context.Context, dir string, env []string, name string, args ...string) (string, string, error) {
+	// codeql[go/command-injection] safe: command and arguments are controlled by application logic
+	cmd := exec.CommandContext(ctx, name, args...) // #nosec G204 --  args controlled by application logic
+	cmd.Dir = dir
+	cmd.Env = env
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
 }
 
 // ExecutionResult contains the result of a Terraform execution.
@@ -28,6 +59,12 @@ type ExecutionResult struct {
 	Error    string            `json:"error"`
 	Duration time.Duration     `json:"duration"`
 	Outputs  map[string]string `json:"outputs"`
+	// SensitiveOutputs names the outputs declared sensitive; their values are in Outputs.
+	SensitiveOutputs []string `json:"sensitive_outputs,omitempty"`
+	// CreatedAddresses lists the resource addresses a successful apply created.
+	CreatedAddresses []string `json:"created_addresses,omitempty"`
+	// Plan summarizes the resource changes of a successful destroy plan.
+	Plan *PlanSummary `json:"plan,omitempty"`
 }
 
 // Config represents configuration for terraform file generation.
@@ -36,6 +73,9 @@ type Config struct {
 	Environment string                 `json:"environment"` // dev, test, staging, prod
 	Spec        map[string]interface{} `json:"spec"`        // Resource specifications
 
+	// VarOverrides sets module variables beyond the spec. Credential variables are ignored.
+	VarOverrides map[string]string `json:"var_overrides"`
+
 	// Git authentication for module downloads
 	GitHost     string `json:"git_host"`     // Git server host (e.g., git.example.com)
 	GitUsername string `json:"git_username"` // Git username
@@ -67,6 +107,11 @@ const (
 	filePerm = 0o644 // File permissions (rw-r--r--)
 )
 
+// RedactedOutputValue replaces the value of each sensitive output returned by GetOutputsRaw.
+const RedactedOutputValue = "(sensitive value)"
+
+var redactedOutputJSON = json.RawMessage(strconv.Quote(RedactedOutputValue))
+
 // ansiRegex matches ANSI escape sequences.
 var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
 
@@ -75,10 +120,18 @@ func stripANSI(s string) string {
 	return ansiRegex.ReplaceAllString(s, "")
 }
 
-// NewExecutor creates a new Terraform executor.
-func NewExecutor(logger *zap.Logger) *Executor {
+// NewExecutor creates a new Terraform executor. specDefaults fill in the spec fields a
+// request omits when raw provider configuration is generated. Provider passwords and tokens
+// are passed to commands as TF_VAR_ environment variables unless secretsInFiles is set, in
+// which case they are written to terraform.tfvars and terragrunt.hcl. workspace places the
+// work directories and sets what Cleanup leaves in them.
+func NewExecutor(logger *zap.Logger, specDefaults SpecDefaults, secretsInFiles bool, workspace Workspace) *Executor {
 	return &Executor{
-		logger: logger,
+		logger:         logger,
+		run:            execRunner,
+		specDefaults:   specDefaults,
+		workspace:      workspace,
+		secretsInFiles: secretsInFiles,
 	}
 }
 
@@ -97,23 +150,43 @@ func (e *Executor) InitWithConfig(workDir string, config Config) error {
 // netrcFilePermission is the permission for .netrc files.
 const netrcFilePermission = 0o600
 
-// configureGitCredentials sets up Git credentials for module downloads.
+// gitHomeDir is the directory inside a work dir that serves as HOME for terraform and
+// terragrunt, so git finds the run's .netrc without touching the process environment.
+const gitHomeDir = ".git-home"
+
+// gitHomePermission keeps the git home, and the credentials in it, private to the service user.
+const gitHomePermission = 0o700
+
+// gitHome returns the HOME directory used for commands run in workDir.
+func gitHome(workDir string) string {
+	return filepath.Join(workDir, gitHomeDir)
+}
+
+// configureGitCredentials writes a .netrc for module downloads into the work dir's git home.
+// Only commands started for this work dir see it, through the HOME set by buildEnv.
 func (e *Executor) configureGitCredentials(workDir string, config Config) error {
+	for _, value := range []string{config.GitHost, config.GitUsername, config.GitToken} {
+		if strings.ContainsAny(value, " \t\r\n") {
+			return errors.New("git credentials must not contain whitespace")
+		}
+	}
+
+	home := gitHome(workDir)
+	if err := os.MkdirAll(home, gitHomePermission); err != nil {
+		return fmt.Errorf("failed to create git home: %w", err)
+	}
+
 	// Create .netrc file for HTTPS authentication
 	netrcContent := fmt.Sprintf("machine %s\nlogin %s\npassword %s\n",
 		config.GitHost,
 		config.GitUsername,
 		config.GitToken,
 	)
-	netrcPath := filepath.Join(workDir, ".netrc")
+	netrcPath := filepath.Join(home, ".netrc")
 	if err := os.WriteFile(netrcPath, []byte(netrcContent), netrcFilePermission); err != nil {
 		return fmt.Errorf("failed to write .netrc: %w", err)
 	}
 
-	// Set HOME to workDir so git uses our .netrc
-	if err := os.Setenv("HOME", workDir); err != nil {
-		return fmt.Errorf("failed to set HOME: %w", err)
-	}
 	e.logger.Info("configured git credentials", zap.String("host", config.GitHost))
 	return nil
 }
@@ -136,79 +209,64 @@ func (e *Executor) buildEnv(workDir string) []string {
 		e.logger.Info("using custom terraform config", zap.String("config", rcPath))
 	}
 
-	// Check if .netrc exists and set HOME to workDir
-	netrcPath := filepath.Join(workDir, ".netrc")
+	// Point HOME at the work dir's git home when credentials were configured; later entries
+	// override the inherited HOME for this command only
+	netrcPath := filepath.Join(gitHome(workDir), ".netrc")
 	if _, err := os.Stat(netrcPath); err == nil {
-		env = append(env, fmt.Sprintf("HOME=%s", workDir))
+		env = append(env, fmt.Sprintf("HOME=%s", gitHome(workDir)))
 		e.logger.Info("using .netrc for git authentication", zap.String("path", netrcPath))
 	}
 
-	return env
+	return append(env, e.secretEnvFor(workDir)...)
 }
 
 // Init initializes a Terraform/Terragrunt working directory.
 func (e *Executor) Init(workDir string) error {
 	ctx := context.Background()
 
-	var cmd *exec.Cmd
+	var stdout, stderr string
+	var err error
 	if e.isTerragrunt(workDir) {
-		cmd = exec.CommandContext(ctx, "terragrunt", "init", "--terragrunt-non-interactive")
 		e.logger.Info("using terragrunt init")
+		stdout, stderr, err = e.run(ctx, workDir, e.buildEnv(workDir), "terragrunt", "init", "--terragrunt-non-interactive")
 	} else {
-		cmd = exec.CommandContext(ctx, "terraform", "init", "-no-color")
 		e.logger.Info("using terraform init")
+		stdout, stderr, err = e.run(ctx, workDir, e.buildEnv(workDir), "terraform", "init", "-no-color")
 	}
-	cmd.Dir = workDir
-	cmd.Env = e.buildEnv(workDir)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
+	if err != nil {
 		e.logger.Error("init failed",
-			zap.String("stderr", stderr.String()),
-			zap.String("stdout", stdout.String()),
+			zap.String("stderr", stderr),
+			zap.String("stdout", stdout),
 			zap.Error(err),
 		)
-		return fmt.Errorf("init failed: %s", stripANSI(stderr.String()))
+		return fmt.Errorf("init failed: %s", stripANSI(stderr))
 	}
 
-	e.logger.Info("init completed", zap.String("output", stripANSI(stdout.String())))
+	e.logger.Info("init completed", zap.String("output", stripANSI(stdout)))
 	return nil
 }
 
 // runCommand executes a terraform/terragrunt command and returns the result.
-func (e *Executor) runCommand(workDir, operation string, tfArgs, tgArgs []string) *ExecutionResult {
+func (e *Executor) runCommand(ctx context.Context, workDir, operation string, tfArgs, tgArgs []string) *ExecutionResult {
 	start := time.Now()
 	result := &ExecutionResult{}
-	ctx := context.Background()
 
-	var cmd *exec.Cmd
+	binary, args := "terraform", tfArgs
 	if e.isTerragrunt(workDir) {
-		// codeql[go/command-injection] safe: arguments are controlled by application logic
-		cmd = exec.CommandContext(ctx, "terragrunt", tgArgs...) // #nosec G204 --  args controlled by application logic
-	} else {
-		// codeql[go/command-injection] safe: arguments are controlled by application logic
-		cmd = exec.CommandContext(ctx, "terraform", tfArgs...) // #nosec G204 --  args controlled by application logic
+		binary, args = "terragrunt", tgArgs
 	}
-	cmd.Dir = workDir
-	cmd.Env = e.buildEnv(workDir)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
+	stdout, stderr, err := e.run(ctx, workDir, e.buildEnv(workDir), binary, args...)
 	result.Duration = time.Since(start)
-	result.Output = stripANSI(stdout.String())
+	result.Output = stripANSI(stdout)
 
 	if err != nil {
 		e.logger.Error(operation+" failed",
 			zap.Error(err),
-			zap.String("stderr", stripANSI(stderr.String())),
+			zap.String("stderr", stripANSI(stderr)),
 		)
-		result.Error = stripANSI(stderr.String())
+		result.Error = stripANSI(stderr)
 		return result
 	}
 
@@ -216,77 +274,199 @@ func (e *Executor) runCommand(workDir, operation string, tfArgs, tgArgs []string
 	return result
 }
 
-// Plan runs terraform/terragrunt plan.
+// Plan runs terraform/terragrunt plan and records a hash of the configuration it was built from.
 func (e *Executor) Plan(workDir string) *ExecutionResult {
-	return e.runCommand(workDir, "plan",
-		[]string{"plan", "-no-color", "-out=tfplan"},
-		[]string{"plan", "--terragrunt-non-interactive", "-out=tfplan"},
+	result := e.runCommand(context.Background(), workDir, "plan",
+		[]string{"plan", "-no-color", "-out=" + planFile},
+		[]string{"plan", "--terragrunt-non-interactive", "-out=" + planFile},
 	)
+	if result.Success {
+		if err := recordPlanHash(workDir, planHashFile); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+		}
+	}
+	return result
 }
 
 // Apply applies the Terraform/Terragrunt plan.
+// It refuses to run when the configuration no longer matches the one the plan was created from.
 func (e *Executor) Apply(workDir string) *ExecutionResult {
-	result := e.runCommand(workDir, "apply",
-		[]string{"apply", "-no-color", "-auto-approve", "tfplan"},
-		[]string{"apply", "--terragrunt-non-interactive", "-auto-approve", "tfplan"},
+	if err := verifyPlanHash(workDir, planHashFile); err != nil {
+		e.logger.Error("refusing to apply plan", zap.String("work_dir", workDir), zap.Error(err))
+		return &ExecutionResult{Error: err.Error()}
+	}
+
+	result := e.runCommand(context.Background(), workDir, "apply",
+		[]string{"apply", "-no-color", "-auto-approve", planFile},
+		[]string{"apply", "--terragrunt-non-interactive", "-auto-approve", planFile},
 	)
 	if result.Success {
-		result.Outputs = e.GetOutputs(workDir)
+		result.CreatedAddresses = CreatedResourceAddresses(result.Output)
+		result.Outputs, result.SensitiveOutputs = e.GetOutputs(workDir)
 	}
 	return result
 }
 
-// Destroy destroys the Terraform/Terragrunt-managed infrastructure.
-func (e *Executor) Destroy(workDir string) *ExecutionResult {
-	return e.runCommand(workDir, "destroy",
-		[]string{"destroy", "-no-color", "-auto-approve"},
-		[]string{"destroy", "--terragrunt-non-interactive", "-auto-approve"},
+// DestroyPlan runs a destroy plan without changing any infrastructure and summarizes what
+// Destroy would remove. The saved plan is what Destroy later applies.
+func (e *Executor) DestroyPlan(ctx context.Context, workDir string) *ExecutionResult {
+	result := e.runCommand(ctx, workDir, "destroy plan",
+		[]string{"plan", "-destroy", "-no-color", "-out=" + destroyPlanFile},
+		[]string{"plan", "-destroy", "--terragrunt-non-interactive", "-out=" + destroyPlanFile},
+	)
+	if !result.Success {
+		return result
+	}
+
+	show := e.runCommand(ctx, workDir, "show destroy plan",
+		[]string{"show", "-json", "-no-color", destroyPlanFile},
+		[]string{"show", "--terragrunt-non-interactive", "-json", destroyPlanFile},
 	)
+	if !show.Success {
+		result.Success = false
+		result.Error = show.Error
+		return result
+	}
+	summary, err := ParsePlanJSON([]byte(show.Output))
+	if err == nil {
+		err = recordPlanHash(workDir, destroyPlanHashFile)
+	}
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+		return result
+	}
+	result.Plan = summary
+	return result
 }
 
-// GetOutputs retrieves Terraform/Terragrunt outputs.
-func (e *Executor) GetOutputs(workDir string) map[string]string {
-	ctx := context.Background()
+// Destroy destroys the Terraform/Terragrunt-managed infrastructure by applying the plan saved
+// by DestroyPlan. It refuses to run without a destroy plan, or when the configuration changed
+// after the plan was reviewed. A destroy plan is applied at most once.
+func (e *Executor) Destroy(workDir string) *ExecutionResult {
+	if err := verifyPlanHash(workDir, destroyPlanHashFile); err != nil {
+		e.logger.Error("refusing to destroy", zap.String("work_dir", workDir), zap.Error(err))
+		return &ExecutionResult{Error: err.Error()}
+	}
 
-	var cmd *exec.Cmd
-	if e.isTerragrunt(workDir) {
-		cmd = exec.CommandContext(ctx, "terragrunt", "output", "-json")
-	} else {
-		cmd = exec.CommandContext(ctx, "terraform", "output", "-json")
+	result := e.runCommand(context.Background(), workDir, "destroy",
+		[]string{"apply", "-no-color", "-auto-approve", destroyPlanFile},
+		[]string{"apply", "--terragrunt-non-interactive", "-auto-approve", destroyPlanFile},
+	)
+	if err := os.Remove(filepath.Join(workDir, destroyPlanHashFile)); err != nil && !os.IsNotExist(err) {
+		e.logger.Warn("failed to remove destroy plan hash", zap.String("work_dir", workDir), zap.Error(err))
 	}
-	cmd.Dir = workDir
-	cmd.Env = e.buildEnv(workDir)
+	return result
+}
 
-	output, err := cmd.Output()
+// GetOutputs retrieves Terraform/Terragrunt outputs, along with the names of the outputs
+// declared sensitive. Strings, bools and numbers are returned in their string form; lists,
+// maps and objects are left out.
+func (e *Executor) GetOutputs(workDir string) (outputs map[string]string, sensitive []string) {
+	output, err := e.outputJSON(context.Background(), workDir)
 	if err != nil {
 		e.logger.Error("failed to get outputs", zap.Error(err))
-		return nil
+		return nil, nil
 	}
 
-	var rawOutputs map[string]interface{}
-	if err := json.Unmarshal(output, &rawOutputs); err != nil {
-		return nil
+	var rawOutputs map[string]struct {
+		Sensitive bool        `json:"sensitive"`
+		Value     interface{} `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(output), &rawOutputs); err != nil {
+		return nil, nil
 	}
 
-	outputs := make(map[string]string)
+	outputs = make(map[string]string)
 	for key, val := range rawOutputs {
-		if valMap, ok := val.(map[string]interface{}); ok {
-			if value, ok := valMap["value"].(string); ok {
-				outputs[key] = value
-			}
+		if val.Sensitive {
+			sensitive = append(sensitive, key)
+		}
+		switch value := val.Value.(type) {
+		case string:
+			outputs[key] = value
+		case bool:
+			outputs[key] = strconv.FormatBool(value)
+		case float64:
+			outputs[key] = strconv.FormatFloat(value, 'f', -1, 64)
+		}
+	}
+	sort.Strings(sensitive)
+
+	return outputs, sensitive
+}
+
+// GetOutputsRaw returns the `output -json` document for workDir as terraform produced it,
+// nested values and types included, except that the value of every sensitive output is
+// replaced with RedactedOutputValue.
+func (e *Executor) GetOutputsRaw(ctx context.Context, workDir string) (json.RawMessage, error) {
+	if _, err := os.Stat(workDir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrWorkDirNotFound
+		}
+		return nil, err
+	}
+
+	output, err := e.outputJSON(ctx, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var document map[string]map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(output), &document); err != nil {
+		return nil, fmt.Errorf("failed to parse outputs: %w", err)
+	}
+	for _, out := range document {
+		var sensitive bool
+		if err := json.Unmarshal(out["sensitive"], &sensitive); err == nil && sensitive {
+			out["value"] = redactedOutputJSON
 		}
 	}
 
-	return outputs
+	raw, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// outputJSON runs `output -json` in workDir and returns its stdout.
+func (e *Executor) outputJSON(ctx context.Context, workDir string) (string, error) {
+	binary := "terraform"
+	if e.isTerragrunt(workDir) {
+		binary = "terragrunt"
+	}
+
+	stdout, stderr, err := e.run(ctx, workDir, e.buildEnv(workDir), binary, "output", "-json")
+	if err != nil {
+		return "", fmt.Errorf("%s output failed: %w: %s", binary, err, stripANSI(stderr))
+	}
+	return stdout, nil
 }
 
-// GenerateTFFiles generates Terraform configuration files for a resource.
+// GenerateTFFiles generates Terraform configuration files for a resource. Unless the
+// executor writes secrets to files, the provider password and token are left out of them and
+// kept in memory for the commands later run in workDir, so those must run in this process.
+// A spec whose known fields have the wrong type is rejected with a *SpecError before
+// anything is written.
 func (e *Executor) GenerateTFFiles(workDir string, config Config) error {
+	if err := ValidateSpec(config.Provider, config.Spec); err != nil {
+		return err
+	}
+
 	// Create work directory
 	if err := os.MkdirAll(workDir, dirPerm); err != nil {
 		return fmt.Errorf("failed to create work directory: %w", err)
 	}
 
+	if e.secretsInFiles {
+		e.setSecretEnv(workDir, nil)
+	} else {
+		e.setSecretEnv(workDir, secretVariables(config, config.ModuleSource != ""))
+		config = withoutSecrets(config)
+	}
+
 	// Generate .terraformrc for registry mirror if configured
 	if config.RegistryEndpoint != "" {
 		terraformRC := generateTerraformRC(config)
@@ -306,6 +486,7 @@ func (e *Executor) GenerateTFFiles(workDir string, config Config) error {
 	}
 
 	// Pure Terraform for raw provider configurations
+	config.Spec = e.specDefaults.Apply(config.Provider, config.Spec)
 	var mainTF string
 	var err error
 	mainTF, err = generateMainTF(config)
@@ -350,6 +531,13 @@ func (e *Executor) generateTerragruntFiles(workDir string, config Config) error
 	return nil
 }
 
+// NormalizeModuleSource returns the canonical form of a module source, as used by the
+// executor and stored for synced modules: https URLs become git::https://...git//subpath.
+// Registry and other sources are returned trimmed but otherwise unchanged.
+func NormalizeModuleSource(source string) string {
+	return formatModuleSource(strings.TrimSpace(source), "")
+}
+
 // formatModuleSource converts a module URL to git::https:// format if needed.
 func formatModuleSource(source, version string) string {
 	// If already in git:: format, add version if needed and return
@@ -438,7 +626,7 @@ func buildTerragruntInputs(config Config) []string {
 		inputs = append(inputs, formatInputValue(key, value))
 	}
 
-	return inputs
+	return applyVarOverrides(inputs, config.VarOverrides, formatStringInput)
 }
 
 // buildPVEInputs builds Proxmox VE specific inputs.
@@ -486,24 +674,9 @@ func extractHost(endpoint string) string {
 	return endpoint
 }
 
-// formatInputValue formats a value for HCL.
+// formatInputValue formats an attribute of a terragrunt inputs block.
 func formatInputValue(key string, value interface{}) string {
-	switch v := value.(type) {
-	case string:
-		return fmt.Sprintf("  %s = %q", key, v)
-	case float64:
-		if v == float64(int64(v)) {
-			return fmt.Sprintf("  %s = %d", key, int64(v))
-		}
-		return fmt.Sprintf("  %s = %f", key, v)
-	case bool:
-		return fmt.Sprintf("  %s = %t", key, v)
-	case int, int64:
-		return fmt.Sprintf("  %s = %d", key, v)
-	default:
-		jsonVal, _ := json.Marshal(v) //nolint:errcheck // will not fail
-		return fmt.Sprintf("  %s = %s", key, string(jsonVal))
-	}
+	return fmt.Sprintf("  %s = %s", hclObjectKey(key), FormatHCLValue(value, 1))
 }
 
 // generateTerraformRC generates a .terraformrc file for registry mirror.
@@ -619,13 +792,9 @@ func generateTFVars(config Config) string {
 		case providerPVE:
 			if targetNode, ok := config.Spec["target_node"]; ok {
 				lines = append(lines, fmt.Sprintf(`target_node = "%v"`, targetNode))
-			} else {
-				lines = append(lines, `target_node = "pve"`)
 			}
 			if templateName, ok := config.Spec["template_name"]; ok {
 				lines = append(lines, fmt.Sprintf(`template_name = "%v"`, templateName))
-			} else {
-				lines = append(lines, `template_name = "ubuntu-template"`)
 			}
 			if storagePool, ok := config.Spec["storage_pool"]; ok {
 				lines = append(lines, fmt.Sprintf(`storage_pool = "%v"`, storagePool))
@@ -668,6 +837,7 @@ func generateTFVars(config Config) string {
 	// Add environment tag
 	lines = append(lines, fmt.Sprintf(`environment = "%s"`, config.Environment))
 
+	lines = applyVarOverrides(lines, config.VarOverrides, formatTFVar)
 	return strings.Join(lines, "\n") + "\n"
 }
 
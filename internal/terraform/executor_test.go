@@ -0,0 +1,488 @@
+// Package terraform provides executor tests.
+package terraform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeRunner records invoked commands and returns canned output.
+type fakeRunner struct {
+	calls   []string
+	stdout  map[string]string
+	lastEnv []string
+}
+
+func (f *fakeRunner) run(_ context.Context, _ string, env []string, name string, args ...string) (string, string, error) {
+	call := name + " " + strings.Join(args, " ")
+	f.calls = append(f.calls, call)
+	f.lastEnv = env
+	if len(args) > 0 {
+		return f.stdout[args[0]], "", nil
+	}
+	return "", "", nil
+}
+
+func (f *fakeRunner) called(subcommand string) bool {
+	for _, call := range f.calls {
+		if strings.HasPrefix(call, "terraform "+subcommand) || strings.HasPrefix(call, "terragrunt "+subcommand) {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestExecutor(runner *fakeRunner) *Executor {
+	return &Executor{logger: zap.NewNop(), run: runner.run}
+}
+
+func writeWorkFile(t *testing.T, workDir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, name), []byte(content), filePerm))
+}
+
+func TestExecutor_ApplyVerifiesPlanHash(t *testing.T) {
+	t.Run("unchanged config applies", func(t *testing.T) {
+		workDir := t.TempDir()
+		writeWorkFile(t, workDir, "terragrunt.hcl", "inputs = { cpu = 2 }\n")
+
+		runner := &fakeRunner{stdout: map[string]string{
+			"output": `{"ip":{"value":"10.0.0.5"}}`,
+			"apply":  "proxmox_vm_qemu.vm: Creation complete after 30s [id=pve/qemu/101]\n",
+		}}
+		executor := newTestExecutor(runner)
+
+		require.True(t, executor.Plan(workDir).Success)
+
+		// Files unrelated to the configuration may change between plan and apply.
+		writeWorkFile(t, workDir, ".netrc", "machine git.example.com\n")
+
+		result := executor.Apply(workDir)
+		require.True(t, result.Success, result.Error)
+		assert.True(t, runner.called("apply"))
+		assert.Equal(t, "10.0.0.5", result.Outputs["ip"])
+		assert.Equal(t, []string{"proxmox_vm_qemu.vm"}, result.CreatedAddresses)
+	})
+
+	t.Run("changed config is rejected", func(t *testing.T) {
+		workDir := t.TempDir()
+		writeWorkFile(t, workDir, "terragrunt.hcl", "inputs = { cpu = 2 }\n")
+
+		runner := &fakeRunner{}
+		executor := newTestExecutor(runner)
+
+		require.True(t, executor.Plan(workDir).Success)
+		writeWorkFile(t, workDir, "terragrunt.hcl", "inputs = { cpu = 8 }\n")
+
+		result := executor.Apply(workDir)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, ErrStalePlan.Error())
+		assert.False(t, runner.called("apply"))
+	})
+
+	t.Run("apply without plan is rejected", func(t *testing.T) {
+		workDir := t.TempDir()
+		writeWorkFile(t, workDir, "main.tf", "")
+
+		runner := &fakeRunner{}
+		result := newTestExecutor(runner).Apply(workDir)
+		assert.False(t, result.Success)
+		assert.False(t, runner.called("apply"))
+	})
+}
+
+func TestExecutor_DestroyPlan(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "destroy_plan.json"))
+	require.NoError(t, err)
+
+	t.Run("terraform arguments and summary", func(t *testing.T) {
+		workDir := t.TempDir()
+		writeWorkFile(t, workDir, "main.tf", "")
+
+		runner := &fakeRunner{stdout: map[string]string{"show": string(fixture)}}
+		result := newTestExecutor(runner).DestroyPlan(context.Background(), workDir)
+		require.True(t, result.Success, result.Error)
+		assert.Equal(t, []string{
+			"terraform plan -destroy -no-color -out=tfdestroyplan",
+			"terraform show -json -no-color tfdestroyplan",
+		}, runner.calls)
+		require.NotNil(t, result.Plan)
+		assert.Equal(t, []string{"module.vm.proxmox_vm_qemu.this[0]", "module.vm.proxmox_vm_qemu.this[1]"}, result.Plan.Delete)
+	})
+
+	t.Run("terragrunt arguments", func(t *testing.T) {
+		workDir := t.TempDir()
+		writeWorkFile(t, workDir, "terragrunt.hcl", "")
+
+		runner := &fakeRunner{stdout: map[string]string{"show": string(fixture)}}
+		result := newTestExecutor(runner).DestroyPlan(context.Background(), workDir)
+		require.True(t, result.Success, result.Error)
+		assert.Equal(t, []string{
+			"terragrunt plan -destroy --terragrunt-non-interactive -out=tfdestroyplan",
+			"terragrunt show --terragrunt-non-interactive -json tfdestroyplan",
+		}, runner.calls)
+	})
+
+	t.Run("unparseable plan fails", func(t *testing.T) {
+		workDir := t.TempDir()
+		writeWorkFile(t, workDir, "main.tf", "")
+
+		runner := &fakeRunner{stdout: map[string]string{"show": "not json"}}
+		executor := newTestExecutor(runner)
+		result := executor.DestroyPlan(context.Background(), workDir)
+		assert.False(t, result.Success)
+		assert.False(t, executor.Destroy(workDir).Success, "a failed destroy plan does not unlock destroy")
+	})
+}
+
+func TestExecutor_DestroyRequiresReviewedPlan(t *testing.T) {
+	t.Run("destroy without a destroy plan is rejected", func(t *testing.T) {
+		workDir := t.TempDir()
+		writeWorkFile(t, workDir, "main.tf", "")
+
+		runner := &fakeRunner{}
+		executor := newTestExecutor(runner)
+		require.True(t, executor.Plan(workDir).Success, "a regular plan does not count")
+
+		result := executor.Destroy(workDir)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, ErrStalePlan.Error())
+		assert.False(t, runner.called("apply"))
+	})
+
+	t.Run("reviewed plan is applied once", func(t *testing.T) {
+		workDir := t.TempDir()
+		writeWorkFile(t, workDir, "main.tf", "")
+
+		runner := &fakeRunner{stdout: map[string]string{"show": `{"resource_changes": []}`}}
+		executor := newTestExecutor(runner)
+		require.True(t, executor.DestroyPlan(context.Background(), workDir).Success)
+
+		result := executor.Destroy(workDir)
+		require.True(t, result.Success, result.Error)
+		assert.Equal(t, "terraform apply -no-color -auto-approve tfdestroyplan", runner.calls[len(runner.calls)-1])
+
+		assert.False(t, executor.Destroy(workDir).Success, "the plan has to be reviewed again")
+	})
+
+	t.Run("changed config is rejected", func(t *testing.T) {
+		workDir := t.TempDir()
+		writeWorkFile(t, workDir, "main.tf", "")
+
+		runner := &fakeRunner{stdout: map[string]string{"show": `{"resource_changes": []}`}}
+		executor := newTestExecutor(runner)
+		require.True(t, executor.DestroyPlan(context.Background(), workDir).Success)
+		writeWorkFile(t, workDir, "main.tf", "resource \"null_resource\" \"x\" {}")
+
+		result := executor.Destroy(workDir)
+		assert.False(t, result.Success)
+		assert.False(t, runner.called("apply"))
+	})
+}
+
+func TestConfigHash(t *testing.T) {
+	workDir := t.TempDir()
+	writeWorkFile(t, workDir, "main.tf", "a")
+	writeWorkFile(t, workDir, "terraform.tfvars", "b")
+
+	first, err := ConfigHash(workDir)
+	require.NoError(t, err)
+
+	writeWorkFile(t, workDir, "tfplan", "binary plan")
+	second, err := ConfigHash(workDir)
+	require.NoError(t, err)
+	assert.Equal(t, first, second, "plan file is not part of the configuration")
+
+	require.NoError(t, os.Rename(filepath.Join(workDir, "terraform.tfvars"), filepath.Join(workDir, "extra.tfvars")))
+	third, err := ConfigHash(workDir)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, third, "renaming a file changes the hash")
+}
+
+func TestNormalizeModuleSource(t *testing.T) {
+	tests := map[string]string{
+		"https://git.example.com/lab/modules//compute/vm":     "git::https://git.example.com/lab/modules.git//compute/vm",
+		"https://git.example.com/lab/modules.git//compute/vm": "git::https://git.example.com/lab/modules.git//compute/vm",
+		"git::https://git.example.com/lab/modules.git//vm":    "git::https://git.example.com/lab/modules.git//vm",
+		" https://git.example.com/lab/modules ":               "git::https://git.example.com/lab/modules.git",
+		"registry.example.com/lab/vm/proxmox":                 "registry.example.com/lab/vm/proxmox",
+	}
+	for source, want := range tests {
+		assert.Equal(t, want, NormalizeModuleSource(source), source)
+	}
+}
+
+// envValue returns the effective value of key in env, where later entries win.
+func envValue(env []string, key string) string {
+	value := ""
+	for _, entry := range env {
+		if strings.HasPrefix(entry, key+"=") {
+			value = strings.TrimPrefix(entry, key+"=")
+		}
+	}
+	return value
+}
+
+func TestExecutor_GitCredentialsAreScopedToWorkDir(t *testing.T) {
+	processHome := os.Getenv("HOME")
+	executor := newTestExecutor(&fakeRunner{})
+
+	workDirs := []string{t.TempDir(), t.TempDir()}
+	configs := []Config{
+		{GitHost: "git.example.com", GitUsername: "alice", GitToken: "token-a"},
+		{GitHost: "git.example.com", GitUsername: "bob", GitToken: "token-b"},
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(workDirs))
+	envs := make([][]string, len(workDirs))
+	for i := range workDirs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = executor.configureGitCredentials(workDirs[i], configs[i])
+			envs[i] = executor.buildEnv(workDirs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, processHome, os.Getenv("HOME"), "process HOME must not change")
+	for i, workDir := range workDirs {
+		require.NoError(t, errs[i])
+		home := envValue(envs[i], "HOME")
+		assert.Equal(t, filepath.Join(workDir, gitHomeDir), home)
+
+		netrc, err := os.ReadFile(filepath.Join(home, ".netrc"))
+		require.NoError(t, err)
+		assert.Contains(t, string(netrc), "login "+configs[i].GitUsername)
+		assert.Contains(t, string(netrc), "password "+configs[i].GitToken)
+
+		info, err := os.Stat(filepath.Join(home, ".netrc"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(netrcFilePermission), info.Mode().Perm())
+	}
+}
+
+func TestExecutor_ConfigureGitCredentialsRejectsWhitespace(t *testing.T) {
+	workDir := t.TempDir()
+	executor := newTestExecutor(&fakeRunner{})
+
+	err := executor.configureGitCredentials(workDir, Config{GitHost: "git.example.com", GitUsername: "alice", GitToken: "x\nmachine evil.example.com"})
+	require.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(workDir, gitHomeDir, ".netrc"))
+	assert.Empty(t, envValue(executor.buildEnv(workDir)[len(os.Environ()):], "HOME"))
+}
+
+func TestVarOverrides(t *testing.T) {
+	config := Config{
+		Provider:        "pve",
+		Environment:     "dev",
+		Spec:            map[string]interface{}{"template_name": "ubuntu-22.04"},
+		ClusterEndpoint: "https://pve.example.com:8006/api2/json",
+		ClusterPassword: "real-secret",
+		VarOverrides: map[string]string{
+			"template_name":    "ubuntu-24.04",
+			"image":            "ami-0abc1234",
+			"proxmox_password": "attacker",
+			"proxmox_api_url":  "https://evil.example.com",
+		},
+	}
+
+	t.Run("tfvars", func(t *testing.T) {
+		tfvars := generateTFVars(config)
+		assert.Contains(t, tfvars, `template_name = "ubuntu-24.04"`)
+		assert.NotContains(t, tfvars, "ubuntu-22.04")
+		assert.Contains(t, tfvars, `image = "ami-0abc1234"`)
+		assert.Equal(t, 1, strings.Count(tfvars, "template_name ="), "overrides replace rather than repeat a variable")
+		assert.Contains(t, tfvars, `proxmox_password = "real-secret"`)
+		assert.Contains(t, tfvars, `proxmox_api_url = "https://pve.example.com:8006/api2/json"`)
+		assert.NotContains(t, tfvars, "attacker")
+		assert.NotContains(t, tfvars, "evil.example.com")
+	})
+
+	t.Run("terragrunt inputs", func(t *testing.T) {
+		inputs := strings.Join(buildTerragruntInputs(config), "\n")
+		assert.Contains(t, inputs, `template_name = "ubuntu-24.04"`)
+		assert.NotContains(t, inputs, "ubuntu-22.04")
+		assert.Contains(t, inputs, `image = "ami-0abc1234"`)
+		assert.Contains(t, inputs, `pm_password = "real-secret"`)
+		assert.NotContains(t, inputs, "attacker")
+	})
+}
+
+func TestExecutor_SecretsInEnvironment(t *testing.T) {
+	readWorkFile := func(t *testing.T, workDir, name string) string {
+		t.Helper()
+		content, err := os.ReadFile(filepath.Join(workDir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("terraform secrets are passed as TF_VAR_ entries, not written to tfvars", func(t *testing.T) {
+		workDir := t.TempDir()
+		runner := &fakeRunner{}
+		executor := newTestExecutor(runner)
+
+		require.NoError(t, executor.GenerateTFFiles(workDir, Config{
+			Provider:        "vmware",
+			Environment:     "dev",
+			ClusterEndpoint: "vcenter.example.com",
+			ClusterUsername: "admin",
+			ClusterPassword: "vc-s3cret",
+		}))
+		tfvars := readWorkFile(t, workDir, "terraform.tfvars")
+		assert.NotContains(t, tfvars, "vc-s3cret")
+		assert.Contains(t, tfvars, `vsphere_user = "admin"`, "non-secret credentials stay in tfvars")
+
+		executor.Plan(workDir)
+		assert.Contains(t, runner.lastEnv, "TF_VAR_vsphere_password=vc-s3cret")
+	})
+
+	t.Run("terragrunt token is passed as a TF_VAR_ entry, not written to terragrunt.hcl", func(t *testing.T) {
+		workDir := t.TempDir()
+		runner := &fakeRunner{}
+		executor := newTestExecutor(runner)
+
+		require.NoError(t, executor.GenerateTFFiles(workDir, Config{
+			Provider:        "pve",
+			Environment:     "dev",
+			ModuleSource:    "git::https://git.example.com/modules/vm.git",
+			ClusterEndpoint: "https://pve.example.com:8006/api2/json",
+			ClusterUsername: "root@pam!ci",
+			ClusterToken:    "pve-t0ken",
+		}))
+		hcl := readWorkFile(t, workDir, "terragrunt.hcl")
+		assert.NotContains(t, hcl, "pve-t0ken")
+		assert.Contains(t, hcl, `pm_user = "root@pam!ci"`)
+
+		executor.Plan(workDir)
+		assert.Contains(t, runner.lastEnv, "TF_VAR_pm_api_token=pve-t0ken")
+	})
+
+	t.Run("secrets in files when configured", func(t *testing.T) {
+		workDir := t.TempDir()
+		runner := &fakeRunner{}
+		executor := newTestExecutor(runner)
+		executor.secretsInFiles = true
+
+		require.NoError(t, executor.GenerateTFFiles(workDir, Config{
+			Provider:        "pve",
+			Environment:     "dev",
+			ClusterPassword: "pve-s3cret",
+		}))
+		assert.Contains(t, readWorkFile(t, workDir, "terraform.tfvars"), `proxmox_password = "pve-s3cret"`)
+
+		executor.Plan(workDir)
+		for _, entry := range runner.lastEnv {
+			assert.NotContains(t, entry, "pve-s3cret")
+		}
+	})
+}
+
+func TestSpecDefaults(t *testing.T) {
+	defaults := DefaultSpecDefaults().Merge(SpecDefaults{
+		"pve":    {"template_name": "debian-12", "storage_pool": "local-lvm"},
+		"vmware": {"datacenter": "dc1"},
+	})
+
+	t.Run("merge overrides built-in values field by field", func(t *testing.T) {
+		assert.Equal(t, map[string]interface{}{
+			"target_node":   "pve",
+			"template_name": "debian-12",
+			"storage_pool":  "local-lvm",
+		}, defaults["pve"])
+		assert.Equal(t, "ubuntu-template", DefaultSpecDefaults()["pve"]["template_name"], "built-in defaults are not modified")
+	})
+
+	t.Run("omitted fields get defaults", func(t *testing.T) {
+		spec := map[string]interface{}{"cpu": 2}
+		tfvars := generateTFVars(Config{Provider: "pve", Environment: "dev", Spec: defaults.Apply("pve", spec)})
+		assert.Contains(t, tfvars, `target_node = "pve"`)
+		assert.Contains(t, tfvars, `template_name = "debian-12"`)
+		assert.Contains(t, tfvars, `storage_pool = "local-lvm"`)
+		assert.Equal(t, map[string]interface{}{"cpu": 2}, spec, "request spec is not modified")
+	})
+
+	t.Run("spec values take precedence", func(t *testing.T) {
+		spec := map[string]interface{}{"target_node": "pve-02", "template_name": "rocky-9"}
+		tfvars := generateTFVars(Config{Provider: "pve", Environment: "dev", Spec: defaults.Apply("pve", spec)})
+		assert.Contains(t, tfvars, `target_node = "pve-02"`)
+		assert.Contains(t, tfvars, `template_name = "rocky-9"`)
+		assert.NotContains(t, tfvars, "debian-12")
+	})
+
+	t.Run("executor applies defaults to generated tfvars", func(t *testing.T) {
+		workDir := t.TempDir()
+		executor := &Executor{logger: zap.NewNop(), run: (&fakeRunner{}).run, specDefaults: defaults}
+		require.NoError(t, executor.GenerateTFFiles(workDir, Config{
+			Provider:    "vmware",
+			Environment: "dev",
+			Spec:        map[string]interface{}{"cluster": "c1"},
+		}))
+
+		tfvars, err := os.ReadFile(filepath.Join(workDir, "terraform.tfvars"))
+		require.NoError(t, err)
+		assert.Contains(t, string(tfvars), `datacenter = "dc1"`)
+		assert.Contains(t, string(tfvars), `cluster = "c1"`)
+	})
+
+	t.Run("providers without defaults keep the spec", func(t *testing.T) {
+		spec := map[string]interface{}{"flavor_name": "m1.small"}
+		assert.Equal(t, spec, defaults.Apply("openstack", spec))
+	})
+}
+
+func TestExecutor_GetOutputs(t *testing.T) {
+	runner := &fakeRunner{stdout: map[string]string{
+		"output": `{
+			"vm_ip": {"sensitive": false, "type": "string", "value": "10.0.0.5"},
+			"root_password": {"sensitive": true, "type": "string", "value": "hunter2"},
+			"disks": {"sensitive": false, "type": ["list", "string"], "value": ["a", "b"]},
+			"ready": {"sensitive": false, "type": "bool", "value": false},
+			"port": {"sensitive": false, "type": "number", "value": 8006}
+		}`,
+	}}
+	executor := newTestExecutor(runner)
+
+	outputs, sensitive := executor.GetOutputs(t.TempDir())
+
+	assert.Equal(t, map[string]string{"vm_ip": "10.0.0.5", "root_password": "hunter2", "ready": "false", "port": "8006"}, outputs)
+	assert.Equal(t, []string{"root_password"}, sensitive)
+}
+
+func TestExecutor_GetOutputsRaw(t *testing.T) {
+	runner := &fakeRunner{stdout: map[string]string{
+		"output": `{
+			"vm_ip": {"sensitive": false, "type": "string", "value": "10.0.0.5"},
+			"root_password": {"sensitive": true, "type": "string", "value": "hunter2"},
+			"disks": {"sensitive": false, "type": ["list", ["object", {"size": "number"}]], "value": [{"size": 32}, {"size": 64}]},
+			"kubeconfig": {"sensitive": true, "type": ["map", "string"], "value": {"admin": "secret-config"}}
+		}`,
+	}}
+	executor := newTestExecutor(runner)
+
+	raw, err := executor.GetOutputsRaw(context.Background(), t.TempDir())
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"vm_ip": {"sensitive": false, "type": "string", "value": "10.0.0.5"},
+		"root_password": {"sensitive": true, "type": "string", "value": "(sensitive value)"},
+		"disks": {"sensitive": false, "type": ["list", ["object", {"size": "number"}]], "value": [{"size": 32}, {"size": 64}]},
+		"kubeconfig": {"sensitive": true, "type": ["map", "string"], "value": "(sensitive value)"}
+	}`, string(raw))
+	assert.NotContains(t, string(raw), "hunter2")
+	assert.NotContains(t, string(raw), "secret-config")
+
+	t.Run("missing work directory", func(t *testing.T) {
+		_, err := executor.GetOutputsRaw(context.Background(), filepath.Join(t.TempDir(), "missing"))
+		require.ErrorIs(t, err, ErrWorkDirNotFound)
+	})
+}
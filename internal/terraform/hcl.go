@@ -0,0 +1,245 @@
+// Package terraform provides Terraform execution and management functionality.
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// hclIndent is one level of indentation, as terraform fmt lays it out.
+const hclIndent = "  "
+
+// heredocMarker delimits heredoc strings. A numeric suffix is added when a line of the
+// string would otherwise end the heredoc early.
+const heredocMarker = "EOT"
+
+// hclKeywords are identifiers that cannot be used unquoted as object keys, since they would
+// be read as a literal or start a for expression.
+var hclKeywords = map[string]bool{"true": true, "false": true, "null": true, "for": true, "if": true}
+
+// HCLExpression is an HCL expression written verbatim by FormatHCLValue, such as a reference
+// to a dependency's outputs. Callers are responsible for its syntax.
+type HCLExpression string
+
+// FormatHCLValue renders v as an HCL expression, laid out as terraform fmt would for the
+// value of an attribute depth levels deep. v may be any value that encodes to JSON; the
+// values of a decoded JSON document are rendered directly.
+//
+// Objects are written one attribute per line with aligned equals signs and lists of
+// objects or lists one element per line. Strings spanning several lines and ending in a
+// newline become heredocs. Template sequences are escaped in every string, so "${" and
+// "%{" reach the module verbatim instead of being interpolated.
+func FormatHCLValue(v interface{}, depth int) string {
+	var b strings.Builder
+	writeHCLValue(&b, v, depth)
+	return b.String()
+}
+
+func writeHCLValue(b *strings.Builder, v interface{}, depth int) {
+	switch val := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case HCLExpression:
+		b.WriteString(string(val))
+	case string:
+		writeHCLString(b, val)
+	case bool:
+		b.WriteString(strconv.FormatBool(val))
+	case float64:
+		b.WriteString(strconv.FormatFloat(val, 'f', -1, 64))
+	case float32:
+		b.WriteString(strconv.FormatFloat(float64(val), 'f', -1, 32))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		fmt.Fprintf(b, "%d", val)
+	case json.Number:
+		b.WriteString(val.String())
+	case []interface{}:
+		writeHCLList(b, val, depth)
+	case map[string]interface{}:
+		writeHCLObject(b, val, depth)
+	default:
+		writeHCLValue(b, jsonValue(val), depth)
+	}
+}
+
+// jsonValue converts v to the generic form encoding/json decodes into, so typed slices,
+// maps and structs are rendered like the JSON they encode to. Values that cannot be
+// encoded become null.
+func jsonValue(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// writeHCLList writes a list inline when its elements all fit on one line, and one
+// element per line otherwise.
+func writeHCLList(b *strings.Builder, list []interface{}, depth int) {
+	elems := make([]string, len(list))
+	inline := true
+	for i, elem := range list {
+		elems[i] = FormatHCLValue(elem, depth+1)
+		if strings.Contains(elems[i], "\n") {
+			inline = false
+		}
+	}
+	if inline {
+		b.WriteString("[" + strings.Join(elems, ", ") + "]")
+		return
+	}
+
+	inner := strings.Repeat(hclIndent, depth+1)
+	b.WriteString("[\n")
+	for _, elem := range elems {
+		b.WriteString(inner + elem)
+		if isHeredoc(elem) {
+			// The closing marker must end its line, so the comma goes on the next one
+			b.WriteString("\n" + inner)
+		}
+		b.WriteString(",\n")
+	}
+	b.WriteString(strings.Repeat(hclIndent, depth) + "]")
+}
+
+// writeHCLObject writes an object one attribute per line, in key order. As terraform fmt
+// does, the equals signs of consecutive attributes are aligned; an attribute whose value
+// opens a bracket over several lines stands on its own.
+func writeHCLObject(b *strings.Builder, obj map[string]interface{}, depth int) {
+	if len(obj) == 0 {
+		b.WriteString("{}")
+		return
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	names := make([]string, len(keys))
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = hclObjectKey(key)
+		values[i] = FormatHCLValue(obj[key], depth+1)
+	}
+
+	inner := strings.Repeat(hclIndent, depth+1)
+	b.WriteString("{\n")
+	for start := 0; start < len(keys); {
+		end := start + 1
+		if aligns(values[start]) {
+			for end < len(keys) && aligns(values[end]) {
+				end++
+			}
+		}
+		width := 0
+		for _, name := range names[start:end] {
+			width = max(width, utf8.RuneCountInString(name))
+		}
+		for i := start; i < end; i++ {
+			pad := 1
+			if aligns(values[i]) {
+				pad += width - utf8.RuneCountInString(names[i])
+			}
+			b.WriteString(inner + names[i] + strings.Repeat(" ", pad) + "= " + values[i] + "\n")
+		}
+		start = end
+	}
+	b.WriteString(strings.Repeat(hclIndent, depth) + "}")
+}
+
+// aligns reports whether an attribute with the rendered value takes part in equals sign
+// alignment: it fits on one line or is a heredoc.
+func aligns(value string) bool {
+	return !strings.Contains(value, "\n") || isHeredoc(value)
+}
+
+func isHeredoc(value string) bool {
+	return strings.HasPrefix(value, "<<")
+}
+
+// hclObjectKey returns key as written in an object: bare when it is an identifier,
+// quoted otherwise.
+func hclObjectKey(key string) string {
+	if IsValidVariableName(key) && !hclKeywords[key] {
+		return key
+	}
+	var b strings.Builder
+	writeQuotedHCLString(&b, key)
+	return b.String()
+}
+
+// writeHCLString writes s as a heredoc when it is a run of whole lines, and quoted
+// otherwise: a heredoc always ends in a newline and cannot hold control characters other
+// than tabs.
+func writeHCLString(b *strings.Builder, s string) {
+	if !strings.HasSuffix(s, "\n") || strings.ContainsFunc(s, isHeredocUnsafe) {
+		writeQuotedHCLString(b, s)
+		return
+	}
+
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	marker := heredocMarker
+	for n := 1; containsLine(lines, marker); n++ {
+		marker = heredocMarker + strconv.Itoa(n)
+	}
+	b.WriteString("<<" + marker + "\n")
+	for _, line := range lines {
+		b.WriteString(escapeTemplateSequences(line) + "\n")
+	}
+	b.WriteString(marker)
+}
+
+func isHeredocUnsafe(r rune) bool {
+	return (r < ' ' && r != '\n' && r != '\t') || r == 0x7f
+}
+
+// containsLine reports whether a line of lines would be read as the heredoc marker.
+func containsLine(lines []string, marker string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// writeQuotedHCLString writes s as a quoted string using only the escapes HCL accepts.
+func writeQuotedHCLString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for _, r := range escapeTemplateSequences(s) {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < ' ' || r == 0x7f:
+			fmt.Fprintf(b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+}
+
+// escapeTemplateSequences doubles the leading character of "${" and "%{" so HCL reads
+// them literally rather than as an interpolation or directive.
+func escapeTemplateSequences(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
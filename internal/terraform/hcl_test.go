@@ -0,0 +1,65 @@
+// Package terraform provides HCL encoding tests.
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatHCLValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		depth int
+		want  string
+	}{
+		{name: "null", value: nil, want: "null"},
+		{name: "whole number", value: float64(12345678901234), want: "12345678901234"},
+		{name: "fraction", value: 0.25, want: "0.25"},
+		{name: "int", value: 8, want: "8"},
+		{name: "bool", value: true, want: "true"},
+		{name: "quotes and backslashes", value: `say "hi" \ back`, want: `"say \"hi\" \\ back"`},
+		{name: "control characters", value: "bell\atab\tcr\r", want: `"bell\u0007tab\tcr\r"`},
+		{name: "template sequences", value: "${var.x} %{if} $${y}", want: `"$${var.x} %%{if} $$${y}"`},
+		{name: "line without trailing newline stays quoted", value: "a\nb", want: `"a\nb"`},
+		{name: "lines become a heredoc", value: "a\n${b}\n", depth: 1, want: "<<EOT\na\n$${b}\nEOT"},
+		{name: "heredoc marker avoids the content", value: "EOT\n  EOT1\n", want: "<<EOT2\nEOT\n  EOT1\nEOT2"},
+		{name: "expression written verbatim", value: HCLExpression("dependency.net.outputs.id"), want: "dependency.net.outputs.id"},
+		{name: "scalar list inline", value: []interface{}{"lab", float64(2)}, want: `["lab", 2]`},
+		{name: "empty collections", value: map[string]interface{}{"l": []interface{}{}, "m": map[string]interface{}{}}, want: "{\n  l = []\n  m = {}\n}"},
+		{
+			name:  "object keys are sorted, aligned and quoted when needed",
+			value: map[string]interface{}{"zone": "a", "cpu": float64(2), "for": "x", "with space": "y"},
+			want:  "{\n  cpu          = 2\n  \"for\"        = \"x\"\n  \"with space\" = \"y\"\n  zone         = \"a\"\n}",
+		},
+		{
+			name: "multi-line values break alignment",
+			value: map[string]interface{}{
+				"a":      "x",
+				"labels": map[string]interface{}{"env": "dev"},
+				"name":   "vm",
+				"zz":     "y",
+			},
+			depth: 1,
+			want:  "{\n    a = \"x\"\n    labels = {\n      env = \"dev\"\n    }\n    name = \"vm\"\n    zz   = \"y\"\n  }",
+		},
+		{
+			name:  "list of objects one per line",
+			value: []interface{}{map[string]interface{}{"size": float64(20)}, "x\n"},
+			want:  "[\n  {\n    size = 20\n  },\n  <<EOT\nx\nEOT\n  ,\n]",
+		},
+		{name: "typed values encode like JSON", value: map[string]int{"b": 1}, want: "{\n  b = 1\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatHCLValue(tt.value, tt.depth))
+		})
+	}
+}
+
+func TestFormatInputValue(t *testing.T) {
+	assert.Equal(t, "  tags = {\n    env = \"dev\"\n  }", formatInputValue("tags", map[string]interface{}{"env": "dev"}))
+	assert.Equal(t, `  script = "echo $${HOME}"`, formatInputValue("script", "echo ${HOME}"))
+}
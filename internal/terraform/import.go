@@ -0,0 +1,38 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+)
+
+// instanceResourceTypes maps a provider to the resource type of the instance that the raw
+// configuration from GenerateTFFiles declares.
+var instanceResourceTypes = map[string]string{
+	providerPVE: "proxmox_vm_qemu",
+	"vmware":    "vsphere_virtual_machine",
+	"openstack": "openstack_compute_instance_v2",
+}
+
+// InstanceAddress returns the address of the instance resource that GenerateTFFiles declares
+// for a raw provider configuration, which is where ImportState binds an existing instance.
+func InstanceAddress(config Config) (string, error) {
+	resourceType, ok := instanceResourceTypes[config.Provider]
+	if !ok {
+		return "", fmt.Errorf("unsupported provider: %s", config.Provider)
+	}
+	name := config.Environment + "-vm"
+	if config.Provider == "openstack" {
+		name = config.Environment + "-instance"
+	}
+	return resourceType + "." + name, nil
+}
+
+// ImportState runs terraform import in workDir, binding the existing infrastructure object
+// id to address so later plans manage it instead of creating a new one. The configuration
+// in workDir must declare address and be initialized.
+func (e *Executor) ImportState(ctx context.Context, workDir, address, id string) *ExecutionResult {
+	return e.runCommand(ctx, workDir, "import",
+		[]string{"import", "-no-color", "-input=false", address, id},
+		[]string{"import", "--terragrunt-non-interactive", address, id},
+	)
+}
@@ -0,0 +1,38 @@
+// Package terraform provides instance import tests.
+package terraform
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceAddress(t *testing.T) {
+	for _, provider := range []string{providerPVE, "vmware", "openstack"} {
+		t.Run(provider, func(t *testing.T) {
+			config := Config{Provider: provider, Environment: "dev", Spec: map[string]interface{}{}}
+			address, err := InstanceAddress(config)
+			require.NoError(t, err)
+
+			mainTF, err := generateMainTF(config)
+			require.NoError(t, err)
+			resourceType, name, _ := strings.Cut(address, ".")
+			assert.Contains(t, mainTF, `resource "`+resourceType+`" "`+name+`"`, "the address is declared by the generated configuration")
+		})
+	}
+
+	_, err := InstanceAddress(Config{Provider: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestExecutor_ImportState(t *testing.T) {
+	runner := &fakeRunner{}
+	executor := newTestExecutor(runner)
+
+	result := executor.ImportState(context.Background(), t.TempDir(), "proxmox_vm_qemu.dev-vm", "pve-01/qemu/104")
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, []string{"terraform import -no-color -input=false proxmox_vm_qemu.dev-vm pve-01/qemu/104"}, runner.calls)
+}
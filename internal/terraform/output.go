@@ -0,0 +1,164 @@
+// Package terraform provides Terraform execution utilities.
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Severity classifies a line of terraform/terragrunt output.
+type Severity string
+
+const (
+	// SeverityInfo is regular progress output.
+	SeverityInfo Severity = "info"
+	// SeverityWarn marks warnings and deprecation notices.
+	SeverityWarn Severity = "warn"
+	// SeverityError marks errors.
+	SeverityError Severity = "error"
+)
+
+// OutputMode selects how an ExecutionResult is rendered.
+type OutputMode string
+
+const (
+	// OutputModeJSON renders the structured result as JSON.
+	OutputModeJSON OutputMode = "json"
+	// OutputModeHuman renders the log lines as plain text with severity prefixes.
+	OutputModeHuman OutputMode = "human"
+)
+
+// LogLine is a single classified line of command output.
+type LogLine struct {
+	Number   int      `json:"number"`
+	Stream   string   `json:"stream"` // stdout or stderr
+	Severity Severity `json:"severity"`
+	Text     string   `json:"text"`
+}
+
+// StructuredResult is an ExecutionResult with its output split into classified lines.
+type StructuredResult struct {
+	*ExecutionResult
+	Lines []LogLine `json:"lines"`
+}
+
+// Lines splits the stdout and stderr output into classified lines. Lines that
+// continue a terraform diagnostic box take the severity of the diagnostic.
+func (r *ExecutionResult) Lines() []LogLine {
+	lines := classifyStream(nil, "stdout", r.Output)
+	return classifyStream(lines, "stderr", r.Error)
+}
+
+// Structured returns the result with a line-split view of its output. The raw
+// output and error remain available on the embedded result.
+func (r *ExecutionResult) Structured() *StructuredResult {
+	return &StructuredResult{ExecutionResult: r, Lines: r.Lines()}
+}
+
+// Render formats the result in the given output mode.
+func (r *ExecutionResult) Render(mode OutputMode) (string, error) {
+	switch mode {
+	case OutputModeJSON:
+		data, err := json.Marshal(r.Structured())
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case OutputModeHuman:
+		var b strings.Builder
+		for _, line := range r.Lines() {
+			fmt.Fprintf(&b, "[%-5s] %s\n", line.Severity, line.Text)
+		}
+		status := "succeeded"
+		if !r.Success {
+			status = "failed"
+		}
+		fmt.Fprintf(&b, "%s in %s\n", status, r.Duration)
+		return b.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported output mode: %q", mode)
+	}
+}
+
+// classifyStream appends the non-empty lines of text to lines.
+func classifyStream(lines []LogLine, stream, text string) []LogLine {
+	if text == "" {
+		return lines
+	}
+
+	// Severity of the diagnostic box ("╷ … ╵") currently being read, if any
+	var boxSeverity Severity
+	for _, raw := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		line := strings.TrimRight(raw, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		severity := ClassifyLine(trimmed)
+		switch {
+		case strings.HasPrefix(trimmed, "╷"):
+			boxSeverity = ""
+		case strings.HasPrefix(trimmed, "│") && severity != SeverityInfo:
+			boxSeverity = severity
+		case strings.HasPrefix(trimmed, "│") || strings.HasPrefix(trimmed, "╵"):
+			if boxSeverity != "" {
+				severity = boxSeverity
+			}
+		}
+
+		lines = append(lines, LogLine{
+			Number:   len(lines) + 1,
+			Stream:   stream,
+			Severity: severity,
+			Text:     line,
+		})
+	}
+	return lines
+}
+
+// ClassifyLine returns the severity of a single line of terraform or terragrunt output.
+func ClassifyLine(line string) Severity {
+	line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "│╷╵"))
+	lower := strings.ToLower(line)
+
+	switch {
+	case strings.HasPrefix(line, "Error:"),
+		strings.HasPrefix(line, "ERRO["),
+		strings.Contains(line, "[ERROR]"),
+		strings.Contains(lower, "level=error"),
+		strings.HasPrefix(lower, "error "):
+		return SeverityError
+	case strings.HasPrefix(line, "Warning:"),
+		strings.HasPrefix(line, "WARN["),
+		strings.Contains(line, "[WARN]"),
+		strings.Contains(lower, "level=warn"):
+		return SeverityWarn
+	default:
+		return SeverityInfo
+	}
+}
+
+// createdAddressRegex matches the line terraform prints when a resource has been created,
+// e.g. `module.vm.proxmox_vm_qemu.this[0]: Creation complete after 42s [id=pve/qemu/101]`.
+// Terragrunt may put a timestamp or module path in front of it.
+var createdAddressRegex = regexp.MustCompile(
+	`([A-Za-z0-9_-]+(?:\[[^\]]*\])?(?:\.[A-Za-z0-9_-]+(?:\[[^\]]*\])?)+): Creation complete after`)
+
+// CreatedResourceAddresses returns the addresses of the resources an apply created, in the
+// order they completed, from the apply output.
+func CreatedResourceAddresses(output string) []string {
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(stripANSI(output), "\n") {
+		match := createdAddressRegex.FindStringSubmatch(line)
+		if match == nil || seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		addresses = append(addresses, match[1])
+	}
+	return addresses
+}
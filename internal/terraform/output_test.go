@@ -0,0 +1,123 @@
+// Package terraform provides output classification tests.
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want Severity
+	}{
+		{"proxmox_vm_qemu.vm: Creating...", SeverityInfo},
+		{"Apply complete! Resources: 1 added, 0 changed, 0 destroyed.", SeverityInfo},
+		{"Plan: 1 to add, 0 to change, 0 to destroy.", SeverityInfo},
+		{"Error: Invalid value for variable", SeverityError},
+		{"│ Error: error creating VM: 500 no such storage", SeverityError},
+		{"Warning: Argument is deprecated", SeverityWarn},
+		{"╷ Warning: Value for undeclared variable", SeverityWarn},
+		{"time=2024-05-01T10:00:00Z level=error msg=Module ./vm has finished with an error", SeverityError},
+		{"time=2024-05-01T10:00:00Z level=warning msg=No double-slash (//) found in source URL", SeverityWarn},
+		{"ERRO[0003] Hit multiple errors:", SeverityError},
+		{"WARN[0000] No double-slash (//) found in source URL", SeverityWarn},
+		{"2024/05/01 10:00:00 [ERROR] provider crashed", SeverityError},
+		{"  + error_log_path = \"/var/log\"", SeverityInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassifyLine(tt.line))
+		})
+	}
+}
+
+func TestExecutionResult_Lines(t *testing.T) {
+	result := &ExecutionResult{
+		Output: "proxmox_vm_qemu.vm: Creating...\n\n" +
+			"╷\n" +
+			"│ Warning: Argument is deprecated\n" +
+			"│ \n" +
+			"│   with proxmox_vm_qemu.vm,\n" +
+			"╵\n",
+		Error: "╷\n│ Error: error creating VM\n│   on main.tf line 1\n╵\n",
+	}
+
+	lines := result.Lines()
+	require.Len(t, lines, 10)
+
+	severities := make([]Severity, 0, len(lines))
+	for _, line := range lines {
+		severities = append(severities, line.Severity)
+	}
+	assert.Equal(t, []Severity{
+		SeverityInfo, SeverityInfo, SeverityWarn, SeverityWarn, SeverityWarn, SeverityWarn,
+		SeverityInfo, SeverityError, SeverityError, SeverityError,
+	}, severities)
+
+	assert.Equal(t, "stdout", lines[0].Stream)
+	assert.Equal(t, "stderr", lines[9].Stream)
+	assert.Equal(t, 10, lines[9].Number)
+	assert.Equal(t, "│   with proxmox_vm_qemu.vm,", lines[4].Text)
+}
+
+func TestExecutionResult_Render(t *testing.T) {
+	result := &ExecutionResult{
+		Success:  false,
+		Output:   "vm: Creating...\n",
+		Error:    "Error: boom\n",
+		Duration: 2 * time.Second,
+	}
+
+	t.Run("json keeps raw output", func(t *testing.T) {
+		rendered, err := result.Render(OutputModeJSON)
+		require.NoError(t, err)
+
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(rendered), &decoded))
+		assert.Equal(t, "vm: Creating...\n", decoded["output"])
+		assert.Len(t, decoded["lines"], 2)
+	})
+
+	t.Run("human", func(t *testing.T) {
+		rendered, err := result.Render(OutputModeHuman)
+		require.NoError(t, err)
+		assert.Equal(t, "[info ] vm: Creating...\n[error] Error: boom\nfailed in 2s\n", rendered)
+	})
+
+	t.Run("unsupported mode", func(t *testing.T) {
+		_, err := result.Render("yaml")
+		assert.Error(t, err)
+	})
+}
+
+// applyOutput is captured from a terragrunt apply of a PVE VM module.
+const applyOutput = `Initializing the backend...
+module.vm.proxmox_virtual_environment_file.cloud_init: Creating...
+module.vm.proxmox_virtual_environment_file.cloud_init: Creation complete after 1s [id=local:snippets/web-01.yaml]
+module.vm.proxmox_virtual_environment_vm.this["web-01"]: Creating...
+module.vm.proxmox_virtual_environment_vm.this["web-01"]: Still creating... [10s elapsed]
+module.vm.proxmox_virtual_environment_vm.this["web-01"]: Creation complete after 42s [id=101]
+10:04:05.123 STDOUT terraform: module.dns.powerdns_record.a[0]: Creation complete after 0s [id=web-01.lab.:::A]
+data.proxmox_virtual_environment_nodes.all: Read complete after 0s [id=nodes]
+module.vm.null_resource.wait: Destruction complete after 0s
+` + "\x1b[0m\x1b[1mmodule.vm.null_resource.wait: Creation complete after 5s [id=123]\x1b[0m" + `
+
+Apply complete! Resources: 4 added, 0 changed, 1 destroyed.
+`
+
+func TestCreatedResourceAddresses(t *testing.T) {
+	assert.Equal(t, []string{
+		"module.vm.proxmox_virtual_environment_file.cloud_init",
+		`module.vm.proxmox_virtual_environment_vm.this["web-01"]`,
+		"module.dns.powerdns_record.a[0]",
+		"module.vm.null_resource.wait",
+	}, CreatedResourceAddresses(applyOutput))
+
+	assert.Empty(t, CreatedResourceAddresses("No changes. Your infrastructure matches the configuration.\n"))
+}
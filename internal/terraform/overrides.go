@@ -0,0 +1,116 @@
+// Package terraform provides Terraform execution and management functionality.
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// credentialVariables are the variables the executor fills from the request's credential.
+var credentialVariables = map[string]bool{
+	"proxmox_api_url":    true,
+	"proxmox_user":       true,
+	"proxmox_password":   true,
+	"proxmox_host":       true,
+	"pm_user":            true,
+	"pm_api_token":       true,
+	"pm_password":        true,
+	"vsphere_server":     true,
+	"vsphere_user":       true,
+	"vsphere_password":   true,
+	"openstack_auth_url": true,
+	"openstack_user":     true,
+	"openstack_password": true,
+	"api_endpoint":       true,
+	"api_username":       true,
+	"api_password":       true,
+	"api_token":          true,
+}
+
+// credentialNameParts mark variables of any module that hold secrets.
+var credentialNameParts = []string{"password", "secret", "token", "access_key", "private_key", "api_key", "credential"}
+
+// variableNamePattern matches a Terraform identifier.
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// IsCredentialVariable reports whether a variable carries provider credentials and so must
+// only ever be set from the request's credential, never by a requester.
+func IsCredentialVariable(name string) bool {
+	return credentialVariables[strings.ToLower(name)] || isSecretVariable(name)
+}
+
+// isSecretVariable reports whether a variable's name marks it as holding a secret, unlike
+// the other credential variables such as the user name and endpoint.
+func isSecretVariable(name string) bool {
+	lower := strings.ToLower(name)
+	for _, part := range credentialNameParts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidVariableName reports whether name is a valid Terraform variable name.
+func IsValidVariableName(name string) bool {
+	return variableNamePattern.MatchString(name)
+}
+
+// applyVarOverrides replaces the assignments of overridden variables in lines and appends
+// the overrides not already assigned, in name order. Overrides of credential variables and
+// invalid names are dropped; the service rejects them before they get this far.
+func applyVarOverrides(lines []string, overrides map[string]string, format func(key, value string) string) []string {
+	if len(overrides) == 0 {
+		return lines
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		if IsValidVariableName(name) && !IsCredentialVariable(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	applied := make(map[string]bool, len(names))
+	merged := make([]string, 0, len(lines)+len(names))
+	for _, line := range lines {
+		name := assignedVariable(line)
+		value, ok := overrides[name]
+		if !ok || IsCredentialVariable(name) || !IsValidVariableName(name) {
+			merged = append(merged, line)
+			continue
+		}
+		if !applied[name] {
+			merged = append(merged, format(name, value))
+			applied[name] = true
+		}
+	}
+	for _, name := range names {
+		if !applied[name] {
+			merged = append(merged, format(name, overrides[name]))
+		}
+	}
+	return merged
+}
+
+// assignedVariable returns the variable assigned by an HCL line such as `  name = "value"`.
+func assignedVariable(line string) string {
+	name, _, ok := strings.Cut(line, "=")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
+
+// formatTFVar formats a string variable for terraform.tfvars.
+func formatTFVar(key, value string) string {
+	return fmt.Sprintf("%s = %q", key, value)
+}
+
+// formatStringInput formats a string variable for a terragrunt inputs block.
+func formatStringInput(key, value string) string {
+	return formatInputValue(key, value)
+}
@@ -0,0 +1,101 @@
+// Package terraform provides Terraform execution utilities.
+package terraform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Plan files and the configuration hashes recorded next to them by the last successful plan.
+const (
+	planFile            = "tfplan"
+	planHashFile        = ".tfplan.sha256"
+	destroyPlanFile     = "tfdestroyplan"
+	destroyPlanHashFile = ".tfdestroyplan.sha256"
+)
+
+// ErrStalePlan indicates the configuration in the work directory changed after the plan was created.
+var ErrStalePlan = errors.New("configuration changed since the plan was created")
+
+// isConfigFile reports whether a file in the work directory is part of the generated configuration.
+func isConfigFile(name string) bool {
+	if name == ".terraformrc" {
+		return true
+	}
+	switch filepath.Ext(name) {
+	case ".tf", ".tfvars", ".hcl":
+		return !strings.HasPrefix(name, ".")
+	default:
+		return false
+	}
+}
+
+// ConfigHash returns a SHA-256 digest over the configuration files at the top level of workDir.
+// File names are included in the digest so renaming or removing a file also changes the hash.
+func ConfigHash(workDir string) (string, error) {
+	entries, err := os.ReadDir(workDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read work directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && isConfigFile(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		content, readErr := os.ReadFile(filepath.Join(workDir, name)) // #nosec G304 --  name comes from the work directory listing
+		if readErr != nil {
+			return "", fmt.Errorf("failed to read %s: %w", name, readErr)
+		}
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write(content)
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// recordPlanHash stores the current configuration hash in hashFile, next to the plan file.
+func recordPlanHash(workDir, hashFile string) error {
+	hash, err := ConfigHash(workDir)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(workDir, hashFile), []byte(hash), filePerm); err != nil {
+		return fmt.Errorf("failed to write plan hash: %w", err)
+	}
+	return nil
+}
+
+// verifyPlanHash checks that the configuration still matches the hash recorded in hashFile
+// at plan time.
+func verifyPlanHash(workDir, hashFile string) error {
+	recorded, err := os.ReadFile(filepath.Join(workDir, hashFile)) // #nosec G304 --  path is constructed from controlled input
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: no plan hash recorded", ErrStalePlan)
+		}
+		return fmt.Errorf("failed to read plan hash: %w", err)
+	}
+
+	current, err := ConfigHash(workDir)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(recorded)) != current {
+		return ErrStalePlan
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Package terraform provides Terraform execution utilities.
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PlanSummary lists the resource addresses a plan would change, by kind of change.
+type PlanSummary struct {
+	Create  []string `json:"create"`
+	Update  []string `json:"update"`
+	Replace []string `json:"replace"`
+	Delete  []string `json:"delete"`
+}
+
+// planJSON is the part of `terraform show -json <plan>` the summary is built from.
+type planJSON struct {
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  struct {
+			Actions []string `json:"actions"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+// ParsePlanJSON summarizes the output of `terraform show -json` for a saved plan. Resources
+// without changes and data source reads are left out.
+func ParsePlanJSON(data []byte) (*PlanSummary, error) {
+	var plan planJSON
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan JSON: %w", err)
+	}
+
+	summary := &PlanSummary{Create: []string{}, Update: []string{}, Replace: []string{}, Delete: []string{}}
+	for _, rc := range plan.ResourceChanges {
+		actions := rc.Change.Actions
+		switch {
+		case len(actions) == 2:
+			// ["delete", "create"] or ["create", "delete"]
+			summary.Replace = append(summary.Replace, rc.Address)
+		case len(actions) != 1:
+			continue
+		case actions[0] == "create":
+			summary.Create = append(summary.Create, rc.Address)
+		case actions[0] == "update":
+			summary.Update = append(summary.Update, rc.Address)
+		case actions[0] == "delete":
+			summary.Delete = append(summary.Delete, rc.Address)
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,49 @@
+// Package terraform provides plan summary tests.
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlanJSON(t *testing.T) {
+	t.Run("destroy plan fixture", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join("testdata", "destroy_plan.json"))
+		require.NoError(t, err)
+
+		summary, err := ParsePlanJSON(data)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"module.vm.proxmox_vm_qemu.this[0]",
+			"module.vm.proxmox_vm_qemu.this[1]",
+		}, summary.Delete)
+		assert.Empty(t, summary.Create)
+		assert.Empty(t, summary.Update)
+		assert.Empty(t, summary.Replace)
+	})
+
+	t.Run("mixed actions", func(t *testing.T) {
+		summary, err := ParsePlanJSON([]byte(`{"resource_changes": [
+			{"address": "a.new", "change": {"actions": ["create"]}},
+			{"address": "a.changed", "change": {"actions": ["update"]}},
+			{"address": "a.replaced", "change": {"actions": ["delete", "create"]}},
+			{"address": "a.gone", "change": {"actions": ["delete"]}}
+		]}`))
+		require.NoError(t, err)
+		assert.Equal(t, &PlanSummary{
+			Create:  []string{"a.new"},
+			Update:  []string{"a.changed"},
+			Replace: []string{"a.replaced"},
+			Delete:  []string{"a.gone"},
+		}, summary)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := ParsePlanJSON([]byte("Plan: 0 to add"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,79 @@
+// Package terraform provides Terraform execution utilities.
+package terraform
+
+import (
+	"fmt"
+	"sort"
+)
+
+// secretVariables returns the provider passwords and tokens among the variables generated
+// for config, by variable name. The names match those written by generateTFVars, or by
+// buildTerragruntInputs when terragrunt is set.
+func secretVariables(config Config, terragrunt bool) map[string]string {
+	vars := make(map[string]string)
+	set := func(name, value string) {
+		if value != "" {
+			vars[name] = value
+		}
+	}
+
+	switch {
+	case terragrunt && config.Provider == providerPVE:
+		if config.ClusterToken != "" {
+			set("pm_api_token", config.ClusterToken)
+		} else {
+			set("pm_password", config.ClusterPassword)
+		}
+	case terragrunt:
+		set("api_token", config.ClusterToken)
+	case config.Provider == providerPVE:
+		set("proxmox_password", config.ClusterPassword)
+	case config.Provider == "vmware":
+		set("vsphere_password", config.ClusterPassword)
+	case config.Provider == "openstack":
+		set("openstack_password", config.ClusterPassword)
+	default:
+		set("api_password", config.ClusterPassword)
+		set("api_token", config.ClusterToken)
+	}
+	return vars
+}
+
+// withoutSecrets returns config with the provider password and token cleared, so the files
+// generated from it leave them out.
+func withoutSecrets(config Config) Config {
+	config.ClusterPassword = ""
+	config.ClusterToken = ""
+	return config
+}
+
+// tfVarEnv turns variables into TF_VAR_ environment entries, sorted by name.
+func tfVarEnv(vars map[string]string) []string {
+	env := make([]string, 0, len(vars))
+	for name, value := range vars {
+		env = append(env, fmt.Sprintf("TF_VAR_%s=%s", name, value))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// setSecretEnv keeps the TF_VAR_ entries for workDir's secrets in memory for buildEnv.
+func (e *Executor) setSecretEnv(workDir string, vars map[string]string) {
+	e.secretsMu.Lock()
+	defer e.secretsMu.Unlock()
+	if len(vars) == 0 {
+		delete(e.secretEnv, workDir)
+		return
+	}
+	if e.secretEnv == nil {
+		e.secretEnv = make(map[string][]string)
+	}
+	e.secretEnv[workDir] = tfVarEnv(vars)
+}
+
+// secretEnvFor returns the TF_VAR_ entries kept for workDir, if any.
+func (e *Executor) secretEnvFor(workDir string) []string {
+	e.secretsMu.Lock()
+	defer e.secretsMu.Unlock()
+	return e.secretEnv[workDir]
+}
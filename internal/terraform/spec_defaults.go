@@ -0,0 +1,48 @@
+// Package terraform provides Terraform execution utilities.
+package terraform
+
+// SpecDefaults maps a provider to the spec values used when a request leaves them out.
+type SpecDefaults map[string]map[string]interface{}
+
+// DefaultSpecDefaults returns the built-in spec defaults.
+func DefaultSpecDefaults() SpecDefaults {
+	return SpecDefaults{
+		providerPVE: {
+			"target_node":   "pve",
+			"template_name": "ubuntu-template",
+		},
+	}
+}
+
+// Merge returns a copy of d with the values in overrides taking precedence, field by field.
+func (d SpecDefaults) Merge(overrides SpecDefaults) SpecDefaults {
+	merged := make(SpecDefaults, len(d)+len(overrides))
+	for _, source := range []SpecDefaults{d, overrides} {
+		for provider, values := range source {
+			if merged[provider] == nil {
+				merged[provider] = make(map[string]interface{}, len(values))
+			}
+			for key, value := range values {
+				merged[provider][key] = value
+			}
+		}
+	}
+	return merged
+}
+
+// Apply returns spec with the provider's defaults filled in for the fields it omits.
+// spec itself is not modified.
+func (d SpecDefaults) Apply(provider string, spec map[string]interface{}) map[string]interface{} {
+	defaults := d[provider]
+	if len(defaults) == 0 {
+		return spec
+	}
+	merged := make(map[string]interface{}, len(spec)+len(defaults))
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	for key, value := range spec {
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,134 @@
+// Package terraform provides Terraform execution utilities.
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidSpec indicates a spec field has the wrong type for the provider.
+var ErrInvalidSpec = errors.New("invalid spec")
+
+// specFieldType is the type a known spec field must have.
+type specFieldType string
+
+const (
+	specInteger specFieldType = "an integer"
+	specString  specFieldType = "a string"
+)
+
+// commonSpecSchema types the spec fields every provider reads.
+var commonSpecSchema = map[string]specFieldType{
+	"cpu":      specInteger,
+	"memory":   specInteger,
+	"disk":     specInteger,
+	"name":     specString,
+	"network":  specString,
+	"os_image": specString,
+}
+
+// providerSpecSchemas types the spec fields only one provider reads.
+var providerSpecSchemas = map[string]map[string]specFieldType{
+	providerPVE: {
+		"target_node":    specString,
+		"template_name":  specString,
+		"storage_pool":   specString,
+		"network_bridge": specString,
+	},
+	"vmware": {
+		"datacenter":    specString,
+		"cluster":       specString,
+		"datastore":     specString,
+		"network_name":  specString,
+		"template_name": specString,
+	},
+	"openstack": {
+		"flavor_name":  specString,
+		"image_name":   specString,
+		"network_name": specString,
+		"tenant_name":  specString,
+	},
+}
+
+// SpecFieldError describes a spec field whose value has the wrong type.
+type SpecFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// SpecError lists the spec fields that failed validation. It unwraps to ErrInvalidSpec.
+type SpecError struct {
+	Fields []SpecFieldError
+}
+
+// Error implements the error interface.
+func (e *SpecError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + " " + field.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidSpec, strings.Join(messages, "; "))
+}
+
+// Unwrap returns ErrInvalidSpec so callers can match with errors.Is.
+func (e *SpecError) Unwrap() error {
+	return ErrInvalidSpec
+}
+
+// ValidateSpec checks the known fields of spec against the provider's schema and returns a
+// *SpecError naming each one with the wrong type. Fields the schema does not know are left
+// to the module.
+func ValidateSpec(provider string, spec map[string]interface{}) error {
+	var fields []SpecFieldError
+	for key, value := range spec {
+		want, ok := providerSpecSchemas[provider][key]
+		if !ok {
+			want, ok = commonSpecSchema[key]
+		}
+		if !ok || hasSpecType(value, want) {
+			continue
+		}
+		fields = append(fields, SpecFieldError{Field: key, Message: fmt.Sprintf("must be %s, got %s", want, describeSpecValue(value))})
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return &SpecError{Fields: fields}
+}
+
+// hasSpecType reports whether value has type want. Integers decoded from JSON arrive as
+// whole float64 values.
+func hasSpecType(value interface{}, want specFieldType) bool {
+	switch want {
+	case specInteger:
+		switch v := value.(type) {
+		case int, int32, int64:
+			return true
+		case float64:
+			return v == math.Trunc(v) && !math.IsInf(v, 0)
+		}
+		return false
+	case specString:
+		_, ok := value.(string)
+		return ok
+	}
+	return true
+}
+
+// describeSpecValue describes value for a field error.
+func describeSpecValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case float64, int, int32, int64, bool:
+		return fmt.Sprintf("%v", v)
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
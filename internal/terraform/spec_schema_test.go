@@ -0,0 +1,60 @@
+// Package terraform provides spec schema tests.
+package terraform
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestValidateSpec(t *testing.T) {
+	t.Run("valid types are accepted", func(t *testing.T) {
+		var spec map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"cpu": 2, "memory": 4096, "disk": 50, "name": "web-01",
+			"target_node": "pve-02", "template_name": "debian-12", "tags": ["web"]
+		}`), &spec))
+		assert.NoError(t, ValidateSpec("pve", spec))
+		assert.NoError(t, ValidateSpec("pve", map[string]interface{}{"cpu": 4}), "integers set in code")
+		assert.NoError(t, ValidateSpec("aws", map[string]interface{}{"instance_type": 3}), "fields outside the schema are not checked")
+	})
+
+	t.Run("wrong types are rejected field by field", func(t *testing.T) {
+		err := ValidateSpec("vmware", map[string]interface{}{
+			"cpu": "two", "memory": 1.5, "disk": 40, "datastore": 7, "name": "db-01",
+		})
+		require.ErrorIs(t, err, ErrInvalidSpec)
+		var specErr *SpecError
+		require.ErrorAs(t, err, &specErr)
+		assert.Equal(t, []SpecFieldError{
+			{Field: "cpu", Message: `must be an integer, got "two"`},
+			{Field: "datastore", Message: "must be a string, got 7"},
+			{Field: "memory", Message: "must be an integer, got 1.5"},
+		}, specErr.Fields)
+	})
+
+	t.Run("provider fields are only checked for their provider", func(t *testing.T) {
+		assert.NoError(t, ValidateSpec("pve", map[string]interface{}{"flavor_name": 2}))
+		assert.Error(t, ValidateSpec("openstack", map[string]interface{}{"flavor_name": 2}))
+	})
+}
+
+func TestExecutor_GenerateTFFilesRejectsInvalidSpec(t *testing.T) {
+	workDir := filepath.Join(t.TempDir(), "work")
+	executor := &Executor{logger: zap.NewNop(), run: (&fakeRunner{}).run}
+
+	err := executor.GenerateTFFiles(workDir, Config{
+		Provider:    "pve",
+		Environment: "dev",
+		Spec:        map[string]interface{}{"cpu": "two", "target_node": "pve"},
+	})
+	require.ErrorIs(t, err, ErrInvalidSpec)
+	assert.Contains(t, err.Error(), "cpu must be an integer")
+	_, statErr := os.Stat(workDir)
+	assert.ErrorIs(t, statErr, os.ErrNotExist, "nothing is generated")
+}
@@ -0,0 +1,120 @@
+package terraform
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Veritas-Calculus/vc-lab-platform/internal/constants"
+	"go.uber.org/zap"
+)
+
+// CleanupPolicy decides what is left of a work directory once a run has finished.
+type CleanupPolicy string
+
+// Cleanup policies.
+const (
+	// CleanupKeepState removes saved plans and crash logs. The configuration, state, lock
+	// file and initialized .terraform directory stay, so later runs need no re-init.
+	CleanupKeepState CleanupPolicy = constants.TerraformCleanupKeepState
+	// CleanupRemoveProviders also removes the downloaded provider plugins, the bulk of a
+	// work directory. State and the lock file stay; the next init downloads the same
+	// provider versions again.
+	CleanupRemoveProviders CleanupPolicy = constants.TerraformCleanupRemoveProviders
+	// CleanupRemoveAll removes the whole work directory. Only use it for providers whose
+	// modules keep their state in a remote backend.
+	CleanupRemoveAll CleanupPolicy = constants.TerraformCleanupRemoveAll
+)
+
+// ErrWorkDirNotFound indicates a request's work directory does not exist, because it was
+// never created or a cleanup policy removed it.
+var ErrWorkDirNotFound = errors.New("terraform work directory not found")
+
+// runArtifacts are files a finished run leaves behind that no later run reads.
+var runArtifacts = map[string]bool{
+	planFile:            true,
+	planHashFile:        true,
+	destroyPlanFile:     true,
+	destroyPlanHashFile: true,
+	"crash.log":         true,
+}
+
+// Workspace controls where work directories are created and how they are cleaned up.
+type Workspace struct {
+	// Root holds one work directory per resource request. Empty means
+	// constants.DefaultTerraformWorkDir.
+	Root string
+	// Cleanup is the policy for providers without an entry in ProviderCleanup. Empty means
+	// keep-state.
+	Cleanup string
+	// ProviderCleanup overrides Cleanup per provider.
+	ProviderCleanup map[string]string
+}
+
+// WorkDir returns the work directory for a resource request.
+func (w Workspace) WorkDir(requestID string) string {
+	root := w.Root
+	if root == "" {
+		root = constants.DefaultTerraformWorkDir
+	}
+	return filepath.Join(root, requestID)
+}
+
+// CleanupPolicy returns the cleanup policy for provider's work directories.
+func (w Workspace) CleanupPolicy(provider string) CleanupPolicy {
+	if policy, ok := w.ProviderCleanup[provider]; ok && policy != "" {
+		return CleanupPolicy(policy)
+	}
+	if w.Cleanup != "" {
+		return CleanupPolicy(w.Cleanup)
+	}
+	return CleanupKeepState
+}
+
+// WorkDir returns the work directory for a resource request.
+func (e *Executor) WorkDir(requestID string) string {
+	return e.workspace.WorkDir(requestID)
+}
+
+// Cleanup applies provider's cleanup policy to workDir. Call it once the run is over, not
+// between plan and apply: every policy removes the saved plan an apply would use.
+func (e *Executor) Cleanup(workDir, provider string) error {
+	// The run is over whatever is kept on disk, so its secrets are dropped from memory
+	e.setSecretEnv(workDir, nil)
+
+	policy := e.workspace.CleanupPolicy(provider)
+	if policy == CleanupRemoveAll {
+		return os.RemoveAll(workDir)
+	}
+
+	removeProviders := policy == CleanupRemoveProviders
+	err := filepath.WalkDir(workDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Terragrunt nests a .terraform directory inside its cache, so look at every level.
+			if removeProviders && d.Name() == "providers" && filepath.Base(filepath.Dir(path)) == ".terraform" {
+				if err := os.RemoveAll(path); err != nil {
+					return err
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if runArtifacts[d.Name()] {
+			return os.Remove(path)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	e.logger.Debug("cleaned up work directory", zap.String("work_dir", workDir), zap.String("policy", string(policy)))
+	return nil
+}
@@ -0,0 +1,124 @@
+// Package terraform provides work directory cleanup tests.
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// workDirFiles are the paths a finished terragrunt run leaves in its work directory.
+var workDirFiles = []string{
+	"terragrunt.hcl",
+	"terraform.tfstate",
+	".terraform.lock.hcl",
+	planHashFile,
+	destroyPlanHashFile,
+	"crash.log",
+	".terraform/modules/vm/main.tf",
+	".terraform/providers/registry.terraform.io/bpg/proxmox/0.38.0/linux_amd64/terraform-provider-proxmox",
+	".terragrunt-cache/abc/def/tfplan",
+	".terragrunt-cache/abc/def/main.tf",
+	".terragrunt-cache/abc/def/.terraform/providers/registry.terraform.io/bpg/proxmox/0.38.0/linux_amd64/terraform-provider-proxmox",
+}
+
+func newCleanupWorkDir(t *testing.T) string {
+	t.Helper()
+	workDir := filepath.Join(t.TempDir(), "req-1")
+	for _, name := range workDirFiles {
+		path := filepath.Join(workDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), dirPerm))
+		require.NoError(t, os.WriteFile(path, []byte("x"), filePerm))
+	}
+	return workDir
+}
+
+// remainingFiles lists the files left under workDir, relative to it.
+func remainingFiles(t *testing.T, workDir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(workDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(workDir, path)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestExecutor_Cleanup(t *testing.T) {
+	t.Run("keep-state removes only run artifacts", func(t *testing.T) {
+		workDir := newCleanupWorkDir(t)
+		executor := &Executor{logger: zap.NewNop()}
+		executor.setSecretEnv(workDir, map[string]string{"cluster_password": "s3cret"})
+
+		require.NoError(t, executor.Cleanup(workDir, "pve"))
+
+		assert.ElementsMatch(t, []string{
+			"terragrunt.hcl",
+			"terraform.tfstate",
+			".terraform.lock.hcl",
+			".terraform/modules/vm/main.tf",
+			".terraform/providers/registry.terraform.io/bpg/proxmox/0.38.0/linux_amd64/terraform-provider-proxmox",
+			".terragrunt-cache/abc/def/main.tf",
+			".terragrunt-cache/abc/def/.terraform/providers/registry.terraform.io/bpg/proxmox/0.38.0/linux_amd64/terraform-provider-proxmox",
+		}, remainingFiles(t, workDir))
+		assert.Empty(t, executor.secretEnvFor(workDir), "secrets are dropped even though the state is kept")
+		assert.Empty(t, executor.secretEnv)
+	})
+
+	t.Run("remove-providers also removes provider plugins", func(t *testing.T) {
+		workDir := newCleanupWorkDir(t)
+		executor := &Executor{logger: zap.NewNop(), workspace: Workspace{
+			ProviderCleanup: map[string]string{"pve": string(CleanupRemoveProviders)},
+		}}
+
+		require.NoError(t, executor.Cleanup(workDir, "pve"))
+
+		assert.ElementsMatch(t, []string{
+			"terragrunt.hcl",
+			"terraform.tfstate",
+			".terraform.lock.hcl",
+			".terraform/modules/vm/main.tf",
+			".terragrunt-cache/abc/def/main.tf",
+		}, remainingFiles(t, workDir))
+	})
+
+	t.Run("remove-all removes the work directory", func(t *testing.T) {
+		workDir := newCleanupWorkDir(t)
+		executor := &Executor{logger: zap.NewNop(), workspace: Workspace{Cleanup: string(CleanupRemoveAll)}}
+		executor.setSecretEnv(workDir, map[string]string{"cluster_password": "s3cret"})
+
+		require.NoError(t, executor.Cleanup(workDir, "pve"))
+
+		_, err := os.Stat(workDir)
+		assert.True(t, os.IsNotExist(err))
+		assert.Empty(t, executor.secretEnvFor(workDir))
+	})
+
+	t.Run("missing work directory", func(t *testing.T) {
+		executor := &Executor{logger: zap.NewNop()}
+		require.NoError(t, executor.Cleanup(filepath.Join(t.TempDir(), "missing"), "pve"))
+	})
+}
+
+func TestWorkspace(t *testing.T) {
+	workspace := Workspace{
+		Root:            "/var/lib/vc-lab/terraform",
+		Cleanup:         string(CleanupRemoveProviders),
+		ProviderCleanup: map[string]string{"aws": string(CleanupRemoveAll)},
+	}
+
+	assert.Equal(t, "/var/lib/vc-lab/terraform/req-1", workspace.WorkDir("req-1"))
+	assert.Equal(t, "/tmp/terraform/req-1", Workspace{}.WorkDir("req-1"))
+	assert.Equal(t, CleanupRemoveAll, workspace.CleanupPolicy("aws"))
+	assert.Equal(t, CleanupRemoveProviders, workspace.CleanupPolicy("pve"))
+	assert.Equal(t, CleanupKeepState, Workspace{}.CleanupPolicy("pve"))
+}
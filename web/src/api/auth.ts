@@ -1,5 +1,5 @@
 import apiClient from './client';
-import type { TokenPair, User } from '@/types';
+import type { TokenPair, User, UserPreferences } from '@/types';
 
 /**
  * Authentication API functions.
@@ -58,4 +58,20 @@ export const authApi = {
       new_password: newPassword,
     });
   },
+
+  /**
+   * Get the current user's default environment, provider and zone.
+   */
+  async getPreferences(): Promise<UserPreferences> {
+    const response = await apiClient.get<UserPreferences>('/users/me/preferences');
+    return response.data;
+  },
+
+  /**
+   * Replace the current user's defaults. Omitted fields are cleared.
+   */
+  async updatePreferences(data: Partial<UserPreferences>): Promise<UserPreferences> {
+    const response = await apiClient.put<UserPreferences>('/users/me/preferences', data);
+    return response.data;
+  },
 };
@@ -11,6 +11,13 @@ import type {
   TestConnectionReq,
   NodeConfig,
   NodeConfigListResponse,
+  NodeConfigRetrySummary,
+  NodeConfigDiff,
+  NodeConfigArtifactURL,
+  NodeConfigHistoryResponse,
+  NodeConfigPreview,
+  PreviewNodeConfigReq,
+  RetryFailedNodeConfigsReq,
   GitModuleListResponse,
 } from '../types';
 
@@ -79,6 +86,33 @@ export const nodeConfigApi = {
     );
     return response.data;
   },
+
+  retryFailed: async (data: RetryFailedNodeConfigsReq = {}): Promise<NodeConfigRetrySummary> => {
+    const response = await apiClient.post<NodeConfigRetrySummary>('/git/node-configs/retry-failed', data);
+    return response.data;
+  },
+
+  diff: async (id: string): Promise<NodeConfigDiff> => {
+    const response = await apiClient.get<NodeConfigDiff>(`/git/node-configs/${id}/diff`);
+    return response.data;
+  },
+
+  history: async (id: string, page = 1, pageSize = 20): Promise<NodeConfigHistoryResponse> => {
+    const response = await apiClient.get<NodeConfigHistoryResponse>(`/git/node-configs/${id}/history`, {
+      params: { page, page_size: pageSize },
+    });
+    return response.data;
+  },
+
+  artifactURL: async (id: string): Promise<NodeConfigArtifactURL> => {
+    const response = await apiClient.post<NodeConfigArtifactURL>(`/git/node-configs/${id}/artifacts/url`);
+    return response.data;
+  },
+
+  preview: async (data: PreviewNodeConfigReq): Promise<NodeConfigPreview> => {
+    const response = await apiClient.post<NodeConfigPreview>('/git/node-configs/preview', data);
+    return response.data;
+  },
 };
 
 // Git Modules API - scan Terraform modules from git repository
@@ -92,4 +126,10 @@ export const gitModulesApi = {
     const response = await apiClient.post<GitModuleListResponse>('/git/modules/sync');
     return response.data;
   },
+
+  // Admin only: drop the cached clones so the next scan clones afresh
+  clearCache: async (): Promise<{ removed: number; message: string }> => {
+    const response = await apiClient.post<{ removed: number; message: string }>('/git/modules/clear-cache');
+    return response.data;
+  },
 };
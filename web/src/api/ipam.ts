@@ -7,6 +7,9 @@ import type {
   IPAllocation,
   IPAllocationListResponse,
   AllocateIPReq,
+  ReserveIPRangeReq,
+  IPReservationListResponse,
+  Usage,
 } from '@/types';
 
 /**
@@ -39,6 +42,14 @@ export const ipPoolApi = {
     return response.data;
   },
 
+  /**
+   * List the allocations and resources that depend on an IP pool.
+   */
+  async getUsage(id: string): Promise<Usage> {
+    const response = await apiClient.get<Usage>(`/ipam/pools/${id}/usage`);
+    return response.data;
+  },
+
   /**
    * Create a new IP pool.
    */
@@ -77,6 +88,29 @@ export const ipPoolApi = {
     });
     return response.data;
   },
+
+  /**
+   * List the reserved addresses of a pool.
+   */
+  async listReservations(poolId: string, params?: {
+    page?: number;
+    pageSize?: number;
+  }): Promise<IPReservationListResponse> {
+    const response = await apiClient.get<IPReservationListResponse>(`/ipam/pools/${poolId}/reservations`, {
+      params: {
+        page: params?.page || 1,
+        page_size: params?.pageSize || 50,
+      },
+    });
+    return response.data;
+  },
+
+  /**
+   * Reserve a range of a pool's addresses so they are never allocated automatically.
+   */
+  async reserveRange(poolId: string, data: ReserveIPRangeReq): Promise<void> {
+    await apiClient.post(`/ipam/pools/${poolId}/reservations`, data);
+  },
 };
 
 /**
@@ -6,6 +6,12 @@ import type {
   ResourceRequest,
   RequestListResponse,
   CreateResourceRequestReq,
+  RequestAttachment,
+  RequestStatusEvent,
+  BulkDeleteResult,
+  ImportResourcesRequest,
+  ImportResourcesResult,
+  TerraformOutput,
 } from '@/types';
 
 interface ResourceListParams {
@@ -48,6 +54,25 @@ export const resourceApi = {
     return response.data;
   },
 
+  /**
+   * Search resources by name, hostname, IP address, tag or spec value, best matches first.
+   */
+  async search(q: string, params: ResourceListParams = {}): Promise<ResourceListResponse> {
+    const response = await apiClient.get<ResourceListResponse>('/resources/search', {
+      params: {
+        q,
+        page: params.page ?? 1,
+        page_size: params.pageSize ?? 20,
+        type: params.type,
+        provider: params.provider,
+        status: params.status,
+        environment: params.environment,
+        owner_id: params.ownerId,
+      },
+    });
+    return response.data;
+  },
+
   /**
    * Get resource by ID.
    */
@@ -78,6 +103,23 @@ export const resourceApi = {
   async delete(id: string): Promise<void> {
     await apiClient.delete(`/resources/${id}`);
   },
+
+  /**
+   * Delete several resources, reporting which were deleted and why the rest were not.
+   */
+  async bulkDelete(ids: string[]): Promise<BulkDeleteResult> {
+    const response = await apiClient.post<BulkDeleteResult>('/resources/bulk-delete', { ids });
+    return response.data;
+  },
+
+  /**
+   * Import existing instances of a provider zone as resources, reconciling their IP
+   * addresses into the zone's pools.
+   */
+  async import(data: ImportResourcesRequest): Promise<ImportResourcesResult> {
+    const response = await apiClient.post<ImportResourcesResult>('/resources/import', data);
+    return response.data;
+  },
 };
 
 /**
@@ -150,4 +192,84 @@ export const resourceRequestApi = {
   async delete(id: string): Promise<void> {
     await apiClient.delete(`/resource-requests/${id}`);
   },
+
+  /**
+   * Delete several resource requests, reporting which were deleted and why the rest were not.
+   */
+  async bulkDelete(ids: string[]): Promise<BulkDeleteResult> {
+    const response = await apiClient.post<BulkDeleteResult>('/resource-requests/bulk-delete', { ids });
+    return response.data;
+  },
+
+  /**
+   * Get the status history of a resource request, oldest first.
+   */
+  async getHistory(id: string): Promise<RequestStatusEvent[]> {
+    const response = await apiClient.get<{ history: RequestStatusEvent[] }>(
+      `/resource-requests/${id}/history`
+    );
+    return response.data.history;
+  },
+
+  /**
+   * Get the raw `terraform output -json` document of a completed request. Sensitive
+   * values are redacted.
+   */
+  async getRawOutputs(id: string): Promise<Record<string, TerraformOutput>> {
+    const response = await apiClient.get<Record<string, TerraformOutput>>(
+      `/resource-requests/${id}/outputs/raw`
+    );
+    return response.data;
+  },
+
+  /**
+   * List the attachments of a resource request.
+   */
+  async listAttachments(id: string): Promise<RequestAttachment[]> {
+    const response = await apiClient.get<{ attachments: RequestAttachment[] }>(
+      `/resource-requests/${id}/attachments`
+    );
+    return response.data.attachments;
+  },
+
+  /**
+   * Attach a link to a resource request.
+   */
+  async addLinkAttachment(id: string, url: string, name?: string): Promise<RequestAttachment> {
+    const response = await apiClient.post<RequestAttachment>(`/resource-requests/${id}/attachments/links`, {
+      url,
+      name,
+    });
+    return response.data;
+  },
+
+  /**
+   * Upload a file attachment to a resource request.
+   */
+  async addFileAttachment(id: string, file: File): Promise<RequestAttachment> {
+    const form = new FormData();
+    form.append('file', file);
+    const response = await apiClient.post<RequestAttachment>(`/resource-requests/${id}/attachments/files`, form, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    });
+    return response.data;
+  },
+
+  /**
+   * Download the content of a file attachment.
+   */
+  async downloadAttachment(id: string, attachmentId: string): Promise<Blob> {
+    const response = await apiClient.get<Blob>(
+      `/resource-requests/${id}/attachments/${attachmentId}/download`,
+      { responseType: 'blob' }
+    );
+    return response.data;
+  },
+
+  /**
+   * Remove an attachment from a resource request.
+   */
+  async removeAttachment(id: string, attachmentId: string): Promise<void> {
+    await apiClient.delete(`/resource-requests/${id}/attachments/${attachmentId}`);
+  },
 };
@@ -6,10 +6,14 @@ import type {
   UpdateProviderReq,
   TestProviderConnectionReq,
   Credential,
+  CredentialSecret,
   CredentialListResponse,
   CreateCredentialReq,
   UpdateCredentialReq,
   TestCredentialConnectionReq,
+  Usage,
+  SupportedProvider,
+  MaintenanceStatus,
 } from '@/types';
 
 /**
@@ -23,17 +27,27 @@ export const providerApi = {
     page?: number;
     pageSize?: number;
     type?: string;
+    zoneId?: string;
   }): Promise<ProviderListResponse> {
     const response = await apiClient.get<ProviderListResponse>('/settings/providers', {
       params: {
         page: params?.page || 1,
         page_size: params?.pageSize || 20,
         type: params?.type,
+        zone_id: params?.zoneId,
       },
     });
     return response.data;
   },
 
+  /**
+   * List the provider types the platform supports and their capabilities.
+   */
+  async listSupported(): Promise<SupportedProvider[]> {
+    const response = await apiClient.get<{ providers: SupportedProvider[] }>('/providers/supported');
+    return response.data.providers || [];
+  },
+
   /**
    * Get provider by ID.
    */
@@ -42,6 +56,14 @@ export const providerApi = {
     return response.data;
   },
 
+  /**
+   * List what depends on a provider.
+   */
+  async getUsage(id: string): Promise<Usage> {
+    const response = await apiClient.get<Usage>(`/settings/providers/${id}/usage`);
+    return response.data;
+  },
+
   /**
    * Create a new provider.
    */
@@ -85,12 +107,14 @@ export const credentialApi = {
     page?: number;
     pageSize?: number;
     type?: string;
+    zoneId?: string;
   }): Promise<CredentialListResponse> {
     const response = await apiClient.get<CredentialListResponse>('/settings/credentials', {
       params: {
         page: params?.page || 1,
         page_size: params?.pageSize || 20,
         type: params?.type,
+        zone_id: params?.zoneId,
       },
     });
     return response.data;
@@ -104,6 +128,22 @@ export const credentialApi = {
     return response.data;
   },
 
+  /**
+   * Reveal a credential's secrets (admin only).
+   */
+  async reveal(id: string): Promise<CredentialSecret> {
+    const response = await apiClient.get<CredentialSecret>(`/settings/credentials/${id}/secret`);
+    return response.data;
+  },
+
+  /**
+   * List what depends on a credential.
+   */
+  async getUsage(id: string): Promise<Usage> {
+    const response = await apiClient.get<Usage>(`/settings/credentials/${id}/usage`);
+    return response.data;
+  },
+
   /**
    * Create a new credential.
    */
@@ -135,3 +175,24 @@ export const credentialApi = {
     return response.data;
   },
 };
+
+/**
+ * Maintenance mode API client (admin only).
+ */
+export const maintenanceApi = {
+  /**
+   * Get whether read-only maintenance mode is enabled.
+   */
+  async get(): Promise<MaintenanceStatus> {
+    const response = await apiClient.get<MaintenanceStatus>('/admin/maintenance');
+    return response.data;
+  },
+
+  /**
+   * Turn maintenance mode on or off.
+   */
+  async set(enabled: boolean): Promise<MaintenanceStatus> {
+    const response = await apiClient.put<MaintenanceStatus>('/admin/maintenance', { enabled });
+    return response.data;
+  },
+};
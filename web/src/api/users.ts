@@ -8,6 +8,9 @@ import type {
   RoleListResponse,
   CreateRoleRequest,
   UpdateRoleRequest,
+  RolePermissionsResponse,
+  Delegation,
+  CreateDelegationRequest,
 } from '@/types';
 
 /**
@@ -100,4 +103,70 @@ export const roleApi = {
   async delete(id: string): Promise<void> {
     await apiClient.delete(`/roles/${id}`);
   },
+
+  /**
+   * List a role's permissions.
+   */
+  async listPermissions(id: string): Promise<RolePermissionsResponse> {
+    const response = await apiClient.get<RolePermissionsResponse>(`/roles/${id}/permissions`);
+    return response.data;
+  },
+
+  /**
+   * Grant permissions to a role. Returns the role's resulting permissions.
+   */
+  async addPermissions(id: string, permissionIds: string[]): Promise<RolePermissionsResponse> {
+    const response = await apiClient.post<RolePermissionsResponse>(`/roles/${id}/permissions`, {
+      permission_ids: permissionIds,
+    });
+    return response.data;
+  },
+
+  /**
+   * Revoke permissions from a role. Returns the role's resulting permissions.
+   */
+  async removePermissions(id: string, permissionIds: string[]): Promise<RolePermissionsResponse> {
+    const response = await apiClient.delete<RolePermissionsResponse>(`/roles/${id}/permissions`, {
+      data: { permission_ids: permissionIds },
+    });
+    return response.data;
+  },
+
+  /**
+   * Replace a role's permissions. Returns the role's resulting permissions.
+   */
+  async setPermissions(id: string, permissionIds: string[]): Promise<RolePermissionsResponse> {
+    const response = await apiClient.put<RolePermissionsResponse>(`/roles/${id}/permissions`, {
+      permission_ids: permissionIds,
+    });
+    return response.data;
+  },
+};
+
+/**
+ * Approval delegation API functions.
+ */
+export const delegationApi = {
+  /**
+   * List the delegations the current user granted or received.
+   */
+  async list(): Promise<Delegation[]> {
+    const response = await apiClient.get<{ delegations: Delegation[] }>('/delegations');
+    return response.data.delegations;
+  },
+
+  /**
+   * Delegate the current user's approval authority to another user.
+   */
+  async create(data: CreateDelegationRequest): Promise<Delegation> {
+    const response = await apiClient.post<Delegation>('/delegations', data);
+    return response.data;
+  },
+
+  /**
+   * Revoke a delegation the current user granted.
+   */
+  async delete(id: string): Promise<void> {
+    await apiClient.delete(`/delegations/${id}`);
+  },
 };
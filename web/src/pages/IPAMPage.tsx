@@ -100,6 +100,7 @@ export default function IPAMPage() {
       end_ip: formData.get('end_ip') as string,
       zone_id: formData.get('zone_id') as string,
       network_type: formData.get('network_type') as string,
+      bridge: formData.get('bridge') as string,
       description: formData.get('description') as string,
     };
 
@@ -461,7 +462,7 @@ export default function IPAMPage() {
                       name="vlan_tag"
                       min="0"
                       max="4094"
-                      defaultValue={editingPool?.vlan_tag || 0}
+                      defaultValue={Math.max(editingPool?.vlan_tag ?? 0, 0)}
                       className="mt-1 block w-full border border-gray-300 rounded-md shadow-sm py-2 px-3 focus:outline-none focus:ring-primary-500 focus:border-primary-500"
                     />
                   </div>
@@ -469,15 +470,26 @@ export default function IPAMPage() {
                     <label className="block text-sm font-medium text-gray-700">Network Type</label>
                     <select
                       name="network_type"
-                      defaultValue={editingPool?.network_type || 'bridge'}
+                      defaultValue={editingPool?.network_type || 'private'}
                       className="mt-1 block w-full border border-gray-300 rounded-md shadow-sm py-2 px-3 focus:outline-none focus:ring-primary-500 focus:border-primary-500"
                     >
-                      <option value="bridge">Bridge</option>
-                      <option value="nat">NAT</option>
-                      <option value="vxlan">VXLAN</option>
+                      <option value="private">Private</option>
+                      <option value="public">Public</option>
+                      <option value="management">Management</option>
+                      <option value="storage">Storage</option>
                     </select>
                   </div>
                 </div>
+                <div>
+                  <label className="block text-sm font-medium text-gray-700">Bridge</label>
+                  <input
+                    type="text"
+                    name="bridge"
+                    placeholder="vmbr0"
+                    defaultValue={editingPool?.bridge}
+                    className="mt-1 block w-full border border-gray-300 rounded-md shadow-sm py-2 px-3 focus:outline-none focus:ring-primary-500 focus:border-primary-500"
+                  />
+                </div>
                 <div>
                   <label className="block text-sm font-medium text-gray-700">Description</label>
                   <input
@@ -887,8 +887,8 @@ export const InfraPage: React.FC = () => {
                       <div className="flex flex-wrap gap-1">
                         {mod.variables && mod.variables.length > 0 ? (
                           mod.variables.slice(0, 3).map((v) => (
-                            <span key={v} className="px-2 py-0.5 text-xs bg-purple-100 text-purple-700 rounded">
-                              {v}
+                            <span key={v.name} title={v.type} className="px-2 py-0.5 text-xs bg-purple-100 text-purple-700 rounded">
+                              {v.name}{v.required && '*'}
                             </span>
                           ))
                         ) : (
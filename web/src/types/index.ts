@@ -19,11 +19,20 @@ export interface User {
   status: number;
   last_login_at: string | null;
   last_login_ip: string;
+  preferences: UserPreferences;
   roles: Role[];
   created_at: string;
   updated_at: string;
 }
 
+// Defaults applied when a request or resource is created without these fields.
+// An empty value means no default.
+export interface UserPreferences {
+  default_environment: Environment | '';
+  default_provider: ProviderType | '';
+  default_zone_id: string | null;
+}
+
 export interface CreateUserRequest {
   username: string;
   email: string;
@@ -76,6 +85,34 @@ export interface Permission {
   action: string;
 }
 
+export interface RolePermissionsResponse {
+  permissions: Permission[];
+  total: number;
+}
+
+// Approval delegation types
+export interface Delegation {
+  id: string;
+  from_user_id: string;
+  from_user?: User;
+  to_user_id: string;
+  to_user?: User;
+  scope: string; // Environment name or '*' for every environment
+  starts_at: string;
+  ends_at: string;
+  reason: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface CreateDelegationRequest {
+  to_user_id: string;
+  scope?: string;
+  starts_at: string;
+  ends_at: string;
+  reason?: string;
+}
+
 // Auth types
 export interface TokenPair {
   access_token: string;
@@ -106,13 +143,24 @@ export interface Resource {
   expires_at: string | null;
   tags: string;
   description: string;
+  resource_addresses: string[] | null;
   created_at: string;
   updated_at: string;
 }
 
 export type ResourceType = 'vm' | 'container' | 'bare_metal';
 export type ProviderType = 'pve' | 'vmware' | 'openstack' | 'aws' | 'aliyun' | 'gcp' | 'azure';
-export type ResourceStatus = 'pending' | 'provisioning' | 'running' | 'stopped' | 'error';
+
+export interface SupportedProvider {
+  type: ProviderType;
+  name: string;
+  supports_terragrunt: boolean;
+  supports_raw_terraform: boolean;
+  required_spec_fields: string[];
+  requires_credential: boolean;
+  connection_test: boolean;
+}
+export type ResourceStatus = 'pending' | 'provisioning' | 'running' | 'degraded' | 'stopped' | 'error' | 'imported';
 export type Environment = 'dev' | 'test' | 'staging' | 'prod';
 
 export interface ResourceSpec {
@@ -137,12 +185,20 @@ export interface CreateResourceRequest {
 }
 
 // Resource request types
+export interface NodeDependency {
+  name: string;                   // terragrunt dependency block label
+  request_id: string;
+  inputs: Record<string, string>; // module input -> dependency output
+}
+
 export interface ResourceRequest {
   id: string;
   title: string;
   description: string;
   type: 'vm' | 'container' | 'bare_metal';
   spec: string;
+  var_overrides?: Record<string, string>;
+  dependencies?: NodeDependency[];
   environment: Environment;
   provider: ProviderType;
   quantity: number;
@@ -152,6 +208,9 @@ export interface ResourceRequest {
   approver_id: string | null;
   approver?: User;
   approved_at: string | null;
+  approved_on_behalf_of_id?: string | null;
+  auto_approved_by?: string;
+  approval_policy?: 'manual' | 'environment-gated' | 'environment-auto' | 'rule';
   rejected_at: string | null;
   provision_started_at: string | null;
   provision_completed_at: string | null;
@@ -168,18 +227,54 @@ export interface ResourceRequest {
 
 export type RequestStatus = 'pending' | 'approved' | 'rejected' | 'provisioning' | 'completed' | 'failed';
 
+// A single entry of `terraform output -json`.
+export interface TerraformOutput {
+  sensitive: boolean;
+  type: unknown;
+  value: unknown;
+}
+
+export interface RequestStatusEvent {
+  id: string;
+  request_id: string;
+  sequence: number;
+  from_status: string;
+  to_status: RequestStatus;
+  actor_id: string;
+  reason: string;
+  created_at: string;
+}
+
+export type AttachmentKind = 'link' | 'file';
+
+export interface RequestAttachment {
+  id: string;
+  request_id: string;
+  kind: AttachmentKind;
+  name: string;
+  url?: string;
+  content_type?: string;
+  size?: number;
+  added_by_id: string;
+  added_by?: User;
+  created_at: string;
+  updated_at: string;
+}
+
 export interface CreateResourceRequestReq {
   title: string;
   description?: string;
   type: 'vm' | 'container' | 'bare_metal';
-  environment: Environment;
-  provider: ProviderType;
+  environment?: Environment;  // Defaults to the user's preference
+  provider?: ProviderType;    // Defaults to the user's preference
   region_id?: string;
   zone_id?: string;
   tf_provider_id?: string;  // Selected Terraform provider
   tf_module_id?: string;    // Selected Terraform module
   credential_id?: string;   // Selected credential for access
   spec: string;
+  var_overrides?: Record<string, string>; // Module variables set beyond the spec
+  dependencies?: NodeDependency[];        // Sibling nodes whose outputs feed module inputs
   quantity?: number;
   expires_at?: string;
 }
@@ -240,6 +335,7 @@ export interface TestProviderConnectionReq {
   endpoint: string;
   credential_id?: string;
   config?: string;
+  force?: boolean; // skip the cached result of a test in the last 30 seconds
 }
 
 export interface UpdateProviderReq {
@@ -249,6 +345,7 @@ export interface UpdateProviderReq {
   config?: string;
   status?: number;
   is_default?: boolean;
+  credential_id?: string; // Empty string unlinks the credential
 }
 
 export interface ProviderListResponse extends PaginatedResponse<ProviderConfig> {
@@ -265,6 +362,8 @@ export interface Credential {
   endpoint: string;
   provider_id: string | null;
   provider?: ProviderConfig;
+  secret_key_masked: string; // e.g. "********aple"; empty when unset
+  token_masked: string;
   description: string;
   status: number;
   last_used_at: string | null;
@@ -274,6 +373,13 @@ export interface Credential {
   updated_at: string;
 }
 
+// Secrets of a credential, returned only by the admin reveal endpoint, which audits each call
+export interface CredentialSecret {
+  access_key: string;
+  secret_key: string;
+  token: string;
+}
+
 export interface CreateCredentialReq {
   name: string;
   type: ProviderType;
@@ -303,12 +409,33 @@ export interface TestCredentialConnectionReq {
   access_key?: string;
   secret_key?: string;
   token?: string;
+  force?: boolean; // skip the cached result of a test in the last 30 seconds
 }
 
 export interface CredentialListResponse extends PaginatedResponse<Credential> {
   credentials: Credential[];
 }
 
+// Usage report listing what references a credential, provider or IP pool
+export interface UsageRef {
+  id: string;
+  name: string;
+  status?: string;
+}
+
+export interface UsageGroup {
+  count: number;
+  items: UsageRef[];
+}
+
+export interface Usage {
+  providers?: UsageGroup;
+  credentials?: UsageGroup;
+  requests?: UsageGroup;
+  resources?: UsageGroup;
+  allocations?: UsageGroup;
+}
+
 // Region types
 export interface Region {
   id: string;
@@ -451,12 +578,23 @@ export interface TerraformModule {
   provider_id: string | null;
   provider?: TerraformProvider;
   description: string;
+  display_name?: string;
+  category?: string;
+  icon?: string;
+  tags?: string;
   variables: string;
   status: number;
+  parse_warnings?: ModuleParseWarning[];
   created_at: string;
   updated_at: string;
 }
 
+// ModuleParseWarning names a module file left out of the last sync because it could not be parsed
+export interface ModuleParseWarning {
+  file: string;
+  message: string;
+}
+
 export interface CreateTfModuleReq {
   name: string;
   source: string;
@@ -486,14 +624,29 @@ export interface TfModuleListResponse extends PaginatedResponse<TerraformModule>
 export type GitRepoType = 'modules' | 'storage';
 export type GitAuthType = 'none' | 'token' | 'password' | 'ssh_key';
 
+// ModuleVariable is a variable declared by a Terraform module
+export interface ModuleVariable {
+  name: string;
+  type?: string;        // Type constraint as written, such as map(string)
+  description?: string;
+  required: boolean;
+  sensitive?: boolean;
+  default?: unknown;    // Set when the default is written as a literal
+}
+
 // GitModule represents a Terraform module discovered from a git repository
 export interface GitModule {
   name: string;
   path: string;
   description?: string;
   source: string;
-  variables?: string[];
+  variables?: ModuleVariable[];
   outputs?: string[];
+  parse_warnings?: ModuleParseWarning[]; // Files whose variables and outputs are missing
+  display_name?: string;
+  category?: string;
+  icon?: string;
+  tags?: string[];
 }
 
 export interface GitModuleListResponse {
@@ -514,6 +667,7 @@ export interface GitRepository {
   description: string;
   status: number;
   is_default: boolean;
+  environment: Environment | '';
   last_sync_at: string | null;
   created_at: string;
   updated_at: string;
@@ -531,6 +685,8 @@ export interface CreateGitRepoReq {
   base_path?: string;
   description?: string;
   is_default?: boolean;
+  environment?: Environment | '';
+  webhook_secret?: string; // Signs push webhooks to /git/webhook/:repo_id
 }
 
 export interface UpdateGitRepoReq {
@@ -545,6 +701,8 @@ export interface UpdateGitRepoReq {
   description?: string;
   status?: number;
   is_default?: boolean;
+  environment?: Environment | '';
+  webhook_secret?: string; // Empty string disables the webhook
 }
 
 export interface TestConnectionReq {
@@ -574,6 +732,7 @@ export interface NodeConfig {
   module_repo_id: string | null;
   module_repo?: GitRepository;
   terragrunt_config: string;
+  extra_files?: Record<string, string>; // committed beside terragrunt.hcl, keyed by path in the node directory
   terraform_vars: string;
   status: NodeConfigStatus;
   commit_sha: string;
@@ -583,6 +742,7 @@ export interface NodeConfig {
   error_message: string;
   provisioned_at: string | null;
   destroyed_at: string | null;
+  resource_addresses: string[] | null;
   created_at: string;
   updated_at: string;
 }
@@ -591,6 +751,101 @@ export interface NodeConfigListResponse extends PaginatedResponse<NodeConfig> {
   node_configs: NodeConfig[];
 }
 
+export interface RetryFailedNodeConfigsReq {
+  storage_repo_id?: string;
+  provider?: string;
+  failed_for?: string; // Go duration, e.g. "30m"
+  limit?: number;
+}
+
+export interface BulkDeleteResult {
+  deleted: string[];
+  errors?: Record<string, string>;
+}
+
+export interface ImportInstance {
+  id: string;
+  name: string;
+  ip_address?: string;
+  hostname?: string;
+  spec?: Record<string, unknown>;
+}
+
+export interface ImportResourcesRequest {
+  provider: string;
+  zone_id: string;
+  environment: string;
+  type?: string;
+  instances: ImportInstance[];
+  terraform_import?: boolean;
+  credential_id?: string;
+}
+
+export interface ImportedResource {
+  resource: Resource;
+  allocation?: IPAllocation;
+  warnings?: string[];
+}
+
+export interface ImportResourcesResult {
+  imported: ImportedResource[];
+  errors?: Record<string, string>; // instance ID to the reason it was not imported
+}
+
+export interface NodeConfigRetrySummary {
+  matched: number;
+  retried: string[];
+  skipped: string[];
+  errors?: Record<string, string>;
+}
+
+export interface NodeConfigDiff {
+  config_id: string;
+  path: string;
+  from_sha: string; // pending commit
+  to_sha: string; // deployed commit
+  diff: string; // unified diff, empty when unchanged
+}
+
+// A short-lived signed URL that downloads a node's terraform files and plan as a zip
+export interface NodeConfigArtifactURL {
+  url: string;
+  expires_at: string;
+}
+
+// A commit that changed a node's config directory
+export interface NodeConfigCommit {
+  sha: string;
+  author: string;
+  author_email: string;
+  date: string;
+  message: string;
+}
+
+export interface NodeConfigHistoryResponse extends PaginatedResponse<NodeConfigCommit> {
+  commits: NodeConfigCommit[];
+}
+
+export interface PreviewNodeConfigReq {
+  title: string;
+  type: 'vm' | 'container' | 'bare_metal';
+  environment: 'dev' | 'test' | 'staging' | 'prod';
+  provider: string;
+  tf_module_id?: string;
+  module_source?: string; // inline module instead of tf_module_id
+  module_version?: string;
+  spec?: string;
+  var_overrides?: Record<string, string>;
+  dependencies?: NodeDependency[];
+}
+
+export interface NodeConfigPreview {
+  name: string;
+  path: string;
+  storage_repo_id?: string; // absent when no default storage repo is configured
+  terragrunt_config: string;
+}
+
 // API error type
 export interface ApiError {
   error: string;
@@ -644,8 +899,11 @@ export interface IPPool {
   zone_id: string;
   zone?: Zone;
   network_type: string;
+  bridge: string;
   description: string;
   status: number;
+  is_default: boolean;
+  verify_before_allocate: boolean; // Probe each address before handing it out
   created_at: string;
   updated_at: string;
 }
@@ -660,7 +918,10 @@ export interface CreateIPPoolReq {
   end_ip: string;
   zone_id: string;
   network_type?: string;
+  bridge?: string;
   description?: string;
+  is_default?: boolean;
+  verify_before_allocate?: boolean;
 }
 
 export interface UpdateIPPoolReq {
@@ -668,8 +929,11 @@ export interface UpdateIPPoolReq {
   gateway?: string;
   dns?: string;
   vlan_tag?: number;
+  bridge?: string;
   description?: string;
   status?: number;
+  is_default?: boolean;
+  verify_before_allocate?: boolean;
 }
 
 export interface IPPoolListResponse extends PaginatedResponse<IPPool> {
@@ -703,6 +967,16 @@ export interface IPAllocationListResponse extends PaginatedResponse<IPAllocation
   allocations: IPAllocation[];
 }
 
+export interface ReserveIPRangeReq {
+  start_ip: string;
+  end_ip: string;
+  note?: string;
+}
+
+export interface IPReservationListResponse extends PaginatedResponse<IPAllocation> {
+  reservations: IPAllocation[];
+}
+
 // VM Template types
 export interface VMTemplate {
   id: string;
@@ -759,3 +1033,9 @@ export interface UpdateVMTemplateReq {
 export interface VMTemplateListResponse extends PaginatedResponse<VMTemplate> {
   templates: VMTemplate[];
 }
+
+// Maintenance mode
+export interface MaintenanceStatus {
+  enabled: boolean;
+  message: string; // returned as the error of refused requests
+}